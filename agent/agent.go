@@ -58,6 +58,31 @@ const (
 	StorageAddr      = "STORAGE_ADDR"
 	AgentServiceName = "AGENT_SERVICE_NAME"
 	MongoOplogSize   = "MONGO_OPLOG_SIZE"
+
+	// MongoJournal may be set to "false" to disable journaling
+	// in the state server's mongod. MongoVersion holds the version
+	// of mongod that last served the state server's database.
+	MongoJournal = "MONGO_JOURNAL"
+	MongoVersion = "MONGO_VERSION"
+
+	// MongoCacheSize holds the size, in megabytes, of the state
+	// server's mongod storage engine cache. MongoDBDir holds the
+	// directory of the state server's database, if it is not kept
+	// in the agent's data directory.
+	MongoCacheSize = "MONGO_CACHE_SIZE"
+	MongoDBDir     = "MONGO_DB_DIR"
+
+	// WatcherPeriod, WatcherBatchSize and WatcherCoalesceWindow
+	// tune how state server agents poll the transaction log for
+	// changes. The period and coalescing window are durations such
+	// as "2s"; the batch size is a count of log entries.
+	WatcherPeriod         = "WATCHER_PERIOD"
+	WatcherBatchSize      = "WATCHER_BATCH_SIZE"
+	WatcherCoalesceWindow = "WATCHER_COALESCE_WINDOW"
+
+	// DebugLogWindow holds how long, as a duration such as "30m",
+	// an agent logs at DEBUG level after receiving SIGUSR1.
+	DebugLogWindow = "DEBUG_LOG_WINDOW"
 )
 
 // The Config interface is the sole way that the agent gets access to the
@@ -93,6 +118,11 @@ type Config interface {
 	// Dir returns the agent's directory.
 	Dir() string
 
+	// Environment returns the tag of the environment that the agent
+	// belongs to. It is empty for agents configured by versions of
+	// juju that did not record the environment.
+	Environment() names.EnvironTag
+
 	// Nonce returns the nonce saved when the machine was provisioned
 	// TODO: make this one of the key/value pairs.
 	Nonce() string
@@ -101,13 +131,19 @@ type Config interface {
 	// API server's certificate.
 	CACert() string
 
-	// APIAddresses returns the addresses needed to connect to the api server
+	// APIAddresses returns the addresses needed to connect to the api server,
+	// in order of preference.
 	APIAddresses() ([]string, error)
 
-	// WriteCommands returns shell commands to write the agent configuration.
-	// It returns an error if the configuration does not have all the right
-	// elements.
-	WriteCommands() ([]string, error)
+	// APIAddressFailures returns the number of consecutive failures
+	// to connect to the given API address.
+	APIAddressFailures(addr string) int
+
+	// WriteCommands returns shell commands to write the agent configuration
+	// on a machine running the given series; on Windows these are PowerShell
+	// commands. It returns an error if the configuration does not have all
+	// the right elements.
+	WriteCommands(series string) ([]string, error)
 
 	// StateServingInfo returns the details needed to run
 	// a state server and reports whether those details
@@ -118,7 +154,9 @@ type Config interface {
 	APIInfo() *api.Info
 
 	// MongoInfo returns details for connecting to the state server's mongo
-	// database and reports whether those details are available
+	// database and reports whether those details are available. A machine
+	// that runs only an API server connects to the state server addresses;
+	// any other state server connects to its local mongo.
 	MongoInfo() (*authentication.MongoInfo, bool)
 
 	// OldPassword returns the fallback password when connecting to the
@@ -161,8 +199,23 @@ type ConfigSetterOnly interface {
 	SetUpgradedToVersion(newVersion version.Number)
 
 	// SetAPIHostPorts sets the API host/port addresses to connect to.
+	// Addresses on the local machine are preferred, followed by those
+	// on the same subnet.
 	SetAPIHostPorts(servers [][]network.HostPort)
 
+	// RecordAPIAddressFailure records that connecting to the given API
+	// address failed. An address that fails repeatedly is tried only
+	// once all others have been.
+	RecordAPIAddressFailure(addr string)
+
+	// RecordAPIAddressSuccess records that connecting to the given API
+	// address succeeded, clearing any failures recorded for it.
+	RecordAPIAddressSuccess(addr string)
+
+	// SetEnvironment sets the tag of the environment that the agent
+	// belongs to.
+	SetEnvironment(tag names.EnvironTag)
+
 	// Migrate takes an existing agent config and applies the given
 	// parameters to change it.
 	//
@@ -233,6 +286,7 @@ type configInternal struct {
 	dataDir           string
 	logDir            string
 	tag               names.Tag
+	environment       names.EnvironTag
 	nonce             string
 	jobs              []params.MachineJob
 	upgradedToVersion version.Number
@@ -243,6 +297,10 @@ type configInternal struct {
 	servingInfo       *params.StateServingInfo
 	values            map[string]string
 	preferIPv6        bool
+
+	// apiAddressFailures holds the number of consecutive
+	// failures to connect to each API address.
+	apiAddressFailures map[string]int
 }
 
 type AgentConfigParams struct {
@@ -251,6 +309,7 @@ type AgentConfigParams struct {
 	Jobs              []params.MachineJob
 	UpgradedToVersion version.Number
 	Tag               names.Tag
+	Environment       names.EnvironTag
 	Password          string
 	Nonce             string
 	StateAddresses    []string
@@ -296,6 +355,7 @@ func NewAgentConfig(configParams AgentConfigParams) (ConfigSetterWriter, error)
 		jobs:              configParams.Jobs,
 		upgradedToVersion: configParams.UpgradedToVersion,
 		tag:               configParams.Tag,
+		environment:       configParams.Environment,
 		nonce:             configParams.Nonce,
 		caCert:            configParams.CACert,
 		oldPassword:       configParams.Password,
@@ -377,8 +437,9 @@ func ReadConfig(configFilePath string) (ConfigSetterWriter, error) {
 	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("cannot read format file: %v", err)
 	}
+	legacyFormatExists := err == nil
 	formatData := string(formatBytes)
-	if err == nil {
+	if legacyFormatExists && !bytes.HasPrefix(configData, []byte(formatPrefix)) {
 		// It exists, so unmarshal with a legacy formatter.
 		// Drop the format prefix to leave the version only.
 		if !strings.HasPrefix(formatData, legacyFormatPrefix) {
@@ -390,7 +451,8 @@ func ReadConfig(configFilePath string) (ConfigSetterWriter, error) {
 		}
 		config, err = format.unmarshal(configData)
 	} else {
-		// Does not exist, just parse the data.
+		// Either it does not exist, or a previous migration was
+		// interrupted before removing it; just parse the data.
 		format, config, err = parseConfigData(configData)
 	}
 	if err != nil {
@@ -398,13 +460,23 @@ func ReadConfig(configFilePath string) (ConfigSetterWriter, error) {
 	}
 	logger.Debugf("read agent config, format %q", format.version())
 	config.configFilePath = configFilePath
+	inClear, err := config.decryptSecrets()
+	if err != nil {
+		return nil, err
+	}
 	if format != currentFormat {
-		// Migrate from a legacy format to the new one.
-		err := config.Write()
-		if err != nil {
-			return nil, fmt.Errorf("cannot migrate %s agent config to %s: %v", format.version(), currentFormat.version(), err)
+		if err := migrateConfig(config, format, configData); err != nil {
+			return nil, err
 		}
-		logger.Debugf("migrated agent config from %s to %s", format.version(), currentFormat.version())
+	} else if inClear {
+		// Encrypt the secrets written in the clear when the
+		// machine was provisioned.
+		if err := config.Write(); err != nil {
+			return nil, fmt.Errorf("cannot encrypt agent config: %v", err)
+		}
+		logger.Debugf("encrypted agent config secrets")
+	}
+	if legacyFormatExists {
 		err = os.Remove(legacyFormatPath)
 		if err != nil && !os.IsNotExist(err) {
 			return nil, fmt.Errorf("cannot remove legacy format file %q: %v", legacyFormatPath, err)
@@ -424,6 +496,12 @@ func (c0 *configInternal) Clone() Config {
 	for key, val := range c0.values {
 		c1.values[key] = val
 	}
+	if c0.apiAddressFailures != nil {
+		c1.apiAddressFailures = make(map[string]int, len(c0.apiAddressFailures))
+		for addr, n := range c0.apiAddressFailures {
+			c1.apiAddressFailures[addr] = n
+		}
+	}
 	return &c1
 }
 
@@ -469,7 +547,45 @@ func (c *configInternal) SetAPIHostPorts(servers [][]network.HostPort) {
 			addrs = append(addrs, addr)
 		}
 	}
+	sortByLocality(addrs)
 	c.apiDetails.addresses = addrs
+	// Forget the failures of addresses that are no longer in use.
+	for addr := range c.apiAddressFailures {
+		if !containsString(addrs, addr) {
+			delete(c.apiAddressFailures, addr)
+		}
+	}
+}
+
+func (c *configInternal) RecordAPIAddressFailure(addr string) {
+	if c.apiDetails == nil || !containsString(c.apiDetails.addresses, addr) {
+		return
+	}
+	if c.apiAddressFailures == nil {
+		c.apiAddressFailures = make(map[string]int)
+	}
+	c.apiAddressFailures[addr]++
+}
+
+func (c *configInternal) APIAddressFailures(addr string) int {
+	return c.apiAddressFailures[addr]
+}
+
+func (c *configInternal) RecordAPIAddressSuccess(addr string) {
+	delete(c.apiAddressFailures, addr)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *configInternal) SetEnvironment(tag names.EnvironTag) {
+	c.environment = tag
 }
 
 func (c *configInternal) SetValue(key, value string) {
@@ -493,8 +609,39 @@ func (c *configInternal) SetPassword(newPassword string) {
 	}
 }
 
+// migrateConfig writes config, which was read from data in an older
+// format, in the current format. The original data is saved first,
+// alongside the config file, so that it can be recovered by hand if
+// the migration loses anything.
+func migrateConfig(config *configInternal, format formatter, data []byte) error {
+	backupPath := configBackupPath(config.configFilePath, format.version())
+	if err := utils.AtomicWriteFile(backupPath, data, 0600); err != nil {
+		return fmt.Errorf("cannot back up %s agent config: %v", format.version(), err)
+	}
+	if err := config.Write(); err != nil {
+		return fmt.Errorf("cannot migrate %s agent config to %s: %v", format.version(), currentFormat.version(), err)
+	}
+	logger.Infof("migrated agent config from %s to %s, saving the original in %q", format.version(), currentFormat.version(), backupPath)
+	return nil
+}
+
+// configBackupPath returns the path in which the agent config at
+// configFilePath is saved before being migrated from the given format.
+func configBackupPath(configFilePath, formatVersion string) string {
+	return configFilePath + "." + formatVersion + ".bak"
+}
+
 func (c *configInternal) Write() error {
-	data, err := c.fileContents()
+	key, err := readSecretKey()
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if key != nil {
+		data, err = c.encryptedFileContents(key)
+	} else {
+		data, err = c.fileContents()
+	}
 	if err != nil {
 		return err
 	}
@@ -565,7 +712,7 @@ func (c *configInternal) APIAddresses() ([]string, error) {
 	if c.apiDetails == nil {
 		return []string{}, errors.New("No apidetails in config")
 	}
-	return append([]string{}, c.apiDetails.addresses...), nil
+	return demoteFailing(c.apiDetails.addresses, c.apiAddressFailures), nil
 }
 
 func (c *configInternal) OldPassword() string {
@@ -576,6 +723,10 @@ func (c *configInternal) Tag() names.Tag {
 	return c.tag
 }
 
+func (c *configInternal) Environment() names.EnvironTag {
+	return c.environment
+}
+
 func (c *configInternal) Dir() string {
 	return Dir(c.dataDir, c.tag)
 }
@@ -622,11 +773,20 @@ func (c *configInternal) fileContents() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (c *configInternal) WriteCommands() ([]string, error) {
+func (c *configInternal) WriteCommands(series string) ([]string, error) {
 	data, err := c.fileContents()
 	if err != nil {
 		return nil, err
 	}
+	targetOS, err := version.GetOSFromSeries(series)
+	if err != nil {
+		return nil, err
+	}
+	if targetOS == version.Windows {
+		commands := []string{"mkdir -Force " + psQuote(c.Dir())}
+		commands = append(commands, winWriteFileCommands(c.File(agentConfigFilename), data)...)
+		return commands, nil
+	}
 	commands := []string{"mkdir -p " + utils.ShQuote(c.Dir())}
 	commands = append(commands, writeFileCommands(c.File(agentConfigFilename), data, 0600)...)
 	return commands, nil
@@ -634,31 +794,33 @@ func (c *configInternal) WriteCommands() ([]string, error) {
 
 func (c *configInternal) APIInfo() *api.Info {
 	servingInfo, isStateServer := c.StateServingInfo()
-	addrs := c.apiDetails.addresses
+	addrs := demoteFailing(c.apiDetails.addresses, c.apiAddressFailures)
 	if isStateServer {
 		port := servingInfo.APIPort
 		localAPIAddr := net.JoinHostPort("localhost", strconv.Itoa(port))
 		if c.preferIPv6 {
 			localAPIAddr = net.JoinHostPort("::1", strconv.Itoa(port))
 		}
-		addrInAddrs := false
-		for _, addr := range addrs {
-			if addr == localAPIAddr {
-				addrInAddrs = true
-				break
-			}
-		}
-		if !addrInAddrs {
-			addrs = append(addrs, localAPIAddr)
+		if !containsString(addrs, localAPIAddr) {
+			// The API server on the local machine is
+			// preferred over all others.
+			addrs = append([]string{localAPIAddr}, addrs...)
 		}
 	}
-	return &api.Info{
+	info := &api.Info{
 		Addrs:    addrs,
 		Password: c.apiDetails.password,
 		CACert:   c.caCert,
 		Tag:      c.tag,
 		Nonce:    c.nonce,
 	}
+	if c.environment.Id() != "" {
+		// Connecting to the environment's own API endpoint means
+		// the server refuses the connection if the agent has been
+		// pointed at a different environment.
+		info.EnvironTag = c.environment
+	}
+	return info
 }
 
 func (c *configInternal) MongoInfo() (info *authentication.MongoInfo, ok bool) {
@@ -666,16 +828,34 @@ func (c *configInternal) MongoInfo() (info *authentication.MongoInfo, ok bool) {
 	if !ok {
 		return nil, false
 	}
-	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(ssi.StatePort))
+	addrs := []string{net.JoinHostPort("127.0.0.1", strconv.Itoa(ssi.StatePort))}
 	if c.preferIPv6 {
-		addr = net.JoinHostPort("::1", strconv.Itoa(ssi.StatePort))
+		addrs = []string{net.JoinHostPort("::1", strconv.Itoa(ssi.StatePort))}
+	}
+	if c.runsAPIServerOnly() && c.stateDetails != nil && len(c.stateDetails.addresses) > 0 {
+		addrs = append([]string(nil), c.stateDetails.addresses...)
 	}
 	return &authentication.MongoInfo{
 		Info: mongo.Info{
-			Addrs:  []string{addr},
+			Addrs:  addrs,
 			CACert: c.caCert,
 		},
 		Password: c.stateDetails.password,
 		Tag:      c.tag,
 	}, true
 }
+
+// runsAPIServerOnly reports whether the agent runs an API server
+// without a local mongo.
+func (c *configInternal) runsAPIServerOnly() bool {
+	apiOnly := false
+	for _, job := range c.jobs {
+		switch job {
+		case params.JobManageEnviron:
+			return false
+		case params.JobManageAPI:
+			apiOnly = true
+		}
+	}
+	return apiOnly
+}
@@ -433,6 +433,20 @@ func (*suite) TestAttributes(c *gc.C) {
 	c.Assert(conf.UpgradedToVersion(), jc.DeepEquals, version.Current.Number)
 }
 
+func (*suite) TestEnvironment(c *gc.C) {
+	conf, err := agent.NewAgentConfig(attributeParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.Environment(), gc.Equals, names.EnvironTag{})
+	c.Assert(conf.APIInfo().EnvironTag, gc.IsNil)
+
+	attrParams := attributeParams
+	attrParams.Environment = names.NewEnvironTag("dcfbdb4a-bca2-49ad-aa7c-f011424e0fe4")
+	conf, err = agent.NewAgentConfig(attrParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.Environment(), gc.Equals, attrParams.Environment)
+	c.Assert(conf.APIInfo().EnvironTag, gc.Equals, attrParams.Environment)
+}
+
 func (*suite) TestStateServingInfo(c *gc.C) {
 	servingInfo := params.StateServingInfo{
 		Cert:           "old cert",
@@ -482,6 +496,79 @@ func (s *suite) TestAPIAddressesCannotWriteBack(c *gc.C) {
 	c.Assert(newValue, jc.DeepEquals, []string{"localhost:1235"})
 }
 
+func (s *suite) TestRecordAPIAddressFailureDemotesAddress(c *gc.C) {
+	attrParams := attributeParams
+	attrParams.APIAddresses = []string{"10.0.0.1:1235", "10.0.0.2:1235", "10.0.0.3:1235"}
+	conf, err := agent.NewAgentConfig(attrParams)
+	c.Assert(err, gc.IsNil)
+
+	// A couple of failures are not enough to demote an address.
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	value, err := conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, attrParams.APIAddresses)
+
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	expected := []string{"10.0.0.2:1235", "10.0.0.3:1235", "10.0.0.1:1235"}
+	value, err = conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, expected)
+	c.Assert(conf.APIInfo().Addrs, jc.DeepEquals, expected)
+
+	// A success restores the address to its place.
+	conf.RecordAPIAddressSuccess("10.0.0.1:1235")
+	value, err = conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, attrParams.APIAddresses)
+}
+
+func (s *suite) TestRecordAPIAddressFailureIgnoresUnknownAddress(c *gc.C) {
+	conf, err := agent.NewAgentConfig(attributeParams)
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 5; i++ {
+		conf.RecordAPIAddressFailure("10.0.0.9:1235")
+	}
+	clone := conf.Clone()
+	conf.RecordAPIAddressSuccess("10.0.0.9:1235")
+	c.Assert(clone, jc.DeepEquals, conf)
+}
+
+func (*suite) TestAPIAddressFailuresPersist(c *gc.C) {
+	testParams := attributeParams
+	testParams.DataDir = c.MkDir()
+	testParams.LogDir = c.MkDir()
+	testParams.APIAddresses = []string{"10.0.0.1:1235", "10.0.0.2:1235"}
+	conf, err := agent.NewAgentConfig(testParams)
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 3; i++ {
+		conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	}
+
+	c.Assert(conf.Write(), gc.IsNil)
+	reread, err := agent.ReadConfig(agent.ConfigPath(conf.DataDir(), conf.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(reread, jc.DeepEquals, conf)
+	value, err := reread.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, []string{"10.0.0.2:1235", "10.0.0.1:1235"})
+}
+
+func (s *suite) TestCloneCopiesAPIAddressFailures(c *gc.C) {
+	attrParams := attributeParams
+	attrParams.APIAddresses = []string{"10.0.0.1:1235", "10.0.0.2:1235"}
+	conf, err := agent.NewAgentConfig(attrParams)
+	c.Assert(err, gc.IsNil)
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	clone := conf.Clone()
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+	conf.RecordAPIAddressFailure("10.0.0.1:1235")
+
+	value, err := clone.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, attrParams.APIAddresses)
+}
+
 func (*suite) TestWriteAndRead(c *gc.C) {
 	testParams := attributeParams
 	testParams.DataDir = c.MkDir()
@@ -570,6 +657,25 @@ func (*suite) TestMongoInfoHonorsPreferIPv6(c *gc.C) {
 	c.Check(mongoInfo.Info.Addrs, jc.DeepEquals, []string{"127.0.0.1:69"})
 }
 
+func (*suite) TestMongoInfoForAPIServerOnly(c *gc.C) {
+	attrParams := attributeParams
+	attrParams.Jobs = []params.MachineJob{params.JobManageAPI}
+	attrParams.StateAddresses = []string{"10.0.0.1:69", "10.0.0.2:69"}
+	servingInfo := params.StateServingInfo{
+		Cert:           "old cert",
+		PrivateKey:     "old key",
+		StatePort:      69,
+		APIPort:        1492,
+		SharedSecret:   "shared",
+		SystemIdentity: "identity",
+	}
+	conf, err := agent.NewStateMachineConfig(attrParams, servingInfo)
+	c.Assert(err, gc.IsNil)
+	mongoInfo, ok := conf.MongoInfo()
+	c.Assert(ok, jc.IsTrue)
+	c.Check(mongoInfo.Info.Addrs, jc.DeepEquals, attrParams.StateAddresses)
+}
+
 func (*suite) TestAPIInfoDoesntAddLocalhostWhenNoServingInfoPreferIPv6Off(c *gc.C) {
 	attrParams := attributeParams
 	attrParams.PreferIPv6 = false
@@ -0,0 +1,112 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"net"
+	"sort"
+)
+
+// apiAddressDemotionThreshold is the number of consecutive failures
+// after which an API address is tried only once all others have been.
+const apiAddressDemotionThreshold = 3
+
+// Address localities, in order of preference.
+const (
+	localityMachine = iota
+	localitySubnet
+	localityRemote
+)
+
+// interfaceAddrs returns the addresses of the local machine's network
+// interfaces. It is a variable so that it can be replaced in tests.
+var interfaceAddrs = net.InterfaceAddrs
+
+// localNetworks returns the networks of the local machine's interfaces.
+func localNetworks() []*net.IPNet {
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		logger.Warningf("cannot get local interface addresses: %v", err)
+		return nil
+	}
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// addressLocality returns how close the host of the given host:port
+// address is to the local machine, given the local machine's networks.
+func addressLocality(hostPort string, nets []*net.IPNet) int {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return localityRemote
+	}
+	if host == "localhost" {
+		return localityMachine
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return localityRemote
+	}
+	if ip.IsLoopback() {
+		return localityMachine
+	}
+	locality := localityRemote
+	for _, ipNet := range nets {
+		if ipNet.IP.Equal(ip) {
+			return localityMachine
+		}
+		if ipNet.Contains(ip) {
+			locality = localitySubnet
+		}
+	}
+	return locality
+}
+
+// byLocality sorts API addresses so that those on the local machine
+// come first, followed by those on the same subnet.
+type byLocality struct {
+	addrs      []string
+	localities []int
+}
+
+func (b byLocality) Len() int { return len(b.addrs) }
+
+func (b byLocality) Less(i, j int) bool { return b.localities[i] < b.localities[j] }
+
+func (b byLocality) Swap(i, j int) {
+	b.addrs[i], b.addrs[j] = b.addrs[j], b.addrs[i]
+	b.localities[i], b.localities[j] = b.localities[j], b.localities[i]
+}
+
+// sortByLocality sorts addrs in order of preference by locality,
+// keeping the original order of addresses of equal locality.
+func sortByLocality(addrs []string) {
+	nets := localNetworks()
+	b := byLocality{addrs, make([]int, len(addrs))}
+	for i, addr := range addrs {
+		b.localities[i] = addressLocality(addr, nets)
+	}
+	sort.Stable(b)
+}
+
+// demoteFailing returns a copy of addrs in which the addresses that
+// have failed at least apiAddressDemotionThreshold times in a row are
+// moved to the end.
+func demoteFailing(addrs []string, failures map[string]int) []string {
+	result := make([]string, 0, len(addrs))
+	var demoted []string
+	for _, addr := range addrs {
+		if failures[addr] >= apiAddressDemotionThreshold {
+			demoted = append(demoted, addr)
+		} else {
+			result = append(result, addr)
+		}
+	}
+	return append(result, demoted...)
+}
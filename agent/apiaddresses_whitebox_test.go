@@ -0,0 +1,105 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"net"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/testing"
+)
+
+type apiAddressesSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&apiAddressesSuite{})
+
+func (s *apiAddressesSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.PatchValue(&interfaceAddrs, func() ([]net.Addr, error) {
+		_, ipNet, err := net.ParseCIDR("10.0.3.0/24")
+		c.Assert(err, gc.IsNil)
+		ipNet.IP = net.ParseIP("10.0.3.5")
+		return []net.Addr{ipNet}, nil
+	})
+}
+
+var addressLocalityTests = []struct {
+	addr     string
+	locality int
+}{
+	{"localhost:17070", localityMachine},
+	{"127.0.0.1:17070", localityMachine},
+	{"[::1]:17070", localityMachine},
+	{"10.0.3.5:17070", localityMachine},
+	{"10.0.3.42:17070", localitySubnet},
+	{"10.0.4.42:17070", localityRemote},
+	{"54.1.2.3:17070", localityRemote},
+	{"example.com:17070", localityRemote},
+	{"no-port", localityRemote},
+}
+
+func (s *apiAddressesSuite) TestAddressLocality(c *gc.C) {
+	nets := localNetworks()
+	for i, t := range addressLocalityTests {
+		c.Logf("test %d: %s", i, t.addr)
+		c.Check(addressLocality(t.addr, nets), gc.Equals, t.locality)
+	}
+}
+
+func (s *apiAddressesSuite) TestSortByLocality(c *gc.C) {
+	addrs := []string{
+		"54.1.2.3:17070",
+		"10.0.3.42:17070",
+		"54.1.2.4:17070",
+		"10.0.3.5:17070",
+		"10.0.3.43:17070",
+	}
+	sortByLocality(addrs)
+	c.Assert(addrs, jc.DeepEquals, []string{
+		"10.0.3.5:17070",
+		"10.0.3.42:17070",
+		"10.0.3.43:17070",
+		"54.1.2.3:17070",
+		"54.1.2.4:17070",
+	})
+}
+
+func (s *apiAddressesSuite) TestDemoteFailing(c *gc.C) {
+	addrs := []string{"a:1", "b:1", "c:1", "d:1"}
+	failures := map[string]int{
+		"a:1": apiAddressDemotionThreshold,
+		"b:1": apiAddressDemotionThreshold - 1,
+		"c:1": apiAddressDemotionThreshold + 1,
+	}
+	c.Assert(demoteFailing(addrs, failures), jc.DeepEquals, []string{"b:1", "d:1", "a:1", "c:1"})
+	c.Assert(addrs, jc.DeepEquals, []string{"a:1", "b:1", "c:1", "d:1"})
+	c.Assert(demoteFailing(addrs, nil), jc.DeepEquals, addrs)
+}
+
+func (s *apiAddressesSuite) TestSetAPIHostPortsOrdersByLocality(c *gc.C) {
+	conf, err := NewAgentConfig(AgentConfigParams{
+		DataDir:        c.MkDir(),
+		Tag:            names.NewMachineTag("1"),
+		Password:       "sekrit",
+		Nonce:          "a nonce",
+		StateAddresses: []string{"localhost:1234"},
+		APIAddresses:   []string{"localhost:1235"},
+		CACert:         "ca cert",
+	})
+	c.Assert(err, gc.IsNil)
+	conf.SetAPIHostPorts([][]network.HostPort{
+		network.AddressesWithPort(network.NewAddresses("54.1.2.3"), 17070),
+		network.AddressesWithPort(network.NewAddresses("10.0.3.42"), 17070),
+		network.AddressesWithPort(network.NewAddresses("10.0.3.5"), 17070),
+	})
+	addrs, err := conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addrs, jc.DeepEquals, []string{"10.0.3.5:17070", "10.0.3.42:17070", "54.1.2.3:17070"})
+}
@@ -84,6 +84,37 @@ func (*format_1_16Suite) TestReadConfReadsLegacyFormatAndWritesNew(c *gc.C) {
 	data, err := ioutil.ReadFile(configPath)
 	c.Assert(err, gc.IsNil)
 	c.Assert(string(data), gc.Not(gc.Equals), agentConfig1_16Contents)
+	// And the old contents were saved.
+	data, err = ioutil.ReadFile(configBackupPath(configPath, "1.16"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, agentConfig1_16Contents)
+}
+
+func (*format_1_16Suite) TestReadConfCompletesInterruptedMigration(c *gc.C) {
+	dataDir := c.MkDir()
+	formatPath := filepath.Join(dataDir, legacyFormatFilename)
+	err := utils.AtomicWriteFile(formatPath, []byte(legacyFormatFileContents), 0600)
+	c.Assert(err, gc.IsNil)
+	configPath := filepath.Join(dataDir, agentConfigFilename)
+	err = utils.AtomicWriteFile(configPath, []byte(agentConfig1_16Contents), 0600)
+	c.Assert(err, gc.IsNil)
+	_, err = ReadConfig(configPath)
+	c.Assert(err, gc.IsNil)
+	migrated, err := ioutil.ReadFile(configPath)
+	c.Assert(err, gc.IsNil)
+
+	// Simulate a migration interrupted after the config was
+	// rewritten, but before the legacy format file was removed.
+	err = utils.AtomicWriteFile(formatPath, []byte(legacyFormatFileContents), 0600)
+	c.Assert(err, gc.IsNil)
+
+	config, err := ReadConfig(configPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(config.UpgradedToVersion(), jc.DeepEquals, version.MustParse("1.16.0"))
+	assertFileNotExist(c, formatPath)
+	data, err := ioutil.ReadFile(configPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(data, gc.DeepEquals, migrated)
 }
 
 const legacyFormatFileContents = "format 1.16"
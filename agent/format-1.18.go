@@ -27,6 +27,7 @@ var _ formatter = formatter_1_18{}
 // format_1_18Serialization holds information for a given agent.
 type format_1_18Serialization struct {
 	Tag               string
+	Environment       string `yaml:",omitempty"`
 	DataDir           string
 	LogDir            string
 	Nonce             string
@@ -37,8 +38,9 @@ type format_1_18Serialization struct {
 	StateAddresses []string `yaml:",omitempty"`
 	StatePassword  string   `yaml:",omitempty"`
 
-	APIAddresses []string `yaml:",omitempty"`
-	APIPassword  string   `yaml:",omitempty"`
+	APIAddresses       []string       `yaml:",omitempty"`
+	APIPassword        string         `yaml:",omitempty"`
+	APIAddressFailures map[string]int `yaml:",omitempty"`
 
 	OldPassword string
 	Values      map[string]string
@@ -78,8 +80,16 @@ func (formatter_1_18) unmarshal(data []byte) (*configInternal, error) {
 	if err != nil {
 		return nil, err
 	}
+	var environment names.EnvironTag
+	if format.Environment != "" {
+		environment, err = names.ParseEnvironTag(format.Environment)
+		if err != nil {
+			return nil, err
+		}
+	}
 	config := &configInternal{
 		tag:               tag,
+		environment:       environment,
 		dataDir:           format.DataDir,
 		logDir:            format.LogDir,
 		jobs:              format.Jobs,
@@ -89,6 +99,8 @@ func (formatter_1_18) unmarshal(data []byte) (*configInternal, error) {
 		oldPassword:       format.OldPassword,
 		values:            format.Values,
 		preferIPv6:        format.PreferIPv6,
+
+		apiAddressFailures: format.APIAddressFailures,
 	}
 	if config.logDir == "" {
 		config.logDir = DefaultLogDir
@@ -153,6 +165,9 @@ func (formatter_1_18) marshal(config *configInternal) ([]byte, error) {
 		Values:            config.values,
 		PreferIPv6:        config.preferIPv6,
 	}
+	if config.environment.Id() != "" {
+		format.Environment = config.environment.String()
+	}
 	if config.servingInfo != nil {
 		format.StateServerCert = config.servingInfo.Cert
 		format.StateServerKey = config.servingInfo.PrivateKey
@@ -168,6 +183,7 @@ func (formatter_1_18) marshal(config *configInternal) ([]byte, error) {
 	if config.apiDetails != nil {
 		format.APIAddresses = config.apiDetails.addresses
 		format.APIPassword = config.apiDetails.password
+		format.APIAddressFailures = config.apiAddressFailures
 	}
 	return goyaml.Marshal(format)
 }
@@ -34,6 +34,12 @@ import (
 // We don't need to create new formats for each release, the version
 // number is just a convenience for us to know which stable release
 // introduced that format.
+//
+// A config in an older format is migrated to the current format when
+// it is read by ReadConfig. The original file is saved first, as
+// agent.conf.<version>.bak, and the legacy format file is removed only
+// once the migrated config has been written, so an interrupted
+// migration is completed the next time the config is read.
 
 var formats = make(map[string]formatter)
 
@@ -73,6 +79,20 @@ func writeFileCommands(filename string, contents []byte, permission int) []strin
 	}
 }
 
+// winWriteFileCommands returns PowerShell commands that write the given
+// contents to filename. The file is protected by the permissions of
+// the agent's data directory rather than its own.
+func winWriteFileCommands(filename string, contents []byte) []string {
+	return []string{
+		fmt.Sprintf("Set-Content %s @'\n%s\n'@", psQuote(filename), contents),
+	}
+}
+
+// psQuote quotes s so that PowerShell interprets it literally.
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
 func getFormatter(version string) (formatter, error) {
 	version = strings.TrimSpace(version)
 	format, ok := formats[version]
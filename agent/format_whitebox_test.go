@@ -47,7 +47,7 @@ func newTestConfig(c *gc.C) *configInternal {
 
 func (*formatSuite) TestWriteCommands(c *gc.C) {
 	config := newTestConfig(c)
-	commands, err := config.WriteCommands()
+	commands, err := config.WriteCommands("quantal")
 	c.Assert(err, gc.IsNil)
 	c.Assert(commands, gc.HasLen, 3)
 	c.Assert(commands[0], gc.Matches, `mkdir -p '\S+/agents/machine-1'`)
@@ -55,6 +55,21 @@ func (*formatSuite) TestWriteCommands(c *gc.C) {
 	c.Assert(commands[2], gc.Matches, `printf '%s\\n' '(.|\n)*' > '\S+/agents/machine-1/agent.conf'`)
 }
 
+func (*formatSuite) TestWriteCommandsWindows(c *gc.C) {
+	config := newTestConfig(c)
+	commands, err := config.WriteCommands("win2012")
+	c.Assert(err, gc.IsNil)
+	c.Assert(commands, gc.HasLen, 2)
+	c.Assert(commands[0], gc.Matches, `mkdir -Force '\S+/agents/machine-1'`)
+	c.Assert(commands[1], gc.Matches, `Set-Content '\S+/agents/machine-1/agent.conf' @'\n# format 1.18\n(.|\n)*\n'@`)
+}
+
+func (*formatSuite) TestWriteCommandsUnknownSeries(c *gc.C) {
+	config := newTestConfig(c)
+	_, err := config.WriteCommands("nosuchseries")
+	c.Assert(err, gc.ErrorMatches, `invalid series "nosuchseries"`)
+}
+
 func (*formatSuite) TestWriteAgentConfig(c *gc.C) {
 	config := newTestConfig(c)
 	err := config.Write()
@@ -71,6 +86,18 @@ func (*formatSuite) TestRead(c *gc.C) {
 	assertWriteAndRead(c, config)
 }
 
+func (*formatSuite) TestReadWriteEnvironment(c *gc.C) {
+	params := agentParams
+	params.DataDir = c.MkDir()
+	params.Environment = names.NewEnvironTag("dcfbdb4a-bca2-49ad-aa7c-f011424e0fe4")
+	configInterface, err := NewAgentConfig(params)
+	c.Assert(err, gc.IsNil)
+	config, ok := configInterface.(*configInternal)
+	c.Assert(ok, jc.IsTrue)
+
+	assertWriteAndRead(c, config)
+}
+
 func (*formatSuite) TestReadWriteStateConfig(c *gc.C) {
 	servingInfo := params.StateServingInfo{
 		Cert:       "some special cert",
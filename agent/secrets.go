@@ -0,0 +1,259 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/version"
+)
+
+// Secrets in agent config files, such as passwords and the state
+// server's private key, are encrypted when the machine has a secret
+// key. The key is created on the machine itself when it is
+// provisioned, so it never leaves the machine; copies of agent config
+// files are then of no use elsewhere. The key is kept outside the data
+// directory, in a directory only the agent may read, so that the key
+// is not found alongside the configs it protects. Config files are
+// written with their secrets in the clear by cloud-init, and these are
+// encrypted the first time the agent reads its config.
+
+// secretKeyFilename is the name of the file holding the machine's
+// secret key.
+const secretKeyFilename = "secret.key"
+
+// secretKeyDirs holds the directory holding the machine's secret key
+// for each OS.
+var secretKeyDirs = map[version.OSType]string{
+	version.Ubuntu:  "/etc/juju",
+	version.Windows: "C:/ProgramData/Juju",
+}
+
+// secretKeyDir returns the directory holding the running machine's
+// secret key. It is a variable so that tests can change it.
+var secretKeyDir = func() string {
+	return secretKeyDirs[version.Current.OS]
+}
+
+// secretKeySize holds the number of random bytes in a secret key.
+const secretKeySize = 32
+
+// encryptedPrefix prefixes the encrypted secrets in a config file,
+// distinguishing them from secrets still in the clear.
+const encryptedPrefix = "encrypted:"
+
+// SecretKeyPath returns the path of the secret key, with which secrets
+// in agent configs are encrypted, on machines running the given series.
+func SecretKeyPath(series string) (string, error) {
+	targetOS, err := version.GetOSFromSeries(series)
+	if err != nil {
+		return "", err
+	}
+	dir, ok := secretKeyDirs[targetOS]
+	if !ok {
+		return "", fmt.Errorf("no secret key directory for series %q", series)
+	}
+	return path.Join(dir, secretKeyFilename), nil
+}
+
+// SecretKeyCommands returns commands that create the machine's secret
+// key, readable only by the agent, unless it already exists, for
+// machines running the given series. The commands are shell commands,
+// or PowerShell commands on Windows.
+func SecretKeyCommands(series string) ([]string, error) {
+	keyPath, err := SecretKeyPath(series)
+	if err != nil {
+		return nil, err
+	}
+	if version.MustOSFromSeries(series) == version.Windows {
+		// Access to the key's directory is restricted, before the
+		// key is written, to the jujud user the agent runs as.
+		dir := strings.Replace(path.Dir(keyPath), "/", `\`, -1)
+		keyPath = strings.Replace(keyPath, "/", `\`, -1)
+		return []string{
+			fmt.Sprintf("if (-not (Test-Path %s)) {", psQuote(keyPath)),
+			fmt.Sprintf("mkdir -Force %s", psQuote(dir)),
+			fmt.Sprintf(`icacls %s /inheritance:r /grant:r "jujud:(OI)(CI)(F)" "SYSTEM:(OI)(CI)(F)"`, psQuote(dir)),
+			fmt.Sprintf("$secretKey = New-Object byte[] %d", secretKeySize),
+			"[System.Security.Cryptography.RandomNumberGenerator]::Create().GetBytes($secretKey)",
+			fmt.Sprintf("[System.IO.File]::WriteAllBytes(%s, $secretKey)", psQuote(keyPath)),
+			"}",
+		}, nil
+	}
+	dir := utils.ShQuote(path.Dir(keyPath))
+	keyPath = utils.ShQuote(keyPath)
+	return []string{
+		fmt.Sprintf("mkdir -p -m 700 %s", dir),
+		fmt.Sprintf("test -e %s || (umask 077 && head -c %d /dev/urandom > %s)", keyPath, secretKeySize, keyPath),
+	}, nil
+}
+
+// readSecretKey returns the running machine's secret key, or nil if
+// it has none.
+func readSecretKey() ([]byte, error) {
+	keyPath := filepath.Join(secretKeyDir(), secretKeyFilename)
+	key, err := ioutil.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot read secret key: %v", err)
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("secret key %q is empty", keyPath)
+	}
+	return key, nil
+}
+
+// newSecretCipher returns the cipher with which secrets are encrypted
+// under the given machine secret key.
+func newSecretCipher(key []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret returns secret encrypted with aead. An empty secret is
+// left empty, so that it is still omitted from the config file.
+func encryptSecret(aead cipher.AEAD, secret string) (string, error) {
+	if secret == "" {
+		return "", nil
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret returns the secret held in value, decrypting it with
+// aead if it is encrypted.
+func decryptSecret(aead cipher.AEAD, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("encrypted secret too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// DecryptSecret returns the secret held in value, a secret from an
+// agent config file, decrypting it with the given machine secret key
+// if it is encrypted. The key may be nil if value is in the clear.
+func DecryptSecret(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if key == nil {
+		return "", fmt.Errorf("cannot decrypt secret without a secret key")
+	}
+	aead, err := newSecretCipher(key)
+	if err != nil {
+		return "", err
+	}
+	return decryptSecret(aead, value)
+}
+
+// secrets returns the secret fields of c.
+func (c *configInternal) secrets() []*string {
+	secrets := []*string{&c.oldPassword}
+	if c.stateDetails != nil {
+		secrets = append(secrets, &c.stateDetails.password)
+	}
+	if c.apiDetails != nil {
+		secrets = append(secrets, &c.apiDetails.password)
+	}
+	if c.servingInfo != nil {
+		secrets = append(secrets,
+			&c.servingInfo.PrivateKey,
+			&c.servingInfo.SharedSecret,
+			&c.servingInfo.SystemIdentity,
+		)
+	}
+	return secrets
+}
+
+// decryptSecrets decrypts the secrets of c, as read from its config
+// file, with the machine's secret key. It reports whether the machine
+// has a secret key but some secrets were in the clear, in which case
+// the config should be written again to encrypt them.
+func (c *configInternal) decryptSecrets() (inClear bool, err error) {
+	key, err := readSecretKey()
+	if err != nil {
+		return false, err
+	}
+	var aead cipher.AEAD
+	if key != nil {
+		if aead, err = newSecretCipher(key); err != nil {
+			return false, err
+		}
+	}
+	for _, secret := range c.secrets() {
+		if !strings.HasPrefix(*secret, encryptedPrefix) {
+			inClear = inClear || (key != nil && *secret != "")
+			continue
+		}
+		if aead == nil {
+			return false, fmt.Errorf("cannot decrypt agent config: no secret key in %q", secretKeyDir())
+		}
+		if *secret, err = decryptSecret(aead, *secret); err != nil {
+			return false, fmt.Errorf("cannot decrypt agent config: %v", err)
+		}
+	}
+	return inClear, nil
+}
+
+// encryptedFileContents returns the contents of c's config file, with
+// its secrets encrypted with the given machine secret key. The
+// secrets of c itself are left in the clear.
+func (c *configInternal) encryptedFileContents(key []byte) ([]byte, error) {
+	aead, err := newSecretCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := *c
+	if c.stateDetails != nil {
+		stateDetails := *c.stateDetails
+		encrypted.stateDetails = &stateDetails
+	}
+	if c.apiDetails != nil {
+		apiDetails := *c.apiDetails
+		encrypted.apiDetails = &apiDetails
+	}
+	if c.servingInfo != nil {
+		servingInfo := *c.servingInfo
+		encrypted.servingInfo = &servingInfo
+	}
+	for _, secret := range encrypted.secrets() {
+		if *secret, err = encryptSecret(aead, *secret); err != nil {
+			return nil, fmt.Errorf("cannot encrypt agent config: %v", err)
+		}
+	}
+	return encrypted.fileContents()
+}
@@ -0,0 +1,189 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
+)
+
+type secretsSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&secretsSuite{})
+
+func (s *secretsSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	keyDir := c.MkDir()
+	s.PatchValue(&secretKeyDir, func() string { return keyDir })
+}
+
+func testSecretKeyPath() string {
+	return filepath.Join(secretKeyDir(), secretKeyFilename)
+}
+
+func writeSecretKey(c *gc.C) []byte {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	err := ioutil.WriteFile(testSecretKeyPath(), key, 0600)
+	c.Assert(err, gc.IsNil)
+	return key
+}
+
+func newTestStateConfig(c *gc.C) *configInternal {
+	configParams := agentParams
+	configParams.DataDir = c.MkDir()
+	configParams.LogDir = c.MkDir()
+	config, err := NewStateMachineConfig(configParams, params.StateServingInfo{
+		Cert:           "some special cert",
+		PrivateKey:     "a special key",
+		SharedSecret:   "a shared secret",
+		SystemIdentity: "a system identity",
+		StatePort:      12345,
+		APIPort:        23456,
+	})
+	c.Assert(err, gc.IsNil)
+	config.SetPassword("new sekrit")
+	return config.(*configInternal)
+}
+
+func readConfigFile(c *gc.C, config *configInternal) string {
+	data, err := ioutil.ReadFile(ConfigPath(config.DataDir(), config.Tag()))
+	c.Assert(err, gc.IsNil)
+	return string(data)
+}
+
+func (*secretsSuite) TestSecretKeyPath(c *gc.C) {
+	keyPath, err := SecretKeyPath("precise")
+	c.Assert(err, gc.IsNil)
+	c.Assert(keyPath, gc.Equals, "/etc/juju/secret.key")
+	keyPath, err = SecretKeyPath("win2012")
+	c.Assert(err, gc.IsNil)
+	c.Assert(keyPath, gc.Equals, "C:/ProgramData/Juju/secret.key")
+	_, err = SecretKeyPath("bewildered")
+	c.Assert(err, gc.ErrorMatches, `invalid series "bewildered"`)
+}
+
+func (s *secretsSuite) TestSecretKeyCommands(c *gc.C) {
+	// Run the commands against the test key directory, which
+	// does not yet exist.
+	keyDir := filepath.Join(c.MkDir(), "juju")
+	s.PatchValue(&secretKeyDir, func() string { return keyDir })
+	s.PatchValue(&secretKeyDirs, map[version.OSType]string{version.Ubuntu: keyDir})
+	commands, err := SecretKeyCommands("precise")
+	c.Assert(err, gc.IsNil)
+	script := strings.Join(commands, "\n")
+	out, err := exec.Command("bash", "-c", script).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", out))
+	key, err := readSecretKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(key, gc.HasLen, secretKeySize)
+	info, err := os.Stat(keyDir)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode().Perm(), gc.Equals, os.FileMode(0700))
+
+	// An existing key is left alone.
+	out, err = exec.Command("bash", "-c", script).CombinedOutput()
+	c.Assert(err, gc.IsNil, gc.Commentf("%s", out))
+	again, err := readSecretKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.DeepEquals, key)
+}
+
+func (*secretsSuite) TestSecretKeyCommandsWindows(c *gc.C) {
+	commands, err := SecretKeyCommands("win2012")
+	c.Assert(err, gc.IsNil)
+	script := strings.Join(commands, "\n")
+	c.Assert(script, jc.Contains, `if (-not (Test-Path 'C:\ProgramData\Juju\secret.key')) {`)
+	c.Assert(script, jc.Contains, `icacls 'C:\ProgramData\Juju' /inheritance:r`)
+	c.Assert(script, gc.Not(jc.Contains), "umask")
+}
+
+func (*secretsSuite) TestWriteWithoutSecretKey(c *gc.C) {
+	config := newTestStateConfig(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	data := readConfigFile(c, config)
+	c.Assert(data, jc.Contains, "sekrit")
+	c.Assert(data, gc.Not(jc.Contains), encryptedPrefix)
+}
+
+func (*secretsSuite) TestWriteAndReadWithSecretKey(c *gc.C) {
+	config := newTestStateConfig(c)
+	writeSecretKey(c)
+	assertWriteAndRead(c, config)
+
+	data := readConfigFile(c, config)
+	for _, secret := range []string{"sekrit", "new sekrit", "a special key", "a shared secret", "a system identity"} {
+		c.Assert(data, gc.Not(jc.Contains), secret)
+	}
+	c.Assert(strings.Count(data, encryptedPrefix), gc.Equals, 6)
+	// Fields that are not secret are left in the clear.
+	c.Assert(data, jc.Contains, "some special cert")
+}
+
+func (*secretsSuite) TestReadEncryptsSecretsInClear(c *gc.C) {
+	config := newTestStateConfig(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	writeSecretKey(c)
+
+	readConfig, err := ReadConfig(ConfigPath(config.DataDir(), config.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(readConfig, jc.DeepEquals, config)
+	data := readConfigFile(c, config)
+	c.Assert(data, gc.Not(jc.Contains), "sekrit")
+	c.Assert(data, jc.Contains, encryptedPrefix)
+}
+
+func (*secretsSuite) TestReadWithoutSecretKey(c *gc.C) {
+	config := newTestStateConfig(c)
+	writeSecretKey(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	err = os.Remove(testSecretKeyPath())
+	c.Assert(err, gc.IsNil)
+
+	_, err = ReadConfig(ConfigPath(config.DataDir(), config.Tag()))
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt agent config: no secret key in .*")
+}
+
+func (*secretsSuite) TestReadWithWrongSecretKey(c *gc.C) {
+	config := newTestStateConfig(c)
+	writeSecretKey(c)
+	err := config.Write()
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(testSecretKeyPath(), []byte("another key"), 0600)
+	c.Assert(err, gc.IsNil)
+
+	_, err = ReadConfig(ConfigPath(config.DataDir(), config.Tag()))
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt agent config: .*")
+}
+
+func (*secretsSuite) TestDecryptSecret(c *gc.C) {
+	key := writeSecretKey(c)
+	aead, err := newSecretCipher(key)
+	c.Assert(err, gc.IsNil)
+	encrypted, err := encryptSecret(aead, "sekrit")
+	c.Assert(err, gc.IsNil)
+
+	secret, err := DecryptSecret(key, encrypted)
+	c.Assert(err, gc.IsNil)
+	c.Assert(secret, gc.Equals, "sekrit")
+	secret, err = DecryptSecret(nil, "sekrit")
+	c.Assert(err, gc.IsNil)
+	c.Assert(secret, gc.Equals, "sekrit")
+	_, err = DecryptSecret(nil, encrypted)
+	c.Assert(err, gc.ErrorMatches, "cannot decrypt secret without a secret key")
+}
@@ -89,7 +89,7 @@ func (s *DiskManagerSuite) assertToolsContents(c *gc.C, t *coretools.Tools, file
 	for _, f := range files {
 		wantNames = append(wantNames, f.Header.Name)
 	}
-	wantNames = append(wantNames, toolsFile)
+	wantNames = append(wantNames, toolsFile, checksumsFile)
 	dir := s.manager.(*agenttools.DiskManager).SharedToolsDir(t.Version)
 	assertDirNames(c, dir, wantNames)
 	expectedFileContents, err := json.Marshal(t)
@@ -42,6 +42,7 @@ func (t *ToolsSuite) TestPackageDependencies(c *gc.C) {
 }
 
 const toolsFile = "downloaded-tools.txt"
+const checksumsFile = "downloaded-tools.sha256"
 
 // gzyesses holds the result of running:
 // yes | head -17000 | gzip
@@ -148,6 +149,52 @@ func (t *ToolsSuite) TestUnpackToolsContents(c *gc.C) {
 	t.assertToolsContents(c, testTools, files)
 }
 
+func (t *ToolsSuite) TestVerifyTools(c *gc.C) {
+	files := []*testing.TarFile{
+		testing.NewTarFile("jujud", agenttools.DirPerm, "jujud contents"),
+	}
+	data, checksum := testing.TarGz(files...)
+	testTools := &coretest.Tools{
+		URL:     "http://foo/bar",
+		Version: version.MustParseBinary("1.2.3-quantal-amd64"),
+		Size:    int64(len(data)),
+		SHA256:  checksum,
+	}
+	err := agenttools.UnpackTools(t.dataDir, testTools, bytes.NewReader(data))
+	c.Assert(err, gc.IsNil)
+
+	gotTools, err := agenttools.VerifyTools(t.dataDir, testTools.Version)
+	c.Assert(err, gc.IsNil)
+	c.Assert(*gotTools, gc.Equals, *testTools)
+
+	// Tamper with the unpacked binary.
+	jujud := filepath.Join(agenttools.SharedToolsDir(t.dataDir, testTools.Version), "jujud")
+	err = os.Chmod(jujud, 0600)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(jujud, []byte("something else"), 0600)
+	c.Assert(err, gc.IsNil)
+	_, err = agenttools.VerifyTools(t.dataDir, testTools.Version)
+	c.Assert(err, gc.ErrorMatches, `sha256 mismatch for "jujud", expected [0-9a-f]+, got [0-9a-f]+`)
+}
+
+func (t *ToolsSuite) TestVerifyToolsNoChecksums(c *gc.C) {
+	testTools := &coretest.Tools{
+		URL:     "http://foo/bar",
+		Version: version.MustParseBinary("1.2.3-quantal-amd64"),
+	}
+	dir := agenttools.SharedToolsDir(t.dataDir, testTools.Version)
+	err := os.MkdirAll(dir, 0755)
+	c.Assert(err, gc.IsNil)
+	data, err := json.Marshal(testTools)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, toolsFile), data, 0644)
+	c.Assert(err, gc.IsNil)
+
+	gotTools, err := agenttools.VerifyTools(t.dataDir, testTools.Version)
+	c.Assert(err, gc.Equals, agenttools.ErrNoChecksums)
+	c.Assert(*gotTools, gc.Equals, *testTools)
+}
+
 func (t *ToolsSuite) TestReadToolsErrors(c *gc.C) {
 	vers := version.MustParseBinary("1.2.3-precise-amd64")
 	testTools, err := agenttools.ReadTools(t.dataDir, vers)
@@ -186,7 +233,7 @@ func (t *ToolsSuite) TestChangeAgentTools(c *gc.C) {
 	c.Assert(*gotTools, gc.Equals, *testTools)
 
 	assertDirNames(c, t.toolsDir(), []string{"1.2.3-quantal-amd64", "testagent"})
-	assertDirNames(c, agenttools.ToolsDir(t.dataDir, "testagent"), []string{"jujuc", "jujud", toolsFile})
+	assertDirNames(c, agenttools.ToolsDir(t.dataDir, "testagent"), []string{"jujuc", "jujud", toolsFile, checksumsFile})
 
 	// Upgrade again to check that the link replacement logic works ok.
 	files2 := []*testing.TarFile{
@@ -208,7 +255,7 @@ func (t *ToolsSuite) TestChangeAgentTools(c *gc.C) {
 	c.Assert(*gotTools, gc.Equals, *tools2)
 
 	assertDirNames(c, t.toolsDir(), []string{"1.2.3-quantal-amd64", "1.2.4-quantal-amd64", "testagent"})
-	assertDirNames(c, agenttools.ToolsDir(t.dataDir, "testagent"), []string{"quantal", "amd64", toolsFile})
+	assertDirNames(c, agenttools.ToolsDir(t.dataDir, "testagent"), []string{"quantal", "amd64", toolsFile, checksumsFile})
 }
 
 func (t *ToolsSuite) TestSharedToolsDir(c *gc.C) {
@@ -223,7 +270,7 @@ func (t *ToolsSuite) assertToolsContents(c *gc.C, testTools *coretest.Tools, fil
 	for _, f := range files {
 		wantNames = append(wantNames, f.Header.Name)
 	}
-	wantNames = append(wantNames, toolsFile)
+	wantNames = append(wantNames, toolsFile, checksumsFile)
 	dir := agenttools.SharedToolsDir(t.dataDir, testTools.Version)
 	assertDirNames(c, dir, wantNames)
 	expectedURLFileContents, err := json.Marshal(testTools)
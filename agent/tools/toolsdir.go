@@ -5,6 +5,8 @@ package tools
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/json"
@@ -23,6 +25,7 @@ import (
 )
 
 const toolsFile = "downloaded-tools.txt"
+const checksumsFile = "downloaded-tools.sha256"
 const dirPerm = 0755
 
 // SharedToolsDir returns the directory that is used to
@@ -87,6 +90,7 @@ func UnpackTools(dataDir string, tools *coretools.Tools, r io.Reader) (err error
 		return err
 	}
 	tr := tar.NewReader(f)
+	var checksums bytes.Buffer
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
@@ -102,9 +106,17 @@ func UnpackTools(dataDir string, tools *coretools.Tools, r io.Reader) (err error
 			return fmt.Errorf("bad file type %c in file %q in tools archive", hdr.Typeflag, hdr.Name)
 		}
 		name := path.Join(dir, hdr.Name)
-		if err := writeFile(name, os.FileMode(hdr.Mode&0777), tr); err != nil {
+		fileHash := sha256.New()
+		if err := writeFile(name, os.FileMode(hdr.Mode&0777), io.TeeReader(tr, fileHash)); err != nil {
 			return errors.Annotatef(err, "tar extract %q failed", name)
 		}
+		fmt.Fprintf(&checksums, "%x  %s\n", fileHash.Sum(nil), hdr.Name)
+	}
+	// Record the checksums of the unpacked files so that they can
+	// later be checked against the tarball they came from.
+	err = ioutil.WriteFile(path.Join(dir, checksumsFile), checksums.Bytes(), 0644)
+	if err != nil {
+		return err
 	}
 	toolsMetadataData, err := json.Marshal(tools)
 	if err != nil {
@@ -168,6 +180,61 @@ func ReadTools(dataDir string, vers version.Binary) (*coretools.Tools, error) {
 	return &tools, nil
 }
 
+// ErrNoChecksums is returned by VerifyTools when the tools were
+// unpacked without recording the checksums of their files.
+var ErrNoChecksums = errors.New("no checksums recorded for unpacked tools")
+
+// VerifyTools checks that the files unpacked for the given version in
+// the dataDir directory still match the checksums recorded when the
+// tools tarball was unpacked, and returns the tools read. The tarball
+// itself was checked against tools.SHA256 by UnpackTools.
+func VerifyTools(dataDir string, vers version.Binary) (*coretools.Tools, error) {
+	tools, err := ReadTools(dataDir, vers)
+	if err != nil {
+		return nil, err
+	}
+	dir := SharedToolsDir(dataDir, vers)
+	f, err := os.Open(path.Join(dir, checksumsFile))
+	if os.IsNotExist(err) {
+		return tools, ErrNoChecksums
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid checksum line %q", scanner.Text())
+		}
+		expected, name := fields[0], fields[1]
+		got, err := fileSHA256(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if got != expected {
+			return nil, fmt.Errorf("sha256 mismatch for %q, expected %s, got %s", name, expected, got)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
 // ChangeAgentTools atomically replaces the agent-specific symlink
 // under dataDir so it points to the previously unpacked
 // version vers. It returns the new tools read.
@@ -52,6 +52,11 @@ Juju tools to cloud storage if no outgoing Internet access is available. In this
 use the --metadata-source paramater to tell bootstrap a local directory from which to
 upload tools and/or image metadata.
 
+If bootstrap fails, the environment is normally destroyed. Use --keep-broken to leave
+the bootstrap instance and environment storage in place for debugging instead; what was
+created is recorded alongside the environment's .jenv file, and is cleaned up by
+juju destroy-environment.
+
 See Also:
    juju help switch
    juju help constraints
@@ -68,6 +73,8 @@ type BootstrapCommand struct {
 	seriesOld      []string
 	MetadataSource string
 	Placement      string
+	KeepBroken     bool
+	ForceReinstall bool
 }
 
 func (c *BootstrapCommand) Info() *cmd.Info {
@@ -85,6 +92,8 @@ func (c *BootstrapCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(newSeriesValue(nil, &c.seriesOld), "series", "upload tools for supplied comma-separated series list (DEPRECATED, see --upload-series)")
 	f.StringVar(&c.MetadataSource, "metadata-source", "", "local path to use as tools and/or metadata source")
 	f.StringVar(&c.Placement, "to", "", "a placement directive indicating an instance to bootstrap")
+	f.BoolVar(&c.KeepBroken, "keep-broken", false, "do not destroy the environment if bootstrap fails")
+	f.BoolVar(&c.ForceReinstall, "force-reinstall", false, "remove any previous juju installation from a reused bootstrap machine")
 }
 
 func (c *BootstrapCommand) Init(args []string) (err error) {
@@ -182,9 +191,20 @@ func (c *BootstrapCommand) Run(ctx *cmd.Context) (resultErr error) {
 		return errors.Annotatef(err, "there was an issue examining the environment")
 	}
 
-	// If we error out for any reason, clean up the environment.
+	// If we error out for any reason, clean up the environment,
+	// unless asked to keep it for debugging.
 	defer func() {
-		if resultErr != nil {
+		if resultErr == nil {
+			// Any manifest kept by an earlier failed bootstrap
+			// no longer describes the environment.
+			if err := bootstrap.RemoveManifest(c.ConnectionName()); err != nil {
+				logger.Warningf("cannot remove stale bootstrap manifest: %v", err)
+			}
+			return
+		}
+		if c.KeepBroken {
+			keepBrokenEnviron(ctx, environ)
+		} else {
 			cleanup()
 		}
 	}()
@@ -247,11 +267,27 @@ func (c *BootstrapCommand) Run(ctx *cmd.Context) (resultErr error) {
 		}
 	}
 	return bootstrapFuncs.Bootstrap(ctx, environ, environs.BootstrapParams{
-		Constraints: c.Constraints,
-		Placement:   c.Placement,
+		Constraints:    c.Constraints,
+		Placement:      c.Placement,
+		KeepBroken:     c.KeepBroken,
+		ForceReinstall: c.ForceReinstall,
 	})
 }
 
+// keepBrokenEnviron records what a failed bootstrap left in the
+// environment, so that destroy-environment can clean it up later.
+func keepBrokenEnviron(ctx *cmd.Context, environ environs.Environ) {
+	envName := environ.Config().Name()
+	manifest, err := bootstrap.RecordManifest(environ)
+	if err != nil {
+		logger.Errorf("cannot record resources of failed bootstrap: %v", err)
+		return
+	}
+	ctx.Infof("Bootstrap failed; keeping %d instance(s) and %d storage file(s) for debugging.",
+		len(manifest.Instances), len(manifest.StorageFiles))
+	ctx.Infof("Run juju destroy-environment %s to clean up.", envName)
+}
+
 var uploadCustomMetadata = func(metadataDir string, env environs.Environ) error {
 	logger.Infof("Setting default tools and image metadata sources: %s", metadataDir)
 	tools.DefaultBaseURL = metadataDir
@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/configstore"
 	"github.com/juju/juju/environs/filestorage"
@@ -406,6 +408,22 @@ func (s *BootstrapSuite) checkSeriesArg(c *gc.C, argVariant string) *cmd.Context
 	return ctx
 }
 
+func (s *BootstrapSuite) TestBootstrapForceReinstall(c *gc.C) {
+	_bootstrap := &fakeBootstrapFuncs{}
+	s.PatchValue(&getBootstrapFuncs, func() BootstrapInterface {
+		return _bootstrap
+	})
+	resetJujuHome(c)
+
+	_, err := coretesting.RunCommand(c, envcmd.Wrap(&BootstrapCommand{}), "--upload-tools")
+	c.Assert(err, gc.IsNil)
+	c.Check(_bootstrap.args.ForceReinstall, jc.IsFalse)
+
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&BootstrapCommand{}), "--upload-tools", "--force-reinstall")
+	c.Assert(err, gc.IsNil)
+	c.Check(_bootstrap.args.ForceReinstall, jc.IsTrue)
+}
+
 // In the case where we cannot examine an environment, we want the
 // error to propagate back up to the user.
 func (s *BootstrapSuite) TestBootstrapPropagatesEnvErrors(c *gc.C) {
@@ -672,7 +690,8 @@ func (s *BootstrapSuite) TestMissingToolsUploadFailedError(c *gc.C) {
 	ctx, err := coretesting.RunCommand(c, envcmd.Wrap(&BootstrapCommand{}))
 
 	c.Check(coretesting.Stderr(ctx), gc.Matches,
-		"uploading tools for series \\[precise raring .*\\]\n")
+		"Bootstrapping environment \"peckham\"\n"+
+			"uploading tools for series \\[precise raring .*\\]\n")
 	c.Check(err, gc.ErrorMatches, "cannot upload bootstrap tools: an error")
 }
 
@@ -703,6 +722,51 @@ func (s *BootstrapSuite) TestBootstrapDestroy(c *gc.C) {
 	c.Assert(opDestroy.Error, gc.ErrorMatches, "dummy.Destroy is broken")
 }
 
+func (s *BootstrapSuite) TestBootstrapKeepBroken(c *gc.C) {
+	resetJujuHome(c)
+	devVersion := version.Current
+	// Force a dev version by having a non zero build number.
+	// This is because we have not uploaded any tools and auto
+	// upload is only enabled for dev versions.
+	devVersion.Build = 1234
+	s.PatchValue(&version.Current, devVersion)
+	opc, errc := runCommand(nullContext(c), envcmd.Wrap(new(BootstrapCommand)), "-e", "brokenenv", "--keep-broken")
+	err := <-errc
+	c.Assert(err, gc.ErrorMatches, "dummy.Bootstrap is broken")
+	for done := false; !done; {
+		select {
+		case op := <-opc:
+			if _, ok := op.(dummy.OpDestroy); ok {
+				c.Fatalf("unexpected call to env.Destroy")
+			}
+		default:
+			done = true
+		}
+	}
+
+	// The environment is kept, along with a record of its resources.
+	_, err = os.Stat(gitjujutesting.HomePath(".juju", "environments", "brokenenv.jenv"))
+	c.Assert(err, gc.IsNil)
+	manifest, err := bootstrap.ReadManifest("brokenenv")
+	c.Assert(err, gc.IsNil)
+	c.Assert(manifest.Instances, gc.HasLen, 0)
+	c.Assert(manifest.StorageFiles, gc.Not(gc.HasLen), 0)
+}
+
+func (s *BootstrapSuite) TestBootstrapRemovesStaleManifest(c *gc.C) {
+	s.PatchValue(&getBootstrapFuncs, func() BootstrapInterface {
+		return &fakeBootstrapFuncs{}
+	})
+	resetJujuHome(c)
+	err := ioutil.WriteFile(bootstrap.ManifestPath("peckham"), []byte("instances: [i-old]\n"), 0600)
+	c.Assert(err, gc.IsNil)
+
+	_, err = coretesting.RunCommand(c, envcmd.Wrap(&BootstrapCommand{}))
+	c.Assert(err, gc.IsNil)
+	_, err = bootstrap.ReadManifest("peckham")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 // createToolsSource writes the mock tools and metadata into a temporary
 // directory and returns it.
 func createToolsSource(c *gc.C, versions []version.Binary) string {
@@ -783,6 +847,7 @@ func joinBinaryVersions(versions ...[]version.Binary) []version.Binary {
 // file which execute large amounts of external functionality.
 type fakeBootstrapFuncs struct {
 	uploadToolsSeries []string
+	args              environs.BootstrapParams
 }
 
 func (fake *fakeBootstrapFuncs) EnsureNotBootstrapped(env environs.Environ) error {
@@ -794,6 +859,7 @@ func (fake *fakeBootstrapFuncs) UploadTools(ctx environs.BootstrapContext, env e
 	return nil
 }
 
-func (fake fakeBootstrapFuncs) Bootstrap(ctx environs.BootstrapContext, env environs.Environ, args environs.BootstrapParams) error {
+func (fake *fakeBootstrapFuncs) Bootstrap(ctx environs.BootstrapContext, env environs.Environ, args environs.BootstrapParams) error {
+	fake.args = args
 	return nil
 }
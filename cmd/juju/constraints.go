@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
@@ -140,8 +141,17 @@ func (c *SetConstraintsCommand) Run(_ *cmd.Context) (err error) {
 		return err
 	}
 	defer apiclient.Close()
+	var unsupported []string
 	if c.ServiceName == "" {
-		return apiclient.SetEnvironmentConstraints(c.Constraints)
+		unsupported, err = apiclient.SetEnvironmentConstraints(c.Constraints)
+	} else {
+		unsupported, err = apiclient.SetServiceConstraints(c.ServiceName, c.Constraints)
+	}
+	if err != nil {
+		return err
+	}
+	if len(unsupported) > 0 {
+		logger.Warningf("unsupported constraints: %s", strings.Join(unsupported, ","))
 	}
-	return apiclient.SetServiceConstraints(c.ServiceName, c.Constraints)
+	return nil
 }
@@ -5,15 +5,16 @@ package main
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"io"
 	"strings"
 
 	"github.com/juju/cmd"
+	"github.com/juju/errors"
 	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/configstore"
 	"github.com/juju/juju/juju"
 	"github.com/juju/juju/state/api/params"
@@ -98,6 +99,22 @@ func (c *DestroyEnvironmentCommand) Run(ctx *cmd.Context) (result error) {
 			return errors.New("environment destruction aborted")
 		}
 	}
+	// An environment kept after a failed bootstrap has no API
+	// server, so it is destroyed directly, which stops all of its
+	// instances, including those recorded in the manifest.
+	manifest, err := bootstrap.ReadManifest(c.envName)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if manifest != nil {
+		if len(manifest.Instances) > 0 {
+			ctx.Infof("destroying instances kept by failed bootstrap: %v", manifest.Instances)
+		}
+		if err := environs.Destroy(environ, store); err != nil {
+			return err
+		}
+		return bootstrap.RemoveManifest(c.envName)
+	}
 	// If --force is supplied, then don't attempt to use the API.
 	// This is necessary to destroy broken environments, where the
 	// API server is inaccessible or faulty.
@@ -12,6 +12,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/configstore"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/testing"
@@ -111,6 +112,24 @@ func (s *destroyEnvSuite) TestDestroyEnvironmentCommandBroken(c *gc.C) {
 	c.Check(<-opc, gc.IsNil)
 }
 
+func (s *destroyEnvSuite) TestDestroyEnvironmentCommandKeptBootstrap(c *gc.C) {
+	// Record the environment as kept after a failed bootstrap.
+	inst, _ := testing.AssertStartInstance(c, s.Environ, "42")
+	manifest, err := bootstrap.RecordManifest(s.Environ)
+	c.Assert(err, gc.IsNil)
+	c.Assert(manifest.Instances, jc.SameContents, []instance.Id{dummy.BootstrapInstanceId, inst.Id()})
+
+	// The environment is destroyed without using the API.
+	opc, errc := runCommand(nullContext(c), new(DestroyEnvironmentCommand), "dummyenv", "--yes")
+	c.Check(<-errc, gc.IsNil)
+	c.Check((<-opc).(dummy.OpDestroy).Env, gc.Equals, "dummyenv")
+
+	_, err = s.ConfigStore.ReadInfo("dummyenv")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = bootstrap.ReadManifest("dummyenv")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (*destroyEnvSuite) TestDestroyEnvironmentCommandConfirmationFlag(c *gc.C) {
 	com := new(DestroyEnvironmentCommand)
 	c.Check(coretesting.InitCommand(com, []string{"dummyenv"}), gc.IsNil)
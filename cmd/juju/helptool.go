@@ -5,11 +5,14 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/cmd"
+	"github.com/juju/loggo"
 	"launchpad.net/gnuflag"
 
+	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/worker/uniter/jujuc"
 )
 
@@ -26,15 +29,26 @@ func (dummyHookContext) PublicAddress() (string, bool) {
 func (dummyHookContext) PrivateAddress() (string, bool) {
 	return "", false
 }
-func (dummyHookContext) OpenPort(protocol string, port int) error {
+func (dummyHookContext) WriteLog(level loggo.Level, message string) {
+}
+func (dummyHookContext) NetworkInfo(endpoint string) (jujuc.NetworkInfo, error) {
+	return jujuc.NetworkInfo{}, nil
+}
+func (dummyHookContext) OpenPorts(protocol string, fromPort, toPort int) error {
 	return nil
 }
-func (dummyHookContext) ClosePort(protocol string, port int) error {
+func (dummyHookContext) ClosePorts(protocol string, fromPort, toPort int) error {
 	return nil
 }
 func (dummyHookContext) ConfigSettings() (charm.Settings, error) {
 	return charm.NewConfig().DefaultSettings(), nil
 }
+func (dummyHookContext) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	return nil
+}
+func (dummyHookContext) AddMetric(key, value string, created time.Time) error {
+	return nil
+}
 func (dummyHookContext) HookRelation() (jujuc.ContextRelation, bool) {
 	return nil, false
 }
@@ -94,6 +94,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&DeployCommand{}))
 	r.Register(wrapEnvCommand(&AddRelationCommand{}))
 	r.Register(wrapEnvCommand(&AddUnitCommand{}))
+	r.Register(wrapEnvCommand(&MoveUnitCommand{}))
 
 	// Destruction commands.
 	r.Register(wrapEnvCommand(&RemoveMachineCommand{}))
@@ -106,6 +107,7 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&StatusCommand{}))
 	r.Register(&SwitchCommand{})
 	r.Register(wrapEnvCommand(&EndpointCommand{}))
+	r.Register(wrapEnvCommand(&ScheduledTasksCommand{}))
 
 	// Error resolution and debugging commands.
 	r.Register(wrapEnvCommand(&RunCommand{}))
@@ -131,6 +133,9 @@ func registerCommands(r commandRegistry, ctx *cmd.Context) {
 	r.Register(wrapEnvCommand(&UnexposeCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeJujuCommand{}))
 	r.Register(wrapEnvCommand(&UpgradeCharmCommand{}))
+	r.Register(wrapEnvCommand(&UpgradeSeriesCommand{}))
+	r.Register(wrapEnvCommand(&EnableScheduledTaskCommand{}))
+	r.Register(wrapEnvCommand(&DisableScheduledTaskCommand{}))
 
 	// Charm publishing commands.
 	r.Register(wrapEnvCommand(&PublishCommand{}))
@@ -202,6 +202,8 @@ var commandNames = []string{
 	"destroy-relation",
 	"destroy-service",
 	"destroy-unit",
+	"disable-scheduled-task",
+	"enable-scheduled-task",
 	"ensure-availability",
 	"env", // alias for switch
 	"expose",
@@ -213,6 +215,7 @@ var commandNames = []string{
 	"help",
 	"help-tool",
 	"init",
+	"move-unit",
 	"publish",
 	"remove-machine",  // alias for destroy-machine
 	"remove-relation", // alias for destroy-relation
@@ -221,6 +224,7 @@ var commandNames = []string{
 	"resolved",
 	"retry-provisioning",
 	"run",
+	"scheduled-tasks",
 	"scp",
 	"set",
 	"set-constraints",
@@ -238,6 +242,7 @@ var commandNames = []string{
 	"unset-environment",
 	"upgrade-charm",
 	"upgrade-juju",
+	"upgrade-series",
 	"user",
 	"version",
 }
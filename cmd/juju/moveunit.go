@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const moveUnitDoc = `
+Move a unit, together with its subordinates, onto another machine
+without destroying it. The unit's agent is deployed on the new machine,
+where it installs the charm and rejoins the unit's relations, and the
+agent on the old machine is stopped and removed.
+
+The unit's relation settings are kept; its private-address is updated
+to that of the new machine, if known.
+
+Example:
+   juju move-unit mysql/0 3
+`
+
+// MoveUnitCommand moves a unit from one machine to another.
+type MoveUnitCommand struct {
+	envcmd.EnvCommandBase
+	UnitName  string
+	MachineId string
+}
+
+func (c *MoveUnitCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "move-unit",
+		Args:    "<unit> <machine>",
+		Purpose: "move a unit to another machine",
+		Doc:     moveUnitDoc,
+	}
+}
+
+func (c *MoveUnitCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return fmt.Errorf("no unit specified")
+	case 1:
+		return fmt.Errorf("no machine specified")
+	}
+	c.UnitName, c.MachineId = args[0], args[1]
+	if !names.IsValidUnit(c.UnitName) {
+		return fmt.Errorf("invalid unit name %q", c.UnitName)
+	}
+	if !names.IsValidMachine(c.MachineId) {
+		return fmt.Errorf("invalid machine %q", c.MachineId)
+	}
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *MoveUnitCommand) Run(_ *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.MoveUnit(c.UnitName, c.MachineId)
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type MoveUnitSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&MoveUnitSuite{})
+
+func runMoveUnit(c *gc.C, args ...string) error {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&MoveUnitCommand{}), args...)
+	return err
+}
+
+var moveUnitInitErrorTests = []struct {
+	args []string
+	err  string
+}{
+	{
+		err: `no unit specified`,
+	}, {
+		args: []string{"wordpress/0"},
+		err:  `no machine specified`,
+	}, {
+		args: []string{"wordpress", "1"},
+		err:  `invalid unit name "wordpress"`,
+	}, {
+		args: []string{"wordpress/0", "jeremy-fisher"},
+		err:  `invalid machine "jeremy-fisher"`,
+	}, {
+		args: []string{"wordpress/0", "1", "2"},
+		err:  `unrecognized args: \["2"\]`,
+	},
+}
+
+func (s *MoveUnitSuite) TestInitErrors(c *gc.C) {
+	for i, t := range moveUnitInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(envcmd.Wrap(&MoveUnitCommand{}), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MoveUnitSuite) TestMoveUnit(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(m0)
+	c.Assert(err, gc.IsNil)
+
+	err = runMoveUnit(c, "wordpress/0", m1.Id())
+	c.Assert(err, gc.IsNil)
+	err = unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	machineId, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, m1.Id())
+
+	err = runMoveUnit(c, "wordpress/0", "42")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
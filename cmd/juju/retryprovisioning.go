@@ -8,15 +8,37 @@ import (
 
 	"github.com/juju/cmd"
 	"github.com/juju/names"
+	"launchpad.net/gnuflag"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
 )
 
+const retryProvisioningDoc = `
+Retry provisioning of machines whose provisioning failed, for example
+because of a transient error in the cloud provider.
+
+The machines' constraints and placement directive may be replaced before
+provisioning is retried, by specifying --constraints and --placement.
+The new constraints replace the machines' existing constraints entirely.
+
+Examples:
+   juju retry-provisioning 1 2
+   juju retry-provisioning --constraints "mem=4G" 1
+   juju retry-provisioning --placement zone=us-east-1b 1
+`
+
 // RetryProvisioningCommand updates machines' error status to tell
 // the provisoner that it should try to re-provision the machine.
 type RetryProvisioningCommand struct {
 	envcmd.EnvCommandBase
 	Machines []string
+	// Constraints, if non-nil, replace the machines' constraints.
+	Constraints *constraints.Value
+	// Placement, if non-empty, replaces the machines' placement directive.
+	Placement string
+
+	constraintsStr string
 }
 
 func (c *RetryProvisioningCommand) Info() *cmd.Info {
@@ -24,13 +46,26 @@ func (c *RetryProvisioningCommand) Info() *cmd.Info {
 		Name:    "retry-provisioning",
 		Args:    "<machine> [...]",
 		Purpose: "retries provisioning for failed machines",
+		Doc:     retryProvisioningDoc,
 	}
 }
 
+func (c *RetryProvisioningCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.constraintsStr, "constraints", "", "replacement machine constraints")
+	f.StringVar(&c.Placement, "placement", "", "replacement placement directive")
+}
+
 func (c *RetryProvisioningCommand) Init(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no machine specified")
 	}
+	if c.constraintsStr != "" {
+		cons, err := constraints.Parse(c.constraintsStr)
+		if err != nil {
+			return err
+		}
+		c.Constraints = &cons
+	}
 	c.Machines = make([]string, len(args))
 	for i, arg := range args {
 		if !names.IsValidMachine(arg) {
@@ -47,7 +82,7 @@ func (c *RetryProvisioningCommand) Run(context *cmd.Context) error {
 		return err
 	}
 	defer client.Close()
-	results, err := client.RetryProvisioning(c.Machines...)
+	results, err := client.RetryProvisioningWithArgs(c.Constraints, c.Placement, c.Machines...)
 	if err != nil {
 		return err
 	}
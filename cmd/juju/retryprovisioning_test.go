@@ -10,6 +10,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/cmd/envcmd"
+	"github.com/juju/juju/constraints"
 	jujutesting "github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
@@ -81,3 +82,38 @@ func (s *retryProvisioningSuite) TestResolved(c *gc.C) {
 		}
 	}
 }
+
+func (s *retryProvisioningSuite) TestInitArgs(c *gc.C) {
+	com := &RetryProvisioningCommand{}
+	err := testing.InitCommand(com, []string{"--constraints", "mem=4G", "--placement", "zone=a", "1"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.Machines, gc.DeepEquals, []string{"machine-1"})
+	c.Assert(com.Constraints, gc.NotNil)
+	c.Assert(*com.Constraints, gc.DeepEquals, constraints.MustParse("mem=4G"))
+	c.Assert(com.Placement, gc.Equals, "zone=a")
+
+	com = &RetryProvisioningCommand{}
+	err = testing.InitCommand(com, []string{"--constraints", "cpu-cores=nine", "1"})
+	c.Assert(err, gc.ErrorMatches, `bad "cpu-cores" constraint: must be a non-negative integer`)
+}
+
+func (s *retryProvisioningSuite) TestWithConstraints(c *gc.C) {
+	m, err := s.State.AddOneMachine(state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	})
+	c.Assert(err, gc.IsNil)
+	err = m.SetStatus(params.StatusError, "broken", nil)
+	c.Assert(err, gc.IsNil)
+
+	context, err := testing.RunCommand(c, envcmd.Wrap(&RetryProvisioningCommand{}), "--constraints", "mem=4G", m.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stderr(context), gc.Equals, "")
+
+	mcons, err := m.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mcons, gc.DeepEquals, constraints.MustParse("mem=4G"))
+	_, _, data, err := m.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(data["transient"], jc.IsTrue)
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const scheduledTasksDoc = `
+List the tasks run periodically by the state servers, such as pruning
+completed transactions, together with whether each is enabled and the
+outcome of its most recent run.
+
+See also:
+   enable-scheduled-task
+   disable-scheduled-task
+`
+
+// ScheduledTasksCommand lists the tasks run periodically by the state
+// servers.
+type ScheduledTasksCommand struct {
+	envcmd.EnvCommandBase
+	out cmd.Output
+}
+
+func (c *ScheduledTasksCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "scheduled-tasks",
+		Purpose: "list the tasks run periodically by the state servers",
+		Doc:     scheduledTasksDoc,
+	}
+}
+
+func (c *ScheduledTasksCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "yaml", cmd.DefaultFormatters)
+}
+
+func (c *ScheduledTasksCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// scheduledTaskInfo holds the details of a task to be written out.
+type scheduledTaskInfo struct {
+	Interval  string `json:"interval" yaml:"interval"`
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	LastRun   string `json:"last-run,omitempty" yaml:"last-run,omitempty"`
+	LastError string `json:"last-error,omitempty" yaml:"last-error,omitempty"`
+}
+
+func (c *ScheduledTasksCommand) Run(ctx *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tasks, err := client.ScheduledTasks()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]scheduledTaskInfo)
+	for _, task := range tasks {
+		info := scheduledTaskInfo{
+			Interval:  task.Interval.String(),
+			Enabled:   task.Enabled,
+			LastError: task.LastError,
+		}
+		if task.LastRun != nil {
+			info.LastRun = task.LastRun.UTC().Format("2006-01-02 15:04:05")
+		}
+		result[task.Name] = info
+	}
+	return c.out.Write(ctx, result)
+}
+
+// setScheduledTaskEnabledCommand holds what is common to the commands
+// that enable and disable a scheduled task.
+type setScheduledTaskEnabledCommand struct {
+	envcmd.EnvCommandBase
+	Name string
+}
+
+func (c *setScheduledTaskEnabledCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no task specified")
+	}
+	c.Name = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *setScheduledTaskEnabledCommand) run(enabled bool) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SetScheduledTaskEnabled(c.Name, enabled)
+}
+
+// EnableScheduledTaskCommand enables a task run periodically by the
+// state servers.
+type EnableScheduledTaskCommand struct {
+	setScheduledTaskEnabledCommand
+}
+
+func (c *EnableScheduledTaskCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "enable-scheduled-task",
+		Args:    "<task>",
+		Purpose: "enable a task run periodically by the state servers",
+	}
+}
+
+func (c *EnableScheduledTaskCommand) Run(_ *cmd.Context) error {
+	return c.run(true)
+}
+
+// DisableScheduledTaskCommand disables a task run periodically by the
+// state servers.
+type DisableScheduledTaskCommand struct {
+	setScheduledTaskEnabledCommand
+}
+
+func (c *DisableScheduledTaskCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "disable-scheduled-task",
+		Args:    "<task>",
+		Purpose: "disable a task run periodically by the state servers",
+	}
+}
+
+func (c *DisableScheduledTaskCommand) Run(_ *cmd.Context) error {
+	return c.run(false)
+}
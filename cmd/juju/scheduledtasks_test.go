@@ -0,0 +1,72 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/testing"
+)
+
+type ScheduledTasksSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&ScheduledTasksSuite{})
+
+func (s *ScheduledTasksSuite) TestScheduledTasks(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+	err = task.SetLastRun(time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC), fmt.Errorf("boom"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.EnsureScheduledTask("backup", 24*time.Hour)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, envcmd.Wrap(&ScheduledTasksCommand{}))
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"backup:\n"+
+		"  interval: 24h0m0s\n"+
+		"  enabled: true\n"+
+		"prune:\n"+
+		"  interval: 1h0m0s\n"+
+		"  enabled: true\n"+
+		"  last-run: 2014-07-01 12:00:00\n"+
+		"  last-error: boom\n")
+}
+
+func (s *ScheduledTasksSuite) TestEnableDisable(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&DisableScheduledTaskCommand{}), "prune")
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsFalse)
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&EnableScheduledTaskCommand{}), "prune")
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsTrue)
+
+	_, err = testing.RunCommand(c, envcmd.Wrap(&EnableScheduledTaskCommand{}), "missing")
+	c.Assert(err, gc.ErrorMatches, `scheduled task "missing" not found`)
+}
+
+func (s *ScheduledTasksSuite) TestInitErrors(c *gc.C) {
+	err := testing.InitCommand(envcmd.Wrap(&EnableScheduledTaskCommand{}), nil)
+	c.Assert(err, gc.ErrorMatches, "no task specified")
+	err = testing.InitCommand(envcmd.Wrap(&DisableScheduledTaskCommand{}), []string{"prune", "extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+	err = testing.InitCommand(envcmd.Wrap(&ScheduledTasksCommand{}), []string{"extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/juju/cmd"
 	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
 
 	"github.com/juju/juju/cmd/envcmd"
 )
@@ -23,6 +24,8 @@ type SetCommand struct {
 	ServiceName     string
 	SettingsStrings map[string]string
 	SettingsYAML    cmd.FileVar
+	ToDefault       bool
+	ResetOptions    []string
 }
 
 const setDoc = `
@@ -34,6 +37,14 @@ In case a value starts with an at sign (@) the rest of the value is interpreted
 as a filename. The value itself is then read out of the named file. The maximum
 size of this value is 5M.
 
+With --to-default, the named options are set back to their default values,
+as with the unset command.
+
+The file given with --config may hold either the settings for the service
+keyed by its name, or the output of the get command for the service. In the
+latter case, options shown as having their default value are set back to
+their default, so the configuration saved with get is restored exactly.
+
 Option values may be any UTF-8 encoded string. UTF-8 is accepted on the command 
 line and in configuration files.
 `
@@ -45,12 +56,13 @@ func (c *SetCommand) Info() *cmd.Info {
 		Name:    "set",
 		Args:    "<service> name=value ...",
 		Purpose: "set service config options",
-		Doc:     "Set one or more configuration options for the specified service.",
+		Doc:     setDoc,
 	}
 }
 
 func (c *SetCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.Var(&c.SettingsYAML, "config", "path to yaml-formatted service config")
+	f.BoolVar(&c.ToDefault, "to-default", false, "set the named options back to their default values")
 }
 
 func (c *SetCommand) Init(args []string) error {
@@ -61,6 +73,21 @@ func (c *SetCommand) Init(args []string) error {
 		return errors.New("cannot specify --config when using key=value arguments")
 	}
 	c.ServiceName = args[0]
+	if c.ToDefault {
+		if c.SettingsYAML.Path != "" {
+			return errors.New("cannot specify --config with --to-default")
+		}
+		c.ResetOptions = args[1:]
+		if len(c.ResetOptions) == 0 {
+			return errors.New("no configuration options specified")
+		}
+		for _, option := range c.ResetOptions {
+			if strings.Contains(option, "=") {
+				return fmt.Errorf("cannot specify a value with --to-default: %q", option)
+			}
+		}
+		return nil
+	}
 	settings, err := parse(args[1:])
 	if err != nil {
 		return err
@@ -77,11 +104,18 @@ func (c *SetCommand) Run(ctx *cmd.Context) error {
 	}
 	defer api.Close()
 
+	if c.ToDefault {
+		return api.ServiceUnset(c.ServiceName, c.ResetOptions)
+	}
 	if c.SettingsYAML.Path != "" {
 		b, err := c.SettingsYAML.Read(ctx)
 		if err != nil {
 			return err
 		}
+		b, err = fromGetOutput(b, c.ServiceName)
+		if err != nil {
+			return err
+		}
 		return api.ServiceSetYAML(c.ServiceName, string(b))
 	} else if len(c.SettingsStrings) == 0 {
 		return nil
@@ -107,6 +141,44 @@ func (c *SetCommand) Run(ctx *cmd.Context) error {
 	return api.ServiceSet(c.ServiceName, settings)
 }
 
+// getOutput holds the output of the get command.
+type getOutput struct {
+	Service  string
+	Settings map[string]struct {
+		Value   interface{}
+		Default bool
+	}
+}
+
+// fromGetOutput returns the given service config YAML unchanged,
+// unless it holds the output of the get command, in which case it
+// returns the equivalent service config. Options having their default
+// value are given a null value, which sets them back to their default.
+func fromGetOutput(data []byte, serviceName string) ([]byte, error) {
+	var fields map[string]interface{}
+	if err := goyaml.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	_, hasService := fields["service"]
+	_, hasSettings := fields["settings"]
+	if !hasService || !hasSettings {
+		return data, nil
+	}
+	var out getOutput
+	if err := goyaml.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]interface{})
+	for name, option := range out.Settings {
+		if option.Default {
+			settings[name] = nil
+		} else {
+			settings[name] = option.Value
+		}
+	}
+	return goyaml.Marshal(map[string]interface{}{serviceName: settings})
+}
+
 // parse parses the option k=v strings into a map of options to be
 // updated in the config. Keys with empty values are returned separately
 // and should be removed.
@@ -97,6 +97,72 @@ func (s *SetSuite) TestSetConfig(c *gc.C) {
 	})
 }
 
+func (s *SetSuite) TestSetConfigFromGetOutput(c *gc.C) {
+	err := s.svc.UpdateConfigSettings(charm.Settings{
+		"title":    "Nearly There",
+		"username": "hello",
+	})
+	c.Assert(err, gc.IsNil)
+
+	// Options shown as having their default value are reset.
+	setupValueFile(c, s.dir, "getoutput.yaml", `
+charm: dummy
+service: other-service
+settings:
+  outlook:
+    description: No default outlook.
+    type: string
+    default: true
+  skill-level:
+    description: A number indicating skill.
+    type: int
+    value: 9
+  title:
+    description: A descriptive title used for the service.
+    type: string
+    value: Restored
+  username:
+    description: The name of the initial account (given admin permissions).
+    type: string
+    value: admin001
+    default: true
+`)
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--config",
+		"getoutput.yaml",
+	}, charm.Settings{
+		"title":       "Restored",
+		"skill-level": int64(9),
+	})
+}
+
+func (s *SetSuite) TestSetToDefault(c *gc.C) {
+	err := s.svc.UpdateConfigSettings(charm.Settings{
+		"username": "hello",
+		"outlook":  "hello@world.tld",
+	})
+	c.Assert(err, gc.IsNil)
+	assertSetSuccess(c, s.dir, s.svc, []string{
+		"--to-default",
+		"username",
+	}, charm.Settings{
+		"outlook": "hello@world.tld",
+	})
+}
+
+func (s *SetSuite) TestSetToDefaultFail(c *gc.C) {
+	assertSetFail(c, s.dir, []string{"--to-default"}, "error: no configuration options specified\n")
+	assertSetFail(c, s.dir, []string{
+		"--to-default",
+		"username=hello",
+	}, "error: cannot specify a value with --to-default: \"username=hello\"\n")
+	assertSetFail(c, s.dir, []string{
+		"--to-default",
+		"--config",
+		"testconfig.yaml",
+	}, "error: cannot specify --config with --to-default\n")
+}
+
 // assertSetSuccess sets configuration options and checks the expected settings.
 func assertSetSuccess(c *gc.C, dir string, svc *state.Service, args []string, expect charm.Settings) {
 	ctx := coretesting.ContextForDir(c, dir)
@@ -6,6 +6,8 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/juju/cmd"
 	"launchpad.net/gnuflag"
@@ -22,6 +24,7 @@ type StatusCommand struct {
 	envcmd.EnvCommandBase
 	out      cmd.Output
 	patterns []string
+	watch    bool
 }
 
 var statusDoc = `
@@ -36,6 +39,11 @@ will be displayed.
 Wildcards ('*') may be specified in service/unit names to match any sequence
 of characters. For example, 'nova-*' will match any service whose name begins
 with 'nova-': 'nova-compute', 'nova-volume', etc.
+
+With --watch, status is written again each time the environment changes,
+until the command is interrupted. Changes are streamed from the server
+rather than polled, so agent liveness, instance state and subordinate
+relationships are not shown, and patterns are not supported.
 `
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -53,10 +61,14 @@ func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
 		"yaml": cmd.FormatYaml,
 		"json": cmd.FormatJson,
 	})
+	f.BoolVar(&c.watch, "watch", false, "write status again whenever it changes")
 }
 
 func (c *StatusCommand) Init(args []string) error {
 	c.patterns = args
+	if c.watch && len(c.patterns) > 0 {
+		return fmt.Errorf("patterns cannot be used with --watch")
+	}
 	return nil
 }
 
@@ -69,11 +81,29 @@ Error details:
 
 type statusAPI interface {
 	Status(patterns []string) (*api.Status, error)
+	WatchAll() (statusWatcher, error)
 	Close() error
 }
 
 var newApiClientForStatus = func(c *StatusCommand) (statusAPI, error) {
-	return c.NewAPIClient()
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	return statusClient{client}, nil
+}
+
+// statusClient adapts *api.Client to the statusAPI interface.
+type statusClient struct {
+	*api.Client
+}
+
+func (c statusClient) WatchAll() (statusWatcher, error) {
+	w, err := c.Client.WatchAll()
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
 func (c *StatusCommand) Run(ctx *cmd.Context) error {
@@ -88,6 +118,9 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 	}
 	defer apiclient.Close()
 
+	if c.watch {
+		return c.watchStatus(ctx, apiclient)
+	}
 	status, err := apiclient.Status(c.patterns)
 	if err != nil {
 		if status == nil {
@@ -101,6 +134,33 @@ func (c *StatusCommand) Run(ctx *cmd.Context) error {
 	return c.out.Write(ctx, result)
 }
 
+// watchStatus writes the status of the environment each time it
+// changes, until the watcher fails.
+func (c *StatusCommand) watchStatus(ctx *cmd.Context, apiclient statusAPI) error {
+	watcher, err := apiclient.WatchAll()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+	model := newStatusModel()
+	var last formattedStatus
+	for {
+		deltas, err := watcher.Next()
+		if err != nil {
+			return err
+		}
+		model.apply(deltas)
+		result := newStatusFormatter(model.status(c.ConnectionName())).format()
+		if reflect.DeepEqual(result, last) {
+			continue
+		}
+		if err := c.out.Write(ctx, result); err != nil {
+			return err
+		}
+		last = result
+	}
+}
+
 type formattedStatus struct {
 	Environment string                   `json:"environment"`
 	Machines    map[string]machineStatus `json:"machines"`
@@ -116,6 +176,7 @@ type machineStatus struct {
 	Err            error                    `json:"-" yaml:",omitempty"`
 	AgentState     params.Status            `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
 	AgentStateInfo string                   `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentLastSeen  string                   `json:"agent-last-seen,omitempty" yaml:"agent-last-seen,omitempty"`
 	AgentVersion   string                   `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
 	DNSName        string                   `json:"dns-name,omitempty" yaml:"dns-name,omitempty"`
 	InstanceId     instance.Id              `json:"instance-id,omitempty" yaml:"instance-id,omitempty"`
@@ -181,16 +242,19 @@ func (s serviceStatus) GetYAML() (tag string, value interface{}) {
 }
 
 type unitStatus struct {
-	Err            error                 `json:"-" yaml:",omitempty"`
-	Charm          string                `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
-	AgentState     params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	Life           string                `json:"life,omitempty" yaml:"life,omitempty"`
-	Machine        string                `json:"machine,omitempty" yaml:"machine,omitempty"`
-	OpenedPorts    []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
-	PublicAddress  string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
-	Subordinates   map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+	Err                error                 `json:"-" yaml:",omitempty"`
+	Charm              string                `json:"upgrading-from,omitempty" yaml:"upgrading-from,omitempty"`
+	AgentState         params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo     string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentLastSeen      string                `json:"agent-last-seen,omitempty" yaml:"agent-last-seen,omitempty"`
+	AgentVersion       string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	Life               string                `json:"life,omitempty" yaml:"life,omitempty"`
+	WorkloadStatus     params.WorkloadStatus `json:"workload-status,omitempty" yaml:"workload-status,omitempty"`
+	WorkloadStatusInfo string                `json:"workload-status-info,omitempty" yaml:"workload-status-info,omitempty"`
+	Machine            string                `json:"machine,omitempty" yaml:"machine,omitempty"`
+	OpenedPorts        []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
+	PublicAddress      string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
+	Subordinates       map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
 }
 
 type unitStatusNoMarshal unitStatus
@@ -301,6 +365,7 @@ func (sf *statusFormatter) formatMachine(machine api.MachineStatus) machineStatu
 		out = machineStatus{
 			AgentState:     machine.AgentState,
 			AgentStateInfo: adjustInfoIfAgentDown(machine.AgentState, agent.Status, agent.Info),
+			AgentLastSeen:  formatLastSeen(agent.LastSeen),
 			AgentVersion:   agent.Version,
 			Life:           agent.Life,
 			Err:            agent.Err,
@@ -356,6 +421,7 @@ func (sf *statusFormatter) formatUnit(unit api.UnitStatus, serviceName string) u
 		Err:            unit.Err,
 		AgentState:     unit.AgentState,
 		AgentStateInfo: sf.getUnitStatusInfo(unit, serviceName),
+		AgentLastSeen:  formatLastSeen(unit.Agent.LastSeen),
 		AgentVersion:   unit.AgentVersion,
 		Life:           unit.Life,
 		Machine:        unit.Machine,
@@ -364,6 +430,12 @@ func (sf *statusFormatter) formatUnit(unit api.UnitStatus, serviceName string) u
 		Charm:          unit.Charm,
 		Subordinates:   make(map[string]unitStatus),
 	}
+	// Units whose charm has not reported a workload status, including
+	// all units of older servers, show none.
+	if unit.WorkloadStatus != params.WorkloadStatusUnknown {
+		out.WorkloadStatus = unit.WorkloadStatus
+		out.WorkloadStatusInfo = unit.WorkloadStatusInfo
+	}
 	for k, m := range unit.Subordinates {
 		out.Subordinates[k] = sf.formatUnit(m, serviceName)
 	}
@@ -388,6 +460,15 @@ func (sf *statusFormatter) getUnitStatusInfo(unit api.UnitStatus, serviceName st
 	return adjustInfoIfAgentDown(unit.AgentState, unit.Agent.Status, statusInfo)
 }
 
+// formatLastSeen returns the time an agent was last seen alive, as
+// shown in status, or the empty string if it is not known.
+func formatLastSeen(lastSeen *time.Time) string {
+	if lastSeen == nil {
+		return ""
+	}
+	return lastSeen.UTC().Format(time.RFC3339)
+}
+
 func (sf *statusFormatter) formatNetwork(network api.NetworkStatus) networkStatus {
 	return networkStatus{
 		Err:        network.Err,
@@ -1697,6 +1697,47 @@ var statusTests = []testCase{
 				},
 			},
 		},
+	), test(
+		"unit with workload status set by its charm",
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		setAddresses{"0", []network.Address{network.NewAddress("dummyenv-0.dns", network.ScopeUnknown)}},
+		startAliveMachine{"0"},
+		setMachineStatus{"0", params.StatusStarted, ""},
+		addMachine{machineId: "1", job: state.JobHostUnits},
+		setAddresses{"1", []network.Address{network.NewAddress("dummyenv-1.dns", network.ScopeUnknown)}},
+		startAliveMachine{"1"},
+		setMachineStatus{"1", params.StatusStarted, ""},
+		addCharm{"wordpress"},
+		addService{name: "wordpress", charm: "wordpress"},
+		addAliveUnit{"wordpress", "1"},
+		setUnitStatus{"wordpress/0", params.StatusStarted, "", nil},
+		setUnitWorkloadStatus{"wordpress/0", params.WorkloadStatusBlocked, "waiting for db relation"},
+
+		expect{
+			"workload status is shown alongside the agent state",
+			M{
+				"environment": "dummyenv",
+				"machines": M{
+					"0": machine0,
+					"1": machine1,
+				},
+				"services": M{
+					"wordpress": M{
+						"charm":   "cs:quantal/wordpress-3",
+						"exposed": false,
+						"units": M{
+							"wordpress/0": M{
+								"machine":              "1",
+								"agent-state":          "started",
+								"workload-status":      "blocked",
+								"workload-status-info": "waiting for db relation",
+								"public-address":       "dummyenv-1.dns",
+							},
+						},
+					},
+				},
+			},
+		},
 	),
 }
 
@@ -1973,6 +2014,19 @@ func (sus setUnitStatus) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type setUnitWorkloadStatus struct {
+	unitName   string
+	status     params.WorkloadStatus
+	statusInfo string
+}
+
+func (sus setUnitWorkloadStatus) step(c *gc.C, ctx *context) {
+	u, err := ctx.st.Unit(sus.unitName)
+	c.Assert(err, gc.IsNil)
+	err = u.SetWorkloadStatus(sus.status, sus.statusInfo)
+	c.Assert(err, gc.IsNil)
+}
+
 type setUnitCharmURL struct {
 	unitName string
 	charm    string
@@ -2170,6 +2224,7 @@ type fakeApiClient struct {
 	statusReturn *api.Status
 	patternsUsed []string
 	closeCalled  bool
+	watcher      *fakeStatusWatcher
 }
 
 func newFakeApiClient(statusReturn *api.Status) fakeApiClient {
@@ -2183,11 +2238,36 @@ func (a *fakeApiClient) Status(patterns []string) (*api.Status, error) {
 	return a.statusReturn, nil
 }
 
+func (a *fakeApiClient) WatchAll() (statusWatcher, error) {
+	return a.watcher, nil
+}
+
 func (a *fakeApiClient) Close() error {
 	a.closeCalled = true
 	return nil
 }
 
+// fakeStatusWatcher returns each of its batches of deltas in turn, and
+// then fails.
+type fakeStatusWatcher struct {
+	batches [][]params.Delta
+	stopped bool
+}
+
+func (w *fakeStatusWatcher) Next() ([]params.Delta, error) {
+	if len(w.batches) == 0 {
+		return nil, fmt.Errorf("watcher was stopped")
+	}
+	deltas := w.batches[0]
+	w.batches = w.batches[1:]
+	return deltas, nil
+}
+
+func (w *fakeStatusWatcher) Stop() error {
+	w.stopped = true
+	return nil
+}
+
 // Check that the client works with an older server which doesn't
 // return the top level Relations field nor the unit and machine level
 // Agent field (they were introduced at the same time).
@@ -2311,3 +2391,95 @@ func (s *StatusSuite) TestStatusWithPreRelationsServer(c *gc.C) {
 	defer s.resetContext(c, ctx)
 	ctx.run(c, []stepper{expected})
 }
+
+func (s *StatusSuite) TestStatusWatch(c *gc.C) {
+	unit := &params.UnitInfo{
+		Name:      "wordpress/0",
+		Service:   "wordpress",
+		CharmURL:  "local:quantal/wordpress-3",
+		MachineId: "0",
+		Status:    params.StatusStarted,
+	}
+	erroredUnit := *unit
+	erroredUnit.Status = params.StatusError
+	erroredUnit.StatusInfo = "blam"
+	watcher := &fakeStatusWatcher{
+		batches: [][]params.Delta{{
+			{Entity: &params.MachineInfo{
+				Id:         "0",
+				InstanceId: "dummyenv-0",
+				Status:     params.StatusStarted,
+				Life:       params.Alive,
+				Series:     "quantal",
+				Jobs:       []params.MachineJob{params.JobHostUnits},
+			}},
+			{Entity: &params.ServiceInfo{
+				Name:     "wordpress",
+				CharmURL: "local:quantal/wordpress-3",
+				Life:     params.Alive,
+			}},
+			{Entity: unit},
+		}, {
+			{Entity: &erroredUnit},
+		}, {
+			// Changes that do not affect status are not shown.
+			{Entity: &erroredUnit},
+		}},
+	}
+	client := newFakeApiClient(nil)
+	client.watcher = watcher
+	s.PatchValue(&newApiClientForStatus, func(_ *StatusCommand) (statusAPI, error) {
+		return &client, nil
+	})
+
+	code, stdout, stderr := runStatus(c, "--watch", "--format", "json")
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(string(stderr), gc.Equals, "error: watcher was stopped\n")
+	c.Assert(watcher.stopped, jc.IsTrue)
+
+	unitStatus := M{
+		"agent-state": "started",
+		"machine":     "0",
+	}
+	expected := M{
+		"environment": "dummyenv",
+		"machines": M{
+			"0": M{
+				"agent-state": "started",
+				"instance-id": "dummyenv-0",
+				"series":      "quantal",
+			},
+		},
+		"services": M{
+			"wordpress": M{
+				"charm":   "local:quantal/wordpress-3",
+				"exposed": false,
+				"units": M{
+					"wordpress/0": unitStatus,
+				},
+			},
+		},
+	}
+	lines := strings.Split(strings.TrimSpace(string(stdout)), "\n")
+	c.Assert(lines, gc.HasLen, 2)
+	assertStatus := func(line string) {
+		buf, err := json.Marshal(expected)
+		c.Assert(err, gc.IsNil)
+		var want, got interface{}
+		err = json.Unmarshal(buf, &want)
+		c.Assert(err, gc.IsNil)
+		err = json.Unmarshal([]byte(line), &got)
+		c.Assert(err, gc.IsNil)
+		c.Assert(got, jc.DeepEquals, want)
+	}
+	assertStatus(lines[0])
+	unitStatus["agent-state"] = "error"
+	unitStatus["agent-state-info"] = "blam"
+	assertStatus(lines[1])
+}
+
+func (s *StatusSuite) TestStatusWatchWithPatterns(c *gc.C) {
+	code, _, stderr := runStatus(c, "--watch", "mysql")
+	c.Assert(code, gc.Equals, 2)
+	c.Assert(string(stderr), gc.Equals, "error: patterns cannot be used with --watch\n")
+}
@@ -0,0 +1,272 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+// statusWatcher is implemented by *api.AllWatcher.
+type statusWatcher interface {
+	Next() ([]params.Delta, error)
+	Stop() error
+}
+
+// statusModel holds the state of an environment as described by the
+// deltas from an AllWatcher, so that status can be shown as it changes
+// without querying everything again.
+type statusModel struct {
+	machines  map[string]*params.MachineInfo
+	services  map[string]*params.ServiceInfo
+	units     map[string]*params.UnitInfo
+	relations map[string]*params.RelationInfo
+}
+
+func newStatusModel() *statusModel {
+	return &statusModel{
+		machines:  make(map[string]*params.MachineInfo),
+		services:  make(map[string]*params.ServiceInfo),
+		units:     make(map[string]*params.UnitInfo),
+		relations: make(map[string]*params.RelationInfo),
+	}
+}
+
+// apply updates the model with the given deltas.
+func (m *statusModel) apply(deltas []params.Delta) {
+	for _, delta := range deltas {
+		switch info := delta.Entity.(type) {
+		case *params.MachineInfo:
+			if delta.Removed {
+				delete(m.machines, info.Id)
+			} else {
+				m.machines[info.Id] = info
+			}
+		case *params.ServiceInfo:
+			if delta.Removed {
+				delete(m.services, info.Name)
+			} else {
+				m.services[info.Name] = info
+			}
+		case *params.UnitInfo:
+			if delta.Removed {
+				delete(m.units, info.Name)
+			} else {
+				m.units[info.Name] = info
+			}
+		case *params.RelationInfo:
+			if delta.Removed {
+				delete(m.relations, info.Key)
+			} else {
+				m.relations[info.Key] = info
+			}
+		}
+	}
+}
+
+// status returns the status of the modelled environment. The
+// AllWatcher does not report instance state or subordinate
+// relationships, so these are not shown.
+func (m *statusModel) status(environName string) *api.Status {
+	status := &api.Status{
+		EnvironmentName: environName,
+		Machines:        make(map[string]api.MachineStatus),
+		Services:        make(map[string]api.ServiceStatus),
+	}
+	for id := range m.machines {
+		if parentMachineId(id) == "" {
+			status.Machines[id] = m.machineStatus(id)
+		}
+	}
+	for name := range m.services {
+		status.Services[name] = m.serviceStatus(name)
+	}
+	keys := make([]string, 0, len(m.relations))
+	for key := range m.relations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		status.Relations = append(status.Relations, relationStatus(m.relations[key]))
+	}
+	return status
+}
+
+func (m *statusModel) machineStatus(id string) api.MachineStatus {
+	info := m.machines[id]
+	status := api.MachineStatus{
+		Agent: api.AgentStatus{
+			Status:   info.Status,
+			Info:     info.StatusInfo,
+			Data:     info.StatusData,
+			Life:     lifeString(info.Life),
+			LastSeen: lastSeen(info.AgentAlive, info.AgentLastSeen),
+		},
+		AgentState:     agentState(info.Status, info.AgentAlive, info.Life),
+		AgentStateInfo: info.StatusInfo,
+		Life:           lifeString(info.Life),
+		Id:             info.Id,
+		InstanceId:     instance.Id(info.InstanceId),
+		Series:         info.Series,
+		Jobs:           info.Jobs,
+		Containers:     make(map[string]api.MachineStatus),
+	}
+	if info.InstanceId == "" {
+		// As in full status, an unprovisioned machine's agent
+		// state is not shown.
+		status.InstanceId = "pending"
+		status.AgentState = ""
+	} else {
+		status.DNSName = network.SelectPublicAddress(info.Addresses)
+	}
+	if info.HardwareCharacteristics != nil {
+		status.Hardware = info.HardwareCharacteristics.String()
+	}
+	for childId := range m.machines {
+		if parentMachineId(childId) == id {
+			status.Containers[childId] = m.machineStatus(childId)
+		}
+	}
+	return status
+}
+
+func (m *statusModel) serviceStatus(name string) api.ServiceStatus {
+	info := m.services[name]
+	status := api.ServiceStatus{
+		Charm:     info.CharmURL,
+		Exposed:   info.Exposed,
+		Life:      lifeString(info.Life),
+		Relations: make(map[string][]string),
+		Units:     make(map[string]api.UnitStatus),
+	}
+	for _, relation := range m.relations {
+		for _, ep := range relation.Endpoints {
+			if ep.ServiceName != name {
+				continue
+			}
+			related := relatedServices(relation, ep)
+			status.Relations[ep.Relation.Name] = mergeSorted(status.Relations[ep.Relation.Name], related)
+		}
+	}
+	for unitName, unit := range m.units {
+		if unit.Service != name {
+			continue
+		}
+		unitStatus := api.UnitStatus{
+			Agent: api.AgentStatus{
+				Status:   unit.Status,
+				Info:     unit.StatusInfo,
+				Data:     unit.StatusData,
+				LastSeen: lastSeen(unit.AgentAlive, unit.AgentLastSeen),
+			},
+			AgentState:         agentState(unit.Status, unit.AgentAlive, ""),
+			AgentStateInfo:     unit.StatusInfo,
+			WorkloadStatus:     unit.WorkloadStatus,
+			WorkloadStatusInfo: unit.WorkloadStatusInfo,
+			Machine:            unit.MachineId,
+			PublicAddress:      unit.PublicAddress,
+		}
+		for _, port := range unit.Ports {
+			unitStatus.OpenedPorts = append(unitStatus.OpenedPorts, port.String())
+		}
+		if info.CharmURL != "" && unit.CharmURL != "" && unit.CharmURL != info.CharmURL {
+			unitStatus.Charm = unit.CharmURL
+		}
+		status.Units[unitName] = unitStatus
+	}
+	return status
+}
+
+// relatedServices returns the names of the services at the other end
+// of the relation from the given endpoint; a peer relation relates a
+// service to itself.
+func relatedServices(relation *params.RelationInfo, ep params.Endpoint) []string {
+	if len(relation.Endpoints) == 1 {
+		return []string{ep.ServiceName}
+	}
+	var related []string
+	for _, other := range relation.Endpoints {
+		if other.ServiceName != ep.ServiceName {
+			related = append(related, other.ServiceName)
+		}
+	}
+	return related
+}
+
+// mergeSorted returns the sorted union of the given lists of names.
+func mergeSorted(a, b []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, name := range append(a, b...) {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func relationStatus(info *params.RelationInfo) api.RelationStatus {
+	status := api.RelationStatus{
+		Id:  info.Id,
+		Key: info.Key,
+	}
+	for _, ep := range info.Endpoints {
+		status.Endpoints = append(status.Endpoints, api.EndpointStatus{
+			ServiceName: ep.ServiceName,
+			Name:        ep.Relation.Name,
+			Role:        ep.Relation.Role,
+		})
+		// These match on both sides, so use the last.
+		status.Interface = ep.Relation.Interface
+		status.Scope = ep.Relation.Scope
+	}
+	return status
+}
+
+// parentMachineId returns the id of the machine hosting the container
+// with the given id, or the empty string if the id is not that of a
+// container.
+func parentMachineId(id string) string {
+	parts := strings.Split(id, "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-2], "/")
+}
+
+// agentState returns the agent state to show in status for an agent
+// with the given status, presence and life. As in full status, an
+// agent that has started but is no longer alive is shown as down.
+func agentState(status params.Status, alive bool, life params.Life) params.Status {
+	if alive || status == "" || status == params.StatusPending || life == params.Dead {
+		return status
+	}
+	return params.StatusDown
+}
+
+// lastSeen returns the time at which an agent that is not alive was
+// last seen, or nil if the agent is alive or was never seen.
+func lastSeen(alive bool, seen time.Time) *time.Time {
+	if alive || seen.IsZero() {
+		return nil
+	}
+	return &seen
+}
+
+// lifeString returns the life to show in status; alive is the usual
+// state, so it is omitted.
+func lifeString(life params.Life) string {
+	if life == params.Alive {
+		return ""
+	}
+	return string(life)
+}
@@ -0,0 +1,148 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	"github.com/juju/charm"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type statusModelSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&statusModelSuite{})
+
+func (s *statusModelSuite) TestContainers(c *gc.C) {
+	model := newStatusModel()
+	model.apply([]params.Delta{
+		{Entity: &params.MachineInfo{Id: "0", InstanceId: "inst-0", Life: params.Alive}},
+		{Entity: &params.MachineInfo{Id: "0/lxc/0", Life: params.Alive}},
+		{Entity: &params.MachineInfo{Id: "0/lxc/0/kvm/1", InstanceId: "inst-1", Life: params.Dying}},
+	})
+	status := model.status("env")
+	c.Assert(status.Machines, gc.HasLen, 1)
+	host := status.Machines["0"]
+	c.Assert(host.InstanceId, gc.Equals, instance.Id("inst-0"))
+	c.Assert(host.Containers, gc.HasLen, 1)
+	container := host.Containers["0/lxc/0"]
+	c.Assert(container.InstanceId, gc.Equals, instance.Id("pending"))
+	c.Assert(container.Containers["0/lxc/0/kvm/1"].Life, gc.Equals, "dying")
+}
+
+func (s *statusModelSuite) TestRelations(c *gc.C) {
+	endpoint := func(service, name string, role charm.RelationRole) params.Endpoint {
+		return params.Endpoint{
+			ServiceName: service,
+			Relation:    charm.Relation{Name: name, Role: role, Interface: "mysql"},
+		}
+	}
+	model := newStatusModel()
+	model.apply([]params.Delta{
+		{Entity: &params.ServiceInfo{Name: "wordpress", Life: params.Alive}},
+		{Entity: &params.ServiceInfo{Name: "mysql", Life: params.Alive}},
+		{Entity: &params.RelationInfo{
+			Key: "wordpress:db mysql:server",
+			Id:  1,
+			Endpoints: []params.Endpoint{
+				endpoint("wordpress", "db", charm.RoleRequirer),
+				endpoint("mysql", "server", charm.RoleProvider),
+			},
+		}},
+		{Entity: &params.RelationInfo{
+			Key:       "mysql:cluster",
+			Id:        2,
+			Endpoints: []params.Endpoint{endpoint("mysql", "cluster", charm.RolePeer)},
+		}},
+	})
+	status := model.status("env")
+	c.Assert(status.Services["wordpress"].Relations, jc.DeepEquals, map[string][]string{
+		"db": {"mysql"},
+	})
+	c.Assert(status.Services["mysql"].Relations, jc.DeepEquals, map[string][]string{
+		"server":  {"wordpress"},
+		"cluster": {"mysql"},
+	})
+	c.Assert(status.Relations, gc.HasLen, 2)
+	c.Assert(status.Relations[1], jc.DeepEquals, api.RelationStatus{
+		Id:        1,
+		Key:       "wordpress:db mysql:server",
+		Interface: "mysql",
+		Endpoints: []api.EndpointStatus{{
+			ServiceName: "wordpress",
+			Name:        "db",
+			Role:        charm.RoleRequirer,
+		}, {
+			ServiceName: "mysql",
+			Name:        "server",
+			Role:        charm.RoleProvider,
+		}},
+	})
+}
+
+func (s *statusModelSuite) TestUnits(c *gc.C) {
+	model := newStatusModel()
+	model.apply([]params.Delta{
+		{Entity: &params.ServiceInfo{Name: "wordpress", CharmURL: "cs:quantal/wordpress-4"}},
+		{Entity: &params.UnitInfo{
+			Name:     "wordpress/0",
+			Service:  "wordpress",
+			CharmURL: "cs:quantal/wordpress-3",
+			Ports:    []network.Port{{"tcp", 80}},
+		}},
+		{Entity: &params.UnitInfo{Name: "wordpress/1", Service: "wordpress"}},
+	})
+	model.apply([]params.Delta{
+		{Removed: true, Entity: &params.UnitInfo{Name: "wordpress/1", Service: "wordpress"}},
+	})
+	units := model.status("env").Services["wordpress"].Units
+	c.Assert(units, gc.HasLen, 1)
+	c.Assert(units["wordpress/0"].Charm, gc.Equals, "cs:quantal/wordpress-3")
+	c.Assert(units["wordpress/0"].OpenedPorts, jc.DeepEquals, []string{"80/tcp"})
+}
+
+func (s *statusModelSuite) TestAgentDown(c *gc.C) {
+	lastSeen := time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC)
+	model := newStatusModel()
+	model.apply([]params.Delta{
+		{Entity: &params.MachineInfo{
+			Id:            "0",
+			InstanceId:    "inst-0",
+			Status:        params.StatusStarted,
+			AgentLastSeen: lastSeen,
+		}},
+		{Entity: &params.MachineInfo{
+			Id:         "1",
+			InstanceId: "inst-1",
+			Status:     params.StatusStarted,
+			AgentAlive: true,
+		}},
+		{Entity: &params.ServiceInfo{Name: "wordpress"}},
+		{Entity: &params.UnitInfo{
+			Name:    "wordpress/0",
+			Service: "wordpress",
+			Status:  params.StatusStarted,
+		}},
+	})
+	status := model.status("env")
+	down := status.Machines["0"]
+	c.Assert(down.AgentState, gc.Equals, params.StatusDown)
+	c.Assert(down.Agent.Status, gc.Equals, params.StatusStarted)
+	c.Assert(down.Agent.LastSeen, jc.DeepEquals, &lastSeen)
+	up := status.Machines["1"]
+	c.Assert(up.AgentState, gc.Equals, params.StatusStarted)
+	c.Assert(up.Agent.LastSeen, gc.IsNil)
+	unit := status.Services["wordpress"].Units["wordpress/0"]
+	c.Assert(unit.AgentState, gc.Equals, params.StatusDown)
+	c.Assert(unit.Agent.LastSeen, gc.IsNil)
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/juju/cmd"
+	"github.com/juju/names"
+	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/cmd/envcmd"
+)
+
+const upgradeSeriesDoc = `
+Upgrade the OS series of a machine in place, in two steps.
+
+First, prepare the upgrade by naming the machine and the series to
+upgrade it to. The machine agent stops the agents of the units on the
+machine. Every unit on the machine must have a charm for the new series.
+
+Then upgrade the machine's OS by hand and restart the machine. Once the
+machine agent finds the machine running the new series, complete the
+upgrade with --complete. The machine is recorded as running the new
+series, and its agent restarts the unit agents.
+
+Examples:
+   juju upgrade-series 1 trusty
+   juju upgrade-series --complete 1
+`
+
+// UpgradeSeriesCommand prepares or completes an in-place upgrade of a
+// machine's OS series.
+type UpgradeSeriesCommand struct {
+	envcmd.EnvCommandBase
+	MachineId string
+	Series    string
+	Complete  bool
+}
+
+func (c *UpgradeSeriesCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "upgrade-series",
+		Args:    "<machine> [<series>]",
+		Purpose: "upgrade the OS series of a machine",
+		Doc:     upgradeSeriesDoc,
+	}
+}
+
+func (c *UpgradeSeriesCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.BoolVar(&c.Complete, "complete", false, "complete a prepared upgrade once the OS has been upgraded")
+}
+
+func (c *UpgradeSeriesCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no machine specified")
+	}
+	c.MachineId, args = args[0], args[1:]
+	if !names.IsValidMachine(c.MachineId) {
+		return fmt.Errorf("invalid machine %q", c.MachineId)
+	}
+	if !c.Complete {
+		if len(args) == 0 {
+			return fmt.Errorf("no series specified")
+		}
+		c.Series, args = args[0], args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *UpgradeSeriesCommand) Run(_ *cmd.Context) error {
+	client, err := c.NewAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.Complete {
+		return client.CompleteSeriesUpgrade(c.MachineId)
+	}
+	return client.PrepareSeriesUpgrade(c.MachineId, c.Series)
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/cmd/envcmd"
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+)
+
+type UpgradeSeriesSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&UpgradeSeriesSuite{})
+
+func runUpgradeSeries(c *gc.C, args ...string) error {
+	_, err := testing.RunCommand(c, envcmd.Wrap(&UpgradeSeriesCommand{}), args...)
+	return err
+}
+
+var upgradeSeriesInitErrorTests = []struct {
+	args []string
+	err  string
+}{
+	{
+		err: `no machine specified`,
+	}, {
+		args: []string{"jeremy-fisher", "trusty"},
+		err:  `invalid machine "jeremy-fisher"`,
+	}, {
+		args: []string{"1"},
+		err:  `no series specified`,
+	}, {
+		args: []string{"1", "trusty", "utopic"},
+		err:  `unrecognized args: \["utopic"\]`,
+	}, {
+		args: []string{"--complete", "1", "trusty"},
+		err:  `unrecognized args: \["trusty"\]`,
+	},
+}
+
+func (s *UpgradeSeriesSuite) TestInitErrors(c *gc.C) {
+	for i, t := range upgradeSeriesInitErrorTests {
+		c.Logf("test %d: %v", i, t.args)
+		err := testing.InitCommand(envcmd.Wrap(&UpgradeSeriesCommand{}), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *UpgradeSeriesSuite) TestUpgradeSeries(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = runUpgradeSeries(c, m.Id(), "trusty")
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, series := m.UpgradeSeriesStatus()
+	c.Assert(status, gc.Equals, params.UpgradeSeriesPreparing)
+	c.Assert(series, gc.Equals, "trusty")
+
+	err = runUpgradeSeries(c, "--complete", m.Id())
+	c.Assert(err, gc.ErrorMatches, `cannot complete series upgrade of machine 0: machine is not yet running series "trusty"`)
+
+	// Simulate the machine agent's side of the upgrade.
+	err = m.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+	err = m.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+	c.Assert(err, gc.IsNil)
+
+	err = runUpgradeSeries(c, "--complete", m.Id())
+	c.Assert(err, gc.IsNil)
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Series(), gc.Equals, "trusty")
+}
+
+func (s *UpgradeSeriesSuite) TestUpgradeSeriesIncompatibleUnit(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+
+	err = runUpgradeSeries(c, m.Id(), "trusty")
+	c.Assert(err, gc.ErrorMatches, `cannot prepare series upgrade of machine 0 to "trusty": unit "wordpress/0" has a charm for series "quantal"`)
+}
@@ -29,6 +29,8 @@ import (
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/deployer"
+	"github.com/juju/juju/worker/logsignal"
+	"github.com/juju/juju/worker/machiner"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/upgrader"
 )
@@ -42,6 +44,10 @@ var (
 		Total: 1 * time.Minute,
 		Delay: 5 * time.Second,
 	}
+
+	// passwordRotationCheckInterval holds how often a running agent
+	// checks whether it has been asked to change its password.
+	passwordRotationCheckInterval = 5 * time.Minute
 )
 
 // requiredError is useful when complaining about missing command-line options.
@@ -275,31 +281,26 @@ func openAPIState(agentConfig agent.Config, a Agent) (_ *api.State, _ *apiagent.
 		}
 		return nil, nil, err
 	}
-	if usedOldPassword {
+	if err := recordAPIAddressHealth(agentConfig, st.FailedAddrs(), st.Addr(), a); err != nil {
+		return nil, nil, err
+	}
+	if agentConfig.Environment().Id() == "" {
+		// Agents configured before the environment was recorded
+		// learn it from the API server, so that future connections
+		// are refused by a server for any other environment.
+		if err := recordEnvironment(st, a); err != nil {
+			return nil, nil, err
+		}
+	}
+	if usedOldPassword || entity.PasswordRotationRequired() {
 		// We succeeded in connecting with the fallback
+		// password, or have been asked to rotate our
 		// password, so we need to create a new password
 		// for the future.
-
-		newPassword, err := utils.RandomPassword()
+		newPassword, err := rotatePassword(entity, info.Password, a)
 		if err != nil {
 			return nil, nil, err
 		}
-		// Change the configuration *before* setting the entity
-		// password, so that we avoid the possibility that
-		// we might successfully change the entity's
-		// password but fail to write the configuration,
-		// thus locking us out completely.
-		if err := a.ChangeConfig(func(c agent.ConfigSetter) error {
-			c.SetPassword(newPassword)
-			c.SetOldPassword(info.Password)
-			return nil
-		}); err != nil {
-			return nil, nil, err
-		}
-		if err := entity.SetPassword(newPassword); err != nil {
-			return nil, nil, err
-		}
-
 		st.Close()
 		info.Password = newPassword
 		st, err = apiOpen(info, api.DialOpts{})
@@ -311,6 +312,100 @@ func openAPIState(agentConfig agent.Config, a Agent) (_ *api.State, _ *apiagent.
 	return st, entity, nil
 }
 
+// rotatePassword chooses a new password for the agent, replacing
+// oldPassword, and returns it.
+func rotatePassword(entity *apiagent.Entity, oldPassword string, a Agent) (string, error) {
+	newPassword, err := utils.RandomPassword()
+	if err != nil {
+		return "", err
+	}
+	// Change the configuration *before* setting the entity
+	// password, so that we avoid the possibility that
+	// we might successfully change the entity's
+	// password but fail to write the configuration,
+	// thus locking us out completely.
+	if err := a.ChangeConfig(func(c agent.ConfigSetter) error {
+		c.SetPassword(newPassword)
+		c.SetOldPassword(oldPassword)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	if err := entity.SetPassword(newPassword); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// passwordRotatingAgent is implemented by agents that can have their
+// passwords changed while running.
+type passwordRotatingAgent interface {
+	Agent
+	CurrentConfig() agent.Config
+}
+
+// newPasswordRotator returns a worker that changes the agent's
+// password whenever the agent has been asked to, so that a forced
+// rotation does not wait for the agent to reconnect.
+func newPasswordRotator(st *api.State, a passwordRotatingAgent) worker.Worker {
+	return worker.NewSimpleWorker(func(stop <-chan struct{}) error {
+		for {
+			select {
+			case <-stop:
+				return nil
+			case <-time.After(passwordRotationCheckInterval):
+			}
+			entity, err := st.Agent().Entity(a.Tag())
+			if err != nil {
+				return err
+			}
+			if !entity.PasswordRotationRequired() {
+				continue
+			}
+			oldPassword := a.CurrentConfig().APIInfo().Password
+			if _, err := rotatePassword(entity, oldPassword, a); err != nil {
+				return err
+			}
+			logger.Infof("agent password changed as required")
+		}
+	})
+}
+
+// recordAPIAddressHealth records in the agent's configuration that
+// the API connection was made to connectedAddr, and that the failed
+// addresses could not be dialed first. Addresses that were abandoned
+// once the connection was made are not counted as failing. The
+// configuration is written only if what it records changes.
+func recordAPIAddressHealth(agentConfig agent.Config, failed []string, connectedAddr string, a Agent) error {
+	if len(failed) == 0 && agentConfig.APIAddressFailures(connectedAddr) == 0 {
+		return nil
+	}
+	return a.ChangeConfig(func(c agent.ConfigSetter) error {
+		for _, addr := range failed {
+			c.RecordAPIAddressFailure(addr)
+		}
+		c.RecordAPIAddressSuccess(connectedAddr)
+		return nil
+	})
+}
+
+// recordEnvironment saves the tag of the environment that st is
+// connected to in the agent's configuration.
+func recordEnvironment(st *api.State, a Agent) error {
+	if st.EnvironTag() == "" {
+		// Older API servers do not report the environment.
+		return nil
+	}
+	tag, err := names.ParseEnvironTag(st.EnvironTag())
+	if err != nil {
+		return err
+	}
+	return a.ChangeConfig(func(c agent.ConfigSetter) error {
+		c.SetEnvironment(tag)
+		return nil
+	})
+}
+
 // agentDone processes the error returned by
 // an exiting agent.
 func agentDone(err error) error {
@@ -361,6 +456,12 @@ var newDeployContext = func(st *apideployer.State, agentConfig agent.Config) dep
 	return deployer.NewSimpleContext(agentConfig, st)
 }
 
+// newUnitAgents gives the tests the opportunity to avoid stopping and
+// starting unit agents on the system running the tests.
+var newUnitAgents = func(st *apideployer.State, agentConfig agent.Config) machiner.UnitAgents {
+	return deployer.NewSimpleContext(agentConfig, st)
+}
+
 // newRsyslogConfigWorker creates and returns a new RsyslogConfigWorker
 // based on the specified configuration parameters.
 var newRsyslogConfigWorker = func(st *apirsyslog.State, agentConfig agent.Config, mode rsyslog.RsyslogMode) (worker.Worker, error) {
@@ -373,6 +474,32 @@ var newRsyslogConfigWorker = func(st *apirsyslog.State, agentConfig agent.Config
 	return rsyslog.NewRsyslogConfigWorker(st, mode, tag, namespace, addrs)
 }
 
+// newLogSignalWorker returns a worker that manages the agent's logging
+// when it is signalled, reopening its log file and logging at DEBUG
+// level for the window configured in agentConfig.
+func newLogSignalWorker(agentConfig agent.Config) (worker.Worker, error) {
+	debugWindow, err := debugLogWindow(agentConfig)
+	if err != nil {
+		return nil, err
+	}
+	logPath := filepath.Join(agentConfig.LogDir(), agentConfig.Tag().String()+".log")
+	return logsignal.NewWorker(logPath, debugWindow), nil
+}
+
+// debugLogWindow returns how long the agent should log at DEBUG level
+// when signalled, as configured in agentConfig.
+func debugLogWindow(agentConfig agent.Config) (time.Duration, error) {
+	window := agentConfig.Value(agent.DebugLogWindow)
+	if window == "" {
+		return logsignal.DefaultDebugWindow, nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid debug log window: %q", window)
+	}
+	return d, nil
+}
+
 // hookExecutionLock returns an *fslock.Lock suitable for use as a unit
 // hook execution lock. Other workers may also use this lock if they
 // require isolation from hook execution.
@@ -178,6 +178,108 @@ func (s *apiOpenSuite) TestOpenAPIStateWaitsProvisionedGivesUp(c *gc.C) {
 	c.Assert(called, gc.Equals, checkProvisionedStrategy.Min+1)
 }
 
+// configAgent is an Agent whose configuration is held in memory.
+type configAgent struct {
+	conf    agent.ConfigSetterWriter
+	changes int
+}
+
+func (a *configAgent) Tag() names.Tag {
+	return a.conf.Tag()
+}
+
+func (a *configAgent) ChangeConfig(change AgentConfigMutator) error {
+	a.changes++
+	return change(a.conf)
+}
+
+func (s *apiOpenSuite) newConfigAgent(c *gc.C, apiAddrs ...string) *configAgent {
+	conf, err := agent.NewAgentConfig(agent.AgentConfigParams{
+		DataDir:           c.MkDir(),
+		Tag:               names.NewMachineTag("1"),
+		UpgradedToVersion: version.Current.Number,
+		Password:          "sekrit",
+		Nonce:             "a nonce",
+		APIAddresses:      apiAddrs,
+		CACert:            "ca cert",
+	})
+	c.Assert(err, gc.IsNil)
+	return &configAgent{conf: conf}
+}
+
+func (s *apiOpenSuite) TestRecordAPIAddressHealth(c *gc.C) {
+	addrs := []string{"10.0.0.1:17070", "10.0.0.2:17070", "10.0.0.3:17070"}
+	a := s.newConfigAgent(c, addrs...)
+
+	// Every time the first address fails to be dialed, it is
+	// counted as failing; eventually it is tried last.
+	for i := 0; i < 3; i++ {
+		err := recordAPIAddressHealth(a.conf, []string{"10.0.0.1:17070"}, "10.0.0.2:17070", a)
+		c.Assert(err, gc.IsNil)
+	}
+	value, err := a.conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, []string{"10.0.0.2:17070", "10.0.0.3:17070", "10.0.0.1:17070"})
+
+	// Connecting to it again restores it.
+	err = recordAPIAddressHealth(a.conf, nil, "10.0.0.1:17070", a)
+	c.Assert(err, gc.IsNil)
+	value, err = a.conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, addrs)
+}
+
+func (s *apiOpenSuite) TestRecordAPIAddressHealthUnchanged(c *gc.C) {
+	addrs := []string{"10.0.0.1:17070", "10.0.0.2:17070"}
+	a := s.newConfigAgent(c, addrs...)
+
+	// Addresses that lost the dial without failing are not
+	// counted as failing, and a connection that changes
+	// nothing does not rewrite the configuration.
+	for i := 0; i < 3; i++ {
+		err := recordAPIAddressHealth(a.conf, nil, "10.0.0.2:17070", a)
+		c.Assert(err, gc.IsNil)
+	}
+	c.Assert(a.changes, gc.Equals, 0)
+	value, err := a.conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(value, jc.DeepEquals, addrs)
+}
+
+type debugLogWindowSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&debugLogWindowSuite{})
+
+func (s *debugLogWindowSuite) TestDebugLogWindow(c *gc.C) {
+	for i, test := range []struct {
+		values map[string]string
+		window time.Duration
+		err    string
+	}{{
+		window: 10 * time.Minute,
+	}, {
+		values: map[string]string{agent.DebugLogWindow: "30m"},
+		window: 30 * time.Minute,
+	}, {
+		values: map[string]string{agent.DebugLogWindow: "a while"},
+		err:    `invalid debug log window: "a while"`,
+	}, {
+		values: map[string]string{agent.DebugLogWindow: "-1m"},
+		err:    `invalid debug log window: "-1m"`,
+	}} {
+		c.Logf("test %d", i)
+		window, err := debugLogWindow(valuesAgentConfig{values: test.values})
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Assert(window, gc.Equals, test.window)
+	}
+}
+
 type testPinger func() error
 
 func (f testPinger) Ping() error {
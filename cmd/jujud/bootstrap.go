@@ -23,6 +23,7 @@ import (
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/peergrouper"
 )
 
@@ -173,6 +174,35 @@ func newEnsureServerParams(agentConfig agent.Config) (mongo.EnsureServerParams,
 		}
 	}
 
+	var cacheSize int
+	if cacheSizeString := agentConfig.Value(agent.MongoCacheSize); cacheSizeString != "" {
+		var err error
+		if cacheSize, err = strconv.Atoi(cacheSizeString); err != nil {
+			return mongo.EnsureServerParams{}, fmt.Errorf("invalid mongo cache size: %q", cacheSizeString)
+		}
+	}
+
+	// Journaling is enabled unless the agent configuration disables it.
+	var noJournal bool
+	if journalString := agentConfig.Value(agent.MongoJournal); journalString != "" {
+		journal, err := strconv.ParseBool(journalString)
+		if err != nil {
+			return mongo.EnsureServerParams{}, fmt.Errorf("invalid mongo journal setting: %q", journalString)
+		}
+		noJournal = !journal
+	}
+
+	// The version of mongod that last served the database is recorded
+	// at bootstrap and by upgrade steps; it is missing in agent
+	// configurations written before it was introduced.
+	var mongoVersion version.Number
+	if versionString := agentConfig.Value(agent.MongoVersion); versionString != "" {
+		var err error
+		if mongoVersion, err = version.Parse(versionString); err != nil {
+			return mongo.EnsureServerParams{}, fmt.Errorf("invalid mongo version: %q", versionString)
+		}
+	}
+
 	servingInfo, ok := agentConfig.StateServingInfo()
 	if !ok {
 		return mongo.EnsureServerParams{}, fmt.Errorf("agent config has no state serving info")
@@ -183,6 +213,10 @@ func newEnsureServerParams(agentConfig agent.Config) (mongo.EnsureServerParams,
 		DataDir:          agentConfig.DataDir(),
 		Namespace:        agentConfig.Value(agent.Namespace),
 		OplogSize:        oplogSize,
+		CacheSize:        cacheSize,
+		DBDir:            agentConfig.Value(agent.MongoDBDir),
+		NoJournal:        noJournal,
+		Version:          mongoVersion,
 	}
 	return params, nil
 }
@@ -222,6 +256,9 @@ func (c *BootstrapCommand) startMongo(addrs []network.Address, agentConfig agent
 	if err != nil {
 		return err
 	}
+	if err := c.recordMongoVersion(); err != nil {
+		return err
+	}
 
 	peerAddr := mongo.SelectPeerAddress(addrs)
 	if peerAddr == "" {
@@ -235,6 +272,22 @@ func (c *BootstrapCommand) startMongo(addrs []network.Address, agentConfig agent
 	})
 }
 
+// recordMongoVersion records the version of the installed mongod in
+// the agent configuration, so that later upgrades can tell whether
+// the database can be served in place. Failing to find the version
+// is not fatal; the next upgrade will record it.
+func (c *BootstrapCommand) recordMongoVersion() error {
+	v, err := mongoInstalledVersion()
+	if err != nil {
+		logger.Warningf("cannot record mongod version: %v", err)
+		return nil
+	}
+	return c.ChangeConfig(func(config agent.ConfigSetter) error {
+		config.SetValue(agent.MongoVersion, v.String())
+		return nil
+	})
+}
+
 // yamlBase64Value implements gnuflag.Value on a map[string]interface{}.
 type yamlBase64Value map[string]interface{}
 
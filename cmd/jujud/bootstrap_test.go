@@ -49,6 +49,10 @@ type BootstrapSuite struct {
 	dataDir         string
 	logDir          string
 	mongoOplogSize  string
+	mongoJournal    string
+	mongoCacheSize  string
+	mongoDBDir      string
+	mongoVersion    string
 	fakeEnsureMongo fakeEnsure
 	bootstrapName   string
 }
@@ -61,6 +65,10 @@ type fakeEnsure struct {
 	dataDir        string
 	namespace      string
 	oplogSize      int
+	cacheSize      int
+	dbDir          string
+	noJournal      bool
+	version        version.Number
 	info           params.StateServingInfo
 	initiateParams peergrouper.InitiateMongoParams
 	err            error
@@ -69,6 +77,8 @@ type fakeEnsure struct {
 func (f *fakeEnsure) fakeEnsureMongo(args mongo.EnsureServerParams) error {
 	f.ensureCount++
 	f.dataDir, f.namespace, f.info, f.oplogSize = args.DataDir, args.Namespace, args.StateServingInfo, args.OplogSize
+	f.cacheSize, f.dbDir = args.CacheSize, args.DBDir
+	f.noJournal, f.version = args.NoJournal, args.Version
 	return f.err
 }
 
@@ -81,6 +91,9 @@ func (f *fakeEnsure) fakeInitiateMongo(p peergrouper.InitiateMongoParams) error
 func (s *BootstrapSuite) SetUpSuite(c *gc.C) {
 	s.PatchValue(&ensureMongoServer, s.fakeEnsureMongo.fakeEnsureMongo)
 	s.PatchValue(&maybeInitiateMongoServer, s.fakeEnsureMongo.fakeInitiateMongo)
+	s.PatchValue(&mongoInstalledVersion, func() (version.Number, error) {
+		return version.MustParse("2.4.9"), nil
+	})
 
 	s.BaseSuite.SetUpSuite(c)
 	s.MgoSuite.SetUpSuite(c)
@@ -99,6 +112,10 @@ func (s *BootstrapSuite) SetUpTest(c *gc.C) {
 	s.dataDir = c.MkDir()
 	s.logDir = c.MkDir()
 	s.mongoOplogSize = "1234"
+	s.mongoJournal = ""
+	s.mongoCacheSize = ""
+	s.mongoDBDir = ""
+	s.mongoVersion = ""
 	s.fakeEnsureMongo = fakeEnsure{}
 }
 
@@ -136,6 +153,10 @@ func (s *BootstrapSuite) initBootstrapCommand(c *gc.C, jobs []params.MachineJob,
 		Values: map[string]string{
 			agent.Namespace:      "foobar",
 			agent.MongoOplogSize: s.mongoOplogSize,
+			agent.MongoJournal:   s.mongoJournal,
+			agent.MongoVersion:   s.mongoVersion,
+			agent.MongoCacheSize: s.mongoCacheSize,
+			agent.MongoDBDir:     s.mongoDBDir,
 		},
 	}
 	servingInfo := params.StateServingInfo{
@@ -168,6 +189,13 @@ func (s *BootstrapSuite) TestInitializeEnvironment(c *gc.C) {
 	c.Assert(s.fakeEnsureMongo.ensureCount, gc.Equals, 1)
 	c.Assert(s.fakeEnsureMongo.dataDir, gc.Equals, s.dataDir)
 	c.Assert(s.fakeEnsureMongo.oplogSize, gc.Equals, 1234)
+	c.Assert(s.fakeEnsureMongo.noJournal, jc.IsFalse)
+	c.Assert(s.fakeEnsureMongo.version, gc.Equals, version.Zero)
+
+	// The version of the installed mongod is recorded.
+	machConf1, err := agent.ReadConfig(agent.ConfigPath(s.dataDir, names.NewMachineTag("0")))
+	c.Assert(err, gc.IsNil)
+	c.Assert(machConf1.Value(agent.MongoVersion), gc.Equals, "2.4.9")
 
 	expectInfo, exists := machConf.StateServingInfo()
 	c.Assert(exists, jc.IsTrue)
@@ -222,6 +250,40 @@ func (s *BootstrapSuite) TestInitializeEnvironmentInvalidOplogSize(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `invalid oplog size: "NaN"`)
 }
 
+func (s *BootstrapSuite) TestInitializeEnvironmentMongoSettings(c *gc.C) {
+	s.mongoJournal = "false"
+	s.mongoVersion = "2.4.6"
+	s.mongoCacheSize = "2048"
+	s.mongoDBDir = "/srv/juju-db"
+	hw := instance.MustParseHardware("arch=amd64 mem=8G")
+	_, cmd, err := s.initBootstrapCommand(c, nil, "--env-config", s.envcfg, "--instance-id", string(s.instanceId), "--hardware", hw.String())
+	c.Assert(err, gc.IsNil)
+	err = cmd.Run(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.fakeEnsureMongo.noJournal, jc.IsTrue)
+	c.Assert(s.fakeEnsureMongo.version, gc.Equals, version.MustParse("2.4.6"))
+	c.Assert(s.fakeEnsureMongo.cacheSize, gc.Equals, 2048)
+	c.Assert(s.fakeEnsureMongo.dbDir, gc.Equals, "/srv/juju-db")
+}
+
+func (s *BootstrapSuite) TestInitializeEnvironmentInvalidMongoCacheSize(c *gc.C) {
+	s.mongoCacheSize = "lots"
+	hw := instance.MustParseHardware("arch=amd64 mem=8G")
+	_, cmd, err := s.initBootstrapCommand(c, nil, "--env-config", s.envcfg, "--instance-id", string(s.instanceId), "--hardware", hw.String())
+	c.Assert(err, gc.IsNil)
+	err = cmd.Run(nil)
+	c.Assert(err, gc.ErrorMatches, `invalid mongo cache size: "lots"`)
+}
+
+func (s *BootstrapSuite) TestInitializeEnvironmentInvalidMongoJournal(c *gc.C) {
+	s.mongoJournal = "sometimes"
+	hw := instance.MustParseHardware("arch=amd64 mem=8G")
+	_, cmd, err := s.initBootstrapCommand(c, nil, "--env-config", s.envcfg, "--instance-id", string(s.instanceId), "--hardware", hw.String())
+	c.Assert(err, gc.IsNil)
+	err = cmd.Run(nil)
+	c.Assert(err, gc.ErrorMatches, `invalid mongo journal setting: "sometimes"`)
+}
+
 func (s *BootstrapSuite) TestSetConstraints(c *gc.C) {
 	tcons := constraints.Value{Mem: uint64p(2048), CpuCores: uint64p(2)}
 	_, cmd, err := s.initBootstrapCommand(c, nil,
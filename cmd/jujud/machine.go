@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
 	"sync"
@@ -28,6 +29,7 @@ import (
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/container/kvm"
 	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/instance"
 	jujunames "github.com/juju/juju/juju/names"
 	"github.com/juju/juju/juju/paths"
@@ -40,7 +42,9 @@ import (
 	"github.com/juju/juju/state/api"
 	apiagent "github.com/juju/juju/state/api/agent"
 	"github.com/juju/juju/state/api/params"
+	apiwatcher "github.com/juju/juju/state/api/watcher"
 	"github.com/juju/juju/state/apiserver"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
@@ -48,20 +52,26 @@ import (
 	"github.com/juju/juju/worker/charmrevisionworker"
 	"github.com/juju/juju/worker/cleaner"
 	"github.com/juju/juju/worker/deployer"
+	"github.com/juju/juju/worker/eventpublisher"
 	"github.com/juju/juju/worker/firewaller"
 	"github.com/juju/juju/worker/instancepoller"
 	"github.com/juju/juju/worker/localstorage"
 	workerlogger "github.com/juju/juju/worker/logger"
+	"github.com/juju/juju/worker/logsignal"
 	"github.com/juju/juju/worker/machineenvironmentworker"
+	"github.com/juju/juju/worker/machinemetrics"
 	"github.com/juju/juju/worker/machiner"
 	"github.com/juju/juju/worker/minunitsworker"
 	"github.com/juju/juju/worker/networker"
+	"github.com/juju/juju/worker/notifier"
 	"github.com/juju/juju/worker/peergrouper"
 	"github.com/juju/juju/worker/provisioner"
 	"github.com/juju/juju/worker/resumer"
 	"github.com/juju/juju/worker/rsyslog"
+	"github.com/juju/juju/worker/scheduler"
 	"github.com/juju/juju/worker/singular"
 	"github.com/juju/juju/worker/terminationworker"
+	"github.com/juju/juju/worker/txnpruner"
 	"github.com/juju/juju/worker/upgrader"
 )
 
@@ -71,6 +81,10 @@ var newRunner = worker.NewRunner
 
 const bootstrapMachineId = "0"
 
+// modelCacheStaleness bounds how far the API server's model cache
+// may lag behind the state's multiwatcher.
+const modelCacheStaleness = time.Second
+
 // eitherState can be either a *state.State or a *api.State.
 type eitherState interface{}
 
@@ -82,6 +96,7 @@ var (
 	// The following are defined as variables to
 	// allow the tests to intercept calls to the functions.
 	ensureMongoServer        = mongo.EnsureServer
+	mongoInstalledVersion    = mongo.InstalledVersion
 	maybeInitiateMongoServer = peergrouper.MaybeInitiateMongoServer
 	ensureMongoAdminUser     = mongo.EnsureAdminUser
 	newSingularRunner        = singular.New
@@ -158,6 +173,7 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 	// lines of all logging in the log file.
 	loggo.RemoveWriter("logfile")
 	defer a.tomb.Done()
+	logsignal.CatchSignals()
 	if err := a.ReadConfig(a.Tag().String()); err != nil {
 		return fmt.Errorf("cannot read agent configuration: %v", err)
 	}
@@ -174,6 +190,9 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 	a.runner.StartWorker("termination", func() (worker.Worker, error) {
 		return terminationworker.NewWorker(), nil
 	})
+	a.runner.StartWorker("logsignal", func() (worker.Worker, error) {
+		return newLogSignalWorker(a.CurrentConfig())
+	})
 	// At this point, all workers will have been configured to start
 	close(a.workersStarted)
 	err := a.runner.Wait()
@@ -246,6 +265,10 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	}
 	reportOpenedAPI(st)
 
+	if err := a.recordJobs(entity.Jobs()); err != nil {
+		return nil, err
+	}
+
 	// Refresh the configuration, since it may have been updated after opening state.
 	agentConfig = a.CurrentConfig()
 
@@ -268,7 +291,7 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	}
 
 	rsyslogMode := rsyslog.RsyslogModeForwarding
-	runner := newRunner(connectionIsFatal(st), moreImportant)
+	runner := newRunner(jobsChangedIsFatal(connectionIsFatal(st)), moreImportant)
 	var singularRunner worker.Runner
 	for _, job := range entity.Jobs() {
 		if job == params.JobManageEnviron {
@@ -282,10 +305,29 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 		}
 	}
 
+	// Restart all the API workers if the machine's jobs change.
+	jobs := entity.Jobs()
+	runner.StartWorker("jobswatcher", func() (worker.Worker, error) {
+		machine, err := st.Machiner().Machine(a.Tag().(names.MachineTag))
+		if err != nil {
+			return nil, err
+		}
+		return newJobsWatcher(machine.Watch, func() (bool, error) {
+			current, err := st.Agent().Entity(a.Tag())
+			if err != nil {
+				return false, err
+			}
+			return !reflect.DeepEqual(current.Jobs(), jobs), nil
+		}), nil
+	})
+	runner.StartWorker("passwordrotator", func() (worker.Worker, error) {
+		return newPasswordRotator(st, a), nil
+	})
+
 	// Run the upgrader and the upgrade-steps worker without waiting for
 	// the upgrade steps to complete.
 	runner.StartWorker("upgrader", func() (worker.Worker, error) {
-		return upgrader.NewUpgrader(st.Upgrader(), agentConfig), nil
+		return upgrader.NewUpgrader(st.Upgrader(), agentConfig, clock.WallClock), nil
 	})
 	runner.StartWorker("upgrade-steps", func() (worker.Worker, error) {
 		return a.upgradeWorkerContext.Worker(a, st, entity.Jobs()), nil
@@ -294,7 +336,15 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 	// All other workers must wait for the upgrade steps to complete
 	// before starting.
 	a.startWorkerAfterUpgrade(runner, "machiner", func() (worker.Worker, error) {
-		return machiner.NewMachiner(st.Machiner(), agentConfig), nil
+		unitAgents := newUnitAgents(st.Deployer(), agentConfig)
+		return machiner.NewMachiner(st.Machiner(), agentConfig, unitAgents), nil
+	})
+	a.startWorkerAfterUpgrade(runner, "machinemetrics", func() (worker.Worker, error) {
+		machine, err := st.Machiner().Machine(a.Tag().(names.MachineTag))
+		if err != nil {
+			return nil, err
+		}
+		return machinemetrics.NewCollector(machine, clock.WallClock), nil
 	})
 	a.startWorkerAfterUpgrade(runner, "apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), a), nil
@@ -335,7 +385,7 @@ func (a *MachineAgent) APIWorker() (worker.Worker, error) {
 			a.startWorkerAfterUpgrade(runner, "deployer", func() (worker.Worker, error) {
 				apiDeployer := st.Deployer()
 				context := newDeployContext(apiDeployer, agentConfig)
-				return deployer.NewDeployer(apiDeployer, context), nil
+				return deployer.NewDeployer(apiDeployer, context, clock.WallClock), nil
 			})
 		case params.JobManageEnviron:
 			a.startWorkerAfterUpgrade(singularRunner, "environ-provisioner", func() (worker.Worker, error) {
@@ -439,18 +489,21 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 		return nil, err
 	}
 
-	// Start MondoDB server
-	if err := a.ensureMongoServer(agentConfig); err != nil {
-		return nil, err
+	// Start MondoDB server, unless this machine runs only
+	// an API server and connects to the state servers' mongo.
+	if !apiServerOnly(agentConfig.Jobs()) {
+		if err := a.ensureMongoServer(agentConfig); err != nil {
+			return nil, err
+		}
 	}
-	st, m, err := openState(agentConfig, mongo.DialOpts{})
+	st, m, err := openState(agentConfig, mongo.DialOpts{}, stateWorkersWatcher)
 	if err != nil {
 		return nil, err
 	}
 	reportOpenedState(st)
 
 	singularStateConn := singularStateConn{st.MongoSession(), m}
-	runner := newRunner(connectionIsFatal(st), moreImportant)
+	runner := newRunner(jobsChangedIsFatal(connectionIsFatal(st)), moreImportant)
 	singularRunner, err := newSingularRunner(runner, singularStateConn)
 	if err != nil {
 		return nil, fmt.Errorf("cannot make singular State Runner: %v", err)
@@ -467,7 +520,40 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			return localstorage.NewWorker(agentConfig), nil
 		})
 	}
-	for _, job := range m.Jobs() {
+	// Restart all the state workers if the machine's jobs change.
+	jobs := m.Jobs()
+	runner.StartWorker("jobswatcher", func() (worker.Worker, error) {
+		watch := func() (apiwatcher.NotifyWatcher, error) {
+			return m.Watch(), nil
+		}
+		return newJobsWatcher(watch, func() (bool, error) {
+			current, err := st.Machine(m.Id())
+			if err != nil {
+				return false, err
+			}
+			if reflect.DeepEqual(current.Jobs(), jobs) {
+				return false, nil
+			}
+			// Record the new jobs before the state worker
+			// restarts, so that it decides whether to run
+			// a local mongo by them.
+			newJobs := make([]params.MachineJob, len(current.Jobs()))
+			for i, job := range current.Jobs() {
+				newJobs[i] = job.ToParams()
+			}
+			return true, a.recordJobs(newJobs)
+		}), nil
+	})
+	// Apply changes to the watcher settings in the environment
+	// configuration to the watcher opened above.
+	runner.StartWorker("watcherparams", func() (worker.Worker, error) {
+		provisioned, err := newWatcherParams(agentConfig)
+		if err != nil {
+			return nil, err
+		}
+		return newWatcherParamsUpdater(st, provisioned), nil
+	})
+	for _, job := range jobs {
 		switch job {
 		case state.JobHostUnits:
 			// Implemented in APIWorker.
@@ -480,37 +566,7 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				return peergrouperNew(st)
 			})
 			runner.StartWorker("apiserver", func() (worker.Worker, error) {
-				// If the configuration does not have the required information,
-				// it is currently not a recoverable error, so we kill the whole
-				// agent, potentially enabling human intervention to fix
-				// the agent's configuration file. In the future, we may retrieve
-				// the state server certificate and key from the state, and
-				// this should then change.
-				info, ok := agentConfig.StateServingInfo()
-				if !ok {
-					return nil, &fatalError{"StateServingInfo not available and we need it"}
-				}
-				cert := []byte(info.Cert)
-				key := []byte(info.PrivateKey)
-
-				if len(cert) == 0 || len(key) == 0 {
-					return nil, &fatalError{"configuration does not have state server cert/key"}
-				}
-				dataDir := agentConfig.DataDir()
-				logDir := agentConfig.LogDir()
-
-				endpoint := net.JoinHostPort("", strconv.Itoa(info.APIPort))
-				listener, err := net.Listen("tcp", endpoint)
-				if err != nil {
-					return nil, err
-				}
-				return apiserver.NewServer(st, listener, apiserver.ServerConfig{
-					Cert:      cert,
-					Key:       key,
-					DataDir:   dataDir,
-					LogDir:    logDir,
-					Validator: a.limitLoginsDuringUpgrade,
-				})
+				return a.newAPIServer(st, agentConfig)
 			})
 			a.startWorkerAfterUpgrade(singularRunner, "cleaner", func() (worker.Worker, error) {
 				return cleaner.NewCleaner(st), nil
@@ -519,11 +575,27 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				// The action of resumer is so subtle that it is not tested,
 				// because we can't figure out how to do so without brutalising
 				// the transaction log.
-				return resumer.NewResumer(st), nil
+				return resumer.NewResumer(st, clock.WallClock), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "scheduler", func() (worker.Worker, error) {
+				return scheduler.NewScheduler(st, clock.WallClock, []scheduler.Task{
+					txnpruner.NewTask(st),
+					cleaner.NewSettingsTask(st),
+				}), nil
 			})
 			a.startWorkerAfterUpgrade(singularRunner, "minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			a.startWorkerAfterUpgrade(singularRunner, "notifier", func() (worker.Worker, error) {
+				return notifier.NewNotifier(st), nil
+			})
+			a.startWorkerAfterUpgrade(singularRunner, "eventpublisher", func() (worker.Worker, error) {
+				return eventpublisher.NewPublisher(st), nil
+			})
+		case state.JobManageAPI:
+			runner.StartWorker("apiserver", func() (worker.Worker, error) {
+				return a.newAPIServer(st, agentConfig)
+			})
 		case state.JobManageStateDeprecated:
 			// Legacy environments may set this, but we ignore it.
 		default:
@@ -533,6 +605,71 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	return newCloseWorker(runner, st), nil
 }
 
+// newAPIServer returns an API server using the given state and
+// listening on the API port from the agent's state serving info.
+func (a *MachineAgent) newAPIServer(st *state.State, agentConfig agent.Config) (worker.Worker, error) {
+	// If the configuration does not have the required information,
+	// it is currently not a recoverable error, so we kill the whole
+	// agent, potentially enabling human intervention to fix
+	// the agent's configuration file. In the future, we may retrieve
+	// the state server certificate and key from the state, and
+	// this should then change.
+	info, ok := agentConfig.StateServingInfo()
+	if !ok {
+		return nil, &fatalError{"StateServingInfo not available and we need it"}
+	}
+	cert := []byte(info.Cert)
+	key := []byte(info.PrivateKey)
+
+	if len(cert) == 0 || len(key) == 0 {
+		return nil, &fatalError{"configuration does not have state server cert/key"}
+	}
+	dataDir := agentConfig.DataDir()
+	logDir := agentConfig.LogDir()
+
+	endpoint := net.JoinHostPort("", strconv.Itoa(info.APIPort))
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return apiserver.NewServer(st, listener, apiserver.ServerConfig{
+		Cert:      cert,
+		Key:       key,
+		DataDir:   dataDir,
+		LogDir:    logDir,
+		Validator: a.limitLoginsDuringUpgrade,
+
+		ModelCacheStaleness: modelCacheStaleness,
+	})
+}
+
+// recordJobs records the machine's current jobs in the agent's
+// configuration, from which the state worker decides whether to run
+// a local mongo and how to connect to the state servers' mongo.
+func (a *MachineAgent) recordJobs(jobs []params.MachineJob) error {
+	if reflect.DeepEqual(a.CurrentConfig().Jobs(), jobs) {
+		return nil
+	}
+	return a.ChangeConfig(func(config agent.ConfigSetter) error {
+		return config.Migrate(agent.MigrateParams{Jobs: jobs})
+	})
+}
+
+// apiServerOnly reports whether the given jobs run an API
+// server without a local mongo.
+func apiServerOnly(jobs []params.MachineJob) bool {
+	apiOnly := false
+	for _, job := range jobs {
+		switch job {
+		case params.JobManageEnviron:
+			return false
+		case params.JobManageAPI:
+			apiOnly = true
+		}
+	}
+	return apiOnly
+}
+
 // limitLoginsDuringUpgrade is called by the API server for each login
 // attempt. It returns an error if upgrades are in progress unless the
 // login is for a user (i.e. a client) or the local machine.
@@ -608,7 +745,7 @@ func (a *MachineAgent) ensureMongoServer(agentConfig agent.Config) (err error) {
 		// Note: we set Direct=true in the mongo options because it's
 		// possible that we've previously upgraded the mongo server's
 		// configuration to form a replicaset, but failed to initiate it.
-		st, m, err := openState(agentConfig, mongo.DialOpts{Direct: true})
+		st, m, err := openState(agentConfig, mongo.DialOpts{Direct: true}, "")
 		if err != nil {
 			return err
 		}
@@ -679,6 +816,7 @@ func (a *MachineAgent) ensureMongoAdminUser(agentConfig agent.Config) (added boo
 		DialInfo:  dialInfo,
 		Namespace: agentConfig.Value(agent.Namespace),
 		DataDir:   agentConfig.DataDir(),
+		DBDir:     agentConfig.Value(agent.MongoDBDir),
 		Port:      servingInfo.StatePort,
 		User:      stateInfo.Tag.String(),
 		Password:  stateInfo.Password,
@@ -689,12 +827,106 @@ func isPreHAVersion(v version.Number) bool {
 	return v.Compare(version.MustParse("1.19.0")) < 0
 }
 
-func openState(agentConfig agent.Config, dialOpts mongo.DialOpts) (_ *state.State, _ *state.Machine, err error) {
+// newWatcherParams creates a state.WatcherParams from an agent
+// configuration. Unset values are left as zero so that the state
+// uses its defaults.
+func newWatcherParams(agentConfig agent.Config) (state.WatcherParams, error) {
+	var params state.WatcherParams
+	if period := agentConfig.Value(agent.WatcherPeriod); period != "" {
+		d, err := time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return params, fmt.Errorf("invalid watcher period: %q", period)
+		}
+		params.Period = d
+	}
+	if batchSize := agentConfig.Value(agent.WatcherBatchSize); batchSize != "" {
+		n, err := strconv.Atoi(batchSize)
+		if err != nil || n <= 0 {
+			return params, fmt.Errorf("invalid watcher batch size: %q", batchSize)
+		}
+		params.BatchSize = n
+	}
+	if window := agentConfig.Value(agent.WatcherCoalesceWindow); window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d < 0 {
+			return params, fmt.Errorf("invalid watcher coalesce window: %q", window)
+		}
+		params.CoalesceWindow = d
+	}
+	return params, nil
+}
+
+// environWatcherParams returns params with any watcher settings in
+// the given environment configuration in place of its own.
+func environWatcherParams(cfg *config.Config, params state.WatcherParams) state.WatcherParams {
+	if period, ok := cfg.WatcherPeriod(); ok {
+		params.Period = period
+	}
+	if batchSize, ok := cfg.WatcherBatchSize(); ok {
+		params.BatchSize = batchSize
+	}
+	if window, ok := cfg.WatcherCoalesceWindow(); ok {
+		params.CoalesceWindow = window
+	}
+	return params
+}
+
+// watcherParamsUpdater implements worker.NotifyWatchHandler, applying
+// the watcher settings in the environment configuration to the
+// state's watcher whenever the configuration changes, so that they
+// can be tuned after bootstrap.
+type watcherParamsUpdater struct {
+	st *state.State
+
+	// provisioned holds the watcher settings recorded in the
+	// agent's configuration, which apply where the environment
+	// configuration has none.
+	provisioned state.WatcherParams
+}
+
+func newWatcherParamsUpdater(st *state.State, provisioned state.WatcherParams) worker.Worker {
+	return worker.NewNotifyWorker(&watcherParamsUpdater{
+		st:          st,
+		provisioned: provisioned,
+	})
+}
+
+func (u *watcherParamsUpdater) SetUp() (apiwatcher.NotifyWatcher, error) {
+	return u.st.WatchForEnvironConfigChanges(), nil
+}
+
+func (u *watcherParamsUpdater) Handle() error {
+	cfg, err := u.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	u.st.SetWatcherParams(environWatcherParams(cfg, u.provisioned))
+	return nil
+}
+
+func (u *watcherParamsUpdater) TearDown() error {
+	return nil
+}
+
+// stateWorkersWatcher names the watcher of the state used by the
+// machine agent's state workers, so that it resumes from where it
+// stopped when the agent restarts.
+const stateWorkersWatcher = "state-workers"
+
+// openState opens the state for the agent. If watcherName is not
+// empty, the state's watcher records its position under that name;
+// short-lived connections pass an empty name.
+func openState(agentConfig agent.Config, dialOpts mongo.DialOpts, watcherName string) (_ *state.State, _ *state.Machine, err error) {
 	info, ok := agentConfig.MongoInfo()
 	if !ok {
 		return nil, nil, fmt.Errorf("no state info available")
 	}
-	st, err := state.Open(info, dialOpts, environs.NewStatePolicy())
+	watcherParams, err := newWatcherParams(agentConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	watcherParams.Name = watcherName
+	st, err := state.OpenWithWatcherParams(info, dialOpts, environs.NewStatePolicy(), watcherParams)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -852,3 +1084,54 @@ func (c singularStateConn) IsMaster() (bool, error) {
 func (c singularStateConn) Ping() error {
 	return c.session.Ping()
 }
+
+// errJobsChanged is returned by a jobs watcher when the machine's jobs
+// no longer match those that the agent's workers were started for.
+var errJobsChanged = errors.New("machine jobs changed")
+
+// jobsChangedIsFatal returns an isFatal function for worker.NewRunner
+// that treats errJobsChanged as fatal, in addition to any error that
+// isFatal reports as fatal. The runner then stops, so that it can be
+// restarted with workers that match the machine's new jobs.
+func jobsChangedIsFatal(isFatal func(error) bool) func(error) bool {
+	return func(err error) bool {
+		return err == errJobsChanged || isFatal(err)
+	}
+}
+
+// jobsWatcher implements worker.NotifyWatchHandler, failing with
+// errJobsChanged when the machine's jobs change.
+type jobsWatcher struct {
+	watch       func() (apiwatcher.NotifyWatcher, error)
+	jobsChanged func() (bool, error)
+}
+
+// newJobsWatcher returns a worker that stops with errJobsChanged when,
+// after an event from the watcher returned by watch, jobsChanged reports
+// that the machine's jobs have changed.
+func newJobsWatcher(watch func() (apiwatcher.NotifyWatcher, error), jobsChanged func() (bool, error)) worker.Worker {
+	return worker.NewNotifyWorker(&jobsWatcher{
+		watch:       watch,
+		jobsChanged: jobsChanged,
+	})
+}
+
+func (w *jobsWatcher) SetUp() (apiwatcher.NotifyWatcher, error) {
+	return w.watch()
+}
+
+func (w *jobsWatcher) Handle() error {
+	changed, err := w.jobsChanged()
+	if err != nil {
+		return err
+	}
+	if changed {
+		logger.Infof("machine jobs changed; restarting workers")
+		return errJobsChanged
+	}
+	return nil
+}
+
+func (w *jobsWatcher) TearDown() error {
+	return nil
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -39,6 +40,7 @@ import (
 	apideployer "github.com/juju/juju/state/api/deployer"
 	"github.com/juju/juju/state/api/params"
 	apirsyslog "github.com/juju/juju/state/api/rsyslog"
+	apiwatcher "github.com/juju/juju/state/api/watcher"
 	charmtesting "github.com/juju/juju/state/apiserver/charmrevisionupdater/testing"
 	"github.com/juju/juju/state/watcher"
 	coretesting "github.com/juju/juju/testing"
@@ -400,9 +402,12 @@ func (s *MachineSuite) TestManageEnviron(c *gc.C) {
 		"charm-revision-updater",
 		"cleaner",
 		"environ-provisioner",
+		"eventpublisher",
 		"firewaller",
 		"minunitsworker",
+		"notifier",
 		"resumer",
+		"scheduler",
 	})
 }
 
@@ -1111,3 +1116,171 @@ func newDummyWorker() worker.Worker {
 		return nil
 	})
 }
+
+type WatcherParamsSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&WatcherParamsSuite{})
+
+// valuesAgentConfig is an agent.Config that only
+// implements Value.
+type valuesAgentConfig struct {
+	agent.Config
+	values map[string]string
+}
+
+func (c valuesAgentConfig) Value(key string) string {
+	return c.values[key]
+}
+
+func (s *WatcherParamsSuite) TestNewWatcherParams(c *gc.C) {
+	for i, test := range []struct {
+		values map[string]string
+		params state.WatcherParams
+		err    string
+	}{{
+		params: state.WatcherParams{},
+	}, {
+		values: map[string]string{
+			agent.WatcherPeriod:         "2s",
+			agent.WatcherBatchSize:      "50",
+			agent.WatcherCoalesceWindow: "500ms",
+		},
+		params: state.WatcherParams{
+			Period:         2 * time.Second,
+			BatchSize:      50,
+			CoalesceWindow: 500 * time.Millisecond,
+		},
+	}, {
+		values: map[string]string{agent.WatcherPeriod: "often"},
+		err:    `invalid watcher period: "often"`,
+	}, {
+		values: map[string]string{agent.WatcherBatchSize: "0"},
+		err:    `invalid watcher batch size: "0"`,
+	}, {
+		values: map[string]string{agent.WatcherCoalesceWindow: "-1s"},
+		err:    `invalid watcher coalesce window: "-1s"`,
+	}} {
+		c.Logf("test %d", i)
+		params, err := newWatcherParams(valuesAgentConfig{values: test.values})
+		if test.err != "" {
+			c.Assert(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Assert(params, gc.Equals, test.params)
+	}
+}
+
+func (s *WatcherParamsSuite) TestEnvironWatcherParams(c *gc.C) {
+	provisioned := state.WatcherParams{
+		Period:    2 * time.Second,
+		BatchSize: 50,
+	}
+
+	// Without settings in the environment, the
+	// provisioned settings are used.
+	cfg := coretesting.EnvironConfig(c)
+	params := environWatcherParams(cfg, provisioned)
+	c.Assert(params, gc.Equals, provisioned)
+
+	// Settings in the environment take precedence.
+	cfg = coretesting.CustomEnvironConfig(c, coretesting.Attrs{
+		"watcher-period":          "10s",
+		"watcher-coalesce-window": "500ms",
+	})
+	params = environWatcherParams(cfg, provisioned)
+	c.Assert(params, gc.Equals, state.WatcherParams{
+		Period:         10 * time.Second,
+		BatchSize:      50,
+		CoalesceWindow: 500 * time.Millisecond,
+	})
+}
+
+type JobsWatcherSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&JobsWatcherSuite{})
+
+// fakeNotifyWatcher is a NotifyWatcher that sends
+// events written to its changes channel.
+type fakeNotifyWatcher struct {
+	changes chan struct{}
+}
+
+func (w *fakeNotifyWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+func (w *fakeNotifyWatcher) Stop() error {
+	return nil
+}
+
+func (w *fakeNotifyWatcher) Err() error {
+	return nil
+}
+
+func (s *JobsWatcherSuite) TestStopsWhenJobsChange(c *gc.C) {
+	w := &fakeNotifyWatcher{changes: make(chan struct{}, 1)}
+	watch := func() (apiwatcher.NotifyWatcher, error) {
+		return w, nil
+	}
+	changed := make(chan bool)
+	jw := newJobsWatcher(watch, func() (bool, error) {
+		return <-changed, nil
+	})
+	defer jw.Kill()
+
+	// An event that leaves the jobs unchanged is ignored.
+	for _, jobsChanged := range []bool{false, true} {
+		w.changes <- struct{}{}
+		select {
+		case changed <- jobsChanged:
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for jobs check")
+		}
+	}
+	c.Assert(jw.Wait(), gc.Equals, errJobsChanged)
+}
+
+func (s *JobsWatcherSuite) TestJobsChangedIsFatal(c *gc.C) {
+	isFatal := jobsChangedIsFatal(func(err error) bool {
+		return err.Error() == "fatal"
+	})
+	c.Assert(isFatal(errJobsChanged), jc.IsTrue)
+	c.Assert(isFatal(fmt.Errorf("fatal")), jc.IsTrue)
+	c.Assert(isFatal(fmt.Errorf("not fatal")), jc.IsFalse)
+}
+
+func (s *JobsWatcherSuite) TestAPIServerOnly(c *gc.C) {
+	for i, test := range []struct {
+		jobs   []params.MachineJob
+		expect bool
+	}{
+		{nil, false},
+		{[]params.MachineJob{params.JobHostUnits}, false},
+		{[]params.MachineJob{params.JobManageEnviron}, false},
+		{[]params.MachineJob{params.JobManageAPI}, true},
+		{[]params.MachineJob{params.JobHostUnits, params.JobManageAPI}, true},
+		{[]params.MachineJob{params.JobManageAPI, params.JobManageEnviron}, false},
+	} {
+		c.Logf("test %d: %v", i, test.jobs)
+		c.Check(apiServerOnly(test.jobs), gc.Equals, test.expect)
+	}
+}
+
+func (s *MachineSuite) TestRecordJobs(c *gc.C) {
+	m, _, _ := s.primeAgent(c, version.Current, state.JobHostUnits)
+	a := s.newAgent(c, m)
+	jobs := []params.MachineJob{params.JobHostUnits, params.JobManageAPI}
+	err := a.recordJobs(jobs)
+	c.Assert(err, gc.IsNil)
+	c.Assert(a.CurrentConfig().Jobs(), gc.DeepEquals, jobs)
+
+	// The jobs are written out for when the agent next starts.
+	config, err := agent.ReadConfig(agent.ConfigPath(a.CurrentConfig().DataDir(), m.Tag()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(config.Jobs(), gc.DeepEquals, jobs)
+}
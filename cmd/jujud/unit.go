@@ -14,10 +14,12 @@ import (
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/network"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/apiaddressupdater"
 	workerlogger "github.com/juju/juju/worker/logger"
+	"github.com/juju/juju/worker/logsignal"
 	"github.com/juju/juju/worker/rsyslog"
 	"github.com/juju/juju/worker/uniter"
 	"github.com/juju/juju/worker/upgrader"
@@ -71,12 +73,16 @@ func (a *UnitAgent) Stop() error {
 // Run runs a unit agent.
 func (a *UnitAgent) Run(ctx *cmd.Context) error {
 	defer a.tomb.Done()
+	logsignal.CatchSignals()
 	if err := a.ReadConfig(a.Tag().String()); err != nil {
 		return err
 	}
 	agentLogger.Infof("unit agent %v start (%s [%s])", a.Tag().String(), version.Current, runtime.Compiler)
 	network.InitializeFromConfig(a.CurrentConfig())
 	a.runner.StartWorker("api", a.APIWorkers)
+	a.runner.StartWorker("logsignal", func() (worker.Worker, error) {
+		return newLogSignalWorker(a.CurrentConfig())
+	})
 	err := agentDone(a.runner.Wait())
 	a.tomb.Kill(err)
 	return err
@@ -95,13 +101,13 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 	}
 	runner := worker.NewRunner(connectionIsFatal(st), moreImportant)
 	runner.StartWorker("upgrader", func() (worker.Worker, error) {
-		return upgrader.NewUpgrader(st.Upgrader(), agentConfig), nil
+		return upgrader.NewUpgrader(st.Upgrader(), agentConfig, clock.WallClock), nil
 	})
 	runner.StartWorker("logger", func() (worker.Worker, error) {
 		return workerlogger.NewLogger(st.Logger(), agentConfig), nil
 	})
 	runner.StartWorker("uniter", func() (worker.Worker, error) {
-		return uniter.NewUniter(st.Uniter(), entity.Tag(), dataDir, hookLock), nil
+		return uniter.NewUniter(st.Uniter(), entity.Tag(), dataDir, agentConfig.CACert(), hookLock, clock.WallClock), nil
 	})
 	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
 		return apiaddressupdater.NewAPIAddressUpdater(st.Uniter(), a), nil
@@ -109,6 +115,9 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 	runner.StartWorker("rsyslog", func() (worker.Worker, error) {
 		return newRsyslogConfigWorker(st.Rsyslog(), agentConfig, rsyslog.RsyslogModeForwarding)
 	})
+	runner.StartWorker("passwordrotator", func() (worker.Worker, error) {
+		return newPasswordRotator(st, a), nil
+	})
 	return newCloseWorker(runner, st), nil
 }
 
@@ -205,6 +205,72 @@ func (s *UnitSuite) TestOpenAPIState(c *gc.C) {
 	s.testOpenAPIState(c, unit, s.newAgent(c, unit), initialUnitPassword)
 }
 
+func (s *UnitSuite) TestOpenAPIStateRotatesPasswordWhenRequired(c *gc.C) {
+	_, unit, conf, _ := s.primeAgent(c)
+	err := unit.RequirePasswordRotation()
+	c.Assert(err, gc.IsNil)
+
+	st, _, err := openAPIState(conf, s.newAgent(c, unit))
+	c.Assert(err, gc.IsNil)
+	st.Close()
+
+	// The agent has chosen a new password, keeping
+	// the previous one to fall back on.
+	err = unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.PasswordRotationRequired(), gc.Equals, false)
+	c.Assert(unit.PasswordValid(initialUnitPassword), gc.Equals, false)
+	conf = refreshConfig(c, conf)
+	c.Assert(conf.OldPassword(), gc.Equals, initialUnitPassword)
+	st, _, err = openAPIState(conf, s.newAgent(c, unit))
+	c.Assert(err, gc.IsNil)
+	st.Close()
+}
+
+func (s *UnitSuite) TestPasswordRotatorRotatesWhenRequired(c *gc.C) {
+	s.PatchValue(&passwordRotationCheckInterval, 10*time.Millisecond)
+	_, unit, conf, _ := s.primeAgent(c)
+	a := s.newAgent(c, unit)
+	st, _, err := openAPIState(conf, a)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	w := newPasswordRotator(st, a)
+	defer func() { c.Check(worker.Stop(w), gc.IsNil) }()
+
+	// The running agent changes its password without
+	// reconnecting.
+	err = unit.RequirePasswordRotation()
+	c.Assert(err, gc.IsNil)
+	for attempt := coretesting.LongAttempt.Start(); attempt.Next(); {
+		err := unit.Refresh()
+		c.Assert(err, gc.IsNil)
+		if !unit.PasswordRotationRequired() {
+			break
+		}
+	}
+	c.Assert(unit.PasswordRotationRequired(), gc.Equals, false)
+	c.Assert(unit.PasswordValid(initialUnitPassword), gc.Equals, false)
+	conf = refreshConfig(c, conf)
+	c.Assert(conf.OldPassword(), gc.Equals, initialUnitPassword)
+	c.Assert(unit.PasswordValid(conf.APIInfo().Password), gc.Equals, true)
+}
+
+func (s *UnitSuite) TestOpenAPIStateRecordsEnvironment(c *gc.C) {
+	_, unit, conf, _ := s.primeAgent(c)
+	c.Assert(conf.Environment().Id(), gc.Equals, "")
+
+	st, _, err := openAPIState(conf, s.newAgent(c, unit))
+	c.Assert(err, gc.IsNil)
+	st.Close()
+
+	conf = refreshConfig(c, conf)
+	c.Assert(conf.Environment(), gc.Equals, s.State.EnvironTag())
+	c.Assert(conf.APIInfo().EnvironTag, gc.Equals, s.State.EnvironTag())
+	st, _, err = openAPIState(conf, s.newAgent(c, unit))
+	c.Assert(err, gc.IsNil)
+	st.Close()
+}
+
 func (s *UnitSuite) TestOpenAPIStateWithBadCredsTerminates(c *gc.C) {
 	conf, _ := s.agentSuite.primeAgent(c, names.NewUnitTag("missing/0"), "no-password", version.Current)
 	_, _, err := openAPIState(conf, nil)
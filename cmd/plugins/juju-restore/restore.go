@@ -24,6 +24,7 @@ import (
 	"launchpad.net/gnuflag"
 	"launchpad.net/goyaml"
 
+	"github.com/juju/juju/agent"
 	"github.com/juju/juju/cmd/envcmd"
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environmentserver/authentication"
@@ -136,14 +137,14 @@ var updateBootstrapMachineTemplate = mustParseTemplate(`
 	rm -r /var/log/juju
 
 	tar -C / -xvp -f juju-backup/root.tar
-	mkdir -p /var/lib/juju/db
+	mkdir -p {{.AgentConfig.DBDir | shquote}}
 
 	# Prefer jujud-mongodb binaries if available 
 	export MONGORESTORE=mongorestore
 	if [ -f /usr/lib/juju/bin/mongorestore ]; then
 		export MONGORESTORE=/usr/lib/juju/bin/mongorestore;
 	fi	
-	$MONGORESTORE --drop --dbpath /var/lib/juju/db juju-backup/dump
+	$MONGORESTORE --drop --dbpath {{.AgentConfig.DBDir | shquote}} juju-backup/dump
 
 	initctl start juju-db
 
@@ -399,28 +400,35 @@ type agentConfig struct {
 	Credentials credentials
 	ApiPort     string
 	StatePort   string
+	DBDir       string
 }
 
-func extractConfig(backupFile string) (agentConfig, error) {
+// readBackupFile returns the contents of the named file in the root
+// filesystem archive held in the given backup.
+func readBackupFile(backupFile, name string) ([]byte, error) {
 	f, err := os.Open(backupFile)
 	if err != nil {
-		return agentConfig{}, err
+		return nil, err
 	}
 	defer f.Close()
 	gzr, err := gzip.NewReader(f)
 	if err != nil {
-		return agentConfig{}, fmt.Errorf("cannot unzip %q: %v", backupFile, err)
+		return nil, fmt.Errorf("cannot unzip %q: %v", backupFile, err)
 	}
 	defer gzr.Close()
 	outerTar, err := findFileInTar(gzr, "juju-backup/root.tar")
 	if err != nil {
-		return agentConfig{}, err
+		return nil, err
 	}
-	agentConf, err := findFileInTar(outerTar, "var/lib/juju/agents/machine-0/agent.conf")
+	file, err := findFileInTar(outerTar, name)
 	if err != nil {
-		return agentConfig{}, err
+		return nil, err
 	}
-	data, err := ioutil.ReadAll(agentConf)
+	return ioutil.ReadAll(file)
+}
+
+func extractConfig(backupFile string) (agentConfig, error) {
+	data, err := readBackupFile(backupFile, "var/lib/juju/agents/machine-0/agent.conf")
 	if err != nil {
 		return agentConfig{}, fmt.Errorf("failed to read agent config file: %v", err)
 	}
@@ -440,6 +448,18 @@ func extractConfig(backupFile string) (agentConfig, error) {
 	if !ok || oldPassword == "" {
 		return agentConfig{}, fmt.Errorf("agent old password not found in configuration")
 	}
+	// Backups made before agent config secrets were encrypted have
+	// no secret key, and need none.
+	secretKey, err := readBackupFile(backupFile, "etc/juju/secret.key")
+	if err != nil {
+		secretKey = nil
+	}
+	if password, err = agent.DecryptSecret(secretKey, password); err != nil {
+		return agentConfig{}, fmt.Errorf("cannot decrypt agent password: %v", err)
+	}
+	if oldPassword, err = agent.DecryptSecret(secretKey, oldPassword); err != nil {
+		return agentConfig{}, fmt.Errorf("cannot decrypt agent old password: %v", err)
+	}
 	statePortNum, ok := m["stateport"].(int)
 	if !ok {
 		return agentConfig{}, fmt.Errorf("state port not found in configuration")
@@ -452,6 +472,15 @@ func extractConfig(backupFile string) (agentConfig, error) {
 	}
 	apiPort := strconv.Itoa(apiPortNum)
 
+	// The database is kept in the data directory unless the
+	// environment was bootstrapped with mongo-db-dir.
+	dbDir := "/var/lib/juju/db"
+	if values, ok := m["values"].(map[interface{}]interface{}); ok {
+		if v, ok := values[agent.MongoDBDir].(string); ok && v != "" {
+			dbDir = v
+		}
+	}
+
 	return agentConfig{
 		Credentials: credentials{
 			Tag:         "machine-0",
@@ -460,6 +489,7 @@ func extractConfig(backupFile string) (agentConfig, error) {
 		},
 		StatePort: statePort,
 		ApiPort:   apiPort,
+		DBDir:     dbDir,
 	}, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -276,6 +277,17 @@ func (v *Value) attributesWithValues() (result map[string]interface{}) {
 	return result
 }
 
+// Attributes returns the names of the attributes for which the
+// constraint has a non-nil value, in sorted order.
+func (v *Value) Attributes() []string {
+	var result []string
+	for tag := range v.attributesWithValues() {
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
+}
+
 // hasAny returns any attrTags for which the constraint has a non-nil value.
 func (v *Value) hasAny(attrTags ...string) []string {
 	attrValues := v.attributesWithValues()
@@ -429,6 +429,13 @@ func ctypep(ctype string) *instance.ContainerType {
 	return &res
 }
 
+func (s *ConstraintsSuite) TestAttributes(c *gc.C) {
+	con := constraints.Value{}
+	c.Check(con.Attributes(), gc.HasLen, 0)
+	con = constraints.MustParse("mem=4G arch=amd64 tags=")
+	c.Check(con.Attributes(), gc.DeepEquals, []string{"arch", "mem", "tags"})
+}
+
 type roundTrip struct {
 	Name  string
 	Value constraints.Value
@@ -4,6 +4,8 @@
 package downloader
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -25,11 +27,47 @@ type Status struct {
 	Err error
 }
 
+// Verification describes how to verify the server that a file is
+// downloaded from.
+type Verification struct {
+	// HostnameVerification specifies whether servers other than the
+	// API servers are verified against the system roots.
+	HostnameVerification utils.SSLHostnameVerification
+
+	// FromAPIServer is true if the file is served by an API server,
+	// whose certificate is instead verified against the environment's
+	// CA certificate.
+	FromAPIServer bool
+}
+
+// HTTPClient returns an HTTP client that verifies servers as
+// described by v, using the given CA certificate for API servers.
+func (v Verification) HTTPClient(caCert string) (*http.Client, error) {
+	if !v.FromAPIServer {
+		return utils.GetHTTPClient(v.HostnameVerification), nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caCert)) {
+		return nil, fmt.Errorf("cannot parse CA certificate")
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				RootCAs: pool,
+				// API server certificates are not issued
+				// for any particular host name.
+				ServerName: "anything",
+			},
+		},
+	}, nil
+}
+
 // Download can download a file from the network.
 type Download struct {
-	tomb                 tomb.Tomb
-	done                 chan Status
-	hostnameVerification utils.SSLHostnameVerification
+	tomb   tomb.Tomb
+	done   chan Status
+	client *http.Client
 }
 
 // New returns a new Download instance downloading from the given URL
@@ -37,9 +75,15 @@ type Download struct {
 // os.TempDir(). If disableSSLHostnameVerification is true then a non-
 // validating http client will be used.
 func New(url, dir string, hostnameVerification utils.SSLHostnameVerification) *Download {
+	return NewWithClient(url, dir, utils.GetHTTPClient(hostnameVerification))
+}
+
+// NewWithClient is like New, but downloads using the given
+// HTTP client.
+func NewWithClient(url, dir string, client *http.Client) *Download {
 	d := &Download{
-		done:                 make(chan Status),
-		hostnameVerification: hostnameVerification,
+		done:   make(chan Status),
+		client: client,
 	}
 	go d.run(url, dir)
 	return d
@@ -63,7 +107,7 @@ func (d *Download) run(url, dir string) {
 	// TODO(dimitern) 2013-10-03 bug #1234715
 	// Add a testing HTTPS storage to verify the
 	// disableSSLHostnameVerification behavior here.
-	file, err := download(url, dir, d.hostnameVerification)
+	file, err := download(url, dir, d.client)
 	if err != nil {
 		err = fmt.Errorf("cannot download %q: %v", url, err)
 	}
@@ -78,7 +122,7 @@ func (d *Download) run(url, dir string) {
 	}
 }
 
-func download(url, dir string, hostnameVerification utils.SSLHostnameVerification) (file *os.File, err error) {
+func download(url, dir string, client *http.Client) (file *os.File, err error) {
 	if dir == "" {
 		dir = os.TempDir()
 	}
@@ -92,7 +136,6 @@ func download(url, dir string, hostnameVerification utils.SSLHostnameVerificatio
 		}
 	}()
 	// TODO(rog) make the download operation interruptible.
-	client := utils.GetHTTPClient(hostnameVerification)
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, err
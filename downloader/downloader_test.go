@@ -103,3 +103,16 @@ func assertFileContents(c *gc.C, f *os.File, expect string) {
 		c.Logf("info %#v", info)
 	}
 }
+
+func (s *suite) TestVerificationHTTPClient(c *gc.C) {
+	client, err := downloader.Verification{HostnameVerification: utils.VerifySSLHostnames}.HTTPClient("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(client, gc.NotNil)
+
+	v := downloader.Verification{FromAPIServer: true}
+	client, err = v.HTTPClient(testing.CACert)
+	c.Assert(err, gc.IsNil)
+	c.Assert(client, gc.NotNil)
+	_, err = v.HTTPClient("rubbish")
+	c.Assert(err, gc.ErrorMatches, "cannot parse CA certificate")
+}
@@ -64,6 +64,10 @@ func NewAPIAuthenticator(st *apiprovisioner.State) (AuthenticationProvider, erro
 	if err != nil {
 		return nil, err
 	}
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
 	stateInfo := &MongoInfo{
 		Info: mongo.Info{
 			Addrs:  stateAddresses,
@@ -74,6 +78,9 @@ func NewAPIAuthenticator(st *apiprovisioner.State) (AuthenticationProvider, erro
 		Addrs:  apiAddresses,
 		CACert: caCert,
 	}
+	if uuid, ok := envConfig.UUID(); ok {
+		apiInfo.EnvironTag = names.NewEnvironTag(uuid)
+	}
 	return &simpleAuth{stateInfo, apiInfo}, nil
 }
 
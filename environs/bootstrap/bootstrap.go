@@ -46,8 +46,12 @@ func Bootstrap(ctx environs.BootstrapContext, environ environs.Environ, args env
 		return err
 	}
 	logger.Debugf("environment %q supports service/machine networks: %v", cfg.Name(), environ.SupportNetworks())
-	logger.Infof("bootstrapping environment %q", cfg.Name())
-	return environ.Bootstrap(ctx, args)
+	ctx.Infof("Bootstrapping environment %q", cfg.Name())
+	if err := environ.Bootstrap(ctx, args); err != nil {
+		return err
+	}
+	ctx.Infof("Bootstrap complete")
+	return nil
 }
 
 // SetBootstrapTools returns the newest tools from the given tools list,
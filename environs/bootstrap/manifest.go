@@ -0,0 +1,93 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package bootstrap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/storage"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/juju/osenv"
+)
+
+// Manifest records the resources left in place by a failed bootstrap
+// that was asked to keep them for debugging, so that they can be
+// cleaned up when the environment is destroyed.
+type Manifest struct {
+	// Instances holds the ids of the instances that were started.
+	Instances []instance.Id `yaml:"instances,omitempty"`
+
+	// StorageFiles holds the names of the files written to the
+	// environment's storage.
+	StorageFiles []string `yaml:"storage-files,omitempty"`
+}
+
+// ManifestPath returns the path of the bootstrap manifest for the
+// named environment, which is kept alongside its .jenv file.
+func ManifestPath(envName string) string {
+	return osenv.JujuHomePath("environments", envName+".bootstrap-manifest")
+}
+
+// RecordManifest writes a bootstrap manifest of the instances and
+// storage files currently in the given environment, and returns it.
+func RecordManifest(environ environs.Environ) (*Manifest, error) {
+	var manifest Manifest
+	insts, err := environ.AllInstances()
+	if err != nil && err != environs.ErrNoInstances {
+		return nil, errors.Annotate(err, "cannot list instances")
+	}
+	for _, inst := range insts {
+		manifest.Instances = append(manifest.Instances, inst.Id())
+	}
+	manifest.StorageFiles, err = storage.List(environ.Storage(), "")
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot list storage")
+	}
+	data, err := goyaml.Marshal(&manifest)
+	if err != nil {
+		return nil, err
+	}
+	path := ManifestPath(environ.Config().Name())
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := utils.AtomicWriteFile(path, data, 0600); err != nil {
+		return nil, errors.Annotate(err, "cannot write bootstrap manifest")
+	}
+	return &manifest, nil
+}
+
+// ReadManifest reads the bootstrap manifest for the named
+// environment. It returns a not found error if there is none.
+func ReadManifest(envName string) (*Manifest, error) {
+	path := ManifestPath(envName)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.NotFoundf("bootstrap manifest for environment %q", envName)
+	} else if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := goyaml.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Annotatef(err, "cannot parse %q", path)
+	}
+	return &manifest, nil
+}
+
+// RemoveManifest removes the bootstrap manifest for the named
+// environment, if there is one.
+func RemoveManifest(envName string) error {
+	err := os.Remove(ManifestPath(envName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
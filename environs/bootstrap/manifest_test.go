@@ -0,0 +1,67 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package bootstrap_test
+
+import (
+	"os"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/bootstrap"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/configstore"
+	envtesting "github.com/juju/juju/environs/testing"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/dummy"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type manifestSuite struct {
+	coretesting.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&manifestSuite{})
+
+func (s *manifestSuite) TearDownTest(c *gc.C) {
+	dummy.Reset()
+	s.FakeJujuHomeSuite.TearDownTest(c)
+}
+
+func (s *manifestSuite) TestRecordManifest(c *gc.C) {
+	cfg, err := config.New(config.NoDefaults, dummy.SampleConfig())
+	c.Assert(err, gc.IsNil)
+	env, err := environs.Prepare(cfg, coretesting.Context(c), configstore.NewMem())
+	c.Assert(err, gc.IsNil)
+	envtesting.UploadFakeTools(c, env.Storage())
+	err = bootstrap.Bootstrap(coretesting.Context(c), env, environs.BootstrapParams{})
+	c.Assert(err, gc.IsNil)
+
+	manifest, err := bootstrap.RecordManifest(env)
+	c.Assert(err, gc.IsNil)
+	c.Assert(manifest.Instances, gc.DeepEquals, []instance.Id{dummy.BootstrapInstanceId})
+	c.Assert(manifest.StorageFiles, gc.Not(gc.HasLen), 0)
+
+	read, err := bootstrap.ReadManifest(env.Config().Name())
+	c.Assert(err, gc.IsNil)
+	c.Assert(read, gc.DeepEquals, manifest)
+
+	err = bootstrap.RemoveManifest(env.Config().Name())
+	c.Assert(err, gc.IsNil)
+	_, err = os.Stat(bootstrap.ManifestPath(env.Config().Name()))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *manifestSuite) TestReadManifestNotFound(c *gc.C) {
+	_, err := bootstrap.ReadManifest("nowhere")
+	c.Assert(err, gc.ErrorMatches, `bootstrap manifest for environment "nowhere" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *manifestSuite) TestRemoveManifestNotFound(c *gc.C) {
+	err := bootstrap.RemoveManifest("nowhere")
+	c.Assert(err, gc.IsNil)
+}
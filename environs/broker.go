@@ -37,6 +37,12 @@ type StartInstanceParams struct {
 	// this information to distribute instances for
 	// high availability.
 	DistributionGroup func() ([]instance.Id, error)
+
+	// IdempotencyToken, if non-empty, identifies this request
+	// to start an instance. A broker that implements
+	// IdempotentStarter returns the instance already started
+	// with the same token, if any, rather than starting another.
+	IdempotencyToken string
 }
 
 // TODO(wallyworld) - we want this in the environs/instance package but import loops
@@ -57,3 +63,13 @@ type InstanceBroker interface {
 	// AllInstances returns all instances currently known to the broker.
 	AllInstances() ([]instance.Instance, error)
 }
+
+// IdempotentStarter is implemented by an InstanceBroker that honours
+// StartInstanceParams.IdempotencyToken, so that a StartInstance call
+// that failed, perhaps by timing out after the instance was started,
+// may be safely retried.
+type IdempotentStarter interface {
+	// StartInstanceIsIdempotent reports whether StartInstance
+	// honours idempotency tokens.
+	StartInstanceIsIdempotent() bool
+}
@@ -6,6 +6,7 @@ package environs
 import (
 	"fmt"
 	"path"
+	"strconv"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -128,9 +129,13 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 		return err
 	}
 
-	// The following settings are only appropriate at bootstrap time. At the
-	// moment, the only state server is the bootstrap node, but this
-	// will probably change.
+	// Every state server needs the mongo settings, including those
+	// started later by ensure-availability.
+	if mcfg.Bootstrap || wantsJob(mcfg, params.JobManageEnviron) {
+		setStateServerAgentEnvironment(mcfg, cfg)
+	}
+
+	// The following settings are only appropriate at bootstrap time.
 	if !mcfg.Bootstrap {
 		return nil
 	}
@@ -147,6 +152,9 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 	}
 	passwordHash := utils.UserPasswordHash(password, utils.CompatSalt)
 	mcfg.APIInfo = &api.Info{Password: passwordHash, CACert: caCert}
+	if uuid, ok := cfg.UUID(); ok {
+		mcfg.APIInfo.EnvironTag = names.NewEnvironTag(uuid)
+	}
 	mcfg.MongoInfo = &authentication.MongoInfo{Password: passwordHash, Info: mongo.Info{CACert: caCert}}
 
 	// These really are directly relevant to running a state server.
@@ -164,6 +172,7 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 	}
 	mcfg.StateServingInfo = &srvInfo
 	mcfg.Constraints = cons
+
 	if mcfg.Config, err = BootstrapConfig(cfg); err != nil {
 		return err
 	}
@@ -171,7 +180,44 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 	return nil
 }
 
+// wantsJob reports whether the machine will run the given job.
+func wantsJob(mcfg *cloudinit.MachineConfig, job params.MachineJob) bool {
+	for _, j := range mcfg.Jobs {
+		if j == job {
+			return true
+		}
+	}
+	return false
+}
+
+// setStateServerAgentEnvironment records the mongo and watcher
+// settings in the agent config, where the machine agent finds them
+// when it starts mongod and opens state.
+func setStateServerAgentEnvironment(mcfg *cloudinit.MachineConfig, cfg *config.Config) {
+	if oplogSize, ok := cfg.MongoOplogSize(); ok {
+		mcfg.AgentEnvironment[agent.MongoOplogSize] = strconv.Itoa(oplogSize)
+	}
+	if cacheSize, ok := cfg.MongoCacheSize(); ok {
+		mcfg.AgentEnvironment[agent.MongoCacheSize] = strconv.Itoa(cacheSize)
+	}
+	if dbDir := cfg.MongoDBDir(); dbDir != "" {
+		mcfg.AgentEnvironment[agent.MongoDBDir] = dbDir
+	}
+	if period, ok := cfg.WatcherPeriod(); ok {
+		mcfg.AgentEnvironment[agent.WatcherPeriod] = period.String()
+	}
+	if batchSize, ok := cfg.WatcherBatchSize(); ok {
+		mcfg.AgentEnvironment[agent.WatcherBatchSize] = strconv.Itoa(batchSize)
+	}
+	if window, ok := cfg.WatcherCoalesceWindow(); ok {
+		mcfg.AgentEnvironment[agent.WatcherCoalesceWindow] = window.String()
+	}
+}
+
 func configureCloudinit(mcfg *cloudinit.MachineConfig, cloudcfg *coreCloudinit.Config) error {
+	if mcfg.IsWindows() {
+		return cloudinit.ConfigureWindows(mcfg, cloudcfg)
+	}
 	// When bootstrapping, we only want to apt-get update/upgrade
 	// and setup the SSH keys. The rest we leave to cloudinit/sshinit.
 	if mcfg.Bootstrap {
@@ -185,17 +231,50 @@ func configureCloudinit(mcfg *cloudinit.MachineConfig, cloudcfg *coreCloudinit.C
 // and then renders it and returns it as a binary (gzipped) blob of user data.
 //
 // If the provided cloudcfg is nil, a new one will be created internally.
+// Machines running Windows are given a PowerShell script rather than a
+// cloud-init configuration, and use the directories standard for
+// Windows rather than those in mcfg.
 func ComposeUserData(mcfg *cloudinit.MachineConfig, cloudcfg *coreCloudinit.Config) ([]byte, error) {
 	if cloudcfg == nil {
 		cloudcfg = coreCloudinit.New()
 	}
+	render := cloudcfg.Render
+	if mcfg.IsWindows() {
+		winMcfg, err := windowsMachineConfig(mcfg)
+		if err != nil {
+			return nil, err
+		}
+		mcfg = winMcfg
+		render = func() ([]byte, error) {
+			return cloudinit.RenderWindows(cloudcfg)
+		}
+	}
 	if err := configureCloudinit(mcfg, cloudcfg); err != nil {
 		return nil, err
 	}
-	data, err := cloudcfg.Render()
+	data, err := render()
 	logger.Tracef("Generated cloud init:\n%s", string(data))
 	if err != nil {
 		return nil, err
 	}
 	return utils.Gzip(data), nil
 }
+
+// windowsMachineConfig returns a copy of mcfg using the directories
+// of the Windows series that mcfg's tools are for.
+func windowsMachineConfig(mcfg *cloudinit.MachineConfig) (*cloudinit.MachineConfig, error) {
+	series := mcfg.Tools.Version.Series
+	dataDir, err := paths.DataDir(series)
+	if err != nil {
+		return nil, err
+	}
+	logDir, err := paths.LogDir(series)
+	if err != nil {
+		return nil, err
+	}
+	winMcfg := *mcfg
+	winMcfg.DataDir = dataDir
+	winMcfg.LogDir = logDir
+	winMcfg.CloudInitOutputLog = path.Join(logDir, "cloud-init-output.log")
+	return &winMcfg, nil
+}
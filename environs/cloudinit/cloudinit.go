@@ -396,6 +396,11 @@ func (cfg *MachineConfig) agentConfig(tag names.Tag) (agent.ConfigSetter, error)
 		Values:            cfg.AgentEnvironment,
 		PreferIPv6:        cfg.PreferIPv6,
 	}
+	if cfg.APIInfo != nil {
+		if environTag, ok := cfg.APIInfo.EnvironTag.(names.EnvironTag); ok {
+			configParams.Environment = environTag
+		}
+	}
 	if !cfg.Bootstrap {
 		return agent.NewAgentConfig(configParams)
 	}
@@ -410,10 +415,17 @@ func (cfg *MachineConfig) addAgentInfo(c *cloudinit.Config, tag names.Tag) (agen
 		return nil, err
 	}
 	acfg.SetValue(agent.AgentServiceName, cfg.MachineAgentServiceName)
-	cmds, err := acfg.WriteCommands()
+	cmds, err := acfg.WriteCommands(cfg.Tools.Version.Series)
 	if err != nil {
 		return nil, errors.Annotate(err, "failed to write commands")
 	}
+	// The agent encrypts the secrets in its config with the
+	// machine's secret key when it first starts.
+	keyCmds, err := agent.SecretKeyCommands(cfg.Tools.Version.Series)
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to create secret key commands")
+	}
+	c.AddScripts(keyCmds...)
 	c.AddScripts(cmds...)
 	return acfg, nil
 }
@@ -804,3 +804,133 @@ Param (
 "@
 
 `
+
+// winToolsHelperFunctions defines the PowerShell functions used to
+// verify and unpack the tools on Windows machines, which have neither
+// sha256sum nor tar.
+var winToolsHelperFunctions = `
+
+function Get-FileSHA256([string]$FilePath)
+{
+    $hash = [Security.Cryptography.HashAlgorithm]::Create("SHA256")
+    $stream = ([IO.StreamReader]$FilePath).BaseStream
+    try
+    {
+        return -join ($hash.ComputeHash($stream) | ForEach { "{0:x2}" -f $_ })
+    }
+    finally
+    {
+        $stream.Close()
+    }
+}
+
+$TarSource = @"
+using System;
+using System.IO;
+using System.IO.Compression;
+using System.Text;
+
+namespace Juju
+{
+    public static class Tar
+    {
+        // ExtractGzip extracts the directories and regular files
+        // of the gzipped tar archive at path into dir.
+        public static void ExtractGzip(string path, string dir)
+        {
+            using (var file = File.OpenRead(path))
+            using (var gz = new GZipStream(file, CompressionMode.Decompress))
+            {
+                var header = new byte[512];
+                while (ReadBlock(gz, header))
+                {
+                    string name = ReadString(header, 0, 100);
+                    if (name.Length == 0)
+                    {
+                        // The end of the archive.
+                        return;
+                    }
+                    string prefix = ReadString(header, 345, 155);
+                    if (prefix.Length > 0)
+                    {
+                        name = prefix + "/" + name;
+                    }
+                    long size = Convert.ToInt64(ReadString(header, 124, 12).Trim(), 8);
+                    char type = (char)header[156];
+                    string target = Path.Combine(dir, name.Replace('/', Path.DirectorySeparatorChar));
+                    if (type == '5')
+                    {
+                        Directory.CreateDirectory(target);
+                    }
+                    else if (type == '0' || type == '\0')
+                    {
+                        Directory.CreateDirectory(Path.GetDirectoryName(target));
+                        using (var output = File.Create(target))
+                        {
+                            Copy(gz, output, size);
+                        }
+                    }
+                    else
+                    {
+                        Copy(gz, Stream.Null, size);
+                    }
+                    Copy(gz, Stream.Null, (512 - size % 512) % 512);
+                }
+            }
+        }
+
+        static bool ReadBlock(Stream input, byte[] block)
+        {
+            int total = 0;
+            while (total < block.Length)
+            {
+                int n = input.Read(block, total, block.Length - total);
+                if (n == 0)
+                {
+                    if (total == 0)
+                    {
+                        return false;
+                    }
+                    throw new EndOfStreamException("truncated tar header");
+                }
+                total += n;
+            }
+            return true;
+        }
+
+        static string ReadString(byte[] block, int offset, int length)
+        {
+            int end = offset;
+            while (end < offset + length && block[end] != 0)
+            {
+                end++;
+            }
+            return Encoding.ASCII.GetString(block, offset, end - offset);
+        }
+
+        static void Copy(Stream input, Stream output, long n)
+        {
+            var buf = new byte[32 * 1024];
+            while (n > 0)
+            {
+                int read = input.Read(buf, 0, (int)Math.Min(buf.Length, n));
+                if (read == 0)
+                {
+                    throw new EndOfStreamException("truncated tar entry");
+                }
+                output.Write(buf, 0, read);
+                n -= read;
+            }
+        }
+    }
+}
+"@
+
+Add-Type -TypeDefinition $TarSource -Language CSharp
+
+function Expand-TarGz([string]$Path, [string]$Destination)
+{
+    [Juju.Tar]::ExtractGzip($Path, $Destination)
+}
+
+`
@@ -0,0 +1,155 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudinit
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+
+	agenttools "github.com/juju/juju/agent/tools"
+	"github.com/juju/juju/cloudinit"
+	"github.com/juju/juju/juju/paths"
+	"github.com/juju/juju/service/windows"
+	"github.com/juju/juju/version"
+)
+
+// windowsUserDataHeader tells cloudbase-init, which initialises
+// Windows images, to run the user data as a PowerShell script.
+const windowsUserDataHeader = "#ps1_sysnative\r\n"
+
+// IsWindows reports whether the machine being configured runs Windows.
+func (cfg *MachineConfig) IsWindows() bool {
+	if cfg.Tools == nil {
+		return false
+	}
+	targetOS, err := version.GetOSFromSeries(cfg.Tools.Version.Series)
+	return err == nil && targetOS == version.Windows
+}
+
+// ConfigureWindows fills out c with the PowerShell commands that
+// initialise a Windows machine with the given configuration: they
+// create the jujud user that agents run as, fetch the tools, write
+// the agent configuration and install the machine agent as a service.
+// Windows machines cannot be state servers, so cfg must not be for
+// bootstrapping.
+func ConfigureWindows(cfg *MachineConfig, c *cloudinit.Config) error {
+	if err := verifyConfig(cfg); err != nil {
+		return err
+	}
+	if cfg.Bootstrap {
+		return fmt.Errorf("cannot bootstrap a Windows machine")
+	}
+	series := cfg.Tools.Version.Series
+	tmpDir, err := paths.TempDir(series)
+	if err != nil {
+		return err
+	}
+	jujuRun, err := paths.JujuRun(series)
+	if err != nil {
+		return err
+	}
+	binDir := path.Dir(jujuRun)
+	baseDir := path.Dir(binDir)
+
+	c.AddScripts(
+		winPowershellHelperFunctions,
+		winToolsHelperFunctions,
+		"mkdir -Force "+psquote(binDir),
+		"mkdir -Force "+psquote(tmpDir),
+		"mkdir -Force "+psquote(cfg.DataDir),
+		"mkdir -Force "+psquote(path.Join(cfg.DataDir, "locks")),
+		"mkdir -Force "+psquote(cfg.LogDir),
+		fmt.Sprintf(`icacls %s /grant "jujud:(OI)(CI)(F)" /T`, psquote(winPath(baseDir))),
+		// Store the jujud user's password where only that user
+		// can read it, so that the machine agent can install unit
+		// agent services to run as the same user.
+		winSetPasswdScript,
+		`Start-ProcessAsUser -Command $powershell -Arguments "-File C:\juju\bin\save_pass.ps1 $juju_passwd" -Credential $jujuCreds`,
+	)
+
+	// Make a directory for the tools to live in, then fetch the
+	// tools and unarchive them into it.
+	toolsJson, err := json.Marshal(cfg.Tools)
+	if err != nil {
+		return err
+	}
+	c.AddScripts(
+		"$binDir = "+psquote(cfg.jujuTools()),
+		"mkdir -Force $binDir",
+	)
+	if strings.HasPrefix(cfg.Tools.URL, fileSchemePrefix) {
+		c.AddScripts(fmt.Sprintf(`Copy-Item %s "$binDir\tools.tar.gz"`,
+			psquote(cfg.Tools.URL[len(fileSchemePrefix):])))
+	} else {
+		if cfg.DisableSSLHostnameVerification {
+			c.AddScripts(`[System.Net.ServicePointManager]::ServerCertificateValidationCallback = {$true}`)
+		}
+		c.AddScripts(
+			`$WebClient = New-Object System.Net.WebClient`,
+			fmt.Sprintf(`ExecRetry { $WebClient.DownloadFile(%s, "$binDir\tools.tar.gz") }`,
+				psquote(cfg.Tools.URL)),
+		)
+	}
+	c.AddScripts(
+		`$dToolsHash = Get-FileSHA256 "$binDir\tools.tar.gz"`,
+		fmt.Sprintf(`if ($dToolsHash -ne %s) { Throw "Tools checksum mismatch" }`,
+			psquote(strings.ToLower(cfg.Tools.SHA256))),
+		`Expand-TarGz "$binDir\tools.tar.gz" $binDir`,
+		`rm "$binDir\tools.tar.gz"`,
+		fmt.Sprintf(`Set-Content "$binDir\downloaded-tools.txt" %s`, psquote(string(toolsJson))),
+	)
+
+	machineTag := names.NewMachineTag(cfg.MachineId)
+	if _, err := cfg.addAgentInfo(c, machineTag); err != nil {
+		return err
+	}
+	return cfg.addWindowsMachineAgentToBoot(c, machineTag.String())
+}
+
+func (cfg *MachineConfig) addWindowsMachineAgentToBoot(c *cloudinit.Config, tag string) error {
+	// Make the agent run via a symbolic link to the actual tools
+	// directory, so it can upgrade itself without needing to change
+	// the service.
+	toolsDir := agenttools.ToolsDir(cfg.DataDir, tag)
+	c.AddScripts(fmt.Sprintf(`cmd.exe /C mklink /D %s %v`, winPath(toolsDir), cfg.Tools.Version))
+
+	svc := windows.MachineAgentWindowsService(
+		cfg.MachineAgentServiceName, toolsDir, cfg.DataDir, cfg.LogDir, tag, cfg.MachineId)
+	cmds, err := svc.InstallCommands()
+	if err != nil {
+		return errors.Annotatef(err, "cannot make cloud-init service for the %s agent", tag)
+	}
+	c.AddScripts(cmds...)
+	return nil
+}
+
+// RenderWindows renders c, as filled out by ConfigureWindows, as user
+// data for a Windows image.
+func RenderWindows(c *cloudinit.Config) ([]byte, error) {
+	var buf []string
+	for _, cmd := range c.RunCmds() {
+		script, ok := cmd.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot render command %v for Windows", cmd)
+		}
+		buf = append(buf, script)
+	}
+	return []byte(windowsUserDataHeader + strings.Join(buf, "\r\n") + "\r\n"), nil
+}
+
+// psquote quotes p so that PowerShell interprets it literally.
+func psquote(p string) string {
+	return "'" + strings.Replace(p, "'", "''", -1) + "'"
+}
+
+// winPath converts p to use Windows path separators, for the benefit
+// of commands that do not accept forward slashes.
+func winPath(p string) string {
+	return strings.Replace(p, "/", `\`, -1)
+}
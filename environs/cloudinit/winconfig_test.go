@@ -0,0 +1,141 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cloudinit_test
+
+import (
+	"strings"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/agent"
+	coreCloudinit "github.com/juju/juju/cloudinit"
+	"github.com/juju/juju/environmentserver/authentication"
+	"github.com/juju/juju/environs/cloudinit"
+	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/testing"
+)
+
+type winConfigSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&winConfigSuite{})
+
+func windowsMachineConfig() *cloudinit.MachineConfig {
+	return &cloudinit.MachineConfig{
+		MachineId:          "99",
+		AgentEnvironment:   map[string]string{agent.ProviderType: "dummy"},
+		DataDir:            "C:/Juju/lib/juju",
+		LogDir:             "C:/Juju/log",
+		Jobs:               normalMachineJobs,
+		CloudInitOutputLog: "C:/Juju/log/cloud-init-output.log",
+		Tools:              newSimpleTools("1.2.3-win2012-amd64"),
+		MachineNonce:       "FAKE_NONCE",
+		MongoInfo: &authentication.MongoInfo{
+			Tag:      names.NewMachineTag("99"),
+			Password: "arble",
+			Info: mongo.Info{
+				Addrs:  []string{"state-addr.testing.invalid:12345"},
+				CACert: "CA CERT\n" + testing.CACert,
+			},
+		},
+		APIInfo: &api.Info{
+			Addrs:    []string{"state-addr.testing.invalid:54321"},
+			Tag:      names.NewMachineTag("99"),
+			Password: "bletch",
+			CACert:   "CA CERT\n" + testing.CACert,
+		},
+		MachineAgentServiceName: "jujud-machine-99",
+	}
+}
+
+func (*winConfigSuite) TestIsWindows(c *gc.C) {
+	cfg := windowsMachineConfig()
+	c.Assert(cfg.IsWindows(), jc.IsTrue)
+	cfg.Tools = newSimpleTools("1.2.3-quantal-amd64")
+	c.Assert(cfg.IsWindows(), jc.IsFalse)
+	cfg.Tools = nil
+	c.Assert(cfg.IsWindows(), jc.IsFalse)
+}
+
+func (*winConfigSuite) TestConfigureWindows(c *gc.C) {
+	ci := coreCloudinit.New()
+	err := cloudinit.ConfigureWindows(windowsMachineConfig(), ci)
+	c.Assert(err, gc.IsNil)
+	data, err := cloudinit.RenderWindows(ci)
+	c.Assert(err, gc.IsNil)
+	script := string(data)
+	c.Assert(strings.HasPrefix(script, "#ps1_sysnative\r\n"), jc.IsTrue)
+	c.Assert(script, gc.Not(jc.Contains), "\napt-get")
+
+	for _, line := range []string{
+		`create-account jujud "Juju Admin user" $juju_passwd`,
+		`mkdir -Force 'C:/Juju/lib/juju/locks'`,
+		`icacls 'C:\Juju' /grant "jujud:(OI)(CI)(F)" /T`,
+		`$binDir = 'C:/Juju/lib/juju/tools/1.2.3-win2012-amd64'`,
+		`ExecRetry { $WebClient.DownloadFile('http://foo.com/tools/releases/juju1.2.3-win2012-amd64.tgz', "$binDir\tools.tar.gz") }`,
+		`if ($dToolsHash -ne '1234') { Throw "Tools checksum mismatch" }`,
+		`Expand-TarGz "$binDir\tools.tar.gz" $binDir`,
+		`if (-not (Test-Path 'C:\ProgramData\Juju\secret.key')) {`,
+		`icacls 'C:\ProgramData\Juju' /inheritance:r /grant:r "jujud:(OI)(CI)(F)" "SYSTEM:(OI)(CI)(F)"`,
+		`[System.IO.File]::WriteAllBytes('C:\ProgramData\Juju\secret.key', $secretKey)`,
+		`mkdir -Force 'C:/Juju/lib/juju/agents/machine-99'`,
+		`Set-Content 'C:/Juju/lib/juju/agents/machine-99/agent.conf' @'`,
+		`cmd.exe /C mklink /D C:\Juju\lib\juju\tools\machine-99 1.2.3-win2012-amd64`,
+		`New-Service -Credential $jujuCreds -Name 'jujud-machine-99' -DisplayName 'juju machine-99 agent' '"C:/Juju/lib/juju/tools/machine-99/jujud.exe" machine --data-dir "C:/Juju/lib/juju" --machine-id 99 --debug'`,
+		`Start-Service 'jujud-machine-99'`,
+	} {
+		c.Check(script, jc.Contains, line)
+	}
+	// No shell commands are emitted.
+	c.Assert(script, gc.Not(jc.Contains), "umask")
+	// The certificate is not skipped unless asked.
+	c.Assert(script, gc.Not(jc.Contains), "ServerCertificateValidationCallback = {$true}")
+}
+
+func (*winConfigSuite) TestConfigureWindowsDisableSSLHostnameVerification(c *gc.C) {
+	cfg := windowsMachineConfig()
+	cfg.DisableSSLHostnameVerification = true
+	ci := coreCloudinit.New()
+	err := cloudinit.ConfigureWindows(cfg, ci)
+	c.Assert(err, gc.IsNil)
+	data, err := cloudinit.RenderWindows(ci)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), jc.Contains, "ServerCertificateValidationCallback = {$true}")
+}
+
+func (*winConfigSuite) TestConfigureWindowsFileTools(c *gc.C) {
+	cfg := windowsMachineConfig()
+	cfg.Tools = newFileTools("1.2.3-win2012-amd64", "C:/tools.tgz")
+	ci := coreCloudinit.New()
+	err := cloudinit.ConfigureWindows(cfg, ci)
+	c.Assert(err, gc.IsNil)
+	data, err := cloudinit.RenderWindows(ci)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), jc.Contains, `Copy-Item 'C:/tools.tgz' "$binDir\tools.tar.gz"`)
+	c.Assert(string(data), gc.Not(jc.Contains), "DownloadFile")
+}
+
+func (*winConfigSuite) TestConfigureWindowsBootstrap(c *gc.C) {
+	cfg := windowsMachineConfig()
+	cfg.Bootstrap = true
+	cfg.InstanceId = "i-bootstrap"
+	cfg.SystemPrivateSSHKey = "private rsa key"
+	cfg.StateServingInfo = stateServingInfo
+	cfg.MongoInfo.Tag = nil
+	cfg.APIInfo.Tag = nil
+	cfg.Config = minimalConfig(c)
+	err := cloudinit.ConfigureWindows(cfg, coreCloudinit.New())
+	c.Assert(err, gc.ErrorMatches, "cannot bootstrap a Windows machine")
+}
+
+func (*winConfigSuite) TestRenderWindowsArgs(c *gc.C) {
+	ci := coreCloudinit.New()
+	ci.AddRunCmdArgs("echo", "hello")
+	_, err := cloudinit.RenderWindows(ci)
+	c.Assert(err, gc.ErrorMatches, `cannot render command \[echo hello\] for Windows`)
+}
@@ -4,6 +4,7 @@
 package environs_test
 
 import (
+	"strings"
 	"time"
 
 	"github.com/juju/names"
@@ -117,8 +118,11 @@ func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	c.Check(mcfg.AuthorizedKeys, gc.Equals, "we-are-the-keys")
 	c.Check(mcfg.DisableSSLHostnameVerification, jc.IsFalse)
 	password := utils.UserPasswordHash("lisboan-pork", utils.CompatSalt)
+	uuid, ok := cfg.UUID()
+	c.Assert(ok, jc.IsTrue)
 	c.Check(mcfg.APIInfo, gc.DeepEquals, &api.Info{
 		Password: password, CACert: testing.CACert,
+		EnvironTag: names.NewEnvironTag(uuid),
 	})
 	c.Check(mcfg.MongoInfo, gc.DeepEquals, &authentication.MongoInfo{
 		Password: password, Info: mongo.Info{CACert: testing.CACert},
@@ -143,6 +147,63 @@ func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	c.Assert(err, gc.NotNil)
 }
 
+func (s *CloudInitSuite) TestFinishBootstrapConfigMongoSettings(c *gc.C) {
+	attrs := dummySampleConfig().Merge(testing.Attrs{
+		"authorized-keys":         "we-are-the-keys",
+		"admin-secret":            "lisboan-pork",
+		"agent-version":           "1.2.3",
+		"mongo-oplog-size":        512,
+		"mongo-cache-size":        2048,
+		"mongo-db-dir":            "/srv/juju-db",
+		"watcher-period":          "2s",
+		"watcher-batch-size":      50,
+		"watcher-coalesce-window": "500ms",
+	})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	mcfg := &cloudinit.MachineConfig{
+		Bootstrap: true,
+	}
+	err = environs.FinishMachineConfig(mcfg, cfg, constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	c.Check(mcfg.AgentEnvironment[agent.MongoOplogSize], gc.Equals, "512")
+	c.Check(mcfg.AgentEnvironment[agent.MongoCacheSize], gc.Equals, "2048")
+	c.Check(mcfg.AgentEnvironment[agent.MongoDBDir], gc.Equals, "/srv/juju-db")
+	c.Check(mcfg.AgentEnvironment[agent.WatcherPeriod], gc.Equals, "2s")
+	c.Check(mcfg.AgentEnvironment[agent.WatcherBatchSize], gc.Equals, "50")
+	c.Check(mcfg.AgentEnvironment[agent.WatcherCoalesceWindow], gc.Equals, "500ms")
+}
+
+func (s *CloudInitSuite) TestFinishMachineConfigStateServerMongoSettings(c *gc.C) {
+	attrs := dummySampleConfig().Merge(testing.Attrs{
+		"mongo-cache-size": 2048,
+		"mongo-db-dir":     "/srv/juju-db",
+	})
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+
+	// A machine started by ensure-availability gets the settings too.
+	mcfg := &cloudinit.MachineConfig{
+		Jobs: []params.MachineJob{params.JobManageEnviron, params.JobHostUnits},
+	}
+	err = environs.FinishMachineConfig(mcfg, cfg, constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	c.Check(mcfg.AgentEnvironment[agent.MongoCacheSize], gc.Equals, "2048")
+	c.Check(mcfg.AgentEnvironment[agent.MongoDBDir], gc.Equals, "/srv/juju-db")
+	c.Check(mcfg.StateServingInfo, gc.IsNil)
+
+	// Other machines do not.
+	mcfg = &cloudinit.MachineConfig{
+		Jobs: []params.MachineJob{params.JobHostUnits},
+	}
+	err = environs.FinishMachineConfig(mcfg, cfg, constraints.Value{})
+	c.Assert(err, gc.IsNil)
+	_, ok := mcfg.AgentEnvironment[agent.MongoCacheSize]
+	c.Check(ok, jc.IsFalse)
+	_, ok = mcfg.AgentEnvironment[agent.MongoDBDir]
+	c.Check(ok, jc.IsFalse)
+}
+
 func (s *CloudInitSuite) TestUserData(c *gc.C) {
 	s.testUserData(c, false)
 }
@@ -250,3 +311,47 @@ func (*CloudInitSuite) testUserData(c *gc.C, bootstrap bool) {
 		c.Check(len(runCmd) > 2, jc.IsTrue)
 	}
 }
+
+func (*CloudInitSuite) TestWindowsUserData(c *gc.C) {
+	cfg := &cloudinit.MachineConfig{
+		MachineId:    "10",
+		MachineNonce: "5432",
+		Tools: &tools.Tools{
+			URL:     "http://foo.com/tools/releases/juju1.2.3-win2012-amd64.tgz",
+			Version: version.MustParseBinary("1.2.3-win2012-amd64"),
+		},
+		MongoInfo: &authentication.MongoInfo{
+			Info: mongo.Info{
+				Addrs:  []string{"127.0.0.1:1234"},
+				CACert: "CA CERT\n" + testing.CACert,
+			},
+			Password: "pw1",
+			Tag:      names.NewMachineTag("10"),
+		},
+		APIInfo: &api.Info{
+			Addrs:    []string{"127.0.0.1:1234"},
+			Password: "pw2",
+			CACert:   "CA CERT\n" + testing.CACert,
+			Tag:      names.NewMachineTag("10"),
+		},
+		DataDir:                 environs.DataDir,
+		LogDir:                  agent.DefaultLogDir,
+		Jobs:                    []params.MachineJob{params.JobHostUnits},
+		CloudInitOutputLog:      environs.CloudInitOutputLog,
+		AgentEnvironment:        map[string]string{agent.ProviderType: "dummy"},
+		MachineAgentServiceName: "jujud-machine-10",
+	}
+	result, err := environs.ComposeUserData(cfg, nil)
+	c.Assert(err, gc.IsNil)
+	unzipped, err := utils.Gunzip(result)
+	c.Assert(err, gc.IsNil)
+
+	// Windows machines are given a PowerShell script which uses
+	// the Windows directories.
+	script := string(unzipped)
+	c.Assert(strings.HasPrefix(script, "#ps1_sysnative\r\n"), jc.IsTrue)
+	c.Assert(script, jc.Contains, "mkdir -Force 'C:/Juju/lib/juju/agents/machine-10'")
+	c.Assert(script, gc.Not(jc.Contains), "/var/lib/juju")
+	// The given configuration is unchanged.
+	c.Assert(cfg.DataDir, gc.Equals, environs.DataDir)
+}
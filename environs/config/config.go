@@ -6,6 +6,7 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -322,6 +323,94 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	if v, ok := cfg.defined["hook-retry-attempts"].(int); ok && v < 0 {
+		return fmt.Errorf("hook-retry-attempts must not be negative, got %d", v)
+	}
+
+	// The ports are opened in the provider's firewall and recorded in
+	// the agent configurations at bootstrap, so each must be usable
+	// and distinct from the others.
+	ports := make(map[int]string)
+	for _, attr := range []string{"state-port", "api-port", "syslog-port"} {
+		v, ok := cfg.defined[attr].(int)
+		if !ok {
+			continue
+		}
+		if v <= 0 || v > 65535 {
+			return fmt.Errorf("%s must be between 1 and 65535, got %d", attr, v)
+		}
+		if other, ok := ports[v]; ok {
+			return fmt.Errorf("%s and %s must be different, both are %d", other, attr, v)
+		}
+		ports[v] = attr
+	}
+
+	// The mongo settings are rendered into the state server's mongod
+	// service configuration at bootstrap.
+	for _, attr := range []string{"mongo-oplog-size", "mongo-cache-size"} {
+		if v, ok := cfg.defined[attr].(int); ok && v <= 0 {
+			return fmt.Errorf("%s must be positive, got %d", attr, v)
+		}
+	}
+	if v, ok := cfg.defined["mongo-db-dir"].(string); ok && v != "" && !filepath.IsAbs(v) {
+		return fmt.Errorf("mongo-db-dir must be an absolute path, got %q", v)
+	}
+
+	// The watcher settings are recorded in the state servers' agent
+	// configuration when they are provisioned, and applied by running
+	// state servers when they change.
+	if v, ok := cfg.defined["watcher-period"].(string); ok {
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			return fmt.Errorf("watcher-period must be a positive duration, got %q", v)
+		}
+	}
+	if v, ok := cfg.defined["watcher-batch-size"].(int); ok && v <= 0 {
+		return fmt.Errorf("watcher-batch-size must be positive, got %d", v)
+	}
+	if v, ok := cfg.defined["watcher-coalesce-window"].(string); ok {
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			return fmt.Errorf("watcher-coalesce-window must be a non-negative duration, got %q", v)
+		}
+	}
+
+	// Notifications may only be sent to HTTPS endpoints.
+	if v, ok := cfg.defined["notification-url"].(string); ok && v != "" {
+		u, err := url.Parse(v)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid notification-url %q: must be an https URL", v)
+		}
+	}
+	// Changes are only published to MQTT brokers.
+	if v, ok := cfg.defined["event-publisher-url"].(string); ok && v != "" {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "mqtt" && u.Scheme != "mqtts") || u.Host == "" {
+			return fmt.Errorf("invalid event-publisher-url %q: must be an mqtt or mqtts URL", v)
+		}
+		// Credentials in the URL would be revealed to every agent
+		// that can read the environment configuration.
+		if u.User != nil {
+			return fmt.Errorf("invalid event-publisher-url: credentials must be set with event-publisher-username and event-publisher-password")
+		}
+	}
+	if v, ok := cfg.defined["event-publisher-topic"].(string); ok && strings.ContainsAny(v, "+#") {
+		return fmt.Errorf("invalid event-publisher-topic %q: wildcards are not allowed", v)
+	}
+	// The state server DNS name is joined with the API port, so it
+	// must be a bare host name.
+	if v, ok := cfg.defined["state-server-dns-name"].(string); ok && v != "" {
+		if strings.ContainsAny(v, ":/ ") {
+			return fmt.Errorf("invalid state-server-dns-name %q: must be a host name", v)
+		}
+	}
+	if v, ok := cfg.defined["notification-events"].(string); ok {
+		for _, event := range strings.Split(v, ",") {
+			event = strings.TrimSpace(event)
+			if !isNotificationEvent(event) {
+				return fmt.Errorf("unknown notification event %q", event)
+			}
+		}
+	}
+
 	// Check firewall mode.
 	if mode := cfg.FirewallMode(); mode != FwInstance && mode != FwGlobal {
 		return fmt.Errorf("invalid firewall mode in environment configuration: %q", mode)
@@ -723,6 +812,172 @@ func (c *Config) ProvisionerSafeMode() bool {
 	return v
 }
 
+// HookRetryAttempts returns the number of times a unit agent should
+// automatically retry a failed hook before waiting for the error to be
+// resolved by hand. Zero means failed hooks are never retried.
+func (c *Config) HookRetryAttempts() int {
+	v, _ := c.defined["hook-retry-attempts"].(int)
+	return v
+}
+
+// MongoOplogSize returns the size, in megabytes, of the state server's
+// mongo oplog, and whether it has been set. If it has not, the size is
+// calculated from the free space on the database volume.
+func (c *Config) MongoOplogSize() (int, bool) {
+	v, ok := c.defined["mongo-oplog-size"].(int)
+	return v, ok
+}
+
+// MongoCacheSize returns the size, in megabytes, of the state server's
+// mongo storage engine cache, and whether it has been set.
+func (c *Config) MongoCacheSize() (int, bool) {
+	v, ok := c.defined["mongo-cache-size"].(int)
+	return v, ok
+}
+
+// MongoDBDir returns the directory, usually on a separate volume,
+// holding the state server's mongo database, or the empty string
+// if the database is kept in the agent's data directory.
+func (c *Config) MongoDBDir() string {
+	return c.asString("mongo-db-dir")
+}
+
+// WatcherPeriod returns the delay between each poll of the
+// transaction log by the state servers' watchers, and whether
+// it has been set.
+func (c *Config) WatcherPeriod() (time.Duration, bool) {
+	return c.duration("watcher-period")
+}
+
+// WatcherBatchSize returns the number of transaction log entries
+// fetched by the state servers' watchers in each round trip, and
+// whether it has been set.
+func (c *Config) WatcherBatchSize() (int, bool) {
+	v, ok := c.defined["watcher-batch-size"].(int)
+	return v, ok
+}
+
+// WatcherCoalesceWindow returns how long the state servers' watchers
+// hold back changes so that those made close together are delivered
+// together, and whether it has been set.
+func (c *Config) WatcherCoalesceWindow() (time.Duration, bool) {
+	return c.duration("watcher-coalesce-window")
+}
+
+// duration returns the value of a validated duration attribute,
+// and whether it has been set.
+func (c *Config) duration(name string) (time.Duration, bool) {
+	v, ok := c.defined[name].(string)
+	if !ok {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// NotificationURL returns the HTTPS URL to which notifications of
+// environment events are posted, or the empty string if notifications
+// are disabled.
+func (c *Config) NotificationURL() string {
+	return c.asString("notification-url")
+}
+
+// NotificationSecret returns the key used to sign notifications, or the
+// empty string if notifications should not be signed.
+func (c *Config) NotificationSecret() string {
+	return c.asString("notification-secret")
+}
+
+// secretAttrs holds the provider-independent attributes whose values
+// are secret, and so are not revealed to agents.
+var secretAttrs = []string{
+	"notification-secret",
+	"event-publisher-password",
+}
+
+// SecretAttrs returns the provider-independent attributes of the
+// configuration whose values are secret, in the same form as
+// EnvironProvider.SecretAttrs.
+func (c *Config) SecretAttrs() map[string]string {
+	secrets := make(map[string]string)
+	for _, attr := range secretAttrs {
+		if v := c.asString(attr); v != "" {
+			secrets[attr] = v
+		}
+	}
+	return secrets
+}
+
+// NotificationEvents returns the kinds of event for which notifications
+// are sent. All known events are returned if none were specified.
+func (c *Config) NotificationEvents() []string {
+	v := c.asString("notification-events")
+	if v == "" {
+		return append([]string(nil), notificationEvents...)
+	}
+	var events []string
+	for _, event := range strings.Split(v, ",") {
+		events = append(events, strings.TrimSpace(event))
+	}
+	return events
+}
+
+// notificationEvents holds the kinds of event that may be named in
+// notification-events.
+var notificationEvents = []string{
+	"unit-error",
+	"machine-down",
+	"service-removed",
+}
+
+func isNotificationEvent(event string) bool {
+	for _, known := range notificationEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// EventPublisherURL returns the URL of the MQTT broker to which changes
+// in the environment are published, or the empty string if changes
+// are not published.
+func (c *Config) EventPublisherURL() string {
+	return c.asString("event-publisher-url")
+}
+
+// EventPublisherUsername returns the user name with which to connect
+// to the event publisher's broker, or the empty string if none is
+// needed.
+func (c *Config) EventPublisherUsername() string {
+	return c.asString("event-publisher-username")
+}
+
+// EventPublisherPassword returns the password with which to connect to
+// the event publisher's broker.
+func (c *Config) EventPublisherPassword() string {
+	return c.asString("event-publisher-password")
+}
+
+// EventPublisherTopic returns the MQTT topic to which changes in the
+// environment are published.
+func (c *Config) EventPublisherTopic() string {
+	if v := c.asString("event-publisher-topic"); v != "" {
+		return v
+	}
+	return "juju/events"
+}
+
+// StateServerDNSName returns the DNS name by which the environment's
+// state servers can be reached, or the empty string if the state
+// servers must be found through the provider.
+func (c *Config) StateServerDNSName() string {
+	return c.asString("state-server-dns-name")
+}
+
 // ImageStream returns the simplestreams stream
 // used to identify which image ids to search
 // when starting an instance.
@@ -835,6 +1090,21 @@ var fields = schema.Fields{
 	"lxc-clone":                 schema.Bool(),
 	"lxc-clone-aufs":            schema.Bool(),
 	"prefer-ipv6":               schema.Bool(),
+	"hook-retry-attempts":       schema.ForceInt(),
+	"mongo-oplog-size":          schema.ForceInt(),
+	"mongo-cache-size":          schema.ForceInt(),
+	"mongo-db-dir":              schema.String(),
+	"watcher-period":            schema.String(),
+	"watcher-batch-size":        schema.ForceInt(),
+	"watcher-coalesce-window":   schema.String(),
+	"notification-url":          schema.String(),
+	"notification-secret":       schema.String(),
+	"notification-events":       schema.String(),
+	"event-publisher-url":       schema.String(),
+	"event-publisher-topic":     schema.String(),
+	"event-publisher-username":  schema.String(),
+	"event-publisher-password":  schema.String(),
+	"state-server-dns-name":     schema.String(),
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     schema.String(),
@@ -870,6 +1140,21 @@ var alwaysOptional = schema.Defaults{
 	"apt-https-proxy":           schema.Omit,
 	"apt-ftp-proxy":             schema.Omit,
 	"lxc-clone":                 schema.Omit,
+	"hook-retry-attempts":       schema.Omit,
+	"mongo-oplog-size":          schema.Omit,
+	"mongo-cache-size":          schema.Omit,
+	"mongo-db-dir":              schema.Omit,
+	"watcher-period":            schema.Omit,
+	"watcher-batch-size":        schema.Omit,
+	"watcher-coalesce-window":   schema.Omit,
+	"notification-url":          schema.Omit,
+	"notification-secret":       schema.Omit,
+	"notification-events":       schema.Omit,
+	"event-publisher-url":       schema.Omit,
+	"event-publisher-topic":     schema.Omit,
+	"event-publisher-username":  schema.Omit,
+	"event-publisher-password":  schema.Omit,
+	"state-server-dns-name":     schema.Omit,
 
 	// Deprecated fields, retain for backwards compatibility.
 	"tools-url":     "",
@@ -969,6 +1254,9 @@ var immutableAttributes = []string{
 	"lxc-clone-aufs",
 	"syslog-port",
 	"prefer-ipv6",
+	"mongo-oplog-size",
+	"mongo-cache-size",
+	"mongo-db-dir",
 }
 
 var (
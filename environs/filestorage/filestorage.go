@@ -4,6 +4,7 @@
 package filestorage
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -107,6 +108,47 @@ func (f *fileStorageReader) List(prefix string) ([]string, error) {
 	return names, nil
 }
 
+// ListMetadata implements storage.MetadataLister.ListMetadata.
+func (f *fileStorageReader) ListMetadata(prefix, marker string, limit int) ([]storage.Metadata, string, error) {
+	names, err := f.List(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	all := make([]storage.Metadata, len(names))
+	for i, name := range names {
+		all[i].Name = name
+	}
+	page, next := storage.Page(all, marker, limit)
+	for i := range page {
+		if err := f.stat(&page[i]); err != nil {
+			return nil, "", err
+		}
+	}
+	return page, next, nil
+}
+
+// stat fills in the size, modification time and checksum
+// of the named file.
+func (f *fileStorageReader) stat(md *storage.Metadata) error {
+	file, err := os.Open(f.fullPath(md.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	md.Size = info.Size()
+	md.Modified = info.ModTime()
+	md.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+	return nil
+}
+
 // URL implements storage.StorageReader.URL.
 func (f *fileStorageReader) URL(name string) (string, error) {
 	return "file://" + filepath.Join(f.path, name), nil
@@ -137,9 +179,29 @@ func NewFileStorageWriter(path string) (storage.Storage, error) {
 }
 
 func (f *fileStorageWriter) Put(name string, r io.Reader, length int64) error {
+	return f.put("Put", name, r, length, utils.ReplaceFile)
+}
+
+// PutIfAbsent implements storage.ConditionalWriter.PutIfAbsent.
+func (f *fileStorageWriter) PutIfAbsent(name string, r io.Reader, length int64) error {
+	return f.put("PutIfAbsent", name, r, length, func(tmpname, fullpath string) error {
+		// Link fails if the target exists, so only one
+		// writer can succeed.
+		err := os.Link(tmpname, fullpath)
+		os.Remove(tmpname)
+		if os.IsExist(err) {
+			return errors.AlreadyExistsf("file %q", name)
+		}
+		return err
+	})
+}
+
+// put writes the data from r to a temporary file, and then calls
+// commit to move it to the named file's path.
+func (f *fileStorageWriter) put(op, name string, r io.Reader, length int64, commit func(tmpname, fullpath string) error) error {
 	if isInternalPath(name) {
 		return &os.PathError{
-			Op:   "Put",
+			Op:   op,
 			Path: name,
 			Err:  os.ErrPermission,
 		}
@@ -165,7 +227,7 @@ func (f *fileStorageWriter) Put(name string, r io.Reader, length int64) error {
 		os.Remove(file.Name())
 		return err
 	}
-	return utils.ReplaceFile(file.Name(), fullpath)
+	return commit(file.Name(), fullpath)
 }
 
 func (f *fileStorageWriter) Remove(name string) error {
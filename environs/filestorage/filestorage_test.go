@@ -9,6 +9,8 @@ package filestorage_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -111,6 +113,35 @@ func (s *filestorageSuite) TestListHidesTempDir(c *gc.C) {
 	c.Check(files, gc.DeepEquals, []string(nil))
 }
 
+func (s *filestorageSuite) TestListMetadata(c *gc.C) {
+	fullpath, data := s.createFile(c, "a/b")
+	s.createFile(c, "a/c")
+	s.createFile(c, "b")
+	lister := s.reader.(storage.MetadataLister)
+	page, marker, err := lister.ListMetadata("a", "", 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 1)
+	c.Assert(marker, gc.Equals, "a/b")
+
+	info, err := os.Stat(fullpath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page[0].Name, gc.Equals, "a/b")
+	c.Assert(page[0].Size, gc.Equals, int64(len(data)))
+	c.Assert(page[0].Modified.Equal(info.ModTime()), jc.IsTrue)
+	hash := sha256.Sum256(data)
+	c.Assert(page[0].SHA256, gc.Equals, fmt.Sprintf("%x", hash[:]))
+
+	page, marker, err = lister.ListMetadata("a", marker, 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 1)
+	c.Assert(page[0].Name, gc.Equals, "a/c")
+	c.Assert(marker, gc.Equals, "")
+
+	metadata, err := storage.ListMetadata(s.reader, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.HasLen, 3)
+}
+
 func (s *filestorageSuite) TestURL(c *gc.C) {
 	expectedpath, _ := s.createFile(c, "test-file")
 	_, file := filepath.Split(expectedpath)
@@ -160,6 +191,26 @@ func (s *filestorageSuite) TestPut(c *gc.C) {
 	c.Assert(b, gc.DeepEquals, data)
 }
 
+func (s *filestorageSuite) TestPutIfAbsent(c *gc.C) {
+	writer := s.writer.(storage.ConditionalWriter)
+	data := []byte{1, 2, 3, 4, 5}
+	err := writer.PutIfAbsent("test-write", bytes.NewReader(data), int64(len(data)))
+	c.Assert(err, gc.IsNil)
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, "test-write"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(b, gc.DeepEquals, data)
+
+	err = writer.PutIfAbsent("test-write", bytes.NewReader([]byte{6}), 1)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	b, err = ioutil.ReadFile(filepath.Join(s.dir, "test-write"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(b, gc.DeepEquals, data)
+
+	// The temporary file is cleaned up.
+	_, err = os.Stat(filepath.Join(s.dir, ".tmp"))
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
 func (s *filestorageSuite) TestPutRefusesTmp(c *gc.C) {
 	data := []byte{1, 2, 3, 4, 5}
 	err := s.writer.Put(".tmp/test-write", bytes.NewReader(data), int64(len(data)))
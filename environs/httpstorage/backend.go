@@ -6,14 +6,18 @@ package httpstorage
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	jujuerrors "github.com/juju/errors"
+
 	"github.com/juju/juju/cert"
 	"github.com/juju/juju/environs/storage"
 )
@@ -126,6 +130,10 @@ func (s *storageBackend) handleGet(w http.ResponseWriter, req *http.Request) {
 func (s *storageBackend) handleList(w http.ResponseWriter, req *http.Request) {
 	prefix := req.URL.Path
 	prefix = prefix[1 : len(prefix)-1] // drop the leading '/' and trailing '*'
+	if _, ok := req.URL.Query()["metadata"]; ok {
+		s.handleListMetadata(w, req, prefix)
+		return
+	}
 	names, err := s.backend.List(prefix)
 	if err != nil {
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
@@ -136,14 +144,70 @@ func (s *storageBackend) handleList(w http.ResponseWriter, req *http.Request) {
 	w.Write(data)
 }
 
+// listMetadataResponse holds a page of file metadata, as returned
+// to the client in response to a list request with the "metadata"
+// query parameter.
+type listMetadataResponse struct {
+	Metadata []storage.Metadata `json:"metadata"`
+	Marker   string             `json:"marker,omitempty"`
+}
+
+// handleListMetadata returns a page of metadata for the files in the
+// storage with the given prefix to the client. The page is selected
+// by the "marker" and "limit" query parameters.
+func (s *storageBackend) handleListMetadata(w http.ResponseWriter, req *http.Request, prefix string) {
+	query := req.URL.Query()
+	marker := query.Get("marker")
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+	var resp listMetadataResponse
+	var err error
+	if lister, ok := s.backend.(storage.MetadataLister); ok {
+		resp.Metadata, resp.Marker, err = lister.ListMetadata(prefix, marker, limit)
+	} else {
+		var all []storage.Metadata
+		if all, err = storage.ListMetadata(s.backend, prefix); err == nil {
+			resp.Metadata, resp.Marker = storage.Page(all, marker, limit)
+		}
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(&resp)
+	if err != nil {
+		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
 // handlePut stores data from the client in the storage.
 func (s *storageBackend) handlePut(w http.ResponseWriter, req *http.Request) {
 	if req.ContentLength < 0 {
 		http.Error(w, "missing or invalid Content-Length header", http.StatusInternalServerError)
 		return
 	}
-	err := s.backend.Put(req.URL.Path[1:], req.Body, req.ContentLength)
-	if err != nil {
+	name := req.URL.Path[1:]
+	var err error
+	if req.Header.Get("If-None-Match") == "*" {
+		// The client only wants the file written if
+		// it does not already exist.
+		err = storage.PutIfAbsent(s.backend, name, req.Body, req.ContentLength)
+	} else {
+		err = s.backend.Put(name, req.Body, req.ContentLength)
+	}
+	if jujuerrors.IsAlreadyExists(err) {
+		http.Error(w, fmt.Sprint(err), http.StatusPreconditionFailed)
+		return
+	} else if err != nil {
 		http.Error(w, fmt.Sprint(err), http.StatusInternalServerError)
 		return
 	}
@@ -6,12 +6,14 @@ package httpstorage
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -136,6 +138,34 @@ func (s *localStorage) List(prefix string) ([]string, error) {
 	return names, nil
 }
 
+// ListMetadata implements storage.MetadataLister.ListMetadata.
+func (s *localStorage) ListMetadata(prefix, marker string, limit int) ([]storage.Metadata, string, error) {
+	baseURL, err := s.URL(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	v := url.Values{}
+	v.Set("metadata", "")
+	v.Set("marker", marker)
+	v.Set("limit", strconv.Itoa(limit))
+	resp, err := s.client.Get(baseURL + "*?" + v.Encode())
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("%s", resp.Status)
+	}
+	var result listMetadataResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", err
+	}
+	return result.Metadata, result.Marker, nil
+}
+
 // URL returns a URL that can be used to access the given storage file.
 func (s *localStorage) URL(name string) (string, error) {
 	return fmt.Sprintf("http://%s/%s", s.addr, name), nil
@@ -170,6 +200,15 @@ func (s *localStorage) ShouldRetry(err error) bool {
 // Put reads from r and writes to the given storage file.
 // The length must be set to the total length of the file.
 func (s *localStorage) Put(name string, r io.Reader, length int64) error {
+	return s.put(name, r, length, false)
+}
+
+// PutIfAbsent implements storage.ConditionalWriter.PutIfAbsent.
+func (s *localStorage) PutIfAbsent(name string, r io.Reader, length int64) error {
+	return s.put(name, r, length, true)
+}
+
+func (s *localStorage) put(name string, r io.Reader, length int64, ifAbsent bool) error {
 	logger.Debugf("putting %q (len %d) to storage", name, length)
 	url, err := s.modURL(name)
 	if err != nil {
@@ -187,11 +226,17 @@ func (s *localStorage) Put(name string, r io.Reader, length int64) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/octet-stream")
+	if ifAbsent {
+		req.Header.Set("If-None-Match", "*")
+	}
 	req.ContentLength = length
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return errors.AlreadyExistsf("file %q", name)
+	}
 	if resp.StatusCode != 201 {
 		return fmt.Errorf("%d %s", resp.StatusCode, resp.Status)
 	}
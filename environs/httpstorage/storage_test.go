@@ -5,10 +5,12 @@ package httpstorage_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 
 	"github.com/juju/errors"
@@ -78,6 +80,51 @@ func (s *storageSuite) TestList(c *gc.C) {
 	c.Assert(names, gc.HasLen, 0)
 }
 
+func (s *storageSuite) TestListMetadata(c *gc.C) {
+	listener, _, storageDir := startServer(c)
+	defer listener.Close()
+	stor := httpstorage.Client(listener.Addr().String())
+	for _, name := range []string{"a", "b", "c"} {
+		checkPutFile(c, stor, name, []byte(name))
+	}
+
+	lister := stor.(storage.MetadataLister)
+	page, marker, err := lister.ListMetadata("", "a", 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 1)
+	c.Assert(marker, gc.Equals, "b")
+	info, err := os.Stat(filepath.Join(storageDir, "b"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(page[0].Name, gc.Equals, "b")
+	c.Assert(page[0].Size, gc.Equals, int64(1))
+	hash := sha256.Sum256([]byte("b"))
+	c.Assert(page[0].SHA256, gc.Equals, fmt.Sprintf("%x", hash[:]))
+	c.Assert(page[0].Modified.Equal(info.ModTime()), jc.IsTrue)
+
+	metadata, err := storage.ListMetadata(stor, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.HasLen, 3)
+}
+
+func (s *storageSuite) TestPutIfAbsent(c *gc.C) {
+	listener, _, storageDir := startServerTLS(c)
+	defer listener.Close()
+	stor, err := httpstorage.ClientTLS(listener.Addr().String(), coretesting.CACert, testAuthkey)
+	c.Assert(err, gc.IsNil)
+
+	writer := stor.(storage.ConditionalWriter)
+	data := []byte("hello")
+	err = writer.PutIfAbsent("filename", bytes.NewReader(data), int64(len(data)))
+	c.Assert(err, gc.IsNil)
+	checkFileHasContents(c, stor, "filename", data)
+
+	err = writer.PutIfAbsent("filename", bytes.NewReader([]byte("bye")), 3)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	b, err := ioutil.ReadFile(filepath.Join(storageDir, "filename"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(b, gc.DeepEquals, data)
+}
+
 // TestPersistence tests the adding, reading, listing and removing
 // of files from the local storage.
 func (s *storageSuite) TestPersistence(c *gc.C) {
@@ -74,6 +74,17 @@ type BootstrapParams struct {
 	// Placement, if non-empty, holds an environment-specific placement
 	// directive used to choose the initial instance.
 	Placement string
+
+	// KeepBroken, if true, causes a failed bootstrap to leave any
+	// instance it started, and the environment's storage, in place
+	// for debugging rather than cleaning them up.
+	KeepBroken bool
+
+	// ForceReinstall, if true, causes any previous juju installation
+	// found on a reused bootstrap host to be removed rather than
+	// causing the bootstrap to fail. Providers that always start a
+	// fresh instance ignore it.
+	ForceReinstall bool
 }
 
 // An Environ represents a juju environment as specified
@@ -160,17 +171,17 @@ type Environ interface {
 	// OpenPorts opens the given ports for the whole environment.
 	// Must only be used if the environment was setup with the
 	// FwGlobal firewall mode.
-	OpenPorts(ports []network.Port) error
+	OpenPorts(ports []network.PortRange) error
 
 	// ClosePorts closes the given ports for the whole environment.
 	// Must only be used if the environment was setup with the
 	// FwGlobal firewall mode.
-	ClosePorts(ports []network.Port) error
+	ClosePorts(ports []network.PortRange) error
 
 	// Ports returns the ports opened for the whole environment.
 	// Must only be used if the environment was setup with the
 	// FwGlobal firewall mode.
-	Ports() ([]network.Port, error)
+	Ports() ([]network.PortRange, error)
 
 	// Provider returns the EnvironProvider that created this Environ.
 	Provider() EnvironProvider
@@ -33,6 +33,11 @@ type BootstrapArgs struct {
 	Context                 environs.BootstrapContext
 	Series                  string
 	HardwareCharacteristics *instance.HardwareCharacteristics
+
+	// Force causes any previous juju installation found on the
+	// host to be removed before bootstrapping. Otherwise, Bootstrap
+	// fails with a *PreviousInstallationError.
+	Force bool
 }
 
 func errMachineIdInvalid(machineId string) error {
@@ -65,12 +70,17 @@ func Bootstrap(args BootstrapArgs) (err error) {
 		return errors.New("possible tools is empty")
 	}
 
-	provisioned, err := checkProvisioned(args.Host)
+	found, err := detectInstallation(args.Host, args.DataDir)
 	if err != nil {
-		return fmt.Errorf("failed to check provisioned status: %v", err)
+		return fmt.Errorf("failed to check for a previous installation: %v", err)
 	}
-	if provisioned {
-		return ErrProvisioned
+	if len(found) > 0 {
+		if !args.Force {
+			return &PreviousInstallationError{Host: args.Host, Paths: found}
+		}
+		if err := removeInstallation(args.Host, args.DataDir); err != nil {
+			return fmt.Errorf("failed to remove previous installation: %v", err)
+		}
 	}
 
 	// Filter tools based on detected series/arch.
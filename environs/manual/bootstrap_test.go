@@ -22,6 +22,8 @@ import (
 	"github.com/juju/juju/version"
 )
 
+const bootstrapDataDir = "/var/lib/juju"
+
 type bootstrapSuite struct {
 	testing.JujuConnSuite
 	env *localStorageEnviron
@@ -67,7 +69,7 @@ func (s *bootstrapSuite) getArgs(c *gc.C) manual.BootstrapArgs {
 	arch := "amd64"
 	return manual.BootstrapArgs{
 		Host:          hostname,
-		DataDir:       "/var/lib/juju",
+		DataDir:       bootstrapDataDir,
 		Environ:       s.env,
 		PossibleTools: toolsList,
 		Series:        "precise",
@@ -82,25 +84,40 @@ func (s *bootstrapSuite) TestBootstrap(c *gc.C) {
 	args := s.getArgs(c)
 	args.Host = "ubuntu@" + args.Host
 
-	defer fakeSSH{SkipDetection: true}.install(c).Restore()
+	defer fakeSSH{DataDir: bootstrapDataDir, SkipDetection: true}.install(c).Restore()
 	err := manual.Bootstrap(args)
 	c.Assert(err, gc.IsNil)
 
-	// If the machine has no juju* upstart jobs, then bootstrap
-	// should fail with "machine is already provisioned".
+	// If the machine has a previous juju installation, then
+	// bootstrap should fail unless asked to remove it.
 	defer fakeSSH{
+		DataDir:            bootstrapDataDir,
 		Provisioned:        true,
 		SkipDetection:      true,
 		SkipProvisionAgent: true,
 	}.install(c).Restore()
 	err = manual.Bootstrap(args)
-	c.Assert(err, gc.Equals, manual.ErrProvisioned)
+	c.Assert(err, gc.FitsTypeOf, (*manual.PreviousInstallationError)(nil))
+	c.Assert(err, gc.ErrorMatches, `ubuntu@.* has a previous juju installation \(found /etc/init/jujud-machine-0.conf, /var/lib/juju/agents\)`)
+}
+
+func (s *bootstrapSuite) TestBootstrapForceRemovesPreviousInstallation(c *gc.C) {
+	args := s.getArgs(c)
+	args.Force = true
+	defer fakeSSH{
+		DataDir:            bootstrapDataDir,
+		Provisioned:        true,
+		RemoveInstallation: true,
+		SkipDetection:      true,
+	}.install(c).Restore()
+	err := manual.Bootstrap(args)
+	c.Assert(err, gc.IsNil)
 }
 
 func (s *bootstrapSuite) TestBootstrapScriptFailure(c *gc.C) {
 	args := s.getArgs(c)
 	args.Host = "ubuntu@" + args.Host
-	defer fakeSSH{SkipDetection: true, ProvisionAgentExitCode: 1}.install(c).Restore()
+	defer fakeSSH{DataDir: bootstrapDataDir, SkipDetection: true, ProvisionAgentExitCode: 1}.install(c).Restore()
 	err := manual.Bootstrap(args)
 	c.Assert(err, gc.NotNil)
 }
@@ -127,13 +144,13 @@ func (s *bootstrapSuite) TestBootstrapNoMatchingTools(c *gc.C) {
 	// Empty tools list.
 	args := s.getArgs(c)
 	args.PossibleTools = nil
-	defer fakeSSH{SkipDetection: true, SkipProvisionAgent: true}.install(c).Restore()
+	defer fakeSSH{DataDir: bootstrapDataDir, SkipDetection: true, SkipProvisionAgent: true}.install(c).Restore()
 	c.Assert(manual.Bootstrap(args), gc.ErrorMatches, "possible tools is empty")
 
 	// Non-empty list, but none that match the series/arch.
 	args = s.getArgs(c)
 	args.Series = "edgy"
-	defer fakeSSH{SkipDetection: true, SkipProvisionAgent: true}.install(c).Restore()
+	defer fakeSSH{DataDir: bootstrapDataDir, SkipDetection: true, SkipProvisionAgent: true}.install(c).Restore()
 	c.Assert(manual.Bootstrap(args), gc.ErrorMatches, "no matching tools available")
 }
 
@@ -157,7 +174,7 @@ func (s *bootstrapSuite) testBootstrapToolsURL(c *gc.C, toolsURL, expectedURL st
 	})
 	args := s.getArgs(c)
 	args.PossibleTools[0].URL = toolsURL
-	defer fakeSSH{SkipDetection: true}.install(c).Restore()
+	defer fakeSSH{DataDir: bootstrapDataDir, SkipDetection: true}.install(c).Restore()
 	err := manual.Bootstrap(args)
 	c.Assert(err, gc.IsNil)
 }
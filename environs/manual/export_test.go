@@ -7,6 +7,9 @@ var (
 	NetLookupHost         = &netLookupHost
 	ProvisionMachineAgent = &provisionMachineAgent
 	CheckProvisioned      = checkProvisioned
+
+	DetectInstallationScript = detectInstallationScript
+	RemoveInstallationScript = removeInstallationScript
 )
 
 const (
@@ -112,6 +112,15 @@ type fakeSSH struct {
 	// exit code for the checkProvisioned script.
 	CheckProvisionedExitCode int
 
+	// DataDir should be set when testing Bootstrap, which checks
+	// for a previous installation using the data directory rather
+	// than for provisioned agents.
+	DataDir string
+
+	// RemoveInstallation should be set to true if the fakeSSH
+	// script should expect a previous installation to be removed.
+	RemoveInstallation bool
+
 	// exit code for the machine agent provisioning script.
 	ProvisionAgentExitCode int
 
@@ -144,11 +153,21 @@ func (r fakeSSH) install(c *gc.C) testing.Restorer {
 	if !r.SkipDetection {
 		restore.Add(installDetectionFakeSSH(c, r.Series, r.Arch))
 	}
+	if r.RemoveInstallation {
+		add(manual.RemoveInstallationScript(r.DataDir), nil, 0)
+	}
 	var checkProvisionedOutput interface{}
 	if r.Provisioned {
 		checkProvisionedOutput = "/etc/init/jujud-machine-0.conf"
 	}
-	add(manual.CheckProvisionedScript, checkProvisionedOutput, r.CheckProvisionedExitCode)
+	if r.DataDir != "" {
+		if r.Provisioned {
+			checkProvisionedOutput = "/etc/init/jujud-machine-0.conf\n" + r.DataDir + "/agents"
+		}
+		add(manual.DetectInstallationScript(r.DataDir), checkProvisionedOutput, r.CheckProvisionedExitCode)
+	} else {
+		add(manual.CheckProvisionedScript, checkProvisionedOutput, r.CheckProvisionedExitCode)
+	}
 	if r.InitUbuntuUser {
 		add("", nil, 0)
 	}
@@ -0,0 +1,98 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package manual
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/utils/ssh"
+)
+
+// PreviousInstallationError is returned by Bootstrap if the host
+// has remnants of an earlier juju installation and removing them
+// was not requested.
+type PreviousInstallationError struct {
+	Host  string
+	Paths []string
+}
+
+func (e *PreviousInstallationError) Error() string {
+	return fmt.Sprintf("%s has a previous juju installation (found %s)",
+		e.Host, strings.Join(e.Paths, ", "))
+}
+
+// detectInstallationScript returns the script to run on the remote
+// machine to list the init scripts and agent directories left behind
+// by a juju installation. The output is empty if there are none.
+//
+// The data directory itself is not a sign of a previous installation:
+// sshstorage creates its storage directory there, and bootstrap
+// uploads tools to it, before the check is made.
+func detectInstallationScript(dataDir string) string {
+	return fmt.Sprintf(
+		"ls -d /etc/init/juju*.conf %s 2>/dev/null || exit 0",
+		utils.ShQuote(path.Join(dataDir, "agents")),
+	)
+}
+
+// removeInstallationScript returns the script to run, as root, on
+// the remote machine to stop and remove the agents of a previous
+// juju installation, along with their data. The storage directory
+// is left alone, as it holds the tools for the new installation.
+func removeInstallationScript(dataDir string) string {
+	return fmt.Sprintf(`set -e
+for conf in /etc/init/juju*.conf; do
+    [ -e "$conf" ] || continue
+    stop "$(basename "$conf" .conf)" || true
+    rm -f "$conf"
+done
+if [ -d %[1]s ]; then
+    find %[1]s -mindepth 1 -maxdepth 1 ! -name storage -exec rm -rf {} +
+fi
+rm -rf %[2]s`, utils.ShQuote(dataDir), utils.ShQuote(agent.DefaultLogDir))
+}
+
+// detectInstallation returns the paths of any juju init scripts and
+// agent directories that exist on the host machine.
+func detectInstallation(host, dataDir string) ([]string, error) {
+	logger.Infof("Checking for a previous juju installation on %s", host)
+	output, err := runSSHScript(host, nil, detectInstallationScript(dataDir))
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// removeInstallation stops and removes the agents of a previous
+// juju installation on the host machine, and removes their data
+// other than storage.
+func removeInstallation(host, dataDir string) error {
+	logger.Infof("Removing previous juju installation from %s", host)
+	_, err := runSSHScript(host, []string{"sudo"}, removeInstallationScript(dataDir))
+	return err
+}
+
+// runSSHScript runs the given script with bash on the host machine,
+// as the ubuntu user, and returns its output. The command is
+// prefixed with the given arguments, for example to run as root.
+func runSSHScript(host string, prefix []string, script string) (string, error) {
+	cmd := ssh.Command("ubuntu@"+host, append(prefix, "/bin/bash"), nil)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Stdin = strings.NewReader(script)
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() != 0 {
+			err = fmt.Errorf("%v (%v)", err, strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -14,11 +14,13 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/utils"
 
+	"github.com/juju/juju/environs/storage"
 	"github.com/juju/juju/utils/ssh"
 )
 
@@ -288,6 +290,97 @@ func (s *SSHStorage) List(prefix string) ([]string, error) {
 	return names, nil
 }
 
+// ListMetadata implements storage.MetadataLister.ListMetadata.
+// The checksums of the files are computed on the remote host, so
+// only the metadata is transferred.
+func (s *SSHStorage) ListMetadata(prefix, marker string, limit int) ([]storage.Metadata, string, error) {
+	remotepath, err := s.path(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	dir, prefix := path.Split(remotepath)
+	quotedDir := utils.ShQuote(dir)
+	out, err := s.runf(flockShared, "(test -d %s && find %s -type f -printf '%%s %%T@ %%p\\n') || true", quotedDir, quotedDir)
+	if err != nil {
+		return nil, "", err
+	}
+	if out == "" {
+		return nil, "", nil
+	}
+	var all []storage.Metadata
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, "", fmt.Errorf("cannot parse file metadata %q", line)
+		}
+		name := fields[2]
+		if !strings.HasPrefix(name[len(dir):], prefix) {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot parse size of %q: %v", name, err)
+		}
+		modified, err := parseModTime(fields[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot parse modification time of %q: %v", name, err)
+		}
+		all = append(all, storage.Metadata{
+			Name:     name[len(s.remotepath)+1:],
+			Size:     size,
+			Modified: modified,
+		})
+	}
+	sort.Sort(byName(all))
+	page, next := storage.Page(all, marker, limit)
+	if len(page) == 0 {
+		return page, next, nil
+	}
+	quotedPaths := make([]string, len(page))
+	for i, md := range page {
+		quotedPaths[i] = utils.ShQuote(path.Join(s.remotepath, md.Name))
+	}
+	out, err = s.runf(flockShared, "sha256sum %s", strings.Join(quotedPaths, " "))
+	if err != nil {
+		return nil, "", err
+	}
+	sums := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) == 2 {
+			sums[fields[1]] = fields[0]
+		}
+	}
+	for i := range page {
+		page[i].SHA256 = sums[path.Join(s.remotepath, page[i].Name)]
+	}
+	return page, next, nil
+}
+
+// parseModTime parses a modification time, as printed by find's
+// %T@ directive: seconds since the epoch with a fractional part.
+func parseModTime(s string) (time.Time, error) {
+	parts := strings.SplitN(s, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nsec int64
+	if len(parts) == 2 {
+		frac := (parts[1] + "000000000")[:9]
+		if nsec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+type byName []storage.Metadata
+
+func (m byName) Len() int           { return len(m) }
+func (m byName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byName) Less(i, j int) bool { return m[i].Name < m[j].Name }
+
 // URL implements storage.StorageReader.URL.
 func (s *SSHStorage) URL(name string) (string, error) {
 	path, err := s.path(name)
@@ -309,6 +402,23 @@ func (s *SSHStorage) ShouldRetry(err error) bool {
 
 // Put implements storage.StorageWriter.Put
 func (s *SSHStorage) Put(name string, r io.Reader, length int64) error {
+	return s.put(name, r, length, false)
+}
+
+// existsExitCode is the exit code of the command run by PutIfAbsent
+// if the file already exists.
+const existsExitCode = 17
+
+// PutIfAbsent implements storage.ConditionalWriter.PutIfAbsent.
+func (s *SSHStorage) PutIfAbsent(name string, r io.Reader, length int64) error {
+	err := s.put(name, r, length, true)
+	if err, ok := err.(SSHStorageError); ok && err.ExitCode == existsExitCode {
+		return errors.AlreadyExistsf("file %q", name)
+	}
+	return err
+}
+
+func (s *SSHStorage) put(name string, r io.Reader, length int64, ifAbsent bool) error {
 	logger.Debugf("putting %q (len %d) to storage", name, length)
 	path, err := s.path(name)
 	if err != nil {
@@ -323,6 +433,12 @@ func (s *SSHStorage) Put(name string, r io.Reader, length int64) error {
 		"TMPFILE=`mktemp --tmpdir=%s` && ((%s && mv $TMPFILE %s) || rm -f $TMPFILE)",
 		tmpdir, command, path,
 	)
+	if ifAbsent {
+		// The storage lock is held exclusively,
+		// so nothing can create the file between
+		// the check and the move.
+		command = fmt.Sprintf("(test ! -e %s || exit %d) && %s", path, existsExitCode, command)
+	}
 
 	_, err = s.run(flockExclusive, command+"\n", r, length)
 	return err
@@ -5,6 +5,7 @@ package sshstorage
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -215,6 +216,26 @@ func (s *storageSuite) TestPut(c *gc.C) {
 	}
 }
 
+func (s *storageSuite) TestPutIfAbsent(c *gc.C) {
+	stor, storageDir := s.makeStorage(c)
+	data := []byte("abc\000def")
+	err := stor.PutIfAbsent("a/b", bytes.NewBuffer(data), int64(len(data)))
+	c.Assert(err, gc.IsNil)
+	out, err := ioutil.ReadFile(filepath.Join(storageDir, "a/b"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.DeepEquals, data)
+
+	err = stor.PutIfAbsent("a/b", bytes.NewBufferString("xyz"), 3)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	out, err = ioutil.ReadFile(filepath.Join(storageDir, "a/b"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.DeepEquals, data)
+
+	// The storage is still usable afterwards.
+	err = stor.Put("a/b", bytes.NewBufferString("xyz"), 3)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *storageSuite) assertList(c *gc.C, stor storage.StorageReader, prefix string, expected []string) {
 	c.Logf("List: %v", prefix)
 	names, err := storage.List(stor, prefix)
@@ -241,6 +262,48 @@ func (s *storageSuite) TestList(c *gc.C) {
 	s.assertList(c, stor, "b", []string{"b"})
 }
 
+func (s *storageSuite) TestListMetadata(c *gc.C) {
+	stor, storageDir := s.makeStorage(c)
+	page, marker, err := stor.ListMetadata("", "", 0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 0)
+	c.Assert(marker, gc.Equals, "")
+
+	createFiles(c, storageDir, "a/b1", "a/b2", "b")
+	data := []byte("hello")
+	err = ioutil.WriteFile(filepath.Join(storageDir, "a/b2"), data, 0644)
+	c.Assert(err, gc.IsNil)
+	info, err := os.Stat(filepath.Join(storageDir, "a/b2"))
+	c.Assert(err, gc.IsNil)
+
+	page, marker, err = stor.ListMetadata("a", "", 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 1)
+	c.Assert(page[0].Name, gc.Equals, "a/b1")
+	c.Assert(marker, gc.Equals, "a/b1")
+
+	page, marker, err = stor.ListMetadata("a", marker, 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(page, gc.HasLen, 1)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(page[0].Name, gc.Equals, "a/b2")
+	c.Assert(page[0].Size, gc.Equals, int64(len(data)))
+	hash := sha256.Sum256(data)
+	c.Assert(page[0].SHA256, gc.Equals, fmt.Sprintf("%x", hash[:]))
+	c.Assert(page[0].Modified.Unix(), gc.Equals, info.ModTime().Unix())
+}
+
+func (s *storageSuite) TestParseModTime(c *gc.C) {
+	t, err := parseModTime("1413456789.5")
+	c.Assert(err, gc.IsNil)
+	c.Assert(t.Equal(time.Unix(1413456789, 500000000)), jc.IsTrue)
+	t, err = parseModTime("1413456789")
+	c.Assert(err, gc.IsNil)
+	c.Assert(t.Equal(time.Unix(1413456789, 0)), jc.IsTrue)
+	_, err = parseModTime("yesterday")
+	c.Assert(err, gc.NotNil)
+}
+
 func (s *storageSuite) TestRemove(c *gc.C) {
 	stor, storageDir := s.makeStorage(c)
 	err := os.Mkdir(filepath.Join(storageDir, "a"), 0755)
@@ -5,6 +5,7 @@ package storage
 
 import (
 	"io"
+	"time"
 
 	"github.com/juju/utils"
 )
@@ -41,10 +42,51 @@ type StorageReader interface {
 	ShouldRetry(error) bool
 }
 
+// Metadata describes a file in storage.
+type Metadata struct {
+	// Name holds the full name of the file.
+	Name string `json:"name"`
+
+	// Size holds the length of the file in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 holds the hex-encoded SHA256 checksum of the
+	// file's contents, if the storage knows it.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Modified holds the time the file was last written.
+	Modified time.Time `json:"modified"`
+}
+
+// A MetadataLister lists the metadata of files in storage, a page
+// at a time. A StorageReader may implement it so that its files need
+// not be fetched just to find their size or checksum.
+type MetadataLister interface {
+	// ListMetadata returns the metadata of up to limit files with
+	// the given prefix whose names sort after marker, in
+	// alphabetical order. If limit is not positive, all such files
+	// are returned. The returned marker should be passed to get
+	// the next page; it is empty if there are no more files.
+	ListMetadata(prefix, marker string, limit int) ([]Metadata, string, error)
+}
+
+// A URLSigner makes URLs through which files in storage may be
+// fetched, without credentials, only until a given time. A
+// StorageReader may implement it so that its files need not be
+// world-readable to be downloaded by agents.
+type URLSigner interface {
+	// SignedURL returns a URL through which the named file may be
+	// fetched until the given time. It returns an error satisfying
+	// errors.IsNotSupported if no such URL can be made.
+	SignedURL(name string, expires time.Time) (string, error)
+}
+
 // A StorageWriter adds and removes files in a storage provider.
 type StorageWriter interface {
 	// Put reads from r and writes to the given storage file.
 	// The length must give the total length of the file.
+	// The file must be replaced atomically, so that concurrent
+	// readers and writers never see partially written contents.
 	Put(name string, r io.Reader, length int64) error
 
 	// Remove removes the given file from the environment's
@@ -61,6 +103,16 @@ type StorageWriter interface {
 	RemoveAll() error
 }
 
+// A ConditionalWriter can write a file only if it does not already
+// exist. A StorageWriter may implement it so that concurrent writers
+// cannot overwrite each other's files.
+type ConditionalWriter interface {
+	// PutIfAbsent is like Put, but fails with an error satisfying
+	// errors.IsAlreadyExists if the named file already exists. The
+	// check and the write are atomic with respect to other writers.
+	PutIfAbsent(name string, r io.Reader, length int64) error
+}
+
 // Storage represents storage that can be both
 // read and written.
 type Storage interface {
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"sort"
 
+	"github.com/juju/errors"
 	"github.com/juju/utils"
 
 	"github.com/juju/juju/environs/simplestreams"
@@ -65,6 +67,84 @@ func ListWithRetry(stor StorageReader, prefix string, attempt utils.AttemptStrat
 	return list, err
 }
 
+// PutIfAbsent writes to the named file in stor only if it does not
+// already exist, and otherwise returns an error satisfying
+// errors.IsAlreadyExists. If stor is not a ConditionalWriter, the
+// check is made separately from the write, so it cannot guard
+// against a concurrent writer.
+func PutIfAbsent(stor Storage, name string, r io.Reader, length int64) error {
+	if writer, ok := stor.(ConditionalWriter); ok {
+		return writer.PutIfAbsent(name, r, length)
+	}
+	rc, err := stor.Get(name)
+	if err == nil {
+		rc.Close()
+		return errors.AlreadyExistsf("file %q", name)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	return stor.Put(name, r, length)
+}
+
+// ListMetadata returns the metadata of the files matching prefix in
+// stor, in alphabetical order, using the stor's default consistency
+// strategy. If stor is not a MetadataLister, only the names of the
+// files are filled in.
+func ListMetadata(stor StorageReader, prefix string) ([]Metadata, error) {
+	lister, ok := stor.(MetadataLister)
+	if !ok {
+		names, err := List(stor, prefix)
+		if err != nil {
+			return nil, err
+		}
+		metadata := make([]Metadata, len(names))
+		for i, name := range names {
+			metadata[i].Name = name
+		}
+		return metadata, nil
+	}
+	var metadata []Metadata
+	var marker string
+	for {
+		var page []Metadata
+		var next string
+		var err error
+		for a := stor.DefaultConsistencyStrategy().Start(); a.Next(); {
+			page, next, err = lister.ListMetadata(prefix, marker, listMetadataPageSize)
+			if err == nil || !stor.ShouldRetry(err) {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, page...)
+		if next == "" {
+			return metadata, nil
+		}
+		marker = next
+	}
+}
+
+// listMetadataPageSize is the number of files that ListMetadata
+// asks for at a time.
+var listMetadataPageSize = 1000
+
+// Page returns the page of up to limit entries in metadata whose
+// names sort after marker, along with the marker for the next page,
+// as specified by MetadataLister.ListMetadata. The metadata must be
+// in alphabetical order.
+func Page(metadata []Metadata, marker string, limit int) ([]Metadata, string) {
+	start := sort.Search(len(metadata), func(i int) bool {
+		return metadata[i].Name > marker
+	})
+	metadata = metadata[start:]
+	if limit <= 0 || len(metadata) <= limit {
+		return metadata, ""
+	}
+	return metadata[:limit], metadata[limit-1].Name
+}
+
 // BaseToolsPath is the container where tools tarballs and metadata are found.
 var BaseToolsPath = "tools"
 
@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	stdtesting "testing"
 
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
 	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
@@ -198,3 +200,84 @@ func (s *storageSuite) TestListNoRetryAllowed(c *gc.C) {
 	c.Assert(stor.listPrefix, gc.Equals, "foo")
 	c.Assert(stor.invokeCount, gc.Equals, 1)
 }
+
+// fakeMetadataStorage is a fakeStorage that lists its files'
+// metadata two at a time.
+type fakeMetadataStorage struct {
+	fakeStorage
+	metadata []storage.Metadata
+	markers  []string
+}
+
+func (s *fakeMetadataStorage) List(prefix string) ([]string, error) {
+	var names []string
+	for _, md := range s.metadata {
+		names = append(names, md.Name)
+	}
+	return names, nil
+}
+
+func (s *fakeMetadataStorage) ListMetadata(prefix, marker string, limit int) ([]storage.Metadata, string, error) {
+	s.markers = append(s.markers, marker)
+	page, next := storage.Page(s.metadata, marker, 2)
+	return page, next, nil
+}
+
+var testMetadata = []storage.Metadata{
+	{Name: "a", Size: 1, SHA256: "aaa"},
+	{Name: "b", Size: 2, SHA256: "bbb"},
+	{Name: "c", Size: 3, SHA256: "ccc"},
+}
+
+func (s *storageSuite) TestPage(c *gc.C) {
+	for i, test := range []struct {
+		marker   string
+		limit    int
+		expected []storage.Metadata
+		next     string
+	}{
+		{"", 0, testMetadata, ""},
+		{"", 2, testMetadata[:2], "b"},
+		{"a", 2, testMetadata[1:], ""},
+		{"b", 1, testMetadata[2:], ""},
+		{"c", 1, []storage.Metadata{}, ""},
+	} {
+		c.Logf("test %d: marker=%q limit=%d", i, test.marker, test.limit)
+		page, next := storage.Page(testMetadata, test.marker, test.limit)
+		c.Check(page, gc.DeepEquals, test.expected)
+		c.Check(next, gc.Equals, test.next)
+	}
+}
+
+func (s *storageSuite) TestListMetadata(c *gc.C) {
+	stor := &fakeMetadataStorage{metadata: testMetadata}
+	metadata, err := storage.ListMetadata(stor, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.DeepEquals, testMetadata)
+	c.Assert(stor.markers, gc.DeepEquals, []string{"", "b"})
+}
+
+func (s *storageSuite) TestListMetadataNamesOnly(c *gc.C) {
+	stor := &fakeMetadataStorage{metadata: testMetadata}
+	// Hide the ListMetadata method.
+	reader := struct{ storage.StorageReader }{stor}
+	metadata, err := storage.ListMetadata(reader, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(metadata, gc.DeepEquals, []storage.Metadata{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	c.Assert(stor.markers, gc.HasLen, 0)
+}
+
+func (s *datasourceSuite) TestPutIfAbsent(c *gc.C) {
+	// Hide the storage's own PutIfAbsent method.
+	stor := struct{ storage.Storage }{s.stor}
+	err := storage.PutIfAbsent(stor, "foo", bytes.NewReader([]byte("bar")), 3)
+	c.Assert(err, gc.IsNil)
+	err = storage.PutIfAbsent(stor, "foo", bytes.NewReader([]byte("baz")), 3)
+	c.Assert(err, jc.Satisfies, errors.IsAlreadyExists)
+	r, err := storage.Get(stor, "foo")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "bar")
+}
@@ -273,15 +273,28 @@ func MetadataFromTools(toolsList coretools.List) []*ToolsMetadata {
 	return metadata
 }
 
-// ResolveMetadata resolves incomplete metadata
-// by fetching the tools from storage and computing
-// the size and hash locally.
+// ResolveMetadata resolves incomplete metadata. The size and
+// hash are taken from the storage's file metadata if it has
+// them; otherwise the tools are fetched from storage and the
+// size and hash are computed locally.
 func ResolveMetadata(stor storage.StorageReader, metadata []*ToolsMetadata) error {
+	var listed map[string]storage.Metadata
 	for _, md := range metadata {
 		if md.Size != 0 {
 			continue
 		}
 		binary := md.binary()
+		if listed == nil {
+			var err error
+			if listed, err = listToolsMetadata(stor); err != nil {
+				return err
+			}
+		}
+		if stored, ok := listed[StorageName(binary)]; ok && stored.SHA256 != "" {
+			md.Size = stored.Size
+			md.SHA256 = stored.SHA256
+			continue
+		}
 		logger.Infof("Fetching tools to generate hash: %v", binary)
 		size, sha256hash, err := fetchToolsHash(stor, binary)
 		if err != nil {
@@ -407,6 +420,24 @@ func MergeAndWriteMetadata(stor storage.Storage, tools coretools.List, writeMirr
 	return WriteMetadata(stor, metadata, writeMirrors)
 }
 
+// listToolsMetadata returns the storage metadata of the tools in
+// stor, keyed by storage name. It is empty if stor cannot list
+// file metadata.
+func listToolsMetadata(stor storage.StorageReader) (map[string]storage.Metadata, error) {
+	listed := make(map[string]storage.Metadata)
+	if _, ok := stor.(storage.MetadataLister); !ok {
+		return listed, nil
+	}
+	metadata, err := storage.ListMetadata(stor, toolPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, md := range metadata {
+		listed[md.Name] = md
+	}
+	return listed, nil
+}
+
 // fetchToolsHash fetches the tools from storage and calculates
 // its size in bytes and computes a SHA256 hash of its contents.
 func fetchToolsHash(stor storage.StorageReader, ver version.Binary) (size int64, sha256hash hash.Hash, err error) {
@@ -587,6 +587,41 @@ func (*metadataHelperSuite) TestResolveMetadata(c *gc.C) {
 	c.Assert(metadata[0].SHA256, gc.Not(gc.Equals), "")
 }
 
+// countingMetadataStorage is a countingStorage that can list
+// file metadata.
+type countingMetadataStorage struct {
+	*countingStorage
+	storage.MetadataLister
+}
+
+func (*metadataHelperSuite) TestResolveMetadataFromStorageMetadata(c *gc.C) {
+	var versionStrings = []string{"1.2.3-precise-amd64"}
+	dir := c.MkDir()
+	toolstesting.MakeTools(c, dir, "releases", versionStrings)
+	toolsList := coretools.List{{
+		Version: version.MustParseBinary(versionStrings[0]),
+	}}
+
+	stor, err := filestorage.NewFileStorageReader(dir)
+	c.Assert(err, gc.IsNil)
+	counting := &countingMetadataStorage{
+		countingStorage: &countingStorage{StorageReader: stor},
+		MetadataLister:  stor.(storage.MetadataLister),
+	}
+	metadata := tools.MetadataFromTools(toolsList)
+	err = tools.ResolveMetadata(counting, metadata)
+	c.Assert(err, gc.IsNil)
+
+	// The size and hash come from the storage's
+	// metadata, so the tools are not fetched.
+	c.Assert(counting.counter, gc.Equals, 0)
+	listed, err := storage.ListMetadata(stor, tools.StorageName(toolsList[0].Version))
+	c.Assert(err, gc.IsNil)
+	c.Assert(listed, gc.HasLen, 1)
+	c.Assert(metadata[0].Size, gc.Equals, listed[0].Size)
+	c.Assert(metadata[0].SHA256, gc.Equals, listed[0].SHA256)
+}
+
 func (*metadataHelperSuite) TestMergeMetadata(c *gc.C) {
 	md1 := &tools.ToolsMetadata{
 		Release: "precise",
@@ -2,9 +2,12 @@ package environs
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/juju/errors"
+	"github.com/juju/names"
 	"github.com/juju/utils"
 
 	"github.com/juju/juju/environs/storage"
@@ -76,27 +79,39 @@ func waitAnyInstanceAddresses(
 }
 
 // APIInfo returns an api.Info for the environment. The result is populated
-// with addresses and CA certificate, but no tag or password.
+// with addresses, CA certificate and environment tag, but no entity tag or password.
+//
+// If the environment configuration names the state servers' DNS name,
+// that is used as the API address; otherwise the state server instances
+// are found through the provider.
 func APIInfo(env Environ) (*api.Info, error) {
-	instanceIds, err := env.StateServerInstances()
-	if err != nil {
-		return nil, err
-	}
-	logger.Debugf("StateServerInstances returned: %v", instanceIds)
-	addrs, err := waitAnyInstanceAddresses(env, instanceIds)
-	if err != nil {
-		return nil, err
-	}
 	config := env.Config()
 	cert, hasCert := config.CACert()
 	if !hasCert {
 		return nil, errors.New("config has no CACert")
 	}
 	apiPort := config.APIPort()
-	apiAddrs := make([]string, len(addrs))
-	for i, hp := range network.AddressesWithPort(addrs, apiPort) {
-		apiAddrs[i] = hp.NetAddr()
+	var apiAddrs []string
+	if dnsName := config.StateServerDNSName(); dnsName != "" {
+		logger.Debugf("using state server DNS name %q", dnsName)
+		apiAddrs = []string{net.JoinHostPort(dnsName, strconv.Itoa(apiPort))}
+	} else {
+		instanceIds, err := env.StateServerInstances()
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("StateServerInstances returned: %v", instanceIds)
+		addrs, err := waitAnyInstanceAddresses(env, instanceIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, hp := range network.AddressesWithPort(addrs, apiPort) {
+			apiAddrs = append(apiAddrs, hp.NetAddr())
+		}
 	}
 	apiInfo := &api.Info{Addrs: apiAddrs, CACert: cert}
+	if uuid, ok := config.UUID(); ok {
+		apiInfo.EnvironTag = names.NewEnvironTag(uuid)
+	}
 	return apiInfo, nil
 }
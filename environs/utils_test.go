@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environs_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/environs/configstore"
+	"github.com/juju/juju/provider/dummy"
+	"github.com/juju/juju/testing"
+)
+
+type APIInfoSuite struct {
+	testing.FakeJujuHomeSuite
+}
+
+var _ = gc.Suite(&APIInfoSuite{})
+
+func (s *APIInfoSuite) TearDownTest(c *gc.C) {
+	dummy.Reset()
+	s.FakeJujuHomeSuite.TearDownTest(c)
+}
+
+func (s *APIInfoSuite) prepare(c *gc.C, attrs testing.Attrs) environs.Environ {
+	cfg, err := config.New(config.NoDefaults, dummySampleConfig().Merge(attrs))
+	c.Assert(err, gc.IsNil)
+	env, err := environs.Prepare(cfg, testing.Context(c), configstore.NewMem())
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+func (s *APIInfoSuite) TestAPIInfoNotBootstrapped(c *gc.C) {
+	env := s.prepare(c, nil)
+	_, err := environs.APIInfo(env)
+	c.Assert(err, gc.Equals, environs.ErrNotBootstrapped)
+}
+
+func (s *APIInfoSuite) TestAPIInfoStateServerDNSName(c *gc.C) {
+	env := s.prepare(c, testing.Attrs{
+		"state-server-dns-name": "juju.example.com",
+		"api-port":              17070,
+	})
+	// The environment has not been bootstrapped, so the state
+	// server instances cannot be found through the provider.
+	info, err := environs.APIInfo(env)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Addrs, gc.DeepEquals, []string{"juju.example.com:17070"})
+	c.Assert(info.CACert, gc.Equals, testing.CACert)
+}
@@ -32,18 +32,18 @@ type Instance interface {
 	// associated with the instance.
 	Addresses() ([]network.Address, error)
 
-	// OpenPorts opens the given ports on the instance, which
+	// OpenPorts opens the given port ranges on the instance, which
 	// should have been started with the given machine id.
-	OpenPorts(machineId string, ports []network.Port) error
+	OpenPorts(machineId string, ports []network.PortRange) error
 
-	// ClosePorts closes the given ports on the instance, which
+	// ClosePorts closes the given port ranges on the instance, which
 	// should have been started with the given machine id.
-	ClosePorts(machineId string, ports []network.Port) error
+	ClosePorts(machineId string, ports []network.PortRange) error
 
-	// Ports returns the set of ports open on the instance, which
-	// should have been started with the given machine id.
-	// The ports are returned as sorted by SortPorts.
-	Ports(machineId string) ([]network.Port, error)
+	// Ports returns the set of port ranges open on the instance,
+	// which should have been started with the given machine id.
+	// The ranges are returned as sorted by SortPortRanges.
+	Ports(machineId string) ([]network.PortRange, error)
 }
 
 // HardwareCharacteristics represents the characteristics of the instance (if known).
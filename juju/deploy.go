@@ -9,11 +9,13 @@ import (
 
 	"github.com/juju/charm"
 	"github.com/juju/names"
+	"github.com/juju/utils/set"
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/version"
 )
 
 // DeployServiceParams contains the arguments required to deploy the referenced
@@ -34,6 +36,28 @@ type DeployServiceParams struct {
 	Networks []string
 }
 
+// checkMinJujuVersion returns an error if the charm requires a newer
+// version of juju than the environment's agents are running.
+func checkMinJujuVersion(st *state.State, ch *state.Charm) error {
+	minVersion := ch.MinJujuVersion()
+	if minVersion == version.Zero {
+		return nil
+	}
+	conf, err := st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	agentVersion, ok := conf.AgentVersion()
+	if !ok {
+		return fmt.Errorf("no agent version set in environment configuration")
+	}
+	if agentVersion.Compare(minVersion) < 0 {
+		return fmt.Errorf("charm %q requires juju version %s or later, but the environment is running %s",
+			ch.URL(), minVersion, agentVersion)
+	}
+	return nil
+}
+
 // DeployService takes a charm and various parameters and deploys it.
 func DeployService(st *state.State, args DeployServiceParams) (*state.Service, error) {
 	if args.NumUnits > 1 && args.ToMachineSpec != "" {
@@ -51,11 +75,12 @@ func DeployService(st *state.State, args DeployServiceParams) (*state.Service, e
 			return nil, fmt.Errorf("subordinate service must be deployed without constraints")
 		}
 	}
+	if err := checkMinJujuVersion(st, args.Charm); err != nil {
+		return nil, err
+	}
 	if args.ServiceOwner == "" {
 		args.ServiceOwner = "user-admin"
 	}
-	// TODO(fwereade): transactional State.AddService including settings, constraints
-	// (minimumUnitCount, initialMachineIds?).
 	if len(args.Networks) > 0 || args.Constraints.HaveNetworks() {
 		conf, err := st.EnvironConfig()
 		if err != nil {
@@ -69,36 +94,73 @@ func DeployService(st *state.State, args DeployServiceParams) (*state.Service, e
 			return nil, fmt.Errorf("cannot deploy with networks: not suppored by the environment")
 		}
 	}
-	service, err := st.AddService(
+	// The service is added together with its settings and constraints,
+	// which are validated before anything is written.
+	service, err := st.AddServiceWithConfig(
 		args.ServiceName,
 		args.ServiceOwner,
 		args.Charm,
 		args.Networks,
+		settings,
+		args.Constraints,
 	)
 	if err != nil {
 		return nil, err
 	}
-	if len(settings) > 0 {
-		if err := service.UpdateConfigSettings(settings); err != nil {
+	if args.NumUnits > 0 {
+		// Remember the machines that already exist, so that those
+		// added for the new units can be told apart from them.
+		machines, err := st.AllMachines()
+		if err != nil {
 			return nil, err
 		}
-	}
-	if args.Charm.Meta().Subordinate {
-		return service, nil
-	}
-	if !constraints.IsEmpty(&args.Constraints) {
-		if err := service.SetConstraints(args.Constraints); err != nil {
-			return nil, err
+		existing := set.NewStrings()
+		for _, m := range machines {
+			existing.Add(m.Id())
 		}
-	}
-	if args.NumUnits > 0 {
 		if _, err := AddUnits(st, service, args.NumUnits, args.ToMachineSpec); err != nil {
+			// Don't leave a partially deployed service behind.
+			removePartialDeploy(st, service, existing)
 			return nil, err
 		}
 	}
 	return service, nil
 }
 
+// removePartialDeploy destroys the service, its units, and the
+// machines added for its units that are not in existing. Errors are
+// logged rather than returned, as the caller is already failing.
+func removePartialDeploy(st *state.State, service *state.Service, existing set.Strings) {
+	units, err := service.AllUnits()
+	if err != nil {
+		logger.Errorf("cannot remove units of partially deployed service %q: %v", service.Name(), err)
+	}
+	for _, unit := range units {
+		machineId, err := unit.AssignedMachineId()
+		if err != nil && !state.IsNotAssigned(err) {
+			logger.Errorf("cannot remove partially deployed unit %q: %v", unit.Name(), err)
+			continue
+		}
+		if err := unit.Destroy(); err != nil {
+			logger.Errorf("cannot remove partially deployed unit %q: %v", unit.Name(), err)
+			continue
+		}
+		if machineId == "" || existing.Contains(machineId) {
+			continue
+		}
+		machine, err := st.Machine(machineId)
+		if err == nil {
+			err = machine.Destroy()
+		}
+		if err != nil {
+			logger.Errorf("cannot remove machine %s added for unit %q: %v", machineId, unit.Name(), err)
+		}
+	}
+	if err := service.Destroy(); err != nil {
+		logger.Errorf("cannot remove partially deployed service %q: %v", service.Name(), err)
+	}
+}
+
 // AddUnits starts n units of the given service and allocates machines
 // to them as necessary.
 func AddUnits(st *state.State, svc *state.Service, n int, machineIdSpec string) ([]*state.Unit, error) {
@@ -5,6 +5,8 @@ package juju_test
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	stdtesting "testing"
 
 	"github.com/juju/charm"
@@ -115,6 +117,26 @@ func (s *DeployLocalSuite) TestDeploySettingsError(c *gc.C) {
 	c.Assert(err, jc.Satisfies, errors.IsNotFound)
 }
 
+func (s *DeployLocalSuite) TestDeployMinJujuVersion(c *gc.C) {
+	repoPath := c.MkDir()
+	path := charmtesting.Charms.ClonedDirPath(filepath.Join(repoPath, "quantal"), "dummy")
+	metaYaml := "name: dummy\nsummary: blah\ndescription: blah\nmin-juju-version: 99.0.0\n"
+	err := ioutil.WriteFile(filepath.Join(path, "metadata.yaml"), []byte(metaYaml), 0644)
+	c.Assert(err, gc.IsNil)
+	repo := &charm.LocalRepository{Path: repoPath}
+	ch, err := testing.PutCharm(s.State, charm.MustParseURL("local:quantal/dummy"), repo, true)
+	c.Assert(err, gc.IsNil)
+
+	_, err = juju.DeployService(s.State,
+		juju.DeployServiceParams{
+			ServiceName: "bob",
+			Charm:       ch,
+		})
+	c.Assert(err, gc.ErrorMatches, `charm "local:quantal/dummy-.*" requires juju version 99.0.0 or later, but the environment is running .*`)
+	_, err = s.State.Service("bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *DeployLocalSuite) TestDeployConstraints(c *gc.C) {
 	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=2G"))
 	c.Assert(err, gc.IsNil)
@@ -179,6 +201,24 @@ func (s *DeployLocalSuite) TestDeployForceMachineId(c *gc.C) {
 	s.assertMachines(c, service, constraints.Value{}, "0")
 }
 
+func (s *DeployLocalSuite) TestDeployForceMachineIdNotFound(c *gc.C) {
+	serviceCons := constraints.MustParse("cpu-cores=2")
+	_, err := juju.DeployService(s.State,
+		juju.DeployServiceParams{
+			ServiceName:   "bob",
+			Charm:         s.charm,
+			Constraints:   serviceCons,
+			NumUnits:      1,
+			ToMachineSpec: "42",
+		})
+	c.Assert(err, gc.ErrorMatches, `cannot assign unit "bob/0" to machine: machine 42 not found`)
+	// The partially deployed service and its unit are removed.
+	_, err = s.State.Unit("bob/0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = s.State.Service("bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *DeployLocalSuite) TestDeployForceMachineIdWithContainer(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
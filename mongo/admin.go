@@ -28,6 +28,9 @@ type EnsureAdminUserParams struct {
 	Namespace string
 	// DataDir is the Juju data directory, used to start a --noauth server.
 	DataDir string
+	// DBDir is the directory holding the database. If this is empty,
+	// the database is kept in the "db" directory within DataDir.
+	DBDir string
 	// Port is the listening port of the Mongo server.
 	Port int
 	// User holds the user to log in to the mongo server as.
@@ -83,7 +86,7 @@ func EnsureAdminUser(p EnsureAdminUserParams) (added bool, err error) {
 
 	// Start mongod in --noauth mode.
 	logger.Debugf("starting mongo with --noauth")
-	cmd, err := noauthCommand(p.DataDir, p.Port)
+	cmd, err := noauthCommand(p.DataDir, p.DBDir, p.Port)
 	if err != nil {
 		return false, fmt.Errorf("failed to prepare mongod command: %v", err)
 	}
@@ -152,6 +152,24 @@ type EnsureServerParams struct {
 	// calculate a default size according to the
 	// algorithm defined in Mongo.
 	OplogSize int
+
+	// CacheSize is the size, in megabytes, of the mongod storage
+	// engine cache. If this is zero, mongod chooses the size.
+	CacheSize int
+
+	// DBDir is the directory holding the database. If this is
+	// empty, the database is kept in the "db" directory within
+	// DataDir.
+	DBDir string
+
+	// NoJournal disables journaling in mongod.
+	NoJournal bool
+
+	// Version holds the version of mongod that last served the
+	// database. If this is non-zero, EnsureServer will refuse to
+	// start an installed mongod that cannot serve the database
+	// without migrating it.
+	Version version.Number
 }
 
 // EnsureServer ensures that the correct mongo upstart script is installed
@@ -168,7 +186,7 @@ func EnsureServer(args EnsureServerParams) error {
 		"Ensuring mongo server is running; data directory %s; port %d",
 		args.DataDir, args.StatePort,
 	)
-	dbDir := filepath.Join(args.DataDir, "db")
+	dbDir := dbDirOrDefault(args.DataDir, args.DBDir)
 
 	if err := os.MkdirAll(dbDir, 0700); err != nil {
 		return fmt.Errorf("cannot create mongo database directory: %v", err)
@@ -210,17 +228,28 @@ func EnsureServer(args EnsureServerParams) error {
 		}
 	}
 
-	svc, mongoPath, err := upstartService(args.Namespace, args.DataDir, dbDir, args.StatePort, oplogSizeMB)
+	svc, mongoPath, err := upstartService(args.Namespace, args.DataDir, dbDir, args.StatePort, oplogSizeMB, args.CacheSize, args.NoJournal)
 	if err != nil {
 		return err
 	}
 	logVersion(mongoPath)
+	if args.Version != version.Zero {
+		installed, err := mongodVersion(mongoPath)
+		if err != nil {
+			return err
+		}
+		if !CanUpgradeInPlace(args.Version, installed) {
+			return fmt.Errorf("cannot run mongod %v on a database last served by mongod %v", installed, args.Version)
+		}
+	}
 
 	if err := upstartServiceStop(svc); err != nil {
 		return fmt.Errorf("failed to stop mongo: %v", err)
 	}
-	if err := makeJournalDirs(dbDir); err != nil {
-		return fmt.Errorf("error creating journal directories: %v", err)
+	if !args.NoJournal {
+		if err := makeJournalDirs(dbDir); err != nil {
+			return fmt.Errorf("error creating journal directories: %v", err)
+		}
 	}
 	if err := preallocOplog(dbDir, oplogSizeMB); err != nil {
 		return fmt.Errorf("error creating oplog files: %v", err)
@@ -272,12 +301,16 @@ func sharedSecretPath(dataDir string) string {
 // upstartService returns the upstart config for the mongo state service.
 // It also returns the path to the mongod executable that the upstart config
 // will be using.
-func upstartService(namespace, dataDir, dbDir string, port, oplogSizeMB int) (*upstart.Service, string, error) {
+func upstartService(namespace, dataDir, dbDir string, port, oplogSizeMB, cacheSizeMB int, noJournal bool) (*upstart.Service, string, error) {
 	mongoPath, err := Path()
 	if err != nil {
 		return nil, "", err
 	}
 
+	journal := " --journal"
+	if noJournal {
+		journal = " --nojournal"
+	}
 	mongoCmd := mongoPath + " --auth" +
 		" --dbpath=" + utils.ShQuote(dbDir) +
 		" --sslOnNormalPorts" +
@@ -287,11 +320,14 @@ func upstartService(namespace, dataDir, dbDir string, port, oplogSizeMB int) (*u
 		" --noprealloc" +
 		" --syslog" +
 		" --smallfiles" +
-		" --journal" +
+		journal +
 		" --keyFile " + utils.ShQuote(sharedSecretPath(dataDir)) +
 		" --replSet " + ReplicaSetName +
 		" --ipv6 " +
 		" --oplogSize " + strconv.Itoa(oplogSizeMB)
+	if cacheSizeMB > 0 {
+		mongoCmd += cacheSizeOption(mongoPath, cacheSizeMB)
+	}
 	conf := common.Conf{
 		Desc: "juju state database",
 		Limit: map[string]string{
@@ -304,6 +340,27 @@ func upstartService(namespace, dataDir, dbDir string, port, oplogSizeMB int) (*u
 	return svc, mongoPath, nil
 }
 
+// cacheSizeOption returns the mongod option that sets the storage
+// engine cache to the given size, or the empty string if the mongod
+// at mongoPath does not support it. Only mongod 3.0 and later, with
+// the WiredTiger storage engine, have a configurable cache; its size
+// is given in whole gigabytes.
+func cacheSizeOption(mongoPath string, cacheSizeMB int) string {
+	v, err := mongodVersion(mongoPath)
+	if err != nil {
+		logger.Warningf("ignoring mongo cache size: %v", err)
+		return ""
+	}
+	if v.Major < 3 {
+		logger.Warningf("ignoring mongo cache size: not supported by mongod %v", v)
+		return ""
+	}
+	// The cache option belongs to the WiredTiger storage engine,
+	// which is not the default before mongod 3.2.
+	cacheSizeGB := (cacheSizeMB + 1023) / 1024
+	return " --storageEngine wiredTiger --wiredTigerCacheSizeGB " + strconv.Itoa(cacheSizeGB)
+}
+
 func aptGetInstallMongod() error {
 	// Only Quantal requires the PPA.
 	if version.Current.Series == "quantal" {
@@ -356,11 +413,20 @@ func packageForSeries(series string) string {
 	}
 }
 
+// dbDirOrDefault returns dbDir, or the "db" directory within dataDir
+// if dbDir is empty.
+func dbDirOrDefault(dataDir, dbDir string) string {
+	if dbDir == "" {
+		return filepath.Join(dataDir, "db")
+	}
+	return dbDir
+}
+
 // noauthCommand returns an os/exec.Cmd that may be executed to
 // run mongod without security.
-func noauthCommand(dataDir string, port int) (*exec.Cmd, error) {
+func noauthCommand(dataDir, dbDir string, port int) (*exec.Cmd, error) {
 	sslKeyFile := path.Join(dataDir, "server.pem")
-	dbDir := filepath.Join(dataDir, "db")
+	dbDir = dbDirOrDefault(dataDir, dbDir)
 	mongoPath, err := Path()
 	if err != nil {
 		return nil, err
@@ -228,7 +228,7 @@ func (s *MongoSuite) TestInstallMongod(c *gc.C) {
 func (s *MongoSuite) TestUpstartServiceWithReplSet(c *gc.C) {
 	dataDir := c.MkDir()
 
-	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024)
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 0, false)
 	c.Assert(err, gc.IsNil)
 	c.Assert(strings.Contains(svc.Conf.Cmd, "--replSet"), jc.IsTrue)
 }
@@ -236,7 +236,7 @@ func (s *MongoSuite) TestUpstartServiceWithReplSet(c *gc.C) {
 func (s *MongoSuite) TestUpstartServiceIPv6(c *gc.C) {
 	dataDir := c.MkDir()
 
-	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024)
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 0, false)
 	c.Assert(err, gc.IsNil)
 	c.Assert(strings.Contains(svc.Conf.Cmd, "--ipv6"), jc.IsTrue)
 }
@@ -244,16 +244,95 @@ func (s *MongoSuite) TestUpstartServiceIPv6(c *gc.C) {
 func (s *MongoSuite) TestUpstartServiceWithJournal(c *gc.C) {
 	dataDir := c.MkDir()
 
-	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024)
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 0, false)
 	c.Assert(err, gc.IsNil)
 	journalPresent := strings.Contains(svc.Conf.Cmd, " --journal ") || strings.HasSuffix(svc.Conf.Cmd, " --journal")
 	c.Assert(journalPresent, jc.IsTrue)
 }
 
+func (s *MongoSuite) TestUpstartServiceWithNoJournal(c *gc.C) {
+	dataDir := c.MkDir()
+
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 0, true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Conf.Cmd, jc.Contains, " --nojournal ")
+	c.Assert(svc.Conf.Cmd, gc.Not(jc.Contains), " --journal ")
+}
+
+func (s *MongoSuite) TestUpstartServiceWithCacheSize(c *gc.C) {
+	dataDir := c.MkDir()
+	err := ioutil.WriteFile(s.mongodPath, []byte("#!/bin/bash\n\nprintf %s 'db version v3.0.4'\n"), 0755)
+	c.Assert(err, gc.IsNil)
+
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 1536, false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Conf.Cmd, jc.HasSuffix, " --storageEngine wiredTiger --wiredTigerCacheSizeGB 2")
+}
+
+func (s *MongoSuite) TestUpstartServiceCacheSizeUnsupported(c *gc.C) {
+	dataDir := c.MkDir()
+
+	svc, _, err := mongo.UpstartService("", dataDir, dataDir, 1234, 1024, 1536, false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Conf.Cmd, gc.Not(jc.Contains), "--wiredTigerCacheSizeGB")
+}
+
+func (s *MongoSuite) TestEnsureServerDBDir(c *gc.C) {
+	dataDir := c.MkDir()
+	dbDir := filepath.Join(c.MkDir(), "juju-db")
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+
+	args := makeEnsureServerParams(dataDir, "")
+	args.DBDir = dbDir
+	err := mongo.EnsureServer(args)
+	c.Assert(err, gc.IsNil)
+
+	testJournalDirs(dbDir, c)
+	c.Assert(filepath.Join(dataDir, "db"), jc.DoesNotExist)
+	c.Assert(s.installed, gc.HasLen, 1)
+	c.Assert(s.installed[0].Conf.Cmd, jc.Contains, " --dbpath="+utils.ShQuote(dbDir)+" ")
+}
+
+func (s *MongoSuite) TestEnsureServerNoJournal(c *gc.C) {
+	dataDir := c.MkDir()
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+
+	args := makeEnsureServerParams(dataDir, "")
+	args.NoJournal = true
+	err := mongo.EnsureServer(args)
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(filepath.Join(dataDir, "db", "journal"), jc.DoesNotExist)
+	c.Assert(s.installed, gc.HasLen, 1)
+	c.Assert(s.installed[0].Conf.Cmd, jc.Contains, " --nojournal ")
+}
+
+func (s *MongoSuite) TestEnsureServerInPlaceUpgrade(c *gc.C) {
+	dataDir := c.MkDir()
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+
+	args := makeEnsureServerParams(dataDir, "")
+	args.Version = version.MustParse("2.4.6")
+	err := mongo.EnsureServer(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.installed, gc.HasLen, 1)
+}
+
+func (s *MongoSuite) TestEnsureServerRefusesMigration(c *gc.C) {
+	dataDir := c.MkDir()
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+
+	args := makeEnsureServerParams(dataDir, "")
+	args.Version = version.MustParse("2.2.4")
+	err := mongo.EnsureServer(args)
+	c.Assert(err, gc.ErrorMatches, "cannot run mongod 2.4.9 on a database last served by mongod 2.2.4")
+	c.Assert(s.installed, gc.HasLen, 0)
+}
+
 func (s *MongoSuite) TestNoAuthCommandWithJournal(c *gc.C) {
 	dataDir := c.MkDir()
 
-	cmd, err := mongo.NoauthCommand(dataDir, 1234)
+	cmd, err := mongo.NoauthCommand(dataDir, "", 1234)
 	c.Assert(err, gc.IsNil)
 	var isJournalPresent bool
 	for _, value := range cmd.Args {
@@ -264,6 +343,21 @@ func (s *MongoSuite) TestNoAuthCommandWithJournal(c *gc.C) {
 	c.Assert(isJournalPresent, jc.IsTrue)
 }
 
+func (s *MongoSuite) TestNoAuthCommandDBDir(c *gc.C) {
+	dataDir := c.MkDir()
+	dbDir := filepath.Join(c.MkDir(), "juju-db")
+
+	cmd, err := mongo.NoauthCommand(dataDir, dbDir, 1234)
+	c.Assert(err, gc.IsNil)
+	var dbPath string
+	for i, arg := range cmd.Args {
+		if arg == "--dbpath" && i+1 < len(cmd.Args) {
+			dbPath = cmd.Args[i+1]
+		}
+	}
+	c.Assert(dbPath, gc.Equals, dbDir)
+}
+
 func (s *MongoSuite) TestRemoveService(c *gc.C) {
 	err := mongo.RemoveService("namespace")
 	c.Assert(err, gc.IsNil)
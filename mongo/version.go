@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package mongo
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/juju/juju/service/common"
+	"github.com/juju/juju/service/upstart"
+	"github.com/juju/juju/version"
+)
+
+var mongodVersionPattern = regexp.MustCompile(`db version v(\d+\.\d+\.\d+)`)
+
+// InstalledVersion returns the version of the mongod executable
+// that EnsureServer will run on this machine.
+func InstalledVersion() (version.Number, error) {
+	mongoPath, err := Path()
+	if err != nil {
+		return version.Zero, err
+	}
+	return mongodVersion(mongoPath)
+}
+
+// mongodVersion returns the version reported by the given
+// mongod executable.
+func mongodVersion(mongoPath string) (version.Number, error) {
+	output, err := exec.Command(mongoPath, "--version").CombinedOutput()
+	if err != nil {
+		return version.Zero, fmt.Errorf("cannot get mongod version: %v", err)
+	}
+	match := mongodVersionPattern.FindSubmatch(output)
+	if match == nil {
+		return version.Zero, fmt.Errorf("cannot find mongod version in %q", output)
+	}
+	return version.Parse(string(match[1]))
+}
+
+// CanUpgradeInPlace reports whether a database last served by
+// mongod version from can be served by mongod version to.
+// Releases in the same minor series share a data format, so
+// moving between them needs only a restart; any other change
+// requires the database to be migrated.
+func CanUpgradeInPlace(from, to version.Number) bool {
+	return from.Major == to.Major && from.Minor == to.Minor
+}
+
+// UpgradeServer installs the latest mongod package available for this
+// machine's series. If the installed mongod then differs from the
+// running version, the juju database service in the given namespace
+// is restarted so that the new mongod serves the database. It returns
+// the version of the installed mongod.
+//
+// Upgrades are only made within a minor series, which shares a data
+// format. If the package installs a mongod that cannot serve the
+// database in place, the service is left running the old mongod and
+// an error is returned.
+func UpgradeServer(namespace string, running version.Number) (version.Number, error) {
+	if err := aptGetInstallMongod(); err != nil {
+		return version.Zero, fmt.Errorf("cannot upgrade mongod: %v", err)
+	}
+	installed, err := InstalledVersion()
+	if err != nil {
+		return version.Zero, err
+	}
+	if installed == running {
+		return installed, nil
+	}
+	if !CanUpgradeInPlace(running, installed) {
+		return version.Zero, fmt.Errorf("cannot upgrade mongod %v to %v in place", running, installed)
+	}
+	logger.Infof("restarting mongod to upgrade it from %v to %v", running, installed)
+	svc := upstart.NewService(ServiceName(namespace), common.Conf{})
+	if err := upstartServiceStop(svc); err != nil {
+		return version.Zero, fmt.Errorf("cannot stop mongod: %v", err)
+	}
+	if err := upstartServiceStart(svc); err != nil {
+		return version.Zero, fmt.Errorf("cannot start mongod: %v", err)
+	}
+	return installed, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package mongo_test
+
+import (
+	"io/ioutil"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/service/upstart"
+	"github.com/juju/juju/version"
+)
+
+func (s *MongoSuite) TestInstalledVersion(c *gc.C) {
+	v, err := mongo.InstalledVersion()
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, version.MustParse("2.4.9"))
+}
+
+func (s *MongoSuite) TestInstalledVersionUnparseable(c *gc.C) {
+	err := ioutil.WriteFile(s.mongodPath, []byte("#!/bin/bash\n\nprintf %s 'mongod'\n"), 0755)
+	c.Assert(err, gc.IsNil)
+	_, err = mongo.InstalledVersion()
+	c.Assert(err, gc.ErrorMatches, `cannot find mongod version in "mongod"`)
+}
+
+func (s *MongoSuite) TestInstalledVersionNoMongod(c *gc.C) {
+	s.PatchValue(&mongo.JujuMongodPath, "/not/going/to/exist/mongod")
+	_, err := mongo.InstalledVersion()
+	c.Assert(err, gc.NotNil)
+}
+
+var canUpgradeInPlaceTests = []struct {
+	from, to string
+	expect   bool
+}{
+	{"2.4.6", "2.4.9", true},
+	{"2.4.9", "2.4.6", true},
+	{"2.4.9", "2.4.9", true},
+	{"2.2.4", "2.4.9", false},
+	{"2.4.9", "2.6.1", false},
+	{"2.4.9", "3.0.0", false},
+}
+
+func (s *MongoSuite) TestCanUpgradeInPlace(c *gc.C) {
+	for i, test := range canUpgradeInPlaceTests {
+		c.Logf("test %d: %s -> %s", i, test.from, test.to)
+		from := version.MustParse(test.from)
+		to := version.MustParse(test.to)
+		c.Check(mongo.CanUpgradeInPlace(from, to), gc.Equals, test.expect)
+	}
+}
+
+func (s *MongoSuite) patchRestart(c *gc.C) *[]string {
+	var calls []string
+	s.PatchValue(mongo.UpstartServiceStop, func(svc *upstart.Service) error {
+		calls = append(calls, "stop "+svc.Name)
+		return nil
+	})
+	s.PatchValue(mongo.UpstartServiceStart, func(svc *upstart.Service) error {
+		calls = append(calls, "start "+svc.Name)
+		return nil
+	})
+	return &calls
+}
+
+func (s *MongoSuite) TestUpgradeServerRestartsMongod(c *gc.C) {
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+	calls := s.patchRestart(c)
+
+	v, err := mongo.UpgradeServer("namespace", version.MustParse("2.4.6"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, version.MustParse("2.4.9"))
+	c.Assert(*calls, gc.DeepEquals, []string{"stop juju-db-namespace", "start juju-db-namespace"})
+}
+
+func (s *MongoSuite) TestUpgradeServerUnchanged(c *gc.C) {
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+	calls := s.patchRestart(c)
+
+	v, err := mongo.UpgradeServer("namespace", version.MustParse("2.4.9"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(v, gc.Equals, version.MustParse("2.4.9"))
+	c.Assert(*calls, gc.HasLen, 0)
+}
+
+func (s *MongoSuite) TestUpgradeServerRefusesMigration(c *gc.C) {
+	mockShellCommand(c, &s.CleanupSuite, "apt-get")
+	calls := s.patchRestart(c)
+
+	_, err := mongo.UpgradeServer("namespace", version.MustParse("2.2.4"))
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade mongod 2.2.4 to 2.4.9 in place")
+	c.Assert(*calls, gc.HasLen, 0)
+}
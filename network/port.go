@@ -8,6 +8,7 @@ import (
 	"net"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 // Port identifies a network port number for a particular protocol.
@@ -21,6 +22,107 @@ func (p Port) String() string {
 	return fmt.Sprintf("%d/%s", p.Number, p.Protocol)
 }
 
+// PortRange identifies a contiguous range of port numbers for a
+// particular protocol. ICMP has no port numbers, so an ICMP range
+// has both FromPort and ToPort set to -1.
+type PortRange struct {
+	FromPort int
+	ToPort   int
+	Protocol string
+}
+
+// ParsePortRange parses a port range of the form
+// <port>[-<port>][/<protocol>], or the bare protocol "icmp".
+// The protocol defaults to "tcp".
+func ParsePortRange(s string) (PortRange, error) {
+	if strings.ToLower(s) == "icmp" {
+		return PortRange{FromPort: -1, ToPort: -1, Protocol: "icmp"}, nil
+	}
+	ports, protocol := s, "tcp"
+	if i := strings.Index(s, "/"); i != -1 {
+		ports, protocol = s[:i], strings.ToLower(s[i+1:])
+	}
+	from, to := ports, ports
+	if i := strings.Index(ports, "-"); i != -1 {
+		from, to = ports[:i], ports[i+1:]
+	}
+	fromPort, err := strconv.Atoi(from)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port %q", from)
+	}
+	toPort, err := strconv.Atoi(to)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port %q", to)
+	}
+	p := PortRange{FromPort: fromPort, ToPort: toPort, Protocol: protocol}
+	if err := p.Validate(); err != nil {
+		return PortRange{}, err
+	}
+	return p, nil
+}
+
+// Validate returns an error if the port range is not valid.
+func (p PortRange) Validate() error {
+	switch p.Protocol {
+	case "tcp", "udp":
+	case "icmp":
+		if p.FromPort != -1 || p.ToPort != -1 {
+			return fmt.Errorf("icmp port range must not specify ports")
+		}
+		return nil
+	default:
+		return fmt.Errorf(`protocol must be "tcp", "udp" or "icmp"; got %q`, p.Protocol)
+	}
+	for _, port := range []int{p.FromPort, p.ToPort} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("port must be in the range [1, 65535]; got %d", port)
+		}
+	}
+	if p.FromPort > p.ToPort {
+		return fmt.Errorf("invalid port range %d-%d", p.FromPort, p.ToPort)
+	}
+	return nil
+}
+
+// ConflictsWith returns whether the two port ranges overlap.
+func (a PortRange) ConflictsWith(b PortRange) bool {
+	if a.Protocol != b.Protocol {
+		return false
+	}
+	return a.FromPort <= b.ToPort && b.FromPort <= a.ToPort
+}
+
+// String implements Stringer. Ranges of a single port are
+// formatted in the same way as a Port.
+func (p PortRange) String() string {
+	switch {
+	case p.Protocol == "icmp":
+		return p.Protocol
+	case p.FromPort == p.ToPort:
+		return fmt.Sprintf("%d/%s", p.FromPort, p.Protocol)
+	}
+	return fmt.Sprintf("%d-%d/%s", p.FromPort, p.ToPort, p.Protocol)
+}
+
+type portRangeSlice []PortRange
+
+func (p portRangeSlice) Len() int      { return len(p) }
+func (p portRangeSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p portRangeSlice) Less(i, j int) bool {
+	p1 := p[i]
+	p2 := p[j]
+	if p1.Protocol != p2.Protocol {
+		return p1.Protocol < p2.Protocol
+	}
+	return p1.FromPort < p2.FromPort
+}
+
+// SortPortRanges sorts the given port ranges, first by protocol,
+// then by first port.
+func SortPortRanges(ranges []PortRange) {
+	sort.Sort(portRangeSlice(ranges))
+}
+
 // HostPort associates an address with a port.
 type HostPort struct {
 	Address
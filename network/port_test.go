@@ -143,3 +143,75 @@ func (*PortSuite) TestSortHostPorts(c *gc.C) {
 		1234,
 	))
 }
+
+var parsePortRangeTests = []struct {
+	about  string
+	input  string
+	expect network.PortRange
+	err    string
+}{{
+	about:  "single port defaults to tcp",
+	input:  "80",
+	expect: network.PortRange{FromPort: 80, ToPort: 80, Protocol: "tcp"},
+}, {
+	about:  "single port with protocol",
+	input:  "53/UDP",
+	expect: network.PortRange{FromPort: 53, ToPort: 53, Protocol: "udp"},
+}, {
+	about:  "port range",
+	input:  "8000-8100/tcp",
+	expect: network.PortRange{FromPort: 8000, ToPort: 8100, Protocol: "tcp"},
+}, {
+	about:  "icmp",
+	input:  "icmp",
+	expect: network.PortRange{FromPort: -1, ToPort: -1, Protocol: "icmp"},
+}, {
+	about: "icmp with ports",
+	input: "80/icmp",
+	err:   "icmp port range must not specify ports",
+}, {
+	about: "invalid port",
+	input: "two",
+	err:   `invalid port "two"`,
+}, {
+	about: "port out of range",
+	input: "65536",
+	err:   `port must be in the range \[1, 65535\]; got 65536`,
+}, {
+	about: "reversed range",
+	input: "90-80",
+	err:   "invalid port range 90-80",
+}, {
+	about: "unknown protocol",
+	input: "80/http",
+	err:   `protocol must be "tcp", "udp" or "icmp"; got "http"`,
+}}
+
+func (*PortSuite) TestParsePortRange(c *gc.C) {
+	for i, t := range parsePortRangeTests {
+		c.Logf("test %d: %s", i, t.about)
+		p, err := network.ParsePortRange(t.input)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Check(err, gc.IsNil)
+		c.Check(p, gc.Equals, t.expect)
+	}
+}
+
+func (*PortSuite) TestPortRangeString(c *gc.C) {
+	c.Assert(network.PortRange{80, 80, "tcp"}.String(), gc.Equals, "80/tcp")
+	c.Assert(network.PortRange{80, 100, "udp"}.String(), gc.Equals, "80-100/udp")
+	c.Assert(network.PortRange{-1, -1, "icmp"}.String(), gc.Equals, "icmp")
+}
+
+func (*PortSuite) TestPortRangeConflictsWith(c *gc.C) {
+	r := network.PortRange{80, 90, "tcp"}
+	c.Assert(r.ConflictsWith(network.PortRange{85, 85, "tcp"}), jc.IsTrue)
+	c.Assert(r.ConflictsWith(network.PortRange{70, 80, "tcp"}), jc.IsTrue)
+	c.Assert(r.ConflictsWith(network.PortRange{90, 100, "tcp"}), jc.IsTrue)
+	c.Assert(r.ConflictsWith(network.PortRange{70, 100, "tcp"}), jc.IsTrue)
+	c.Assert(r.ConflictsWith(network.PortRange{91, 100, "tcp"}), jc.IsFalse)
+	c.Assert(r.ConflictsWith(network.PortRange{80, 90, "udp"}), jc.IsFalse)
+}
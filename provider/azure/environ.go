@@ -30,6 +30,7 @@ import (
 	"github.com/juju/juju/provider/common"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/tools"
 )
 
 const (
@@ -639,9 +640,24 @@ func (env *azureEnviron) StartInstance(args environs.StartInstanceParams) (_ ins
 		return nil, nil, nil, err
 	}
 
-	// Pick envtools.  Needed for the custom data (which is what we normally
-	// call userdata).
-	args.MachineConfig.Tools = args.Tools[0]
+	snapshot := env.getSnapshot()
+	location := snapshot.ecfg.location()
+	instanceType, sourceImageName, err := env.selectInstanceTypeAndImage(&instances.InstanceConstraint{
+		Region:      location,
+		Series:      args.Tools.OneSeries(),
+		Arches:      args.Tools.Arches(),
+		Constraints: args.Constraints,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Pick envtools to suit the instance type.  Needed for the custom
+	// data (which is what we normally call userdata).
+	args.MachineConfig.Tools, err = instanceTypeTools(instanceType, args.Tools)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	logger.Infof("picked tools %q", args.MachineConfig.Tools)
 
 	// Compose userdata.
@@ -656,18 +672,6 @@ func (env *azureEnviron) StartInstance(args environs.StartInstanceParams) (_ ins
 	}
 	defer env.releaseManagementAPI(azure)
 
-	snapshot := env.getSnapshot()
-	location := snapshot.ecfg.location()
-	instanceType, sourceImageName, err := env.selectInstanceTypeAndImage(&instances.InstanceConstraint{
-		Region:      location,
-		Series:      args.Tools.OneSeries(),
-		Arches:      args.Tools.Arches(),
-		Constraints: args.Constraints,
-	})
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
 	// We use the cloud service label as a way to group instances with
 	// the same affinity, so that machines can be be allocated to the
 	// same availability set.
@@ -711,6 +715,24 @@ func (env *azureEnviron) StartInstance(args environs.StartInstanceParams) (_ ins
 	return inst, hc, nil, nil
 }
 
+// instanceTypeTools returns tools from possibleTools that run on one
+// of the architectures supported by the given instance type. Instance
+// types selected without image metadata do not record architectures,
+// in which case any of the tools will do.
+func instanceTypeTools(instanceType *instances.InstanceType, possibleTools tools.List) (*tools.Tools, error) {
+	if len(instanceType.Arches) == 0 {
+		return possibleTools[0], nil
+	}
+	for _, arch := range instanceType.Arches {
+		matching, err := possibleTools.Match(tools.Filter{Arch: arch})
+		if err == nil {
+			return matching[0], nil
+		}
+	}
+	return nil, fmt.Errorf("no tools available for instance type %q with architectures %v",
+		instanceType.Name, instanceType.Arches)
+}
+
 // getInstance returns an up-to-date version of the instance with the given
 // name.
 func (env *azureEnviron) getInstance(hostedService *gwacl.HostedService, roleName string) (instance.Instance, error) {
@@ -1121,20 +1143,20 @@ func (env *azureEnviron) Destroy() error {
 
 // OpenPorts is specified in the Environ interface. However, Azure does not
 // support the global firewall mode.
-func (env *azureEnviron) OpenPorts(ports []network.Port) error {
+func (env *azureEnviron) OpenPorts(ports []network.PortRange) error {
 	return nil
 }
 
 // ClosePorts is specified in the Environ interface. However, Azure does not
 // support the global firewall mode.
-func (env *azureEnviron) ClosePorts(ports []network.Port) error {
+func (env *azureEnviron) ClosePorts(ports []network.PortRange) error {
 	return nil
 }
 
 // Ports is specified in the Environ interface.
-func (env *azureEnviron) Ports() ([]network.Port, error) {
+func (env *azureEnviron) Ports() ([]network.PortRange, error) {
 	// TODO: implement this.
-	return []network.Port{}, nil
+	return []network.PortRange{}, nil
 }
 
 // Provider is specified in the Environ interface.
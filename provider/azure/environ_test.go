@@ -38,6 +38,7 @@ import (
 	"github.com/juju/juju/state/api"
 	apiparams "github.com/juju/juju/state/api/params"
 	coretesting "github.com/juju/juju/testing"
+	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 )
 
@@ -1175,7 +1176,7 @@ func (s *environSuite) TestInitialPorts(c *gc.C) {
 		c.Assert(err, gc.IsNil)
 		portmap := make(map[int]bool)
 		for _, port := range ports {
-			portmap[port.Number] = true
+			portmap[port.FromPort] = true
 		}
 		return portmap[env.Config().StatePort()] && portmap[env.Config().APIPort()]
 	}
@@ -1449,6 +1450,35 @@ func (s *environSuite) TestSelectInstanceTypeAndImageUsesSimplestreamsByDefault(
 	c.Assert(image, gc.Equals, "image-id")
 }
 
+func (*environSuite) TestInstanceTypeToolsMatchesArch(c *gc.C) {
+	possibleTools := coretools.List{
+		{Version: version.MustParseBinary("1.2.3-precise-i386")},
+		{Version: version.MustParseBinary("1.2.3-precise-amd64")},
+	}
+	instanceType := &instances.InstanceType{Name: "Small", Arches: []string{"amd64"}}
+	picked, err := instanceTypeTools(instanceType, possibleTools)
+	c.Assert(err, gc.IsNil)
+	c.Check(picked.Version, gc.Equals, version.MustParseBinary("1.2.3-precise-amd64"))
+}
+
+func (*environSuite) TestInstanceTypeToolsNoMatchingArch(c *gc.C) {
+	possibleTools := coretools.List{
+		{Version: version.MustParseBinary("1.2.3-precise-i386")},
+	}
+	instanceType := &instances.InstanceType{Name: "Small", Arches: []string{"amd64"}}
+	_, err := instanceTypeTools(instanceType, possibleTools)
+	c.Assert(err, gc.ErrorMatches, `no tools available for instance type "Small" with architectures \[amd64\]`)
+}
+
+func (*environSuite) TestInstanceTypeToolsNoArches(c *gc.C) {
+	possibleTools := coretools.List{
+		{Version: version.MustParseBinary("1.2.3-precise-i386")},
+	}
+	picked, err := instanceTypeTools(&instances.InstanceType{Name: "Small"}, possibleTools)
+	c.Assert(err, gc.IsNil)
+	c.Check(picked, gc.Equals, possibleTools[0])
+}
+
 func (*environSuite) TestExtractStorageKeyPicksPrimaryKeyIfSet(c *gc.C) {
 	keys := gwacl.StorageAccountKeys{
 		Primary:   "mainkey",
@@ -122,7 +122,7 @@ func (azInstance *azureInstance) ipAddress() string {
 }
 
 // OpenPorts is specified in the Instance interface.
-func (azInstance *azureInstance) OpenPorts(machineId string, ports []network.Port) error {
+func (azInstance *azureInstance) OpenPorts(machineId string, ports []network.PortRange) error {
 	return azInstance.apiCall(true, func(context *azureManagementContext) error {
 		return azInstance.openEndpoints(context, ports)
 	})
@@ -144,25 +144,41 @@ func (azInstance *azureInstance) apiCall(lock bool, f func(*azureManagementConte
 	return f(context)
 }
 
+// endpointPorts calls f for each of the ports in the given ranges.
+// Azure endpoints each forward a single port, so ranges must be opened
+// port by port. ICMP cannot be forwarded by an endpoint, so ICMP
+// ranges are skipped.
+func endpointPorts(ports []network.PortRange, f func(protocol string, number int)) {
+	for _, portRange := range ports {
+		if portRange.Protocol == "icmp" {
+			logger.Warningf("ignoring %v: azure endpoints cannot forward icmp", portRange)
+			continue
+		}
+		for number := portRange.FromPort; number <= portRange.ToPort; number++ {
+			f(portRange.Protocol, number)
+		}
+	}
+}
+
 // openEndpoints opens the endpoints in the Azure deployment. The caller is
 // responsible for locking and unlocking the environ and releasing the
 // management context.
-func (azInstance *azureInstance) openEndpoints(context *azureManagementContext, ports []network.Port) error {
+func (azInstance *azureInstance) openEndpoints(context *azureManagementContext, ports []network.PortRange) error {
 	request := &gwacl.AddRoleEndpointsRequest{
 		ServiceName:    azInstance.serviceName(),
 		DeploymentName: azInstance.deploymentName,
 		RoleName:       azInstance.roleName,
 	}
-	for _, port := range ports {
-		name := fmt.Sprintf("%s%d", port.Protocol, port.Number)
+	endpointPorts(ports, func(protocol string, number int) {
+		name := fmt.Sprintf("%s%d", protocol, number)
 		endpoint := gwacl.InputEndpoint{
-			LocalPort: port.Number,
+			LocalPort: number,
 			Name:      name,
-			Port:      port.Number,
-			Protocol:  port.Protocol,
+			Port:      number,
+			Protocol:  protocol,
 		}
 		if azInstance.supportsLoadBalancing() {
-			probePort := port.Number
+			probePort := number
 			if strings.ToUpper(endpoint.Protocol) == "UDP" {
 				// Load balancing needs a TCP port to probe, or an HTTP
 				// server port & path to query. For UDP, we just use the
@@ -180,12 +196,12 @@ func (azInstance *azureInstance) openEndpoints(context *azureManagementContext,
 			}
 		}
 		request.InputEndpoints = append(request.InputEndpoints, endpoint)
-	}
+	})
 	return context.AddRoleEndpoints(request)
 }
 
 // ClosePorts is specified in the Instance interface.
-func (azInstance *azureInstance) ClosePorts(machineId string, ports []network.Port) error {
+func (azInstance *azureInstance) ClosePorts(machineId string, ports []network.PortRange) error {
 	return azInstance.apiCall(true, func(context *azureManagementContext) error {
 		return azInstance.closeEndpoints(context, ports)
 	})
@@ -194,40 +210,43 @@ func (azInstance *azureInstance) ClosePorts(machineId string, ports []network.Po
 // closeEndpoints closes the endpoints in the Azure deployment. The caller is
 // responsible for locking and unlocking the environ and releasing the
 // management context.
-func (azInstance *azureInstance) closeEndpoints(context *azureManagementContext, ports []network.Port) error {
+func (azInstance *azureInstance) closeEndpoints(context *azureManagementContext, ports []network.PortRange) error {
 	request := &gwacl.RemoveRoleEndpointsRequest{
 		ServiceName:    azInstance.serviceName(),
 		DeploymentName: azInstance.deploymentName,
 		RoleName:       azInstance.roleName,
 	}
-	for _, port := range ports {
-		name := fmt.Sprintf("%s%d", port.Protocol, port.Number)
+	endpointPorts(ports, func(protocol string, number int) {
+		name := fmt.Sprintf("%s%d", protocol, number)
 		request.InputEndpoints = append(request.InputEndpoints, gwacl.InputEndpoint{
-			LocalPort:                   port.Number,
+			LocalPort:                   number,
 			Name:                        name,
-			Port:                        port.Number,
-			Protocol:                    port.Protocol,
+			Port:                        number,
+			Protocol:                    protocol,
 			LoadBalancedEndpointSetName: name,
 		})
-	}
+	})
 	return context.RemoveRoleEndpoints(request)
 }
 
-// convertEndpointsToPorts converts a slice of gwacl.InputEndpoint into a slice of network.Port.
-func convertEndpointsToPorts(endpoints []gwacl.InputEndpoint) []network.Port {
-	ports := []network.Port{}
+// convertEndpointsToPorts converts a slice of gwacl.InputEndpoint into a
+// slice of network.PortRange, each holding the single port forwarded by
+// an endpoint.
+func convertEndpointsToPorts(endpoints []gwacl.InputEndpoint) []network.PortRange {
+	ports := []network.PortRange{}
 	for _, endpoint := range endpoints {
-		ports = append(ports, network.Port{
+		ports = append(ports, network.PortRange{
+			FromPort: endpoint.Port,
+			ToPort:   endpoint.Port,
 			Protocol: strings.ToLower(endpoint.Protocol),
-			Number:   endpoint.Port,
 		})
 	}
 	return ports
 }
 
-// convertAndFilterEndpoints converts a slice of gwacl.InputEndpoint into a slice of network.Port
+// convertAndFilterEndpoints converts a slice of gwacl.InputEndpoint into a slice of network.PortRange
 // and filters out the initial endpoints that every instance should have opened (ssh port, etc.).
-func convertAndFilterEndpoints(endpoints []gwacl.InputEndpoint, env *azureEnviron, stateServer bool) []network.Port {
+func convertAndFilterEndpoints(endpoints []gwacl.InputEndpoint, env *azureEnviron, stateServer bool) []network.PortRange {
 	return firewaller.Diff(
 		convertEndpointsToPorts(endpoints),
 		convertEndpointsToPorts(env.getInitialEndpoints(stateServer)),
@@ -235,23 +254,23 @@ func convertAndFilterEndpoints(endpoints []gwacl.InputEndpoint, env *azureEnviro
 }
 
 // Ports is specified in the Instance interface.
-func (azInstance *azureInstance) Ports(machineId string) (ports []network.Port, err error) {
+func (azInstance *azureInstance) Ports(machineId string) (ports []network.PortRange, err error) {
 	err = azInstance.apiCall(false, func(context *azureManagementContext) error {
 		ports, err = azInstance.listPorts(context)
 		return err
 	})
 	if ports != nil {
-		network.SortPorts(ports)
+		network.SortPortRanges(ports)
 	}
 	return ports, err
 }
 
-// listPorts returns the slice of ports (network.Port) that this machine
+// listPorts returns the slice of ports (network.PortRange) that this machine
 // has opened. The returned list does not contain the "initial ports"
 // (i.e. the ports every instance shoud have opened). The caller is
 // responsible for locking and unlocking the environ and releasing the
 // management context.
-func (azInstance *azureInstance) listPorts(context *azureManagementContext) ([]network.Port, error) {
+func (azInstance *azureInstance) listPorts(context *azureManagementContext) ([]network.PortRange, error) {
 	endpoints, err := context.ListRoleEndpoints(&gwacl.ListRoleEndpointsRequest{
 		ServiceName:    azInstance.serviceName(),
 		DeploymentName: azInstance.deploymentName,
@@ -99,6 +99,18 @@ func (storage *azureStorage) URL(name string) (string, error) {
 	return context.GetFileURL(storage.getContainer(), name), nil
 }
 
+// SignedURL is specified in the URLSigner interface.
+func (storage *azureStorage) SignedURL(name string, expires time.Time) (string, error) {
+	context, err := storage.getStorageContext()
+	if err != nil {
+		return "", err
+	}
+	if context.Key == "" {
+		return "", errors.NotSupportedf("signed URLs without a storage account key")
+	}
+	return context.GetAnonymousFileURL(storage.getContainer(), name, expires)
+}
+
 // DefaultConsistencyStrategy is specified in the StorageReader interface.
 func (storage *azureStorage) DefaultConsistencyStrategy() utils.AttemptStrategy {
 	// This storage backend has immediate consistency, so there's no
@@ -154,7 +154,7 @@ func DistributeInstances(env ZonedEnviron, candidates, group []instance.Id) ([]i
 	eligible := make([]instance.Id, 0, len(candidates))
 	for _, candidate := range candidates {
 		n := sort.SearchStrings(allEligible, string(candidate))
-		if n >= 0 && n < len(allEligible) {
+		if n < len(allEligible) && allEligible[n] == string(candidate) {
 			eligible = append(eligible, candidate)
 		}
 	}
@@ -219,6 +219,16 @@ func (s *AvailabilityZoneSuite) TestDistributeInstances(c *gc.C) {
 		}},
 		candidates: []instance.Id{"i3", "i4", "i5"},
 		eligible:   []instance.Id{},
+	}, {
+		zoneInstances: []common.AvailabilityZoneInstances{{
+			ZoneName:  "az0",
+			Instances: []instance.Id{"i0"},
+		}, {
+			ZoneName:  "az1",
+			Instances: []instance.Id{"i2"},
+		}},
+		candidates: []instance.Id{"i1", "i2"},
+		eligible:   []instance.Id{"i2"},
 	}, {
 		zoneInstances: []common.AvailabilityZoneInstances{{
 			ZoneName:  "az0",
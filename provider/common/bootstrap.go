@@ -35,12 +35,14 @@ var logger = loggo.GetLogger("juju.provider.common")
 // environs.Environ; we strongly recommend that this implementation be used
 // when writing a new provider.
 func Bootstrap(ctx environs.BootstrapContext, env environs.Environ, args environs.BootstrapParams) (err error) {
-	// TODO make safe in the case of racing Bootstraps
-	// If two Bootstraps are called concurrently, there's
-	// no way to make sure that only one succeeds.
-
+	// The state file is only written once there is an instance to
+	// record in it, so that an interrupted bootstrap does not leave
+	// the environment looking bootstrapped. It is only removed on
+	// failure if this Bootstrap wrote it, so that a concurrent
+	// Bootstrap's state is left alone.
 	var inst instance.Instance
-	defer func() { handleBootstrapError(err, ctx, inst, env) }()
+	var claimed bool
+	defer func() { handleBootstrapError(err, ctx, inst, env, args.KeepBroken, claimed) }()
 
 	network.InitializeFromConfig(env.Config())
 
@@ -79,13 +81,20 @@ func Bootstrap(ctx environs.BootstrapContext, env environs.Environ, args environ
 	machineConfig.InstanceId = inst.Id()
 	machineConfig.HardwareCharacteristics = hw
 
-	err = SaveState(env.Storage(), &BootstrapState{
+	// If two Bootstraps are called concurrently, only the first
+	// to record its instance proceeds.
+	err = claimStateFile(env.Storage(), &BootstrapState{
 		StateInstances: []instance.Id{inst.Id()},
+		Phase:          PhaseBootstrapping,
 	})
 	if err != nil {
-		return fmt.Errorf("cannot save state: %v", err)
+		return err
+	}
+	claimed = true
+	if err := FinishBootstrap(ctx, client, inst, machineConfig); err != nil {
+		return err
 	}
-	return FinishBootstrap(ctx, client, inst, machineConfig)
+	return setStatePhase(env.Storage(), PhaseReady)
 }
 
 // GenerateSystemSSHKey creates a new key for the system identity. The
@@ -111,13 +120,21 @@ func GenerateSystemSSHKey(env environs.Environ) (privateKey string, err error) {
 	return privateKey, nil
 }
 
-// handleBootstrapError cleans up after a failed bootstrap.
-func handleBootstrapError(err error, ctx environs.BootstrapContext, inst instance.Instance, env environs.Environ) {
+// handleBootstrapError cleans up after a failed bootstrap, unless
+// keepBroken is true. The state file is only removed if claimed
+// is true, meaning that the failed bootstrap wrote it.
+func handleBootstrapError(err error, ctx environs.BootstrapContext, inst instance.Instance, env environs.Environ, keepBroken, claimed bool) {
 	if err == nil {
 		return
 	}
 
 	logger.Errorf("bootstrap failed: %v", err)
+	if keepBroken {
+		if inst != nil {
+			fmt.Fprintf(ctx.GetStderr(), "Keeping instance %s for debugging\n", inst.Id())
+		}
+		return
+	}
 	ch := make(chan os.Signal, 1)
 	ctx.InterruptNotify(ch)
 	defer ctx.StopInterruptNotify(ch)
@@ -137,9 +154,10 @@ func handleBootstrapError(err error, ctx environs.BootstrapContext, inst instanc
 			inst = nil
 		}
 	}
-	// We only delete the bootstrap state file if either we didn't
-	// start an instance, or we managed to cleanly stop it.
-	if inst == nil {
+	// We only delete the bootstrap state file if we wrote it, and
+	// either we didn't start an instance, or we managed to cleanly
+	// stop it.
+	if inst == nil && claimed {
 		if rmerr := DeleteStateFile(env.Storage()); rmerr != nil {
 			logger.Errorf("cannot delete bootstrap state file: %v", rmerr)
 		}
@@ -198,6 +216,7 @@ var FinishBootstrap = func(ctx environs.BootstrapContext, client ssh.Client, ins
 		return err
 	}
 	script := shell.DumpFileOnErrorScript(machineConfig.CloudInitOutputLog) + configScript
+	fmt.Fprintln(ctx.GetStderr(), "Installing Juju agent on bootstrap instance")
 	return sshinit.RunConfigureScript(script, sshinit.ConfigureParams{
 		Host:           "ubuntu@" + addr,
 		Client:         client,
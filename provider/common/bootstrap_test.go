@@ -138,6 +138,39 @@ func (s *BootstrapSuite) TestCannotRecordStartedInstance(c *gc.C) {
 	c.Assert(stopped[0], gc.Equals, instance.Id("i-blah"))
 }
 
+func (s *BootstrapSuite) TestCannotRecordStartedInstanceKeepBroken(c *gc.C) {
+	innerStorage := newStorage(s, c)
+	stor := &mockStorage{Storage: innerStorage}
+
+	startInstance := func(
+		_ string, _ constraints.Value, _ []string, _ tools.List, _ *cloudinit.MachineConfig,
+	) (
+		instance.Instance, *instance.HardwareCharacteristics, []network.Info, error,
+	) {
+		stor.putErr = fmt.Errorf("suddenly a wild blah")
+		return &mockInstance{id: "i-blah"}, nil, nil, nil
+	}
+
+	var stopped []instance.Id
+	stopInstances := func(ids []instance.Id) error {
+		stopped = append(stopped, ids...)
+		return nil
+	}
+
+	env := &mockEnviron{
+		storage:       stor,
+		startInstance: startInstance,
+		stopInstances: stopInstances,
+		config:        configGetter(c),
+	}
+
+	ctx := coretesting.Context(c)
+	err := common.Bootstrap(ctx, env, environs.BootstrapParams{KeepBroken: true})
+	c.Assert(err, gc.ErrorMatches, "cannot save state: suddenly a wild blah")
+	c.Assert(stopped, gc.HasLen, 0)
+	c.Assert(coretesting.Stderr(ctx), jc.Contains, "Keeping instance i-blah for debugging\n")
+}
+
 func (s *BootstrapSuite) TestCannotRecordThenCannotStop(c *gc.C) {
 	innerStorage := newStorage(s, c)
 	stor := &mockStorage{Storage: innerStorage}
@@ -178,6 +211,71 @@ func (s *BootstrapSuite) TestCannotRecordThenCannotStop(c *gc.C) {
 	}})
 }
 
+func (s *BootstrapSuite) TestStateFileAlreadyClaimed(c *gc.C) {
+	stor := newStorage(s, c)
+	err := common.SaveState(stor, &common.BootstrapState{
+		StateInstances: []instance.Id{"i-other"},
+	})
+	c.Assert(err, gc.IsNil)
+
+	startInstance := func(
+		_ string, _ constraints.Value, _ []string, _ tools.List, _ *cloudinit.MachineConfig,
+	) (
+		instance.Instance, *instance.HardwareCharacteristics, []network.Info, error,
+	) {
+		return &mockInstance{id: "i-blah"}, nil, nil, nil
+	}
+	var stopped []instance.Id
+	stopInstances := func(ids []instance.Id) error {
+		stopped = append(stopped, ids...)
+		return nil
+	}
+	env := &mockEnviron{
+		storage:       stor,
+		startInstance: startInstance,
+		stopInstances: stopInstances,
+		config:        configGetter(c),
+	}
+
+	ctx := coretesting.Context(c)
+	err = common.Bootstrap(ctx, env, environs.BootstrapParams{})
+	c.Assert(err, gc.Equals, environs.ErrAlreadyBootstrapped)
+
+	// The instance started by the losing bootstrap is stopped.
+	c.Assert(stopped, gc.DeepEquals, []instance.Id{"i-blah"})
+
+	// The other bootstrap's state is left alone.
+	state, err := common.LoadState(stor)
+	c.Assert(err, gc.IsNil)
+	c.Assert(state.StateInstances, gc.DeepEquals, []instance.Id{"i-other"})
+}
+
+func (s *BootstrapSuite) TestCannotStartInstanceLeavesNoStateFile(c *gc.C) {
+	stor := newStorage(s, c)
+	startInstance := func(
+		_ string, _ constraints.Value, _ []string, _ tools.List, _ *cloudinit.MachineConfig,
+	) (
+		instance.Instance, *instance.HardwareCharacteristics, []network.Info, error,
+	) {
+		// Nothing is recorded until there is an instance,
+		// so an interrupted bootstrap can be retried.
+		_, err := common.LoadState(stor)
+		c.Check(err, gc.Equals, environs.ErrNotBootstrapped)
+		return nil, nil, nil, fmt.Errorf("meh, not started")
+	}
+	env := &mockEnviron{
+		storage:       stor,
+		startInstance: startInstance,
+		config:        configGetter(c),
+	}
+
+	ctx := coretesting.Context(c)
+	err := common.Bootstrap(ctx, env, environs.BootstrapParams{})
+	c.Assert(err, gc.ErrorMatches, "cannot start bootstrap instance: meh, not started")
+	_, err = common.LoadState(stor)
+	c.Assert(err, gc.Equals, environs.ErrNotBootstrapped)
+}
+
 func (s *BootstrapSuite) TestSuccess(c *gc.C) {
 	stor := newStorage(s, c)
 	checkInstanceId := "i-success"
@@ -218,6 +316,11 @@ func (s *BootstrapSuite) TestSuccess(c *gc.C) {
 	authKeys := env.Config().AuthorizedKeys()
 	c.Assert(authKeys, gc.Not(gc.Equals), originalAuthKeys)
 	c.Assert(authKeys, jc.HasSuffix, "juju-system-key\n")
+
+	state, err := common.LoadState(stor)
+	c.Assert(err, gc.IsNil)
+	c.Assert(state.StateInstances, gc.DeepEquals, []instance.Id{"i-success"})
+	c.Assert(state.Phase, gc.Equals, common.PhaseReady)
 }
 
 type neverRefreshes struct {
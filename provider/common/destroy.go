@@ -13,6 +13,12 @@ import (
 // used when writing a new provider.
 func Destroy(env environs.Environ) error {
 	logger.Infof("destroying environment %q", env.Config().Name())
+	// Recording the phase is not allowed to stop a broken
+	// environment from being destroyed.
+	err := setStatePhase(env.Storage(), PhaseDestroying)
+	if err != nil && err != environs.ErrNotBootstrapped {
+		logger.Warningf("cannot record environment destruction: %v", err)
+	}
 	instances, err := env.AllInstances()
 	switch err {
 	case nil:
@@ -25,6 +25,7 @@ var _ = gc.Suite(&DestroySuite{})
 
 func (s *DestroySuite) TestCannotGetInstances(c *gc.C) {
 	env := &mockEnviron{
+		storage: newStorage(s, c),
 		allInstances: func() ([]instance.Instance, error) {
 			return nil, fmt.Errorf("nope")
 		},
@@ -36,6 +37,7 @@ func (s *DestroySuite) TestCannotGetInstances(c *gc.C) {
 
 func (s *DestroySuite) TestCannotStopInstances(c *gc.C) {
 	env := &mockEnviron{
+		storage: newStorage(s, c),
 		allInstances: func() ([]instance.Instance, error) {
 			return []instance.Instance{
 				&mockInstance{id: "one"},
@@ -56,7 +58,10 @@ func (s *DestroySuite) TestCannotStopInstances(c *gc.C) {
 
 func (s *DestroySuite) TestCannotTrashStorage(c *gc.C) {
 	env := &mockEnviron{
-		storage: &mockStorage{removeAllErr: fmt.Errorf("noes!")},
+		storage: &mockStorage{
+			Storage:      newStorage(s, c),
+			removeAllErr: fmt.Errorf("noes!"),
+		},
 		allInstances: func() ([]instance.Instance, error) {
 			return []instance.Instance{
 				&mockInstance{id: "one"},
@@ -75,6 +80,29 @@ func (s *DestroySuite) TestCannotTrashStorage(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "noes!")
 }
 
+func (s *DestroySuite) TestRecordsDestroyingPhase(c *gc.C) {
+	stor := newStorage(s, c)
+	err := common.SaveState(stor, &common.BootstrapState{
+		StateInstances: []instance.Id{"one"},
+		Phase:          common.PhaseReady,
+	})
+	c.Assert(err, gc.IsNil)
+
+	env := &mockEnviron{
+		storage: stor,
+		allInstances: func() ([]instance.Instance, error) {
+			// The phase is recorded before anything is destroyed.
+			state, err := common.LoadState(stor)
+			c.Check(err, gc.IsNil)
+			c.Check(state.Phase, gc.Equals, common.PhaseDestroying)
+			return nil, fmt.Errorf("nope")
+		},
+		config: configGetter(c),
+	}
+	err = common.Destroy(env)
+	c.Assert(err, gc.ErrorMatches, "nope")
+}
+
 func (s *DestroySuite) TestSuccess(c *gc.C) {
 	stor := newStorage(s, c)
 	err := stor.Put("somewhere", strings.NewReader("stuff"), 5)
@@ -102,7 +130,10 @@ func (s *DestroySuite) TestSuccess(c *gc.C) {
 
 func (s *DestroySuite) TestCannotTrashStorageWhenNoInstances(c *gc.C) {
 	env := &mockEnviron{
-		storage: &mockStorage{removeAllErr: fmt.Errorf("noes!")},
+		storage: &mockStorage{
+			Storage:      newStorage(s, c),
+			removeAllErr: fmt.Errorf("noes!"),
+		},
 		allInstances: func() ([]instance.Instance, error) {
 			return nil, environs.ErrNoInstances
 		},
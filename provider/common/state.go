@@ -28,8 +28,30 @@ const StateFile = "provider-state"
 type BootstrapState struct {
 	// StateInstances are the state servers.
 	StateInstances []instance.Id `yaml:"state-instances"`
+
+	// Phase holds the phase of the environment's lifecycle, so
+	// that clients can tell a bootstrap in progress from an API
+	// server that cannot be reached. It is empty in state files
+	// written by earlier versions.
+	Phase string `yaml:"phase,omitempty"`
 }
 
+// The phases of an environment's lifecycle that are recorded in the
+// state file. PhaseReady and PhaseDestroying match the phases reported
+// by the API server.
+const (
+	// PhaseBootstrapping records that the bootstrap instance has
+	// been started, but bootstrap has not yet completed.
+	PhaseBootstrapping = "bootstrapping"
+
+	// PhaseReady records that bootstrap has completed.
+	PhaseReady = "ready"
+
+	// PhaseDestroying records that the environment is being
+	// destroyed.
+	PhaseDestroying = "destroying"
+)
+
 // putState writes the given data to the state file on the given storage.
 // The file's name is as defined in StateFile.
 func putState(stor storage.StorageWriter, data []byte) error {
@@ -37,6 +59,25 @@ func putState(stor storage.StorageWriter, data []byte) error {
 	return stor.Put(StateFile, bytes.NewBuffer(data), int64(len(data)))
 }
 
+// claimStateFile writes the given state to the state file on the
+// given storage. It fails with environs.ErrAlreadyBootstrapped if the
+// file already exists, so that only one of several concurrent
+// bootstraps proceeds.
+func claimStateFile(stor storage.Storage, state *BootstrapState) error {
+	data, err := goyaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("claiming %q in bootstrap storage %T", StateFile, stor)
+	err = storage.PutIfAbsent(stor, StateFile, bytes.NewReader(data), int64(len(data)))
+	if errors.IsAlreadyExists(err) {
+		return environs.ErrAlreadyBootstrapped
+	} else if err != nil {
+		return fmt.Errorf("cannot save state: %v", err)
+	}
+	return nil
+}
+
 // CreateStateFile creates an empty state file on the given storage, and
 // returns its URL.
 func CreateStateFile(stor storage.Storage) (string, error) {
@@ -61,6 +102,18 @@ func SaveState(storage storage.StorageWriter, state *BootstrapState) error {
 	return putState(storage, data)
 }
 
+// setStatePhase records the given phase in the state file on the
+// given storage. It returns environs.ErrNotBootstrapped if there is
+// no state file.
+func setStatePhase(stor storage.Storage, phase string) error {
+	state, err := LoadState(stor)
+	if err != nil {
+		return err
+	}
+	state.Phase = phase
+	return SaveState(stor, state)
+}
+
 // LoadState reads state from the given storage.
 func LoadState(stor storage.StorageReader) (*BootstrapState, error) {
 	r, err := storage.Get(stor, StateFile)
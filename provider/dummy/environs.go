@@ -14,6 +14,9 @@
 // after the environment has been opened will return
 // the error "broken environment", and will also log that.
 //
+// Tests may also make individual operations fail, or respond
+// slowly, on demand; see InjectFailure and SetMethodDelay.
+//
 // The DNS name of instances is the same as the Id,
 // with ".dns" appended.
 //
@@ -167,14 +170,14 @@ type OpOpenPorts struct {
 	Env        string
 	MachineId  string
 	InstanceId instance.Id
-	Ports      []network.Port
+	Ports      []network.PortRange
 }
 
 type OpClosePorts struct {
 	Env        string
 	MachineId  string
 	InstanceId instance.Id
-	Ports      []network.Port
+	Ports      []network.PortRange
 }
 
 type OpPutFile struct {
@@ -209,7 +212,7 @@ type environState struct {
 	maxId        int // maximum instance id allocated so far.
 	maxAddr      int // maximum allocated address last byte
 	insts        map[instance.Id]*dummyInstance
-	globalPorts  map[network.Port]bool
+	globalPorts  map[network.PortRange]bool
 	bootstrapped bool
 	storageDelay time.Duration
 	storage      *storageServer
@@ -233,6 +236,7 @@ type environ struct {
 var _ imagemetadata.SupportsCustomSources = (*environ)(nil)
 var _ tools.SupportsCustomSources = (*environ)(nil)
 var _ environs.Environ = (*environ)(nil)
+var _ environs.IdempotentStarter = (*environ)(nil)
 
 // discardOperations discards all Operations written to it.
 var discardOperations chan<- Operation
@@ -275,6 +279,7 @@ func Reset() {
 		gitjujutesting.MgoServer.Reset()
 	}
 	providerInstance.statePolicy = environs.NewStatePolicy()
+	ClearFailures()
 }
 
 func (state *environState) destroy() {
@@ -326,7 +331,7 @@ func newState(name string, ops chan<- Operation, policy state.Policy) *environSt
 		ops:         ops,
 		statePolicy: policy,
 		insts:       make(map[instance.Id]*dummyInstance),
-		globalPorts: make(map[network.Port]bool),
+		globalPorts: make(map[network.PortRange]bool),
 	}
 	s.storage = newStorageServer(s, "/"+name+"/private")
 	s.listenStorage()
@@ -579,7 +584,7 @@ func (e *environ) checkBroken(method string) error {
 			return fmt.Errorf("dummy.%s is broken", method)
 		}
 	}
-	return nil
+	return checkInjected(method)
 }
 
 // SupportedArchitectures is specified on the EnvironCapability interface.
@@ -654,7 +659,7 @@ func (e *environ) Bootstrap(ctx environs.BootstrapContext, args environs.Bootstr
 	i := &dummyInstance{
 		id:           BootstrapInstanceId,
 		addresses:    network.NewAddresses("localhost"),
-		ports:        make(map[network.Port]bool),
+		ports:        make(map[network.PortRange]bool),
 		machineId:    agent.BootstrapMachineId,
 		series:       series,
 		firewallMode: e.Config().FirewallMode(),
@@ -797,6 +802,14 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	if args.MachineConfig.APIInfo.Tag != names.NewMachineTag(machineId) {
 		return nil, nil, nil, fmt.Errorf("entity tag must match started machine")
 	}
+	if args.IdempotencyToken != "" {
+		for _, inst := range estate.insts {
+			if inst.idempotencyToken == args.IdempotencyToken {
+				logger.Infof("instance %s already started for machine %s", inst.id, machineId)
+				return inst, inst.hardware, inst.networkInfo, nil
+			}
+		}
+	}
 	logger.Infof("would pick tools from %s", args.Tools)
 	series := args.Tools.OneSeries()
 
@@ -809,7 +822,7 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	i := &dummyInstance{
 		id:           instance.Id(idString),
 		addresses:    addrs,
-		ports:        make(map[network.Port]bool),
+		ports:        make(map[network.PortRange]bool),
 		machineId:    machineId,
 		series:       series,
 		firewallMode: e.Config().FirewallMode(),
@@ -870,6 +883,9 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 			}
 		}
 	}
+	i.idempotencyToken = args.IdempotencyToken
+	i.hardware = hc
+	i.networkInfo = networkInfo
 	estate.insts[i.id] = i
 	estate.maxId++
 	estate.ops <- OpStartInstance{
@@ -886,9 +902,17 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 		APIInfo:       args.MachineConfig.APIInfo,
 		Secret:        e.ecfg().secret(),
 	}
+	if err := checkInjected("StartInstance.Started"); err != nil {
+		return nil, nil, nil, err
+	}
 	return i, hc, networkInfo, nil
 }
 
+// StartInstanceIsIdempotent is specified in the IdempotentStarter interface.
+func (*environ) StartInstanceIsIdempotent() bool {
+	return true
+}
+
 func (e *environ) StopInstances(ids ...instance.Id) error {
 	defer delay()
 	if err := e.checkBroken("StopInstance"); err != nil {
@@ -1014,10 +1038,13 @@ func (e *environ) AllInstances() ([]instance.Instance, error) {
 	return insts, nil
 }
 
-func (e *environ) OpenPorts(ports []network.Port) error {
+func (e *environ) OpenPorts(ports []network.PortRange) error {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment", mode)
 	}
+	if err := checkInjected("OpenPorts"); err != nil {
+		return err
+	}
 	estate, err := e.state()
 	if err != nil {
 		return err
@@ -1030,10 +1057,13 @@ func (e *environ) OpenPorts(ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) ClosePorts(ports []network.Port) error {
+func (e *environ) ClosePorts(ports []network.PortRange) error {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on environment", mode)
 	}
+	if err := checkInjected("ClosePorts"); err != nil {
+		return err
+	}
 	estate, err := e.state()
 	if err != nil {
 		return err
@@ -1046,10 +1076,13 @@ func (e *environ) ClosePorts(ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) Ports() (ports []network.Port, err error) {
+func (e *environ) Ports() (ports []network.PortRange, err error) {
 	if mode := e.ecfg().FirewallMode(); mode != config.FwGlobal {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from environment", mode)
 	}
+	if err := checkInjected("Ports"); err != nil {
+		return nil, err
+	}
 	estate, err := e.state()
 	if err != nil {
 		return nil, err
@@ -1059,7 +1092,7 @@ func (e *environ) Ports() (ports []network.Port, err error) {
 	for p := range estate.globalPorts {
 		ports = append(ports, p)
 	}
-	network.SortPorts(ports)
+	network.SortPortRanges(ports)
 	return
 }
 
@@ -1069,7 +1102,7 @@ func (*environ) Provider() environs.EnvironProvider {
 
 type dummyInstance struct {
 	state        *environState
-	ports        map[network.Port]bool
+	ports        map[network.PortRange]bool
 	id           instance.Id
 	status       string
 	machineId    string
@@ -1077,6 +1110,12 @@ type dummyInstance struct {
 	firewallMode string
 	stateServer  bool
 
+	// idempotencyToken, hardware and networkInfo record the
+	// request that started the instance and the results returned.
+	idempotencyToken string
+	hardware         *instance.HardwareCharacteristics
+	networkInfo      []network.Info
+
 	mu        sync.Mutex
 	addresses []network.Address
 }
@@ -1117,7 +1156,7 @@ func (inst *dummyInstance) Addresses() ([]network.Address, error) {
 	return append([]network.Address{}, inst.addresses...), nil
 }
 
-func (inst *dummyInstance) OpenPorts(machineId string, ports []network.Port) error {
+func (inst *dummyInstance) OpenPorts(machineId string, ports []network.PortRange) error {
 	defer delay()
 	logger.Infof("openPorts %s, %#v", machineId, ports)
 	if inst.firewallMode != config.FwInstance {
@@ -1127,6 +1166,9 @@ func (inst *dummyInstance) OpenPorts(machineId string, ports []network.Port) err
 	if inst.machineId != machineId {
 		panic(fmt.Errorf("OpenPorts with mismatched machine id, expected %q got %q", inst.machineId, machineId))
 	}
+	if err := checkInjected("OpenPorts"); err != nil {
+		return err
+	}
 	inst.state.mu.Lock()
 	defer inst.state.mu.Unlock()
 	inst.state.ops <- OpOpenPorts{
@@ -1141,7 +1183,7 @@ func (inst *dummyInstance) OpenPorts(machineId string, ports []network.Port) err
 	return nil
 }
 
-func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) error {
+func (inst *dummyInstance) ClosePorts(machineId string, ports []network.PortRange) error {
 	defer delay()
 	if inst.firewallMode != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on instance",
@@ -1150,6 +1192,9 @@ func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) er
 	if inst.machineId != machineId {
 		panic(fmt.Errorf("ClosePorts with mismatched machine id, expected %s got %s", inst.machineId, machineId))
 	}
+	if err := checkInjected("ClosePorts"); err != nil {
+		return err
+	}
 	inst.state.mu.Lock()
 	defer inst.state.mu.Unlock()
 	inst.state.ops <- OpClosePorts{
@@ -1164,7 +1209,7 @@ func (inst *dummyInstance) ClosePorts(machineId string, ports []network.Port) er
 	return nil
 }
 
-func (inst *dummyInstance) Ports(machineId string) (ports []network.Port, err error) {
+func (inst *dummyInstance) Ports(machineId string) (ports []network.PortRange, err error) {
 	defer delay()
 	if inst.firewallMode != config.FwInstance {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from instance",
@@ -1178,7 +1223,7 @@ func (inst *dummyInstance) Ports(machineId string) (ports []network.Port, err er
 	for p := range inst.ports {
 		ports = append(ports, p)
 	}
-	network.SortPorts(ports)
+	network.SortPortRanges(ports)
 	return
 }
 
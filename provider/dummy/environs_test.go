@@ -4,10 +4,14 @@
 package dummy_test
 
 import (
+	"errors"
+	"io/ioutil"
 	"net/url"
+	"strings"
 	stdtesting "testing"
 	"time"
 
+	jujuerrors "github.com/juju/errors"
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
@@ -15,6 +19,7 @@ import (
 	"github.com/juju/juju/environs/bootstrap"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/jujutest"
+	"github.com/juju/juju/environs/storage"
 	envtesting "github.com/juju/juju/environs/testing"
 	"github.com/juju/juju/instance"
 	jujutesting "github.com/juju/juju/juju/testing"
@@ -168,6 +173,103 @@ func (s *suite) TestPreferIPv6Off(c *gc.C) {
 	c.Assert(toolsURL.Host, gc.Matches, `127\.0\.0\.1:\d+`)
 }
 
+func (s *suite) TestInjectFailureCount(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+
+	dummy.InjectFailure("StartInstance", errors.New("no capacity"), 2)
+	for i := 0; i < 2; i++ {
+		_, _, _, err := jujutesting.StartInstance(e, "0")
+		c.Assert(err, gc.ErrorMatches, "no capacity")
+	}
+	inst, _ := jujutesting.AssertStartInstance(c, e, "0")
+	c.Assert(inst, gc.NotNil)
+}
+
+func (s *suite) TestInjectFailureUntilCleared(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	inst, _ := jujutesting.AssertStartInstance(c, e, "0")
+
+	dummy.InjectFailure("StopInstance", errors.New("api unavailable"), 0)
+	for i := 0; i < 3; i++ {
+		err := e.StopInstances(inst.Id())
+		c.Assert(err, gc.ErrorMatches, "api unavailable")
+	}
+	dummy.ClearFailures()
+	err := e.StopInstances(inst.Id())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *suite) TestStartInstanceIdempotencyToken(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+
+	dummy.InjectFailure("StartInstance.Started", errors.New("request timed out"), 1)
+	params := environs.StartInstanceParams{IdempotencyToken: "token-1"}
+	_, _, _, err := jujutesting.StartInstanceWithParams(e, "0", params, nil)
+	c.Assert(err, gc.ErrorMatches, "request timed out")
+
+	// Retrying with the same token returns the instance started
+	// by the failed call; another token starts another instance.
+	inst0, _, _, err := jujutesting.StartInstanceWithParams(e, "0", params, nil)
+	c.Assert(err, gc.IsNil)
+	again, _, _, err := jujutesting.StartInstanceWithParams(e, "0", params, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(again.Id(), gc.Equals, inst0.Id())
+	params.IdempotencyToken = "token-2"
+	inst1, _, _, err := jujutesting.StartInstanceWithParams(e, "1", params, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(inst1.Id(), gc.Not(gc.Equals), inst0.Id())
+
+	insts, err := e.AllInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(insts, gc.HasLen, 3) // including the bootstrap instance
+}
+
+func (s *suite) TestInjectStorageFailure(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	stor := e.Storage()
+
+	dummy.InjectFailure("Storage.Put", errors.New("disk full"), 1)
+	err := stor.Put("foo", strings.NewReader("bar"), 3)
+	c.Assert(err, gc.ErrorMatches, "disk full")
+	err = stor.Put("foo", strings.NewReader("bar"), 3)
+	c.Assert(err, gc.IsNil)
+
+	dummy.InjectFailure("Storage.List", errors.New("timed out"), 0)
+	_, err = stor.List("")
+	c.Assert(err, gc.ErrorMatches, "timed out")
+	dummy.InjectFailure("Storage.List", nil, 0)
+	names, err := stor.List("foo")
+	c.Assert(err, gc.IsNil)
+	c.Assert(names, gc.DeepEquals, []string{"foo"})
+}
+
+func (s *suite) TestStoragePutIfAbsent(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+	stor := e.Storage()
+
+	err := storage.PutIfAbsent(stor, "foo", strings.NewReader("bar"), 3)
+	c.Assert(err, gc.IsNil)
+	err = storage.PutIfAbsent(stor, "foo", strings.NewReader("baz"), 3)
+	c.Assert(err, jc.Satisfies, jujuerrors.IsAlreadyExists)
+	r, err := stor.Get("foo")
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "bar")
+}
+
+func (s *suite) TestSetMethodDelay(c *gc.C) {
+	e := s.bootstrapTestEnviron(c, false)
+
+	dummy.SetMethodDelay("AllInstances", 100*time.Millisecond)
+	start := time.Now()
+	_, err := e.AllInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(time.Since(start) >= 100*time.Millisecond, jc.IsTrue)
+
+}
+
 func assertAllocateAddress(c *gc.C, e environs.Environ, opc chan dummy.Operation, expectInstId instance.Id, expectNetId network.Id, expectAddress network.Address) {
 	select {
 	case op := <-opc:
@@ -0,0 +1,100 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package dummy
+
+import (
+	"sync"
+	"time"
+)
+
+// failures holds the failures and delays injected into
+// dummy environments. They apply to all environments,
+// and are cleared by Reset.
+var failures = struct {
+	mu       sync.Mutex
+	injected map[string]*injectedFailure
+	delays   map[string]time.Duration
+}{
+	injected: make(map[string]*injectedFailure),
+	delays:   make(map[string]time.Duration),
+}
+
+type injectedFailure struct {
+	err error
+	// remaining holds the number of calls left to fail,
+	// or -1 if all calls should fail.
+	remaining int
+}
+
+// InjectFailure causes the named method of any dummy environment to
+// fail with the given error. If count is positive, only the next count
+// calls fail; otherwise calls fail until ClearFailures or Reset is
+// called. Injecting a nil error removes any failure for the method.
+//
+// Environ methods are named as for the "broken" configuration
+// attribute (for example "StartInstance", "StopInstance",
+// "OpenPorts"); storage operations are named "Storage.Get",
+// "Storage.Put", "Storage.Remove" and "Storage.List". Failures
+// injected for "StartInstance.Started" are returned by StartInstance
+// after the instance has been started, as when a request times out.
+func InjectFailure(method string, err error, count int) {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+	if err == nil {
+		delete(failures.injected, method)
+		return
+	}
+	if count <= 0 {
+		count = -1
+	}
+	failures.injected[method] = &injectedFailure{err, count}
+}
+
+// SetMethodDelay causes each call to the named method of any dummy
+// environment to be delayed by d before it proceeds, simulating a
+// slow provider. Methods are named as for InjectFailure. A zero
+// duration removes the delay.
+func SetMethodDelay(method string, d time.Duration) {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+	if d <= 0 {
+		delete(failures.delays, method)
+		return
+	}
+	failures.delays[method] = d
+}
+
+// ClearFailures removes all injected failures and delays.
+func ClearFailures() {
+	failures.mu.Lock()
+	defer failures.mu.Unlock()
+	failures.injected = make(map[string]*injectedFailure)
+	failures.delays = make(map[string]time.Duration)
+}
+
+// checkInjected applies any delay injected for the named method
+// and returns any failure injected for it.
+func checkInjected(method string) error {
+	failures.mu.Lock()
+	d := failures.delays[method]
+	var err error
+	if f := failures.injected[method]; f != nil {
+		err = f.err
+		if f.remaining > 0 {
+			f.remaining--
+			if f.remaining == 0 {
+				delete(failures.injected, method)
+			}
+		}
+	}
+	failures.mu.Unlock()
+	if d > 0 {
+		logger.Infof("pausing %s for %v", method, d)
+		time.Sleep(d)
+	}
+	if err != nil {
+		logger.Infof("injected failure in %s: %v", method, err)
+	}
+	return err
+}
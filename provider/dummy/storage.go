@@ -99,6 +99,9 @@ func (s *storageServer) dataWithDelay(path string) (data []byte, err error) {
 }
 
 func (s *storageServer) Put(name string, r io.Reader, length int64) error {
+	if err := checkInjected("Storage.Put"); err != nil {
+		return err
+	}
 	// Allow Put to be poisoned as well.
 	if err := s.poisoned[name]; err != nil {
 		return err
@@ -119,7 +122,33 @@ func (s *storageServer) Put(name string, r io.Reader, length int64) error {
 	return nil
 }
 
+func (s *storageServer) PutIfAbsent(name string, r io.Reader, length int64) error {
+	if err := checkInjected("Storage.Put"); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return err
+	}
+	s.state.mu.Lock()
+	_, exists := s.files[name]
+	if !exists {
+		s.files[name] = buf.Bytes()
+	}
+	s.state.mu.Unlock()
+	if exists {
+		return errors.AlreadyExistsf("file %q", name)
+	}
+	if strings.HasSuffix(s.path, "/private") {
+		s.state.ops <- OpPutFile{s.state.name, name}
+	}
+	return nil
+}
+
 func (s *storageServer) Get(name string) (io.ReadCloser, error) {
+	if err := checkInjected("Storage.Get"); err != nil {
+		return nil, err
+	}
 	data, err := s.dataWithDelay(name)
 	if err != nil {
 		return nil, err
@@ -158,6 +187,9 @@ func (s *storageServer) URL(name string) (string, error) {
 }
 
 func (s *storageServer) Remove(name string) error {
+	if err := checkInjected("Storage.Remove"); err != nil {
+		return err
+	}
 	s.state.mu.Lock()
 	delete(s.files, name)
 	s.state.mu.Unlock()
@@ -181,6 +213,9 @@ func (s *storageServer) RemoveAll() error {
 }
 
 func (s *storageServer) List(prefix string) ([]string, error) {
+	if err := checkInjected("Storage.List"); err != nil {
+		return nil, err
+	}
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
 	var names []string
@@ -240,6 +275,14 @@ func (s *dummyStorage) Put(name string, r io.Reader, length int64) error {
 	return srv.Put(name, r, length)
 }
 
+func (s *dummyStorage) PutIfAbsent(name string, r io.Reader, length int64) error {
+	srv, err := s.server()
+	if err != nil {
+		return err
+	}
+	return srv.PutIfAbsent(name, r, length)
+}
+
 func (s *dummyStorage) Remove(name string) error {
 	srv, err := s.server()
 	if err != nil {
@@ -76,6 +76,7 @@ var _ imagemetadata.SupportsCustomSources = (*environ)(nil)
 var _ envtools.SupportsCustomSources = (*environ)(nil)
 var _ state.Prechecker = (*environ)(nil)
 var _ state.InstanceDistributor = (*environ)(nil)
+var _ environs.IdempotentStarter = (*environ)(nil)
 
 type ec2Instance struct {
 	e *environ
@@ -621,6 +622,10 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 			InstanceType:        spec.InstanceType.Name,
 			SecurityGroups:      groups,
 			BlockDeviceMappings: []ec2.BlockDeviceMapping{device},
+			// EC2 returns the instance already started with
+			// the same client token, rather than starting
+			// another.
+			ClientToken: args.IdempotencyToken,
 		})
 		if isZoneConstrainedError(err) {
 			logger.Infof("%q is constrained, trying another availability zone", availZone)
@@ -652,6 +657,11 @@ func (e *environ) StartInstance(args environs.StartInstanceParams) (instance.Ins
 	return inst, &hc, nil, nil
 }
 
+// StartInstanceIsIdempotent is specified in the IdempotentStarter interface.
+func (*environ) StartInstanceIsIdempotent() bool {
+	return true
+}
+
 var runInstances = _runInstances
 
 // runInstances calls ec2.RunInstances for a fixed number of attempts until
@@ -869,20 +879,23 @@ func (e *environ) Destroy() error {
 	return common.Destroy(e)
 }
 
-func portsToIPPerms(ports []network.Port) []ec2.IPPerm {
+// portsToIPPerms returns the IP permissions needed to open the given
+// port ranges, one for each range. ICMP ranges have both ports set to
+// -1, which EC2 takes to mean every ICMP type and code.
+func portsToIPPerms(ports []network.PortRange) []ec2.IPPerm {
 	ipPerms := make([]ec2.IPPerm, len(ports))
-	for i, p := range ports {
+	for i, r := range ports {
 		ipPerms[i] = ec2.IPPerm{
-			Protocol:  p.Protocol,
-			FromPort:  p.Number,
-			ToPort:    p.Number,
+			Protocol:  r.Protocol,
+			FromPort:  r.FromPort,
+			ToPort:    r.ToPort,
 			SourceIPs: []string{"0.0.0.0/0"},
 		}
 	}
 	return ipPerms
 }
 
-func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
+func (e *environ) openPortsInGroup(name string, ports []network.PortRange) error {
 	if len(ports) == 0 {
 		return nil
 	}
@@ -894,12 +907,12 @@ func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
 	ipPerms := portsToIPPerms(ports)
 	_, err = e.ec2().AuthorizeSecurityGroup(g, ipPerms)
 	if err != nil && ec2ErrCode(err) == "InvalidPermission.Duplicate" {
-		if len(ports) == 1 {
+		if len(ipPerms) == 1 {
 			return nil
 		}
-		// If there's more than one port and we get a duplicate error,
-		// then we go through authorizing each port individually,
-		// otherwise the ports that were *not* duplicates will have
+		// If there's more than one port range and we get a duplicate
+		// error, then we go through authorizing each range individually,
+		// otherwise the ranges that were *not* duplicates will have
 		// been ignored
 		for i := range ipPerms {
 			_, err := e.ec2().AuthorizeSecurityGroup(g, ipPerms[i:i+1])
@@ -915,7 +928,7 @@ func (e *environ) openPortsInGroup(name string, ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) closePortsInGroup(name string, ports []network.Port) error {
+func (e *environ) closePortsInGroup(name string, ports []network.PortRange) error {
 	if len(ports) == 0 {
 		return nil
 	}
@@ -933,7 +946,7 @@ func (e *environ) closePortsInGroup(name string, ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) portsInGroup(name string) (ports []network.Port, err error) {
+func (e *environ) portsInGroup(name string) (ports []network.PortRange, err error) {
 	group, err := e.groupInfoByName(name)
 	if err != nil {
 		return nil, err
@@ -943,18 +956,17 @@ func (e *environ) portsInGroup(name string) (ports []network.Port, err error) {
 			logger.Warningf("unexpected IP permission found: %v", p)
 			continue
 		}
-		for i := p.FromPort; i <= p.ToPort; i++ {
-			ports = append(ports, network.Port{
-				Protocol: p.Protocol,
-				Number:   i,
-			})
-		}
+		ports = append(ports, network.PortRange{
+			FromPort: p.FromPort,
+			ToPort:   p.ToPort,
+			Protocol: p.Protocol,
+		})
 	}
-	network.SortPorts(ports)
+	network.SortPortRanges(ports)
 	return ports, nil
 }
 
-func (e *environ) OpenPorts(ports []network.Port) error {
+func (e *environ) OpenPorts(ports []network.PortRange) error {
 	if e.Config().FirewallMode() != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment",
 			e.Config().FirewallMode())
@@ -966,7 +978,7 @@ func (e *environ) OpenPorts(ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) ClosePorts(ports []network.Port) error {
+func (e *environ) ClosePorts(ports []network.PortRange) error {
 	if e.Config().FirewallMode() != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on environment",
 			e.Config().FirewallMode())
@@ -978,7 +990,7 @@ func (e *environ) ClosePorts(ports []network.Port) error {
 	return nil
 }
 
-func (e *environ) Ports() ([]network.Port, error) {
+func (e *environ) Ports() ([]network.PortRange, error) {
 	if e.Config().FirewallMode() != config.FwGlobal {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from environment",
 			e.Config().FirewallMode())
@@ -1037,7 +1049,7 @@ func (e *environ) jujuGroupName() string {
 	return "juju-" + e.name
 }
 
-func (inst *ec2Instance) OpenPorts(machineId string, ports []network.Port) error {
+func (inst *ec2Instance) OpenPorts(machineId string, ports []network.PortRange) error {
 	if inst.e.Config().FirewallMode() != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on instance",
 			inst.e.Config().FirewallMode())
@@ -1050,7 +1062,7 @@ func (inst *ec2Instance) OpenPorts(machineId string, ports []network.Port) error
 	return nil
 }
 
-func (inst *ec2Instance) ClosePorts(machineId string, ports []network.Port) error {
+func (inst *ec2Instance) ClosePorts(machineId string, ports []network.PortRange) error {
 	if inst.e.Config().FirewallMode() != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on instance",
 			inst.e.Config().FirewallMode())
@@ -1063,7 +1075,7 @@ func (inst *ec2Instance) ClosePorts(machineId string, ports []network.Port) erro
 	return nil
 }
 
-func (inst *ec2Instance) Ports(machineId string) ([]network.Port, error) {
+func (inst *ec2Instance) Ports(machineId string) ([]network.PortRange, error) {
 	if inst.e.Config().FirewallMode() != config.FwInstance {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from instance",
 			inst.e.Config().FirewallMode())
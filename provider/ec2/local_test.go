@@ -553,6 +553,26 @@ func (t *localServerSuite) TestStartInstanceAvailZoneOneConstrained(c *gc.C) {
 	c.Assert(ec2.InstanceEC2(inst).AvailZone, gc.Equals, "az2")
 }
 
+func (t *localServerSuite) TestStartInstancePassesClientToken(c *gc.C) {
+	env := t.Prepare(c)
+	envtesting.UploadFakeTools(c, env.Storage())
+	err := bootstrap.Bootstrap(coretesting.Context(c), env, environs.BootstrapParams{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.(environs.IdempotentStarter).StartInstanceIsIdempotent(), jc.IsTrue)
+
+	var tokens []string
+	realRunInstances := *ec2.RunInstances
+	t.PatchValue(ec2.RunInstances, func(e *amzec2.EC2, ri *amzec2.RunInstances) (*amzec2.RunInstancesResp, error) {
+		tokens = append(tokens, ri.ClientToken)
+		return realRunInstances(e, ri)
+	})
+	_, _, _, err = testing.StartInstanceWithParams(env, "1", environs.StartInstanceParams{
+		IdempotencyToken: "machine-0:some-uuid",
+	}, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(tokens, gc.DeepEquals, []string{"machine-0:some-uuid"})
+}
+
 func (t *localServerSuite) TestAddresses(c *gc.C) {
 	env := t.Prepare(c)
 	envtesting.UploadFakeTools(c, env.Storage())
@@ -78,6 +78,11 @@ func (s *ec2storage) URL(name string) (string, error) {
 	return s.bucket.SignedURL(name, time.Now().AddDate(10, 0, 0)), nil
 }
 
+// SignedURL is specified in the URLSigner interface.
+func (s *ec2storage) SignedURL(name string, expires time.Time) (string, error) {
+	return s.bucket.SignedURL(name, expires), nil
+}
+
 var storageAttempt = utils.AttemptStrategy{
 	Total: 5 * time.Second,
 	Delay: 200 * time.Millisecond,
@@ -15,12 +15,21 @@ import (
 )
 
 const (
-	firewallRuleAll = "FROM tag %s TO tag juju ALLOW %s PORT %d"
+	firewallRuleAll = "FROM tag %s TO tag juju ALLOW %s %s"
 )
 
-// Helper method to create a firewall rule string for the given port
-func createFirewallRuleAll(env *joyentEnviron, port network.Port) string {
-	return fmt.Sprintf(firewallRuleAll, env.Config().Name(), strings.ToLower(port.Protocol), port.Number)
+// Helper method to create the port part of a firewall rule string
+// for the given port range
+func rulePorts(port network.PortRange) string {
+	if port.FromPort == port.ToPort {
+		return fmt.Sprintf("PORT %d", port.FromPort)
+	}
+	return fmt.Sprintf("PORTS %d - %d", port.FromPort, port.ToPort)
+}
+
+// Helper method to create a firewall rule string for the given port range
+func createFirewallRuleAll(env *joyentEnviron, port network.PortRange) string {
+	return fmt.Sprintf(firewallRuleAll, env.Config().Name(), strings.ToLower(port.Protocol), rulePorts(port))
 }
 
 // Helper method to check if a firewall rule string already exist
@@ -34,24 +43,37 @@ func ruleExists(rules []cloudapi.FirewallRule, rule string) (bool, string) {
 	return false, ""
 }
 
-// Helper method to get port from the given firewall rules
-func getPorts(env *joyentEnviron, rules []cloudapi.FirewallRule) []network.Port {
-	ports := []network.Port{}
+// Helper method to get port ranges from the given firewall rules
+func getPorts(env *joyentEnviron, rules []cloudapi.FirewallRule) []network.PortRange {
+	ports := []network.PortRange{}
 	for _, r := range rules {
 		rule := r.Rule
 		if r.Enabled && strings.HasPrefix(rule, "FROM tag "+env.Config().Name()) && strings.Contains(rule, "PORT") {
 			p := rule[strings.Index(rule, "ALLOW")+6 : strings.Index(rule, "PORT")-1]
-			n, _ := strconv.Atoi(rule[strings.LastIndex(rule, " ")+1:])
-			port := network.Port{Protocol: p, Number: n}
+			from, to := parseRulePorts(rule[strings.Index(rule, "PORT"):])
+			port := network.PortRange{FromPort: from, ToPort: to, Protocol: p}
 			ports = append(ports, port)
 		}
 	}
 
-	network.SortPorts(ports)
+	network.SortPortRanges(ports)
 	return ports
 }
 
-func (env *joyentEnviron) OpenPorts(ports []network.Port) error {
+// Helper method to parse the port part of a firewall rule string, as
+// created by rulePorts
+func parseRulePorts(s string) (from, to int) {
+	fields := strings.Fields(s)
+	if len(fields) == 4 && fields[0] == "PORTS" && fields[2] == "-" {
+		from, _ = strconv.Atoi(fields[1])
+		to, _ = strconv.Atoi(fields[3])
+		return from, to
+	}
+	from, _ = strconv.Atoi(fields[len(fields)-1])
+	return from, from
+}
+
+func (env *joyentEnviron) OpenPorts(ports []network.PortRange) error {
 	if env.Config().FirewallMode() != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on environment", env.Config().FirewallMode())
 	}
@@ -84,7 +106,7 @@ func (env *joyentEnviron) OpenPorts(ports []network.Port) error {
 	return nil
 }
 
-func (env *joyentEnviron) ClosePorts(ports []network.Port) error {
+func (env *joyentEnviron) ClosePorts(ports []network.PortRange) error {
 	if env.Config().FirewallMode() != config.FwGlobal {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on environment", env.Config().FirewallMode())
 	}
@@ -117,7 +139,7 @@ func (env *joyentEnviron) ClosePorts(ports []network.Port) error {
 	return nil
 }
 
-func (env *joyentEnviron) Ports() ([]network.Port, error) {
+func (env *joyentEnviron) Ports() ([]network.PortRange, error) {
 	if env.Config().FirewallMode() != config.FwGlobal {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from environment", env.Config().FirewallMode())
 	}
@@ -14,15 +14,15 @@ import (
 )
 
 const (
-	firewallRuleVm = "FROM tag %s TO vm %s ALLOW %s PORT %d"
+	firewallRuleVm = "FROM tag %s TO vm %s ALLOW %s %s"
 )
 
-// Helper method to create a firewall rule string for the given machine Id and port
-func createFirewallRuleVm(env *joyentEnviron, machineId string, port network.Port) string {
-	return fmt.Sprintf(firewallRuleVm, env.Config().Name(), machineId, strings.ToLower(port.Protocol), port.Number)
+// Helper method to create a firewall rule string for the given machine Id and port range
+func createFirewallRuleVm(env *joyentEnviron, machineId string, port network.PortRange) string {
+	return fmt.Sprintf(firewallRuleVm, env.Config().Name(), machineId, strings.ToLower(port.Protocol), rulePorts(port))
 }
 
-func (inst *joyentInstance) OpenPorts(machineId string, ports []network.Port) error {
+func (inst *joyentInstance) OpenPorts(machineId string, ports []network.PortRange) error {
 	if inst.env.Config().FirewallMode() != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for opening ports on instance", inst.env.Config().FirewallMode())
 	}
@@ -56,7 +56,7 @@ func (inst *joyentInstance) OpenPorts(machineId string, ports []network.Port) er
 	return nil
 }
 
-func (inst *joyentInstance) ClosePorts(machineId string, ports []network.Port) error {
+func (inst *joyentInstance) ClosePorts(machineId string, ports []network.PortRange) error {
 	if inst.env.Config().FirewallMode() != config.FwInstance {
 		return fmt.Errorf("invalid firewall mode %q for closing ports on instance", inst.env.Config().FirewallMode())
 	}
@@ -90,7 +90,7 @@ func (inst *joyentInstance) ClosePorts(machineId string, ports []network.Port) e
 	return nil
 }
 
-func (inst *joyentInstance) Ports(machineId string) ([]network.Port, error) {
+func (inst *joyentInstance) Ports(machineId string) ([]network.PortRange, error) {
 	if inst.env.Config().FirewallMode() != config.FwInstance {
 		return nil, fmt.Errorf("invalid firewall mode %q for retrieving ports from instance", inst.env.Config().FirewallMode())
 	}
@@ -42,6 +42,7 @@ import (
 	servicecommon "github.com/juju/juju/service/common"
 	"github.com/juju/juju/service/upstart"
 	"github.com/juju/juju/state/api/params"
+	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/terminationworker"
 )
@@ -335,7 +336,13 @@ func (env *localEnviron) StartInstance(args environs.StartInstanceParams) (insta
 	}
 	series := args.Tools.OneSeries()
 	logger.Debugf("StartInstance: %q, %s", args.MachineConfig.MachineId, series)
-	args.MachineConfig.Tools = args.Tools[0]
+	// Containers run on the host, so they need tools for its architecture.
+	hostArch := arch.HostArch()
+	possibleTools, err := args.Tools.Match(coretools.Filter{Arch: hostArch})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("no tools available for host architecture %q", hostArch)
+	}
+	args.MachineConfig.Tools = possibleTools[0]
 	args.MachineConfig.MachineContainerType = env.config.container()
 	logger.Debugf("tools: %#v", args.MachineConfig.Tools)
 	network := container.BridgeNetworkConfig(env.config.networkBridge())
@@ -510,17 +517,17 @@ func (env *localEnviron) Destroy() error {
 }
 
 // OpenPorts is specified in the Environ interface.
-func (env *localEnviron) OpenPorts(ports []network.Port) error {
+func (env *localEnviron) OpenPorts(ports []network.PortRange) error {
 	return fmt.Errorf("open ports not implemented")
 }
 
 // ClosePorts is specified in the Environ interface.
-func (env *localEnviron) ClosePorts(ports []network.Port) error {
+func (env *localEnviron) ClosePorts(ports []network.PortRange) error {
 	return fmt.Errorf("close ports not implemented")
 }
 
 // Ports is specified in the Environ interface.
-func (env *localEnviron) Ports() ([]network.Port, error) {
+func (env *localEnviron) Ports() ([]network.PortRange, error) {
 	return nil, nil
 }
 
@@ -290,11 +290,37 @@ func (environ *maasEnviron) acquireNode(nodeName string, cons constraints.Value,
 		msg := fmt.Errorf("unexpected result from 'acquire' on MAAS API: %v", err)
 		return gomaasapi.MAASObject{}, nil, msg
 	}
-	tools := possibleTools[0]
-	logger.Warningf("picked arbitrary tools %v", tools)
+	tools, err := nodeTools(node, possibleTools)
+	if err != nil {
+		inst := &maasInstance{maasObject: &node, environ: environ}
+		if err := environ.StopInstances(inst.Id()); err != nil {
+			logger.Errorf("error releasing node %v: %v", inst.Id(), err)
+		}
+		return gomaasapi.MAASObject{}, nil, err
+	}
 	return node, tools, nil
 }
 
+// nodeTools returns tools from possibleTools that match the
+// architecture of the given node. MAAS reports architectures
+// as "<arch>/<subarch>", as in "armhf/highbank".
+func nodeTools(node gomaasapi.MAASObject, possibleTools tools.List) (*tools.Tools, error) {
+	arch, err := node.GetField("architecture")
+	if err != nil || arch == "" {
+		// Older versions of MAAS do not report the architecture.
+		logger.Warningf("node has no architecture; picked arbitrary tools %v", possibleTools[0])
+		return possibleTools[0], nil
+	}
+	if i := strings.Index(arch, "/"); i >= 0 {
+		arch = arch[:i]
+	}
+	matching, err := possibleTools.Match(tools.Filter{Arch: arch})
+	if err != nil {
+		return nil, fmt.Errorf("no tools available for architecture %q", arch)
+	}
+	return matching[0], nil
+}
+
 // startNode installs and boots a node.
 func (environ *maasEnviron) startNode(node gomaasapi.MAASObject, series string, userdata []byte) error {
 	userDataParam := base64.StdEncoding.EncodeToString(userdata)
@@ -630,19 +656,19 @@ func (environ *maasEnviron) Destroy() error {
 }
 
 // MAAS does not do firewalling so these port methods do nothing.
-func (*maasEnviron) OpenPorts([]network.Port) error {
+func (*maasEnviron) OpenPorts([]network.PortRange) error {
 	logger.Debugf("unimplemented OpenPorts() called")
 	return nil
 }
 
-func (*maasEnviron) ClosePorts([]network.Port) error {
+func (*maasEnviron) ClosePorts([]network.PortRange) error {
 	logger.Debugf("unimplemented ClosePorts() called")
 	return nil
 }
 
-func (*maasEnviron) Ports() ([]network.Port, error) {
+func (*maasEnviron) Ports() ([]network.PortRange, error) {
 	logger.Debugf("unimplemented Ports() called")
-	return []network.Port{}, nil
+	return []network.PortRange{}, nil
 }
 
 func (*maasEnviron) Provider() environs.EnvironProvider {
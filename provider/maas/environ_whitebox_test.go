@@ -324,6 +324,34 @@ func (suite *environSuite) TestAcquireNodeTakesConstraintsIntoAccount(c *gc.C) {
 	c.Assert(nodeRequestValues[0].Get("mem"), gc.Equals, "1024")
 }
 
+func (suite *environSuite) TestAcquireNodePicksToolsForNodeArch(c *gc.C) {
+	stor := NewStorage(suite.makeEnviron())
+	possibleTools := envtesting.MustUploadFakeToolsVersions(stor,
+		version.MustParseBinary("1.2.3-precise-amd64"),
+		version.MustParseBinary("1.2.3-precise-armhf"),
+	)
+	env := suite.makeEnviron()
+	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0", "architecture": "armhf/highbank"}`)
+
+	_, tools, err := env.acquireNode("", constraints.Value{}, nil, nil, possibleTools)
+	c.Assert(err, gc.IsNil)
+	c.Assert(tools.Version, gc.Equals, version.MustParseBinary("1.2.3-precise-armhf"))
+}
+
+func (suite *environSuite) TestAcquireNodeNoToolsForNodeArch(c *gc.C) {
+	stor := NewStorage(suite.makeEnviron())
+	possibleTools := envtesting.MustUploadFakeToolsVersions(stor,
+		version.MustParseBinary("1.2.3-precise-amd64"),
+	)
+	env := suite.makeEnviron()
+	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0", "architecture": "armhf/highbank"}`)
+
+	_, _, err := env.acquireNode("", constraints.Value{}, nil, nil, possibleTools)
+	c.Assert(err, gc.ErrorMatches, `no tools available for architecture "armhf"`)
+	// The node is released again.
+	c.Assert(suite.testMAASObject.TestServer.OwnedNodes()["node0"], jc.IsFalse)
+}
+
 func (suite *environSuite) TestAcquireNodePassedAgentName(c *gc.C) {
 	stor := NewStorage(suite.makeEnviron())
 	fakeTools := envtesting.MustUploadFakeToolsVersions(stor, version.Current)[0]
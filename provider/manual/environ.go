@@ -115,7 +115,7 @@ func (e *manualEnviron) Bootstrap(ctx environs.BootstrapContext, args environs.B
 	if err != nil {
 		return err
 	}
-	return manual.Bootstrap(manual.BootstrapArgs{
+	err = manual.Bootstrap(manual.BootstrapArgs{
 		Context:                 ctx,
 		Host:                    host,
 		DataDir:                 agent.DefaultDataDir,
@@ -123,7 +123,12 @@ func (e *manualEnviron) Bootstrap(ctx environs.BootstrapContext, args environs.B
 		PossibleTools:           selectedTools,
 		Series:                  series,
 		HardwareCharacteristics: &hc,
+		Force:                   args.ForceReinstall,
 	})
+	if _, ok := err.(*manual.PreviousInstallationError); ok {
+		return fmt.Errorf("%v; bootstrap with --force-reinstall to remove it", err)
+	}
+	return err
 }
 
 // StateServerInstances is specified in the Environ interface.
@@ -304,16 +309,16 @@ func (e *manualEnviron) ConstraintsValidator() (constraints.Validator, error) {
 	return validator, nil
 }
 
-func (e *manualEnviron) OpenPorts(ports []network.Port) error {
+func (e *manualEnviron) OpenPorts(ports []network.PortRange) error {
 	return nil
 }
 
-func (e *manualEnviron) ClosePorts(ports []network.Port) error {
+func (e *manualEnviron) ClosePorts(ports []network.PortRange) error {
 	return nil
 }
 
-func (e *manualEnviron) Ports() ([]network.Port, error) {
-	return []network.Port{}, nil
+func (e *manualEnviron) Ports() ([]network.PortRange, error) {
+	return []network.PortRange{}, nil
 }
 
 func (*manualEnviron) Provider() environs.EnvironProvider {
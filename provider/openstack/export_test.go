@@ -43,9 +43,11 @@ func MetadataStorage(e environs.Environ) storage.Storage {
 	ecfg := e.(*environ).ecfg()
 	authModeCfg := AuthMode(ecfg.authMode())
 	container := "juju-dist-test"
+	client := e.(*environ).authClient(ecfg, authModeCfg)
 	metadataStorage := &openstackstorage{
 		containerName: container,
-		swift:         swift.New(e.(*environ).authClient(ecfg, authModeCfg)),
+		swift:         swift.New(client),
+		client:        client,
 	}
 
 	// Ensure the container exists.
@@ -315,6 +317,7 @@ func ImageMetadataStorage(e environs.Environ) storage.Storage {
 	return &openstackstorage{
 		containerName: "imagemetadata",
 		swift:         swift.New(env.client),
+		client:        env.client,
 	}
 }
 
@@ -329,6 +332,7 @@ func CreateCustomStorage(e environs.Environ, containerName string) storage.Stora
 	return &openstackstorage{
 		containerName: containerName,
 		swift:         swiftClient,
+		client:        env.client,
 	}
 }
 
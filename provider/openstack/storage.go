@@ -6,12 +6,15 @@ package openstack
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"sync"
 	"time"
 
 	jujuerrors "github.com/juju/errors"
 	"github.com/juju/utils"
+	"launchpad.net/goose/client"
 	gooseerrors "launchpad.net/goose/errors"
+	goosehttp "launchpad.net/goose/http"
 	"launchpad.net/goose/swift"
 
 	"github.com/juju/juju/environs/storage"
@@ -24,6 +27,10 @@ type openstackstorage struct {
 	containerName string
 	containerACL  swift.ACL
 	swift         *swift.Client
+
+	// client is the client underlying swift, used for requests
+	// the swift package does not support.
+	client client.Client
 }
 
 // makeContainer makes the environment's control container, the
@@ -55,6 +62,32 @@ func (s *openstackstorage) Put(file string, r io.Reader, length int64) error {
 	return nil
 }
 
+// PutIfAbsent implements storage.ConditionalWriter.PutIfAbsent. Swift
+// refuses to create an object that already exists when the PUT request
+// has an "If-None-Match: *" header.
+func (s *openstackstorage) PutIfAbsent(file string, r io.Reader, length int64) error {
+	if err := s.makeContainer(s.containerName, s.containerACL); err != nil {
+		return fmt.Errorf("cannot make Swift control container: %v", err)
+	}
+	requestData := goosehttp.RequestData{
+		ReqHeaders:     http.Header{"If-None-Match": {"*"}},
+		ReqReader:      r,
+		ReqLength:      int(length),
+		ExpectedStatus: []int{http.StatusCreated},
+	}
+	path := fmt.Sprintf("%s/%s", s.containerName, file)
+	err := s.client.SendRequest(client.PUT, "object-store", path, &requestData)
+	if err == nil {
+		return nil
+	}
+	// The failed precondition is not reported distinctly, so check
+	// whether the object exists to tell it from other failures.
+	if _, headErr := s.swift.HeadObject(s.containerName, file); headErr == nil {
+		return jujuerrors.AlreadyExistsf("file %q", file)
+	}
+	return fmt.Errorf("cannot write file %q to control container %q: %v", file, s.containerName, err)
+}
+
 func (s *openstackstorage) Get(file string) (io.ReadCloser, error) {
 	r, _, err := s.swift.GetReader(s.containerName, file)
 	if err, _ := maybeNotFound(err); err != nil {
@@ -69,6 +102,11 @@ func (s *openstackstorage) URL(name string) (string, error) {
 	return s.swift.SignedURL(s.containerName, name, expires)
 }
 
+// SignedURL is specified in the URLSigner interface.
+func (s *openstackstorage) SignedURL(name string, expires time.Time) (string, error) {
+	return s.swift.SignedURL(s.containerName, name, expires)
+}
+
 var storageAttempt = utils.AttemptStrategy{
 	// It seems Nova needs more time than EC2.
 	Total: 10 * time.Second,
@@ -1,8 +1,16 @@
 package jsoncodec
 
 import (
+	"bytes"
+	"compress/flate"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"sync"
+	"time"
 
 	"code.google.com/p/go.net/websocket"
 )
@@ -22,13 +30,231 @@ func (conn wsJSONConn) Send(msg interface{}) error {
 }
 
 func (conn wsJSONConn) Receive(msg interface{}) error {
-	return websocket.JSON.Receive(conn.conn, msg)
+	return deflateJSON.Receive(conn.conn, msg)
 }
 
 func (conn wsJSONConn) Close() error {
 	return conn.conn.Close()
 }
 
+// NewQueuedWebsocket returns an rpc codec that uses the given
+// websocket connection to send and receive messages. Messages are not
+// sent directly; they are queued and written by a separate goroutine,
+// each within writeTimeout. If the queue already holds queueSize
+// messages, or a message cannot be written in time, the peer is taken
+// to have stopped reading and the connection is closed, so that a
+// stalled peer cannot block the sender indefinitely.
+//
+// If compressThreshold is greater than zero, messages of at least
+// that many bytes are sent compressed; it should only be set when
+// the peer has asked for compression with CompressionHeader.
+func NewQueuedWebsocket(conn *websocket.Conn, queueSize int, writeTimeout time.Duration, compressThreshold int) *Codec {
+	qconn := &wsQueuedConn{
+		conn:              conn,
+		writeTimeout:      writeTimeout,
+		compressThreshold: compressThreshold,
+		queue:             make(chan []byte, queueSize),
+		flush:             make(chan struct{}),
+		dying:             make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+	go qconn.writer()
+	return New(qconn)
+}
+
+// errQueueFull is returned when a message is sent to a wsQueuedConn
+// whose queue is full.
+var errQueueFull = errors.New("outbound message queue full")
+
+type wsQueuedConn struct {
+	conn              *websocket.Conn
+	writeTimeout      time.Duration
+	compressThreshold int
+	queue             chan []byte
+
+	// flush is closed when Close is called; the writer then writes
+	// the messages remaining in the queue and exits.
+	flush chan struct{}
+
+	// dying is closed when the connection has failed.
+	dying chan struct{}
+
+	// done is closed when the writer has exited.
+	done chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	err    error
+}
+
+func (conn *wsQueuedConn) Send(msg interface{}) error {
+	// Marshal now, so the message can be changed once Send returns.
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.err != nil {
+		return conn.err
+	}
+	if conn.closed {
+		return errors.New("connection closed")
+	}
+	select {
+	case conn.queue <- data:
+		return nil
+	default:
+		conn.failLocked(errQueueFull)
+		return errQueueFull
+	}
+}
+
+func (conn *wsQueuedConn) Receive(msg interface{}) error {
+	return deflateJSON.Receive(conn.conn, msg)
+}
+
+// Close writes any queued messages and closes the connection.
+func (conn *wsQueuedConn) Close() error {
+	conn.mu.Lock()
+	if !conn.closed {
+		conn.closed = true
+		close(conn.flush)
+	}
+	conn.mu.Unlock()
+	<-conn.done
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.err != nil {
+		// The connection has already been closed.
+		return nil
+	}
+	return conn.conn.Close()
+}
+
+func (conn *wsQueuedConn) writer() {
+	defer close(conn.done)
+	for {
+		select {
+		case data := <-conn.queue:
+			if !conn.write(data) {
+				return
+			}
+		case <-conn.flush:
+			for {
+				select {
+				case data := <-conn.queue:
+					if !conn.write(data) {
+						return
+					}
+				default:
+					return
+				}
+			}
+		case <-conn.dying:
+			return
+		}
+	}
+}
+
+// write writes the given message, reporting whether it succeeded.
+func (conn *wsQueuedConn) write(data []byte) bool {
+	// Compressed messages are sent in binary frames, which is how
+	// the receiver tells them apart.
+	var msg interface{} = string(data)
+	if conn.compressThreshold > 0 && len(data) >= conn.compressThreshold {
+		compressed, err := deflate(data)
+		if err != nil {
+			logger.Errorf("cannot compress message: %v", err)
+		} else {
+			msg = compressed
+		}
+	}
+	conn.conn.SetWriteDeadline(time.Now().Add(conn.writeTimeout))
+	if err := websocket.Message.Send(conn.conn, msg); err != nil {
+		conn.mu.Lock()
+		conn.failLocked(err)
+		conn.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// failLocked records that the connection has failed with the given
+// error and closes it. It must be called with conn.mu held.
+func (conn *wsQueuedConn) failLocked(err error) {
+	if conn.err != nil {
+		return
+	}
+	logger.Debugf("closing connection: %v", err)
+	conn.err = err
+	close(conn.dying)
+	conn.conn.Close()
+}
+
+const (
+	// CompressionHeader is the HTTP header with which a client
+	// opening a websocket connection says which compression it can
+	// accept for the messages sent to it.
+	CompressionHeader = "X-Juju-Compression"
+
+	// CompressionDeflate is the value of CompressionHeader that
+	// accepts messages compressed with DEFLATE (RFC 1951).
+	CompressionDeflate = "deflate"
+)
+
+// deflateJSON is a websocket codec for receiving JSON messages, which
+// are read from text frames as is and from binary frames compressed
+// with DEFLATE. It only receives messages; messages are compressed
+// by wsQueuedConn when it sends them.
+var deflateJSON = websocket.Codec{
+	Marshal:   marshalJSON,
+	Unmarshal: unmarshalDeflateJSON,
+}
+
+func marshalJSON(v interface{}) ([]byte, byte, error) {
+	data, err := json.Marshal(v)
+	return data, websocket.TextFrame, err
+}
+
+// maxInflatedSize holds the largest size to which a compressed
+// message may decompress. Messages come from the peer, so without a
+// limit a small message could exhaust the receiver's memory.
+var maxInflatedSize int64 = 64 * 1024 * 1024
+
+func unmarshalDeflateJSON(data []byte, payloadType byte, v interface{}) error {
+	if payloadType != websocket.BinaryFrame {
+		return json.Unmarshal(data, v)
+	}
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	inflated, err := ioutil.ReadAll(io.LimitReader(r, maxInflatedSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(inflated)) > maxInflatedSize {
+		return fmt.Errorf("compressed message exceeds %d bytes", maxInflatedSize)
+	}
+	return json.Unmarshal(inflated, v)
+}
+
+// deflate returns the given data compressed with DEFLATE.
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // NewNet returns an rpc codec that uses the given net
 // connection to send and receive messages.
 func NewNet(conn net.Conn) *Codec {
@@ -0,0 +1,150 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jsoncodec_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"code.google.com/p/go.net/websocket"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/rpc/jsoncodec"
+	"github.com/juju/juju/testing"
+)
+
+type queuedSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&queuedSuite{})
+
+// serve starts a websocket server and returns a client connection to
+// it along with a codec writing to its server side with the given
+// queue size, write timeout and compression threshold.
+func (s *queuedSuite) serve(c *gc.C, queueSize int, writeTimeout time.Duration, compressThreshold int) (*websocket.Conn, *jsoncodec.Codec) {
+	conns := make(chan *websocket.Conn)
+	done := make(chan struct{})
+	srv := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		conns <- conn
+		<-done
+	}))
+	s.AddCleanup(func(*gc.C) {
+		close(done)
+		srv.Close()
+	})
+	url := strings.Replace(srv.URL, "http://", "ws://", 1)
+	client, err := websocket.Dial(url, "", "http://localhost/")
+	c.Assert(err, gc.IsNil)
+	s.AddCleanup(func(*gc.C) { client.Close() })
+	var conn *websocket.Conn
+	select {
+	case conn = <-conns:
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for connection")
+	}
+	return client, jsoncodec.NewQueuedWebsocket(conn, queueSize, writeTimeout, compressThreshold)
+}
+
+func (s *queuedSuite) TestMessagesFlushedOnClose(c *gc.C) {
+	client, codec := s.serve(c, 10, testing.LongWait, 0)
+	for i := 0; i < 5; i++ {
+		err := codec.WriteMessage(&rpc.Header{RequestId: uint64(i)}, value{X: "x"})
+		c.Assert(err, gc.IsNil)
+	}
+	err := codec.Close()
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 5; i++ {
+		var msg struct {
+			RequestId uint64
+			Response  value
+		}
+		err := websocket.JSON.Receive(client, &msg)
+		c.Assert(err, gc.IsNil)
+		c.Assert(msg.RequestId, gc.Equals, uint64(i))
+		c.Assert(msg.Response, gc.Equals, value{X: "x"})
+	}
+}
+
+func (s *queuedSuite) TestStalledClientDisconnected(c *gc.C) {
+	client, codec := s.serve(c, 1, 100*time.Millisecond, 0)
+	// The client reads nothing, so once the network buffers are
+	// full, writes must fail rather than block.
+	body := value{X: strings.Repeat("x", 64*1024)}
+	timeout := time.After(testing.LongWait)
+	var err error
+	for i := 0; err == nil; i++ {
+		select {
+		case <-timeout:
+			c.Fatalf("writes to stalled client never failed")
+		default:
+		}
+		err = codec.WriteMessage(&rpc.Header{RequestId: uint64(i)}, body)
+	}
+	c.Logf("write failed: %v", err)
+
+	// The connection has been closed, so the client sees it end.
+	client.SetReadDeadline(time.Now().Add(testing.LongWait))
+	for {
+		var msg interface{}
+		if err := websocket.JSON.Receive(client, &msg); err != nil {
+			break
+		}
+	}
+	c.Assert(codec.Close(), gc.IsNil)
+}
+
+func (s *queuedSuite) TestLargeMessagesCompressed(c *gc.C) {
+	client, codec := s.serve(c, 10, testing.LongWait, 1024)
+	small := value{X: "x"}
+	large := value{X: strings.Repeat("x", 4096)}
+	err := codec.WriteMessage(&rpc.Header{RequestId: 1}, small)
+	c.Assert(err, gc.IsNil)
+	err = codec.WriteMessage(&rpc.Header{RequestId: 2}, large)
+	c.Assert(err, gc.IsNil)
+
+	// Only the large message is sent compressed.
+	var data []byte
+	err = websocket.Message.Receive(client, &data)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, `{"RequestId":1,"Response":{"X":"x"}}`)
+	err = websocket.Message.Receive(client, &data)
+	c.Assert(err, gc.IsNil)
+	c.Assert(len(data) < 1024, gc.Equals, true)
+}
+
+func (s *queuedSuite) TestCompressedMessagesReceived(c *gc.C) {
+	client, codec := s.serve(c, 10, testing.LongWait, 1024)
+	clientCodec := jsoncodec.NewWebsocket(client)
+	for i, body := range []value{
+		{X: "x"},
+		{X: strings.Repeat("x", 4096)},
+	} {
+		err := codec.WriteMessage(&rpc.Header{RequestId: uint64(i)}, body)
+		c.Assert(err, gc.IsNil)
+
+		var hdr rpc.Header
+		err = clientCodec.ReadHeader(&hdr)
+		c.Assert(err, gc.IsNil)
+		c.Assert(hdr.RequestId, gc.Equals, uint64(i))
+		var got value
+		err = clientCodec.ReadBody(&got, false)
+		c.Assert(err, gc.IsNil)
+		c.Assert(got, gc.Equals, body)
+	}
+}
+
+func (s *queuedSuite) TestCompressedMessageSizeLimited(c *gc.C) {
+	s.PatchValue(jsoncodec.MaxInflatedSize, int64(2048))
+	client, codec := s.serve(c, 10, testing.LongWait, 1024)
+	clientCodec := jsoncodec.NewWebsocket(client)
+	err := codec.WriteMessage(&rpc.Header{RequestId: 1}, value{X: strings.Repeat("x", 4096)})
+	c.Assert(err, gc.IsNil)
+
+	var hdr rpc.Header
+	err = clientCodec.ReadHeader(&hdr)
+	c.Assert(err, gc.ErrorMatches, ".*compressed message exceeds 2048 bytes")
+}
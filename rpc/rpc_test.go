@@ -852,6 +852,77 @@ func (*rpcSuite) TestServerWaitsForOutstandingCalls(c *gc.C) {
 	start <- "xxx"
 }
 
+func (*rpcSuite) TestDrain(c *gc.C) {
+	ready := make(chan struct{})
+	start := make(chan string)
+	root := &Root{
+		delayed: map[string]*DelayedMethods{
+			"1": {
+				ready: ready,
+				done:  start,
+			},
+		},
+	}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer closeClient(c, client, srvDone)
+	done := make(chan struct{})
+	go func() {
+		var r stringVal
+		err := client.Call(rpc.Request{"DelayedMethods", 0, "1", "Delay"}, nil, &r)
+		c.Check(err, gc.IsNil)
+		c.Check(r.Val, gc.Equals, "xxx")
+		done <- struct{}{}
+	}()
+	chanRead(c, ready, "DelayedMethods.Delay ready")
+
+	// The outstanding call prevents the drain from completing.
+	c.Assert(root.conn.Drain(fmt.Errorf("draining"), 25*time.Millisecond), gc.Equals, false)
+
+	// Further calls are refused with the given error.
+	err := client.Call(rpc.Request{"DelayedMethods", 0, "1", "Delay"}, nil, nil)
+	c.Assert(err, gc.ErrorMatches, "request error: draining")
+
+	start <- "xxx"
+	chanRead(c, done, "DelayedMethods.Delay done")
+	c.Assert(root.conn.Drain(fmt.Errorf("draining"), 3*time.Second), gc.Equals, true)
+}
+
+func (*rpcSuite) TestCloseAfterDrainTimeout(c *gc.C) {
+	ready := make(chan struct{})
+	start := make(chan string)
+	root := &Root{
+		delayed: map[string]*DelayedMethods{
+			"1": {
+				ready: ready,
+				done:  start,
+			},
+		},
+	}
+	client, srvDone, _, _ := newRPCClientServer(c, root, nil, false)
+	defer client.Close()
+	go func() {
+		var r stringVal
+		client.Call(rpc.Request{"DelayedMethods", 0, "1", "Delay"}, nil, &r)
+	}()
+	chanRead(c, ready, "DelayedMethods.Delay ready")
+	c.Assert(root.conn.Drain(fmt.Errorf("draining"), 25*time.Millisecond), gc.Equals, false)
+
+	// The outstanding call was abandoned by the drain, so closing
+	// the connection does not wait for it.
+	closed := make(chan struct{})
+	go func() {
+		root.conn.Close()
+		close(closed)
+	}()
+	chanRead(c, closed, "connection closed")
+	start <- "xxx"
+	select {
+	case <-srvDone:
+	case <-time.After(3 * time.Second):
+		c.Fatalf("server did not finish")
+	}
+}
+
 func chanRead(c *gc.C, ch <-chan struct{}, what string) {
 	select {
 	case <-ch:
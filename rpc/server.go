@@ -99,9 +99,6 @@ type Conn struct {
 	// notifier is informed about RPC requests. It may be nil.
 	notifier RequestNotifier
 
-	// srvPending represents the current server requests.
-	srvPending sync.WaitGroup
-
 	// sending guards the write side of the codec - it ensures
 	// that codec.WriteMessage is not called concurrently.
 	// It also guards shutdown.
@@ -133,6 +130,22 @@ type Conn struct {
 	// will be initiated.
 	closing bool
 
+	// drainErr is set when the connection is being drained via
+	// Drain. When this is set, server requests are answered with
+	// it rather than being initiated.
+	drainErr error
+
+	// abandoned is set when Drain times out. When this is set,
+	// Close does not wait for outstanding server requests.
+	abandoned bool
+
+	// srvPending holds the number of current server requests.
+	srvPending int
+
+	// srvIdle, if not nil, is closed when there are no longer any
+	// current server requests.
+	srvIdle chan struct{}
+
 	// shutdown is set when the input loop terminates. When this
 	// is set, no more client requests will be sent to the server.
 	shutdown bool
@@ -298,6 +311,45 @@ func (conn *Conn) Dead() <-chan struct{} {
 	return conn.dead
 }
 
+// Drain prepares the connection to be closed without abandoning the
+// server requests in progress. Further requests are answered with the
+// given error rather than being served, and the object being served is
+// killed if it implements Killer, so that requests waiting on it
+// return. Drain then waits up to the given timeout for outstanding
+// server requests to complete, and reports whether they did. If they
+// did not, they are abandoned: Close will not wait for them.
+func (conn *Conn) Drain(err error, timeout time.Duration) bool {
+	conn.mutex.Lock()
+	conn.drainErr = err
+	if conn.killer != nil {
+		conn.killer.Kill()
+	}
+	idle := conn.idle()
+	conn.mutex.Unlock()
+
+	select {
+	case <-idle:
+		return true
+	case <-time.After(timeout):
+	}
+	conn.mutex.Lock()
+	conn.abandoned = true
+	conn.mutex.Unlock()
+	return false
+}
+
+// idle returns a channel that is closed when there are no current
+// server requests. It must be called with conn.mutex held.
+func (conn *Conn) idle() <-chan struct{} {
+	if conn.srvIdle == nil {
+		conn.srvIdle = make(chan struct{})
+		if conn.srvPending == 0 {
+			close(conn.srvIdle)
+		}
+	}
+	return conn.srvIdle
+}
+
 // Close closes the connection and its underlying codec; it returns when
 // all requests have been terminated.
 //
@@ -323,11 +375,16 @@ func (conn *Conn) Close() error {
 	if conn.killer != nil {
 		conn.killer.Kill()
 	}
+	abandoned := conn.abandoned
+	idle := conn.idle()
 	conn.mutex.Unlock()
 
 	// Wait for any outstanding server requests to complete
-	// and write their replies before closing the codec.
-	conn.srvPending.Wait()
+	// and write their replies before closing the codec, unless
+	// they were abandoned by Drain.
+	if !abandoned {
+		<-idle
+	}
 
 	// Closing the codec should cause the input loop to terminate.
 	if err := conn.codec.Close(); err != nil {
@@ -457,8 +514,12 @@ func (conn *Conn) handleRequest(hdr *Header) error {
 	}
 	conn.mutex.Lock()
 	closing := conn.closing
-	if !closing {
-		conn.srvPending.Add(1)
+	drainErr := conn.drainErr
+	if !closing && drainErr == nil {
+		conn.srvPending++
+		if conn.srvPending == 1 {
+			conn.srvIdle = nil
+		}
 		go conn.runRequest(req, arg, startTime)
 	}
 	conn.mutex.Unlock()
@@ -466,6 +527,9 @@ func (conn *Conn) handleRequest(hdr *Header) error {
 		// We're closing down - no new requests may be initiated.
 		return conn.writeErrorResponse(hdr, req.transformErrors(ErrShutdown), startTime)
 	}
+	if drainErr != nil {
+		return conn.writeErrorResponse(hdr, req.transformErrors(drainErr), startTime)
+	}
 	return nil
 }
 
@@ -527,9 +591,19 @@ func (conn *Conn) bindRequest(hdr *Header) (boundRequest, error) {
 	}, nil
 }
 
+// requestDone records the completion of a server request.
+func (conn *Conn) requestDone() {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	conn.srvPending--
+	if conn.srvPending == 0 && conn.srvIdle != nil {
+		close(conn.srvIdle)
+	}
+}
+
 // runRequest runs the given request and sends the reply.
 func (conn *Conn) runRequest(req boundRequest, arg reflect.Value, startTime time.Time) {
-	defer conn.srvPending.Done()
+	defer conn.requestDone()
 	rv, err := req.Call(req.hdr.Request.Id, arg)
 	if err != nil {
 		err = conn.writeErrorResponse(&req.hdr, req.transformErrors(err), startTime)
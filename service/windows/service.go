@@ -7,6 +7,7 @@ package windows
 import (
 	"errors"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/juju/loggo"
@@ -171,11 +172,41 @@ func NewService(name string, conf common.Conf) *Service {
 	}
 }
 
-// InstallCommands returns shell commands to install and start the service.
+// cloudInitInstallScript installs and starts a service from the
+// cloud-init script of a Windows machine. The agent user's password
+// cannot be read from C:\Juju\Jujud.pass there, since that is only
+// readable by the agent user, so the script uses the $jujuCreds
+// credentials set up by the cloud-init script instead.
+var cloudInitInstallScript = `New-Service -Credential $jujuCreds -Name '%s' -DisplayName '%s' '%s'
+if($? -eq $false){Write-Error "Failed to install service %s"; exit 1}
+cmd.exe /C call sc config '%s' start=delayed-auto
+if($? -eq $false){Write-Error "Failed execute sc"; exit 1}
+Start-Service '%s'`
+
+// InstallCommands returns PowerShell commands to install and start the
+// service from the cloud-init script of a Windows machine.
 func (s *Service) InstallCommands() ([]string, error) {
-	cmd := fmt.Sprintf(serviceInstallScript,
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	cmd := fmt.Sprintf(cloudInitInstallScript,
 		s.Name,
 		s.Conf.Desc,
-		s.Conf.Cmd)
+		strings.Replace(s.Conf.Cmd, "'", "''", -1),
+		s.Name,
+		s.Name,
+		s.Name)
 	return strings.Split(cmd, "\n"), nil
 }
+
+// MachineAgentWindowsService returns the service for a machine agent
+// based on the tag and machineId passed in.
+func MachineAgentWindowsService(name, toolsDir, dataDir, logDir, tag, machineId string) *Service {
+	conf := common.Conf{
+		Desc: fmt.Sprintf("juju %s agent", tag),
+		Cmd: fmt.Sprintf(`"%s" machine --data-dir "%s" --machine-id %s --debug`,
+			path.Join(toolsDir, "jujud.exe"), dataDir, machineId),
+		Out: path.Join(logDir, tag+".log"),
+	}
+	return NewService(name, conf)
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package windows_test
+
+import (
+	"strings"
+	"testing"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/service/common"
+	"github.com/juju/juju/service/windows"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type ServiceSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&ServiceSuite{})
+
+func (s *ServiceSuite) TestMachineAgentWindowsService(c *gc.C) {
+	svc := windows.MachineAgentWindowsService(
+		"jujud-machine-1", "C:/Juju/lib/juju/tools/machine-1", "C:/Juju/lib/juju",
+		"C:/Juju/log", "machine-1", "1")
+	c.Assert(svc.Name, gc.Equals, "jujud-machine-1")
+	c.Assert(svc.Conf, gc.DeepEquals, common.Conf{
+		Desc: "juju machine-1 agent",
+		Cmd:  `"C:/Juju/lib/juju/tools/machine-1/jujud.exe" machine --data-dir "C:/Juju/lib/juju" --machine-id 1 --debug`,
+		Out:  "C:/Juju/log/machine-1.log",
+	})
+}
+
+func (s *ServiceSuite) TestInstallCommands(c *gc.C) {
+	svc := windows.NewService("jujud-machine-1", common.Conf{
+		Desc: "juju machine-1 agent",
+		Cmd:  `"C:/jujud.exe" machine --nonce 'x'`,
+	})
+	cmds, err := svc.InstallCommands()
+	c.Assert(err, gc.IsNil)
+	script := strings.Join(cmds, "\n")
+	c.Assert(cmds[0], gc.Equals, `New-Service -Credential $jujuCreds -Name 'jujud-machine-1' -DisplayName 'juju machine-1 agent' '"C:/jujud.exe" machine --nonce ''x'''`)
+	c.Assert(script, gc.Matches, `(?s).*sc config 'jujud-machine-1' start=delayed-auto.*`)
+	c.Assert(cmds[len(cmds)-1], gc.Equals, `Start-Service 'jujud-machine-1'`)
+}
+
+func (s *ServiceSuite) TestInstallCommandsInvalid(c *gc.C) {
+	svc := windows.NewService("jujud-machine-1", common.Conf{Desc: "juju machine-1 agent"})
+	_, err := svc.InstallCommands()
+	c.Assert(err, gc.ErrorMatches, "missing Cmd")
+}
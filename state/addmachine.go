@@ -34,6 +34,8 @@ type MachineTemplate struct {
 	// A machine must have at least one job to do.
 	// JobManageEnviron can only be part of the jobs
 	// when the first (bootstrap) machine is added.
+	// JobManageAPI cannot be combined with JobManageEnviron,
+	// which already runs an API server.
 	Jobs []MachineJob
 
 	// NoVote holds whether a machine running
@@ -224,6 +226,9 @@ func (st *State) effectiveMachineTemplate(p MachineTemplate, allowStateServer bo
 		if !allowStateServer {
 			return tmpl, errStateServerNotAllowed
 		}
+		if jset[JobManageAPI] {
+			return tmpl, errManageAPIWithManageEnviron
+		}
 	}
 	return p, nil
 }
@@ -414,7 +419,7 @@ func machineDocForTemplate(template MachineTemplate, id string) *machineDoc {
 // document into the database, based on the given template. Only the
 // constraints and networks are used from the template.
 func (st *State) insertNewMachineOps(mdoc *machineDoc, template MachineTemplate) []txn.Op {
-	return []txn.Op{
+	ops := []txn.Op{
 		{
 			C:      machinesC,
 			Id:     mdoc.Id,
@@ -431,6 +436,30 @@ func (st *State) insertNewMachineOps(mdoc *machineDoc, template MachineTemplate)
 		// and known before setting them.
 		createRequestedNetworksOp(st, machineGlobalKey(mdoc.Id), template.RequestedNetworks),
 	}
+	if hasJob(mdoc.Jobs, JobManageAPI) {
+		ops = append(ops, addAPIMachineOp(mdoc.Id))
+	}
+	return ops
+}
+
+// addAPIMachineOp returns an operation that records the given
+// machine as running only an API server.
+func addAPIMachineOp(id string) txn.Op {
+	return txn.Op{
+		C:      stateServersC,
+		Id:     environGlobalKey,
+		Update: bson.D{{"$addToSet", bson.D{{"apimachineids", id}}}},
+	}
+}
+
+// removeAPIMachineOp returns an operation that records the given
+// machine as no longer running only an API server.
+func removeAPIMachineOp(id string) txn.Op {
+	return txn.Op{
+		C:      stateServersC,
+		Id:     environGlobalKey,
+		Update: bson.D{{"$pull", bson.D{{"apimachineids", id}}}},
+	}
 }
 
 func hasJob(jobs []MachineJob, job MachineJob) bool {
@@ -444,6 +473,8 @@ func hasJob(jobs []MachineJob, job MachineJob) bool {
 
 var errStateServerNotAllowed = fmt.Errorf("state server jobs specified without calling EnsureAvailability")
 
+var errManageAPIWithManageEnviron = fmt.Errorf("%s and %s jobs cannot be combined", params.JobManageAPI, params.JobManageEnviron)
+
 // maintainStateServersOps returns a set of operations that will maintain
 // the state server information when the given machine documents
 // are added to the machines collection. If currentInfo is nil,
@@ -649,6 +680,12 @@ func (st *State) ensureAvailabilityIntentions(info *StateServerInfo) (*ensureAva
 		if err != nil {
 			return nil, err
 		}
+		if m.doc.Demoting {
+			// The machine is having JobManageEnviron removed, so
+			// it must not be promoted again; it leaves once the
+			// peer grouper has removed its vote.
+			continue
+		}
 		available, err := stateServerAvailable(m)
 		if err != nil {
 			return nil, err
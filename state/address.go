@@ -16,6 +16,19 @@ import (
 // stateServerAddresses returns the list of internal addresses of the state
 // server machines.
 func (st *State) stateServerAddresses() ([]string, error) {
+	return st.jobMachineAddresses(JobManageEnviron)
+}
+
+// apiServerAddresses returns the list of internal addresses of the
+// machines running an API server, including those that do not also
+// run mongo.
+func (st *State) apiServerAddresses() ([]string, error) {
+	return st.jobMachineAddresses(JobManageEnviron, JobManageAPI)
+}
+
+// jobMachineAddresses returns the list of internal addresses of the
+// machines having any of the given jobs.
+func (st *State) jobMachineAddresses(jobs ...MachineJob) ([]string, error) {
 	type addressMachine struct {
 		Addresses []address
 	}
@@ -23,7 +36,7 @@ func (st *State) stateServerAddresses() ([]string, error) {
 	// TODO(rog) 2013/10/14 index machines on jobs.
 	machines, closer := st.getCollection(machinesC)
 	defer closer()
-	err := machines.Find(bson.D{{"jobs", JobManageEnviron}}).All(&allAddresses)
+	err := machines.Find(bson.D{{"jobs", bson.D{{"$in", jobs}}}}).All(&allAddresses)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +84,7 @@ func (st *State) Addresses() ([]string, error) {
 // This method will be deprecated when API addresses are
 // stored independently in their own document.
 func (st *State) APIAddressesFromMachines() ([]string, error) {
-	addrs, err := st.stateServerAddresses()
+	addrs, err := st.apiServerAddresses()
 	if err != nil {
 		return nil, err
 	}
@@ -153,13 +166,17 @@ func (st *State) DeployerConnectionInfo() (*DeployerConnectionValues, error) {
 	if err != nil {
 		return nil, err
 	}
+	apiAddrs, err := st.apiServerAddresses()
+	if err != nil {
+		return nil, err
+	}
 	config, err := st.EnvironConfig()
 	if err != nil {
 		return nil, err
 	}
 	return &DeployerConnectionValues{
 		StateAddresses: appendPort(addrs, config.StatePort()),
-		APIAddresses:   appendPort(addrs, config.APIPort()),
+		APIAddresses:   appendPort(apiAddrs, config.APIPort()),
 	}, nil
 }
 
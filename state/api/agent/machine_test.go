@@ -50,6 +50,22 @@ func (s *servingInfoSuite) TestStateServingInfo(c *gc.C) {
 	c.Assert(info, jc.DeepEquals, expected)
 }
 
+func (s *servingInfoSuite) TestStateServingInfoForAPIServer(c *gc.C) {
+	st, _ := s.OpenAPIAsNewMachine(c, state.JobManageAPI)
+
+	expected := params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "Some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      33,
+		StatePort:    44,
+	}
+	s.State.SetStateServingInfo(expected)
+	info, err := st.Agent().StateServingInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.DeepEquals, expected)
+}
+
 func (s *servingInfoSuite) TestStateServingInfoPermission(c *gc.C) {
 	st, _ := s.OpenAPIAsNewMachine(c)
 
@@ -117,6 +133,18 @@ func (s *machineSuite) TestMachineEntity(c *gc.C) {
 	c.Assert(m, gc.IsNil)
 }
 
+func (s *machineSuite) TestEntityPasswordRotationRequired(c *gc.C) {
+	entity, err := s.st.Agent().Entity(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entity.PasswordRotationRequired(), jc.IsFalse)
+
+	err = s.machine.RequirePasswordRotation()
+	c.Assert(err, gc.IsNil)
+	entity, err = s.st.Agent().Entity(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entity.PasswordRotationRequired(), jc.IsTrue)
+}
+
 func (s *machineSuite) TestEntitySetPassword(c *gc.C) {
 	entity, err := s.st.Agent().Entity(s.machine.Tag())
 	c.Assert(err, gc.IsNil)
@@ -102,6 +102,12 @@ func (m *Entity) ContainerType() instance.ContainerType {
 	return m.doc.ContainerType
 }
 
+// PasswordRotationRequired returns whether the agent must change its
+// password.
+func (m *Entity) PasswordRotationRequired() bool {
+	return m.doc.PasswordRotationRequired
+}
+
 // SetPassword sets the password associated with the agent's entity.
 func (m *Entity) SetPassword(password string) error {
 	var results params.ErrorResults
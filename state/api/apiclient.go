@@ -8,7 +8,9 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"code.google.com/p/go.net/websocket"
@@ -37,6 +39,12 @@ type State struct {
 	// addr is the address used to connect to the API server.
 	addr string
 
+	// failedAddrs holds the addresses that could not be dialed
+	// before the connection was made. Addresses that were still
+	// being dialed, or not yet dialed, when the connection was
+	// made are not included.
+	failedAddrs []string
+
 	// environTag holds the environment tag once we're connected
 	environTag string
 
@@ -143,6 +151,7 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	// Dial all addresses at reasonable intervals.
 	try := parallel.NewTry(0, nil)
 	defer try.Kill()
+	failures := &dialFailures{}
 	var addrs []string
 	for _, addr := range info.Addrs {
 		if strings.HasPrefix(addr, "localhost:") {
@@ -154,7 +163,7 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		addrs = info.Addrs
 	}
 	for _, addr := range addrs {
-		err := dialWebsocket(addr, environUUID, opts, pool, try)
+		err := dialWebsocket(addr, environUUID, opts, pool, try, failures)
 		if err == parallel.ErrStopped {
 			break
 		}
@@ -177,10 +186,11 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	client := rpc.NewConn(jsoncodec.NewWebsocket(conn), nil)
 	client.Start()
 	st := &State{
-		client:     client,
-		conn:       conn,
-		addr:       conn.Config().Location.Host,
-		serverRoot: "https://" + conn.Config().Location.Host,
+		client:      client,
+		conn:        conn,
+		addr:        conn.Config().Location.Host,
+		failedAddrs: failures.get(),
+		serverRoot:  "https://" + conn.Config().Location.Host,
 		// why are the contents of the tag (username and password) written into the
 		// state structure BEFORE login ?!?
 		tag:      toString(info.Tag),
@@ -207,12 +217,40 @@ func toString(tag names.Tag) string {
 	return tag.String()
 }
 
-func dialWebsocket(addr, environUUID string, opts DialOpts, rootCAs *x509.CertPool, try *parallel.Try) error {
+func dialWebsocket(addr, environUUID string, opts DialOpts, rootCAs *x509.CertPool, try *parallel.Try, failures *dialFailures) error {
 	cfg, err := setUpWebsocket(addr, environUUID, rootCAs)
 	if err != nil {
 		return err
 	}
-	return try.Start(newWebsocketDialer(cfg, opts))
+	return try.Start(newWebsocketDialer(cfg, opts, failures))
+}
+
+// dialFailures records the addresses that could not be dialed.
+type dialFailures struct {
+	mu    sync.Mutex
+	addrs []string
+}
+
+// add records that the given address could not be dialed.
+func (f *dialFailures) add(addr string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, a := range f.addrs {
+		if a == addr {
+			return
+		}
+	}
+	f.addrs = append(f.addrs, addr)
+}
+
+// get returns the addresses recorded so far.
+func (f *dialFailures) get() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.addrs...)
 }
 
 func setUpWebsocket(addr, environUUID string, rootCAs *x509.CertPool) (*websocket.Config, error) {
@@ -232,12 +270,19 @@ func setUpWebsocket(addr, environUUID string, rootCAs *x509.CertPool) (*websocke
 		RootCAs:    rootCAs,
 		ServerName: "anything",
 	}
+	// Large responses, such as the status of a big environment,
+	// are much smaller compressed.
+	cfg.Header = http.Header{
+		jsoncodec.CompressionHeader: {jsoncodec.CompressionDeflate},
+	}
 	return cfg, nil
 }
 
 // newWebsocketDialer returns a function that
-// can be passed to utils/parallel.Try.Start.
-func newWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan struct{}) (io.Closer, error) {
+// can be passed to utils/parallel.Try.Start. Each
+// address that cannot be dialed is recorded in
+// failures, which may be nil.
+func newWebsocketDialer(cfg *websocket.Config, opts DialOpts, failures *dialFailures) func(<-chan struct{}) (io.Closer, error) {
 	openAttempt := utils.AttemptStrategy{
 		Total: opts.Timeout,
 		Delay: opts.RetryDelay,
@@ -254,6 +299,7 @@ func newWebsocketDialer(cfg *websocket.Config, opts DialOpts) func(<-chan struct
 			if err == nil {
 				return conn, nil
 			}
+			failures.add(cfg.Location.Host)
 			if a.HasNext() {
 				logger.Debugf("error dialing %q, will retry: %v", cfg.Location, err)
 			} else {
@@ -325,6 +371,13 @@ func (s *State) Addr() string {
 	return s.addr
 }
 
+// FailedAddrs returns the addresses that could not be dialed before
+// the connection to Addr was made. Addresses that were abandoned when
+// the connection was made are not included.
+func (s *State) FailedAddrs() []string {
+	return s.failedAddrs
+}
+
 // EnvironTag returns the Environment Tag describing the environment we are
 // connected to.
 func (s *State) EnvironTag() string {
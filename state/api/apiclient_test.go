@@ -8,12 +8,14 @@ import (
 	"io"
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/juju/names"
 	"github.com/juju/utils/parallel"
 	gc "launchpad.net/gocheck"
 
 	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/rpc/jsoncodec"
 	"github.com/juju/juju/state/api"
 	"github.com/juju/juju/state/api/params"
 	coretesting "github.com/juju/juju/testing"
@@ -96,15 +98,18 @@ func (s *apiclientSuite) TestOpenMultiple(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	defer st.Close()
 	c.Assert(st.Addr(), gc.Equals, proxyAddr)
+	c.Assert(st.FailedAddrs(), gc.HasLen, 0)
 
 	// Now break Addrs[0], and ensure that Addrs[1]
-	// is successfully connected to.
+	// is successfully connected to, and that Addrs[0]
+	// is reported as failing.
 	info.Addrs = []string{proxyAddr, serverAddr}
 	listener.Close()
-	st, err = api.Open(info, api.DialOpts{})
+	st, err = api.Open(info, api.DialOpts{DialAddressInterval: 50 * time.Millisecond})
 	c.Assert(err, gc.IsNil)
 	defer st.Close()
 	c.Assert(st.Addr(), gc.Equals, serverAddr)
+	c.Assert(st.FailedAddrs(), gc.DeepEquals, []string{proxyAddr})
 }
 
 func (s *apiclientSuite) TestOpenMultipleError(c *gc.C) {
@@ -156,7 +161,7 @@ func (s *apiclientSuite) TestOpenPassesEnvironTag(c *gc.C) {
 
 func (s *apiclientSuite) TestDialWebsocketStopped(c *gc.C) {
 	stopped := make(chan struct{})
-	f := api.NewWebsocketDialer(nil, api.DialOpts{})
+	f := api.NewWebsocketDialer(nil, api.DialOpts{}, nil)
 	close(stopped)
 	result, err := f(stopped)
 	c.Assert(err, gc.Equals, parallel.ErrStopped)
@@ -168,6 +173,7 @@ func (*websocketSuite) TestSetUpWebsocketConfig(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Check(conf.Location.String(), gc.Equals, "wss://0.1.2.3:1234/")
 	c.Check(conf.Origin.String(), gc.Equals, "http://localhost/")
+	c.Check(conf.Header.Get(jsoncodec.CompressionHeader), gc.Equals, jsoncodec.CompressionDeflate)
 }
 
 func (*websocketSuite) TestSetUpWebsocketConfigHandlesEnvironUUID(c *gc.C) {
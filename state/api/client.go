@@ -26,6 +26,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/api/watcher"
 	"github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 )
@@ -54,6 +55,10 @@ type AgentStatus struct {
 	Version string
 	Life    string
 	Err     error
+
+	// LastSeen holds when the agent was last known to be alive.
+	// It is set only when the agent is down and the time is known.
+	LastSeen *time.Time
 }
 
 // MachineStatus holds status info about a machine.
@@ -80,6 +85,10 @@ type MachineStatus struct {
 	Jobs          []params.MachineJob
 	HasVote       bool
 	WantsVote     bool
+
+	// HardwareCharacteristics holds the hardware characteristics
+	// recorded for the machine when it was provisioned, if any.
+	HardwareCharacteristics *instance.HardwareCharacteristics
 }
 
 // ServiceStatus holds status info about a service.
@@ -106,6 +115,11 @@ type UnitStatus struct {
 	Life           string
 	Err            error
 
+	// WorkloadStatus and WorkloadStatusInfo hold the status of the
+	// unit's workload as reported by its charm.
+	WorkloadStatus     params.WorkloadStatus
+	WorkloadStatusInfo string
+
 	Machine       string
 	OpenedPorts   []string
 	PublicAddress string
@@ -153,9 +167,29 @@ type Status struct {
 
 // Status returns the status of the juju environment.
 func (c *Client) Status(patterns []string) (*Status, error) {
+	return c.FilteredStatus(params.StatusParams{Patterns: patterns})
+}
+
+// FilteredStatus returns the status of the parts of the juju
+// environment matching the given filters, which are applied by the
+// server.
+func (c *Client) FilteredStatus(filter params.StatusParams) (*Status, error) {
 	var result Status
-	p := params.StatusParams{Patterns: patterns}
-	if err := c.call("FullStatus", p, &result); err != nil {
+	if err := c.call("FullStatus", filter, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// StatusChanges returns the changes to the environment's entities since
+// the given token was returned by an earlier call, and a token for the
+// next call. Pass an empty token to get every entity. If the result's
+// Reset field is true, it holds every entity rather than just the
+// changes.
+func (c *Client) StatusChanges(token string) (*params.StatusChangesResult, error) {
+	var result params.StatusChangesResult
+	p := params.StatusChangesParams{Token: token}
+	if err := c.call("StatusChanges", p, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -210,10 +244,50 @@ func (c *Client) Resolved(unit string, retry bool) error {
 	return c.call("Resolved", p, nil)
 }
 
+// MoveUnit moves a unit, together with its subordinates, onto another
+// machine without destroying it.
+func (c *Client) MoveUnit(unit, machine string) error {
+	p := params.MoveUnit{
+		UnitName:  unit,
+		MachineId: machine,
+	}
+	return c.call("MoveUnit", p, nil)
+}
+
+// ScheduledTasks returns the tasks run periodically by the state
+// servers, with the outcome of their most recent runs.
+func (c *Client) ScheduledTasks() ([]params.ScheduledTask, error) {
+	var results params.ScheduledTasksResults
+	if err := c.call("ScheduledTasks", nil, &results); err != nil {
+		return nil, err
+	}
+	return results.Tasks, nil
+}
+
+// SetScheduledTaskEnabled enables or disables the named task run
+// periodically by the state servers.
+func (c *Client) SetScheduledTaskEnabled(name string, enabled bool) error {
+	p := params.SetScheduledTaskEnabled{
+		Name:    name,
+		Enabled: enabled,
+	}
+	return c.call("SetScheduledTaskEnabled", p, nil)
+}
+
 // RetryProvisioning updates the provisioning status of a machine allowing the
 // provisioner to retry.
 func (c *Client) RetryProvisioning(machines ...string) ([]params.ErrorResult, error) {
-	p := params.Entities{}
+	return c.RetryProvisioningWithArgs(nil, "", machines...)
+}
+
+// RetryProvisioningWithArgs is like RetryProvisioning, but also replaces
+// the machines' constraints, if cons is non-nil, and their placement
+// directive, if placement is non-empty, before provisioning is retried.
+func (c *Client) RetryProvisioningWithArgs(cons *constraints.Value, placement string, machines ...string) ([]params.ErrorResult, error) {
+	p := params.RetryProvisioning{
+		Constraints: cons,
+		Placement:   placement,
+	}
 	p.Entities = make([]params.Entity, len(machines))
 	for i, machine := range machines {
 		p.Entities[i] = params.Entity{Tag: machine}
@@ -223,6 +297,49 @@ func (c *Client) RetryProvisioning(machines ...string) ([]params.ErrorResult, er
 	return results.Results, err
 }
 
+// RotateAgentPasswords requires the agents of the machines and units
+// with the given tags to change their passwords the next time they
+// connect.
+func (c *Client) RotateAgentPasswords(tags ...string) ([]params.ErrorResult, error) {
+	args := params.Entities{Entities: make([]params.Entity, len(tags))}
+	for i, tag := range tags {
+		args.Entities[i] = params.Entity{Tag: tag}
+	}
+	var results params.ErrorResults
+	err := c.st.Call("Client", "", "RotateAgentPasswords", args, &results)
+	return results.Results, err
+}
+
+// HardwareCharacteristics returns the hardware characteristics
+// recorded for each of the machines with the given tags when it was
+// provisioned.
+func (c *Client) HardwareCharacteristics(tags ...string) ([]params.HardwareCharacteristicsResult, error) {
+	args := params.Entities{Entities: make([]params.Entity, len(tags))}
+	for i, tag := range tags {
+		args.Entities[i] = params.Entity{Tag: tag}
+	}
+	var results params.HardwareCharacteristicsResults
+	err := c.st.Call("Client", "", "HardwareCharacteristics", args, &results)
+	return results.Results, err
+}
+
+// PrepareSeriesUpgrade starts an in-place upgrade of the OS of the
+// given machine to the given series.
+func (c *Client) PrepareSeriesUpgrade(machine, series string) error {
+	p := params.PrepareSeriesUpgrade{
+		Tag:    names.NewMachineTag(machine).String(),
+		Series: series,
+	}
+	return c.call("PrepareSeriesUpgrade", p, nil)
+}
+
+// CompleteSeriesUpgrade completes an in-place upgrade of the OS of the
+// given machine, once the OS has been upgraded.
+func (c *Client) CompleteSeriesUpgrade(machine string) error {
+	p := params.Entity{Tag: names.NewMachineTag(machine).String()}
+	return c.call("CompleteSeriesUpgrade", p, nil)
+}
+
 // PublicAddress returns the public address of the specified
 // machine or unit.
 func (c *Client) PublicAddress(target string) (string, error) {
@@ -232,6 +349,17 @@ func (c *Client) PublicAddress(target string) (string, error) {
 	return results.PublicAddress, err
 }
 
+// WatchPublicAddress returns a watcher that notifies when the public
+// address of the named unit changes.
+func (c *Client) WatchPublicAddress(unitName string) (watcher.NotifyWatcher, error) {
+	var result params.NotifyWatchResult
+	p := params.PublicAddress{Target: unitName}
+	if err := c.call("WatchPublicAddress", p, &result); err != nil {
+		return nil, err
+	}
+	return watcher.NewNotifyWatcher(c.st, result), nil
+}
+
 // PrivateAddress returns the private address of the specified
 // machine or unit.
 func (c *Client) PrivateAddress(target string) (string, error) {
@@ -267,6 +395,23 @@ func (c *Client) AddRelation(endpoints ...string) (*params.AddRelationResults, e
 	return &addRelRes, err
 }
 
+// InferEndpoints returns the endpoints of the relation that the given,
+// possibly partial, endpoint names refer to, without adding it. If the
+// names are ambiguous, the returned error satisfies
+// params.IsCodeAmbiguousRelation and the keys of the relations they
+// could refer to are returned alongside it.
+func (c *Client) InferEndpoints(endpoints ...string) ([]params.Endpoint, []string, error) {
+	var results params.InferEndpointsResults
+	args := params.AddRelation{Endpoints: endpoints}
+	if err := c.call("InferEndpoints", args, &results); err != nil {
+		return nil, nil, err
+	}
+	if results.Error != nil {
+		return nil, results.Candidates, results.Error
+	}
+	return results.Endpoints, nil, nil
+}
+
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(endpoints ...string) error {
 	params := params.DestroyRelation{Endpoints: endpoints}
@@ -281,6 +426,90 @@ func (c *Client) ServiceCharmRelations(service string) ([]string, error) {
 	return results.CharmRelations, err
 }
 
+// ServiceRelations returns the details of all the service's relations.
+func (c *Client) ServiceRelations(service string) ([]params.RelationDetails, error) {
+	var results params.ServiceRelationsResults
+	params := params.ServiceRelations{ServiceName: service}
+	err := c.call("ServiceRelations", params, &results)
+	return results.Relations, err
+}
+
+// WatchServiceRelations returns a watcher that notifies of the keys
+// of relations involving the service as they are added, become dying
+// or are removed.
+func (c *Client) WatchServiceRelations(service string) (watcher.StringsWatcher, error) {
+	var result params.StringsWatchResult
+	p := params.ServiceRelations{ServiceName: service}
+	if err := c.call("WatchServiceRelations", p, &result); err != nil {
+		return nil, err
+	}
+	return watcher.NewStringsWatcher(c.st, result), nil
+}
+
+// ServiceDistribution returns the provisioned units of the service
+// grouped by availability zone, least populated zone first.
+func (c *Client) ServiceDistribution(service string) ([]params.ZoneUnits, error) {
+	var result params.ServiceDistributionResults
+	p := params.ServiceDistribution{ServiceName: service}
+	if err := c.call("ServiceDistribution", p, &result); err != nil {
+		return nil, err
+	}
+	return result.Zones, nil
+}
+
+// GetRelation returns the details of the relation between the
+// specified endpoints.
+func (c *Client) GetRelation(endpoints ...string) (*params.RelationDetails, error) {
+	var result params.RelationDetails
+	params := params.GetRelation{Endpoints: endpoints}
+	err := c.call("GetRelation", params, &result)
+	return &result, err
+}
+
+// OrphanedRelationSettings returns the keys of relation unit settings
+// left behind by relations that no longer exist. They are removed
+// periodically by the state servers.
+func (c *Client) OrphanedRelationSettings() ([]string, error) {
+	var results params.OrphanedRelationSettingsResults
+	err := c.call("OrphanedRelationSettings", nil, &results)
+	return results.Keys, err
+}
+
+// CheckConsistency reports inconsistencies in state. If repair is
+// true, inconsistencies that can be repaired safely are repaired.
+func (c *Client) CheckConsistency(repair bool) ([]params.Inconsistency, error) {
+	args := params.CheckConsistency{Repair: repair}
+	var results params.CheckConsistencyResults
+	err := c.call("CheckConsistency", args, &results)
+	return results.Inconsistencies, err
+}
+
+// ListMachines returns a page of the machines in the environment,
+// with only the fields requested in args filled in, and the marker
+// from which to request the next page. The marker is empty if there
+// are no more machines.
+func (c *Client) ListMachines(args params.ListEntities) ([]params.MachineSummary, string, error) {
+	var results params.ListMachinesResults
+	err := c.call("ListMachines", args, &results)
+	return results.Machines, results.Marker, err
+}
+
+// ListUnits returns a page of the units in the environment, in the
+// same way as ListMachines.
+func (c *Client) ListUnits(args params.ListEntities) ([]params.UnitSummary, string, error) {
+	var results params.ListUnitsResults
+	err := c.call("ListUnits", args, &results)
+	return results.Units, results.Marker, err
+}
+
+// ListServices returns a page of the services in the environment, in
+// the same way as ListMachines.
+func (c *Client) ListServices(args params.ListEntities) ([]params.ServiceSummary, string, error) {
+	var results params.ListServicesResults
+	err := c.call("ListServices", args, &results)
+	return results.Services, results.Marker, err
+}
+
 // AddMachines1dot18 adds new machines with the supplied parameters.
 //
 // TODO(axw) 2014-04-11 #XXX
@@ -314,6 +543,15 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (scrip
 	return result.Script, nil
 }
 
+// MachinesWithJob returns the ids of all machines that have been
+// assigned the given job.
+func (c *Client) MachinesWithJob(job params.MachineJob) ([]string, error) {
+	var results params.MachinesWithJobResults
+	params := params.MachinesWithJob{Job: job}
+	err := c.call("MachinesWithJob", params, &results)
+	return results.Machines, err
+}
+
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(machines ...string) error {
 	params := params.DestroyMachines{MachineNames: machines}
@@ -388,6 +626,32 @@ func (c *Client) ServiceSetCharm(serviceName string, charmUrl string, force bool
 	return c.call("ServiceSetCharm", args, nil)
 }
 
+// ServiceSetCharmStaged starts a staged upgrade of the service to the
+// given charm, which must already have been added. Only new units and
+// the named canary units are upgraded until the others are released
+// with ServiceReleaseUnits.
+func (c *Client) ServiceSetCharmStaged(serviceName, charmUrl string, force bool, canaryUnits []string) error {
+	args := params.ServiceSetCharmStaged{
+		ServiceName: serviceName,
+		CharmUrl:    charmUrl,
+		Force:       force,
+		CanaryUnits: canaryUnits,
+	}
+	return c.call("ServiceSetCharmStaged", args, nil)
+}
+
+// ServiceReleaseUnits advances a staged upgrade of the service by
+// releasing the named units, or all the pinned units if all is true,
+// so that they upgrade to the service's charm.
+func (c *Client) ServiceReleaseUnits(serviceName string, units []string, all bool) error {
+	args := params.ServiceReleaseUnits{
+		ServiceName: serviceName,
+		Units:       units,
+		All:         all,
+	}
+	return c.call("ServiceReleaseUnits", args, nil)
+}
+
 // ServiceGetCharmURL returns the charm URL the given service is
 // running at present.
 func (c *Client) ServiceGetCharmURL(serviceName string) (*charm.URL, error) {
@@ -441,20 +705,28 @@ func (c *Client) GetEnvironmentConstraints() (constraints.Value, error) {
 }
 
 // SetServiceConstraints specifies the constraints for the given service.
-func (c *Client) SetServiceConstraints(service string, constraints constraints.Value) error {
-	params := params.SetConstraints{
+// It returns the names of any constraints that were set but are not
+// supported by the environment's provider.
+func (c *Client) SetServiceConstraints(service string, constraints constraints.Value) ([]string, error) {
+	args := params.SetConstraints{
 		ServiceName: service,
 		Constraints: constraints,
 	}
-	return c.call("SetServiceConstraints", params, nil)
+	var result params.SetConstraintsResults
+	err := c.call("SetServiceConstraints", args, &result)
+	return result.Unsupported, err
 }
 
 // SetEnvironmentConstraints specifies the constraints for the environment.
-func (c *Client) SetEnvironmentConstraints(constraints constraints.Value) error {
-	params := params.SetConstraints{
+// It returns the names of any constraints that were set but are not
+// supported by the environment's provider.
+func (c *Client) SetEnvironmentConstraints(constraints constraints.Value) ([]string, error) {
+	args := params.SetConstraints{
 		Constraints: constraints,
 	}
-	return c.call("SetEnvironmentConstraints", params, nil)
+	var result params.SetConstraintsResults
+	err := c.call("SetEnvironmentConstraints", args, &result)
+	return result.Unsupported, err
 }
 
 // CharmInfo holds information about a charm.
@@ -481,6 +753,10 @@ type EnvironmentInfo struct {
 	ProviderType  string
 	Name          string
 	UUID          string
+
+	// Phase holds the phase of the environment's lifecycle:
+	// "ready" or "destroying".
+	Phase string
 }
 
 // EnvironmentInfo returns details about the Juju environment.
@@ -569,6 +845,20 @@ func (c *Client) SetEnvironAgentVersion(version version.Number) error {
 	return c.call("SetEnvironAgentVersion", args, nil)
 }
 
+// StageAgentVersion starts the download phase of an upgrade to the
+// given agent version. Agents download the new tools, but do not
+// restart into them until ActivateStagedUpgrade is called.
+func (c *Client) StageAgentVersion(version version.Number) error {
+	args := params.SetEnvironAgentVersion{Version: version}
+	return c.call("StageAgentVersion", args, nil)
+}
+
+// ActivateStagedUpgrade upgrades the environment to the staged agent
+// version, once every agent has downloaded its tools.
+func (c *Client) ActivateStagedUpgrade() error {
+	return c.call("ActivateStagedUpgrade", nil, nil)
+}
+
 // FindTools returns a List containing all tools matching the specified parameters.
 func (c *Client) FindTools(majorVersion, minorVersion int,
 	series, arch string) (result params.FindToolsResults, err error) {
@@ -29,3 +29,23 @@ func Life(caller base.Caller, facadeName string, tag names.Tag) (params.Life, er
 	}
 	return result.Results[0].Life, nil
 }
+
+// Lives requests the life cycles of the given entities from the given
+// server-side API facade via the given caller, in a single call. The
+// results are in the same order as the tags.
+func Lives(caller base.Caller, facadeName string, tags ...names.Tag) ([]params.LifeResult, error) {
+	var result params.LifeResults
+	args := params.Entities{
+		Entities: make([]params.Entity, len(tags)),
+	}
+	for i, tag := range tags {
+		args.Entities[i].Tag = tag.String()
+	}
+	if err := caller.Call(facadeName, "", "Life", args, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Results) != len(tags) {
+		return nil, errors.Errorf("expected %d results, got %d", len(tags), len(result.Results))
+	}
+	return result.Results, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller
+
+import (
+	"github.com/juju/juju/state/api/base"
+	"github.com/juju/juju/state/api/params"
+)
+
+const apiName = "Controller"
+
+// Facade provides access to the settings of the controller.
+type Facade struct {
+	caller base.Caller
+}
+
+// NewFacade returns a new api client facade instance.
+func NewFacade(caller base.Caller) *Facade {
+	return &Facade{caller}
+}
+
+// ControllerSettings returns the settings shared by all environments
+// hosted by the controller.
+func (f *Facade) ControllerSettings() (map[string]interface{}, error) {
+	var result params.ControllerSettingsResult
+	err := f.caller.Call(apiName, "", "ControllerSettings", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Settings, nil
+}
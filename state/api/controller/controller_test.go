@@ -0,0 +1,26 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+)
+
+type controllerSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&controllerSuite{})
+
+func (s *controllerSuite) TestControllerSettings(c *gc.C) {
+	expected, err := s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+
+	settings, err := s.APIState.Controller().ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, jc.DeepEquals, expected)
+}
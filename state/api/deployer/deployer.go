@@ -4,11 +4,15 @@
 package deployer
 
 import (
+	"fmt"
+
 	"github.com/juju/names"
 
 	"github.com/juju/juju/state/api/base"
 	"github.com/juju/juju/state/api/common"
 	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
 )
 
 const deployerFacade = "Deployer"
@@ -76,3 +80,28 @@ func (st *State) ConnectionInfo() (result params.DeployerConnectionValues, err e
 	err = st.call("ConnectionInfo", nil, &result)
 	return result, err
 }
+
+// FindAgentTools returns the tools metadata for the given version of
+// the tools run by the agent with the given tag, which must be the
+// machine agent running the deployer.
+func (st *State) FindAgentTools(tag string, vers version.Binary) (*tools.Tools, error) {
+	var results params.ToolsResults
+	args := params.EntitiesVersion{
+		AgentTools: []params.EntityVersion{{
+			Tag:   tag,
+			Tools: &params.Version{Version: vers},
+		}},
+	}
+	err := st.call("FindAgentTools", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
@@ -19,6 +19,7 @@ import (
 	apitesting "github.com/juju/juju/state/api/testing"
 	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 )
 
 func TestAll(t *stdtesting.T) {
@@ -240,3 +241,13 @@ func (s *deployerSuite) TestStateAddresses(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(addresses, gc.DeepEquals, stateAddresses)
 }
+
+func (s *deployerSuite) TestFindAgentTools(c *gc.C) {
+	agentTools, err := s.st.FindAgentTools(s.machine.Tag().String(), version.Current)
+	c.Assert(err, gc.IsNil)
+	c.Assert(agentTools.Version, gc.Equals, version.Current)
+	c.Assert(agentTools.SHA256, gc.Not(gc.Equals), "")
+
+	_, err = s.st.FindAgentTools("machine-42", version.Current)
+	s.assertUnauthorized(c, err)
+}
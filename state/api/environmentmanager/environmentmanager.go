@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager
+
+import (
+	"github.com/juju/names"
+
+	"github.com/juju/juju/state/api/base"
+	"github.com/juju/juju/state/api/params"
+)
+
+const apiName = "EnvironmentManager"
+
+// Facade provides access to the environments hosted by the state
+// servers.
+type Facade struct {
+	caller base.Caller
+}
+
+// NewFacade returns a new api client facade instance.
+func NewFacade(caller base.Caller) *Facade {
+	return &Facade{caller}
+}
+
+// CreateEnvironment creates a new environment hosted by the state
+// servers. Attributes not given in config are taken from the
+// configuration of the state server environment.
+func (f *Facade) CreateEnvironment(config map[string]interface{}) (params.HostedEnvironment, error) {
+	var result params.HostedEnvironment
+	args := params.EnvironmentCreateArgs{Config: config}
+	err := f.caller.Call(apiName, "", "CreateEnvironment", args, &result)
+	return result, err
+}
+
+// ListEnvironments returns all the environments known to the state
+// servers.
+func (f *Facade) ListEnvironments() ([]params.HostedEnvironment, error) {
+	var result params.HostedEnvironmentList
+	err := f.caller.Call(apiName, "", "ListEnvironments", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Environments, nil
+}
+
+// DestroyEnvironment destroys the hosted environment with the given
+// tag.
+func (f *Facade) DestroyEnvironment(tag names.EnvironTag) error {
+	var result params.ErrorResults
+	args := params.Entities{Entities: []params.Entity{{Tag: tag.String()}}}
+	err := f.caller.Call(apiName, "", "DestroyEnvironments", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
@@ -0,0 +1,38 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager_test
+
+import (
+	"github.com/juju/names"
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/api/params"
+)
+
+type environmentManagerSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&environmentManagerSuite{})
+
+func (s *environmentManagerSuite) TestCreateListDestroy(c *gc.C) {
+	facade := s.APIState.EnvironmentManager()
+	created, err := facade.CreateEnvironment(map[string]interface{}{"name": "zzz-hosted"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(created.Name, gc.Equals, "zzz-hosted")
+	c.Assert(created.Life, gc.Equals, params.Alive)
+
+	envs, err := facade.ListEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs, gc.HasLen, 2)
+	c.Assert(envs[1], gc.DeepEquals, created)
+
+	err = facade.DestroyEnvironment(names.NewEnvironTag(created.UUID))
+	c.Assert(err, gc.IsNil)
+	envs, err = facade.ListEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(envs, gc.HasLen, 1)
+	c.Assert(envs[0].UUID, gc.Not(gc.Equals), created.UUID)
+}
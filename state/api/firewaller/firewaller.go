@@ -64,6 +64,42 @@ func (st *State) Machine(tag names.MachineTag) (*Machine, error) {
 	}, nil
 }
 
+// MachineResult holds a machine returned by Machines, or the error
+// encountered getting it.
+type MachineResult struct {
+	Machine *Machine
+	Err     error
+}
+
+// Machines returns the machines with the given tags, getting their
+// life cycles from the server in a single call.
+func (st *State) Machines(tags ...names.MachineTag) ([]MachineResult, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	entityTags := make([]names.Tag, len(tags))
+	for i, tag := range tags {
+		entityTags[i] = tag
+	}
+	lives, err := common.Lives(st.caller, firewallerFacade, entityTags...)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]MachineResult, len(tags))
+	for i, life := range lives {
+		if life.Error != nil {
+			results[i].Err = life.Error
+			continue
+		}
+		results[i].Machine = &Machine{
+			tag:  tags[i],
+			life: life.Life,
+			st:   st,
+		}
+	}
+	return results, nil
+}
+
 // WatchEnvironMachines returns a StringsWatcher that notifies of
 // changes to the life cycles of the top level machines in the current
 // environment.
@@ -19,6 +19,11 @@ type Machine struct {
 	life params.Life
 }
 
+// Tag returns the machine's tag.
+func (m *Machine) Tag() names.Tag {
+	return m.tag
+}
+
 // WatchUnits starts a StringsWatcher to watch all units assigned to
 // the machine.
 func (m *Machine) WatchUnits() (watcher.StringsWatcher, error) {
@@ -47,6 +47,32 @@ func (s *machineSuite) TestMachine(c *gc.C) {
 	c.Assert(apiMachine0, gc.NotNil)
 }
 
+func (s *machineSuite) TestTag(c *gc.C) {
+	c.Assert(s.apiMachine.Tag(), gc.Equals, names.NewMachineTag(s.machines[0].Id()))
+}
+
+func (s *machineSuite) TestMachines(c *gc.C) {
+	results, err := s.firewaller.Machines(
+		s.machines[0].Tag().(names.MachineTag),
+		names.NewMachineTag("42"),
+		s.machines[1].Tag().(names.MachineTag),
+	)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 3)
+	c.Assert(results[0].Err, gc.IsNil)
+	c.Assert(results[0].Machine.Tag(), gc.Equals, s.machines[0].Tag())
+	c.Assert(results[0].Machine.Life(), gc.Equals, params.Alive)
+	c.Assert(results[1].Machine, gc.IsNil)
+	c.Assert(results[1].Err, gc.ErrorMatches, "machine 42 not found")
+	c.Assert(results[1].Err, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(results[2].Err, gc.IsNil)
+	c.Assert(results[2].Machine.Tag(), gc.Equals, s.machines[1].Tag())
+
+	results, err = s.firewaller.Machines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 0)
+}
+
 func (s *machineSuite) TestInstanceId(c *gc.C) {
 	// Add another, not provisioned machine to test
 	// CodeNotProvisioned.
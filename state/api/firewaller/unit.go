@@ -64,12 +64,12 @@ func (u *Unit) Service() (*Service, error) {
 	return service, nil
 }
 
-// OpenedPorts returns the list of opened ports for this unit.
+// OpenedPorts returns the list of opened port ranges for this unit.
 //
 // NOTE: This differs from state.Unit.OpenedPorts() by returning
 // an error as well, because it needs to make an API call.
-func (u *Unit) OpenedPorts() ([]network.Port, error) {
-	var results params.PortsResults
+func (u *Unit) OpenedPorts() ([]network.PortRange, error) {
+	var results params.PortRangesResults
 	args := params.Entities{
 		Entities: []params.Entity{{Tag: u.tag.String()}},
 	}
@@ -84,7 +84,7 @@ func (u *Unit) OpenedPorts() ([]network.Port, error) {
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return result.Ports, nil
+	return result.PortRanges, nil
 }
 
 // AssignedMachine returns the tag of this unit's assigned machine (if
@@ -100,16 +100,16 @@ func (s *unitSuite) TestAssignedMachine(c *gc.C) {
 func (s *unitSuite) TestOpenedPorts(c *gc.C) {
 	ports, err := s.apiUnit.OpenedPorts()
 	c.Assert(err, gc.IsNil)
-	c.Assert(ports, jc.DeepEquals, []network.Port{})
+	c.Assert(ports, jc.DeepEquals, []network.PortRange{})
 
 	// Open some ports and check again.
 	err = s.units[0].OpenPort("tcp", 1234)
 	c.Assert(err, gc.IsNil)
-	err = s.units[0].OpenPort("tcp", 4321)
+	err = s.units[0].OpenPorts("tcp", 4321, 4330)
 	c.Assert(err, gc.IsNil)
 	ports, err = s.apiUnit.OpenedPorts()
 	c.Assert(err, gc.IsNil)
-	c.Assert(ports, jc.DeepEquals, []network.Port{{"tcp", 1234}, {"tcp", 4321}})
+	c.Assert(ports, jc.DeepEquals, []network.PortRange{{1234, 1234, "tcp"}, {4321, 4330, "tcp"}})
 }
 
 func (s *unitSuite) TestService(c *gc.C) {
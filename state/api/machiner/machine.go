@@ -4,8 +4,11 @@
 package machiner
 
 import (
+	"fmt"
+
 	"github.com/juju/names"
 
+	"github.com/juju/juju/instance"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/common"
 	"github.com/juju/juju/state/api/params"
@@ -69,6 +72,79 @@ func (m *Machine) SetMachineAddresses(addresses []network.Address) error {
 	return result.OneError()
 }
 
+// UpgradeSeriesStatus returns the progress of any series upgrade of
+// the machine, and the series it is being upgraded to.
+func (m *Machine) UpgradeSeriesStatus() (params.UpgradeSeriesStatus, string, error) {
+	var results params.UpgradeSeriesStatusResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag.String()}},
+	}
+	err := m.st.call("UpgradeSeriesStatus", args, &results)
+	if err != nil {
+		return "", "", err
+	}
+	if len(results.Results) != 1 {
+		return "", "", fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return "", "", result.Error
+	}
+	return result.Status, result.Series, nil
+}
+
+// HardwareCharacteristics returns the hardware characteristics
+// recorded for the machine when it was provisioned.
+func (m *Machine) HardwareCharacteristics() (*instance.HardwareCharacteristics, error) {
+	var results params.HardwareCharacteristicsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag.String()}},
+	}
+	err := m.st.call("HardwareCharacteristics", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Characteristics, nil
+}
+
+// SetUpgradeSeriesStatus records the agent's progress through a
+// series upgrade of the machine.
+func (m *Machine) SetUpgradeSeriesStatus(status params.UpgradeSeriesStatus) error {
+	var result params.ErrorResults
+	args := params.SetUpgradeSeriesStatus{
+		Entities: []params.EntityUpgradeSeriesStatus{
+			{Tag: m.tag.String(), Status: status},
+		},
+	}
+	err := m.st.call("SetUpgradeSeriesStatus", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// AddMetrics records a batch of metrics collected for the machine.
+func (m *Machine) AddMetrics(metrics []params.Metric) error {
+	var result params.ErrorResults
+	args := params.AddMetrics{
+		Entities: []params.EntityMetrics{
+			{Tag: m.tag.String(), Metrics: metrics},
+		},
+	}
+	err := m.st.call("AddMetrics", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // EnsureDead sets the machine lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (m *Machine) EnsureDead() error {
@@ -5,6 +5,7 @@ package machiner_test
 
 import (
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -147,6 +148,62 @@ func (s *machinerSuite) TestSetMachineAddresses(c *gc.C) {
 	c.Assert(s.machine.MachineAddresses(), gc.DeepEquals, addresses)
 }
 
+func (s *machinerSuite) TestAddMetrics(c *gc.C) {
+	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
+	c.Assert(err, gc.IsNil)
+
+	err = machine.AddMetrics([]params.Metric{{Key: "memory.used", Value: "1024", Time: time.Now()}})
+	c.Assert(err, gc.IsNil)
+	batches, err := s.State.MetricBatches(s.machine.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Metrics()[0].Key, gc.Equals, "memory.used")
+
+	err = machine.AddMetrics(nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for "machine-1": no metrics`)
+}
+
+func (s *machinerSuite) TestUpgradeSeriesStatus(c *gc.C) {
+	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
+	c.Assert(err, gc.IsNil)
+
+	status, series, err := machine.UpgradeSeriesStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.UpgradeSeriesNotStarted)
+	c.Assert(series, gc.Equals, "")
+
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+	status, series, err = machine.UpgradeSeriesStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.UpgradeSeriesPreparing)
+	c.Assert(series, gc.Equals, "trusty")
+
+	err = machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, series = s.machine.UpgradeSeriesStatus()
+	c.Assert(status, gc.Equals, params.UpgradeSeriesPrepared)
+	c.Assert(series, gc.Equals, "trusty")
+
+	err = machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPreparing)
+	c.Assert(err, gc.ErrorMatches, `cannot set series upgrade status of machine 1 to "preparing": invalid status`)
+}
+
+func (s *machinerSuite) TestHardwareCharacteristics(c *gc.C) {
+	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
+	c.Assert(err, gc.IsNil)
+
+	hc, err := machine.HardwareCharacteristics()
+	c.Assert(err, gc.IsNil)
+	expected, err := s.machine.HardwareCharacteristics()
+	c.Assert(err, gc.IsNil)
+	c.Assert(hc, jc.DeepEquals, expected)
+}
+
 func (s *machinerSuite) TestWatch(c *gc.C) {
 	machine, err := s.machiner.Machine(names.NewMachineTag("1"))
 	c.Assert(err, gc.IsNil)
@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+)
+
+// Client provides access to the metrics sent by the agents, for use
+// by monitoring integrations.
+type Client struct {
+	st *api.State
+}
+
+// NewClient returns a new metrics client.
+func NewClient(st *api.State) *Client {
+	return &Client{st}
+}
+
+// Close closes the underlying API connection.
+func (c *Client) Close() error {
+	return c.st.Close()
+}
+
+// MetricBatches returns the batches of metrics received since the
+// given time for the machine or unit with the given tag, oldest first.
+// If the tag is empty, batches for all machines and units are returned.
+func (c *Client) MetricBatches(tag string, since time.Time) ([]params.MetricBatch, error) {
+	batches, _, err := c.MetricBatchesPage(params.MetricsQuery{Tag: tag, Since: since})
+	return batches, err
+}
+
+// MetricBatchesPage returns a page of the batches of metrics selected
+// by the given query, and the marker from which to request the next
+// page. The marker is empty if there are no more batches.
+func (c *Client) MetricBatchesPage(query params.MetricsQuery) ([]params.MetricBatch, string, error) {
+	var results params.MetricBatchesResults
+	args := params.MetricsQueries{
+		Queries: []params.MetricsQuery{query},
+	}
+	if err := c.st.Call("Metrics", "", "MetricBatches", args, &results); err != nil {
+		return nil, "", err
+	}
+	if len(results.Results) != 1 {
+		return nil, "", fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+	return result.Batches, result.Marker, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metrics_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/metrics"
+	"github.com/juju/juju/state/api/params"
+)
+
+type metricsSuite struct {
+	jujutesting.JujuConnSuite
+
+	metrics *metrics.Client
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.metrics = metrics.NewClient(s.APIState)
+	c.Assert(s.metrics, gc.NotNil)
+}
+
+func (s *metricsSuite) TestMetricBatches(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMetricBatch(m.Tag().String(), []state.Metric{{Key: "cpu", Value: "1", Time: time.Now()}})
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.metrics.MetricBatches(m.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Tag, gc.Equals, m.Tag().String())
+	c.Assert(batches[0].Metrics[0].Value, gc.Equals, "1")
+
+	batches, err = s.metrics.MetricBatches("machine-42", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+}
+
+func (s *metricsSuite) TestMetricBatchesPage(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 3; i++ {
+		_, err = s.State.AddMetricBatch(m.Tag().String(), []state.Metric{{Key: "cpu", Value: "1", Time: time.Now()}})
+		c.Assert(err, gc.IsNil)
+	}
+
+	batches, marker, err := s.metrics.MetricBatchesPage(params.MetricsQuery{Limit: 2})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 2)
+	c.Assert(marker, gc.Not(gc.Equals), "")
+
+	batches, marker, err = s.metrics.MetricBatchesPage(params.MetricsQuery{Limit: 2, Marker: marker})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(marker, gc.Equals, "")
+}
@@ -6,6 +6,8 @@ package params
 import (
 	"fmt"
 
+	"github.com/juju/errors"
+
 	"github.com/juju/juju/rpc"
 )
 
@@ -47,13 +49,18 @@ const (
 	CodeTryAgain            = "try again"
 	CodeNotImplemented      = rpc.CodeNotImplemented
 	CodeAlreadyExists       = "already exists"
+	CodeAmbiguousRelation   = "ambiguous relation"
+	CodeUpgradeInProgress   = "upgrade in progress"
+	CodeVersionDowngrade    = "version downgrade"
+	CodeAgentsNotReady      = "agents not ready"
 )
 
 // ErrCode returns the error code associated with
 // the given error, or the empty string if there
-// is none.
+// is none. Errors annotated or traced with the
+// errors package keep the code of their cause.
 func ErrCode(err error) string {
-	if err, _ := err.(rpc.ErrorCoder); err != nil {
+	if err, _ := errors.Cause(err).(rpc.ErrorCoder); err != nil {
 		return err.ErrorCode()
 	}
 	return ""
@@ -140,3 +147,19 @@ func IsCodeNotImplemented(err error) bool {
 func IsCodeAlreadyExists(err error) bool {
 	return ErrCode(err) == CodeAlreadyExists
 }
+
+func IsCodeAmbiguousRelation(err error) bool {
+	return ErrCode(err) == CodeAmbiguousRelation
+}
+
+func IsCodeUpgradeInProgress(err error) bool {
+	return ErrCode(err) == CodeUpgradeInProgress
+}
+
+func IsCodeVersionDowngrade(err error) bool {
+	return ErrCode(err) == CodeVersionDowngrade
+}
+
+func IsCodeAgentsNotReady(err error) bool {
+	return ErrCode(err) == CodeAgentsNotReady
+}
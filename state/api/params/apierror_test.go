@@ -0,0 +1,41 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package params_test
+
+import (
+	stderrors "errors"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/state/api/params"
+)
+
+type errorSuite struct{}
+
+var _ = gc.Suite(&errorSuite{})
+
+func (s *errorSuite) TestErrCode(c *gc.C) {
+	err := &params.Error{Message: "no such unit", Code: params.CodeNotFound}
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeNotFound)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(params.ErrCode(stderrors.New("no code")), gc.Equals, "")
+	c.Assert(params.ErrCode(nil), gc.Equals, "")
+}
+
+func (s *errorSuite) TestErrCodeTraced(c *gc.C) {
+	err := &params.Error{Message: "try later", Code: params.CodeTryAgain}
+	traced := errors.Annotate(errors.Trace(err), "cannot connect")
+	c.Assert(traced, gc.ErrorMatches, "cannot connect: try later")
+	c.Assert(traced, jc.Satisfies, params.IsCodeTryAgain)
+}
+
+func (s *errorSuite) TestClientError(c *gc.C) {
+	err := params.ClientError(&rpc.RequestError{Message: "nope", Code: params.CodeUnauthorized})
+	c.Assert(err, gc.DeepEquals, &params.Error{Message: "nope", Code: params.CodeUnauthorized})
+	other := stderrors.New("other")
+	c.Assert(params.ClientError(other), gc.Equals, other)
+}
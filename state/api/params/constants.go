@@ -20,13 +20,14 @@ type MachineJob string
 const (
 	JobHostUnits     MachineJob = "JobHostUnits"
 	JobManageEnviron MachineJob = "JobManageEnviron"
+	JobManageAPI     MachineJob = "JobManageAPI"
 	// Deprecated in 1.18
 	JobManageStateDeprecated MachineJob = "JobManageState"
 )
 
 // NeedsState returns true if the job requires a state connection.
 func (job MachineJob) NeedsState() bool {
-	return job == JobManageEnviron
+	return job == JobManageEnviron || job == JobManageAPI
 }
 
 // ResolvedMode describes the way state transition errors
@@ -82,3 +83,65 @@ func (status Status) Valid() bool {
 	}
 	return true
 }
+
+// WorkloadStatus describes the state of a unit's workload as reported
+// by its charm. It is distinct from the unit agent's Status, which
+// describes the progress of the agent itself.
+type WorkloadStatus string
+
+const (
+	// The charm has not reported a workload status.
+	WorkloadStatusUnknown WorkloadStatus = "unknown"
+
+	// The workload is being installed, upgraded or otherwise
+	// reconfigured and is not yet ready to provide service.
+	WorkloadStatusMaintenance WorkloadStatus = "maintenance"
+
+	// The workload is waiting for something outside the unit's
+	// control, such as a related service, that is expected to
+	// resolve itself.
+	WorkloadStatusWaiting WorkloadStatus = "waiting"
+
+	// The workload cannot continue without human intervention,
+	// such as adding a missing relation.
+	WorkloadStatusBlocked WorkloadStatus = "blocked"
+
+	// The workload is providing service.
+	WorkloadStatusActive WorkloadStatus = "active"
+)
+
+// Valid returns true if status has a known value.
+func (status WorkloadStatus) Valid() bool {
+	switch status {
+	case
+		WorkloadStatusUnknown,
+		WorkloadStatusMaintenance,
+		WorkloadStatusWaiting,
+		WorkloadStatusBlocked,
+		WorkloadStatusActive:
+	default:
+		return false
+	}
+	return true
+}
+
+// UpgradeSeriesStatus describes the progress of an in-place upgrade
+// of a machine's OS series.
+type UpgradeSeriesStatus string
+
+const (
+	// No series upgrade is in progress.
+	UpgradeSeriesNotStarted UpgradeSeriesStatus = ""
+
+	// A series upgrade has been requested, and the machine agent
+	// has yet to stop the unit agents on the machine.
+	UpgradeSeriesPreparing UpgradeSeriesStatus = "preparing"
+
+	// The unit agents on the machine have been stopped, and the
+	// operator may upgrade the machine's OS.
+	UpgradeSeriesPrepared UpgradeSeriesStatus = "prepared"
+
+	// The machine agent has found the machine running the target
+	// series, and the upgrade may be completed.
+	UpgradeSeriesUpgraded UpgradeSeriesStatus = "upgraded"
+)
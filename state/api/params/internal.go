@@ -57,17 +57,17 @@ type StringsResult struct {
 	Result []string
 }
 
-// PortsResults holds the bulk operation result of an API call
-// that returns a slice of network.Port.
-type PortsResults struct {
-	Results []PortsResult
+// PortRangesResults holds the bulk operation result of an API call
+// that returns a slice of network.PortRange.
+type PortRangesResults struct {
+	Results []PortRangesResult
 }
 
-// PortsResult holds the result of an API call that returns a slice
-// of network.Port or an error.
-type PortsResult struct {
-	Error *Error
-	Ports []network.Port
+// PortRangesResult holds the result of an API call that returns a
+// slice of network.PortRange or an error.
+type PortRangesResult struct {
+	Error      *Error
+	PortRanges []network.PortRange
 }
 
 // StringsResults holds the bulk operation result of an API call
@@ -94,6 +94,10 @@ type CharmArchiveURLResult struct {
 	Error                          *Error
 	Result                         string
 	DisableSSLHostnameVerification bool
+
+	// FromAPIServer is true if the archive is served by an API
+	// server; see ToolsResult.FromAPIServer.
+	FromAPIServer bool
 }
 
 // CharmArchiveURLResults holds the bulk operation result of an API
@@ -172,6 +176,16 @@ type ConfigSettings map[string]interface{}
 type ConfigSettingsResult struct {
 	Error    *Error
 	Settings ConfigSettings
+
+	// Types holds the charm config option type of each setting
+	// ("string", "int", "float" or "boolean"), so that clients can
+	// restore values whose type was lost in transit; for example,
+	// integers are decoded from JSON as floats.
+	Types map[string]string
+
+	// Version holds the generation of the settings. It increases
+	// each time the settings are changed.
+	Version int64
 }
 
 // ConfigSettingsResults holds multiple configuration maps or errors.
@@ -285,6 +299,21 @@ type EntitiesPorts struct {
 	Entities []EntityPort
 }
 
+// EntityPortRange holds an entity's tag, a protocol
+// and a range of ports.
+type EntityPortRange struct {
+	Tag      string
+	Protocol string
+	FromPort int
+	ToPort   int
+}
+
+// EntitiesPortRanges holds the parameters for making an OpenPorts
+// or ClosePorts call on some entities.
+type EntitiesPortRanges struct {
+	Entities []EntityPortRange
+}
+
 // EntityCharmURL holds an entity's tag and a charm URL.
 type EntityCharmURL struct {
 	Tag      string
@@ -428,6 +457,32 @@ type SetStatus struct {
 	Entities []EntityStatus
 }
 
+// EntityWorkloadStatus holds a unit tag and the workload
+// status to set for that unit.
+type EntityWorkloadStatus struct {
+	Tag    string
+	Status WorkloadStatus
+	Info   string
+}
+
+// SetWorkloadStatus holds the parameters for making
+// a SetWorkloadStatus call.
+type SetWorkloadStatus struct {
+	Entities []EntityWorkloadStatus
+}
+
+// EntityMetrics holds a machine or unit tag and a batch of metrics
+// collected for it.
+type EntityMetrics struct {
+	Tag     string
+	Metrics []Metric
+}
+
+// AddMetrics holds the parameters for making an AddMetrics call.
+type AddMetrics struct {
+	Entities []EntityMetrics
+}
+
 // StatusResult holds an entity status, extra information, or an
 // error.
 type StatusResult struct {
@@ -475,10 +530,11 @@ type AgentGetEntitiesResults struct {
 // AgentGetEntitiesResult holds the results of a
 // machineagent.API.GetEntities call for a single entity.
 type AgentGetEntitiesResult struct {
-	Life          Life
-	Jobs          []MachineJob
-	ContainerType instance.ContainerType
-	Error         *Error
+	Life                     Life
+	Jobs                     []MachineJob
+	ContainerType            instance.ContainerType
+	PasswordRotationRequired bool
+	Error                    *Error
 }
 
 // VersionResult holds the version and possibly error for a given
@@ -498,7 +554,14 @@ type VersionResults struct {
 type ToolsResult struct {
 	Tools                          *tools.Tools
 	DisableSSLHostnameVerification bool
-	Error                          *Error
+
+	// FromAPIServer is true if the tools are served by an API server,
+	// whose certificate is signed by the environment's CA rather than
+	// by one of the system roots. DisableSSLHostnameVerification is
+	// also set in that case, for agents that do not know the field.
+	FromAPIServer bool
+
+	Error *Error
 }
 
 // ToolsResults is a list of tools for various requested agents.
@@ -29,6 +29,62 @@ type Entities struct {
 	Entities []Entity
 }
 
+// RetryProvisioning holds the parameters for making a RetryProvisioning
+// call. If Constraints or Placement are set, they replace those of each
+// machine before provisioning is retried.
+type RetryProvisioning struct {
+	Entities    []Entity
+	Constraints *constraints.Value `json:",omitempty"`
+	Placement   string             `json:",omitempty"`
+}
+
+// PrepareSeriesUpgrade holds the parameters for making a
+// PrepareSeriesUpgrade call.
+type PrepareSeriesUpgrade struct {
+	Tag    string
+	Series string
+}
+
+// UpgradeSeriesStatusResult holds the status of a machine's series
+// upgrade and the series it is being upgraded to, or an error.
+type UpgradeSeriesStatusResult struct {
+	Status UpgradeSeriesStatus
+	Series string
+	Error  *Error
+}
+
+// UpgradeSeriesStatusResults holds the results of an
+// UpgradeSeriesStatus call.
+type UpgradeSeriesStatusResults struct {
+	Results []UpgradeSeriesStatusResult
+}
+
+// HardwareCharacteristicsResult holds the hardware characteristics
+// recorded for a machine when it was provisioned, or an error.
+type HardwareCharacteristicsResult struct {
+	Characteristics *instance.HardwareCharacteristics
+	Error           *Error
+}
+
+// HardwareCharacteristicsResults holds the results of a
+// HardwareCharacteristics call.
+type HardwareCharacteristicsResults struct {
+	Results []HardwareCharacteristicsResult
+}
+
+// EntityUpgradeSeriesStatus holds the series upgrade status to set
+// for the entity with the given tag.
+type EntityUpgradeSeriesStatus struct {
+	Tag    string
+	Status UpgradeSeriesStatus
+}
+
+// SetUpgradeSeriesStatus holds the parameters for making a
+// SetUpgradeSeriesStatus call.
+type SetUpgradeSeriesStatus struct {
+	Entities []EntityUpgradeSeriesStatus
+}
+
 // EntityPasswords holds the parameters for making a SetPasswords call.
 type EntityPasswords struct {
 	Changes []EntityPassword
@@ -81,12 +137,39 @@ type AddRelationResults struct {
 	Endpoints map[string]charm.Relation
 }
 
+// InferEndpointsResults holds the results of an InferEndpoints call.
+// If the endpoint names were ambiguous, Error has the code
+// CodeAmbiguousRelation and Candidates holds the keys of the
+// relations they could refer to.
+type InferEndpointsResults struct {
+	Endpoints  []Endpoint
+	Candidates []string
+	Error      *Error
+}
+
 // DestroyRelation holds the parameters for making the DestroyRelation call.
 // The endpoints specified are unordered.
 type DestroyRelation struct {
 	Endpoints []string
 }
 
+// GetRelation holds the parameters for making the GetRelation call.
+// The endpoints specified are unordered.
+type GetRelation struct {
+	Endpoints []string
+}
+
+// RelationDetails describes a relation between services, including
+// the role, interface and scope of each of its endpoints.
+type RelationDetails struct {
+	Id        int
+	Key       string
+	Life      Life
+	Interface string
+	Scope     charm.RelationScope
+	Endpoints []Endpoint
+}
+
 // AddMachineParams encapsulates the parameters used to create a new machine.
 type AddMachineParams struct {
 	// The following fields hold attributes that will be given to the
@@ -148,6 +231,16 @@ type DestroyMachines struct {
 	Force        bool
 }
 
+// MachinesWithJob holds parameters for making the MachinesWithJob call.
+type MachinesWithJob struct {
+	Job MachineJob
+}
+
+// MachinesWithJobResults holds the results of the MachinesWithJob call.
+type MachinesWithJobResults struct {
+	Machines []string
+}
+
 // ServiceDeploy holds the parameters for making the ServiceDeploy call.
 type ServiceDeploy struct {
 	ServiceName   string
@@ -178,6 +271,25 @@ type ServiceSetCharm struct {
 	Force       bool
 }
 
+// ServiceSetCharmStaged holds the parameters for making the
+// ServiceSetCharmStaged call. Only new units and the units named
+// in CanaryUnits are upgraded until the others are released.
+type ServiceSetCharmStaged struct {
+	ServiceName string
+	CharmUrl    string
+	Force       bool
+	CanaryUnits []string
+}
+
+// ServiceReleaseUnits holds the parameters for making the
+// ServiceReleaseUnits call, which advances a staged upgrade.
+// If All is true, every pinned unit is released.
+type ServiceReleaseUnits struct {
+	ServiceName string
+	Units       []string
+	All         bool
+}
+
 // ServiceExpose holds the parameters for making the ServiceExpose call.
 type ServiceExpose struct {
 	ServiceName string
@@ -230,6 +342,140 @@ type ServiceCharmRelationsResults struct {
 	CharmRelations []string
 }
 
+// ServiceRelations holds parameters for making the ServiceRelations call.
+type ServiceRelations struct {
+	ServiceName string
+}
+
+// ServiceRelationsResults holds the results of the ServiceRelations call.
+type ServiceRelationsResults struct {
+	Relations []RelationDetails
+}
+
+// ServiceDistribution holds parameters for making the
+// ServiceDistribution call.
+type ServiceDistribution struct {
+	ServiceName string
+}
+
+// ZoneUnits holds the names of the units of a service whose
+// machines are in a single availability zone.
+type ZoneUnits struct {
+	Zone  string
+	Units []string
+}
+
+// ServiceDistributionResults holds the results of the
+// ServiceDistribution call.
+type ServiceDistributionResults struct {
+	Zones []ZoneUnits
+}
+
+// OrphanedRelationSettingsResults holds the results of the
+// OrphanedRelationSettings call.
+type OrphanedRelationSettingsResults struct {
+	Keys []string
+}
+
+// CheckConsistency holds parameters for the CheckConsistency call.
+type CheckConsistency struct {
+	// Repair specifies that inconsistencies in categories that
+	// can be repaired safely should be repaired.
+	Repair bool
+}
+
+// Inconsistency describes an inconsistency found in state.
+type Inconsistency struct {
+	Kind       string
+	Id         string
+	Detail     string
+	Repairable bool
+	Repaired   bool
+	Error      *Error
+}
+
+// CheckConsistencyResults holds the results of the
+// CheckConsistency call.
+type CheckConsistencyResults struct {
+	Inconsistencies []Inconsistency
+}
+
+// ListEntities holds parameters for the ListMachines, ListUnits
+// and ListServices calls.
+type ListEntities struct {
+	// Marker holds the Marker returned with the previous page.
+	// If it is empty, the listing starts at the beginning.
+	Marker string
+
+	// Limit holds the maximum number of entities to return.
+	// If it is zero, all remaining entities are returned.
+	Limit int
+
+	// Descending reverses the order of the listing, which is
+	// otherwise in ascending order of name.
+	Descending bool
+
+	// Fields holds the fields to return, besides the name.
+	// If it is empty, all fields are returned.
+	Fields []string
+}
+
+// MachineSummary holds the fields of a machine returned by ListMachines.
+// Fields that were not requested are omitted.
+type MachineSummary struct {
+	Id     string
+	Series string       `json:",omitempty"`
+	Life   Life         `json:",omitempty"`
+	Jobs   []MachineJob `json:",omitempty"`
+}
+
+// ListMachinesResults holds the results of the ListMachines call.
+type ListMachinesResults struct {
+	Machines []MachineSummary
+
+	// Marker is passed to request the next page. It is empty
+	// if there are no more machines.
+	Marker string
+}
+
+// UnitSummary holds the fields of a unit returned by ListUnits.
+// Fields that were not requested are omitted. Machine is empty for
+// subordinate units, which are not assigned to machines directly.
+type UnitSummary struct {
+	Name    string
+	Service string `json:",omitempty"`
+	Series  string `json:",omitempty"`
+	Machine string `json:",omitempty"`
+	Life    Life   `json:",omitempty"`
+}
+
+// ListUnitsResults holds the results of the ListUnits call.
+type ListUnitsResults struct {
+	Units []UnitSummary
+
+	// Marker is passed to request the next page. It is empty
+	// if there are no more units.
+	Marker string
+}
+
+// ServiceSummary holds the fields of a service returned by ListServices.
+// Fields that were not requested are omitted.
+type ServiceSummary struct {
+	Name     string
+	CharmURL string `json:",omitempty"`
+	Life     Life   `json:",omitempty"`
+	Exposed  bool   `json:",omitempty"`
+}
+
+// ListServicesResults holds the results of the ListServices call.
+type ListServicesResults struct {
+	Services []ServiceSummary
+
+	// Marker is passed to request the next page. It is empty
+	// if there are no more services.
+	Marker string
+}
+
 // ServiceUnexpose holds parameters for the ServiceUnexpose call.
 type ServiceUnexpose struct {
 	ServiceName string
@@ -261,6 +507,86 @@ type Resolved struct {
 	Retry    bool
 }
 
+// MoveUnit holds parameters for the MoveUnit call.
+type MoveUnit struct {
+	UnitName  string
+	MachineId string
+}
+
+// Metric holds a single measurement taken by an agent.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+// MetricBatch holds a batch of metrics recorded for a machine or unit.
+type MetricBatch struct {
+	Tag     string
+	Created time.Time
+	Metrics []Metric
+}
+
+// MetricsQuery selects the metric batches received since the given
+// time for the entity with the given tag, or for all entities if the
+// tag is empty.
+type MetricsQuery struct {
+	Tag   string
+	Since time.Time
+
+	// Marker holds the Marker returned with the previous page.
+	// If it is empty, the batches are returned from the beginning.
+	Marker string
+
+	// Limit holds the maximum number of batches to return.
+	// If it is zero, all remaining batches are returned.
+	Limit int
+}
+
+// MetricsQueries holds the parameters for the MetricBatches call.
+type MetricsQueries struct {
+	Queries []MetricsQuery
+}
+
+// MetricBatchesResult holds the metric batches selected by a query,
+// or an error.
+type MetricBatchesResult struct {
+	Batches []MetricBatch
+
+	// Marker holds the marker from which to request the next
+	// page of batches. It is empty if there are no more.
+	Marker string
+
+	Error *Error
+}
+
+// MetricBatchesResults holds the results of the MetricBatches call.
+type MetricBatchesResults struct {
+	Results []MetricBatchesResult
+}
+
+// ScheduledTask holds the definition of a task run periodically by the
+// state servers, and the outcome of its most recent run.
+type ScheduledTask struct {
+	Name      string
+	Interval  time.Duration
+	Enabled   bool
+	LastRun   *time.Time `json:",omitempty"`
+	LastError string     `json:",omitempty"`
+}
+
+// ScheduledTasksResults holds the results of the ScheduledTasks call.
+type ScheduledTasksResults struct {
+	Tasks []ScheduledTask
+}
+
+// SetScheduledTaskEnabled holds parameters for the
+// SetScheduledTaskEnabled call.
+type SetScheduledTaskEnabled struct {
+	Name    string
+	Enabled bool
+}
+
 // ResolvedResults holds results of the Resolved call.
 type ResolvedResults struct {
 	Service  string
@@ -330,6 +656,14 @@ type SetConstraints struct {
 	Constraints constraints.Value
 }
 
+// SetConstraintsResults holds the results of a SetServiceConstraints
+// or SetEnvironmentConstraints call.
+type SetConstraintsResults struct {
+	// Unsupported holds the names of the constraints that were set
+	// but are not supported by the environment's provider.
+	Unsupported []string
+}
+
 // CharmInfo stores parameters for a CharmInfo call.
 type CharmInfo struct {
 	CharmURL string
@@ -503,9 +837,12 @@ type IsMasterResult struct {
 type MachineInfo struct {
 	Id                       string `bson:"_id"`
 	InstanceId               string
+	InstanceStatus           string
 	Status                   Status
 	StatusInfo               string
 	StatusData               StatusData
+	AgentAlive               bool
+	AgentLastSeen            time.Time
 	Life                     Life
 	Series                   string
 	SupportedContainers      []instance.ContainerType
@@ -552,6 +889,17 @@ type UnitInfo struct {
 	Status         Status
 	StatusInfo     string
 	StatusData     StatusData
+
+	// AgentAlive reports whether the unit's agent is alive. When
+	// it is not, AgentLastSeen holds when it was last known to be
+	// alive, if that is known. The same holds for a MachineInfo.
+	AgentAlive    bool
+	AgentLastSeen time.Time
+
+	// WorkloadStatus is empty if the unit's charm
+	// has not reported a workload status.
+	WorkloadStatus     WorkloadStatus
+	WorkloadStatusInfo string
 }
 
 func (i *UnitInfo) EntityId() EntityId {
@@ -641,6 +989,35 @@ type EnvironmentGetResults struct {
 	Config map[string]interface{}
 }
 
+// ControllerSettingsResult contains the result of the
+// ControllerSettings API call.
+type ControllerSettingsResult struct {
+	Settings map[string]interface{}
+}
+
+// EnvironmentCreateArgs contains the arguments for the
+// CreateEnvironment API call. Attributes not given in Config are taken
+// from the configuration of the state server environment.
+type EnvironmentCreateArgs struct {
+	Config map[string]interface{}
+}
+
+// HostedEnvironment describes an environment known to the state
+// servers.
+type HostedEnvironment struct {
+	Name       string
+	UUID       string
+	ServerUUID string
+	Life       Life
+	Phase      string
+}
+
+// HostedEnvironmentList contains the result of the ListEnvironments
+// API call.
+type HostedEnvironmentList struct {
+	Environments []HostedEnvironment
+}
+
 // EnvironmentSet contains the arguments for EnvironmentSet client API
 // call.
 type EnvironmentSet struct {
@@ -666,9 +1043,38 @@ type DeployerConnectionValues struct {
 	APIAddresses   []string
 }
 
-// StatusParams holds parameters for the Status call.
+// StatusParams holds parameters for the Status call. Only the parts of
+// the environment matching all the given filters are returned.
 type StatusParams struct {
+	// Patterns holds unit or service name patterns to match.
 	Patterns []string
+	// Services holds the names of services to match.
+	Services []string
+	// Machines holds the ids of machines to match; units on matching
+	// machines and their containers are returned.
+	Machines []string
+}
+
+// StatusChangesParams holds parameters for the StatusChanges call.
+type StatusChangesParams struct {
+	// Token holds the token returned by the previous call, if any.
+	Token string
+}
+
+// StatusChangesResult holds the result of the StatusChanges call.
+type StatusChangesResult struct {
+	// Deltas holds the changes made since the token was returned,
+	// oldest first.
+	Deltas []Delta
+
+	// Token holds an opaque token representing the current state,
+	// to be passed to the next call.
+	Token string
+
+	// Reset is true if Deltas holds every entity rather than just
+	// the changes, in which case anything previously known about the
+	// environment should be discarded.
+	Reset bool
 }
 
 // SetRsyslogCertParams holds parameters for the SetRsyslogCert call.
@@ -55,6 +55,110 @@ func (st *State) Machine(tag names.MachineTag) (*Machine, error) {
 	}, nil
 }
 
+// MachineResult holds a machine returned by Machines, or the error
+// encountered getting it.
+type MachineResult struct {
+	Machine *Machine
+	Err     error
+}
+
+// Machines returns the machines with the given tags, getting their
+// life cycles from the server in a single call.
+func (st *State) Machines(tags ...names.MachineTag) ([]MachineResult, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	entityTags := make([]names.Tag, len(tags))
+	for i, tag := range tags {
+		entityTags[i] = tag
+	}
+	lives, err := common.Lives(st.caller, provisionerFacade, entityTags...)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]MachineResult, len(tags))
+	for i, life := range lives {
+		if life.Error != nil {
+			results[i].Err = life.Error
+			continue
+		}
+		results[i].Machine = &Machine{
+			tag:  tags[i],
+			life: life.Life,
+			st:   st,
+		}
+	}
+	return results, nil
+}
+
+// machineEntities returns the entities for the given machines.
+func machineEntities(machines []*Machine) params.Entities {
+	args := params.Entities{
+		Entities: make([]params.Entity, len(machines)),
+	}
+	for i, m := range machines {
+		args.Entities[i].Tag = m.tag.String()
+	}
+	return args
+}
+
+// InstanceIds returns the provider specific instance ids of the given
+// machines in a single call. Each result holds an error satisfying
+// params.IsCodeNotProvisioned if its machine is not yet provisioned.
+func (st *State) InstanceIds(machines ...*Machine) ([]params.StringResult, error) {
+	if len(machines) == 0 {
+		return nil, nil
+	}
+	var results params.StringResults
+	err := st.call("InstanceId", machineEntities(machines), &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != len(machines) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(machines), len(results.Results))
+	}
+	return results.Results, nil
+}
+
+// Statuses returns the statuses of the given machines in a single call.
+func (st *State) Statuses(machines ...*Machine) ([]params.StatusResult, error) {
+	if len(machines) == 0 {
+		return nil, nil
+	}
+	var results params.StatusResults
+	err := st.call("Status", machineEntities(machines), &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != len(machines) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(machines), len(results.Results))
+	}
+	return results.Results, nil
+}
+
+// SetStatuses sets the status of each of the given machines in a single
+// call, and returns the outcome for each.
+func (st *State) SetStatuses(status params.Status, info string, machines ...*Machine) ([]params.ErrorResult, error) {
+	if len(machines) == 0 {
+		return nil, nil
+	}
+	args := params.SetStatus{
+		Entities: make([]params.EntityStatus, len(machines)),
+	}
+	for i, m := range machines {
+		args.Entities[i] = params.EntityStatus{Tag: m.tag.String(), Status: status, Info: info}
+	}
+	var results params.ErrorResults
+	err := st.call("SetStatus", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != len(machines) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(machines), len(results.Results))
+	}
+	return results.Results, nil
+}
+
 // WatchEnvironMachines returns a StringsWatcher that notifies of
 // changes to the lifecycles of the machines (but not containers) in
 // the current environment.
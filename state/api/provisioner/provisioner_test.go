@@ -84,6 +84,58 @@ func (s *provisionerSuite) TestMachineTagAndId(c *gc.C) {
 	c.Assert(apiMachine.Id(), gc.Equals, s.machine.Id())
 }
 
+func (s *provisionerSuite) TestMachines(c *gc.C) {
+	results, err := s.provisioner.Machines(
+		s.machine.Tag().(names.MachineTag),
+		names.NewMachineTag("42"),
+	)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[0].Err, gc.IsNil)
+	c.Assert(results[0].Machine.Id(), gc.Equals, s.machine.Id())
+	c.Assert(results[0].Machine.Life(), gc.Equals, params.Alive)
+	c.Assert(results[1].Machine, gc.IsNil)
+	c.Assert(results[1].Err, gc.ErrorMatches, "machine 42 not found")
+	c.Assert(results[1].Err, jc.Satisfies, params.IsCodeNotFound)
+
+	results, err = s.provisioner.Machines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 0)
+}
+
+func (s *provisionerSuite) TestBulkInstanceIdsAndStatuses(c *gc.C) {
+	notProvisioned, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	apiMachine, err := s.provisioner.Machine(s.machine.Tag().(names.MachineTag))
+	c.Assert(err, gc.IsNil)
+	apiNotProvisioned, err := s.provisioner.Machine(notProvisioned.Tag().(names.MachineTag))
+	c.Assert(err, gc.IsNil)
+
+	instanceIds, err := s.provisioner.InstanceIds(apiMachine, apiNotProvisioned)
+	c.Assert(err, gc.IsNil)
+	c.Assert(instanceIds, gc.HasLen, 2)
+	c.Assert(instanceIds[0], gc.DeepEquals, params.StringResult{Result: "i-manager"})
+	c.Assert(instanceIds[1].Error, gc.ErrorMatches, "machine 1 is not provisioned")
+	c.Assert(instanceIds[1].Error, jc.Satisfies, params.IsCodeNotProvisioned)
+
+	results, err := s.provisioner.SetStatuses(params.StatusError, "blah", apiMachine, apiNotProvisioned)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, []params.ErrorResult{{}, {}})
+
+	statuses, err := s.provisioner.Statuses(apiMachine, apiNotProvisioned)
+	c.Assert(err, gc.IsNil)
+	c.Assert(statuses, gc.HasLen, 2)
+	for _, status := range statuses {
+		c.Assert(status.Error, gc.IsNil)
+		c.Assert(status.Status, gc.Equals, params.StatusError)
+		c.Assert(status.Info, gc.Equals, "blah")
+	}
+
+	instanceIds, err = s.provisioner.InstanceIds()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instanceIds, gc.HasLen, 0)
+}
+
 func (s *provisionerSuite) TestGetSetStatus(c *gc.C) {
 	apiMachine, err := s.provisioner.Machine(s.machine.Tag().(names.MachineTag))
 	c.Assert(err, gc.IsNil)
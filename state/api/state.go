@@ -13,8 +13,10 @@ import (
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/agent"
 	"github.com/juju/juju/state/api/charmrevisionupdater"
+	"github.com/juju/juju/state/api/controller"
 	"github.com/juju/juju/state/api/deployer"
 	"github.com/juju/juju/state/api/environment"
+	"github.com/juju/juju/state/api/environmentmanager"
 	"github.com/juju/juju/state/api/firewaller"
 	"github.com/juju/juju/state/api/keyupdater"
 	apilogger "github.com/juju/juju/state/api/logger"
@@ -157,6 +159,11 @@ func (st *State) Upgrader() *upgrader.State {
 	return upgrader.NewState(st)
 }
 
+// Controller returns access to the Controller API
+func (st *State) Controller() *controller.Facade {
+	return controller.NewFacade(st)
+}
+
 // Deployer returns access to the Deployer API
 func (st *State) Deployer() *deployer.State {
 	return deployer.NewState(st)
@@ -167,6 +174,11 @@ func (st *State) Environment() *environment.Facade {
 	return environment.NewFacade(st)
 }
 
+// EnvironmentManager returns access to the EnvironmentManager API
+func (st *State) EnvironmentManager() *environmentmanager.Facade {
+	return environmentmanager.NewFacade(st)
+}
+
 // Logger returns access to the Logger API
 func (st *State) Logger() *apilogger.State {
 	return apilogger.NewState(st)
@@ -10,6 +10,7 @@ import (
 	"github.com/juju/charm"
 	"github.com/juju/utils"
 
+	"github.com/juju/juju/downloader"
 	"github.com/juju/juju/state/api/params"
 )
 
@@ -51,8 +52,8 @@ func (c *Charm) getArchiveInfo(apiCall string) (string, error) {
 	return result.Result, nil
 }
 
-// ArchiveURL returns the url to the charm archive (bundle) in the
-// provider storage, and DisableSSLHostnameVerification flag.
+// ArchiveURL returns the url to the charm archive (bundle), along
+// with how to verify the server it is downloaded from.
 //
 // NOTE: This differs from state.Charm.BundleURL() by returning an
 // error as well, because it needs to make an API call. It's also
@@ -61,31 +62,34 @@ func (c *Charm) getArchiveInfo(apiCall string) (string, error) {
 // TODO(dimitern): 2013-09-06 bug 1221834
 // Cache the result after getting it once for the same charm URL,
 // because it's immutable.
-func (c *Charm) ArchiveURL() (*url.URL, utils.SSLHostnameVerification, error) {
+func (c *Charm) ArchiveURL() (*url.URL, downloader.Verification, error) {
 	var results params.CharmArchiveURLResults
 	args := params.CharmURLs{
 		URLs: []params.CharmURL{{URL: c.url}},
 	}
 	err := c.st.call("CharmArchiveURL", args, &results)
 	if err != nil {
-		return nil, false, err
+		return nil, downloader.Verification{}, err
 	}
 	if len(results.Results) != 1 {
-		return nil, false, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+		return nil, downloader.Verification{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
 	}
 	result := results.Results[0]
 	if result.Error != nil {
-		return nil, false, result.Error
+		return nil, downloader.Verification{}, result.Error
 	}
 	archiveURL, err := url.Parse(result.Result)
 	if err != nil {
-		return nil, false, err
+		return nil, downloader.Verification{}, err
+	}
+	v := downloader.Verification{
+		HostnameVerification: utils.VerifySSLHostnames,
+		FromAPIServer:        result.FromAPIServer,
 	}
-	hostnameVerification := utils.VerifySSLHostnames
 	if result.DisableSSLHostnameVerification {
-		hostnameVerification = utils.NoVerifySSLHostnames
+		v.HostnameVerification = utils.NoVerifySSLHostnames
 	}
-	return archiveURL, hostnameVerification, nil
+	return archiveURL, v, nil
 }
 
 // ArchiveSha256 returns the SHA256 digest of the charm archive
@@ -7,6 +7,7 @@ import (
 	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/downloader"
 	envtesting "github.com/juju/juju/environs/testing"
 	"github.com/juju/juju/state/api/uniter"
 )
@@ -46,17 +47,17 @@ func (s *charmSuite) TestURL(c *gc.C) {
 }
 
 func (s *charmSuite) TestArchiveURL(c *gc.C) {
-	archiveURL, hostnameVerification, err := s.apiCharm.ArchiveURL()
+	archiveURL, verification, err := s.apiCharm.ArchiveURL()
 	c.Assert(err, gc.IsNil)
 	c.Assert(archiveURL, gc.DeepEquals, s.wordpressCharm.BundleURL())
-	c.Assert(hostnameVerification, gc.Equals, utils.VerifySSLHostnames)
+	c.Assert(verification, gc.Equals, downloader.Verification{HostnameVerification: utils.VerifySSLHostnames})
 
 	envtesting.SetSSLHostnameVerification(c, s.State, false)
 
-	archiveURL, hostnameVerification, err = s.apiCharm.ArchiveURL()
+	archiveURL, verification, err = s.apiCharm.ArchiveURL()
 	c.Assert(err, gc.IsNil)
 	c.Assert(archiveURL, gc.DeepEquals, s.wordpressCharm.BundleURL())
-	c.Assert(hostnameVerification, gc.Equals, utils.NoVerifySSLHostnames)
+	c.Assert(verification, gc.Equals, downloader.Verification{HostnameVerification: utils.NoVerifySSLHostnames})
 }
 
 func (s *charmSuite) TestArchiveSha256(c *gc.C) {
@@ -135,27 +135,44 @@ func (ru *RelationUnit) Settings() (*Settings, error) {
 // guaranteed to persist for the lifetime of the relation, regardless
 // of the lifetime of the unit.
 func (ru *RelationUnit) ReadSettings(uname string) (params.RelationSettings, error) {
-	tag := names.NewUnitTag(uname)
+	settings, err := ru.ReadSettingsOfUnits([]string{uname})
+	if err != nil {
+		return nil, err
+	}
+	return settings[uname], nil
+}
+
+// ReadSettingsOfUnits returns the settings of each of the named units
+// within this relation, keyed by unit name, using a single API call.
+// It fails if the settings of any of the units cannot be read, for the
+// same reasons as ReadSettings.
+func (ru *RelationUnit) ReadSettingsOfUnits(unames []string) (map[string]params.RelationSettings, error) {
 	var results params.RelationSettingsResults
 	args := params.RelationUnitPairs{
-		RelationUnitPairs: []params.RelationUnitPair{{
+		RelationUnitPairs: make([]params.RelationUnitPair, len(unames)),
+	}
+	for i, uname := range unames {
+		args.RelationUnitPairs[i] = params.RelationUnitPair{
 			Relation:   ru.relation.tag.String(),
 			LocalUnit:  ru.unit.tag.String(),
-			RemoteUnit: tag.String(),
-		}},
+			RemoteUnit: names.NewUnitTag(uname).String(),
+		}
 	}
 	err := ru.st.call("ReadRemoteSettings", args, &results)
 	if err != nil {
 		return nil, err
 	}
-	if len(results.Results) != 1 {
-		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	if len(results.Results) != len(unames) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(unames), len(results.Results))
 	}
-	result := results.Results[0]
-	if result.Error != nil {
-		return nil, result.Error
+	settings := make(map[string]params.RelationSettings)
+	for i, result := range results.Results {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		settings[unames[i]] = result.Settings
 	}
-	return result.Settings, nil
+	return settings, nil
 }
 
 // Watch returns a watcher that notifies of changes to counterpart
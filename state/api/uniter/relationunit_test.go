@@ -239,6 +239,24 @@ func (s *relationUnitSuite) TestReadSettings(c *gc.C) {
 	})
 }
 
+func (s *relationUnitSuite) TestReadSettingsOfUnits(c *gc.C) {
+	myRelUnit, err := s.stateRelation.Unit(s.mysqlUnit)
+	c.Assert(err, gc.IsNil)
+	err = myRelUnit.EnterScope(map[string]interface{}{"some": "settings"})
+	c.Assert(err, gc.IsNil)
+
+	_, apiRelUnit := s.getRelationUnits(c)
+	gotSettings, err := apiRelUnit.ReadSettingsOfUnits([]string{"mysql/0"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(gotSettings, gc.DeepEquals, map[string]params.RelationSettings{
+		"mysql/0": {"some": "settings"},
+	})
+
+	gotSettings, err = apiRelUnit.ReadSettingsOfUnits(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(gotSettings, gc.HasLen, 0)
+}
+
 func (s *relationUnitSuite) TestWatchRelationUnits(c *gc.C) {
 	// Enter scope with mysqlUnit.
 	myRelUnit, err := s.stateRelation.Unit(s.mysqlUnit)
@@ -261,6 +279,14 @@ func (s *relationUnitSuite) TestWatchRelationUnits(c *gc.C) {
 	// Initial event.
 	wc.AssertChange([]string{"mysql/0"}, nil)
 
+	// Change mysqlUnit's settings, check it's detected.
+	settings, err := myRelUnit.Settings()
+	c.Assert(err, gc.IsNil)
+	settings.Set("some", "thing")
+	_, err = settings.Write()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange([]string{"mysql/0"}, nil)
+
 	// Leave scope with mysqlUnit, check it's detected.
 	err = myRelUnit.LeaveScope()
 	c.Assert(err, gc.IsNil)
@@ -67,6 +67,37 @@ func (u *Unit) SetStatus(status params.Status, info string, data params.StatusDa
 	return result.OneError()
 }
 
+// SetWorkloadStatus sets the status of the unit's workload,
+// as reported by its charm.
+func (u *Unit) SetWorkloadStatus(status params.WorkloadStatus, info string) error {
+	var result params.ErrorResults
+	args := params.SetWorkloadStatus{
+		Entities: []params.EntityWorkloadStatus{
+			{Tag: u.tag.String(), Status: status, Info: info},
+		},
+	}
+	err := u.st.call("SetWorkloadStatus", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// AddMetrics records a batch of metrics reported by the unit's charm.
+func (u *Unit) AddMetrics(metrics []params.Metric) error {
+	var result params.ErrorResults
+	args := params.AddMetrics{
+		Entities: []params.EntityMetrics{
+			{Tag: u.tag.String(), Metrics: metrics},
+		},
+	}
+	err := u.st.call("AddMetrics", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // EnsureDead sets the unit lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (u *Unit) EnsureDead() error {
@@ -105,24 +136,59 @@ func (u *Unit) Service() (*Service, error) {
 // ConfigSettings returns the complete set of service charm config settings
 // available to the unit. Unset values will be replaced with the default
 // value for the associated option, and may thus be nil when no default is
-// specified.
+// specified. Values are typed according to the charm's config schema.
 func (u *Unit) ConfigSettings() (charm.Settings, error) {
+	result, err := u.configSettings()
+	if err != nil {
+		return nil, err
+	}
+	return typedSettings(result.Settings, result.Types), nil
+}
+
+// ConfigSettingsVersion returns the generation of the service charm
+// config settings available to the unit. It increases each time the
+// settings are changed, and is zero if the API server does not
+// report it.
+func (u *Unit) ConfigSettingsVersion() (int64, error) {
+	result, err := u.configSettings()
+	if err != nil {
+		return 0, err
+	}
+	return result.Version, nil
+}
+
+func (u *Unit) configSettings() (params.ConfigSettingsResult, error) {
 	var results params.ConfigSettingsResults
 	args := params.Entities{
 		Entities: []params.Entity{{Tag: u.tag.String()}},
 	}
 	err := u.st.call("ConfigSettings", args, &results)
 	if err != nil {
-		return nil, err
+		return params.ConfigSettingsResult{}, err
 	}
 	if len(results.Results) != 1 {
-		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+		return params.ConfigSettingsResult{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
 	}
 	result := results.Results[0]
 	if result.Error != nil {
-		return nil, result.Error
+		return params.ConfigSettingsResult{}, result.Error
+	}
+	return result, nil
+}
+
+// typedSettings returns the given settings with each value converted
+// to the Go type used by the charm package for its option type. Numbers
+// are always decoded from the API as float64, so integer options would
+// otherwise be reported as floats.
+func typedSettings(settings params.ConfigSettings, types map[string]string) charm.Settings {
+	result := make(charm.Settings)
+	for name, value := range settings {
+		if f, ok := value.(float64); ok && types[name] == "int" {
+			value = int64(f)
+		}
+		result[name] = value
 	}
-	return charm.Settings(result.Settings), nil
+	return result
 }
 
 // ServiceName returns the service name.
@@ -325,6 +391,35 @@ func (u *Unit) ClosePort(protocol string, number int) error {
 	return result.OneError()
 }
 
+// OpenPorts sets the policy of the ports in the given range to be
+// opened. ICMP is opened with both port numbers set to -1.
+func (u *Unit) OpenPorts(protocol string, fromPort, toPort int) error {
+	return u.changePorts("OpenPorts", protocol, fromPort, toPort)
+}
+
+// ClosePorts sets the policy of the ports in the given range to be
+// closed. The range must match one previously opened by the unit.
+func (u *Unit) ClosePorts(protocol string, fromPort, toPort int) error {
+	return u.changePorts("ClosePorts", protocol, fromPort, toPort)
+}
+
+func (u *Unit) changePorts(method, protocol string, fromPort, toPort int) error {
+	var result params.ErrorResults
+	args := params.EntitiesPortRanges{
+		Entities: []params.EntityPortRange{{
+			Tag:      u.tag.String(),
+			Protocol: protocol,
+			FromPort: fromPort,
+			ToPort:   toPort,
+		}},
+	}
+	err := u.st.call(method, args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 var ErrNoCharmURLSet = errors.New("unit has no charm url set")
 
 // CharmURL returns the charm URL this unit is currently using.
@@ -6,6 +6,7 @@ package uniter_test
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
@@ -67,6 +68,31 @@ func (s *unitSuite) TestSetStatus(c *gc.C) {
 	c.Assert(data, gc.HasLen, 0)
 }
 
+func (s *unitSuite) TestSetWorkloadStatus(c *gc.C) {
+	status, info := s.wordpressUnit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusUnknown)
+	c.Assert(info, gc.Equals, "")
+
+	err := s.apiUnit.SetWorkloadStatus(params.WorkloadStatusMaintenance, "installing")
+	c.Assert(err, gc.IsNil)
+
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, info = s.wordpressUnit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusMaintenance)
+	c.Assert(info, gc.Equals, "installing")
+}
+
+func (s *unitSuite) TestAddMetrics(c *gc.C) {
+	err := s.apiUnit.AddMetrics([]params.Metric{{Key: "requests", Value: "42", Time: time.Now()}})
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.State.MetricBatches(s.wordpressUnit.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Metrics()[0].Value, gc.Equals, "42")
+}
+
 func (s *unitSuite) TestEnsureDead(c *gc.C) {
 	c.Assert(s.wordpressUnit.Life(), gc.Equals, state.Alive)
 
@@ -239,9 +265,9 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	ports = s.wordpressUnit.OpenedPorts()
 	// OpenedPorts returns a sorted slice.
-	c.Assert(ports, gc.DeepEquals, []network.Port{
-		{Protocol: "tcp", Number: 1234},
-		{Protocol: "tcp", Number: 4321},
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{
+		{FromPort: 1234, ToPort: 1234, Protocol: "tcp"},
+		{FromPort: 4321, ToPort: 4321, Protocol: "tcp"},
 	})
 
 	err = s.apiUnit.ClosePort("tcp", 4321)
@@ -251,8 +277,8 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	ports = s.wordpressUnit.OpenedPorts()
 	// OpenedPorts returns a sorted slice.
-	c.Assert(ports, gc.DeepEquals, []network.Port{
-		{Protocol: "tcp", Number: 1234},
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{
+		{FromPort: 1234, ToPort: 1234, Protocol: "tcp"},
 	})
 
 	err = s.apiUnit.ClosePort("tcp", 1234)
@@ -264,6 +290,32 @@ func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	c.Assert(ports, gc.HasLen, 0)
 }
 
+func (s *unitSuite) TestOpenClosePorts(c *gc.C) {
+	err := s.apiUnit.OpenPorts("tcp", 8000, 8010)
+	c.Assert(err, gc.IsNil)
+	err = s.apiUnit.OpenPorts("udp", 53, 53)
+	c.Assert(err, gc.IsNil)
+
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.OpenedPorts(), gc.DeepEquals, []network.PortRange{
+		{FromPort: 8000, ToPort: 8010, Protocol: "tcp"},
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+	})
+
+	err = s.apiUnit.OpenPorts("tcp", 8010, 8020)
+	c.Assert(err, gc.ErrorMatches, ".* due to conflict")
+
+	err = s.apiUnit.ClosePorts("tcp", 8000, 8010)
+	c.Assert(err, gc.IsNil)
+
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.OpenedPorts(), gc.DeepEquals, []network.PortRange{
+		{FromPort: 53, ToPort: 53, Protocol: "udp"},
+	})
+}
+
 func (s *unitSuite) TestGetSetCharmURL(c *gc.C) {
 	// No charm URL set yet.
 	curl, ok := s.wordpressUnit.CharmURL()
@@ -312,6 +364,24 @@ func (s *unitSuite) TestConfigSettings(c *gc.C) {
 	})
 }
 
+func (s *unitSuite) TestConfigSettingsVersion(c *gc.C) {
+	_, err := s.apiUnit.ConfigSettingsVersion()
+	c.Assert(err, gc.ErrorMatches, "unit charm not set")
+
+	err = s.apiUnit.SetCharmURL(s.wordpressCharm.URL())
+	c.Assert(err, gc.IsNil)
+	version, err := s.apiUnit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
+
+	err = s.wordpressService.UpdateConfigSettings(charm.Settings{
+		"blog-title": "superhero paparazzi",
+	})
+	c.Assert(err, gc.IsNil)
+	changed, err := s.apiUnit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
+	c.Assert(changed > version, gc.Equals, true)
+}
+
 func (s *unitSuite) TestWatchConfigSettings(c *gc.C) {
 	// Make sure WatchConfigSettings returns an error when
 	// no charm URL is set, as its state counterpart does.
@@ -500,3 +570,38 @@ func (s *unitSuite) TestWatchAddressesErrors(c *gc.C) {
 	_, err = s.apiUnit.WatchAddresses()
 	c.Assert(err, jc.Satisfies, params.IsCodeNotAssigned)
 }
+
+func (s *unitSuite) TestConfigSettingsTyped(c *gc.C) {
+	restore := testing.PatchValue(uniter.Call, func(st *uniter.State, method string, args, results interface{}) error {
+		if results, ok := results.(*params.ConfigSettingsResults); ok {
+			results.Results = []params.ConfigSettingsResult{{
+				Settings: params.ConfigSettings{
+					"title":       "My Title",
+					"skill-level": 10000000.0,
+					"ratio":       0.5,
+					"outlook":     true,
+					"username":    nil,
+				},
+				Types: map[string]string{
+					"title":       "string",
+					"skill-level": "int",
+					"ratio":       "float",
+					"outlook":     "boolean",
+					"username":    "string",
+				},
+			}}
+		}
+		return nil
+	})
+	defer restore()
+
+	settings, err := s.apiUnit.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{
+		"title":       "My Title",
+		"skill-level": int64(10000000),
+		"ratio":       0.5,
+		"outlook":     true,
+		"username":    nil,
+	})
+}
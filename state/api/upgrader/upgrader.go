@@ -8,6 +8,7 @@ import (
 
 	"github.com/juju/utils"
 
+	"github.com/juju/juju/downloader"
 	"github.com/juju/juju/state/api/base"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/watcher"
@@ -75,8 +76,8 @@ func (st *State) DesiredVersion(tag string) (version.Number, error) {
 }
 
 // Tools returns the agent tools that should run on the given entity,
-// along with a flag whether to disable SSL hostname verification.
-func (st *State) Tools(tag string) (*tools.Tools, utils.SSLHostnameVerification, error) {
+// along with how to verify the server they are downloaded from.
+func (st *State) Tools(tag string) (*tools.Tools, downloader.Verification, error) {
 	var results params.ToolsResults
 	args := params.Entities{
 		Entities: []params.Entity{{Tag: tag}},
@@ -84,21 +85,17 @@ func (st *State) Tools(tag string) (*tools.Tools, utils.SSLHostnameVerification,
 	err := st.call("Tools", args, &results)
 	if err != nil {
 		// TODO: Not directly tested
-		return nil, false, err
+		return nil, downloader.Verification{}, err
 	}
 	if len(results.Results) != 1 {
 		// TODO: Not directly tested
-		return nil, false, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+		return nil, downloader.Verification{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
 	}
 	result := results.Results[0]
 	if err := result.Error; err != nil {
-		return nil, false, err
+		return nil, downloader.Verification{}, err
 	}
-	hostnameVerification := utils.VerifySSLHostnames
-	if result.DisableSSLHostnameVerification {
-		hostnameVerification = utils.NoVerifySSLHostnames
-	}
-	return result.Tools, hostnameVerification, nil
+	return result.Tools, toolsVerification(result), nil
 }
 
 func (st *State) WatchAPIVersion(agentTag string) (watcher.NotifyWatcher, error) {
@@ -123,3 +120,78 @@ func (st *State) WatchAPIVersion(agentTag string) (watcher.NotifyWatcher, error)
 	w := watcher.NewNotifyWatcher(st.caller, result)
 	return w, nil
 }
+
+// WatchStagedUpgrade returns a watcher that notifies when an upgrade
+// is staged, replaced or activated.
+func (st *State) WatchStagedUpgrade(agentTag string) (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: agentTag}},
+	}
+	err := st.call("WatchStagedUpgrade", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewNotifyWatcher(st.caller, result)
+	return w, nil
+}
+
+// StagedTools returns the tools of the staged upgrade's version that
+// should be downloaded for the given entity, along with how to verify
+// the server they are downloaded from. It returns an error satisfying
+// params.IsCodeNotFound if no upgrade is staged.
+func (st *State) StagedTools(tag string) (*tools.Tools, downloader.Verification, error) {
+	var results params.ToolsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: tag}},
+	}
+	err := st.call("StagedTools", args, &results)
+	if err != nil {
+		return nil, downloader.Verification{}, err
+	}
+	if len(results.Results) != 1 {
+		return nil, downloader.Verification{}, fmt.Errorf("expected 1 result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if err := result.Error; err != nil {
+		return nil, downloader.Verification{}, err
+	}
+	return result.Tools, toolsVerification(result), nil
+}
+
+// toolsVerification returns how to verify the server that the
+// tools of the given result are downloaded from.
+func toolsVerification(result params.ToolsResult) downloader.Verification {
+	v := downloader.Verification{
+		HostnameVerification: utils.VerifySSLHostnames,
+		FromAPIServer:        result.FromAPIServer,
+	}
+	if result.DisableSSLHostnameVerification {
+		v.HostnameVerification = utils.NoVerifySSLHostnames
+	}
+	return v
+}
+
+// SetStagedToolsReady records that the entity with the given tag has
+// downloaded and verified the staged tools of the given version.
+func (st *State) SetStagedToolsReady(tag string, v version.Binary) error {
+	var results params.ErrorResults
+	args := params.EntitiesVersion{
+		AgentTools: []params.EntityVersion{{
+			Tag:   tag,
+			Tools: &params.Version{v},
+		}},
+	}
+	err := st.call("SetStagedToolsReady", args, &results)
+	if err != nil {
+		return err
+	}
+	return results.OneError()
+}
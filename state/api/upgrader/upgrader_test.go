@@ -11,6 +11,7 @@ import (
 	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/downloader"
 	envtesting "github.com/juju/juju/environs/testing"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/state"
@@ -102,19 +103,19 @@ func (s *machineUpgraderSuite) TestTools(c *gc.C) {
 	s.rawMachine.SetAgentVersion(cur)
 	// Upgrader.Tools returns the *desired* set of tools, not the currently
 	// running set. We want to be upgraded to cur.Version
-	stateTools, hostnameVerification, err := s.st.Tools(s.rawMachine.Tag().String())
+	stateTools, verification, err := s.st.Tools(s.rawMachine.Tag().String())
 	c.Assert(err, gc.IsNil)
 	c.Assert(stateTools.Version, gc.Equals, cur)
 	c.Assert(stateTools.URL, gc.Not(gc.Equals), "")
-	c.Assert(hostnameVerification, gc.Equals, utils.VerifySSLHostnames)
+	c.Assert(verification, gc.Equals, downloader.Verification{HostnameVerification: utils.VerifySSLHostnames})
 
 	envtesting.SetSSLHostnameVerification(c, s.State, false)
 
-	stateTools, hostnameVerification, err = s.st.Tools(s.rawMachine.Tag().String())
+	stateTools, verification, err = s.st.Tools(s.rawMachine.Tag().String())
 	c.Assert(err, gc.IsNil)
 	c.Assert(stateTools.Version, gc.Equals, cur)
 	c.Assert(stateTools.URL, gc.Not(gc.Equals), "")
-	c.Assert(hostnameVerification, gc.Equals, utils.NoVerifySSLHostnames)
+	c.Assert(verification, gc.Equals, downloader.Verification{HostnameVerification: utils.NoVerifySSLHostnames})
 }
 
 func (s *machineUpgraderSuite) TestWatchAPIVersion(c *gc.C) {
@@ -152,3 +153,62 @@ func (s *machineUpgraderSuite) TestDesiredVersion(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(stateVersion, gc.Equals, cur.Number)
 }
+
+func (s *machineUpgraderSuite) stageNewVersion(c *gc.C) version.Binary {
+	err := s.rawMachine.SetAgentVersion(version.Current)
+	c.Assert(err, gc.IsNil)
+	newer := version.Current
+	newer.Patch++
+	envtesting.AssertUploadFakeToolsVersions(c, s.Environ.Storage(), newer)
+	err = s.State.StageAgentVersion(newer.Number)
+	c.Assert(err, gc.IsNil)
+	return newer
+}
+
+func (s *machineUpgraderSuite) TestStagedToolsNotStaged(c *gc.C) {
+	stagedTools, _, err := s.st.StagedTools(s.rawMachine.Tag().String())
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(stagedTools, gc.IsNil)
+}
+
+func (s *machineUpgraderSuite) TestStagedTools(c *gc.C) {
+	newer := s.stageNewVersion(c)
+	stagedTools, verification, err := s.st.StagedTools(s.rawMachine.Tag().String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stagedTools.Version, gc.Equals, newer)
+	c.Assert(stagedTools.URL, gc.Not(gc.Equals), "")
+	c.Assert(verification, gc.Equals, downloader.Verification{HostnameVerification: utils.VerifySSLHostnames})
+}
+
+func (s *machineUpgraderSuite) TestStagedToolsWrongMachine(c *gc.C) {
+	stagedTools, _, err := s.st.StagedTools("machine-42")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+	c.Assert(stagedTools, gc.IsNil)
+}
+
+func (s *machineUpgraderSuite) TestSetStagedToolsReady(c *gc.C) {
+	newer := s.stageNewVersion(c)
+	err := s.st.SetStagedToolsReady(s.rawMachine.Tag().String(), newer)
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.ReadyAgents(), gc.DeepEquals, []string{s.rawMachine.Tag().String()})
+
+	err = s.st.SetStagedToolsReady("machine-42", newer)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+}
+
+func (s *machineUpgraderSuite) TestWatchStagedUpgrade(c *gc.C) {
+	w, err := s.st.WatchStagedUpgrade(s.rawMachine.Tag().String())
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	// Initial event
+	wc.AssertOneChange()
+	s.stageNewVersion(c)
+	wc.AssertOneChange()
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
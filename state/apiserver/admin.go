@@ -19,10 +19,11 @@ import (
 	"github.com/juju/juju/state/presence"
 )
 
-func newStateServer(srv *Server, rpcConn *rpc.Conn, reqNotifier *requestNotifier, limiter utils.Limiter) *initialRoot {
+func newStateServer(srv *Server, rpcConn *rpc.Conn, reqNotifier *requestNotifier, limiter utils.Limiter, hostedEnv *state.Environment) *initialRoot {
 	r := &initialRoot{
-		srv:     srv,
-		rpcConn: rpcConn,
+		srv:       srv,
+		rpcConn:   rpcConn,
+		hostedEnv: hostedEnv,
 	}
 	r.admin = &srvAdmin{
 		root:        r,
@@ -40,6 +41,11 @@ type initialRoot struct {
 	srv     *Server
 	rpcConn *rpc.Conn
 
+	// hostedEnv holds the environment addressed by the
+	// connection if it is hosted by the state servers of
+	// another environment.
+	hostedEnv *state.Environment
+
 	admin *srvAdmin
 }
 
@@ -104,6 +110,11 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	if err != nil {
 		return params.LoginResult{}, err
 	}
+	if a.root.hostedEnv != nil {
+		if err := checkHostedAccess(a.root.hostedEnv, entity); err != nil {
+			return params.LoginResult{}, err
+		}
+	}
 	if a.reqNotifier != nil {
 		a.reqNotifier.login(entity.Tag().String())
 	}
@@ -112,6 +123,8 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	var newRoot apiRoot
 	if inUpgrade {
 		newRoot = newUpgradingRoot(a.root, entity)
+	} else if a.root.hostedEnv != nil {
+		newRoot = newHostedEnvironRoot(a.root, entity)
 	} else {
 		newRoot = newSrvRoot(a.root, entity)
 	}
@@ -126,9 +139,24 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 	}
 	logger.Debugf("hostPorts: %v", hostPorts)
 
-	environ, err := a.root.srv.state.Environment()
-	if err != nil {
-		return params.LoginResult{}, err
+	environ := a.root.hostedEnv
+	if environ == nil {
+		environ, err = a.root.srv.state.Environment()
+		if err != nil {
+			return params.LoginResult{}, err
+		}
+	}
+
+	if limitsApply(entity) {
+		tag := entity.Tag().String()
+		if err := a.root.srv.entityLimiter.acquireConnection(tag); err != nil {
+			newRoot.Kill()
+			return params.LoginResult{}, err
+		}
+		newRoot.getResources().RegisterNamed("entityConnection", &entityConnection{
+			limiter: a.root.srv.entityLimiter,
+			tag:     tag,
+		})
 	}
 
 	a.root.rpcConn.ServeFinder(newRoot, serverError)
@@ -143,6 +171,20 @@ func (a *srvAdmin) Login(c params.Creds) (params.LoginResult, error) {
 
 var doCheckCreds = checkCreds
 
+// checkHostedAccess returns an error unless the given entity may
+// connect to the given hosted environment. Hosted environments have
+// no agents of their own, so only the users they were created for
+// may connect.
+func checkHostedAccess(env *state.Environment, entity state.Entity) error {
+	user, ok := entity.Tag().(names.UserTag)
+	if !ok || !env.HasAccess(user) {
+		// As in checkCreds, the error does not reveal whether
+		// the entity exists.
+		return common.ErrBadCreds
+	}
+	return nil
+}
+
 func checkCreds(st *state.State, c params.Creds) (state.Entity, error) {
 	entity, err := st.FindEntity(c.AuthTag)
 	if errors.IsNotFound(err) {
@@ -176,18 +218,6 @@ func getAndUpdateLastConnectionForEntity(entity state.Entity) *time.Time {
 	return nil
 }
 
-func checkForValidMachineAgent(entity state.Entity, c params.Creds) error {
-	// If this is a machine agent connecting, we need to check the
-	// nonce matches, otherwise the wrong agent might be trying to
-	// connect.
-	if machine, ok := entity.(*state.Machine); ok {
-		if !machine.CheckProvisioned(c.Nonce) {
-			return state.NotProvisionedError(machine.Id())
-		}
-	}
-	return nil
-}
-
 // machinePinger wraps a presence.Pinger.
 type machinePinger struct {
 	*presence.Pinger
@@ -217,7 +247,7 @@ func (a *srvAdmin) startPingerIfAgent(newRoot apiRoot, entity state.Entity) erro
 		return err
 	}
 
-	newRoot.getResources().Register(&machinePinger{pinger})
+	newRoot.getResources().RegisterNamed("machinePinger", &machinePinger{pinger})
 	action := func() {
 		if err := newRoot.getRpcConn().Close(); err != nil {
 			logger.Errorf("error closing the RPC connection: %v", err)
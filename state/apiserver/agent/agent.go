@@ -71,6 +71,9 @@ func (api *API) getEntity(tag string) (result params.AgentGetEntitiesResult, err
 		return
 	}
 	result.Life = params.Life(entity.Life().String())
+	if rotator, ok := entity.(state.PasswordRotator); ok {
+		result.PasswordRotationRequired = rotator.PasswordRotationRequired()
+	}
 	if machine, ok := entity.(*state.Machine); ok {
 		result.Jobs = stateJobsToAPIParamsJobs(machine.Jobs())
 		result.ContainerType = machine.ContainerType()
@@ -79,7 +82,7 @@ func (api *API) getEntity(tag string) (result params.AgentGetEntitiesResult, err
 }
 
 func (api *API) StateServingInfo() (result params.StateServingInfo, err error) {
-	if !api.auth.AuthEnvironManager() {
+	if !api.auth.AuthEnvironManager() && !isAPIServer(api.auth.GetAuthEntity()) {
 		err = common.ErrPerm
 		return
 	}
@@ -103,6 +106,21 @@ func (api *API) IsMaster() (params.IsMasterResult, error) {
 	return params.IsMasterResult{Master: isMaster}, err
 }
 
+// isAPIServer reports whether the given entity is a machine
+// that runs only an API server.
+func isAPIServer(entity state.Entity) bool {
+	machine, ok := entity.(*state.Machine)
+	if !ok {
+		return false
+	}
+	for _, job := range machine.Jobs() {
+		if job == state.JobManageAPI {
+			return true
+		}
+	}
+	return false
+}
+
 func stateJobsToAPIParamsJobs(jobs []state.MachineJob) []params.MachineJob {
 	pjobs := make([]params.MachineJob, len(jobs))
 	for i, job := range jobs {
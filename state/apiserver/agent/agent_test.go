@@ -130,6 +130,22 @@ func (s *agentSuite) TestGetEntities(c *gc.C) {
 	})
 }
 
+func (s *agentSuite) TestGetEntitiesPasswordRotationRequired(c *gc.C) {
+	err := s.machine1.RequirePasswordRotation()
+	c.Assert(err, gc.IsNil)
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: "machine-1"}},
+	}
+	results := s.agent.GetEntities(args)
+	c.Assert(results, gc.DeepEquals, params.AgentGetEntitiesResults{
+		Entities: []params.AgentGetEntitiesResult{{
+			Life:                     "alive",
+			Jobs:                     []params.MachineJob{params.JobHostUnits},
+			PasswordRotationRequired: true,
+		}},
+	})
+}
+
 func (s *agentSuite) TestGetNotFoundEntity(c *gc.C) {
 	// Destroy the container first, so we can destroy its parent.
 	err := s.container.Destroy()
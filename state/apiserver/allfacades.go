@@ -10,13 +10,16 @@ import (
 	_ "github.com/juju/juju/state/apiserver/agent"
 	_ "github.com/juju/juju/state/apiserver/charmrevisionupdater"
 	_ "github.com/juju/juju/state/apiserver/client"
+	_ "github.com/juju/juju/state/apiserver/controller"
 	_ "github.com/juju/juju/state/apiserver/deployer"
 	_ "github.com/juju/juju/state/apiserver/environment"
+	_ "github.com/juju/juju/state/apiserver/environmentmanager"
 	_ "github.com/juju/juju/state/apiserver/firewaller"
 	_ "github.com/juju/juju/state/apiserver/keymanager"
 	_ "github.com/juju/juju/state/apiserver/keyupdater"
 	_ "github.com/juju/juju/state/apiserver/logger"
 	_ "github.com/juju/juju/state/apiserver/machine"
+	_ "github.com/juju/juju/state/apiserver/metrics"
 	_ "github.com/juju/juju/state/apiserver/networker"
 	_ "github.com/juju/juju/state/apiserver/provisioner"
 	_ "github.com/juju/juju/state/apiserver/rsyslog"
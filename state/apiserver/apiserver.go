@@ -8,13 +8,16 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/bmizerany/pat"
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
+	"github.com/juju/names"
 	"github.com/juju/utils"
 	"launchpad.net/tomb"
 
@@ -41,6 +44,19 @@ type Server struct {
 	logDir    string
 	limiter   utils.Limiter
 	validator LoginValidator
+	metrics   *serverMetrics
+
+	// modelCache, if not nil, serves reads of machines, units
+	// and services for all connections.
+	modelCache *common.ModelCache
+
+	// entityLimiter limits the connections and requests of agents.
+	entityLimiter *entityLimiter
+
+	// drainErr holds the error returned by drainError, which is
+	// computed once for all connections.
+	drainOnce sync.Once
+	drainErr  error
 
 	mu          sync.Mutex // protects the fields that follow
 	environUUID string
@@ -58,6 +74,11 @@ type ServerConfig struct {
 	DataDir   string
 	LogDir    string
 	Validator LoginValidator
+
+	// ModelCacheStaleness, if non-zero, enables a cache of the
+	// environment's entities used to answer read-heavy calls, and
+	// bounds how far it may lag behind the state's multiwatcher.
+	ModelCacheStaleness time.Duration
 }
 
 // NewServer serves the given state by accepting requests on the given
@@ -80,6 +101,12 @@ func NewServer(s *state.State, lis net.Listener, cfg ServerConfig) (*Server, err
 		logDir:    cfg.LogDir,
 		limiter:   utils.NewLimiter(loginRateLimit),
 		validator: cfg.Validator,
+		metrics:   newServerMetrics(),
+
+		entityLimiter: newEntityLimiter(),
+	}
+	if cfg.ModelCacheStaleness > 0 {
+		srv.modelCache = common.NewModelCache(s, cfg.ModelCacheStaleness)
 	}
 	// TODO(rog) check that *srvRoot is a valid type for using
 	// as an RPC server.
@@ -113,8 +140,12 @@ func (srv *Server) Wait() error {
 }
 
 type requestNotifier struct {
-	id    int64
-	start time.Time
+	id      int64
+	start   time.Time
+	metrics *serverMetrics
+
+	// logging holds whether requests and replies are logged.
+	logging bool
 
 	mu   sync.Mutex
 	tag_ string
@@ -122,11 +153,12 @@ type requestNotifier struct {
 
 var globalCounter int64
 
-func newRequestNotifier() *requestNotifier {
+func newRequestNotifier(metrics *serverMetrics) *requestNotifier {
 	return &requestNotifier{
-		id:    atomic.AddInt64(&globalCounter, 1),
-		tag_:  "<unknown>",
-		start: time.Now(),
+		id:      atomic.AddInt64(&globalCounter, 1),
+		tag_:    "<unknown>",
+		start:   time.Now(),
+		metrics: metrics,
 	}
 }
 
@@ -144,6 +176,9 @@ func (n *requestNotifier) tag() (tag string) {
 }
 
 func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
+	if !n.logging {
+		return
+	}
 	if hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
 		return
 	}
@@ -152,6 +187,10 @@ func (n *requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 }
 
 func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body interface{}, timeSpent time.Duration) {
+	n.metrics.requestServed(req, hdr, timeSpent)
+	if !n.logging {
+		return
+	}
 	if req.Type == "Pinger" && req.Action == "Ping" {
 		return
 	}
@@ -159,10 +198,12 @@ func (n *requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body int
 }
 
 func (n *requestNotifier) join(req *http.Request) {
+	n.metrics.connectionOpened()
 	logger.Infof("[%X] API connection from %s", n.id, req.RemoteAddr)
 }
 
 func (n *requestNotifier) leave() {
+	n.metrics.connectionClosed()
 	logger.Infof("[%X] %s API connection terminated after %v", n.id, n.tag(), time.Since(n.start))
 }
 
@@ -206,6 +247,11 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler: httpHandler{state: srv.state},
 			logDir:      srv.logDir},
 	)
+	handleAll(mux, "/environment/:envuuid/charms/download",
+		&charmDownloadHandler{charmsHandler{
+			httpHandler: httpHandler{state: srv.state},
+			dataDir:     srv.dataDir}},
+	)
 	handleAll(mux, "/environment/:envuuid/charms",
 		&charmsHandler{
 			httpHandler: httpHandler{state: srv.state},
@@ -215,6 +261,9 @@ func (srv *Server) run(lis net.Listener) {
 	// where we only want to support specific request methods. However, our
 	// tests currently assert that errors come back as application/json and
 	// pat only does "text/plain" responses.
+	handleAll(mux, "/environment/:envuuid/tools/download",
+		&toolsDownloadHandler{toolsHandler{httpHandler{state: srv.state}}},
+	)
 	handleAll(mux, "/environment/:envuuid/tools",
 		&toolsHandler{httpHandler{state: srv.state}},
 	)
@@ -230,19 +279,27 @@ func (srv *Server) run(lis net.Listener) {
 			httpHandler: httpHandler{state: srv.state},
 			dataDir:     srv.dataDir},
 	)
+	handleAll(mux, "/tools/download",
+		&toolsDownloadHandler{toolsHandler{httpHandler{state: srv.state}}},
+	)
 	handleAll(mux, "/tools",
 		&toolsHandler{httpHandler{state: srv.state}},
 	)
 	handleAll(mux, "/backup",
 		&backupHandler{httpHandler{state: srv.state}},
 	)
+	handleAll(mux, "/metrics",
+		&metricsHandler{
+			httpHandler: httpHandler{state: srv.state},
+			metrics:     srv.metrics},
+	)
 	handleAll(mux, "/", http.HandlerFunc(srv.apiHandler))
 	// The error from http.Serve is not interesting.
 	http.Serve(lis, mux)
 }
 
 func (srv *Server) apiHandler(w http.ResponseWriter, req *http.Request) {
-	reqNotifier := newRequestNotifier()
+	reqNotifier := newRequestNotifier(srv.metrics)
 	reqNotifier.join(req)
 	defer reqNotifier.leave()
 	wsServer := websocket.Server{
@@ -303,6 +360,19 @@ func (srv *Server) checkEnvironUUID(expected string) error {
 	return nil
 }
 
+// hostedEnvironment returns the environment with the given UUID that
+// is hosted by the state servers, or an unknown environment error if
+// there is none.
+func (srv *Server) hostedEnvironment(envUUID string) (*state.Environment, error) {
+	env, err := srv.state.GetEnvironment(names.NewEnvironTag(envUUID))
+	if errors.IsNotFound(err) {
+		return nil, common.UnknownEnvironmentError(envUUID)
+	} else if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
 func (srv *Server) getEnvironUUID() string {
 	srv.mu.Lock()
 	defer srv.mu.Unlock()
@@ -316,31 +386,74 @@ func (srv *Server) setEnvironUUID(uuid string) {
 }
 
 func (srv *Server) serveConn(wsConn *websocket.Conn, reqNotifier *requestNotifier, envUUID string) error {
-	codec := jsoncodec.NewWebsocket(wsConn)
+	threshold := 0
+	if wsConn.Request().Header.Get(jsoncodec.CompressionHeader) == jsoncodec.CompressionDeflate {
+		threshold = compressThreshold
+	}
+	codec := jsoncodec.NewQueuedWebsocket(wsConn, outboundQueueSize, writeTimeout, threshold)
 	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
 		codec.SetLogging(true)
 	}
-	var notifier rpc.RequestNotifier
-	if logger.EffectiveLogLevel() <= loggo.DEBUG {
-		// Incur request monitoring overhead only if we
-		// know we'll need it.
-		notifier = reqNotifier
-	}
-	conn := rpc.NewConn(codec, notifier)
+	// Requests are always timed for the server metrics, but incur
+	// the overhead of logging them only if we know we'll need it.
+	reqNotifier.logging = logger.EffectiveLogLevel() <= loggo.DEBUG
+	conn := rpc.NewConn(codec, reqNotifier)
+	var hostedEnv *state.Environment
 	err := srv.validateEnvironUUID(envUUID)
+	if common.IsUnknownEnviromentError(err) {
+		// The connection may be for an environment hosted by
+		// the state servers rather than for their own.
+		hostedEnv, err = srv.hostedEnvironment(envUUID)
+	}
 	if err != nil {
 		conn.Serve(&errRoot{err}, serverError)
 	} else {
-		conn.Serve(newStateServer(srv, conn, reqNotifier, srv.limiter), serverError)
+		conn.Serve(newStateServer(srv, conn, reqNotifier, srv.limiter, hostedEnv), serverError)
 	}
 	conn.Start()
 	select {
 	case <-conn.Dead():
 	case <-srv.tomb.Dying():
+		// Let the client know that it should go elsewhere, and
+		// give the requests in progress a chance to complete.
+		if !conn.Drain(srv.drainError(), drainTimeout) {
+			logger.Warningf("closing connection with requests still in progress after %v", drainTimeout)
+		}
 	}
 	return conn.Close()
 }
 
+// drainError returns the error with which requests are answered while
+// the server is stopping. It names the API server addresses that the
+// client may try instead.
+func (srv *Server) drainError() error {
+	srv.drainOnce.Do(func() {
+		srv.drainErr = srv.newDrainError()
+	})
+	return srv.drainErr
+}
+
+func (srv *Server) newDrainError() error {
+	msg := "API server is shutting down"
+	hostPorts, err := srv.state.APIHostPorts()
+	if err != nil {
+		logger.Warningf("cannot get API server addresses: %v", err)
+	}
+	var addrs []string
+	for _, serverHostPorts := range hostPorts {
+		for _, hp := range serverHostPorts {
+			addrs = append(addrs, hp.NetAddr())
+		}
+	}
+	if len(addrs) > 0 {
+		msg += "; try again using one of: " + strings.Join(addrs, ", ")
+	}
+	return &params.Error{
+		Message: msg,
+		Code:    params.CodeTryAgain,
+	}
+}
+
 func (srv *Server) mongoPinger() error {
 	timer := time.NewTimer(0)
 	session := srv.state.MongoSession()
@@ -350,10 +463,12 @@ func (srv *Server) mongoPinger() error {
 		case <-srv.tomb.Dying():
 			return tomb.ErrDying
 		}
+		start := time.Now()
 		if err := session.Ping(); err != nil {
 			logger.Infof("got error pinging mongo: %v", err)
 			return fmt.Errorf("error pinging mongo: %v", err)
 		}
+		srv.metrics.mongoPinged(time.Since(start))
 		timer.Reset(mongoPingInterval)
 	}
 }
@@ -123,6 +123,11 @@ func (s *agentAuthenticatorSuite) TestInvalidLogins(c *gc.C) {
 		nonce:        "123",
 		about:        "machine login",
 		errorMessage: "machine 0 is not provisioned",
+	}, {
+		entity:       s.machine,
+		credentials:  s.machinePassword,
+		about:        "machine login without nonce",
+		errorMessage: "machine 0 is not provisioned",
 	}, {
 		entity:       s.user,
 		credentials:  "wrong-secret",
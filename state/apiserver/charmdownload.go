@@ -0,0 +1,235 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+// charmDownloadHandler serves charm archives from environment storage
+// through HTTPS in the API server, so that agents need no access to
+// provider storage. Requests are authorized by the expiring signature
+// that common.CharmDownloadURL includes in the URL, rather than by
+// credentials. Archives are served with their SHA-256 digest as ETag,
+// and range requests are supported so interrupted downloads can be
+// resumed.
+type charmDownloadHandler struct {
+	charmsHandler
+}
+
+func (h *charmDownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.validateEnvironUUID(r); err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	curl, err := common.VerifyCharmDownload(h.state, r.URL.Query())
+	if err == common.ErrPerm {
+		h.sendError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sch, err := h.state.Charm(curl)
+	if errors.IsNotFound(err) || err == nil && sch.BundleURL() == nil {
+		h.sendError(w, http.StatusNotFound, fmt.Sprintf("charm %q not found", curl))
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	etag := `"` + sch.BundleSha256() + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	archive, err := h.cachedArchive(sch)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, fmt.Sprintf("unable to retrieve and save the charm: %v", err))
+		return
+	}
+	defer archive.Close()
+	w.Header().Set("Content-Type", "application/zip")
+	http.ServeContent(w, r, "", time.Time{}, archive)
+}
+
+// The archives served to agents are cached in their own directory,
+// which holds at most maxCachedArchives archives and at most
+// maxCachedArchiveBytes of data. The least recently served archives
+// are evicted first.
+var (
+	maxCachedArchives           = 50
+	maxCachedArchiveBytes int64 = 1 << 30
+)
+
+// archiveCacheTempPrefix prefixes the names of partially written
+// archives, so they are never served or evicted.
+const archiveCacheTempPrefix = ".tmp-"
+
+// cachedArchive opens a local copy of the given charm's archive, first
+// fetching it from environment storage if necessary. Copies are
+// identified by digest as well as charm URL, so an archive replaced in
+// state is never served from a stale copy, and their content is checked
+// against the digest each time they are served.
+func (h *charmDownloadHandler) cachedArchive(sch *state.Charm) (*os.File, error) {
+	cacheDir := filepath.Join(h.dataDir, "charm-download-cache")
+	name := charm.Quote(sch.URL().String()) + "-" + sch.BundleSha256() + ".zip"
+	archivePath := filepath.Join(cacheDir, name)
+	archive, err := openVerified(archivePath, sch.BundleSha256())
+	switch {
+	case err == nil:
+		// Record the use so that eviction keeps recently served archives.
+		now := time.Now()
+		if err := os.Chtimes(archivePath, now, now); err != nil {
+			logger.Warningf("cannot update charm archive access time: %v", err)
+		}
+		return archive, nil
+	case err == errArchiveDigestMismatch:
+		logger.Warningf("removing corrupt cached archive for charm %q", sch.URL())
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, errors.Annotate(err, "cannot create the charms cache")
+	}
+	storageName := path.Base(sch.BundleURL().Path)
+	if err := h.fetchArchive(storageName, archivePath, sch.BundleSha256()); err != nil {
+		return nil, err
+	}
+	if err := evictArchives(cacheDir, archivePath); err != nil {
+		logger.Warningf("cannot evict charm archives: %v", err)
+	}
+	return openVerified(archivePath, sch.BundleSha256())
+}
+
+// errArchiveDigestMismatch is returned when the content of a charm
+// archive does not match its recorded digest.
+var errArchiveDigestMismatch = errors.New("charm archive does not match its digest")
+
+// openVerified opens the file at archivePath, and checks that its content
+// has the given hex-encoded SHA-256 digest. The returned file is positioned
+// at the start of the content.
+func openVerified(archivePath, expectedSha256 string) (*os.File, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	actualSha256, _, err := utils.ReadSHA256(f)
+	if err == nil && actualSha256 != expectedSha256 {
+		err = errArchiveDigestMismatch
+	}
+	if err == nil {
+		_, err = f.Seek(0, 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// fetchArchive copies the named archive from environment storage to
+// archivePath. The copy is only put in place if its content has the
+// expected digest.
+func (h *charmDownloadHandler) fetchArchive(name, archivePath, expectedSha256 string) error {
+	storage, err := environs.GetStorage(h.state)
+	if err != nil {
+		return errors.Annotate(err, "cannot access provider storage")
+	}
+	reader, err := storage.Get(name)
+	if err != nil {
+		return errors.Annotate(err, "charm not found in the provider storage")
+	}
+	defer reader.Close()
+	f, err := ioutil.TempFile(filepath.Dir(archivePath), archiveCacheTempPrefix)
+	if err != nil {
+		return errors.Annotate(err, "cannot create charm archive temp file")
+	}
+	defer os.Remove(f.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), reader)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Annotate(err, "cannot read charm data")
+	}
+	if actualSha256 := hex.EncodeToString(hash.Sum(nil)); actualSha256 != expectedSha256 {
+		return errors.Errorf("expected sha256 %q, got %q", expectedSha256, actualSha256)
+	}
+	if err := os.Rename(f.Name(), archivePath); err != nil {
+		return errors.Annotate(err, "error renaming the charm archive")
+	}
+	return nil
+}
+
+// evictArchives removes the least recently used archives from cacheDir
+// until it is within both the count and the size limits. The archive
+// at keep is never removed.
+func evictArchives(cacheDir, keep string) error {
+	infos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		return err
+	}
+	var archives []os.FileInfo
+	var total int64
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), archiveCacheTempPrefix) {
+			archives = append(archives, info)
+			total += info.Size()
+		}
+	}
+	sort.Sort(byModTime(archives))
+	count := len(archives)
+	for _, info := range archives {
+		if count <= maxCachedArchives && total <= maxCachedArchiveBytes {
+			break
+		}
+		archivePath := filepath.Join(cacheDir, info.Name())
+		if archivePath == keep {
+			continue
+		}
+		// Another request may be evicting concurrently.
+		if err := os.Remove(archivePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		count--
+		total -= info.Size()
+	}
+	return nil
+}
+
+// byModTime sorts file infos from least to most recently modified.
+type byModTime []os.FileInfo
+
+func (s byModTime) Len() int           { return len(s) }
+func (s byModTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byModTime) Less(i, j int) bool { return s[i].ModTime().Before(s[j].ModTime()) }
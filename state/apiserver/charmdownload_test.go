@@ -0,0 +1,196 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/charm"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+type charmDownloadSuite struct {
+	authHttpSuite
+	charm   *state.Charm
+	archive []byte
+}
+
+var _ = gc.Suite(&charmDownloadSuite{})
+
+func (s *charmDownloadSuite) SetUpTest(c *gc.C) {
+	s.authHttpSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+	s.charm = s.AddTestingCharm(c, "dummy")
+	r, err := s.Environ.Storage().Get(charm.Quote(s.charm.URL().String()))
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	s.archive, err = ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+}
+
+// downloadURL returns the expiring URL of s.charm's archive, addressed
+// to the API server under test.
+func (s *charmDownloadSuite) downloadURL(c *gc.C) *url.URL {
+	downloadURL, err := common.CharmDownloadURL(s.State, s.State.EnvironTag().Id(), s.charm.URL())
+	c.Assert(err, gc.IsNil)
+	uri, err := url.Parse(downloadURL)
+	c.Assert(err, gc.IsNil)
+	uri.Host = s.baseURL(c).Host
+	return uri
+}
+
+func (s *charmDownloadSuite) get(c *gc.C, uri *url.URL, header http.Header) *http.Response {
+	req, err := http.NewRequest("GET", uri.String(), nil)
+	c.Assert(err, gc.IsNil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := utils.GetNonValidatingHTTPClient().Do(req)
+	c.Assert(err, gc.IsNil)
+	return resp
+}
+
+func (s *charmDownloadSuite) TestDownload(c *gc.C) {
+	resp := s.get(c, s.downloadURL(c), nil)
+	body := assertResponse(c, resp, http.StatusOK, "application/zip")
+	c.Assert(body, gc.DeepEquals, s.archive)
+	c.Assert(resp.Header.Get("ETag"), gc.Equals, `"`+s.charm.BundleSha256()+`"`)
+	c.Assert(resp.Header.Get("Accept-Ranges"), gc.Equals, "bytes")
+}
+
+func (s *charmDownloadSuite) TestDownloadRange(c *gc.C) {
+	resp := s.get(c, s.downloadURL(c), http.Header{"Range": {"bytes=10-19"}})
+	body := assertResponse(c, resp, http.StatusPartialContent, "application/zip")
+	c.Assert(body, gc.DeepEquals, s.archive[10:20])
+}
+
+func (s *charmDownloadSuite) TestDownloadNotModified(c *gc.C) {
+	etag := `"` + s.charm.BundleSha256() + `"`
+	resp := s.get(c, s.downloadURL(c), http.Header{"If-None-Match": {etag}})
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusNotModified)
+}
+
+func (s *charmDownloadSuite) TestDownloadCached(c *gc.C) {
+	resp := s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+
+	// The archive is now served from the API server's cache.
+	err := s.Environ.Storage().Remove(charm.Quote(s.charm.URL().String()))
+	c.Assert(err, gc.IsNil)
+	resp = s.get(c, s.downloadURL(c), nil)
+	body := assertResponse(c, resp, http.StatusOK, "application/zip")
+	c.Assert(body, gc.DeepEquals, s.archive)
+}
+
+// cachedArchives returns the names of the archives in the API
+// server's download cache.
+func (s *charmDownloadSuite) cachedArchives(c *gc.C) []string {
+	infos, err := ioutil.ReadDir(filepath.Join(s.DataDir(), "charm-download-cache"))
+	c.Assert(err, gc.IsNil)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names
+}
+
+func (s *charmDownloadSuite) TestDownloadCorruptCache(c *gc.C) {
+	resp := s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+	names := s.cachedArchives(c)
+	c.Assert(names, gc.HasLen, 1)
+	err := ioutil.WriteFile(filepath.Join(s.DataDir(), "charm-download-cache", names[0]), []byte("rubbish"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	// The corrupt copy is replaced from environment storage.
+	resp = s.get(c, s.downloadURL(c), nil)
+	body := assertResponse(c, resp, http.StatusOK, "application/zip")
+	c.Assert(body, gc.DeepEquals, s.archive)
+}
+
+func (s *charmDownloadSuite) TestDownloadEvictsLeastRecentlyUsed(c *gc.C) {
+	s.PatchValue(apiserver.MaxCachedArchives, 1)
+	resp := s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+	first := s.cachedArchives(c)
+	c.Assert(first, gc.HasLen, 1)
+
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	resp = s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+	second := s.cachedArchives(c)
+	c.Assert(second, gc.HasLen, 1)
+	c.Assert(second[0], gc.Not(gc.Equals), first[0])
+}
+
+func (s *charmDownloadSuite) TestDownloadEvictsBySize(c *gc.C) {
+	s.PatchValue(apiserver.MaxCachedArchiveBytes, int64(1))
+	resp := s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+
+	// The archive just fetched is kept even though it is too big.
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	resp = s.get(c, s.downloadURL(c), nil)
+	assertResponse(c, resp, http.StatusOK, "application/zip")
+	names := s.cachedArchives(c)
+	c.Assert(names, gc.HasLen, 1)
+	c.Assert(strings.HasSuffix(names[0], "-"+s.charm.BundleSha256()+".zip"), jc.IsTrue)
+}
+
+func (s *charmDownloadSuite) TestDownloadRequiresGET(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "POST", s.downloadURL(c).String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "POST"`)
+}
+
+func (s *charmDownloadSuite) TestDownloadRejectsBadSignature(c *gc.C) {
+	uri := s.downloadURL(c)
+	query := uri.Query()
+	query.Set("signature", "deadbeef")
+	uri.RawQuery = query.Encode()
+	resp := s.get(c, uri, nil)
+	s.assertErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *charmDownloadSuite) TestDownloadUnknownCharm(c *gc.C) {
+	downloadURL, err := common.CharmDownloadURL(s.State, s.State.EnvironTag().Id(), charm.MustParseURL("local:quantal/mysql-1"))
+	c.Assert(err, gc.IsNil)
+	uri, err := url.Parse(downloadURL)
+	c.Assert(err, gc.IsNil)
+	uri.Host = s.baseURL(c).Host
+	resp := s.get(c, uri, nil)
+	s.assertErrorResponse(c, resp, http.StatusNotFound, `charm "local:quantal/mysql-1" not found`)
+}
+
+func (s *charmDownloadSuite) TestDownloadRejectsWrongEnvUUIDPath(c *gc.C) {
+	uri := s.downloadURL(c)
+	uri.Path = "/environment/dead-beef-123456/charms/download"
+	resp := s.get(c, uri, nil)
+	s.assertErrorResponse(c, resp, http.StatusNotFound, `unknown environment: "dead-beef-123456"`)
+}
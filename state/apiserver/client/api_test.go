@@ -156,13 +156,14 @@ var scenarioStatus = &api.Status{
 				Status: "started",
 				Data:   params.StatusData{},
 			},
-			AgentState:     "down",
-			AgentStateInfo: "(started)",
-			Series:         "quantal",
-			Containers:     map[string]api.MachineStatus{},
-			Jobs:           []params.MachineJob{params.JobManageEnviron},
-			HasVote:        false,
-			WantsVote:      true,
+			AgentState:              "down",
+			AgentStateInfo:          "(started)",
+			Series:                  "quantal",
+			Containers:              map[string]api.MachineStatus{},
+			HardwareCharacteristics: &instance.HardwareCharacteristics{},
+			Jobs:                    []params.MachineJob{params.JobManageEnviron},
+			HasVote:                 false,
+			WantsVote:               true,
 		},
 		"1": {
 			Id:         "1",
@@ -171,13 +172,14 @@ var scenarioStatus = &api.Status{
 				Status: "started",
 				Data:   params.StatusData{},
 			},
-			AgentState:     "down",
-			AgentStateInfo: "(started)",
-			Series:         "quantal",
-			Containers:     map[string]api.MachineStatus{},
-			Jobs:           []params.MachineJob{params.JobHostUnits},
-			HasVote:        false,
-			WantsVote:      false,
+			AgentState:              "down",
+			AgentStateInfo:          "(started)",
+			Series:                  "quantal",
+			Containers:              map[string]api.MachineStatus{},
+			HardwareCharacteristics: &instance.HardwareCharacteristics{},
+			Jobs:                    []params.MachineJob{params.JobHostUnits},
+			HasVote:                 false,
+			WantsVote:               false,
 		},
 		"2": {
 			Id:         "2",
@@ -186,13 +188,14 @@ var scenarioStatus = &api.Status{
 				Status: "started",
 				Data:   params.StatusData{},
 			},
-			AgentState:     "down",
-			AgentStateInfo: "(started)",
-			Series:         "quantal",
-			Containers:     map[string]api.MachineStatus{},
-			Jobs:           []params.MachineJob{params.JobHostUnits},
-			HasVote:        false,
-			WantsVote:      false,
+			AgentState:              "down",
+			AgentStateInfo:          "(started)",
+			Series:                  "quantal",
+			Containers:              map[string]api.MachineStatus{},
+			HardwareCharacteristics: &instance.HardwareCharacteristics{},
+			Jobs:                    []params.MachineJob{params.JobHostUnits},
+			HasVote:                 false,
+			WantsVote:               false,
 		},
 	},
 	Services: map[string]api.ServiceStatus{
@@ -225,13 +228,15 @@ var scenarioStatus = &api.Status{
 					AgentState:     "down",
 					AgentStateInfo: "(error: blam)",
 					Machine:        "1",
+					WorkloadStatus: "unknown",
 					Subordinates: map[string]api.UnitStatus{
 						"logging/0": api.UnitStatus{
 							Agent: api.AgentStatus{
 								Status: "pending",
 								Data:   params.StatusData{},
 							},
-							AgentState: "pending",
+							AgentState:     "pending",
+							WorkloadStatus: "unknown",
 						},
 					},
 				},
@@ -240,15 +245,17 @@ var scenarioStatus = &api.Status{
 						Status: "pending",
 						Data:   params.StatusData{},
 					},
-					AgentState: "pending",
-					Machine:    "2",
+					AgentState:     "pending",
+					Machine:        "2",
+					WorkloadStatus: "unknown",
 					Subordinates: map[string]api.UnitStatus{
 						"logging/1": api.UnitStatus{
 							Agent: api.AgentStatus{
 								Status: "pending",
 								Data:   params.StatusData{},
 							},
-							AgentState: "pending",
+							AgentState:     "pending",
+							WorkloadStatus: "unknown",
 						},
 					},
 				},
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/juju/charm"
@@ -15,6 +16,7 @@ import (
 	"github.com/juju/names"
 	"github.com/juju/utils"
 
+	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/environs/manual"
@@ -26,6 +28,7 @@ import (
 	"github.com/juju/juju/state/api"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver/common"
+	"github.com/juju/juju/state/watcher"
 	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 )
@@ -138,6 +141,63 @@ func (c *Client) ServiceCharmRelations(p params.ServiceCharmRelations) (params.S
 	return results, nil
 }
 
+// ServiceRelations implements the server side of Client.ServiceRelations.
+func (c *Client) ServiceRelations(p params.ServiceRelations) (params.ServiceRelationsResults, error) {
+	var results params.ServiceRelationsResults
+	service, err := c.api.state.Service(p.ServiceName)
+	if err != nil {
+		return results, err
+	}
+	relations, err := service.Relations()
+	if err != nil {
+		return results, err
+	}
+	results.Relations = make([]params.RelationDetails, len(relations))
+	for i, relation := range relations {
+		results.Relations[i] = relationDetails(relation)
+	}
+	return results, nil
+}
+
+// WatchServiceRelations returns a StringsWatcher that notifies of
+// relations involving the named service being added, becoming dying
+// or being removed. The initial event holds the keys of all the
+// service's current relations.
+func (c *Client) WatchServiceRelations(p params.ServiceRelations) (params.StringsWatchResult, error) {
+	service, err := c.api.state.Service(p.ServiceName)
+	if err != nil {
+		return params.StringsWatchResult{}, err
+	}
+	watch := service.WatchRelations()
+	// Consume the initial event and forward it to the result.
+	if changes, ok := <-watch.Changes(); ok {
+		return params.StringsWatchResult{
+			StringsWatcherId: c.api.resources.Register(watch),
+			Changes:          changes,
+		}, nil
+	}
+	return params.StringsWatchResult{}, watcher.MustErr(watch)
+}
+
+// relationDetails returns the details of the given relation.
+func relationDetails(relation *state.Relation) params.RelationDetails {
+	details := params.RelationDetails{
+		Id:   relation.Id(),
+		Key:  relation.String(),
+		Life: params.Life(relation.Life().String()),
+	}
+	for _, ep := range relation.Endpoints() {
+		details.Endpoints = append(details.Endpoints, params.Endpoint{
+			ServiceName: ep.ServiceName,
+			Relation:    ep.Relation,
+		})
+		// These match on both sides, so use the last.
+		details.Interface = ep.Interface
+		details.Scope = ep.Scope
+	}
+	return details
+}
+
 // Resolved implements the server side of Client.Resolved.
 func (c *Client) Resolved(p params.Resolved) error {
 	unit, err := c.api.state.Unit(p.UnitName)
@@ -147,56 +207,193 @@ func (c *Client) Resolved(p params.Resolved) error {
 	return unit.Resolve(p.Retry)
 }
 
+// MoveUnit implements the server side of Client.MoveUnit.
+func (c *Client) MoveUnit(p params.MoveUnit) error {
+	unit, err := c.api.state.Unit(p.UnitName)
+	if err != nil {
+		return err
+	}
+	machine, err := c.api.state.Machine(p.MachineId)
+	if err != nil {
+		return err
+	}
+	return unit.MoveToMachine(machine)
+}
+
+// ScheduledTasks returns the tasks run periodically by the state
+// servers, with the outcome of their most recent runs.
+func (c *Client) ScheduledTasks() (params.ScheduledTasksResults, error) {
+	tasks, err := c.api.state.AllScheduledTasks()
+	if err != nil {
+		return params.ScheduledTasksResults{}, err
+	}
+	results := params.ScheduledTasksResults{
+		Tasks: make([]params.ScheduledTask, len(tasks)),
+	}
+	for i, task := range tasks {
+		result := params.ScheduledTask{
+			Name:      task.Name(),
+			Interval:  task.Interval(),
+			Enabled:   task.Enabled(),
+			LastError: task.LastError(),
+		}
+		if lastRun := task.LastRun(); !lastRun.IsZero() {
+			result.LastRun = &lastRun
+		}
+		results.Tasks[i] = result
+	}
+	return results, nil
+}
+
+// SetScheduledTaskEnabled enables or disables a task run periodically
+// by the state servers.
+func (c *Client) SetScheduledTaskEnabled(p params.SetScheduledTaskEnabled) error {
+	task, err := c.api.state.ScheduledTask(p.Name)
+	if err != nil {
+		return err
+	}
+	return task.SetEnabled(p.Enabled)
+}
+
 // PublicAddress implements the server side of Client.PublicAddress.
 func (c *Client) PublicAddress(p params.PublicAddress) (results params.PublicAddressResults, err error) {
 	switch {
 	case names.IsValidMachine(p.Target):
-		machine, err := c.api.state.Machine(p.Target)
+		addresses, err := c.machineAddresses(p.Target)
 		if err != nil {
 			return results, err
 		}
-		addr := network.SelectPublicAddress(machine.Addresses())
+		addr := network.SelectPublicAddress(addresses)
 		if addr == "" {
-			return results, fmt.Errorf("machine %q has no public address", machine)
+			return results, fmt.Errorf("machine %q has no public address", p.Target)
 		}
 		return params.PublicAddressResults{PublicAddress: addr}, nil
 
 	case names.IsValidUnit(p.Target):
-		unit, err := c.api.state.Unit(p.Target)
+		addr, ok, err := c.unitPublicAddress(p.Target)
 		if err != nil {
 			return results, err
 		}
-		addr, ok := unit.PublicAddress()
 		if !ok {
-			return results, fmt.Errorf("unit %q has no public address", unit)
+			return results, fmt.Errorf("unit %q has no public address", p.Target)
 		}
 		return params.PublicAddressResults{PublicAddress: addr}, nil
 	}
 	return results, fmt.Errorf("unknown unit or machine %q", p.Target)
 }
 
+// machineAddresses returns the addresses of the machine with the
+// given id, using the server's model cache if available.
+func (c *Client) machineAddresses(id string) ([]network.Address, error) {
+	if cache, ok := c.api.resources.Get("modelCache").(common.SharedModelCache); ok {
+		info, err := cache.Machine(id)
+		if err == nil {
+			return info.Addresses, nil
+		} else if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	machine, err := c.api.state.Machine(id)
+	if err != nil {
+		return nil, err
+	}
+	return machine.Addresses(), nil
+}
+
+// cachedUnitAddresses returns the addresses of the machine the named
+// unit is assigned to, and whether they were found in the server's
+// model cache.
+func (c *Client) cachedUnitAddresses(unitName string) ([]network.Address, bool, error) {
+	cache, ok := c.api.resources.Get("modelCache").(common.SharedModelCache)
+	if !ok {
+		return nil, false, nil
+	}
+	addresses, err := cache.UnitAddresses(unitName)
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return addresses, true, nil
+}
+
+// unitPrivateAddress returns the private address of the named unit
+// and whether it is valid, using the server's model cache if
+// available.
+func (c *Client) unitPrivateAddress(unitName string) (string, bool, error) {
+	if addresses, ok, err := c.cachedUnitAddresses(unitName); err != nil {
+		return "", false, err
+	} else if ok {
+		addr := network.SelectInternalAddress(addresses, false)
+		return addr, addr != "", nil
+	}
+	unit, err := c.api.state.Unit(unitName)
+	if err != nil {
+		return "", false, err
+	}
+	addr, ok := unit.PrivateAddress()
+	return addr, ok, nil
+}
+
+// unitPublicAddress returns the public address of the named unit and
+// whether it is valid, using the server's model cache if available.
+func (c *Client) unitPublicAddress(unitName string) (string, bool, error) {
+	if addresses, ok, err := c.cachedUnitAddresses(unitName); err != nil {
+		return "", false, err
+	} else if ok {
+		addr := network.SelectPublicAddress(addresses)
+		return addr, addr != "", nil
+	}
+	unit, err := c.api.state.Unit(unitName)
+	if err != nil {
+		return "", false, err
+	}
+	addr, ok := unit.PublicAddress()
+	return addr, ok, nil
+}
+
+// WatchPublicAddress returns a watcher that notifies when the public
+// address of the unit named by p.Target changes.
+func (c *Client) WatchPublicAddress(p params.PublicAddress) (params.NotifyWatchResult, error) {
+	if !names.IsValidUnit(p.Target) {
+		return params.NotifyWatchResult{}, fmt.Errorf("%q is not a valid unit name", p.Target)
+	}
+	unit, err := c.api.state.Unit(p.Target)
+	if err != nil {
+		return params.NotifyWatchResult{}, err
+	}
+	watch := unit.WatchPublicAddress()
+	// Consume the initial event; clients look up the address when
+	// they start watching.
+	if _, ok := <-watch.Changes(); ok {
+		return params.NotifyWatchResult{
+			NotifyWatcherId: c.api.resources.Register(watch),
+		}, nil
+	}
+	return params.NotifyWatchResult{}, watcher.MustErr(watch)
+}
+
 // PrivateAddress implements the server side of Client.PrivateAddress.
 func (c *Client) PrivateAddress(p params.PrivateAddress) (results params.PrivateAddressResults, err error) {
 	switch {
 	case names.IsValidMachine(p.Target):
-		machine, err := c.api.state.Machine(p.Target)
+		addresses, err := c.machineAddresses(p.Target)
 		if err != nil {
 			return results, err
 		}
-		addr := network.SelectInternalAddress(machine.Addresses(), false)
+		addr := network.SelectInternalAddress(addresses, false)
 		if addr == "" {
-			return results, fmt.Errorf("machine %q has no internal address", machine)
+			return results, fmt.Errorf("machine %q has no internal address", p.Target)
 		}
 		return params.PrivateAddressResults{PrivateAddress: addr}, nil
 
 	case names.IsValidUnit(p.Target):
-		unit, err := c.api.state.Unit(p.Target)
+		addr, ok, err := c.unitPrivateAddress(p.Target)
 		if err != nil {
 			return results, err
 		}
-		addr, ok := unit.PrivateAddress()
 		if !ok {
-			return results, fmt.Errorf("unit %q has no internal address", unit)
+			return results, fmt.Errorf("unit %q has no internal address", p.Target)
 		}
 		return params.PrivateAddressResults{PrivateAddress: addr}, nil
 	}
@@ -291,6 +488,11 @@ func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
 	if err != nil {
 		return err
 	}
+	if args.ToMachineSpec == "" {
+		if err := newArchChecker(c.api.state).check(curl.Series, args.Constraints); err != nil {
+			return err
+		}
+	}
 
 	_, err = juju.DeployService(c.api.state,
 		juju.DeployServiceParams{
@@ -449,6 +651,37 @@ func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
 	return c.serviceSetCharm(service, args.CharmUrl, args.Force)
 }
 
+// ServiceSetCharmStaged starts a staged upgrade of a service to a
+// charm that has already been added to the environment.
+func (c *Client) ServiceSetCharmStaged(args params.ServiceSetCharmStaged) error {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	curl, err := charm.ParseURL(args.CharmUrl)
+	if err != nil {
+		return err
+	}
+	ch, err := c.api.state.Charm(curl)
+	if err != nil {
+		return err
+	}
+	return service.SetCharmStaged(ch, args.Force, args.CanaryUnits)
+}
+
+// ServiceReleaseUnits advances a staged upgrade of a service by
+// releasing units from the service's previous charm.
+func (c *Client) ServiceReleaseUnits(args params.ServiceReleaseUnits) error {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	if args.All {
+		return service.ReleaseAllUnits()
+	}
+	return service.ReleaseUnits(args.Units)
+}
+
 // addServiceUnits adds a given number of units to a service.
 func addServiceUnits(state *state.State, args params.AddServiceUnits) ([]*state.Unit, error) {
 	service, err := state.Service(args.ServiceName)
@@ -529,17 +762,37 @@ func (c *Client) GetEnvironmentConstraints() (params.GetConstraintsResults, erro
 }
 
 // SetServiceConstraints sets the constraints for a given service.
-func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
+// Constraints not supported by the environment's provider are set
+// regardless, and reported in the result.
+func (c *Client) SetServiceConstraints(args params.SetConstraints) (params.SetConstraintsResults, error) {
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
-		return err
+		return params.SetConstraintsResults{}, err
+	}
+	if err := svc.SetConstraints(args.Constraints); err != nil {
+		return params.SetConstraintsResults{}, err
 	}
-	return svc.SetConstraints(args.Constraints)
+	return c.setConstraintsResults(args.Constraints)
 }
 
 // SetEnvironmentConstraints sets the constraints for the environment.
-func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) error {
-	return c.api.state.SetEnvironConstraints(args.Constraints)
+// Constraints not supported by the environment's provider are set
+// regardless, and reported in the result.
+func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) (params.SetConstraintsResults, error) {
+	if err := c.api.state.SetEnvironConstraints(args.Constraints); err != nil {
+		return params.SetConstraintsResults{}, err
+	}
+	return c.setConstraintsResults(args.Constraints)
+}
+
+// setConstraintsResults returns the results of setting the given
+// constraints, naming those the environment's provider does not support.
+func (c *Client) setConstraintsResults(cons constraints.Value) (params.SetConstraintsResults, error) {
+	unsupported, err := c.api.state.ValidateConstraints(cons)
+	if len(unsupported) == 0 && err != nil {
+		return params.SetConstraintsResults{}, err
+	}
+	return params.SetConstraintsResults{Unsupported: unsupported}, nil
 }
 
 // AddRelation adds a relation between the specified endpoints and returns the relation info.
@@ -563,6 +816,30 @@ func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults
 	return params.AddRelationResults{Endpoints: outEps}, nil
 }
 
+// InferEndpoints resolves the specified, possibly partial, endpoint
+// names to the endpoints of the relation they refer to, without
+// adding it. If the names are ambiguous, the keys of all the
+// relations they could refer to are returned along with the error.
+func (c *Client) InferEndpoints(args params.AddRelation) (params.InferEndpointsResults, error) {
+	var results params.InferEndpointsResults
+	eps, err := c.api.state.InferEndpoints(args.Endpoints)
+	if ambiguous, ok := err.(*state.AmbiguousRelationError); ok {
+		results.Candidates = ambiguous.Candidates
+		results.Error = common.ServerError(err)
+		return results, nil
+	} else if err != nil {
+		return results, err
+	}
+	results.Endpoints = make([]params.Endpoint, len(eps))
+	for i, ep := range eps {
+		results.Endpoints[i] = params.Endpoint{
+			ServiceName: ep.ServiceName,
+			Relation:    ep.Relation,
+		}
+	}
+	return results, nil
+}
+
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(args params.DestroyRelation) error {
 	eps, err := c.api.state.InferEndpoints(args.Endpoints)
@@ -576,6 +853,57 @@ func (c *Client) DestroyRelation(args params.DestroyRelation) error {
 	return rel.Destroy()
 }
 
+// GetRelation returns the details of the relation between the
+// specified endpoints.
+func (c *Client) GetRelation(args params.GetRelation) (params.RelationDetails, error) {
+	eps, err := c.api.state.InferEndpoints(args.Endpoints)
+	if err != nil {
+		return params.RelationDetails{}, err
+	}
+	rel, err := c.api.state.EndpointsRelation(eps...)
+	if err != nil {
+		return params.RelationDetails{}, err
+	}
+	return relationDetails(rel), nil
+}
+
+// OrphanedRelationSettings reports the keys of relation unit settings
+// left behind by relations that no longer exist.
+func (c *Client) OrphanedRelationSettings() (params.OrphanedRelationSettingsResults, error) {
+	keys, err := c.api.state.OrphanedRelationSettings()
+	if err != nil {
+		return params.OrphanedRelationSettingsResults{}, err
+	}
+	return params.OrphanedRelationSettingsResults{Keys: keys}, nil
+}
+
+// CheckConsistency reports inconsistencies in state. If args.Repair
+// is set, inconsistencies that can be repaired safely are repaired.
+func (c *Client) CheckConsistency(args params.CheckConsistency) (params.CheckConsistencyResults, error) {
+	found, err := c.api.state.CheckConsistency()
+	if err != nil {
+		return params.CheckConsistencyResults{}, err
+	}
+	results := params.CheckConsistencyResults{
+		Inconsistencies: make([]params.Inconsistency, len(found)),
+	}
+	for i, inc := range found {
+		result := params.Inconsistency{
+			Kind:       string(inc.Kind),
+			Id:         inc.Id,
+			Detail:     inc.Detail,
+			Repairable: inc.Repairable(),
+		}
+		if args.Repair && inc.Repairable() {
+			err := c.api.state.RepairInconsistency(inc)
+			result.Repaired = err == nil
+			result.Error = common.ServerError(err)
+		}
+		results.Inconsistencies[i] = result
+	}
+	return results, nil
+}
+
 // AddMachines adds new machines with the supplied parameters.
 func (c *Client) AddMachines(args params.AddMachines) (params.AddMachinesResults, error) {
 	return c.AddMachinesV2(args)
@@ -586,8 +914,9 @@ func (c *Client) AddMachinesV2(args params.AddMachines) (params.AddMachinesResul
 	results := params.AddMachinesResults{
 		Machines: make([]params.AddMachinesResult, len(args.MachineParams)),
 	}
+	archChecker := newArchChecker(c.api.state)
 	for i, p := range args.MachineParams {
-		m, err := c.addOneMachine(p)
+		m, err := c.addOneMachine(p, archChecker)
 		results.Machines[i].Error = common.ServerError(err)
 		if err == nil {
 			results.Machines[i].Machine = m.Id()
@@ -601,7 +930,7 @@ func (c *Client) InjectMachines(args params.AddMachines) (params.AddMachinesResu
 	return c.AddMachines(args)
 }
 
-func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error) {
+func (c *Client) addOneMachine(p params.AddMachineParams, archChecker *archChecker) (*state.Machine, error) {
 	if p.ParentId != "" && p.ContainerType == "" {
 		return nil, fmt.Errorf("parent machine specified without container type")
 	}
@@ -636,6 +965,15 @@ func (c *Client) addOneMachine(p params.AddMachineParams) (*state.Machine, error
 		p.Series = config.PreferredSeries(conf)
 	}
 
+	if p.ContainerType == "" && p.InstanceId == "" {
+		// Containers run on the architecture of their host, and
+		// injected machines already exist; only machines still to
+		// be provisioned need tools for their constrained arch.
+		if err := archChecker.check(p.Series, p.Constraints); err != nil {
+			return nil, err
+		}
+	}
+
 	var placementDirective string
 	if p.Placement != nil {
 		env, err := c.api.state.Environment()
@@ -698,6 +1036,25 @@ func (c *Client) ProvisioningScript(args params.ProvisioningScriptParams) (param
 	return result, err
 }
 
+// MachinesWithJob returns the ids of all machines that have
+// been assigned the given job.
+func (c *Client) MachinesWithJob(args params.MachinesWithJob) (params.MachinesWithJobResults, error) {
+	var results params.MachinesWithJobResults
+	job, err := state.MachineJobFromParams(args.Job)
+	if err != nil {
+		return results, err
+	}
+	machines, err := c.api.state.MachinesWithJob(job)
+	if err != nil {
+		return results, err
+	}
+	results.Machines = make([]string, len(machines))
+	for i, machine := range machines {
+		results.Machines[i] = machine.Id()
+	}
+	return results, nil
+}
+
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(args params.DestroyMachines) error {
 	var errs []string
@@ -758,6 +1115,7 @@ func (c *Client) EnvironmentInfo() (api.EnvironmentInfo, error) {
 		ProviderType:  conf.Type(),
 		Name:          conf.Name(),
 		UUID:          env.UUID(),
+		Phase:         string(env.Phase()),
 	}
 	return info, nil
 }
@@ -876,11 +1234,167 @@ func (c *Client) EnvironmentUnset(args params.EnvironmentUnset) error {
 	return c.api.state.UpdateEnvironConfig(nil, args.Keys, nil)
 }
 
-// SetEnvironAgentVersion sets the environment agent version.
+// SetEnvironAgentVersion sets the environment agent version. It
+// fails if tools for the new version are not available for every
+// series and architecture that agents are running on.
 func (c *Client) SetEnvironAgentVersion(args params.SetEnvironAgentVersion) error {
+	if err := c.checkToolsAvailable(args.Version); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironAgentVersion(args.Version)
 }
 
+// StageAgentVersion starts the download phase of an upgrade to the
+// given agent version: machine agents download and verify the tools
+// without restarting, until ActivateStagedUpgrade is called. It fails
+// for the same reasons as SetEnvironAgentVersion.
+func (c *Client) StageAgentVersion(args params.SetEnvironAgentVersion) error {
+	if err := c.checkToolsAvailable(args.Version); err != nil {
+		return err
+	}
+	return c.api.state.StageAgentVersion(args.Version)
+}
+
+// ActivateStagedUpgrade upgrades the environment to the staged agent
+// version. It fails unless every machine agent has downloaded the
+// staged tools.
+func (c *Client) ActivateStagedUpgrade() error {
+	return c.api.state.ActivateStagedUpgrade()
+}
+
+// archChecker checks that tools are available for the architectures
+// that new machines require. It looks up the environment constraints
+// and the available tools at most once, so a single checker should be
+// used for all the machines added by one API call.
+type archChecker struct {
+	st *state.State
+
+	envCons *constraints.Value
+
+	// tools holds the tools of the environment's agent version,
+	// once they have been found.
+	tools     coretools.List
+	toolsRead bool
+}
+
+func newArchChecker(st *state.State) *archChecker {
+	return &archChecker{st: st}
+}
+
+// check returns a not found error if there are no tools of the
+// environment's agent version for the given series and the
+// architecture required by cons or, failing that, by the environment
+// constraints. Nothing is checked if no architecture is required.
+func (ac *archChecker) check(series string, cons constraints.Value) error {
+	arch := cons.Arch
+	if arch == nil {
+		if ac.envCons == nil {
+			envCons, err := ac.st.EnvironConstraints()
+			if err != nil {
+				return err
+			}
+			ac.envCons = &envCons
+		}
+		arch = ac.envCons.Arch
+	}
+	if arch == nil || *arch == "" {
+		return nil
+	}
+	if !ac.toolsRead {
+		if err := ac.readTools(); err != nil {
+			return err
+		}
+	}
+	if _, err := ac.tools.Match(coretools.Filter{Series: series, Arch: *arch}); err == coretools.ErrNoMatches {
+		return errors.NotFoundf("tools for series %q and architecture %q", series, *arch)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// readTools finds all the tools of the environment's agent version.
+func (ac *archChecker) readTools() error {
+	envConfig, err := ac.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	agentVersion, ok := envConfig.AgentVersion()
+	if !ok {
+		return fmt.Errorf("no agent version set in environment configuration")
+	}
+	env, err := environs.New(envConfig)
+	if err != nil {
+		return err
+	}
+	filter := coretools.Filter{Number: agentVersion}
+	tools, err := envtools.FindTools(env, agentVersion.Major, agentVersion.Minor, filter, envtools.DoNotAllowRetry)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	ac.tools = tools
+	ac.toolsRead = true
+	return nil
+}
+
+// checkToolsAvailable returns a not found error if tools of the given
+// version are not available for every series and architecture of the
+// tools used by machine agents in the environment.
+func (c *Client) checkToolsAvailable(vers version.Number) error {
+	machines, err := c.api.state.AllMachines()
+	if err != nil {
+		return err
+	}
+	needed := make(map[string]version.Binary)
+	for _, m := range machines {
+		agentTools, err := m.AgentTools()
+		if errors.IsNotFound(err) {
+			// The agent has not started yet; it will
+			// download tools of the new version anyway.
+			continue
+		} else if err != nil {
+			return err
+		}
+		binary := version.Binary{
+			Number: vers,
+			Series: agentTools.Version.Series,
+			Arch:   agentTools.Version.Arch,
+		}
+		needed[binary.String()] = binary
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+	envConfig, err := c.api.state.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	env, err := environs.New(envConfig)
+	if err != nil {
+		return err
+	}
+	filter := coretools.Filter{Number: vers}
+	list, err := envtools.FindTools(env, vers.Major, vers.Minor, filter, envtools.DoNotAllowRetry)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	available := make(map[string]bool)
+	for _, t := range list {
+		available[t.Version.String()] = true
+	}
+	var missing []string
+	for name := range needed {
+		if !available[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.NotFoundf("tools %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // FindTools returns a List containing all tools matching the given parameters.
 func (c *Client) FindTools(args params.FindToolsParams) (params.FindToolsResults, error) {
 	result := params.FindToolsResults{}
@@ -1047,15 +1561,136 @@ func CharmArchiveName(name string, revision int) (string, error) {
 	return charm.Quote(fmt.Sprintf("%s-%d-%s", name, revision, uuid)), nil
 }
 
-// RetryProvisioning marks a provisioning error as transient on the machines.
-func (c *Client) RetryProvisioning(p params.Entities) (params.ErrorResults, error) {
-	entityStatus := make([]params.EntityStatus, len(p.Entities))
+// RetryProvisioning marks a provisioning error as transient on the machines,
+// first replacing their constraints and placement directive if the
+// arguments specify them.
+func (c *Client) RetryProvisioning(p params.RetryProvisioning) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(p.Entities)),
+	}
+	var entityStatus []params.EntityStatus
+	var indices []int
 	for i, entity := range p.Entities {
-		entityStatus[i] = params.EntityStatus{Tag: entity.Tag, Data: params.StatusData{"transient": true}}
+		if err := c.updateProvisioningArgs(entity.Tag, p); err != nil {
+			result.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		entityStatus = append(entityStatus, params.EntityStatus{
+			Tag:  entity.Tag,
+			Data: params.StatusData{"transient": true},
+		})
+		indices = append(indices, i)
 	}
-	return c.api.statusSetter.UpdateStatus(params.SetStatus{
+	statusResults, err := c.api.statusSetter.UpdateStatus(params.SetStatus{
 		Entities: entityStatus,
 	})
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, statusResult := range statusResults.Results {
+		result.Results[indices[i]] = statusResult
+	}
+	return result, nil
+}
+
+// RotateAgentPasswords requires the agents of the given machines and
+// units to change their passwords the next time they connect.
+func (c *Client) RotateAgentPasswords(args params.Entities) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		err := c.rotateAgentPassword(entity.Tag)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (c *Client) rotateAgentPassword(tag string) error {
+	entity, err := c.api.state.FindEntity(tag)
+	if err != nil {
+		return err
+	}
+	rotator, ok := entity.(state.PasswordRotator)
+	if !ok {
+		return common.NotSupportedError(tag, "password rotation")
+	}
+	return rotator.RequirePasswordRotation()
+}
+
+// HardwareCharacteristics returns the hardware characteristics
+// recorded for each of the given machines when it was provisioned.
+func (c *Client) HardwareCharacteristics(args params.Entities) (params.HardwareCharacteristicsResults, error) {
+	result := params.HardwareCharacteristicsResults{
+		Results: make([]params.HardwareCharacteristicsResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		machine, err := c.machineFromTag(entity.Tag)
+		if err == nil {
+			result.Results[i].Characteristics, err = machine.HardwareCharacteristics()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// updateProvisioningArgs sets the constraints and placement directive
+// given in p on the machine with the given tag, if any were given.
+func (c *Client) updateProvisioningArgs(tag string, p params.RetryProvisioning) error {
+	if p.Constraints == nil && p.Placement == "" {
+		return nil
+	}
+	machine, err := c.machineFromTag(tag)
+	if err != nil {
+		return err
+	}
+	status, _, _, err := machine.Status()
+	if err != nil {
+		return err
+	}
+	if status != params.StatusError {
+		return fmt.Errorf("machine %q is not in an error state", tag)
+	}
+	if p.Constraints != nil {
+		if err := machine.SetConstraints(*p.Constraints); err != nil {
+			return err
+		}
+	}
+	if p.Placement != "" {
+		if err := machine.SetPlacement(p.Placement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrepareSeriesUpgrade starts an in-place upgrade of a machine's OS
+// series, causing its agent to stop the machine's unit agents.
+func (c *Client) PrepareSeriesUpgrade(args params.PrepareSeriesUpgrade) error {
+	machine, err := c.machineFromTag(args.Tag)
+	if err != nil {
+		return err
+	}
+	return machine.PrepareSeriesUpgrade(args.Series)
+}
+
+// CompleteSeriesUpgrade records that a machine's OS has been upgraded
+// to the series passed to PrepareSeriesUpgrade, allowing its agent to
+// restart the machine's unit agents.
+func (c *Client) CompleteSeriesUpgrade(args params.Entity) error {
+	machine, err := c.machineFromTag(args.Tag)
+	if err != nil {
+		return err
+	}
+	return machine.CompleteSeriesUpgrade()
+}
+
+func (c *Client) machineFromTag(tag string) (*state.Machine, error) {
+	machineTag, err := names.ParseMachineTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return c.api.state.Machine(machineTag.Id())
 }
 
 // APIHostPorts returns the API host/port addresses stored in state.
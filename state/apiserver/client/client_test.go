@@ -33,6 +33,7 @@ import (
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver/client"
 	"github.com/juju/juju/state/presence"
+	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/testing/factory"
 	"github.com/juju/juju/version"
@@ -295,6 +296,7 @@ func (s *clientSuite) TestClientEnvironmentInfo(c *gc.C) {
 	c.Assert(info.ProviderType, gc.Equals, conf.Type())
 	c.Assert(info.Name, gc.Equals, conf.Name())
 	c.Assert(info.UUID, gc.Equals, env.UUID())
+	c.Assert(info.Phase, gc.Equals, "ready")
 }
 
 var clientAnnotationsTests = []struct {
@@ -562,6 +564,28 @@ func (s *clientSuite) setupDestroyMachinesTest(c *gc.C) (*state.Machine, *state.
 	return m0, m1, m2, u
 }
 
+func (s *clientSuite) TestClientMachinesWithJob(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	machines, err := s.APIState.Client().MachinesWithJob(params.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.DeepEquals, []string{"1", "2"})
+	machines, err = s.APIState.Client().MachinesWithJob(params.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.DeepEquals, []string{"0"})
+	machines, err = s.APIState.Client().MachinesWithJob(params.JobManageAPI)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 0)
+
+	_, err = s.APIState.Client().MachinesWithJob("JobDoSomething")
+	c.Assert(err, gc.ErrorMatches, `invalid machine job "JobDoSomething"`)
+}
+
 func (s *clientSuite) TestDestroyMachines(c *gc.C) {
 	m0, m1, m2, u := s.setupDestroyMachinesTest(c)
 
@@ -695,6 +719,65 @@ func (s *clientSuite) TestClientUnitResolvedRetry(c *gc.C) {
 	s.testClientUnitResolved(c, true, state.ResolvedRetryHooks)
 }
 
+func (s *clientSuite) TestClientMoveUnit(c *gc.C) {
+	s.setUpScenario(c)
+	u, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().MoveUnit("wordpress/0", m.Id())
+	c.Assert(err, gc.IsNil)
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	machineId, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, m.Id())
+
+	err = s.APIState.Client().MoveUnit("logging/0", m.Id())
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "logging/0" to machine .*: unit is a subordinate`)
+}
+
+func (s *clientSuite) TestClientScheduledTasks(c *gc.C) {
+	tasks, err := s.APIState.Client().ScheduledTasks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tasks, gc.HasLen, 0)
+
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+	lastRun := time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC)
+	err = task.SetLastRun(lastRun, fmt.Errorf("boom"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.EnsureScheduledTask("backup", 24*time.Hour)
+	c.Assert(err, gc.IsNil)
+
+	tasks, err = s.APIState.Client().ScheduledTasks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tasks, gc.HasLen, 2)
+	c.Assert(tasks[0], gc.DeepEquals, params.ScheduledTask{
+		Name:     "backup",
+		Interval: 24 * time.Hour,
+		Enabled:  true,
+	})
+	c.Assert(tasks[1].Name, gc.Equals, "prune")
+	c.Assert(tasks[1].LastRun.Equal(lastRun), jc.IsTrue)
+	c.Assert(tasks[1].LastError, gc.Equals, "boom")
+}
+
+func (s *clientSuite) TestClientSetScheduledTaskEnabled(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetScheduledTaskEnabled("prune", false)
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsFalse)
+
+	err = s.APIState.Client().SetScheduledTaskEnabled("missing", true)
+	c.Assert(err, gc.ErrorMatches, `scheduled task "missing" not found`)
+}
+
 func (s *clientSuite) TestClientServiceDeployCharmErrors(c *gc.C) {
 	_, restore := makeMockCharmStore()
 	defer restore()
@@ -783,6 +866,29 @@ func (s *clientSuite) TestClientServiceDeployPrincipal(c *gc.C) {
 	s.assertPrincipalDeployed(c, "service", curl, false, bundle, mem4g)
 }
 
+func (s *clientSuite) TestClientServiceDeployArchNotAvailable(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	curl, _ := addCharm(c, store, "dummy")
+	ppc64 := constraints.MustParse("arch=ppc64")
+	err := s.APIState.Client().ServiceDeploy(
+		curl.String(), "service", 1, "", ppc64, "",
+	)
+	c.Assert(err, gc.ErrorMatches, `tools for series "precise" and architecture "ppc64" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+	_, err = s.State.Service("service")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	vers := version.Current
+	vers.Series = "precise"
+	vers.Arch = "ppc64"
+	toolstesting.UploadToStorage(c, s.Environ.Storage(), vers)
+	err = s.APIState.Client().ServiceDeploy(
+		curl.String(), "service", 1, "", ppc64, "",
+	)
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *clientSuite) TestClientServiceDeploySubordinate(c *gc.C) {
 	store, restore := makeMockCharmStore()
 	defer restore()
@@ -1156,6 +1262,44 @@ func (s *clientSuite) TestClientServiceSetCharmForce(c *gc.C) {
 	c.Assert(force, gc.Equals, true)
 }
 
+func (s *clientSuite) TestClientServiceSetCharmStaged(c *gc.C) {
+	wpCharm := s.AddTestingCharm(c, "wordpress")
+	service := s.AddTestingService(c, "wordpress", wpCharm)
+	for i := 0; i < 2; i++ {
+		_, err := service.AddUnit()
+		c.Assert(err, gc.IsNil)
+	}
+	curl := wpCharm.URL().WithRevision(wpCharm.Revision() + 1)
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/" + curl.Name)
+	c.Assert(err, gc.IsNil)
+	newCharm, err := s.State.AddCharm(charmtesting.Charms.Dir("wordpress"), curl, bundleURL, "wordpress-sha256")
+	c.Assert(err, gc.IsNil)
+
+	client := s.APIState.Client()
+	err = client.ServiceSetCharmStaged("wordpress", curl.String(), false, []string{"wordpress/0"})
+	c.Assert(err, gc.IsNil)
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	svcURL, _ := service.CharmURL()
+	c.Assert(svcURL, gc.DeepEquals, newCharm.URL())
+	pinnedURL, pinned := service.PinnedUnits()
+	c.Assert(pinnedURL, gc.DeepEquals, wpCharm.URL())
+	c.Assert(pinned, gc.DeepEquals, []string{"wordpress/1"})
+
+	err = client.ServiceReleaseUnits("wordpress", nil, true)
+	c.Assert(err, gc.IsNil)
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	pinnedURL, pinned = service.PinnedUnits()
+	c.Assert(pinnedURL, gc.IsNil)
+	c.Assert(pinned, gc.HasLen, 0)
+
+	err = client.ServiceReleaseUnits("wordpress", []string{"wordpress/1"}, false)
+	c.Assert(err, gc.ErrorMatches, `cannot release units of service "wordpress": no staged upgrade in progress`)
+	err = client.ServiceSetCharmStaged("wordpress", "local:quantal/wordpress-999", false, nil)
+	c.Assert(err, gc.ErrorMatches, `charm "local:quantal/wordpress-999" not found`)
+}
+
 func (s *clientSuite) TestClientServiceSetCharmInvalidService(c *gc.C) {
 	_, restore := makeMockCharmStore()
 	defer restore()
@@ -1398,8 +1542,9 @@ func (s *clientSuite) TestClientSetServiceConstraints(c *gc.C) {
 	// Update constraints for the service.
 	cons, err := constraints.Parse("mem=4096", "cpu-cores=2")
 	c.Assert(err, gc.IsNil)
-	err = s.APIState.Client().SetServiceConstraints("dummy", cons)
+	unsupported, err := s.APIState.Client().SetServiceConstraints("dummy", cons)
 	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.HasLen, 0)
 
 	// Ensure the constraints have been correctly updated.
 	obtained, err := service.Constraints()
@@ -1407,6 +1552,40 @@ func (s *clientSuite) TestClientSetServiceConstraints(c *gc.C) {
 	c.Assert(obtained, gc.DeepEquals, cons)
 }
 
+func (s *clientSuite) TestClientSetServiceConstraintsUnsupported(c *gc.C) {
+	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+
+	// The dummy provider does not support cpu-power, but it is set
+	// anyway and reported.
+	cons, err := constraints.Parse("mem=4096", "cpu-power=100")
+	c.Assert(err, gc.IsNil)
+	unsupported, err := s.APIState.Client().SetServiceConstraints("dummy", cons)
+	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.DeepEquals, []string{"cpu-power"})
+
+	obtained, err := service.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(obtained, gc.DeepEquals, cons)
+}
+
+func (s *clientSuite) TestClientSetServiceConstraintsConflicting(c *gc.C) {
+	s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
+
+	// The dummy provider does not allow instance-type with mem.
+	cons, err := constraints.Parse("mem=4096", "instance-type=foo")
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().SetServiceConstraints("dummy", cons)
+	c.Assert(err, gc.ErrorMatches, `.*ambiguous constraints: "instance-type" overlaps with "mem"`)
+}
+
+func (s *clientSuite) TestClientSetEnvironmentConstraintsUnsupported(c *gc.C) {
+	cons, err := constraints.Parse("cpu-power=100")
+	c.Assert(err, gc.IsNil)
+	unsupported, err := s.APIState.Client().SetEnvironmentConstraints(cons)
+	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.DeepEquals, []string{"cpu-power"})
+}
+
 func (s *clientSuite) TestClientGetServiceConstraints(c *gc.C) {
 	service := s.AddTestingService(c, "dummy", s.AddTestingCharm(c, "dummy"))
 
@@ -1426,8 +1605,9 @@ func (s *clientSuite) TestClientSetEnvironmentConstraints(c *gc.C) {
 	// Set constraints for the environment.
 	cons, err := constraints.Parse("mem=4096", "cpu-cores=2")
 	c.Assert(err, gc.IsNil)
-	err = s.APIState.Client().SetEnvironmentConstraints(cons)
+	unsupported, err := s.APIState.Client().SetEnvironmentConstraints(cons)
 	c.Assert(err, gc.IsNil)
+	c.Assert(unsupported, gc.HasLen, 0)
 
 	// Ensure the constraints have been correctly updated.
 	obtained, err := s.State.EnvironConstraints()
@@ -1460,6 +1640,222 @@ func (s *clientSuite) TestClientServiceCharmRelations(c *gc.C) {
 	})
 }
 
+func (s *clientSuite) TestClientServiceRelations(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().ServiceRelations("blah")
+	c.Assert(err, gc.ErrorMatches, `service "blah" not found`)
+
+	relations, err := s.APIState.Client().ServiceRelations("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(relations, gc.HasLen, 0)
+
+	relations, err = s.APIState.Client().ServiceRelations("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(relations, gc.HasLen, 1)
+	s.assertRelationDetails(c, relations[0], "logging", "wordpress")
+}
+
+func (s *clientSuite) TestClientWatchServiceRelations(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().WatchServiceRelations("blah")
+	c.Assert(err, gc.ErrorMatches, `service "blah" not found`)
+
+	w, err := s.APIState.Client().WatchServiceRelations("wordpress")
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.BackingState, w)
+	// Initial event.
+	wc.AssertChange("logging:logging-directory wordpress:logging-dir")
+	wc.AssertNoChange()
+
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(rel.String())
+	wc.AssertNoChange()
+
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(rel.String())
+	wc.AssertNoChange()
+}
+
+func (s *clientSuite) TestClientGetRelation(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().GetRelation("wordpress", "mysql")
+	c.Assert(err, gc.ErrorMatches, `relation "wordpress:db mysql:server" not found`)
+
+	details, err := s.APIState.Client().GetRelation("wordpress", "logging")
+	c.Assert(err, gc.IsNil)
+	s.assertRelationDetails(c, *details, "logging", "wordpress")
+}
+
+func (s *clientSuite) TestClientOrphanedRelationSettings(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+
+	keys, err := s.APIState.Client().OrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(keys, gc.HasLen, 0)
+
+	// Remove the relation; its settings are left
+	// behind until the cleanup runs.
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = ru.LeaveScope()
+	c.Assert(err, gc.IsNil)
+	keys, err = s.APIState.Client().OrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(keys, gc.DeepEquals, []string{fmt.Sprintf("r#%d#provider#mysql/0", rel.Id())})
+}
+
+func (s *clientSuite) TestClientCheckConsistency(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+
+	found, err := s.APIState.Client().CheckConsistency(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 0)
+
+	// Remove the relation, leaving its settings behind.
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = ru.LeaveScope()
+	c.Assert(err, gc.IsNil)
+	key := fmt.Sprintf("r#%d#provider#mysql/0", rel.Id())
+	expected := params.Inconsistency{
+		Kind:       "relation-settings-orphaned",
+		Id:         key,
+		Detail:     fmt.Sprintf("relation settings %q belong to a missing relation", key),
+		Repairable: true,
+	}
+	found, err = s.APIState.Client().CheckConsistency(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.DeepEquals, []params.Inconsistency{expected})
+
+	expected.Repaired = true
+	found, err = s.APIState.Client().CheckConsistency(true)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.DeepEquals, []params.Inconsistency{expected})
+	found, err = s.APIState.Client().CheckConsistency(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 0)
+}
+
+func (s *clientSuite) assertRelationDetails(c *gc.C, details params.RelationDetails, serviceNames ...string) {
+	eps, err := s.State.InferEndpoints(serviceNames)
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.EndpointsRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	c.Assert(details.Id, gc.Equals, rel.Id())
+	c.Assert(details.Key, gc.Equals, rel.String())
+	c.Assert(details.Life, gc.Equals, params.Alive)
+	c.Assert(details.Interface, gc.Equals, "logging")
+	c.Assert(details.Scope, gc.Equals, charm.ScopeContainer)
+	var expectEndpoints []params.Endpoint
+	for _, ep := range rel.Endpoints() {
+		expectEndpoints = append(expectEndpoints, params.Endpoint{
+			ServiceName: ep.ServiceName,
+			Relation:    ep.Relation,
+		})
+	}
+	c.Assert(details.Endpoints, jc.DeepEquals, expectEndpoints)
+}
+
+func (s *clientSuite) TestClientInferEndpoints(c *gc.C) {
+	s.setUpScenario(c)
+	s.AddTestingService(c, "ms", s.AddTestingCharm(c, "mysql-alternative"))
+
+	eps, candidates, err := s.APIState.Client().InferEndpoints("wordpress", "mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(candidates, gc.HasLen, 0)
+	c.Assert(eps, gc.HasLen, 2)
+	c.Assert(eps[0].ServiceName, gc.Equals, "wordpress")
+	c.Assert(eps[0].Relation.Name, gc.Equals, "db")
+	c.Assert(eps[1].ServiceName, gc.Equals, "mysql")
+	c.Assert(eps[1].Relation.Name, gc.Equals, "server")
+	// Inferring endpoints does not add the relation.
+	_, err = s.APIState.Client().GetRelation("wordpress", "mysql")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	eps, candidates, err = s.APIState.Client().InferEndpoints("wordpress", "ms")
+	c.Assert(err, gc.ErrorMatches, `ambiguous relation: "wordpress ms" could refer to "wordpress:db ms:dev"; "wordpress:db ms:prod"`)
+	c.Assert(err, jc.Satisfies, params.IsCodeAmbiguousRelation)
+	c.Assert(eps, gc.HasLen, 0)
+	c.Assert(candidates, gc.DeepEquals, []string{"wordpress:db ms:dev", "wordpress:db ms:prod"})
+
+	_, _, err = s.APIState.Client().InferEndpoints("wordpress", "nosuch")
+	c.Assert(err, gc.ErrorMatches, `service "nosuch" not found`)
+}
+
+func (s *clientSuite) TestClientAddRelationAmbiguous(c *gc.C) {
+	s.setUpScenario(c)
+	s.AddTestingService(c, "ms", s.AddTestingCharm(c, "mysql-alternative"))
+	_, err := s.APIState.Client().AddRelation("wordpress", "ms")
+	c.Assert(err, jc.Satisfies, params.IsCodeAmbiguousRelation)
+}
+
+func (s *clientSuite) TestClientRotateAgentPasswords(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	results, err := s.APIState.Client().RotateAgentPasswords(
+		machine.Tag().String(), "machine-42", "service-wordpress",
+	)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, []params.ErrorResult{
+		{},
+		{&params.Error{Message: "machine 42 not found", Code: params.CodeNotFound}},
+		{&params.Error{Message: `service "wordpress" not found`, Code: params.CodeNotFound}},
+	})
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.PasswordRotationRequired(), jc.IsTrue)
+}
+
+func (s *clientSuite) TestClientHardwareCharacteristics(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	arch := "amd64"
+	mem := uint64(4096)
+	hc := &instance.HardwareCharacteristics{Arch: &arch, Mem: &mem}
+	err = machine.SetProvisioned("i-am", "fake_nonce", hc)
+	c.Assert(err, gc.IsNil)
+	unprovisioned, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.APIState.Client().HardwareCharacteristics(
+		machine.Tag().String(), unprovisioned.Tag().String(), "machine-42", "unit-wordpress-0",
+	)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 4)
+	c.Assert(results[0], jc.DeepEquals, params.HardwareCharacteristicsResult{Characteristics: hc})
+	c.Assert(results[1].Error, gc.ErrorMatches, fmt.Sprintf("instance data for machine %s not found", unprovisioned.Id()))
+	c.Assert(results[1].Error, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(results[2].Error, gc.DeepEquals, &params.Error{Message: "machine 42 not found", Code: params.CodeNotFound})
+	c.Assert(results[3].Error, gc.ErrorMatches, `"unit-wordpress-0" is not a valid machine tag`)
+}
+
 func (s *clientSuite) TestClientPublicAddressErrors(c *gc.C) {
 	s.setUpScenario(c)
 	_, err := s.APIState.Client().PublicAddress("wordpress")
@@ -1502,6 +1898,30 @@ func (s *clientSuite) TestClientPublicAddressUnit(c *gc.C) {
 	c.Assert(addr, gc.Equals, "public")
 }
 
+func (s *clientSuite) TestClientWatchPublicAddress(c *gc.C) {
+	s.setUpScenario(c)
+	w, err := s.APIState.Client().WatchPublicAddress("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+	// Initial event.
+	wc.AssertOneChange()
+
+	m1, err := s.State.Machine("1")
+	c.Assert(err, gc.IsNil)
+	err = m1.SetAddresses(network.NewAddress("public", network.ScopePublic))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
+
+func (s *clientSuite) TestClientWatchPublicAddressErrors(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().WatchPublicAddress("wordpress")
+	c.Assert(err, gc.ErrorMatches, `"wordpress" is not a valid unit name`)
+	_, err = s.APIState.Client().WatchPublicAddress("wordpress/42")
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/42" not found`)
+}
+
 func (s *clientSuite) TestClientPrivateAddressErrors(c *gc.C) {
 	s.setUpScenario(c)
 	_, err := s.APIState.Client().PrivateAddress("wordpress")
@@ -1593,6 +2013,87 @@ func (s *clientSuite) TestClientSetEnvironAgentVersion(c *gc.C) {
 	c.Assert(agentVersion, gc.Equals, "9.8.7")
 }
 
+func (s *clientSuite) TestClientSetEnvironAgentVersionMissingTools(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	current := version.Current
+	current.Series = "quantal"
+	current.Arch = "amd64"
+	err = machine.SetAgentVersion(current)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetEnvironAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.ErrorMatches, "tools 9.8.7-quantal-amd64 not found")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	toolstesting.UploadToStorage(c, s.Environ.Storage(), version.MustParseBinary("9.8.7-quantal-amd64"))
+	err = s.APIState.Client().SetEnvironAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *clientSuite) TestClientSetEnvironAgentVersionUpgradeInProgress(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAgentVersion(version.MustParseBinary("9.9.9-quantal-amd64"))
+	c.Assert(err, gc.IsNil)
+	toolstesting.UploadToStorage(c, s.Environ.Storage(), version.MustParseBinary("9.8.7-quantal-amd64"))
+
+	err = s.APIState.Client().SetEnvironAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.ErrorMatches, "some agents have not upgraded to the current environment version .*: machine-0")
+	c.Assert(err, jc.Satisfies, params.IsCodeUpgradeInProgress)
+}
+
+func (s *clientSuite) TestClientSetEnvironAgentVersionMajorDowngrade(c *gc.C) {
+	err := s.APIState.Client().SetEnvironAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().SetEnvironAgentVersion(version.MustParse("8.9.0"))
+	c.Assert(err, gc.ErrorMatches, "cannot change agent version from 9.8.7 to 8.9.0: major version downgrades are not supported")
+	c.Assert(err, jc.Satisfies, params.IsCodeVersionDowngrade)
+}
+
+func (s *clientSuite) TestClientStageAgentVersion(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	current := version.Current
+	current.Series = "quantal"
+	current.Arch = "amd64"
+	err = machine.SetAgentVersion(current)
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.ErrorMatches, "tools 9.8.7-quantal-amd64 not found")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	toolstesting.UploadToStorage(c, s.Environ.Storage(), version.MustParseBinary("9.8.7-quantal-amd64"))
+	err = s.APIState.Client().StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.Version(), gc.Equals, version.MustParse("9.8.7"))
+
+	// The upgrade cannot be activated until the agent is ready.
+	err = s.APIState.Client().ActivateStagedUpgrade()
+	c.Assert(err, gc.ErrorMatches, "agents not ready for upgrade to 9.8.7: machine-0")
+	c.Assert(err, jc.Satisfies, params.IsCodeAgentsNotReady)
+
+	err = staged.SetAgentReady("machine-0", version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().ActivateStagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	agentVersion, found := envConfig.AllAttrs()["agent-version"]
+	c.Assert(found, jc.IsTrue)
+	c.Assert(agentVersion, gc.Equals, "9.8.7")
+}
+
+func (s *clientSuite) TestClientActivateStagedUpgradeNotStaged(c *gc.C) {
+	err := s.APIState.Client().ActivateStagedUpgrade()
+	c.Assert(err, gc.ErrorMatches, "staged upgrade not found")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
 func (s *clientSuite) TestClientEnvironmentSetCannotChangeAgentVersion(c *gc.C) {
 	args := map[string]interface{}{"agent-version": "9.9.9"}
 	err := s.APIState.Client().EnvironmentSet(args)
@@ -1735,6 +2236,31 @@ func (s *clientSuite) TestClientAddMachinesWithConstraints(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientAddMachinesArchNotAvailable(c *gc.C) {
+	err := s.State.SetEnvironConstraints(constraints.MustParse("arch=ppc64"))
+	c.Assert(err, gc.IsNil)
+	apiParams := []params.AddMachineParams{{
+		Jobs: []params.MachineJob{params.JobHostUnits},
+	}, {
+		Jobs:        []params.MachineJob{params.JobHostUnits},
+		Constraints: constraints.MustParse("arch=i386"),
+	}}
+	machines, err := s.APIState.Client().AddMachines(apiParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 2)
+	c.Assert(machines[0].Error, gc.ErrorMatches, `tools for series "precise" and architecture "ppc64" not found`)
+	c.Assert(machines[1].Error, gc.ErrorMatches, `tools for series "precise" and architecture "i386" not found`)
+
+	vers := version.Current
+	vers.Series = "precise"
+	vers.Arch = "ppc64"
+	toolstesting.UploadToStorage(c, s.Environ.Storage(), vers)
+	machines, err = s.APIState.Client().AddMachines(apiParams[:1])
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+	c.Assert(machines[0].Error, gc.IsNil)
+}
+
 func (s *clientSuite) TestClientAddMachinesWithPlacement(c *gc.C) {
 	apiParams := make([]params.AddMachineParams, 4)
 	for i := range apiParams {
@@ -2221,6 +2747,77 @@ func (s *clientSuite) TestRetryProvisioning(c *gc.C) {
 	c.Assert(data["transient"], gc.Equals, true)
 }
 
+func (s *clientSuite) TestRetryProvisioningWithArgs(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetStatus(params.StatusError, "error", nil)
+	c.Assert(err, gc.IsNil)
+	cons := constraints.MustParse("mem=4G")
+	results, err := s.APIState.Client().RetryProvisioningWithArgs(&cons, "zone=a", machine.Tag().String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Error, gc.IsNil)
+
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	mcons, err := machine.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mcons, gc.DeepEquals, cons)
+	c.Assert(machine.Placement(), gc.Equals, "zone=a")
+	_, _, data, err := machine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(data["transient"], gc.Equals, true)
+}
+
+func (s *clientSuite) TestRetryProvisioningWithArgsNotInError(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	cons := constraints.MustParse("mem=4G")
+	results, err := s.APIState.Client().RetryProvisioningWithArgs(&cons, "", machine.Tag().String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Error, gc.ErrorMatches, `machine ".*" is not in an error state`)
+
+	// The constraints must be left alone.
+	mcons, err := machine.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mcons, gc.DeepEquals, constraints.Value{})
+}
+
+func (s *clientSuite) TestSeriesUpgrade(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	client := s.APIState.Client()
+
+	err = client.PrepareSeriesUpgrade(machine.Id(), "trusty")
+	c.Assert(err, gc.IsNil)
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, series := machine.UpgradeSeriesStatus()
+	c.Assert(status, gc.Equals, params.UpgradeSeriesPreparing)
+	c.Assert(series, gc.Equals, "trusty")
+
+	err = client.CompleteSeriesUpgrade(machine.Id())
+	c.Assert(err, gc.ErrorMatches, `cannot complete series upgrade of machine .*: machine is not yet running series "trusty"`)
+
+	err = machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+	c.Assert(err, gc.IsNil)
+	err = client.CompleteSeriesUpgrade(machine.Id())
+	c.Assert(err, gc.IsNil)
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Series(), gc.Equals, "trusty")
+	status, _ = machine.UpgradeSeriesStatus()
+	c.Assert(status, gc.Equals, params.UpgradeSeriesNotStarted)
+}
+
+func (s *clientSuite) TestPrepareSeriesUpgradeMachineNotFound(c *gc.C) {
+	err := s.APIState.Client().PrepareSeriesUpgrade("42", "trusty")
+	c.Assert(err, gc.ErrorMatches, "machine 42 not found")
+}
+
 func (s *clientSuite) setAgentPresence(c *gc.C, machineId string) *presence.Pinger {
 	m, err := s.BackingState.Machine(machineId)
 	c.Assert(err, gc.IsNil)
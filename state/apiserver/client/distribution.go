@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"sort"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/provider/common"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+// instanceZoneNames returns the names of the available zones in the
+// environment, and the availability zone name of each of the given
+// instances. If the environment does not support availability zones,
+// there are no available zones and all the instance zone names will
+// be empty.
+var instanceZoneNames = func(st *state.State, ids []instance.Id) (available, zoneNames []string, err error) {
+	envConfig, err := st.EnvironConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	env, err := environs.New(envConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	zoned, ok := env.(common.ZonedEnviron)
+	if !ok {
+		return nil, make([]string, len(ids)), nil
+	}
+	zones, err := zoned.AvailabilityZones()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, zone := range zones {
+		if zone.Available() {
+			available = append(available, zone.Name())
+		}
+	}
+	if len(ids) == 0 {
+		return available, nil, nil
+	}
+	zoneNames, err = zoned.InstanceAvailabilityZoneNames(ids)
+	if err != nil && err != environs.ErrPartialInstances {
+		return nil, nil, err
+	}
+	return available, zoneNames, nil
+}
+
+// byUnitCountThenZone orders zones the way the instance distributor
+// prefers them: least populated first, ties broken by zone name.
+type byUnitCountThenZone []params.ZoneUnits
+
+func (b byUnitCountThenZone) Len() int {
+	return len(b)
+}
+
+func (b byUnitCountThenZone) Less(i, j int) bool {
+	switch {
+	case len(b[i].Units) < len(b[j].Units):
+		return true
+	case len(b[i].Units) == len(b[j].Units):
+		return b[i].Zone < b[j].Zone
+	}
+	return false
+}
+
+func (b byUnitCountThenZone) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+// ServiceDistribution returns the provisioned units of the named
+// service grouped by the availability zone of their machines. Every
+// available zone is reported, even if it holds none of the service's
+// units. Zones are ordered by ascending unit count, then by name,
+// which is the order in which new units of the service prefer them.
+// Units whose zone is unknown are reported under the empty zone name.
+func (c *Client) ServiceDistribution(args params.ServiceDistribution) (params.ServiceDistributionResults, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.ServiceDistributionResults{}, err
+	}
+	units, err := service.AllUnits()
+	if err != nil {
+		return params.ServiceDistributionResults{}, err
+	}
+	var unitNames []string
+	var instanceIds []instance.Id
+	for _, unit := range units {
+		machineId, err := unit.AssignedMachineId()
+		if state.IsNotAssigned(err) {
+			continue
+		} else if err != nil {
+			return params.ServiceDistributionResults{}, err
+		}
+		machine, err := c.api.state.Machine(machineId)
+		if err != nil {
+			return params.ServiceDistributionResults{}, err
+		}
+		instanceId, err := machine.InstanceId()
+		if state.IsNotProvisionedError(err) {
+			continue
+		} else if err != nil {
+			return params.ServiceDistributionResults{}, err
+		}
+		unitNames = append(unitNames, unit.Name())
+		instanceIds = append(instanceIds, instanceId)
+	}
+	available, zoneNames, err := instanceZoneNames(c.api.state, instanceIds)
+	if err != nil {
+		return params.ServiceDistributionResults{}, err
+	}
+	unitsByZone := make(map[string][]string)
+	for _, zone := range available {
+		unitsByZone[zone] = []string{}
+	}
+	for i, unitName := range unitNames {
+		unitsByZone[zoneNames[i]] = append(unitsByZone[zoneNames[i]], unitName)
+	}
+	results := params.ServiceDistributionResults{
+		Zones: make([]params.ZoneUnits, 0, len(unitsByZone)),
+	}
+	for zone, names := range unitsByZone {
+		sort.Strings(names)
+		results.Zones = append(results.Zones, params.ZoneUnits{
+			Zone:  zone,
+			Units: names,
+		})
+	}
+	sort.Sort(byUnitCountThenZone(results.Zones))
+	return results, nil
+}
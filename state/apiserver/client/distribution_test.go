@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/instance"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/client"
+)
+
+type distributionSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&distributionSuite{})
+
+func (s *distributionSuite) TestServiceDistributionWithoutZones(c *gc.C) {
+	s.setUpScenario(c)
+	zones, err := s.APIState.Client().ServiceDistribution("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(zones, gc.DeepEquals, []params.ZoneUnits{
+		{Zone: "", Units: []string{"wordpress/0", "wordpress/1"}},
+	})
+}
+
+func (s *distributionSuite) TestServiceDistribution(c *gc.C) {
+	s.setUpScenario(c)
+	zonesByInstance := map[instance.Id]string{
+		"i-machine-1": "az2",
+		"i-machine-2": "az1",
+		"i-machine-3": "az2",
+	}
+	s.PatchValue(client.InstanceZoneNames, func(_ *state.State, ids []instance.Id) ([]string, []string, error) {
+		zoneNames := make([]string, len(ids))
+		for i, id := range ids {
+			zoneNames[i] = zonesByInstance[id]
+		}
+		return []string{"az1", "az2", "az3"}, zoneNames, nil
+	})
+
+	// Zones without units are preferred; equally populated zones
+	// are ordered by name.
+	zones, err := s.APIState.Client().ServiceDistribution("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(zones, gc.DeepEquals, []params.ZoneUnits{
+		{Zone: "az3", Units: []string{}},
+		{Zone: "az1", Units: []string{"wordpress/1"}},
+		{Zone: "az2", Units: []string{"wordpress/0"}},
+	})
+
+	// Add a unit on a provisioned machine in az2, and another
+	// on an unprovisioned machine, which is not reported.
+	wordpress, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	for i := 0; i < 2; i++ {
+		unit, err := wordpress.AddUnit()
+		c.Assert(err, gc.IsNil)
+		m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		if i == 0 {
+			err = m.SetProvisioned(instance.Id("i-"+m.Tag().String()), "fake_nonce", nil)
+			c.Assert(err, gc.IsNil)
+		}
+		err = unit.AssignToMachine(m)
+		c.Assert(err, gc.IsNil)
+	}
+	zones, err = s.APIState.Client().ServiceDistribution("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(zones, gc.DeepEquals, []params.ZoneUnits{
+		{Zone: "az3", Units: []string{}},
+		{Zone: "az1", Units: []string{"wordpress/1"}},
+		{Zone: "az2", Units: []string{"wordpress/0", "wordpress/2"}},
+	})
+}
+
+func (s *distributionSuite) TestServiceDistributionEmptyZones(c *gc.C) {
+	s.setUpScenario(c)
+	s.PatchValue(client.InstanceZoneNames, func(_ *state.State, ids []instance.Id) ([]string, []string, error) {
+		c.Assert(ids, gc.HasLen, 0)
+		return []string{"az2", "az1"}, nil, nil
+	})
+	zones, err := s.APIState.Client().ServiceDistribution("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(zones, gc.DeepEquals, []params.ZoneUnits{
+		{Zone: "az1", Units: []string{}},
+		{Zone: "az2", Units: []string{}},
+	})
+}
+
+func (s *distributionSuite) TestServiceDistributionNoUnits(c *gc.C) {
+	s.setUpScenario(c)
+	zones, err := s.APIState.Client().ServiceDistribution("mysql")
+	c.Assert(err, gc.IsNil)
+	c.Assert(zones, gc.HasLen, 0)
+}
+
+func (s *distributionSuite) TestServiceDistributionServiceNotFound(c *gc.C) {
+	_, err := s.APIState.Client().ServiceDistribution("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
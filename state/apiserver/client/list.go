@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+// ListMachines returns a page of the machines in the environment,
+// with only the requested fields filled in.
+func (c *Client) ListMachines(args params.ListEntities) (params.ListMachinesResults, error) {
+	docs, marker, err := c.api.state.ListMachines(listParams(args))
+	if err != nil {
+		return params.ListMachinesResults{}, err
+	}
+	results := params.ListMachinesResults{
+		Machines: make([]params.MachineSummary, len(docs)),
+		Marker:   marker,
+	}
+	for i, doc := range docs {
+		m := params.MachineSummary{
+			Id:     doc.Id,
+			Series: doc.Series,
+		}
+		if wantField(args, "life") {
+			m.Life = params.Life(doc.Life.String())
+		}
+		for _, job := range doc.Jobs {
+			m.Jobs = append(m.Jobs, job.ToParams())
+		}
+		results.Machines[i] = m
+	}
+	return results, nil
+}
+
+// ListUnits returns a page of the units in the environment,
+// with only the requested fields filled in.
+func (c *Client) ListUnits(args params.ListEntities) (params.ListUnitsResults, error) {
+	docs, marker, err := c.api.state.ListUnits(listParams(args))
+	if err != nil {
+		return params.ListUnitsResults{}, err
+	}
+	results := params.ListUnitsResults{
+		Units:  make([]params.UnitSummary, len(docs)),
+		Marker: marker,
+	}
+	for i, doc := range docs {
+		u := params.UnitSummary{
+			Name:    doc.Name,
+			Service: doc.Service,
+			Series:  doc.Series,
+			Machine: doc.MachineId,
+		}
+		if wantField(args, "life") {
+			u.Life = params.Life(doc.Life.String())
+		}
+		results.Units[i] = u
+	}
+	return results, nil
+}
+
+// ListServices returns a page of the services in the environment,
+// with only the requested fields filled in.
+func (c *Client) ListServices(args params.ListEntities) (params.ListServicesResults, error) {
+	docs, marker, err := c.api.state.ListServices(listParams(args))
+	if err != nil {
+		return params.ListServicesResults{}, err
+	}
+	results := params.ListServicesResults{
+		Services: make([]params.ServiceSummary, len(docs)),
+		Marker:   marker,
+	}
+	for i, doc := range docs {
+		s := params.ServiceSummary{
+			Name:    doc.Name,
+			Exposed: doc.Exposed,
+		}
+		if doc.CharmURL != nil {
+			s.CharmURL = doc.CharmURL.String()
+		}
+		if wantField(args, "life") {
+			s.Life = params.Life(doc.Life.String())
+		}
+		results.Services[i] = s
+	}
+	return results, nil
+}
+
+func listParams(args params.ListEntities) state.ListParams {
+	return state.ListParams{
+		Marker:     args.Marker,
+		Limit:      args.Limit,
+		Descending: args.Descending,
+		Fields:     args.Fields,
+	}
+}
+
+// wantField reports whether the named field was requested. Fields
+// that were not requested are left empty, but the zero value of
+// some, such as life, is meaningful.
+func wantField(args params.ListEntities, name string) bool {
+	if len(args.Fields) == 0 {
+		return true
+	}
+	for _, field := range args.Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
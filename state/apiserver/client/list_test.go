@@ -0,0 +1,80 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+type listSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&listSuite{})
+
+func (s *listSuite) TestListMachines(c *gc.C) {
+	s.setUpScenario(c)
+	machines, marker, err := s.APIState.Client().ListMachines(params.ListEntities{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(machines, gc.DeepEquals, []params.MachineSummary{
+		{Id: "0", Series: "quantal", Life: params.Alive, Jobs: []params.MachineJob{params.JobManageEnviron}},
+		{Id: "1", Series: "quantal", Life: params.Alive, Jobs: []params.MachineJob{params.JobHostUnits}},
+		{Id: "2", Series: "quantal", Life: params.Alive, Jobs: []params.MachineJob{params.JobHostUnits}},
+	})
+}
+
+func (s *listSuite) TestListMachinesPaged(c *gc.C) {
+	s.setUpScenario(c)
+	args := params.ListEntities{Limit: 2, Fields: []string{"series"}}
+	machines, marker, err := s.APIState.Client().ListMachines(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "1")
+	c.Assert(machines, gc.DeepEquals, []params.MachineSummary{
+		{Id: "0", Series: "quantal"},
+		{Id: "1", Series: "quantal"},
+	})
+
+	args.Marker = marker
+	machines, marker, err = s.APIState.Client().ListMachines(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(machines, gc.DeepEquals, []params.MachineSummary{
+		{Id: "2", Series: "quantal"},
+	})
+}
+
+func (s *listSuite) TestListUnitsDescending(c *gc.C) {
+	s.setUpScenario(c)
+	args := params.ListEntities{Descending: true, Fields: []string{"service", "machine"}}
+	units, marker, err := s.APIState.Client().ListUnits(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(units, gc.DeepEquals, []params.UnitSummary{
+		{Name: "wordpress/1", Service: "wordpress", Machine: "2"},
+		{Name: "wordpress/0", Service: "wordpress", Machine: "1"},
+		{Name: "logging/1", Service: "logging"},
+		{Name: "logging/0", Service: "logging"},
+	})
+}
+
+func (s *listSuite) TestListServicesNamesOnly(c *gc.C) {
+	s.setUpScenario(c)
+	args := params.ListEntities{Fields: []string{"life"}}
+	services, _, err := s.APIState.Client().ListServices(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(services, gc.DeepEquals, []params.ServiceSummary{
+		{Name: "logging", Life: params.Alive},
+		{Name: "mysql", Life: params.Alive},
+		{Name: "wordpress", Life: params.Alive},
+	})
+}
+
+func (s *listSuite) TestListServicesUnknownField(c *gc.C) {
+	args := params.ListEntities{Fields: []string{"colour"}}
+	_, _, err := s.APIState.Client().ListServices(args)
+	c.Assert(err, gc.ErrorMatches, `cannot list services: unknown field "colour"`)
+}
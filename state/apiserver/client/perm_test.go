@@ -134,6 +134,14 @@ var operationPermTests = []struct {
 	about: "Client.WatchAll",
 	op:    opClientWatchAll,
 	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.StatusChanges",
+	op:    opClientStatusChanges,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.WatchPublicAddress",
+	op:    opClientWatchPublicAddress,
+	allow: []names.Tag{userAdmin, userOther},
 }, {
 	about: "Client.CharmInfo",
 	op:    opClientCharmInfo,
@@ -146,6 +154,70 @@ var operationPermTests = []struct {
 	about: "Client.DestroyRelation",
 	op:    opClientDestroyRelation,
 	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ServiceRelations",
+	op:    opClientServiceRelations,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ServiceSetCharmStaged",
+	op:    opClientServiceSetCharmStaged,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ServiceReleaseUnits",
+	op:    opClientServiceReleaseUnits,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.WatchServiceRelations",
+	op:    opClientWatchServiceRelations,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ServiceDistribution",
+	op:    opClientServiceDistribution,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.RotateAgentPasswords",
+	op:    opClientRotateAgentPasswords,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.HardwareCharacteristics",
+	op:    opClientHardwareCharacteristics,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.InferEndpoints",
+	op:    opClientInferEndpoints,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.GetRelation",
+	op:    opClientGetRelation,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.MachinesWithJob",
+	op:    opClientMachinesWithJob,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.OrphanedRelationSettings",
+	op:    opClientOrphanedRelationSettings,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.CheckConsistency",
+	op:    opClientCheckConsistency,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ListMachines",
+	op:    opClientListMachines,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ListUnits",
+	op:    opClientListUnits,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ListServices",
+	op:    opClientListServices,
+	allow: []names.Tag{userAdmin, userOther},
+}, {
+	about: "Client.ActivateStagedUpgrade",
+	op:    opClientActivateStagedUpgrade,
+	allow: []names.Tag{userAdmin, userOther},
 }}
 
 // allowed returns the set of allowed entities given an allow list and a
@@ -219,6 +291,107 @@ func opClientDestroyRelation(c *gc.C, st *api.State, mst *state.State) (func(),
 	return func() {}, err
 }
 
+func opClientServiceRelations(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ServiceRelations("nosuch")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientServiceSetCharmStaged(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().ServiceSetCharmStaged("nosuch", "local:quantal/wordpress", false, nil)
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientServiceReleaseUnits(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().ServiceReleaseUnits("nosuch", nil, true)
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientWatchServiceRelations(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	w, err := st.Client().WatchServiceRelations("wordpress")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() { w.Stop() }, nil
+}
+
+func opClientServiceDistribution(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ServiceDistribution("wordpress")
+	return func() {}, err
+}
+
+func opClientRotateAgentPasswords(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().RotateAgentPasswords("machine-42")
+	return func() {}, err
+}
+
+func opClientHardwareCharacteristics(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().HardwareCharacteristics("machine-42")
+	return func() {}, err
+}
+
+func opClientInferEndpoints(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, _, err := st.Client().InferEndpoints("nosuch1", "nosuch2")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientGetRelation(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().GetRelation("nosuch1", "nosuch2")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientMachinesWithJob(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().MachinesWithJob(params.JobHostUnits)
+	return func() {}, err
+}
+
+func opClientOrphanedRelationSettings(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().OrphanedRelationSettings()
+	return func() {}, err
+}
+
+func opClientCheckConsistency(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().CheckConsistency(false)
+	return func() {}, err
+}
+
+func opClientListMachines(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, _, err := st.Client().ListMachines(params.ListEntities{})
+	return func() {}, err
+}
+
+func opClientListUnits(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, _, err := st.Client().ListUnits(params.ListEntities{})
+	return func() {}, err
+}
+
+func opClientListServices(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, _, err := st.Client().ListServices(params.ListEntities{})
+	return func() {}, err
+}
+
+func opClientActivateStagedUpgrade(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().ActivateStagedUpgrade()
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
 func opClientStatus(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	status, err := st.Client().Status(nil)
 	if err != nil {
@@ -393,7 +566,7 @@ func opClientGetServiceConstraints(c *gc.C, st *api.State, mst *state.State) (fu
 
 func opClientSetServiceConstraints(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	nullConstraints := constraints.Value{}
-	err := st.Client().SetServiceConstraints("wordpress", nullConstraints)
+	_, err := st.Client().SetServiceConstraints("wordpress", nullConstraints)
 	if err != nil {
 		return func() {}, err
 	}
@@ -402,7 +575,7 @@ func opClientSetServiceConstraints(c *gc.C, st *api.State, mst *state.State) (fu
 
 func opClientSetEnvironmentConstraints(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	nullConstraints := constraints.Value{}
-	err := st.Client().SetEnvironmentConstraints(nullConstraints)
+	_, err := st.Client().SetEnvironmentConstraints(nullConstraints)
 	if err != nil {
 		return func() {}, err
 	}
@@ -455,3 +628,16 @@ func opClientWatchAll(c *gc.C, st *api.State, mst *state.State) (func(), error)
 	}
 	return func() {}, err
 }
+
+func opClientStatusChanges(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().StatusChanges("")
+	return func() {}, err
+}
+
+func opClientWatchPublicAddress(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	w, err := st.Client().WatchPublicAddress("wordpress/0")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() { w.Stop() }, nil
+}
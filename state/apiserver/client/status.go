@@ -8,9 +8,11 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/errors"
+	"github.com/juju/names"
 	"github.com/juju/utils/set"
 
 	"github.com/juju/juju/constraints"
@@ -21,6 +23,21 @@ import (
 	"github.com/juju/juju/tools"
 )
 
+// StatusChanges returns the changes to the environment's entities since
+// the given token was returned, so that clients polling for status need
+// not fetch everything each time.
+func (c *Client) StatusChanges(args params.StatusChangesParams) (params.StatusChangesResult, error) {
+	deltas, token, reset, err := c.api.state.ChangesSince(args.Token)
+	if err != nil {
+		return params.StatusChangesResult{}, err
+	}
+	return params.StatusChangesResult{
+		Deltas: deltas,
+		Token:  token,
+		Reset:  reset,
+	}, nil
+}
+
 // FullStatus gives the information needed for juju status over the api
 func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 	cfg, err := c.api.state.EnvironConfig()
@@ -33,20 +50,28 @@ func (c *Client) FullStatus(args params.StatusParams) (api.Status, error) {
 	if err != nil {
 		return noStatus, err
 	}
+	services, err := newServiceMatcher(args.Services)
+	if err != nil {
+		return noStatus, err
+	}
+	machines, err := newMachineMatcher(args.Machines)
+	if err != nil {
+		return noStatus, err
+	}
 	if context.services,
-		context.units, context.latestCharms, err = fetchAllServicesAndUnits(c.api.state, unitMatcher); err != nil {
+		context.units, context.latestCharms, err = fetchAllServicesAndUnits(c.api.state, unitMatcher, services, machines); err != nil {
 		return noStatus, err
 	}
 
 	// Filter machines by units in scope.
 	var machineIds *set.Strings
-	if !unitMatcher.matchesAny() {
+	if !unitMatcher.matchesAny() || !services.matchesAny() {
 		machineIds, err = fetchUnitMachineIds(context.units)
 		if err != nil {
 			return noStatus, err
 		}
 	}
-	if context.machines, err = fetchMachines(c.api.state, machineIds); err != nil {
+	if context.machines, err = fetchMachines(c.api.state, machineIds, machines); err != nil {
 		return noStatus, err
 	}
 	if context.relations, err = fetchRelations(c.api.state); err != nil {
@@ -180,11 +205,97 @@ func NewUnitMatcher(patterns []string) (unitMatcher, error) {
 	return unitMatcher{patterns}, nil
 }
 
+// serviceMatcher matches services by name.
+type serviceMatcher struct {
+	names *set.Strings
+}
+
+// matchesAny returns true if the serviceMatcher will
+// match any service.
+func (m serviceMatcher) matchesAny() bool {
+	return m.names == nil
+}
+
+// matchService returns true if the named service
+// is one of those to match.
+func (m serviceMatcher) matchService(name string) bool {
+	return m.matchesAny() || m.names.Contains(name)
+}
+
+// newServiceMatcher returns a serviceMatcher that matches the
+// named services, or all services if no names are specified.
+func newServiceMatcher(serviceNames []string) (serviceMatcher, error) {
+	if len(serviceNames) == 0 {
+		return serviceMatcher{}, nil
+	}
+	for _, name := range serviceNames {
+		if !names.IsValidService(name) {
+			return serviceMatcher{}, fmt.Errorf("%q is not a valid service name", name)
+		}
+	}
+	serviceSet := set.NewStrings(serviceNames...)
+	return serviceMatcher{&serviceSet}, nil
+}
+
+// machineMatcher matches machines by id.
+type machineMatcher struct {
+	ids []string
+}
+
+// matchesAny returns true if the machineMatcher will
+// match any machine.
+func (m machineMatcher) matchesAny() bool {
+	return len(m.ids) == 0
+}
+
+// matchMachine returns true if the machine with the given id is one
+// of those to match or a container within one of them.
+func (m machineMatcher) matchMachine(id string) bool {
+	if m.matchesAny() {
+		return true
+	}
+	for _, mid := range m.ids {
+		if id == mid || strings.HasPrefix(id, mid+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost returns true if the machine with the given id matches
+// or hosts a container that matches, and so must be included for
+// the matching containers to be shown.
+func (m machineMatcher) matchHost(id string) bool {
+	if m.matchMachine(id) {
+		return true
+	}
+	for _, mid := range m.ids {
+		if strings.HasPrefix(mid, id+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// newMachineMatcher returns a machineMatcher that matches the
+// machines with the given ids and their containers, or all
+// machines if no ids are specified.
+func newMachineMatcher(ids []string) (machineMatcher, error) {
+	for _, id := range ids {
+		if !names.IsValidMachine(id) {
+			return machineMatcher{}, fmt.Errorf("%q is not a valid machine id", id)
+		}
+	}
+	return machineMatcher{ids}, nil
+}
+
 // fetchMachines returns a map from top level machine id to machines, where machines[0] is the host
 // machine and machines[1..n] are any containers (including nested ones).
 //
 // If machineIds is non-nil, only machines whose IDs are in the set are returned.
-func fetchMachines(st *state.State, machineIds *set.Strings) (map[string][]*state.Machine, error) {
+// Of those, only machines matching the machineMatcher, and the hosts of
+// matching containers, are returned.
+func fetchMachines(st *state.State, machineIds *set.Strings, matcher machineMatcher) (map[string][]*state.Machine, error) {
 	v := make(map[string][]*state.Machine)
 	machines, err := st.AllMachines()
 	if err != nil {
@@ -195,6 +306,9 @@ func fetchMachines(st *state.State, machineIds *set.Strings) (map[string][]*stat
 		if machineIds != nil && !machineIds.Contains(m.Id()) {
 			continue
 		}
+		if !matcher.matchHost(m.Id()) {
+			continue
+		}
 		parentId, ok := m.ParentId()
 		if !ok {
 			// Only top level host machines go directly into the machine map.
@@ -214,8 +328,9 @@ func fetchMachines(st *state.State, machineIds *set.Strings) (map[string][]*stat
 
 // fetchAllServicesAndUnits returns a map from service name to service,
 // a map from service name to unit name to unit, and a map from base charm URL to latest URL.
+// Only units matching all the matchers, and their services, are returned.
 func fetchAllServicesAndUnits(
-	st *state.State, unitMatcher unitMatcher) (
+	st *state.State, unitMatcher unitMatcher, serviceMatcher serviceMatcher, machineMatcher machineMatcher) (
 	map[string]*state.Service, map[string]map[string]*state.Unit, map[charm.URL]string, error) {
 
 	svcMap := make(map[string]*state.Service)
@@ -226,6 +341,9 @@ func fetchAllServicesAndUnits(
 		return nil, nil, nil, err
 	}
 	for _, s := range services {
+		if !serviceMatcher.matchService(s.Name()) {
+			continue
+		}
 		units, err := s.AllUnits()
 		if err != nil {
 			return nil, nil, nil, err
@@ -235,9 +353,20 @@ func fetchAllServicesAndUnits(
 			if !unitMatcher.matchUnit(u) {
 				continue
 			}
+			if !machineMatcher.matchesAny() {
+				mid, err := u.AssignedMachineId()
+				if state.IsNotAssigned(err) {
+					continue
+				} else if err != nil {
+					return nil, nil, nil, err
+				}
+				if !machineMatcher.matchMachine(mid) {
+					continue
+				}
+			}
 			svcUnitMap[u.Name()] = u
 		}
-		if unitMatcher.matchesAny() || len(svcUnitMap) > 0 {
+		if unitMatcher.matchesAny() && machineMatcher.matchesAny() || len(svcUnitMap) > 0 {
 			unitMap[s.Name()] = svcUnitMap
 			svcMap[s.Name()] = s
 			// Record the base URL for the service's charm so that
@@ -385,6 +514,7 @@ func (context *statusContext) makeMachineStatus(machine *state.Machine) (status
 		}
 	} else {
 		status.Hardware = hc.String()
+		status.HardwareCharacteristics = hc
 	}
 	status.Containers = make(map[string]api.MachineStatus)
 	return
@@ -548,6 +678,7 @@ func (context *statusContext) processUnit(unit *state.Unit, serviceCharm string)
 		status.Charm = curl.String()
 	}
 	status.Agent, status.AgentState, status.AgentStateInfo = processAgent(unit)
+	status.WorkloadStatus, status.WorkloadStatusInfo = unit.WorkloadStatus()
 	status.AgentVersion = status.Agent.Version
 	status.Life = status.Agent.Life
 	status.Err = status.Agent.Err
@@ -605,6 +736,7 @@ type lifer interface {
 type stateAgent interface {
 	lifer
 	AgentPresence() (bool, error)
+	AgentLastSeen() (time.Time, error)
 	AgentTools() (*tools.Tools, error)
 	Status() (params.Status, string, params.StatusData, error)
 }
@@ -659,6 +791,9 @@ func processAgent(entity stateAgent) (
 			compatInfo = fmt.Sprintf("(%s)", out.Status)
 		}
 		compatStatus = params.StatusDown
+		if lastSeen, err := entity.AgentLastSeen(); err == nil && !lastSeen.IsZero() {
+			out.LastSeen = &lastSeen
+		}
 	}
 
 	return
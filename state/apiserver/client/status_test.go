@@ -4,10 +4,13 @@
 package client_test
 
 import (
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
 )
 
 type statusSuite struct {
@@ -57,3 +60,159 @@ func (s *statusSuite) TestLegacyStatus(c *gc.C) {
 	}
 	c.Check(resultMachine.InstanceId, gc.Equals, instanceId)
 }
+
+func (s *statusSuite) TestAgentDownLastSeen(c *gc.C) {
+	machine := s.addMachine(c)
+	err := machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	pinger, err := machine.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+
+	client := s.APIState.Client()
+	status, err := client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	resultMachine := status.Machines[machine.Id()]
+	c.Check(resultMachine.AgentState, gc.Equals, params.StatusStarted)
+	c.Check(resultMachine.Agent.LastSeen, gc.IsNil)
+
+	// Once the agent stops pinging, it is shown as down, along
+	// with the time it was last seen.
+	err = pinger.Kill()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	status, err = client.Status(nil)
+	c.Assert(err, gc.IsNil)
+	resultMachine = status.Machines[machine.Id()]
+	c.Check(resultMachine.AgentState, gc.Equals, params.StatusDown)
+	c.Check(resultMachine.Agent.Status, gc.Equals, params.StatusStarted)
+	c.Assert(resultMachine.Agent.LastSeen, gc.NotNil)
+	c.Check(resultMachine.Agent.LastSeen.IsZero(), jc.IsFalse)
+}
+
+func (s *statusSuite) TestStatusChanges(c *gc.C) {
+	machine := s.addMachine(c)
+	client := s.APIState.Client()
+	result, err := client.StatusChanges("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Reset, jc.IsTrue)
+	c.Assert(result.Deltas, gc.HasLen, 1)
+	c.Assert(result.Deltas[0].Entity.(*params.MachineInfo).Id, gc.Equals, machine.Id())
+
+	// Only the changes since the token are returned.
+	other := s.addMachine(c)
+	err = machine.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = machine.Remove()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	var deltas []params.Delta
+	token := result.Token
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		result, err = client.StatusChanges(token)
+		c.Assert(err, gc.IsNil)
+		c.Assert(result.Reset, jc.IsFalse)
+		deltas = append(deltas, result.Deltas...)
+		token = result.Token
+		if len(deltas) > 0 && deltas[len(deltas)-1].Removed {
+			break
+		}
+	}
+	c.Assert(deltas[0].Removed, jc.IsFalse)
+	c.Assert(deltas[0].Entity.(*params.MachineInfo).Id, gc.Equals, other.Id())
+	last := deltas[len(deltas)-1]
+	c.Assert(last.Removed, jc.IsTrue)
+	c.Assert(last.Entity.(*params.MachineInfo).Id, gc.Equals, machine.Id())
+}
+
+func (s *statusSuite) TestStatusChangesUnknownToken(c *gc.C) {
+	s.addMachine(c)
+	result, err := s.APIState.Client().StatusChanges("some-other-server:42")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Reset, jc.IsTrue)
+	c.Assert(result.Deltas, gc.HasLen, 1)
+}
+
+func (s *statusSuite) TestFilteredStatus(c *gc.C) {
+	machine0 := s.addMachine(c)
+	machine1 := s.addMachine(c)
+	template := state.MachineTemplate{
+		Series: "quantal",
+		Jobs:   []state.MachineJob{state.JobHostUnits},
+	}
+	container, err := s.State.AddMachineInsideMachine(template, machine1.Id(), instance.LXC)
+	c.Assert(err, gc.IsNil)
+	charm := s.AddTestingCharm(c, "dummy")
+	addUnit := func(service string, machine *state.Machine) {
+		svc := s.AddTestingService(c, service, charm)
+		unit, err := svc.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.AssignToMachine(machine)
+		c.Assert(err, gc.IsNil)
+	}
+	addUnit("wordpress", machine0)
+	addUnit("mysql", container)
+
+	for i, test := range []struct {
+		filter   params.StatusParams
+		services []string
+		machines []string
+	}{{
+		filter:   params.StatusParams{},
+		services: []string{"mysql", "wordpress"},
+		machines: []string{"0", "1"},
+	}, {
+		filter:   params.StatusParams{Services: []string{"mysql"}},
+		services: []string{"mysql"},
+		machines: []string{"1"},
+	}, {
+		filter:   params.StatusParams{Machines: []string{"0"}},
+		services: []string{"wordpress"},
+		machines: []string{"0"},
+	}, {
+		filter:   params.StatusParams{Machines: []string{"1/lxc/0"}},
+		services: []string{"mysql"},
+		machines: []string{"1"},
+	}, {
+		filter:   params.StatusParams{Services: []string{"wordpress"}, Patterns: []string{"mysql"}},
+		services: []string{},
+		machines: []string{},
+	}, {
+		filter: params.StatusParams{
+			Services: []string{"mysql", "wordpress"},
+			Machines: []string{"1"},
+		},
+		services: []string{"mysql"},
+		machines: []string{"1"},
+	}} {
+		c.Logf("test %d: %+v", i, test.filter)
+		status, err := s.APIState.Client().FilteredStatus(test.filter)
+		c.Assert(err, gc.IsNil)
+		services := []string{}
+		for name := range status.Services {
+			services = append(services, name)
+		}
+		machines := []string{}
+		for id := range status.Machines {
+			machines = append(machines, id)
+		}
+		c.Check(services, jc.SameContents, test.services)
+		c.Check(machines, jc.SameContents, test.machines)
+	}
+
+	// Containers of a matching host are included.
+	status, err := s.APIState.Client().FilteredStatus(params.StatusParams{Machines: []string{"1"}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(status.Machines["1"].Containers, gc.HasLen, 1)
+	c.Assert(status.Machines["1"].Containers[container.Id()].Id, gc.Equals, container.Id())
+}
+
+func (s *statusSuite) TestFilteredStatusInvalid(c *gc.C) {
+	client := s.APIState.Client()
+	_, err := client.FilteredStatus(params.StatusParams{Services: []string{"bad/name"}})
+	c.Assert(err, gc.ErrorMatches, `"bad/name" is not a valid service name`)
+	_, err = client.FilteredStatus(params.StatusParams{Machines: []string{"foo"}})
+	c.Assert(err, gc.ErrorMatches, `"foo" is not a valid machine id`)
+}
@@ -0,0 +1,45 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+)
+
+// CharmDownloadURL returns a URL through which the archive of the
+// given charm may be downloaded from an API server until a short while
+// from now, so that agents need no access to provider storage. It
+// returns an empty URL if the state server's serving information or
+// API addresses are not yet known.
+func CharmDownloadURL(st DownloadURLState, envUUID string, curl *charm.URL) (string, error) {
+	query := url.Values{}
+	query.Set("url", curl.String())
+	path := "/environment/" + envUUID + "/charms/download"
+	expires := time.Now().Add(downloadURLExpiry)
+	return proxiedDownloadURL(st, path, charmResource(curl), query, expires)
+}
+
+// VerifyCharmDownload checks the query of a charm download proxied
+// through an API server, as made by CharmDownloadURL, and returns the
+// URL of the charm to download.
+func VerifyCharmDownload(st DownloadURLState, query url.Values) (*charm.URL, error) {
+	curl, err := charm.ParseURL(query.Get("url"))
+	if err != nil {
+		return nil, errors.Errorf("invalid charm URL %q", query.Get("url"))
+	}
+	if err := verifyDownload(st, charmResource(curl), query); err != nil {
+		return nil, err
+	}
+	return curl, nil
+}
+
+// charmResource returns the resource signed in a proxied download of
+// the given charm's archive.
+func charmResource(curl *charm.URL) string {
+	return "charm " + curl.String()
+}
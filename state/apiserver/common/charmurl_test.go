@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/juju/charm"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+type charmURLSuite struct {
+	testing.JujuConnSuite
+	curl *charm.URL
+}
+
+var _ = gc.Suite(&charmURLSuite{})
+
+func (s *charmURLSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.curl = charm.MustParseURL("cs:quantal/wordpress-3")
+}
+
+func (s *charmURLSuite) setServingInfo(c *gc.C) {
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *charmURLSuite) downloadURL(c *gc.C) *url.URL {
+	downloadURL, err := common.CharmDownloadURL(s.State, "deadbeef", s.curl)
+	c.Assert(err, gc.IsNil)
+	uri, err := url.Parse(downloadURL)
+	c.Assert(err, gc.IsNil)
+	return uri
+}
+
+func (s *charmURLSuite) TestNoServingInfo(c *gc.C) {
+	downloadURL, err := common.CharmDownloadURL(s.State, "deadbeef", s.curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(downloadURL, gc.Equals, "")
+}
+
+func (s *charmURLSuite) TestCharmDownloadURL(c *gc.C) {
+	s.setServingInfo(c)
+	uri := s.downloadURL(c)
+	c.Assert(uri.Scheme, gc.Equals, "https")
+	c.Assert(uri.Host, gc.Equals, "10.0.0.1:17070")
+	c.Assert(uri.Path, gc.Equals, "/environment/deadbeef/charms/download")
+
+	curl, err := common.VerifyCharmDownload(s.State, uri.Query())
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl, gc.DeepEquals, s.curl)
+}
+
+func (s *charmURLSuite) TestVerifyBadSignature(c *gc.C) {
+	s.setServingInfo(c)
+	query := s.downloadURL(c).Query()
+	query.Set("url", "cs:quantal/mysql-1")
+	_, err := common.VerifyCharmDownload(s.State, query)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *charmURLSuite) TestVerifyBadURL(c *gc.C) {
+	s.setServingInfo(c)
+	query := s.downloadURL(c).Query()
+	query.Set("url", "bad:url")
+	_, err := common.VerifyCharmDownload(s.State, query)
+	c.Assert(err, gc.ErrorMatches, `invalid charm URL "bad:url"`)
+}
+
+func (s *charmURLSuite) TestVerifyExpired(c *gc.C) {
+	s.setServingInfo(c)
+	s.PatchValue(common.DownloadURLExpiry, -time.Minute)
+	_, err := common.VerifyCharmDownload(s.State, s.downloadURL(c).Query())
+	c.Assert(err, gc.ErrorMatches, "download URL has expired")
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api/params"
+)
+
+// downloadURLExpiry holds how long the download URLs handed out to
+// agents remain usable. It need only be long enough for an agent to
+// start the download.
+var downloadURLExpiry = 15 * time.Minute
+
+// DownloadURLState holds the state needed to make and check the
+// expiring URLs of downloads proxied through an API server.
+type DownloadURLState interface {
+	StateServingInfo() (params.StateServingInfo, error)
+	APIHostPorts() ([][]network.HostPort, error)
+	DownloadSigningKey() ([]byte, error)
+}
+
+// proxiedDownloadURL returns a URL through which the given resource may
+// be downloaded from an API server at the given path until expires.
+// The query is extended with the expiry time and a signature covering
+// the resource. It returns an empty URL if the state server's serving
+// information or API addresses are not yet known.
+func proxiedDownloadURL(st DownloadURLState, path, resource string, query url.Values, expires time.Time) (string, error) {
+	if _, err := st.StateServingInfo(); errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	hostPorts, err := st.APIHostPorts()
+	if err != nil {
+		return "", err
+	}
+	// Use the first API server with an address agents can reach;
+	// any of them may serve the download.
+	var addr string
+	for _, serverHostPorts := range hostPorts {
+		if addr = network.SelectInternalHostPort(serverHostPorts, false); addr != "" {
+			break
+		}
+	}
+	if addr == "" {
+		return "", nil
+	}
+	key, err := st.DownloadSigningKey()
+	if err != nil {
+		return "", err
+	}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("signature", downloadSignature(key, resource, expires.Unix()))
+	proxyURL := url.URL{
+		Scheme:   "https",
+		Host:     addr,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	return proxyURL.String(), nil
+}
+
+// verifyDownload checks that the query of a download request, as made
+// by proxiedDownloadURL, is correctly signed for the given resource and
+// has not expired.
+func verifyDownload(st DownloadURLState, resource string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid expiry time %q", query.Get("expires"))
+	}
+	key, err := st.DownloadSigningKey()
+	if err != nil {
+		return err
+	}
+	want := downloadSignature(key, resource, expires)
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(want)) {
+		return ErrPerm
+	}
+	if time.Now().Unix() > expires {
+		return errors.New("download URL has expired")
+	}
+	return nil
+}
+
+// downloadSignature returns the signature of a proxied download of the
+// given resource expiring at the given Unix time, made with the given
+// key, which all API servers share.
+func downloadSignature(key []byte, resource string, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%d", resource, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -98,6 +98,9 @@ func (e *EnvironWatcher) EnvironConfig() (params.EnvironConfigResult, error) {
 		for k := range secretAttrs {
 			allAttrs[k] = "not available"
 		}
+		for k := range config.SecretAttrs() {
+			allAttrs[k] = "not available"
+		}
 	}
 	result.Config = allAttrs
 	return result, nil
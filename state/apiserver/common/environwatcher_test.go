@@ -129,6 +129,30 @@ func (*environWatcherSuite) TestEnvironConfigSuccess(c *gc.C) {
 	c.Check(map[string]interface{}(result.Config), jc.DeepEquals, testingEnvConfig.AllAttrs())
 }
 
+func (*environWatcherSuite) TestEnvironConfigReadSecretsFalseMasksNotificationSecret(c *gc.C) {
+	getCanReadSecrets := func() (common.AuthFunc, error) {
+		return func(tag string) bool {
+			return false
+		}, nil
+	}
+
+	testingEnvConfig, err := testingEnvConfig(c).Apply(map[string]interface{}{
+		"notification-url":    "https://example.com/hook",
+		"notification-secret": "sekrit",
+	})
+	c.Assert(err, gc.IsNil)
+	e := common.NewEnvironWatcher(
+		&fakeEnvironAccessor{envConfig: testingEnvConfig},
+		nil,
+		nil,
+		getCanReadSecrets,
+	)
+	result, err := e.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Check(result.Config["notification-secret"], gc.Equals, "not available")
+	c.Check(result.Config["notification-url"], gc.Equals, "https://example.com/hook")
+}
+
 func (*environWatcherSuite) TestEnvironConfigFetchError(c *gc.C) {
 	getCanReadSecrets := func() (common.AuthFunc, error) {
 		return func(tag string) bool {
@@ -106,27 +106,38 @@ func ServerError(err error) *params.Error {
 	if err == nil {
 		return nil
 	}
-	code, ok := singletonCode(err)
+	// The code is determined by the underlying error, so that
+	// errors may be traced or annotated without losing it.
+	cause := errors.Cause(err)
+	code, ok := singletonCode(cause)
 	switch {
 	case ok:
-	case errors.IsUnauthorized(err):
+	case errors.IsUnauthorized(cause):
 		code = params.CodeUnauthorized
-	case errors.IsNotFound(err):
+	case errors.IsNotFound(cause):
 		code = params.CodeNotFound
-	case errors.IsAlreadyExists(err):
+	case errors.IsAlreadyExists(cause):
 		code = params.CodeAlreadyExists
-	case state.IsNotAssigned(err):
+	case state.IsNotAssigned(cause):
 		code = params.CodeNotAssigned
-	case state.IsHasAssignedUnitsError(err):
+	case state.IsHasAssignedUnitsError(cause):
 		code = params.CodeHasAssignedUnits
-	case IsNoAddressSetError(err):
+	case IsNoAddressSetError(cause):
 		code = params.CodeNoAddressSet
-	case state.IsNotProvisionedError(err):
+	case state.IsNotProvisionedError(cause):
 		code = params.CodeNotProvisioned
-	case IsUnknownEnviromentError(err):
+	case state.IsAmbiguousRelation(cause):
+		code = params.CodeAmbiguousRelation
+	case IsUnknownEnviromentError(cause):
 		code = params.CodeNotFound
+	case state.IsVersionInconsistentError(cause):
+		code = params.CodeUpgradeInProgress
+	case state.IsMajorVersionDowngradeError(cause):
+		code = params.CodeVersionDowngrade
+	case state.IsAgentsNotReadyError(cause):
+		code = params.CodeAgentsNotReady
 	default:
-		code = params.ErrCode(err)
+		code = params.ErrCode(cause)
 	}
 	return &params.Error{
 		Message: err.Error(),
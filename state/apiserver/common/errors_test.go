@@ -95,6 +95,13 @@ var errorTransformTests = []struct {
 	err:        &state.HasAssignedUnitsError{"42", []string{"a"}},
 	code:       params.CodeHasAssignedUnits,
 	helperFunc: params.IsCodeHasAssignedUnits,
+}, {
+	err: &state.AmbiguousRelationError{
+		Names:      []string{"wp", "ms"},
+		Candidates: []string{"wp:db ms:dev", "wp:db ms:prod"},
+	},
+	code:       params.CodeAmbiguousRelation,
+	helperFunc: params.IsCodeAmbiguousRelation,
 }, {
 	err:        common.ErrTryAgain,
 	code:       params.CodeTryAgain,
@@ -135,6 +142,18 @@ func (s *errorsSuite) TestErrorTransform(c *gc.C) {
 	}
 }
 
+func (s *errorsSuite) TestErrorTransformTraced(c *gc.C) {
+	for i, t := range errorTransformTests {
+		if t.err == nil {
+			continue
+		}
+		c.Logf("test %d: %v", i, t.err)
+		err1 := common.ServerError(errors.Annotate(t.err, "context"))
+		c.Check(err1.Message, gc.Equals, "context: "+t.err.Error())
+		c.Check(err1.Code, gc.Equals, t.code)
+	}
+}
+
 func (s *errorsSuite) TestUnknownEnvironment(c *gc.C) {
 	err := common.UnknownEnvironmentError("dead-beef")
 	c.Check(err, gc.ErrorMatches, `unknown environment: "dead-beef"`)
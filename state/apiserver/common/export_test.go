@@ -4,9 +4,11 @@
 package common
 
 var (
-	ValidateNewFacade = validateNewFacade
-	WrapNewFacade     = wrapNewFacade
-	NilFacadeRecord   = facadeRecord{}
+	ValidateNewFacade    = validateNewFacade
+	WrapNewFacade        = wrapNewFacade
+	NilFacadeRecord      = facadeRecord{}
+	DownloadURLExpiry    = &downloadURLExpiry
+	ModelCacheMaxEntries = &modelCacheMaxEntries
 )
 
 type Patcher interface {
@@ -0,0 +1,56 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+// MetricsAdder implements a common AddMetrics method for use by
+// various facades.
+type MetricsAdder struct {
+	st           state.MetricsAdder
+	getCanModify GetAuthFunc
+}
+
+// NewMetricsAdder returns a new MetricsAdder. The GetAuthFunc will be
+// used on each invocation of AddMetrics to determine current
+// permissions.
+func NewMetricsAdder(st state.MetricsAdder, getCanModify GetAuthFunc) *MetricsAdder {
+	return &MetricsAdder{
+		st:           st,
+		getCanModify: getCanModify,
+	}
+}
+
+// AddMetrics records a batch of metrics for each given entity.
+func (m *MetricsAdder) AddMetrics(args params.AddMetrics) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	if len(args.Entities) == 0 {
+		return result, nil
+	}
+	canModify, err := m.getCanModify()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Entities {
+		err := ErrPerm
+		if canModify(arg.Tag) {
+			metrics := make([]state.Metric, len(arg.Metrics))
+			for j, metric := range arg.Metrics {
+				metrics[j] = state.Metric{
+					Key:   metric.Key,
+					Value: metric.Value,
+					Time:  metric.Time,
+				}
+			}
+			_, err = m.st.AddMetricBatch(arg.Tag, metrics)
+		}
+		result.Results[i].Error = ServerError(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	"fmt"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	apiservertesting "github.com/juju/juju/state/apiserver/testing"
+)
+
+type metricsAdderSuite struct{}
+
+var _ = gc.Suite(&metricsAdderSuite{})
+
+type fakeMetricsAdder struct {
+	added map[string][]state.Metric
+}
+
+func (f *fakeMetricsAdder) AddMetricBatch(entityTag string, metrics []state.Metric) (*state.MetricBatch, error) {
+	if entityTag == "x1" {
+		return nil, fmt.Errorf("x1 fails")
+	}
+	f.added[entityTag] = metrics
+	return &state.MetricBatch{}, nil
+}
+
+func (*metricsAdderSuite) TestAddMetrics(c *gc.C) {
+	st := &fakeMetricsAdder{added: make(map[string][]state.Metric)}
+	getCanModify := func() (common.AuthFunc, error) {
+		return func(tag string) bool {
+			return tag == "x0" || tag == "x1"
+		}, nil
+	}
+	m := common.NewMetricsAdder(st, getCanModify)
+	now := time.Now()
+	args := params.AddMetrics{
+		Entities: []params.EntityMetrics{
+			{"x0", []params.Metric{{"cpu", "1", now}}},
+			{"x1", []params.Metric{{"cpu", "2", now}}},
+			{"x2", []params.Metric{{"cpu", "3", now}}},
+		},
+	}
+	result, err := m.AddMetrics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{&params.Error{Message: "x1 fails"}},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	c.Assert(st.added, gc.DeepEquals, map[string][]state.Metric{
+		"x0": {{Key: "cpu", Value: "1", Time: now}},
+	})
+}
+
+func (*metricsAdderSuite) TestAddMetricsError(c *gc.C) {
+	getCanModify := func() (common.AuthFunc, error) {
+		return nil, fmt.Errorf("pow")
+	}
+	m := common.NewMetricsAdder(&fakeMetricsAdder{}, getCanModify)
+	_, err := m.AddMetrics(params.AddMetrics{
+		Entities: []params.EntityMetrics{{Tag: "x0"}},
+	})
+	c.Assert(err, gc.ErrorMatches, "pow")
+}
+
+func (*metricsAdderSuite) TestAddMetricsNoArgsNoError(c *gc.C) {
+	getCanModify := func() (common.AuthFunc, error) {
+		return nil, fmt.Errorf("pow")
+	}
+	m := common.NewMetricsAdder(&fakeMetricsAdder{}, getCanModify)
+	result, err := m.AddMetrics(params.AddMetrics{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 0)
+}
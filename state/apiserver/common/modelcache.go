@@ -0,0 +1,180 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+// ModelCache holds the machines, units and services of the environment
+// as reported by the state's shared multiwatcher, so that read-heavy
+// calls can be answered without querying mongo.
+//
+// The cache catches up with the multiwatcher when it is read, at most
+// once every maxStaleness, so what it returns may lag the multiwatcher
+// by up to that long. Entities the cache does not know about are
+// reported as not found; callers should then fall back to the state,
+// as the entity may have been added since the cache last caught up.
+//
+// The cache holds no more than modelCacheMaxEntries entities; when it
+// is full, the least recently used entities are evicted, and are
+// reported as not found until the multiwatcher next reports a change
+// to them.
+type ModelCache struct {
+	st           *state.State
+	maxStaleness time.Duration
+
+	mu     sync.Mutex
+	token  string
+	synced time.Time
+
+	// entities maps the id of each cached entity to its element
+	// in lru, which holds the most recently used entities first.
+	entities map[params.EntityId]*list.Element
+	lru      *list.List
+}
+
+// modelCacheMaxEntries holds the maximum number of entities held by
+// a ModelCache.
+var modelCacheMaxEntries = 10000
+
+// modelCacheEntry holds an entity in a ModelCache.
+type modelCacheEntry struct {
+	id   params.EntityId
+	info params.EntityInfo
+}
+
+// NewModelCache returns a new ModelCache holding the entities in the
+// given state, no more than maxStaleness out of date with respect to
+// the state's multiwatcher.
+func NewModelCache(st *state.State, maxStaleness time.Duration) *ModelCache {
+	return &ModelCache{
+		st:           st,
+		maxStaleness: maxStaleness,
+		entities:     make(map[params.EntityId]*list.Element),
+		lru:          list.New(),
+	}
+}
+
+// get returns the cached information about the entity with the
+// given id, catching up with the multiwatcher first if necessary.
+func (c *ModelCache) get(id params.EntityId) (params.EntityInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.synced.IsZero() || time.Since(c.synced) >= c.maxStaleness {
+		if err := c.sync(); err != nil {
+			return nil, err
+		}
+	}
+	elem, ok := c.entities[id]
+	if !ok {
+		return nil, errors.NotFoundf("%s %q", id.Kind, id.Id)
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*modelCacheEntry).info, nil
+}
+
+// sync applies the changes made since the cache last caught up with
+// the multiwatcher. It must be called with c.mu held.
+func (c *ModelCache) sync() error {
+	deltas, token, reset, err := c.st.ChangesSince(c.token)
+	if err != nil {
+		return errors.Annotate(err, "cannot update model cache")
+	}
+	if reset {
+		c.entities = make(map[params.EntityId]*list.Element)
+		c.lru.Init()
+	}
+	for _, delta := range deltas {
+		id := delta.Entity.EntityId()
+		elem, ok := c.entities[id]
+		switch {
+		case delta.Removed:
+			if ok {
+				c.lru.Remove(elem)
+				delete(c.entities, id)
+			}
+		case ok:
+			elem.Value.(*modelCacheEntry).info = delta.Entity
+		default:
+			c.entities[id] = c.lru.PushFront(&modelCacheEntry{id, delta.Entity})
+		}
+	}
+	for c.lru.Len() > modelCacheMaxEntries {
+		elem := c.lru.Back()
+		c.lru.Remove(elem)
+		delete(c.entities, elem.Value.(*modelCacheEntry).id)
+	}
+	c.token = token
+	c.synced = time.Now()
+	return nil
+}
+
+// Machine returns the cached information about the machine with the
+// given id. The returned value must not be changed.
+func (c *ModelCache) Machine(id string) (*params.MachineInfo, error) {
+	info, err := c.get(params.EntityId{Kind: "machine", Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return info.(*params.MachineInfo), nil
+}
+
+// Unit returns the cached information about the unit with the given
+// name. The returned value must not be changed.
+func (c *ModelCache) Unit(name string) (*params.UnitInfo, error) {
+	info, err := c.get(params.EntityId{Kind: "unit", Id: name})
+	if err != nil {
+		return nil, err
+	}
+	return info.(*params.UnitInfo), nil
+}
+
+// Service returns the cached information about the service with the
+// given name. The returned value must not be changed.
+func (c *ModelCache) Service(name string) (*params.ServiceInfo, error) {
+	info, err := c.get(params.EntityId{Kind: "service", Id: name})
+	if err != nil {
+		return nil, err
+	}
+	return info.(*params.ServiceInfo), nil
+}
+
+// UnitAddresses returns the addresses of the machine the named unit
+// is assigned to, which is where the unit's public and private
+// addresses are chosen from. It returns no addresses if the unit is
+// not assigned to a machine.
+func (c *ModelCache) UnitAddresses(name string) ([]network.Address, error) {
+	unit, err := c.Unit(name)
+	if err != nil {
+		return nil, err
+	}
+	if unit.MachineId == "" {
+		return nil, nil
+	}
+	machine, err := c.Machine(unit.MachineId)
+	if err != nil {
+		return nil, err
+	}
+	return machine.Addresses, nil
+}
+
+// SharedModelCache allows a ModelCache shared by all connections to be
+// registered as a resource of each of them.
+type SharedModelCache struct {
+	*ModelCache
+}
+
+// Stop implements Resource.Stop.
+func (SharedModelCache) Stop() error {
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type modelCacheSuite struct {
+	testing.JujuConnSuite
+	machine *state.Machine
+	unit    *state.Unit
+}
+
+var _ = gc.Suite(&modelCacheSuite{})
+
+func (s *modelCacheSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	var err error
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+	s.setAddress(c, "10.0.0.1")
+}
+
+func (s *modelCacheSuite) setAddress(c *gc.C, address string) {
+	err := s.machine.SetAddresses(network.NewAddress(address, network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+	s.waitForMachineAddress(c, address)
+}
+
+// waitForMachineAddress waits until the state's multiwatcher
+// reports the given address for s.machine.
+func (s *modelCacheSuite) waitForMachineAddress(c *gc.C, address string) {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.State.StartSync()
+		deltas, _, _, err := s.State.ChangesSince("")
+		c.Assert(err, gc.IsNil)
+		for _, delta := range deltas {
+			info, ok := delta.Entity.(*params.MachineInfo)
+			if ok && info.Id == s.machine.Id() && len(info.Addresses) == 1 && info.Addresses[0].Value == address {
+				return
+			}
+		}
+	}
+	c.Fatalf("multiwatcher never saw address %q for machine %s", address, s.machine.Id())
+}
+
+func (s *modelCacheSuite) TestEntities(c *gc.C) {
+	cache := common.NewModelCache(s.State, 0)
+
+	machine, err := cache.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Addresses, jc.DeepEquals, s.machine.Addresses())
+
+	unit, err := cache.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(unit.MachineId, gc.Equals, s.machine.Id())
+
+	service, err := cache.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.Name, gc.Equals, "wordpress")
+
+	addresses, err := cache.UnitAddresses("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, jc.DeepEquals, s.machine.Addresses())
+}
+
+func (s *modelCacheSuite) TestNotFound(c *gc.C) {
+	cache := common.NewModelCache(s.State, 0)
+	_, err := cache.Machine("42")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = cache.Unit("mysql/0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = cache.Service("mysql")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = cache.UnitAddresses("mysql/0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *modelCacheSuite) TestUnitAddressesUnassigned(c *gc.C) {
+	service, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	_, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	cache := common.NewModelCache(s.State, 0)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.State.StartSync()
+		addresses, err := cache.UnitAddresses("wordpress/1")
+		if errors.IsNotFound(err) {
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Assert(addresses, gc.HasLen, 0)
+		return
+	}
+	c.Fatalf("unit wordpress/1 never cached")
+}
+
+func (s *modelCacheSuite) TestStaleness(c *gc.C) {
+	cache := common.NewModelCache(s.State, time.Hour)
+	addresses, err := cache.UnitAddresses("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, jc.DeepEquals, []network.Address{
+		network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+	})
+
+	// The multiwatcher has seen the change, but the cache
+	// will not catch up with it for another hour.
+	s.setAddress(c, "10.0.0.2")
+	addresses, err = cache.UnitAddresses("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, jc.DeepEquals, []network.Address{
+		network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+	})
+
+	// A cache with no staleness catches up on every read.
+	cache = common.NewModelCache(s.State, 0)
+	addresses, err = cache.UnitAddresses("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, jc.DeepEquals, []network.Address{
+		network.NewAddress("10.0.0.2", network.ScopeCloudLocal),
+	})
+}
+
+func (s *modelCacheSuite) TestRemoval(c *gc.C) {
+	cache := common.NewModelCache(s.State, 0)
+	_, err := cache.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.State.StartSync()
+		_, err = cache.Unit("wordpress/0")
+		if errors.IsNotFound(err) {
+			return
+		}
+		c.Assert(err, gc.IsNil)
+	}
+	c.Fatalf("unit wordpress/0 never removed from cache")
+}
+
+func (s *modelCacheSuite) TestEviction(c *gc.C) {
+	cache := common.NewModelCache(s.State, 0)
+	_, err := cache.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	_, err = cache.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+
+	// Only the most recently used entity is kept when the
+	// cache next catches up.
+	s.PatchValue(common.ModelCacheMaxEntries, 1)
+	_, err = cache.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	_, err = cache.Unit("wordpress/0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = cache.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+}
@@ -23,11 +23,44 @@ type Resources struct {
 	mu        sync.Mutex
 	maxId     uint64
 	resources map[string]Resource
+
+	// watchers holds the ids of the resources registered with
+	// Register rather than RegisterNamed.
+	watchers map[string]bool
+
+	// observer, if not nil, is called with the change in
+	// len(watchers) whenever it changes.
+	observer func(delta int)
 }
 
 func NewResources() *Resources {
 	return &Resources{
 		resources: make(map[string]Resource),
+		watchers:  make(map[string]bool),
+	}
+}
+
+// ObserveWatchers arranges for f to be called with the change in the
+// number of watchers held, that is, resources registered with Register
+// rather than RegisterNamed, whenever that number changes. It is
+// called once straight away with the number currently held. f is
+// called with the Resources' lock held, so it must not call back
+// into the Resources.
+func (rs *Resources) ObserveWatchers(f func(delta int)) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.observer = f
+	if f != nil && len(rs.watchers) > 0 {
+		f(len(rs.watchers))
+	}
+}
+
+// watchersChanged reports the given change in the number of
+// watchers to the observer, if there is one. It must be called
+// with rs.mu held.
+func (rs *Resources) watchersChanged(delta int) {
+	if rs.observer != nil && delta != 0 {
+		rs.observer(delta)
 	}
 }
 
@@ -48,6 +81,8 @@ func (rs *Resources) Register(r Resource) string {
 	rs.maxId++
 	id := strconv.FormatUint(rs.maxId, 10)
 	rs.resources[id] = r
+	rs.watchers[id] = true
+	rs.watchersChanged(1)
 	return id
 }
 
@@ -90,6 +125,10 @@ func (rs *Resources) Stop(id string) error {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 	delete(rs.resources, id)
+	if rs.watchers[id] {
+		delete(rs.watchers, id)
+		rs.watchersChanged(-1)
+	}
 	return err
 }
 
@@ -103,6 +142,8 @@ func (rs *Resources) StopAll() {
 		}
 	}
 	rs.resources = make(map[string]Resource)
+	rs.watchersChanged(-len(rs.watchers))
+	rs.watchers = make(map[string]bool)
 }
 
 // Count returns the number of resources currently held.
@@ -112,6 +153,15 @@ func (rs *Resources) Count() int {
 	return len(rs.resources)
 }
 
+// Watchers returns the number of watchers currently held, that is,
+// the number of resources registered with Register rather than
+// RegisterNamed.
+func (rs *Resources) Watchers() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return len(rs.watchers)
+}
+
 // StringResource is just a regular 'string' that matches the Resource
 // interface.
 type StringResource string
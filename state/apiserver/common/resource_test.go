@@ -36,6 +36,47 @@ func (resourceSuite) TestRegisterGetCount(c *gc.C) {
 	c.Assert(rs.Count(), gc.Equals, 2)
 }
 
+func (resourceSuite) TestWatchers(c *gc.C) {
+	rs := common.NewResources()
+	err := rs.RegisterNamed("named", &fakeResource{})
+	c.Assert(err, gc.IsNil)
+	rs.Register(&fakeResource{})
+	id := rs.Register(&fakeResource{})
+	c.Assert(rs.Count(), gc.Equals, 3)
+	c.Assert(rs.Watchers(), gc.Equals, 2)
+
+	err = rs.Stop(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(rs.Watchers(), gc.Equals, 1)
+}
+
+func (resourceSuite) TestObserveWatchers(c *gc.C) {
+	rs := common.NewResources()
+	rs.Register(&fakeResource{})
+	watchers := 0
+	rs.ObserveWatchers(func(delta int) {
+		watchers += delta
+	})
+	c.Assert(watchers, gc.Equals, 1)
+
+	err := rs.RegisterNamed("named", &fakeResource{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(watchers, gc.Equals, 1)
+	id := rs.Register(&fakeResource{})
+	rs.Register(&fakeResource{})
+	c.Assert(watchers, gc.Equals, 3)
+
+	err = rs.Stop(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(watchers, gc.Equals, 2)
+	err = rs.Stop(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(watchers, gc.Equals, 2)
+
+	rs.StopAll()
+	c.Assert(watchers, gc.Equals, 0)
+}
+
 func (resourceSuite) TestRegisterNamedGetCount(c *gc.C) {
 	rs := common.NewResources()
 	defer rs.StopAll()
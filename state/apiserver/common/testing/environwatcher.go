@@ -60,6 +60,9 @@ func (s *EnvironWatcherTest) AssertEnvironConfig(c *gc.C, envWatcher Environment
 		for key := range secretAttrs {
 			configAttributes[key] = "not available"
 		}
+		for key := range envConfig.SecretAttrs() {
+			configAttributes[key] = "not available"
+		}
 	}
 
 	c.Assert(result.Config, jc.DeepEquals, params.EnvironConfig(configAttributes))
@@ -17,6 +17,7 @@ import (
 
 type EntityFinderEnvironConfigGetter interface {
 	state.EntityFinder
+	DownloadURLState
 	EnvironConfig() (*config.Config, error)
 }
 
@@ -62,6 +63,7 @@ func (t *ToolsGetter) Tools(args params.Entities) (params.ToolsResults, error) {
 		if err == nil {
 			result.Results[i].Tools = agentTools
 			result.Results[i].DisableSSLHostnameVerification = disableSSLHostnameVerification
+			err = SetExpiringToolsURL(t.st, env, &result.Results[i])
 		}
 		result.Results[i].Error = ServerError(err)
 	}
@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/storage"
+	envtools "github.com/juju/juju/environs/tools"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/version"
+)
+
+// SetExpiringToolsURL replaces the URL of the tools in result, if they
+// are held in the environment's storage, with one that may be used only
+// for a short while. If the storage can sign URLs, a signed storage URL
+// is used; otherwise the URL is that of a signed download proxied
+// through an API server. Tools found elsewhere are left alone, as are
+// all tools if the state server's serving information or API addresses
+// are not yet known.
+func SetExpiringToolsURL(st DownloadURLState, env environs.Environ, result *params.ToolsResult) error {
+	tools := result.Tools
+	if tools == nil {
+		return nil
+	}
+	stor := env.Storage()
+	name := envtools.StorageName(tools.Version)
+	inStorage, err := isStorageURL(stor, name, tools.URL)
+	if err != nil || !inStorage {
+		return err
+	}
+	expires := time.Now().Add(downloadURLExpiry)
+	if signer, ok := stor.(storage.URLSigner); ok {
+		signedURL, err := signer.SignedURL(name, expires)
+		if err == nil {
+			tools.URL = signedURL
+			return nil
+		}
+		if !errors.IsNotSupported(err) {
+			return errors.Annotate(err, "cannot sign tools URL")
+		}
+	}
+	path := "/tools/download"
+	if uuid, ok := env.Config().UUID(); ok {
+		path = "/environment/" + uuid + path
+	}
+	query := url.Values{}
+	query.Set("version", tools.Version.String())
+	proxyURL, err := proxiedDownloadURL(st, path, toolsResource(tools.Version), query, expires)
+	if err != nil || proxyURL == "" {
+		return err
+	}
+	tools.URL = proxyURL
+	// Agents verify the API server's certificate against the
+	// environment's CA. Older agents cannot, and verify only the
+	// tools' checksum when they are unpacked.
+	result.FromAPIServer = true
+	result.DisableSSLHostnameVerification = true
+	return nil
+}
+
+// isStorageURL reports whether toolsURL refers to the named file in
+// the given storage. Query parameters are ignored, as storage URLs
+// may be signed afresh each time they are made.
+func isStorageURL(stor storage.StorageReader, name, toolsURL string) (bool, error) {
+	storageURL, err := stor.URL(name)
+	if err != nil {
+		return false, err
+	}
+	want, err := url.Parse(storageURL)
+	if err != nil {
+		return false, err
+	}
+	got, err := url.Parse(toolsURL)
+	if err != nil {
+		return false, nil
+	}
+	return got.Scheme == want.Scheme && got.Host == want.Host && got.Path == want.Path, nil
+}
+
+// VerifyToolsDownload checks the query of a tools download proxied
+// through an API server, as made by SetExpiringToolsURL, and returns
+// the version of the tools to download.
+func VerifyToolsDownload(st DownloadURLState, query url.Values) (version.Binary, error) {
+	vers, err := version.ParseBinary(query.Get("version"))
+	if err != nil {
+		return version.Binary{}, errors.Errorf("invalid tools version %q", query.Get("version"))
+	}
+	if err := verifyDownload(st, toolsResource(vers), query); err != nil {
+		return version.Binary{}, err
+	}
+	return vers, nil
+}
+
+// toolsResource returns the resource signed in a proxied download of
+// the given tools.
+func toolsResource(vers version.Binary) string {
+	return "tools " + vers.String()
+}
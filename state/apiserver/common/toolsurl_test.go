@@ -0,0 +1,203 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common_test
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/storage"
+	envtesting "github.com/juju/juju/environs/testing"
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+type toolsURLSuite struct {
+	testing.JujuConnSuite
+	tools *coretools.Tools
+}
+
+var _ = gc.Suite(&toolsURLSuite{})
+
+func (s *toolsURLSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	vers := version.MustParseBinary("1.2.3-quantal-amd64")
+	s.tools = envtesting.AssertUploadFakeToolsVersions(c, s.Environ.Storage(), vers)[0]
+}
+
+func (s *toolsURLSuite) setServingInfo(c *gc.C) {
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *toolsURLSuite) result() *params.ToolsResult {
+	tools := *s.tools
+	return &params.ToolsResult{Tools: &tools}
+}
+
+func (s *toolsURLSuite) TestNoServingInfo(c *gc.C) {
+	result := s.result()
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Tools.URL, gc.Equals, s.tools.URL)
+	c.Assert(result.DisableSSLHostnameVerification, jc.IsFalse)
+}
+
+func (s *toolsURLSuite) TestNotInStorage(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	result.Tools.URL = "https://example.com/juju-1.2.3-quantal-amd64.tgz"
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Tools.URL, gc.Equals, "https://example.com/juju-1.2.3-quantal-amd64.tgz")
+	c.Assert(result.DisableSSLHostnameVerification, jc.IsFalse)
+}
+
+func (s *toolsURLSuite) TestProxied(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.FromAPIServer, jc.IsTrue)
+	c.Assert(result.DisableSSLHostnameVerification, jc.IsTrue)
+
+	proxyURL, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(proxyURL.Scheme, gc.Equals, "https")
+	c.Assert(proxyURL.Host, gc.Equals, "10.0.0.1:17070")
+	c.Assert(proxyURL.Path, gc.Equals, "/environment/"+s.State.EnvironTag().Id()+"/tools/download")
+
+	vers, err := common.VerifyToolsDownload(s.State, proxyURL.Query())
+	c.Assert(err, gc.IsNil)
+	c.Assert(vers, gc.Equals, s.tools.Version)
+}
+
+func (s *toolsURLSuite) TestProxiedSkipsUnreachableServers(c *gc.C) {
+	s.setServingInfo(c)
+	err := s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("203.0.113.1", network.ScopePublic),
+		Port:    17070,
+	}}, {{
+		Address: network.NewAddress("10.0.0.2", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+	result := s.result()
+	err = common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	proxyURL, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(proxyURL.Host, gc.Equals, "10.0.0.2:17070")
+}
+
+func (s *toolsURLSuite) TestSignatureIndependentOfServingInfo(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	proxyURL, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+
+	// A new certificate and key do not invalidate the URL.
+	err = s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "another key",
+		Cert:         "another cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	_, err = common.VerifyToolsDownload(s.State, proxyURL.Query())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *toolsURLSuite) TestVerifyBadSignature(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	proxyURL, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+
+	query := proxyURL.Query()
+	query.Set("version", "1.2.4-quantal-amd64")
+	_, err = common.VerifyToolsDownload(s.State, query)
+	c.Assert(err, gc.Equals, common.ErrPerm)
+}
+
+func (s *toolsURLSuite) TestVerifyExpired(c *gc.C) {
+	s.setServingInfo(c)
+	s.PatchValue(common.DownloadURLExpiry, -time.Minute)
+	result := s.result()
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	proxyURL, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+
+	_, err = common.VerifyToolsDownload(s.State, proxyURL.Query())
+	c.Assert(err, gc.ErrorMatches, "download URL has expired")
+}
+
+func (s *toolsURLSuite) TestSignedStorageURL(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	env := &signingEnviron{s.Environ, nil}
+	err := common.SetExpiringToolsURL(s.State, env, result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Tools.URL, gc.Equals, "https://signed.example.com/tools/releases/juju-1.2.3-quantal-amd64.tgz")
+	c.Assert(result.DisableSSLHostnameVerification, jc.IsFalse)
+}
+
+func (s *toolsURLSuite) TestSignedStorageURLNotSupported(c *gc.C) {
+	s.setServingInfo(c)
+	result := s.result()
+	env := &signingEnviron{s.Environ, errors.NotSupportedf("signed URLs")}
+	err := common.SetExpiringToolsURL(s.State, env, result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Tools.URL, gc.Matches, "https://10.0.0.1:17070/.*/tools/download\\?.*")
+	c.Assert(result.DisableSSLHostnameVerification, jc.IsTrue)
+}
+
+// signingEnviron is an environs.Environ whose storage signs URLs,
+// failing with err if it is not nil.
+type signingEnviron struct {
+	environs.Environ
+	err error
+}
+
+func (e *signingEnviron) Storage() storage.Storage {
+	return &signingStorage{e.Environ.Storage(), e.err}
+}
+
+type signingStorage struct {
+	storage.Storage
+	err error
+}
+
+func (s *signingStorage) SignedURL(name string, expires time.Time) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "https://signed.example.com/" + name, nil
+}
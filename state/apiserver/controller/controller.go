@@ -0,0 +1,38 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller
+
+import (
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+func init() {
+	common.RegisterStandardFacade("Controller", 0, NewControllerAPI)
+}
+
+// ControllerAPI implements the API used to read the settings shared
+// by all environments hosted by the controller.
+type ControllerAPI struct {
+	st *state.State
+}
+
+// NewControllerAPI creates a new server-side ControllerAPI facade.
+func NewControllerAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*ControllerAPI, error) {
+	if !authorizer.AuthClient() && !authorizer.AuthEnvironManager() {
+		return nil, common.ErrPerm
+	}
+	return &ControllerAPI{st: st}, nil
+}
+
+// ControllerSettings returns the settings shared by all environments
+// hosted by the controller.
+func (c *ControllerAPI) ControllerSettings() (params.ControllerSettingsResult, error) {
+	settings, err := c.st.ControllerSettings()
+	if err != nil {
+		return params.ControllerSettingsResult{}, err
+	}
+	return params.ControllerSettingsResult{Settings: settings}, nil
+}
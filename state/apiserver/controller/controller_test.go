@@ -0,0 +1,69 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package controller_test
+
+import (
+	"github.com/juju/names"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/apiserver/common"
+	"github.com/juju/juju/state/apiserver/controller"
+	apiservertesting "github.com/juju/juju/state/apiserver/testing"
+)
+
+type controllerSuite struct {
+	testing.JujuConnSuite
+
+	authorizer apiservertesting.FakeAuthorizer
+	resources  *common.Resources
+	api        *controller.ControllerAPI
+}
+
+var _ = gc.Suite(&controllerSuite{})
+
+func (s *controllerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag:      names.NewUserTag("admin"),
+		LoggedIn: true,
+		Client:   true,
+	}
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	var err error
+	s.api, err = controller.NewControllerAPI(s.State, s.resources, s.authorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *controllerSuite) TestNewControllerAPIRefusesAgents(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Client = false
+	anAuthorizer.MachineAgent = true
+	anAuthorizer.Tag = names.NewMachineTag("1")
+	api, err := controller.NewControllerAPI(s.State, s.resources, anAuthorizer)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(api, gc.IsNil)
+}
+
+func (s *controllerSuite) TestNewControllerAPIAllowsEnvironManager(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Client = false
+	anAuthorizer.MachineAgent = true
+	anAuthorizer.EnvironManager = true
+	anAuthorizer.Tag = names.NewMachineTag("0")
+	_, err := controller.NewControllerAPI(s.State, s.resources, anAuthorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *controllerSuite) TestControllerSettings(c *gc.C) {
+	expected, err := s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	result, err := s.api.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Settings, gc.DeepEquals, expected)
+	c.Assert(result.Settings["name"], gc.IsNil)
+}
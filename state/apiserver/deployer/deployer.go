@@ -8,6 +8,8 @@ import (
 
 	"github.com/juju/names"
 
+	"github.com/juju/juju/environs"
+	envtools "github.com/juju/juju/environs/tools"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver/common"
@@ -86,6 +88,40 @@ func (d *DeployerAPI) ConnectionInfo() (result params.DeployerConnectionValues,
 	return result, err
 }
 
+// FindAgentTools returns the tools metadata for the tools version
+// that each given agent is running. It is used by the deployer to
+// verify the machine agent's own tools before linking them for the
+// units it deploys; only the machine agent's own tools may be found.
+func (d *DeployerAPI) FindAgentTools(args params.EntitiesVersion) (params.ToolsResults, error) {
+	result := params.ToolsResults{
+		Results: make([]params.ToolsResult, len(args.AgentTools)),
+	}
+	if len(args.AgentTools) == 0 {
+		return result, nil
+	}
+	cfg, err := d.st.EnvironConfig()
+	if err != nil {
+		return result, err
+	}
+	env, err := environs.New(cfg)
+	if err != nil {
+		return result, err
+	}
+	for i, arg := range args.AgentTools {
+		err := common.ErrPerm
+		if d.authorizer.AuthOwner(arg.Tag) {
+			if arg.Tools == nil {
+				err = fmt.Errorf("no tools version specified")
+			} else {
+				vers := arg.Tools.Version
+				result.Results[i].Tools, err = envtools.FindExactTools(env, vers.Number, vers.Series, vers.Arch)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // getAllUnits returns a list of all principal and subordinate units
 // assigned to the given machine.
 func getAllUnits(st *state.State, tag names.Tag) ([]string, error) {
@@ -21,6 +21,7 @@ import (
 	apiservertesting "github.com/juju/juju/state/apiserver/testing"
 	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 )
 
 func Test(t *stdtesting.T) {
@@ -337,3 +338,25 @@ func (s *deployerSuite) TestCACert(c *gc.C) {
 		Result: []byte(s.State.CACert()),
 	})
 }
+
+func (s *deployerSuite) TestFindAgentTools(c *gc.C) {
+	// The tools found are those for the version given, not the
+	// environment's agent-version.
+	vers := version.Current
+	args := params.EntitiesVersion{AgentTools: []params.EntityVersion{
+		{Tag: "machine-1", Tools: &params.Version{Version: vers}},
+		{Tag: "machine-1"},
+		{Tag: "machine-0", Tools: &params.Version{Version: vers}},
+		{Tag: "unit-mysql-0", Tools: &params.Version{Version: vers}},
+	}}
+	results, err := s.deployer.FindAgentTools(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 4)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	agentTools := results.Results[0].Tools
+	c.Check(agentTools.Version, gc.Equals, vers)
+	c.Check(agentTools.SHA256, gc.Not(gc.Equals), "")
+	c.Check(results.Results[1].Error, gc.ErrorMatches, "no tools version specified")
+	c.Check(results.Results[2].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+	c.Check(results.Results[3].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+}
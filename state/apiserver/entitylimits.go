@@ -0,0 +1,124 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/presence"
+)
+
+var (
+	// maxEntityConnections defines how many API connections each
+	// agent may have open at once.
+	maxEntityConnections = 10
+
+	// maxEntityRequests defines how many requests each agent may
+	// have in progress at once, across all its connections. Each
+	// watcher an agent holds usually has a request in progress.
+	maxEntityRequests = 200
+)
+
+// entityLimiter tracks the connections and requests in progress for
+// each authenticated agent, so that a runaway agent cannot overwhelm
+// the API server. Requests over the limits are refused with an error
+// asking the agent to try again.
+type entityLimiter struct {
+	mu          sync.Mutex
+	connections map[string]int
+	requests    map[string]int
+}
+
+func newEntityLimiter() *entityLimiter {
+	return &entityLimiter{
+		connections: make(map[string]int),
+		requests:    make(map[string]int),
+	}
+}
+
+// limitsApply reports whether the connections and requests of the
+// given authenticated entity are limited. Only agents are limited,
+// and not those of state servers, whose requests grow in number with
+// the size of the environment.
+func limitsApply(entity state.Entity) bool {
+	if _, ok := entity.(presence.Presencer); !ok {
+		return false
+	}
+	if machine, ok := entity.(*state.Machine); ok && machine.IsManager() {
+		return false
+	}
+	return true
+}
+
+// acquireConnection records a new connection for the entity with the
+// given tag, failing if it already has too many.
+func (l *entityLimiter) acquireConnection(tag string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.connections[tag] >= maxEntityConnections {
+		logger.Warningf("refusing connection: %s already has %d connections", tag, l.connections[tag])
+		return tryAgainError(fmt.Sprintf("too many connections for %s", tag))
+	}
+	l.connections[tag]++
+	return nil
+}
+
+// releaseConnection records that a connection for the entity with the
+// given tag has closed.
+func (l *entityLimiter) releaseConnection(tag string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.connections[tag]--; l.connections[tag] <= 0 {
+		delete(l.connections, tag)
+	}
+}
+
+// acquireRequest records a new request in progress for the entity
+// with the given tag, failing if it already has too many.
+func (l *entityLimiter) acquireRequest(tag string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.requests[tag] >= maxEntityRequests {
+		logger.Warningf("refusing request: %s already has %d requests in progress", tag, l.requests[tag])
+		return tryAgainError(fmt.Sprintf("too many requests in progress for %s", tag))
+	}
+	l.requests[tag]++
+	return nil
+}
+
+// releaseRequest records that a request for the entity with the given
+// tag has completed.
+func (l *entityLimiter) releaseRequest(tag string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.requests[tag]--; l.requests[tag] <= 0 {
+		delete(l.requests, tag)
+	}
+}
+
+func tryAgainError(msg string) error {
+	return &params.Error{
+		Message: msg + "; try again later",
+		Code:    params.CodeTryAgain,
+	}
+}
+
+// entityConnection is registered as a resource of a limited agent's
+// connection, so that the connection is released when it closes.
+type entityConnection struct {
+	limiter *entityLimiter
+	tag     string
+	once    sync.Once
+}
+
+// Stop implements common.Resource.
+func (c *entityConnection) Stop() error {
+	c.once.Do(func() {
+		c.limiter.releaseConnection(c.tag)
+	})
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver"
+	coretesting "github.com/juju/juju/testing"
+)
+
+type entityLimitsSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&entityLimitsSuite{})
+
+// machineInfo returns the information needed to connect to the API
+// as a new machine with the given jobs.
+func (s *entityLimitsSuite) machineInfo(c *gc.C, jobs ...state.MachineJob) *api.Info {
+	machine, err := s.State.AddMachine("quantal", jobs...)
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = machine.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned("foo", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	info := s.APIInfo(c)
+	info.Tag = machine.Tag()
+	info.Password = password
+	info.Nonce = "fake_nonce"
+	return info
+}
+
+func (s *entityLimitsSuite) TestConnectionLimit(c *gc.C) {
+	s.PatchValue(apiserver.MaxEntityConnections, 1)
+	info := s.machineInfo(c, state.JobHostUnits)
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+
+	_, err = api.Open(info, fastDialOpts)
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	c.Assert(err, gc.ErrorMatches, `too many connections for machine-[0-9]+; try again later`)
+
+	// Once the first connection is closed, another may be opened.
+	err = st.Close()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		st, err = api.Open(info, fastDialOpts)
+		if !params.IsCodeTryAgain(err) {
+			break
+		}
+	}
+	c.Assert(err, gc.IsNil)
+	st.Close()
+}
+
+func (s *entityLimitsSuite) TestRequestLimit(c *gc.C) {
+	s.PatchValue(apiserver.MaxEntityRequests, 1)
+	info := s.machineInfo(c, state.JobHostUnits)
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	machine, err := st.Machiner().Machine(info.Tag.(names.MachineTag))
+	c.Assert(err, gc.IsNil)
+
+	// A watcher keeps a request in progress, waiting for changes.
+	w, err := machine.Watch()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		_, err = st.Machiner().Machine(info.Tag.(names.MachineTag))
+		if err != nil {
+			break
+		}
+	}
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	c.Assert(err, gc.ErrorMatches, `too many requests in progress for machine-[0-9]+; try again later`)
+
+	// Once the watcher is stopped, requests are served again.
+	err = w.Stop()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		_, err = st.Machiner().Machine(info.Tag.(names.MachineTag))
+		if !params.IsCodeTryAgain(err) {
+			break
+		}
+	}
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *entityLimitsSuite) TestStateServerNotLimited(c *gc.C) {
+	s.PatchValue(apiserver.MaxEntityConnections, 1)
+	info := s.machineInfo(c, state.JobManageEnviron)
+	for i := 0; i < 2; i++ {
+		st, err := api.Open(info, fastDialOpts)
+		c.Assert(err, gc.IsNil)
+		defer st.Close()
+	}
+}
+
+func (s *entityLimitsSuite) TestUserNotLimited(c *gc.C) {
+	s.PatchValue(apiserver.MaxEntityConnections, 1)
+	for i := 0; i < 2; i++ {
+		st, err := api.Open(s.APIInfo(c), fastDialOpts)
+		c.Assert(err, gc.IsNil)
+		defer st.Close()
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+
+	"github.com/juju/juju/environs"
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+func init() {
+	common.RegisterStandardFacade("EnvironmentManager", 0, NewEnvironmentManagerAPI)
+}
+
+// EnvironmentManagerAPI implements the API used by clients to create,
+// list and destroy the environments hosted by the state servers.
+type EnvironmentManagerAPI struct {
+	st    *state.State
+	owner names.UserTag
+}
+
+// NewEnvironmentManagerAPI creates a new server-side
+// EnvironmentManagerAPI facade. Only the administrator of the state
+// server environment may use it; it is not served to connections to
+// hosted environments.
+func NewEnvironmentManagerAPI(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*EnvironmentManagerAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	owner, ok := authorizer.GetAuthTag().(names.UserTag)
+	if !ok || owner.Name() != state.AdminUser {
+		return nil, common.ErrPerm
+	}
+	return &EnvironmentManagerAPI{st: st, owner: owner}, nil
+}
+
+// CreateEnvironment creates a new environment hosted by the state
+// servers. The new environment shares the configuration of the state
+// server environment, except for its secrets and where overridden by
+// args, and is given a new UUID unless one is supplied.
+func (em *EnvironmentManagerAPI) CreateEnvironment(args params.EnvironmentCreateArgs) (params.HostedEnvironment, error) {
+	var result params.HostedEnvironment
+	if _, ok := args.Config["name"]; !ok {
+		return result, errors.New("environment name must be specified")
+	}
+	serverCfg, err := em.st.EnvironConfig()
+	if err != nil {
+		return result, err
+	}
+	attrs, err := nonSecretAttrs(serverCfg)
+	if err != nil {
+		return result, err
+	}
+	delete(attrs, "uuid")
+	for key, value := range args.Config {
+		attrs[key] = value
+	}
+	if _, ok := attrs["uuid"]; !ok {
+		uuid, err := utils.NewUUID()
+		if err != nil {
+			return result, err
+		}
+		attrs["uuid"] = uuid.String()
+	}
+	cfg, err := config.New(config.NoDefaults, attrs)
+	if err != nil {
+		return result, err
+	}
+	env, err := em.st.NewEnvironment(cfg, em.owner)
+	if err != nil {
+		return result, err
+	}
+	return hostedEnvironment(env), nil
+}
+
+// nonSecretAttrs returns the attributes of the given configuration
+// other than the secrets and the CA private key, which must not be
+// shared with hosted environments.
+func nonSecretAttrs(cfg *config.Config) (map[string]interface{}, error) {
+	provider, err := environs.Provider(cfg.Type())
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := provider.SecretAttrs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	attrs := cfg.AllAttrs()
+	for key := range secrets {
+		delete(attrs, key)
+	}
+	for key := range cfg.SecretAttrs() {
+		delete(attrs, key)
+	}
+	delete(attrs, "ca-private-key")
+	return attrs, nil
+}
+
+// ListEnvironments returns all the environments known to the state
+// servers, including the one they were bootstrapped into.
+func (em *EnvironmentManagerAPI) ListEnvironments() (params.HostedEnvironmentList, error) {
+	envs, err := em.st.AllEnvironments()
+	if err != nil {
+		return params.HostedEnvironmentList{}, err
+	}
+	result := params.HostedEnvironmentList{
+		Environments: make([]params.HostedEnvironment, len(envs)),
+	}
+	for i, env := range envs {
+		result.Environments[i] = hostedEnvironment(env)
+	}
+	return result, nil
+}
+
+// DestroyEnvironments destroys the given hosted environments. The
+// environment the state servers were bootstrapped into cannot be
+// destroyed this way.
+func (em *EnvironmentManagerAPI) DestroyEnvironments(args params.Entities) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		err := em.destroyEnvironment(entity.Tag)
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (em *EnvironmentManagerAPI) destroyEnvironment(tag string) error {
+	envTag, err := names.ParseEnvironTag(tag)
+	if err != nil {
+		return common.ErrPerm
+	}
+	env, err := em.st.GetEnvironment(envTag)
+	if errors.IsNotFound(err) {
+		return common.ErrPerm
+	} else if err != nil {
+		return err
+	}
+	if !env.IsHosted() {
+		return errors.Errorf("cannot destroy state server environment %q", env.Name())
+	}
+	return env.Destroy()
+}
+
+func hostedEnvironment(env *state.Environment) params.HostedEnvironment {
+	return params.HostedEnvironment{
+		Name:       env.Name(),
+		UUID:       env.UUID(),
+		ServerUUID: env.ServerTag().Id(),
+		Life:       params.Life(env.Life().String()),
+		Phase:      string(env.Phase()),
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package environmentmanager_test
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	"github.com/juju/juju/state/apiserver/environmentmanager"
+	apiservertesting "github.com/juju/juju/state/apiserver/testing"
+)
+
+type environmentManagerSuite struct {
+	testing.JujuConnSuite
+
+	authorizer apiservertesting.FakeAuthorizer
+	resources  *common.Resources
+	api        *environmentmanager.EnvironmentManagerAPI
+}
+
+var _ = gc.Suite(&environmentManagerSuite{})
+
+func (s *environmentManagerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag:      names.NewUserTag("admin"),
+		LoggedIn: true,
+		Client:   true,
+	}
+	s.resources = common.NewResources()
+	s.AddCleanup(func(_ *gc.C) { s.resources.StopAll() })
+
+	var err error
+	s.api, err = environmentmanager.NewEnvironmentManagerAPI(s.State, s.resources, s.authorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *environmentManagerSuite) TestNewEnvironmentManagerAPIRefusesAgents(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Client = false
+	anAuthorizer.MachineAgent = true
+	anAuthorizer.EnvironManager = true
+	anAuthorizer.Tag = names.NewMachineTag("0")
+	api, err := environmentmanager.NewEnvironmentManagerAPI(s.State, s.resources, anAuthorizer)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(api, gc.IsNil)
+}
+
+func (s *environmentManagerSuite) TestNewEnvironmentManagerAPIRefusesNonAdminUsers(c *gc.C) {
+	anAuthorizer := s.authorizer
+	anAuthorizer.Tag = names.NewUserTag("bob")
+	api, err := environmentmanager.NewEnvironmentManagerAPI(s.State, s.resources, anAuthorizer)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(api, gc.IsNil)
+}
+
+func (s *environmentManagerSuite) TestCreateEnvironment(c *gc.C) {
+	serverEnv, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.api.CreateEnvironment(params.EnvironmentCreateArgs{
+		Config: map[string]interface{}{"name": "hosted"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Name, gc.Equals, "hosted")
+	c.Assert(result.UUID, gc.Not(gc.Equals), serverEnv.UUID())
+	c.Assert(result.ServerUUID, gc.Equals, serverEnv.UUID())
+	c.Assert(result.Life, gc.Equals, params.Alive)
+	c.Assert(result.Phase, gc.Equals, string(state.EnvironReady))
+
+	env, err := s.State.GetEnvironment(names.NewEnvironTag(result.UUID))
+	c.Assert(err, gc.IsNil)
+	cfg, err := env.Config()
+	c.Assert(err, gc.IsNil)
+	serverCfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Type(), gc.Equals, serverCfg.Type())
+
+	// Secrets are not shared with the hosted environment.
+	c.Assert(cfg.AllAttrs()["secret"], gc.IsNil)
+	_, ok := cfg.CAPrivateKey()
+	c.Assert(ok, jc.IsFalse)
+
+	// Only the user that created it may connect to it.
+	c.Assert(env.HasAccess(names.NewUserTag("admin")), jc.IsTrue)
+	c.Assert(env.HasAccess(names.NewUserTag("bob")), jc.IsFalse)
+}
+
+func (s *environmentManagerSuite) TestCreateEnvironmentRequiresName(c *gc.C) {
+	_, err := s.api.CreateEnvironment(params.EnvironmentCreateArgs{})
+	c.Assert(err, gc.ErrorMatches, "environment name must be specified")
+}
+
+func (s *environmentManagerSuite) TestListEnvironments(c *gc.C) {
+	created, err := s.api.CreateEnvironment(params.EnvironmentCreateArgs{
+		Config: map[string]interface{}{"name": "zzz-hosted"},
+	})
+	c.Assert(err, gc.IsNil)
+	serverEnv, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.api.ListEnvironments()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Environments, jc.DeepEquals, []params.HostedEnvironment{{
+		Name:       serverEnv.Name(),
+		UUID:       serverEnv.UUID(),
+		ServerUUID: serverEnv.UUID(),
+		Life:       params.Alive,
+		Phase:      string(serverEnv.Phase()),
+	}, created})
+}
+
+func (s *environmentManagerSuite) TestDestroyEnvironments(c *gc.C) {
+	created, err := s.api.CreateEnvironment(params.EnvironmentCreateArgs{
+		Config: map[string]interface{}{"name": "hosted"},
+	})
+	c.Assert(err, gc.IsNil)
+	serverEnv, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.api.DestroyEnvironments(params.Entities{Entities: []params.Entity{
+		{Tag: names.NewEnvironTag(created.UUID).String()},
+		{Tag: serverEnv.Tag().String()},
+		{Tag: "machine-0"},
+	}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.ErrorResults{Results: []params.ErrorResult{
+		{Error: nil},
+		{Error: &params.Error{Message: `cannot destroy state server environment "` + serverEnv.Name() + `"`}},
+		{Error: apiservertesting.ErrUnauthorized},
+	}})
+
+	_, err = s.State.GetEnvironment(names.NewEnvironTag(created.UUID))
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = serverEnv.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(serverEnv.Life(), gc.Equals, state.Alive)
+}
@@ -16,7 +16,15 @@ var (
 	NewPingTimeout        = newPingTimeout
 	MaxClientPingInterval = &maxClientPingInterval
 	MongoPingInterval     = &mongoPingInterval
+	DrainTimeout          = &drainTimeout
+	WriteTimeout          = &writeTimeout
+	OutboundQueueSize     = &outboundQueueSize
+	CompressThreshold     = &compressThreshold
+	MaxEntityConnections  = &maxEntityConnections
+	MaxEntityRequests     = &maxEntityRequests
 	UploadBackupToStorage = &uploadBackupToStorage
+	MaxCachedArchives     = &maxCachedArchives
+	MaxCachedArchiveBytes = &maxCachedArchiveBytes
 )
 
 const LoginRateLimit = loginRateLimit
@@ -56,6 +64,14 @@ func TestingSrvRoot(st *state.State) *srvRoot {
 	}
 }
 
+// TestingHostedEnvironRoot returns a limited hostedEnvironRoot
+// containing a srvRoot as returned by TestingSrvRoot.
+func TestingHostedEnvironRoot(st *state.State) *hostedEnvironRoot {
+	return &hostedEnvironRoot{
+		srvRoot: *TestingSrvRoot(st),
+	}
+}
+
 // TestingUpgradingSrvRoot returns a limited upgradingSrvRoot
 // containing a srvRoot as returned by TestingSrvRoot.
 func TestingUpgradingRoot(st *state.State) *upgradingRoot {
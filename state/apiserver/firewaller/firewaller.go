@@ -98,20 +98,21 @@ func NewFirewallerAPI(
 	}, nil
 }
 
-// OpenedPorts returns the list of opened ports for each given unit.
-func (f *FirewallerAPI) OpenedPorts(args params.Entities) (params.PortsResults, error) {
-	result := params.PortsResults{
-		Results: make([]params.PortsResult, len(args.Entities)),
+// OpenedPorts returns the list of opened port ranges for each given
+// unit.
+func (f *FirewallerAPI) OpenedPorts(args params.Entities) (params.PortRangesResults, error) {
+	result := params.PortRangesResults{
+		Results: make([]params.PortRangesResult, len(args.Entities)),
 	}
 	canAccess, err := f.accessUnit()
 	if err != nil {
-		return params.PortsResults{}, err
+		return params.PortRangesResults{}, err
 	}
 	for i, entity := range args.Entities {
 		var unit *state.Unit
 		unit, err = f.getUnit(canAccess, entity.Tag)
 		if err == nil {
-			result.Results[i].Ports = unit.OpenedPorts()
+			result.Results[i].PortRanges = unit.OpenedPorts()
 		}
 		result.Results[i].Error = common.ServerError(err)
 	}
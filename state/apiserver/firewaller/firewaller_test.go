@@ -333,6 +333,9 @@ func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	err = s.units[2].OpenPort("tcp", 1111)
 	c.Assert(err, gc.IsNil)
+	// Ranges are reported as they were opened.
+	err = s.units[2].OpenPorts("tcp", 2000, 2999)
+	c.Assert(err, gc.IsNil)
 
 	args := addFakeEntities(params.Entities{Entities: []params.Entity{
 		{Tag: s.units[0].Tag().String()},
@@ -341,11 +344,11 @@ func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	}})
 	result, err := s.firewaller.OpenedPorts(args)
 	c.Assert(err, gc.IsNil)
-	c.Assert(result, jc.DeepEquals, params.PortsResults{
-		Results: []params.PortsResult{
-			{Ports: []network.Port{{"tcp", 1234}, {"tcp", 4321}}},
-			{Ports: []network.Port{}},
-			{Ports: []network.Port{{"tcp", 1111}}},
+	c.Assert(result, jc.DeepEquals, params.PortRangesResults{
+		Results: []params.PortRangesResult{
+			{PortRanges: []network.PortRange{{1234, 1234, "tcp"}, {4321, 4321, "tcp"}}},
+			{PortRanges: []network.PortRange{}},
+			{PortRanges: []network.PortRange{{1111, 1111, "tcp"}, {2000, 2999, "tcp"}}},
 			{Error: apiservertesting.ErrUnauthorized},
 			{Error: apiservertesting.NotFoundError(`unit "foo/0"`)},
 			{Error: apiservertesting.ErrUnauthorized},
@@ -364,9 +367,9 @@ func (s *firewallerSuite) TestOpenedPorts(c *gc.C) {
 	}}
 	result, err = s.firewaller.OpenedPorts(args)
 	c.Assert(err, gc.IsNil)
-	c.Assert(result, jc.DeepEquals, params.PortsResults{
-		Results: []params.PortsResult{
-			{Ports: []network.Port{}},
+	c.Assert(result, jc.DeepEquals, params.PortRangesResults{
+		Results: []params.PortRangesResult{
+			{PortRanges: []network.PortRange{{2000, 2999, "tcp"}}},
 		},
 	})
 }
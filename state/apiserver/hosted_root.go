@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"github.com/juju/errors"
+	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/rpc/rpcreflect"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+var hostedEnvironError = errors.New("environment is hosted by another environment's state servers - Juju functionality is limited")
+
+// hostedEnvironRoot is the root served to connections made to an
+// environment hosted by the state servers of another environment.
+// Machines, services and units are not yet kept separately for each
+// hosted environment, so only the facades that do not depend on them
+// are served.
+type hostedEnvironRoot struct {
+	srvRoot
+}
+
+var _ apiRoot = (*hostedEnvironRoot)(nil)
+
+// newHostedEnvironRoot creates a root where all calls to facades that
+// are not independent of the environment fail with
+// hostedEnvironError.
+func newHostedEnvironRoot(root *initialRoot, entity state.Entity) *hostedEnvironRoot {
+	return &hostedEnvironRoot{
+		srvRoot: *newSrvRoot(root, entity),
+	}
+}
+
+// FindMethod extends srvRoot.FindMethod. It returns hostedEnvironError
+// for calls to facades that are not served for hosted environments.
+func (r *hostedEnvironRoot) FindMethod(rootName string, version int, methodName string) (rpcreflect.MethodCaller, error) {
+	if _, _, err := r.lookupMethod(rootName, version, methodName); err != nil {
+		return nil, err
+	}
+	if !hostedEnvironFacades.Contains(rootName) {
+		return nil, hostedEnvironError
+	}
+	return r.srvRoot.FindMethod(rootName, version, methodName)
+}
+
+// DescribeFacades extends srvRoot.DescribeFacades to return only the
+// facades served for hosted environments.
+func (r *hostedEnvironRoot) DescribeFacades() []params.FacadeVersions {
+	var result []params.FacadeVersions
+	for _, facade := range r.srvRoot.DescribeFacades() {
+		if hostedEnvironFacades.Contains(facade.Name) {
+			result = append(result, facade)
+		}
+	}
+	return result
+}
+
+// hostedEnvironFacades holds the names of the facades served for
+// hosted environments. EnvironmentManager is deliberately absent:
+// environments may only be created and destroyed through the state
+// server environment.
+var hostedEnvironFacades = set.NewStrings(
+	"Controller",
+	"Pinger",
+)
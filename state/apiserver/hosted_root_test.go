@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/apiserver"
+	"github.com/juju/juju/testing"
+)
+
+type hostedEnvironRootSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&hostedEnvironRootSuite{})
+
+func (r *hostedEnvironRootSuite) TestFindAllowedMethod(c *gc.C) {
+	root := apiserver.TestingHostedEnvironRoot(nil)
+
+	caller, err := root.FindMethod("Pinger", 0, "Ping")
+
+	c.Assert(err, gc.IsNil)
+	c.Assert(caller, gc.NotNil)
+}
+
+func (r *hostedEnvironRootSuite) TestFindDisallowedMethod(c *gc.C) {
+	root := apiserver.TestingHostedEnvironRoot(nil)
+
+	for _, method := range []struct{ facade, name string }{
+		{"Client", "FullStatus"},
+		{"EnvironmentManager", "CreateEnvironment"},
+	} {
+		caller, err := root.FindMethod(method.facade, 0, method.name)
+
+		c.Assert(err, gc.ErrorMatches, "environment is hosted by another environment's state servers - Juju functionality is limited")
+		c.Assert(caller, gc.IsNil)
+	}
+}
+
+func (r *hostedEnvironRootSuite) TestFindNonExistentMethod(c *gc.C) {
+	root := apiserver.TestingHostedEnvironRoot(nil)
+
+	caller, err := root.FindMethod("Foo", 0, "Bar")
+
+	c.Assert(err, gc.ErrorMatches, "unknown object type \"Foo\"")
+	c.Assert(caller, gc.IsNil)
+}
+
+func (r *hostedEnvironRootSuite) TestDescribeFacades(c *gc.C) {
+	root := apiserver.TestingHostedEnvironRoot(nil)
+
+	var facadeNames []string
+	for _, facade := range root.DescribeFacades() {
+		facadeNames = append(facadeNames, facade.Name)
+	}
+	c.Assert(facadeNames, gc.DeepEquals, []string{"Controller", "Pinger"})
+}
@@ -24,6 +24,7 @@ type MachinerAPI struct {
 	*common.DeadEnsurer
 	*common.AgentEntityWatcher
 	*common.APIAddresser
+	*common.MetricsAdder
 
 	st           *state.State
 	auth         common.Authorizer
@@ -48,9 +49,11 @@ func NewMachinerAPI(st *state.State, resources *common.Resources, authorizer com
 		DeadEnsurer:        common.NewDeadEnsurer(st, getCanModify),
 		AgentEntityWatcher: common.NewAgentEntityWatcher(st, resources, getCanRead),
 		APIAddresser:       common.NewAPIAddresser(st, resources),
+		MetricsAdder:       common.NewMetricsAdder(st, getCanModify),
 		st:                 st,
 		auth:               authorizer,
 		getCanModify:       getCanModify,
+		getCanRead:         getCanRead,
 	}, nil
 }
 
@@ -85,3 +88,81 @@ func (api *MachinerAPI) SetMachineAddresses(args params.SetMachinesAddresses) (p
 	}
 	return results, nil
 }
+
+// UpgradeSeriesStatus returns the progress of any series upgrade of
+// each given machine, and the series it is being upgraded to.
+func (api *MachinerAPI) UpgradeSeriesStatus(args params.Entities) (params.UpgradeSeriesStatusResults, error) {
+	results := params.UpgradeSeriesStatusResults{
+		Results: make([]params.UpgradeSeriesStatusResult, len(args.Entities)),
+	}
+	canRead, err := api.getCanRead()
+	if err != nil {
+		return params.UpgradeSeriesStatusResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canRead(entity.Tag) {
+			var m *state.Machine
+			m, err = api.getMachine(entity.Tag)
+			if err == nil {
+				results.Results[i].Status, results.Results[i].Series = m.UpgradeSeriesStatus()
+			} else if errors.IsNotFound(err) {
+				err = common.ErrPerm
+			}
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+// HardwareCharacteristics returns the hardware characteristics
+// recorded for each given machine when it was provisioned.
+func (api *MachinerAPI) HardwareCharacteristics(args params.Entities) (params.HardwareCharacteristicsResults, error) {
+	results := params.HardwareCharacteristicsResults{
+		Results: make([]params.HardwareCharacteristicsResult, len(args.Entities)),
+	}
+	canRead, err := api.getCanRead()
+	if err != nil {
+		return params.HardwareCharacteristicsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canRead(entity.Tag) {
+			var m *state.Machine
+			m, err = api.getMachine(entity.Tag)
+			if err == nil {
+				results.Results[i].Characteristics, err = m.HardwareCharacteristics()
+			} else if errors.IsNotFound(err) {
+				err = common.ErrPerm
+			}
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+// SetUpgradeSeriesStatus records the machine agent's progress through
+// a series upgrade of each given machine.
+func (api *MachinerAPI) SetUpgradeSeriesStatus(args params.SetUpgradeSeriesStatus) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canModify, err := api.getCanModify()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Entities {
+		err := common.ErrPerm
+		if canModify(arg.Tag) {
+			var m *state.Machine
+			m, err = api.getMachine(arg.Tag)
+			if err == nil {
+				err = m.SetUpgradeSeriesStatus(arg.Status)
+			} else if errors.IsNotFound(err) {
+				err = common.ErrPerm
+			}
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
@@ -4,8 +4,12 @@
 package machine_test
 
 import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/instance"
 	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
@@ -182,6 +186,107 @@ func (s *machinerSuite) TestSetMachineAddresses(c *gc.C) {
 	c.Assert(s.machine0.MachineAddresses(), gc.HasLen, 0)
 }
 
+func (s *machinerSuite) TestAddMetrics(c *gc.C) {
+	metrics := []params.Metric{{Key: "cpu.load1", Value: "0.5", Time: time.Now()}}
+	args := params.AddMetrics{Entities: []params.EntityMetrics{
+		{Tag: "machine-1", Metrics: metrics},
+		{Tag: "machine-0", Metrics: metrics},
+		{Tag: "machine-42", Metrics: metrics},
+	}}
+
+	result, err := s.machiner.AddMetrics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	batches, err := s.State.MetricBatches("", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Entity(), gc.Equals, "machine-1")
+}
+
+func (s *machinerSuite) TestUpgradeSeriesStatus(c *gc.C) {
+	err := s.machine1.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "machine-1"},
+		{Tag: "machine-0"},
+		{Tag: "machine-42"},
+	}}
+	result, err := s.machiner.UpgradeSeriesStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.UpgradeSeriesStatusResults{
+		Results: []params.UpgradeSeriesStatusResult{
+			{Status: params.UpgradeSeriesPreparing, Series: "trusty"},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *machinerSuite) TestHardwareCharacteristics(c *gc.C) {
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "machine-1"},
+		{Tag: "machine-0"},
+		{Tag: "machine-42"},
+	}}
+	result, err := s.machiner.HardwareCharacteristics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 3)
+	c.Assert(result.Results[0].Error, gc.ErrorMatches, `instance data for machine 1 not found`)
+	c.Assert(result.Results[0].Error, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(result.Results[1].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+	c.Assert(result.Results[2].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	arch := "amd64"
+	mem := uint64(4096)
+	hc := &instance.HardwareCharacteristics{Arch: &arch, Mem: &mem}
+	err = s.machine1.SetProvisioned("i-am", "fake_nonce", hc)
+	c.Assert(err, gc.IsNil)
+
+	result, err = s.machiner.HardwareCharacteristics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.HardwareCharacteristicsResults{
+		Results: []params.HardwareCharacteristicsResult{
+			{Characteristics: hc},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *machinerSuite) TestSetUpgradeSeriesStatus(c *gc.C) {
+	err := s.machine1.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+
+	args := params.SetUpgradeSeriesStatus{Entities: []params.EntityUpgradeSeriesStatus{
+		{Tag: "machine-1", Status: params.UpgradeSeriesPrepared},
+		{Tag: "machine-0", Status: params.UpgradeSeriesPrepared},
+		{Tag: "machine-42", Status: params.UpgradeSeriesPrepared},
+	}}
+	result, err := s.machiner.SetUpgradeSeriesStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	err = s.machine1.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, series := s.machine1.UpgradeSeriesStatus()
+	c.Assert(status, gc.Equals, params.UpgradeSeriesPrepared)
+	c.Assert(series, gc.Equals, "trusty")
+}
+
 func (s *machinerSuite) TestWatch(c *gc.C) {
 	c.Assert(s.resources.Count(), gc.Equals, 0)
 
@@ -0,0 +1,252 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/juju/rpc"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+// latencyBuckets holds the upper bounds, in seconds, of the histogram
+// buckets used to record request and mongo latencies.
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+// histogram records the distribution of a set of durations.
+type histogram struct {
+	// buckets holds the number of observations that fell into each
+	// of latencyBuckets, but not into the one before.
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(latencyBuckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// write writes the histogram's samples in the Prometheus text format,
+// with the given labels added to each.
+func (h *histogram) write(w io.Writer, name string, labels []string) {
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		if h.buckets != nil {
+			cumulative += h.buckets[i]
+		}
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		writeSample(w, name+"_bucket", append(labels, "le", le), cumulative)
+	}
+	writeSample(w, name+"_bucket", append(labels, "le", "+Inf"), h.count)
+	writeSample(w, name+"_sum", labels, h.sum)
+	writeSample(w, name+"_count", labels, h.count)
+}
+
+// requestKey identifies an API method.
+type requestKey struct {
+	facade string
+	method string
+}
+
+// serverMetrics collects the health metrics of an API server, to be
+// served at /metrics for scraping by monitoring systems.
+type serverMetrics struct {
+	mu               sync.Mutex
+	connections      int
+	connectionsTotal uint64
+	requests         map[requestKey]*histogram
+	requestErrors    map[requestKey]uint64
+	watchers         int
+	mongoPings       histogram
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests:      make(map[requestKey]*histogram),
+		requestErrors: make(map[requestKey]uint64),
+	}
+}
+
+func (m *serverMetrics) connectionOpened() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connections++
+	m.connectionsTotal++
+}
+
+func (m *serverMetrics) connectionClosed() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connections--
+}
+
+// requestServed records the time taken to serve a request, and whether
+// it failed. Requests for unknown facades or methods are not recorded,
+// so that clients cannot add arbitrary labels.
+func (m *serverMetrics) requestServed(req rpc.Request, hdr *rpc.Header, timeSpent time.Duration) {
+	if hdr.ErrorCode == params.CodeNotImplemented {
+		return
+	}
+	key := requestKey{req.Type, req.Action}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.requests[key]
+	if h == nil {
+		h = &histogram{}
+		m.requests[key] = h
+	}
+	h.observe(timeSpent)
+	if hdr.Error != "" {
+		m.requestErrors[key]++
+	}
+}
+
+// watchersChanged records a change in the number of watchers held by
+// a logged in connection. It is called by the connection's resources,
+// which are observed from when the connection logs in.
+func (m *serverMetrics) watchersChanged(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watchers += delta
+}
+
+func (m *serverMetrics) mongoPinged(timeSpent time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mongoPings.observe(timeSpent)
+}
+
+// write writes all the metrics in the Prometheus text format.
+func (m *serverMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeHeader(w, "juju_apiserver_connections", "gauge", "Number of open API connections.")
+	writeSample(w, "juju_apiserver_connections", nil, m.connections)
+	writeHeader(w, "juju_apiserver_connections_total", "counter", "Number of API connections accepted.")
+	writeSample(w, "juju_apiserver_connections_total", nil, m.connectionsTotal)
+
+	writeHeader(w, "juju_apiserver_watchers", "gauge", "Number of watchers held by API connections.")
+	writeSample(w, "juju_apiserver_watchers", nil, m.watchers)
+
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Sort(requestKeys(keys))
+	writeHeader(w, "juju_apiserver_request_duration_seconds", "histogram", "Time taken to serve API requests.")
+	for _, key := range keys {
+		m.requests[key].write(w, "juju_apiserver_request_duration_seconds", key.labels())
+	}
+	writeHeader(w, "juju_apiserver_request_errors_total", "counter", "Number of API requests that returned an error.")
+	for _, key := range keys {
+		writeSample(w, "juju_apiserver_request_errors_total", key.labels(), m.requestErrors[key])
+	}
+
+	writeHeader(w, "juju_mongo_ping_duration_seconds", "histogram", "Time taken to ping the mongo database.")
+	m.mongoPings.write(w, "juju_mongo_ping_duration_seconds", nil)
+}
+
+func (key requestKey) labels() []string {
+	return []string{"facade", key.facade, "method", key.method}
+}
+
+type requestKeys []requestKey
+
+func (keys requestKeys) Len() int      { return len(keys) }
+func (keys requestKeys) Swap(i, j int) { keys[i], keys[j] = keys[j], keys[i] }
+func (keys requestKeys) Less(i, j int) bool {
+	if keys[i].facade != keys[j].facade {
+		return keys[i].facade < keys[j].facade
+	}
+	return keys[i].method < keys[j].method
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes a single sample; labels holds alternating label
+// names and values.
+func writeSample(w io.Writer, name string, labels []string, value interface{}) {
+	fmt.Fprint(w, name)
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i < len(labels); i += 2 {
+			pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1])))
+		}
+		fmt.Fprintf(w, "{%s}", strings.Join(pairs, ","))
+	}
+	fmt.Fprintf(w, " %v\n", value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsHandler serves the API server's metrics in the Prometheus
+// text format.
+type metricsHandler struct {
+	httpHandler
+	metrics *serverMetrics
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.authenticate(r); err != nil {
+		h.authError(w, h)
+		return
+	}
+	if r.Method != "GET" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	h.metrics.write(w)
+	if err := writeTxnPruneStats(w, h.state); err != nil {
+		logger.Warningf("cannot publish transaction pruning metrics: %v", err)
+	}
+}
+
+// writeTxnPruneStats writes the totals recorded by the transaction
+// pruner in the Prometheus text format. They are read from state
+// because the pruner runs on only one of the state servers.
+func writeTxnPruneStats(w io.Writer, st *state.State) error {
+	stats, err := st.TxnPruneStats()
+	if err != nil {
+		return err
+	}
+	writeHeader(w, "juju_txn_prune_runs_total", "counter", "Number of completed transaction pruning passes.")
+	writeSample(w, "juju_txn_prune_runs_total", nil, stats.Runs)
+	writeHeader(w, "juju_txn_pruned_total", "counter", "Number of completed transactions pruned.")
+	writeSample(w, "juju_txn_pruned_total", nil, stats.Pruned)
+	writeHeader(w, "juju_txn_prune_retained", "gauge", "Number of old transactions kept by the last pruning pass because they were still referenced.")
+	writeSample(w, "juju_txn_prune_retained", nil, stats.Retained)
+	return nil
+}
+
+// sendError sends an error response in plain text.
+func (h *metricsHandler) sendError(w http.ResponseWriter, statusCode int, message string) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(statusCode)
+	_, err := io.WriteString(w, message+"\n")
+	return err
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The metrics package implements the API used by monitoring
+// integrations to query the metrics sent by the agents.
+package metrics
+
+import (
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+func init() {
+	common.RegisterStandardFacade("Metrics", 0, NewMetricsAPI)
+}
+
+// Metrics defines the methods on the metrics API end point.
+type Metrics interface {
+	MetricBatches(args params.MetricsQueries) (params.MetricBatchesResults, error)
+}
+
+// MetricsAPI implements the Metrics interface and is the concrete
+// implementation of the api end point.
+type MetricsAPI struct {
+	state *state.State
+}
+
+var _ Metrics = (*MetricsAPI)(nil)
+
+// NewMetricsAPI creates a new server-side metrics API end point.
+func NewMetricsAPI(
+	st *state.State,
+	resources *common.Resources,
+	authorizer common.Authorizer,
+) (*MetricsAPI, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &MetricsAPI{state: st}, nil
+}
+
+// MetricBatches returns a page of the batches of metrics selected by
+// each of the given queries.
+func (api *MetricsAPI) MetricBatches(args params.MetricsQueries) (params.MetricBatchesResults, error) {
+	results := params.MetricBatchesResults{
+		Results: make([]params.MetricBatchesResult, len(args.Queries)),
+	}
+	for i, query := range args.Queries {
+		batches, marker, err := api.state.MetricBatchesPage(query.Tag, query.Since, query.Marker, query.Limit)
+		if err != nil {
+			results.Results[i].Error = common.ServerError(err)
+			continue
+		}
+		results.Results[i].Marker = marker
+		results.Results[i].Batches = make([]params.MetricBatch, len(batches))
+		for j, batch := range batches {
+			results.Results[i].Batches[j] = convertBatch(batch)
+		}
+	}
+	return results, nil
+}
+
+func convertBatch(batch *state.MetricBatch) params.MetricBatch {
+	metrics := batch.Metrics()
+	result := params.MetricBatch{
+		Tag:     batch.Entity(),
+		Created: batch.Created(),
+		Metrics: make([]params.Metric, len(metrics)),
+	}
+	for i, metric := range metrics {
+		result.Metrics[i] = params.Metric{
+			Key:   metric.Key,
+			Value: metric.Value,
+			Time:  metric.Time,
+		}
+	}
+	return result
+}
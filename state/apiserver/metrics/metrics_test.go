@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metrics_test
+
+import (
+	"time"
+
+	"github.com/juju/names"
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/metrics"
+	apiservertesting "github.com/juju/juju/state/apiserver/testing"
+)
+
+type metricsSuite struct {
+	jujutesting.JujuConnSuite
+
+	metrics    *metrics.MetricsAPI
+	authorizer apiservertesting.FakeAuthorizer
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.authorizer = apiservertesting.FakeAuthorizer{
+		Tag:      names.NewUserTag("admin"),
+		LoggedIn: true,
+		Client:   true,
+	}
+	var err error
+	s.metrics, err = metrics.NewMetricsAPI(s.State, nil, s.authorizer)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *metricsSuite) TestNewMetricsAPIRefusesNonClient(c *gc.C) {
+	anAuthoriser := s.authorizer
+	anAuthoriser.Client = false
+	endPoint, err := metrics.NewMetricsAPI(s.State, nil, anAuthoriser)
+	c.Assert(endPoint, gc.IsNil)
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
+
+func (s *metricsSuite) TestMetricBatches(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	now := time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC)
+	_, err = s.State.AddMetricBatch(m0.Tag().String(), []state.Metric{{Key: "cpu", Value: "1", Time: now}})
+	c.Assert(err, gc.IsNil)
+	batch, err := s.State.AddMetricBatch(m1.Tag().String(), []state.Metric{{Key: "cpu", Value: "2", Time: now}})
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.metrics.MetricBatches(params.MetricsQueries{
+		Queries: []params.MetricsQuery{
+			{Tag: m1.Tag().String()},
+			{},
+			{Since: time.Now().Add(time.Hour)},
+		},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 3)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[0].Batches, gc.HasLen, 1)
+	got := results.Results[0].Batches[0]
+	c.Assert(got.Tag, gc.Equals, m1.Tag().String())
+	c.Assert(got.Created.Equal(batch.Created()), gc.Equals, true)
+	c.Assert(got.Metrics, gc.HasLen, 1)
+	c.Assert(got.Metrics[0].Key, gc.Equals, "cpu")
+	c.Assert(got.Metrics[0].Value, gc.Equals, "2")
+	c.Assert(got.Metrics[0].Time.Equal(now), gc.Equals, true)
+	c.Assert(results.Results[1].Batches, gc.HasLen, 2)
+	c.Assert(results.Results[2].Batches, gc.HasLen, 0)
+}
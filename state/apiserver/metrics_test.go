@@ -0,0 +1,117 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+type metricsSuite struct {
+	authHttpSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) metricsURL(c *gc.C) string {
+	uri := s.baseURL(c)
+	uri.Path += "/metrics"
+	return uri.String()
+}
+
+func (s *metricsSuite) TestRequiresAuth(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.metricsURL(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusUnauthorized, "text/plain")
+	c.Assert(string(body), gc.Equals, "unauthorized\n")
+}
+
+func (s *metricsSuite) TestRequiresGET(c *gc.C) {
+	resp, err := s.authRequest(c, "POST", s.metricsURL(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusMethodNotAllowed, "text/plain")
+	c.Assert(string(body), gc.Equals, `unsupported method: "POST"`+"\n")
+}
+
+func (s *metricsSuite) TestAuthRequiresClientNotMachine(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetProvisioned("foo", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = machine.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+
+	resp, err := s.sendRequest(c, machine.Tag().String(), password, "GET", s.metricsURL(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	assertResponse(c, resp, http.StatusUnauthorized, "text/plain")
+}
+
+func (s *metricsSuite) TestMetrics(c *gc.C) {
+	// Make a request over the API connection, so that it is timed.
+	_, err := s.APIState.Client().Status(nil)
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().ServiceGet("no-such-service")
+	c.Assert(err, gc.NotNil)
+	// Watchers are counted.
+	w, err := s.APIState.Client().WatchAll()
+	c.Assert(err, gc.IsNil)
+	defer w.Stop()
+	// The transaction pruner's totals are published from state.
+	result, err := s.State.PruneTransactions(time.Hour, nil)
+	c.Assert(err, gc.IsNil)
+
+	resp, err := s.authRequest(c, "GET", s.metricsURL(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), gc.Equals, "text/plain; version=0.0.4")
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, gc.IsNil)
+	body := string(data)
+
+	c.Check(body, gc.Matches, `(?s).*# TYPE juju_apiserver_connections gauge\njuju_apiserver_connections [1-9][0-9]*\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_connections_total [1-9][0-9]*\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_watchers [1-9][0-9]*\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_request_duration_seconds_count\{facade="Client",method="FullStatus"\} 1\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_request_duration_seconds_bucket\{facade="Client",method="FullStatus",le="\+Inf"\} 1\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_request_errors_total\{facade="Client",method="FullStatus"\} 0\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_apiserver_request_errors_total\{facade="Client",method="ServiceGet"\} 1\n.*`)
+	c.Check(body, gc.Matches, `(?s).*# TYPE juju_mongo_ping_duration_seconds histogram\n.*`)
+	c.Check(body, gc.Matches, `(?s).*\njuju_txn_prune_runs_total 1\n.*`)
+	c.Check(body, gc.Matches, fmt.Sprintf(`(?s).*\njuju_txn_pruned_total %d\n.*`, result.Pruned))
+	c.Check(body, gc.Matches, `(?s).*# TYPE juju_txn_prune_retained gauge\n.*`)
+
+	// Every line is either a comment or a sample.
+	sample := regexp.MustCompile(`^[a-z_]+(\{[^}]*\})? [0-9.e+-]+$`)
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		if !strings.HasPrefix(line, "# ") {
+			c.Check(sample.MatchString(line), gc.Equals, true, gc.Commentf("line %q", line))
+		}
+	}
+}
+
+func (s *metricsSuite) TestUnknownMethodsNotRecorded(c *gc.C) {
+	err := s.APIState.Call("Client", "", "NoSuchMethod", nil, nil)
+	c.Assert(err, gc.ErrorMatches, `.*no such request.*`)
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeNotImplemented)
+
+	resp, err := s.authRequest(c, "GET", s.metricsURL(c), "", nil)
+	c.Assert(err, gc.IsNil)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Not(gc.Matches), `(?s).*NoSuchMethod.*`)
+}
@@ -35,6 +35,26 @@ var (
 	// alive. When the ping returns an error, the server will be
 	// terminated.
 	mongoPingInterval = 10 * time.Second
+
+	// drainTimeout defines how long a stopping API server waits
+	// for the requests in progress on each connection to complete
+	// before closing it regardless.
+	drainTimeout = 30 * time.Second
+
+	// writeTimeout defines how long the API server waits for a
+	// message to be written to a connection before giving up on
+	// the client and closing the connection.
+	writeTimeout = 30 * time.Second
+
+	// outboundQueueSize defines how many messages may wait to be
+	// written to a connection before the client is assumed to have
+	// stopped reading and the connection is closed.
+	outboundQueueSize = 1000
+
+	// compressThreshold defines the size in bytes from which
+	// messages are compressed for clients that accept compression.
+	// Most responses are small, and are not worth compressing.
+	compressThreshold = 16 * 1024
 )
 
 type objectKey struct {
@@ -49,7 +69,9 @@ type srvRoot struct {
 	state       *state.State
 	rpcConn     *rpc.Conn
 	resources   *common.Resources
+	metrics     *serverMetrics
 	entity      state.Entity
+	limiter     *entityLimiter
 	objectMutex sync.RWMutex
 	objectCache map[objectKey]reflect.Value
 }
@@ -64,10 +86,18 @@ func newSrvRoot(root *initialRoot, entity state.Entity) *srvRoot {
 		state:       root.srv.state,
 		rpcConn:     root.rpcConn,
 		resources:   common.NewResources(),
+		metrics:     root.srv.metrics,
 		entity:      entity,
 		objectCache: make(map[objectKey]reflect.Value),
 	}
+	if limitsApply(entity) {
+		r.limiter = root.srv.entityLimiter
+	}
 	r.resources.RegisterNamed("dataDir", common.StringResource(root.srv.dataDir))
+	if root.srv.modelCache != nil {
+		r.resources.RegisterNamed("modelCache", common.SharedModelCache{root.srv.modelCache})
+	}
+	r.resources.ObserveWatchers(r.metrics.watchersChanged)
 	return r
 }
 
@@ -93,6 +123,11 @@ type srvCaller struct {
 	objMethod rpcreflect.ObjMethod
 	goType    reflect.Type
 	creator   func(id string) (reflect.Value, error)
+
+	// limiter, if not nil, limits the requests in progress
+	// for the entity with the given tag.
+	limiter *entityLimiter
+	tag     string
 }
 
 // ParamsType defines the parameters that should be supplied to this function.
@@ -110,6 +145,12 @@ func (s *srvCaller) ResultType() reflect.Type {
 // Call takes the object Id and an instance of ParamsType to create an object and place
 // a call on its method. It then returns an instance of ResultType.
 func (s *srvCaller) Call(objId string, arg reflect.Value) (reflect.Value, error) {
+	if s.limiter != nil {
+		if err := s.limiter.acquireRequest(s.tag); err != nil {
+			return reflect.Value{}, err
+		}
+		defer s.limiter.releaseRequest(s.tag)
+	}
 	objVal, err := s.creator(objId)
 	if err != nil {
 		return reflect.Value{}, err
@@ -174,10 +215,18 @@ func (r *srvRoot) FindMethod(rootName string, version int, methodName string) (r
 		r.objectCache[objKey] = objValue
 		return objValue, nil
 	}
-	return &srvCaller{
+	caller := &srvCaller{
 		creator:   creator,
 		objMethod: objMethod,
-	}, nil
+	}
+	// Stop requests release resources, such as watchers with
+	// requests in progress, and pings keep the connection alive,
+	// so neither is ever refused.
+	if r.limiter != nil && methodName != "Stop" && rootName != "Pinger" {
+		caller.limiter = r.limiter
+		caller.tag = r.entity.Tag().String()
+	}
+	return caller, nil
 }
 
 func (r *srvRoot) lookupMethod(rootName string, version int, methodName string) (reflect.Type, rpcreflect.ObjMethod, error) {
@@ -94,6 +94,105 @@ func (s *serverSuite) TestStop(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 }
 
+func (s *serverSuite) newServer(c *gc.C) *apiserver.Server {
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, gc.IsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert: []byte(coretesting.ServerCert),
+		Key:  []byte(coretesting.ServerKey),
+	})
+	c.Assert(err, gc.IsNil)
+	return srv
+}
+
+func (s *serverSuite) TestStopDrainsConnections(c *gc.C) {
+	hostPorts := [][]network.HostPort{{{network.NewAddress("10.0.0.1", network.ScopeUnknown), 17070}}}
+	err := s.State.SetAPIHostPorts(hostPorts)
+	c.Assert(err, gc.IsNil)
+	srv := s.newServer(c)
+	defer srv.Stop()
+	info := s.APIInfo(c)
+	info.Addrs = []string{srv.Addr()}
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+	errResults, _ := startNLogins(c, 1, info)
+	// Give the login time to reach the server.
+	time.Sleep(coretesting.ShortWait)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- srv.Stop()
+	}()
+
+	// New requests are refused, naming the other API servers.
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		_, err = st.Client().EnvironmentInfo()
+		if err != nil {
+			break
+		}
+	}
+	c.Assert(err, jc.Satisfies, params.IsCodeTryAgain)
+	c.Assert(err, gc.ErrorMatches, "API server is shutting down; try again using one of: 10.0.0.1:17070")
+
+	// The login in progress is allowed to complete.
+	select {
+	case err := <-stopped:
+		c.Fatalf("server stopped while request in progress: %v", err)
+	case <-time.After(coretesting.ShortWait):
+	}
+	delayChan <- struct{}{}
+	select {
+	case err := <-errResults:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for login")
+	}
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for server to stop")
+	}
+}
+
+func (s *serverSuite) TestStopDrainTimeout(c *gc.C) {
+	s.PatchValue(apiserver.DrainTimeout, coretesting.ShortWait)
+	srv := s.newServer(c)
+	defer srv.Stop()
+	info := s.APIInfo(c)
+	info.Addrs = []string{srv.Addr()}
+
+	delayChan, cleanup := apiserver.DelayLogins()
+	defer cleanup()
+	errResults, _ := startNLogins(c, 1, info)
+	time.Sleep(coretesting.ShortWait)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- srv.Stop()
+	}()
+
+	// The connection is closed, and the server stops, once the
+	// drain times out, without waiting for the login to complete.
+	select {
+	case err := <-errResults:
+		c.Assert(err, gc.NotNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("connection not closed after drain timeout")
+	}
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for server to stop")
+	}
+	delayChan <- struct{}{}
+}
+
 func (s *serverSuite) TestAPIServerCanListenOnBothIPv4AndIPv6(c *gc.C) {
 	// Start our own instance of the server listening on
 	// both IPv4 and IPv6 localhost addresses and an ephemeral port.
@@ -294,6 +393,120 @@ func dialWebsocket(c *gc.C, addr, path string) (*websocket.Conn, error) {
 	return websocket.DialConfig(config)
 }
 
+func (s *serverSuite) TestModelCacheServesAddresses(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.setMachineAddress(c, m, "10.0.0.1")
+
+	listener, err := net.Listen("tcp", ":0")
+	c.Assert(err, gc.IsNil)
+	srv, err := apiserver.NewServer(s.State, listener, apiserver.ServerConfig{
+		Cert:                []byte(coretesting.ServerCert),
+		Key:                 []byte(coretesting.ServerKey),
+		ModelCacheStaleness: time.Hour,
+	})
+	c.Assert(err, gc.IsNil)
+	defer srv.Stop()
+	info := s.APIInfo(c)
+	info.Addrs = []string{srv.Addr()}
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+
+	addr, err := st.Client().PrivateAddress(m.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(addr, gc.Equals, "10.0.0.1")
+
+	// The change is not seen until the cache next catches up.
+	s.setMachineAddress(c, m, "10.0.0.2")
+	addr, err = st.Client().PrivateAddress(m.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(addr, gc.Equals, "10.0.0.1")
+}
+
+// setMachineAddress sets the address of the given machine and waits
+// until the state's multiwatcher has seen it.
+func (s *serverSuite) setMachineAddress(c *gc.C, m *state.Machine, address string) {
+	err := m.SetAddresses(network.NewAddress(address, network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.State.StartSync()
+		deltas, _, _, err := s.State.ChangesSince("")
+		c.Assert(err, gc.IsNil)
+		for _, delta := range deltas {
+			info, ok := delta.Entity.(*params.MachineInfo)
+			if ok && info.Id == m.Id() && len(info.Addresses) == 1 && info.Addresses[0].Value == address {
+				return
+			}
+		}
+	}
+	c.Fatalf("multiwatcher never saw address %q for machine %s", address, m.Id())
+}
+
+func (s *serverSuite) TestLoginToHostedEnvironment(c *gc.C) {
+	hosted, err := s.APIState.EnvironmentManager().CreateEnvironment(
+		map[string]interface{}{"name": "hosted"},
+	)
+	c.Assert(err, gc.IsNil)
+	hostedTag := names.NewEnvironTag(hosted.UUID)
+
+	info := s.APIInfo(c)
+	info.EnvironTag = hostedTag
+	st, err := api.Open(info, fastDialOpts)
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	c.Assert(st.EnvironTag(), gc.Equals, hostedTag.String())
+
+	// Facades that would act on the state server environment are
+	// not served, and nor is the one that manages environments.
+	_, err = st.Client().Status(nil)
+	c.Assert(err, gc.ErrorMatches, "environment is hosted by another environment's state servers - Juju functionality is limited")
+	_, err = st.EnvironmentManager().ListEnvironments()
+	c.Assert(err, gc.ErrorMatches, "environment is hosted by another environment's state servers - Juju functionality is limited")
+
+	// Once destroyed, the hosted environment can no longer be
+	// connected to.
+	err = s.APIState.EnvironmentManager().DestroyEnvironment(hostedTag)
+	c.Assert(err, gc.IsNil)
+	_, err = api.Open(info, fastDialOpts)
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf(`unknown environment: %q`, hosted.UUID))
+}
+
+func (s *serverSuite) TestLoginToHostedEnvironmentRequiresAccess(c *gc.C) {
+	hosted, err := s.APIState.EnvironmentManager().CreateEnvironment(
+		map[string]interface{}{"name": "hosted"},
+	)
+	c.Assert(err, gc.IsNil)
+	hostedTag := names.NewEnvironTag(hosted.UUID)
+
+	// Neither users it was not created for nor the agents of the
+	// state server environment may connect to it.
+	_, err = s.State.AddUser("bob", "", "bob-password", "admin")
+	c.Assert(err, gc.IsNil)
+	stm, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = stm.SetProvisioned("foo", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = stm.SetPassword("machine-password")
+	c.Assert(err, gc.IsNil)
+	for _, creds := range []struct {
+		tag      names.Tag
+		password string
+		nonce    string
+	}{
+		{names.NewUserTag("bob"), "bob-password", ""},
+		{stm.Tag(), "machine-password", "fake_nonce"},
+	} {
+		info := s.APIInfo(c)
+		info.EnvironTag = hostedTag
+		info.Tag = creds.tag
+		info.Password = creds.password
+		info.Nonce = creds.nonce
+		_, err = api.Open(info, fastDialOpts)
+		c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+	}
+}
+
 func (s *serverSuite) TestNonCompatiblePathsAre404(c *gc.C) {
 	// we expose the API at '/' for compatibility, and at '/ENVUUID/api'
 	// for the correct location, but other Paths should fail.
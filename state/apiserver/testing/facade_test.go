@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing_test
+
+import (
+	stdtesting "testing"
+
+	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	apiservertesting "github.com/juju/juju/state/apiserver/testing"
+	coretesting "github.com/juju/juju/testing"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type facadeCallerSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&facadeCallerSuite{})
+
+// echoFacade is a minimal facade used to test FacadeCaller.
+type echoFacade struct {
+	authorizer common.Authorizer
+}
+
+func newEchoFacade(st *state.State, resources *common.Resources, authorizer common.Authorizer) (*echoFacade, error) {
+	if !authorizer.AuthClient() {
+		return nil, common.ErrPerm
+	}
+	return &echoFacade{authorizer}, nil
+}
+
+func (e *echoFacade) Echo(args params.Entities) params.StringResults {
+	results := make([]params.StringResult, len(args.Entities))
+	for i, entity := range args.Entities {
+		if !e.authorizer.AuthOwner(entity.Tag) {
+			results[i].Error = common.ServerError(common.ErrPerm)
+			continue
+		}
+		results[i].Result = entity.Tag
+	}
+	return params.StringResults{Results: results}
+}
+
+func (e *echoFacade) Whoami() (params.StringResult, error) {
+	return params.StringResult{Result: e.authorizer.GetAuthTag().String()}, nil
+}
+
+func init() {
+	common.RegisterStandardFacade("FacadeCallerTest", 0, newEchoFacade)
+}
+
+func (s *facadeCallerSuite) caller(client bool) *apiservertesting.FacadeCaller {
+	return &apiservertesting.FacadeCaller{
+		Authorizer: apiservertesting.FakeAuthorizer{
+			Tag:    names.NewUserTag("admin"),
+			Client: client,
+		},
+	}
+}
+
+func (s *facadeCallerSuite) TestCallBulk(c *gc.C) {
+	result, err := s.caller(true).Call("FacadeCallerTest", 0, "Echo", params.Entities{
+		Entities: []params.Entity{{Tag: "user-admin"}, {Tag: "user-other"}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, jc.DeepEquals, params.StringResults{
+		Results: []params.StringResult{
+			{Result: "user-admin"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *facadeCallerSuite) TestCallNoArgs(c *gc.C) {
+	result, err := s.caller(true).Call("FacadeCallerTest", 0, "Whoami", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.Equals, params.StringResult{Result: "user-admin"})
+}
+
+func (s *facadeCallerSuite) TestCallUnauthorized(c *gc.C) {
+	_, err := s.caller(false).Call("FacadeCallerTest", 0, "Whoami", nil)
+	c.Assert(err, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+}
+
+func (s *facadeCallerSuite) TestCallUnknown(c *gc.C) {
+	_, err := s.caller(true).Call("FacadeCallerTest", 1, "Whoami", nil)
+	c.Assert(err, gc.ErrorMatches, `FacadeCallerTest\(1\) not found`)
+
+	_, err = s.caller(true).Call("FacadeCallerTest", 0, "Missing", nil)
+	c.Assert(err, gc.ErrorMatches, `no such method`)
+}
+
+func (s *facadeCallerSuite) TestCallBadArgs(c *gc.C) {
+	_, err := s.caller(true).Call("FacadeCallerTest", 0, "Whoami", params.Entities{})
+	c.Assert(err, gc.ErrorMatches, `method "Whoami" takes no arguments`)
+
+	_, err = s.caller(true).Call("FacadeCallerTest", 0, "Echo", params.Entity{})
+	c.Assert(err, gc.ErrorMatches, `method "Echo" takes params.Entities, not params.Entity`)
+}
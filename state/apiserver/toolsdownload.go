@@ -0,0 +1,68 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/juju/errors"
+
+	"github.com/juju/juju/environs"
+	envtools "github.com/juju/juju/environs/tools"
+	"github.com/juju/juju/state/apiserver/common"
+)
+
+// toolsDownloadHandler serves tools downloads from environment storage
+// through HTTPS in the API server, for agents whose tools cannot be
+// fetched from storage through a signed URL. Requests are authorized
+// by the expiring signature that common.SetExpiringToolsURL includes
+// in the URL, rather than by credentials.
+type toolsDownloadHandler struct {
+	toolsHandler
+}
+
+func (h *toolsDownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.validateEnvironUUID(r); err != nil {
+		h.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if r.Method != "GET" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	vers, err := common.VerifyToolsDownload(h.state, r.URL.Query())
+	if err == common.ErrPerm {
+		h.sendError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	envConfig, err := h.state.EnvironConfig()
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, fmt.Sprintf("cannot get environment config: %v", err))
+		return
+	}
+	env, err := environs.New(envConfig)
+	if err != nil {
+		h.sendError(w, http.StatusInternalServerError, fmt.Sprintf("cannot access environment: %v", err))
+		return
+	}
+	toolsReader, err := env.Storage().Get(envtools.StorageName(vers))
+	if errors.IsNotFound(err) {
+		h.sendError(w, http.StatusNotFound, fmt.Sprintf("tools %s not found", vers))
+		return
+	} else if err != nil {
+		h.sendError(w, http.StatusInternalServerError, fmt.Sprintf("cannot read tools %s: %v", vers, err))
+		return
+	}
+	defer toolsReader.Close()
+	w.Header().Set("Content-Type", "application/x-tar-gz")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, toolsReader); err != nil {
+		logger.Errorf("error sending tools %s: %v", vers, err)
+	}
+}
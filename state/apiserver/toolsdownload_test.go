@@ -0,0 +1,119 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	gc "launchpad.net/gocheck"
+
+	envtesting "github.com/juju/juju/environs/testing"
+	envtools "github.com/juju/juju/environs/tools"
+	"github.com/juju/juju/network"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/apiserver/common"
+	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
+)
+
+type toolsDownloadSuite struct {
+	authHttpSuite
+	tools *coretools.Tools
+}
+
+var _ = gc.Suite(&toolsDownloadSuite{})
+
+func (s *toolsDownloadSuite) SetUpTest(c *gc.C) {
+	s.authHttpSuite.SetUpTest(c)
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+	vers := version.MustParseBinary("1.2.3-quantal-amd64")
+	s.tools = envtesting.AssertUploadFakeToolsVersions(c, s.Environ.Storage(), vers)[0]
+}
+
+// downloadURL returns the expiring URL of s.tools, addressed to the
+// API server under test.
+func (s *toolsDownloadSuite) downloadURL(c *gc.C) *url.URL {
+	tools := *s.tools
+	result := &params.ToolsResult{Tools: &tools}
+	err := common.SetExpiringToolsURL(s.State, s.Environ, result)
+	c.Assert(err, gc.IsNil)
+	uri, err := url.Parse(result.Tools.URL)
+	c.Assert(err, gc.IsNil)
+	uri.Host = s.baseURL(c).Host
+	return uri
+}
+
+func (s *toolsDownloadSuite) TestDownload(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "GET", s.downloadURL(c).String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	r, err := s.Environ.Storage().Get(envtools.StorageName(s.tools.Version))
+	c.Assert(err, gc.IsNil)
+	defer r.Close()
+	expected, err := ioutil.ReadAll(r)
+	c.Assert(err, gc.IsNil)
+	body := assertResponse(c, resp, http.StatusOK, "application/x-tar-gz")
+	c.Assert(body, gc.DeepEquals, expected)
+}
+
+func (s *toolsDownloadSuite) TestDownloadTopLevelPath(c *gc.C) {
+	uri := s.downloadURL(c)
+	uri.Path = "/tools/download"
+	resp, err := s.sendRequest(c, "", "", "GET", uri.String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(resp.StatusCode, gc.Equals, http.StatusOK)
+	resp.Body.Close()
+}
+
+func (s *toolsDownloadSuite) TestDownloadRequiresGET(c *gc.C) {
+	resp, err := s.sendRequest(c, "", "", "POST", s.downloadURL(c).String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertToolsErrorResponse(c, resp, http.StatusMethodNotAllowed, `unsupported method: "POST"`)
+}
+
+func (s *toolsDownloadSuite) TestDownloadRejectsBadSignature(c *gc.C) {
+	uri := s.downloadURL(c)
+	query := uri.Query()
+	query.Set("signature", "deadbeef")
+	uri.RawQuery = query.Encode()
+	resp, err := s.sendRequest(c, "", "", "GET", uri.String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertToolsErrorResponse(c, resp, http.StatusUnauthorized, "unauthorized")
+}
+
+func (s *toolsDownloadSuite) TestDownloadRequiresVersion(c *gc.C) {
+	uri := s.downloadURL(c)
+	uri.RawQuery = ""
+	resp, err := s.sendRequest(c, "", "", "GET", uri.String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertToolsErrorResponse(c, resp, http.StatusBadRequest, `invalid tools version ""`)
+}
+
+func (s *toolsDownloadSuite) TestDownloadRejectsWrongEnvUUIDPath(c *gc.C) {
+	uri := s.downloadURL(c)
+	uri.Path = "/environment/dead-beef-123456/tools/download"
+	resp, err := s.sendRequest(c, "", "", "GET", uri.String(), "", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertToolsErrorResponse(c, resp, http.StatusNotFound, `unknown environment: "dead-beef-123456"`)
+}
+
+func (s *toolsDownloadSuite) assertToolsErrorResponse(c *gc.C, resp *http.Response, expCode int, expError string) {
+	body := assertResponse(c, resp, expCode, "application/json")
+	err := jsonToolsResponse(c, body).Error
+	c.Assert(err, gc.NotNil)
+	c.Check(err, gc.ErrorMatches, expError)
+}
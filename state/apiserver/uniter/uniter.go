@@ -12,6 +12,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/names"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver/common"
@@ -30,6 +31,7 @@ type UniterAPI struct {
 	*common.AgentEntityWatcher
 	*common.APIAddresser
 	*common.EnvironWatcher
+	*common.MetricsAdder
 
 	st            *state.State
 	auth          common.Authorizer
@@ -67,6 +69,7 @@ func NewUniterAPI(st *state.State, resources *common.Resources, authorizer commo
 		AgentEntityWatcher: common.NewAgentEntityWatcher(st, resources, accessUnitOrService),
 		APIAddresser:       common.NewAPIAddresser(st, resources),
 		EnvironWatcher:     common.NewEnvironWatcher(st, resources, getCanWatch, getCanReadSecrets),
+		MetricsAdder:       common.NewMetricsAdder(st, accessUnit),
 
 		st:            st,
 		auth:          authorizer,
@@ -84,6 +87,63 @@ func (u *UniterAPI) getUnit(tag string) (*state.Unit, error) {
 	return u.st.Unit(t.Id())
 }
 
+// cachedUnitAddresses returns the addresses of the machine the unit
+// with the given tag is assigned to, and whether they were found in
+// the server's model cache.
+func (u *UniterAPI) cachedUnitAddresses(tag string) ([]network.Address, bool, error) {
+	cache, ok := u.resources.Get("modelCache").(common.SharedModelCache)
+	if !ok {
+		return nil, false, nil
+	}
+	t, err := names.ParseUnitTag(tag)
+	if err != nil {
+		return nil, false, err
+	}
+	addresses, err := cache.UnitAddresses(t.Id())
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return addresses, true, nil
+}
+
+// unitPublicAddress returns the public address of the unit with the
+// given tag and whether it is set, using the server's model cache if
+// available.
+func (u *UniterAPI) unitPublicAddress(tag string) (string, bool, error) {
+	if addresses, ok, err := u.cachedUnitAddresses(tag); err != nil {
+		return "", false, err
+	} else if ok {
+		address := network.SelectPublicAddress(addresses)
+		return address, address != "", nil
+	}
+	unit, err := u.getUnit(tag)
+	if err != nil {
+		return "", false, err
+	}
+	address, ok := unit.PublicAddress()
+	return address, ok, nil
+}
+
+// unitPrivateAddress returns the private address of the unit with the
+// given tag and whether it is set, using the server's model cache if
+// available.
+func (u *UniterAPI) unitPrivateAddress(tag string) (string, bool, error) {
+	if addresses, ok, err := u.cachedUnitAddresses(tag); err != nil {
+		return "", false, err
+	} else if ok {
+		address := network.SelectInternalAddress(addresses, false)
+		return address, address != "", nil
+	}
+	unit, err := u.getUnit(tag)
+	if err != nil {
+		return "", false, err
+	}
+	address, ok := unit.PrivateAddress()
+	return address, ok, nil
+}
+
 func (u *UniterAPI) getService(tag string) (*state.Service, error) {
 	t, err := names.ParseServiceTag(tag)
 	if err != nil {
@@ -104,10 +164,10 @@ func (u *UniterAPI) PublicAddress(args params.Entities) (params.StringResults, e
 	for i, entity := range args.Entities {
 		err := common.ErrPerm
 		if canAccess(entity.Tag) {
-			var unit *state.Unit
-			unit, err = u.getUnit(entity.Tag)
+			var address string
+			var ok bool
+			address, ok, err = u.unitPublicAddress(entity.Tag)
 			if err == nil {
-				address, ok := unit.PublicAddress()
 				if ok {
 					result.Results[i].Result = address
 				} else {
@@ -132,10 +192,10 @@ func (u *UniterAPI) PrivateAddress(args params.Entities) (params.StringResults,
 	for i, entity := range args.Entities {
 		err := common.ErrPerm
 		if canAccess(entity.Tag) {
-			var unit *state.Unit
-			unit, err = u.getUnit(entity.Tag)
+			var address string
+			var ok bool
+			address, ok, err = u.unitPrivateAddress(entity.Tag)
 			if err == nil {
-				address, ok := unit.PrivateAddress()
 				if ok {
 					result.Results[i].Result = address
 				} else {
@@ -308,6 +368,9 @@ func (u *UniterAPI) HasSubordinates(args params.Entities) (params.BoolResults, e
 }
 
 // CharmURL returns the charm URL for all given units or services.
+// The charm URL of a service is the one the authenticated unit should
+// use, which differs from the service's while a staged upgrade has
+// pinned the unit to the service's previous charm.
 func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, error) {
 	result := params.StringBoolResults{
 		Results: make([]params.StringBoolResult, len(args.Entities)),
@@ -323,10 +386,16 @@ func (u *UniterAPI) CharmURL(args params.Entities) (params.StringBoolResults, er
 			var unitOrService state.Entity
 			unitOrService, err = u.st.FindEntity(entity.Tag)
 			if err == nil {
-				charmURLer := unitOrService.(interface {
-					CharmURL() (*charm.URL, bool)
-				})
-				curl, ok := charmURLer.CharmURL()
+				var curl *charm.URL
+				var ok bool
+				if service, isService := unitOrService.(*state.Service); isService {
+					curl, ok = service.CharmURLForUnit(u.auth.GetAuthTag().Id())
+				} else {
+					charmURLer := unitOrService.(interface {
+						CharmURL() (*charm.URL, bool)
+					})
+					curl, ok = charmURLer.CharmURL()
+				}
 				if curl != nil {
 					result.Results[i].Result = curl.String()
 					result.Results[i].Ok = ok
@@ -366,6 +435,30 @@ func (u *UniterAPI) SetCharmURL(args params.EntitiesCharmURL) (params.ErrorResul
 	return result, nil
 }
 
+// SetWorkloadStatus sets the workload status reported by the charm
+// of each given unit.
+func (u *UniterAPI) SetWorkloadStatus(args params.SetWorkloadStatus) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.SetWorkloadStatus(entity.Status, entity.Info)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // OpenPort sets the policy of the port with protocol an number to be
 // opened, for all given units.
 func (u *UniterAPI) OpenPort(args params.EntitiesPorts) (params.ErrorResults, error) {
@@ -414,6 +507,44 @@ func (u *UniterAPI) ClosePort(args params.EntitiesPorts) (params.ErrorResults, e
 	return result, nil
 }
 
+// OpenPorts sets the policy of the ports in each given range
+// to be opened, for all given units.
+func (u *UniterAPI) OpenPorts(args params.EntitiesPortRanges) (params.ErrorResults, error) {
+	return u.changePorts(args, (*state.Unit).OpenPorts)
+}
+
+// ClosePorts sets the policy of the ports in each given range
+// to be closed, for all given units.
+func (u *UniterAPI) ClosePorts(args params.EntitiesPortRanges) (params.ErrorResults, error) {
+	return u.changePorts(args, (*state.Unit).ClosePorts)
+}
+
+// changePorts calls change for each given unit and port range.
+func (u *UniterAPI) changePorts(
+	args params.EntitiesPortRanges,
+	change func(unit *state.Unit, protocol string, fromPort, toPort int) error,
+) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = change(unit, entity.Protocol, entity.FromPort, entity.ToPort)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (u *UniterAPI) watchOneUnitConfigSettings(tag string) (string, error) {
 	unit, err := u.getUnit(tag)
 	if err != nil {
@@ -516,11 +647,7 @@ func (u *UniterAPI) ConfigSettings(args params.Entities) (params.ConfigSettingsR
 			var unit *state.Unit
 			unit, err = u.getUnit(entity.Tag)
 			if err == nil {
-				var settings charm.Settings
-				settings, err = unit.ConfigSettings()
-				if err == nil {
-					result.Results[i].Settings = params.ConfigSettings(settings)
-				}
+				result.Results[i], err = u.oneConfigSettings(unit)
 			}
 		}
 		result.Results[i].Error = common.ServerError(err)
@@ -528,6 +655,37 @@ func (u *UniterAPI) ConfigSettings(args params.Entities) (params.ConfigSettingsR
 	return result, nil
 }
 
+// oneConfigSettings returns the config settings of the given unit,
+// along with the type of each setting as declared by the charm.
+func (u *UniterAPI) oneConfigSettings(unit *state.Unit) (params.ConfigSettingsResult, error) {
+	nothing := params.ConfigSettingsResult{}
+	settings, err := unit.ConfigSettings()
+	if err != nil {
+		return nothing, err
+	}
+	version, err := unit.ConfigSettingsVersion()
+	if err != nil {
+		return nothing, err
+	}
+	curl, ok := unit.CharmURL()
+	if !ok {
+		return nothing, fmt.Errorf("unit charm not set")
+	}
+	ch, err := u.st.Charm(curl)
+	if err != nil {
+		return nothing, err
+	}
+	types := make(map[string]string)
+	for name, option := range ch.Config().Options {
+		types[name] = option.Type
+	}
+	return params.ConfigSettingsResult{
+		Settings: params.ConfigSettings(settings),
+		Types:    types,
+		Version:  version,
+	}, nil
+}
+
 func (u *UniterAPI) watchOneServiceRelations(tag string) (params.StringsWatchResult, error) {
 	nothing := params.StringsWatchResult{}
 	service, err := u.getService(tag)
@@ -568,7 +726,9 @@ func (u *UniterAPI) WatchServiceRelations(args params.Entities) (params.StringsW
 
 // CharmArchiveURL returns the URL, corresponding to the charm archive
 // (bundle) in the provider storage for each given charm URL, along
-// with the DisableSSLHostnameVerification flag.
+// with the DisableSSLHostnameVerification flag. Once the state
+// server's addresses are known, the URL is that of an expiring
+// download from an API server rather than from provider storage.
 func (u *UniterAPI) CharmArchiveURL(args params.CharmURLs) (params.CharmArchiveURLResults, error) {
 	result := params.CharmArchiveURLResults{
 		Results: make([]params.CharmArchiveURLResult, len(args.URLs)),
@@ -592,7 +752,18 @@ func (u *UniterAPI) CharmArchiveURL(args params.CharmURLs) (params.CharmArchiveU
 			if errors.IsNotFound(err) {
 				err = common.ErrPerm
 			}
+			var archiveURL string
 			if err == nil {
+				archiveURL, err = common.CharmDownloadURL(u.st, u.st.EnvironTag().Id(), curl)
+			}
+			if err == nil && archiveURL != "" {
+				// Agents verify the API server's certificate against
+				// the environment's CA. Older agents cannot, and
+				// verify only the archive's digest once downloaded.
+				result.Results[i].Result = archiveURL
+				result.Results[i].FromAPIServer = true
+				result.Results[i].DisableSSLHostnameVerification = true
+			} else if err == nil {
 				result.Results[i].Result = sch.BundleURL().String()
 				result.Results[i].DisableSSLHostnameVerification = disableSSLHostnameVerification
 			}
@@ -4,9 +4,12 @@
 package uniter_test
 
 import (
+	"net/url"
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/charm"
+	charmtesting "github.com/juju/charm/testing"
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	patchtesting "github.com/juju/testing"
@@ -627,6 +630,40 @@ func (s *uniterSuite) TestCharmURL(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestCharmURLStagedUpgrade(c *gc.C) {
+	// Add a new revision of the wordpress charm, and stage an
+	// upgrade to it that pins wordpress/0 to the current one.
+	curl := s.wpCharm.URL().WithRevision(s.wpCharm.Revision() + 1)
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/" + curl.Name)
+	c.Assert(err, gc.IsNil)
+	newCharm, err := s.State.AddCharm(charmtesting.Charms.Dir("wordpress"), curl, bundleURL, "wordpress-sha256")
+	c.Assert(err, gc.IsNil)
+	err = s.wordpress.SetCharmStaged(newCharm, true, nil)
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "service-wordpress"},
+	}}
+	result, err := s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{
+			{Result: s.wpCharm.String(), Ok: false},
+		},
+	})
+
+	// Once released, the unit sees the new charm.
+	err = s.wordpress.ReleaseAllUnits()
+	c.Assert(err, gc.IsNil)
+	result, err = s.uniter.CharmURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringBoolResults{
+		Results: []params.StringBoolResult{
+			{Result: newCharm.String(), Ok: true},
+		},
+	})
+}
+
 func (s *uniterSuite) TestSetCharmURL(c *gc.C) {
 	charmUrl, ok := s.wordpressUnit.CharmURL()
 	c.Assert(charmUrl, gc.IsNil)
@@ -656,6 +693,53 @@ func (s *uniterSuite) TestSetCharmURL(c *gc.C) {
 	c.Assert(ok, jc.IsTrue)
 }
 
+func (s *uniterSuite) TestSetWorkloadStatus(c *gc.C) {
+	args := params.SetWorkloadStatus{Entities: []params.EntityWorkloadStatus{
+		{Tag: "unit-mysql-0", Status: params.WorkloadStatusActive},
+		{Tag: "unit-wordpress-0", Status: params.WorkloadStatusBlocked, Info: "waiting for db relation"},
+		{Tag: "unit-foo-42", Status: params.WorkloadStatusActive},
+	}}
+	result, err := s.uniter.SetWorkloadStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the workload status was set.
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, info := s.wordpressUnit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusBlocked)
+	c.Assert(info, gc.Equals, "waiting for db relation")
+}
+
+func (s *uniterSuite) TestAddMetrics(c *gc.C) {
+	metrics := []params.Metric{{Key: "requests", Value: "42", Time: time.Now()}}
+	args := params.AddMetrics{Entities: []params.EntityMetrics{
+		{Tag: "unit-mysql-0", Metrics: metrics},
+		{Tag: "unit-wordpress-0", Metrics: metrics},
+		{Tag: "unit-foo-42", Metrics: metrics},
+	}}
+	result, err := s.uniter.AddMetrics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	batches, err := s.State.MetricBatches("unit-wordpress-0", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Metrics()[0].Key, gc.Equals, "requests")
+}
+
 func (s *uniterSuite) TestOpenPort(c *gc.C) {
 	openedPorts := s.wordpressUnit.OpenedPorts()
 	c.Assert(openedPorts, gc.HasLen, 0)
@@ -679,8 +763,8 @@ func (s *uniterSuite) TestOpenPort(c *gc.C) {
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
 	openedPorts = s.wordpressUnit.OpenedPorts()
-	c.Assert(openedPorts, gc.DeepEquals, []network.Port{
-		{Protocol: "udp", Number: 4321},
+	c.Assert(openedPorts, gc.DeepEquals, []network.PortRange{
+		{FromPort: 4321, ToPort: 4321, Protocol: "udp"},
 	})
 }
 
@@ -691,8 +775,8 @@ func (s *uniterSuite) TestClosePort(c *gc.C) {
 	err = s.wordpressUnit.Refresh()
 	c.Assert(err, gc.IsNil)
 	openedPorts := s.wordpressUnit.OpenedPorts()
-	c.Assert(openedPorts, gc.DeepEquals, []network.Port{
-		{Protocol: "udp", Number: 4321},
+	c.Assert(openedPorts, gc.DeepEquals, []network.PortRange{
+		{FromPort: 4321, ToPort: 4321, Protocol: "udp"},
 	})
 
 	args := params.EntitiesPorts{Entities: []params.EntityPort{
@@ -717,6 +801,49 @@ func (s *uniterSuite) TestClosePort(c *gc.C) {
 	c.Assert(openedPorts, gc.HasLen, 0)
 }
 
+func (s *uniterSuite) TestOpenClosePorts(c *gc.C) {
+	args := params.EntitiesPortRanges{Entities: []params.EntityPortRange{
+		{Tag: "unit-mysql-0", Protocol: "tcp", FromPort: 1234, ToPort: 1240},
+		{Tag: "unit-wordpress-0", Protocol: "tcp", FromPort: 8000, ToPort: 8002},
+		{Tag: "unit-wordpress-0", Protocol: "icmp", FromPort: -1, ToPort: -1},
+		{Tag: "unit-foo-42", Protocol: "tcp", FromPort: 42, ToPort: 42},
+	}}
+	result, err := s.uniter.OpenPorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the wordpressUnit's ports are open.
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.OpenedPorts(), gc.DeepEquals, []network.PortRange{
+		{FromPort: -1, ToPort: -1, Protocol: "icmp"},
+		{FromPort: 8000, ToPort: 8002, Protocol: "tcp"},
+	})
+
+	result, err = s.uniter.ClosePorts(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the wordpressUnit's ports are closed.
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.OpenedPorts(), gc.HasLen, 0)
+}
+
 func (s *uniterSuite) TestWatchConfigSettings(c *gc.C) {
 	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
 	c.Assert(err, gc.IsNil)
@@ -874,6 +1001,8 @@ func (s *uniterSuite) TestConfigSettings(c *gc.C) {
 	settings, err := s.wordpressUnit.ConfigSettings()
 	c.Assert(err, gc.IsNil)
 	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "My Title"})
+	version, err := s.wordpressUnit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
 
 	args := params.Entities{Entities: []params.Entity{
 		{Tag: "unit-mysql-0"},
@@ -885,7 +1014,11 @@ func (s *uniterSuite) TestConfigSettings(c *gc.C) {
 	c.Assert(result, gc.DeepEquals, params.ConfigSettingsResults{
 		Results: []params.ConfigSettingsResult{
 			{Error: apiservertesting.ErrUnauthorized},
-			{Settings: params.ConfigSettings{"blog-title": "My Title"}},
+			{
+				Settings: params.ConfigSettings{"blog-title": "My Title"},
+				Types:    map[string]string{"blog-title": "string"},
+				Version:  version,
+			},
 			{Error: apiservertesting.ErrUnauthorized},
 		},
 	})
@@ -946,6 +1079,38 @@ func (s *uniterSuite) TestCharmArchiveURL(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestCharmArchiveURLFromAPIServer(c *gc.C) {
+	err := s.State.SetStateServingInfo(params.StateServingInfo{
+		PrivateKey:   "some key",
+		Cert:         "some cert",
+		SharedSecret: "really, really secret",
+		APIPort:      17070,
+		StatePort:    37017,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetAPIHostPorts([][]network.HostPort{{{
+		Address: network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		Port:    17070,
+	}}})
+	c.Assert(err, gc.IsNil)
+
+	args := params.CharmURLs{URLs: []params.CharmURL{{URL: s.wpCharm.String()}}}
+	result, err := s.uniter.CharmArchiveURL(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.HasLen, 1)
+	c.Assert(result.Results[0].Error, gc.IsNil)
+	c.Assert(result.Results[0].DisableSSLHostnameVerification, jc.IsTrue)
+	c.Assert(result.Results[0].FromAPIServer, jc.IsTrue)
+
+	archiveURL, err := url.Parse(result.Results[0].Result)
+	c.Assert(err, gc.IsNil)
+	c.Assert(archiveURL.Host, gc.Equals, "10.0.0.1:17070")
+	c.Assert(archiveURL.Path, gc.Equals, "/environment/"+s.State.EnvironTag().Id()+"/charms/download")
+	curl, err := common.VerifyCharmDownload(s.State, archiveURL.Query())
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl, gc.DeepEquals, s.wpCharm.URL())
+}
+
 func (s *uniterSuite) TestCharmArchiveSha256(c *gc.C) {
 	dummyCharm := s.AddTestingCharm(c, "dummy")
 
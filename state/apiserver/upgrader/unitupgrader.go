@@ -182,3 +182,44 @@ func (u *UnitUpgraderAPI) getMachineToolsVersion(tag string) (*version.Number, e
 	}
 	return &machineTools.Version.Number, nil
 }
+
+// WatchStagedUpgrade is not supported for units: their tools
+// are downloaded by the agent of their assigned machine.
+func (u *UnitUpgraderAPI) WatchStagedUpgrade(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		result.Results[i].Error = common.ServerError(u.stagedUpgradeError(entity.Tag))
+	}
+	return result, nil
+}
+
+// StagedTools is not supported for units; see WatchStagedUpgrade.
+func (u *UnitUpgraderAPI) StagedTools(args params.Entities) (params.ToolsResults, error) {
+	result := params.ToolsResults{
+		Results: make([]params.ToolsResult, len(args.Entities)),
+	}
+	for i, entity := range args.Entities {
+		result.Results[i].Error = common.ServerError(u.stagedUpgradeError(entity.Tag))
+	}
+	return result, nil
+}
+
+// SetStagedToolsReady is not supported for units; see WatchStagedUpgrade.
+func (u *UnitUpgraderAPI) SetStagedToolsReady(args params.EntitiesVersion) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.AgentTools)),
+	}
+	for i, agentTools := range args.AgentTools {
+		results.Results[i].Error = common.ServerError(u.stagedUpgradeError(agentTools.Tag))
+	}
+	return results, nil
+}
+
+func (u *UnitUpgraderAPI) stagedUpgradeError(tag string) error {
+	if !u.authorizer.AuthOwner(tag) {
+		return common.ErrPerm
+	}
+	return common.NotSupportedError(tag, "staged upgrades")
+}
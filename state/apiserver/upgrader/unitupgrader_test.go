@@ -284,3 +284,31 @@ func (s *unitUpgraderSuite) TestDesiredVersionForAgent(c *gc.C) {
 	c.Assert(agentVersion, gc.NotNil)
 	c.Check(*agentVersion, gc.DeepEquals, version.Current.Number)
 }
+
+func (s *unitUpgraderSuite) TestStagedUpgradeNotSupported(c *gc.C) {
+	tag := s.rawUnit.Tag().String()
+	expectErr := &params.Error{Message: `entity "` + tag + `" does not support staged upgrades`}
+	args := params.Entities{Entities: []params.Entity{{Tag: tag}, {Tag: "unit-wordpress-42"}}}
+
+	watchResults, err := s.upgrader.WatchStagedUpgrade(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(watchResults.Results, gc.HasLen, 2)
+	c.Assert(watchResults.Results[0].Error, gc.DeepEquals, expectErr)
+	c.Assert(watchResults.Results[1].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	toolsResults, err := s.upgrader.StagedTools(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(toolsResults.Results, gc.HasLen, 2)
+	c.Assert(toolsResults.Results[0].Error, gc.DeepEquals, expectErr)
+	c.Assert(toolsResults.Results[1].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	readyResults, err := s.upgrader.SetStagedToolsReady(params.EntitiesVersion{
+		AgentTools: []params.EntityVersion{{
+			Tag:   tag,
+			Tools: &params.Version{Version: version.Current},
+		}},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(readyResults.Results, gc.HasLen, 1)
+	c.Assert(readyResults.Results[0].Error, gc.DeepEquals, expectErr)
+}
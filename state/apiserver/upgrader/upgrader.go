@@ -4,16 +4,20 @@
 package upgrader
 
 import (
-	"errors"
+	stderrors "errors"
 
+	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 
+	"github.com/juju/juju/environs"
 	"github.com/juju/juju/environs/config"
+	envtools "github.com/juju/juju/environs/tools"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/apiserver/common"
 	"github.com/juju/juju/state/watcher"
+	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 )
 
@@ -53,6 +57,9 @@ type Upgrader interface {
 	DesiredVersion(args params.Entities) (params.VersionResults, error)
 	Tools(args params.Entities) (params.ToolsResults, error)
 	SetTools(args params.EntitiesVersion) (params.ErrorResults, error)
+	WatchStagedUpgrade(args params.Entities) (params.NotifyWatchResults, error)
+	StagedTools(args params.Entities) (params.ToolsResults, error)
+	SetStagedToolsReady(args params.EntitiesVersion) (params.ErrorResults, error)
 }
 
 // UpgraderAPI provides access to the Upgrader API facade.
@@ -120,7 +127,7 @@ func (u *UpgraderAPI) getGlobalAgentVersion() (version.Number, *config.Config, e
 	}
 	agentVersion, ok := cfg.AgentVersion()
 	if !ok {
-		return version.Number{}, nil, errors.New("agent version not set in environment config")
+		return version.Number{}, nil, stderrors.New("agent version not set in environment config")
 	}
 	return agentVersion, cfg, nil
 }
@@ -178,3 +185,109 @@ func (u *UpgraderAPI) DesiredVersion(args params.Entities) (params.VersionResult
 	}
 	return params.VersionResults{Results: results}, nil
 }
+
+// WatchStagedUpgrade starts a watcher to track when an upgrade is
+// staged, so that the agent can download its tools in advance.
+func (u *UpgraderAPI) WatchStagedUpgrade(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	for i, agent := range args.Entities {
+		err := common.ErrPerm
+		if u.authorizer.AuthOwner(agent.Tag) {
+			watch := u.st.WatchStagedUpgrade()
+			// Consume the initial event, as in WatchAPIVersion.
+			if _, ok := <-watch.Changes(); ok {
+				result.Results[i].NotifyWatcherId = u.resources.Register(watch)
+				err = nil
+			} else {
+				err = watcher.MustErr(watch)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// StagedTools returns the tools of the staged upgrade's version for
+// the given agents. If no upgrade is staged, it returns a not found
+// error for each agent.
+func (u *UpgraderAPI) StagedTools(args params.Entities) (params.ToolsResults, error) {
+	result := params.ToolsResults{
+		Results: make([]params.ToolsResult, len(args.Entities)),
+	}
+	staged, err := u.st.StagedUpgrade()
+	if err != nil && !errors.IsNotFound(err) {
+		return result, err
+	}
+	var env environs.Environ
+	var disableSSLHostnameVerification bool
+	if staged != nil {
+		cfg, err := u.st.EnvironConfig()
+		if err != nil {
+			return result, err
+		}
+		// SSLHostnameVerification defaults to true, so we need to
+		// invert it, as in Tools.
+		disableSSLHostnameVerification = !cfg.SSLHostnameVerification()
+		if env, err = environs.New(cfg); err != nil {
+			return result, err
+		}
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if u.authorizer.AuthOwner(entity.Tag) {
+			if staged == nil {
+				err = errors.NotFoundf("staged upgrade")
+			} else {
+				result.Results[i].Tools, err = u.oneStagedTools(entity.Tag, staged.Version(), env)
+				result.Results[i].DisableSSLHostnameVerification = disableSSLHostnameVerification
+				if err == nil {
+					err = common.SetExpiringToolsURL(u.st, env, &result.Results[i])
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+func (u *UpgraderAPI) oneStagedTools(tag string, vers version.Number, env environs.Environ) (*coretools.Tools, error) {
+	entity, err := u.st.FindEntity(tag)
+	if err != nil {
+		return nil, err
+	}
+	tooler, ok := entity.(state.AgentTooler)
+	if !ok {
+		return nil, common.NotSupportedError(tag, "agent tools")
+	}
+	existingTools, err := tooler.AgentTools()
+	if err != nil {
+		return nil, err
+	}
+	return envtools.FindExactTools(env, vers, existingTools.Version.Series, existingTools.Version.Arch)
+}
+
+// SetStagedToolsReady records that the given agents have downloaded
+// and verified the tools of the staged upgrade's version.
+func (u *UpgraderAPI) SetStagedToolsReady(args params.EntitiesVersion) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.AgentTools)),
+	}
+	staged, err := u.st.StagedUpgrade()
+	if err != nil && !errors.IsNotFound(err) {
+		return results, err
+	}
+	for i, agentTools := range args.AgentTools {
+		err := common.ErrPerm
+		if u.authorizer.AuthOwner(agentTools.Tag) {
+			if staged == nil {
+				err = errors.NotFoundf("staged upgrade")
+			} else {
+				err = staged.SetAgentReady(agentTools.Tag, agentTools.Tools.Version.Number)
+			}
+		}
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
@@ -234,6 +234,84 @@ func (s *upgraderSuite) TestSetTools(c *gc.C) {
 	c.Check(realTools.URL, gc.Equals, "")
 }
 
+func (s *upgraderSuite) stageNewVersion(c *gc.C) version.Binary {
+	for _, m := range []*state.Machine{s.apiMachine, s.rawMachine} {
+		err := m.SetAgentVersion(version.Current)
+		c.Assert(err, gc.IsNil)
+	}
+	newer := version.Current
+	newer.Patch++
+	envtesting.AssertUploadFakeToolsVersions(c, s.Environ.Storage(), newer)
+	err := s.State.StageAgentVersion(newer.Number)
+	c.Assert(err, gc.IsNil)
+	return newer
+}
+
+func (s *upgraderSuite) TestStagedToolsNotStaged(c *gc.C) {
+	err := s.rawMachine.SetAgentVersion(version.Current)
+	c.Assert(err, gc.IsNil)
+	args := params.Entities{Entities: []params.Entity{{Tag: s.rawMachine.Tag().String()}}}
+	results, err := s.upgrader.StagedTools(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 1)
+	c.Assert(results.Results[0].Error, jc.Satisfies, params.IsCodeNotFound)
+	c.Assert(results.Results[0].Tools, gc.IsNil)
+}
+
+func (s *upgraderSuite) TestStagedTools(c *gc.C) {
+	newer := s.stageNewVersion(c)
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.rawMachine.Tag().String()},
+		{Tag: s.apiMachine.Tag().String()},
+	}}
+	results, err := s.upgrader.StagedTools(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Check(results.Results[0].Tools.Version, gc.Equals, newer)
+	c.Check(results.Results[0].Tools.URL, gc.Not(gc.Equals), "")
+	c.Assert(results.Results[1].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+}
+
+func (s *upgraderSuite) TestSetStagedToolsReady(c *gc.C) {
+	newer := s.stageNewVersion(c)
+	args := params.EntitiesVersion{AgentTools: []params.EntityVersion{{
+		Tag:   s.rawMachine.Tag().String(),
+		Tools: &params.Version{Version: newer},
+	}, {
+		Tag:   s.apiMachine.Tag().String(),
+		Tools: &params.Version{Version: newer},
+	}}}
+	results, err := s.upgrader.SetStagedToolsReady(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 2)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Assert(results.Results[1].Error, gc.DeepEquals, apiservertesting.ErrUnauthorized)
+
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.ReadyAgents(), gc.DeepEquals, []string{s.rawMachine.Tag().String()})
+}
+
+func (s *upgraderSuite) TestWatchStagedUpgrade(c *gc.C) {
+	args := params.Entities{Entities: []params.Entity{{Tag: s.rawMachine.Tag().String()}}}
+	results, err := s.upgrader.WatchStagedUpgrade(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results, gc.HasLen, 1)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	c.Check(results.Results[0].NotifyWatcherId, gc.Not(gc.Equals), "")
+	resource := s.resources.Get(results.Results[0].NotifyWatcherId)
+	c.Check(resource, gc.NotNil)
+
+	w := resource.(state.NotifyWatcher)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertNoChange()
+	s.stageNewVersion(c)
+	wc.AssertOneChange()
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 func (s *upgraderSuite) TestDesiredVersionNothing(c *gc.C) {
 	// Not an error to watch nothing
 	results, err := s.upgrader.DesiredVersion(params.Entities{})
@@ -78,8 +78,16 @@ func isAgent(auth common.Authorizer) bool {
 	return auth.AuthMachineAgent() || auth.AuthUnitAgent()
 }
 
+// isAgentOrClient reports whether the authenticated entity may use
+// the watchers it has created. Clients create notify and strings
+// watchers through the Client facade; resources are held per
+// connection, so they can never reach another entity's watchers.
+func isAgentOrClient(auth common.Authorizer) bool {
+	return isAgent(auth) || auth.AuthClient()
+}
+
 func newNotifyWatcher(st *state.State, resources *common.Resources, auth common.Authorizer, id string) (interface{}, error) {
-	if !isAgent(auth) {
+	if !isAgentOrClient(auth) {
 		return nil, common.ErrPerm
 	}
 	watcher, ok := resources.Get(id).(state.NotifyWatcher)
@@ -123,7 +131,7 @@ type srvStringsWatcher struct {
 }
 
 func newStringsWatcher(st *state.State, resources *common.Resources, auth common.Authorizer, id string) (interface{}, error) {
-	if !isAgent(auth) {
+	if !isAgentOrClient(auth) {
 		return nil, common.ErrPerm
 	}
 	watcher, ok := resources.Get(id).(state.StringsWatcher)
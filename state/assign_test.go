@@ -14,6 +14,7 @@ import (
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/instance"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/txn"
 )
@@ -131,6 +132,127 @@ func (s *AssignSuite) TestAssignedMachineIdWhenPrincipalNotAlive(c *gc.C) {
 	c.Assert(mid, gc.Equals, machine.Id())
 }
 
+func (s *AssignSuite) TestMoveUnitToMachine(c *gc.C) {
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	subUnit := s.addSubordinate(c, unit)
+	machineOne, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	machineTwo, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machineTwo.SetAddresses(network.NewAddress("10.0.0.2", network.ScopeCloudLocal))
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machineOne)
+	c.Assert(err, gc.IsNil)
+	err = unit.OpenPorts("tcp", 80, 81)
+	c.Assert(err, gc.IsNil)
+	err = subUnit.OpenPort("udp", 514)
+	c.Assert(err, gc.IsNil)
+
+	relations, err := unit.RelationsInScope()
+	c.Assert(err, gc.IsNil)
+	c.Assert(relations, gc.HasLen, 1)
+	ru, err := relations[0].Unit(unit)
+	c.Assert(err, gc.IsNil)
+	settings, err := ru.Settings()
+	c.Assert(err, gc.IsNil)
+	settings.Set("private-address", "10.0.0.1")
+	_, err = settings.Write()
+	c.Assert(err, gc.IsNil)
+
+	err = unit.MoveToMachine(machineTwo)
+	c.Assert(err, gc.IsNil)
+
+	machineId, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, machineTwo.Id())
+	err = subUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	machineId, err = subUnit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, machineTwo.Id())
+
+	err = machineOne.Refresh()
+	c.Assert(err, gc.IsNil)
+	units, err := machineOne.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 0)
+	err = machineTwo.Refresh()
+	c.Assert(err, gc.IsNil)
+	units, err = machineTwo.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 2)
+
+	settings, err = ru.Settings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings.Map()["private-address"], gc.Equals, "10.0.0.2")
+
+	// The ports opened by the unit and its subordinate moved with them.
+	c.Assert(unit.OpenedPorts(), gc.DeepEquals, []network.PortRange{{80, 81, "tcp"}})
+	c.Assert(subUnit.OpenedPorts(), gc.DeepEquals, []network.PortRange{{514, 514, "udp"}})
+	ports, err := machineOne.OpenedPorts(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(ports, gc.HasLen, 1)
+	c.Assert(ports[0].PortsForUnit(unit.Name()), gc.HasLen, 0)
+	c.Assert(ports[0].PortsForUnit(subUnit.Name()), gc.HasLen, 0)
+
+	// Moving the unit to the machine it is on is a no-op.
+	err = unit.MoveToMachine(machineTwo)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *AssignSuite) TestMoveUnitToMachineErrors(c *gc.C) {
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	machineOne, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	err = unit.MoveToMachine(machineOne)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "wordpress/0" to machine 0: unit is not assigned to a machine`)
+
+	err = unit.AssignToMachine(machineOne)
+	c.Assert(err, gc.IsNil)
+	subUnit := s.addSubordinate(c, unit)
+
+	manager, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	err = unit.MoveToMachine(manager)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "wordpress/0" to machine 1: machine "1" cannot host units`)
+
+	precise, err := s.State.AddMachine("precise", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.MoveToMachine(precise)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "wordpress/0" to machine 2: series does not match`)
+
+	machineTwo, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = subUnit.MoveToMachine(machineTwo)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "logging/0" to machine 3: unit is a subordinate`)
+
+	// The unit's ports cannot be opened on a machine where they
+	// conflict with those opened by another unit.
+	err = unit.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	other, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = other.AssignToMachine(machineTwo)
+	c.Assert(err, gc.IsNil)
+	err = other.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = unit.MoveToMachine(machineTwo)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "wordpress/0" to machine 3: cannot open ports 80-80/tcp on machine 3 due to conflict`)
+	machineId, err := unit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machineId, gc.Equals, machineOne.Id())
+
+	machineThree, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machineThree.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = unit.MoveToMachine(machineThree)
+	c.Assert(err, gc.ErrorMatches, `cannot move unit "wordpress/0" to machine 4: machine is not alive`)
+}
+
 func (s *AssignSuite) TestUnassignUnitFromMachineWithChangingState(c *gc.C) {
 	unit, err := s.wordpress.AddUnit()
 	c.Assert(err, gc.IsNil)
@@ -130,6 +130,13 @@ func _getFilesToBackup() ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch juju log conf files: %v", err)
 	}
+	// The secret key, without which the agent configuration cannot
+	// be read, exists only on machines provisioned to create one.
+	// It is kept outside the data directory.
+	secretKeys, err := filepath.Glob("/etc/juju/secret.key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secret key: %v", err)
+	}
 
 	backupFiles := []string{
 		"/etc/init/juju-db.conf",
@@ -145,6 +152,7 @@ func _getFilesToBackup() ([]string, error) {
 	backupFiles = append(backupFiles, initMachineConfs...)
 	backupFiles = append(backupFiles, agentConfs...)
 	backupFiles = append(backupFiles, jujuLogConfs...)
+	backupFiles = append(backupFiles, secretKeys...)
 	return backupFiles, nil
 }
 
@@ -7,6 +7,8 @@ import (
 	"net/url"
 
 	"github.com/juju/charm"
+
+	"github.com/juju/juju/version"
 )
 
 // charmDoc represents the internal state of a charm in MongoDB.
@@ -19,6 +21,10 @@ type charmDoc struct {
 	BundleSha256  string
 	PendingUpload bool
 	Placeholder   bool
+
+	// MinJujuVersion holds the minimum juju version required
+	// by the charm, or the zero version if there is none.
+	MinJujuVersion version.Number
 }
 
 // Charm represents the state of a charm in the environment.
@@ -62,6 +68,14 @@ func (c *Charm) Actions() *charm.Actions {
 	return c.doc.Actions
 }
 
+// MinJujuVersion returns the minimum version of juju required
+// to deploy the charm, as declared by the "min-juju-version" field
+// of its metadata. The zero version is returned if the charm has
+// no such requirement.
+func (c *Charm) MinJujuVersion() version.Number {
+	return c.doc.MinJujuVersion
+}
+
 // BundleURL returns the url to the charm bundle in
 // the provider storage.
 func (c *Charm) BundleURL() *url.URL {
@@ -5,7 +5,9 @@ package state_test
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/url"
+	"path/filepath"
 
 	"github.com/juju/charm"
 	charmtesting "github.com/juju/charm/testing"
@@ -14,6 +16,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/version"
 )
 
 type CharmSuite struct {
@@ -181,3 +184,34 @@ func (s *CharmTestHelperSuite) TestMetaCharm(c *gc.C) {
 		assertCustomCharm(c, ch, "quantal", meta, config, 123)
 	})
 }
+
+func (s *CharmSuite) TestMinJujuVersionUnset(c *gc.C) {
+	dummy, err := s.State.Charm(s.curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(dummy.MinJujuVersion(), gc.Equals, version.Zero)
+}
+
+func (s *CharmSuite) TestMinJujuVersion(c *gc.C) {
+	metaYaml := "name: dummy" + metaYamlSnippet + "min-juju-version: 1.21.0\n"
+	ch := s.AddMetaCharm(c, "dummy", metaYaml, 2)
+	c.Assert(ch.MinJujuVersion(), gc.Equals, version.MustParse("1.21.0"))
+
+	ch, err := s.State.Charm(ch.URL())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ch.MinJujuVersion(), gc.Equals, version.MustParse("1.21.0"))
+}
+
+func (s *CharmSuite) TestInvalidMinJujuVersion(c *gc.C) {
+	path := charmtesting.Charms.ClonedDirPath(c.MkDir(), "dummy")
+	metaYaml := "name: dummy" + metaYamlSnippet + "min-juju-version: blah\n"
+	err := ioutil.WriteFile(filepath.Join(path, "metadata.yaml"), []byte(metaYaml), 0644)
+	c.Assert(err, gc.IsNil)
+	ch, err := charm.ReadDir(path)
+	c.Assert(err, gc.IsNil)
+
+	curl := charm.MustParseURL("local:quantal/dummy-2")
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/quantal-dummy-2")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddCharm(ch, curl, bundleURL, "quantal-dummy-2-sha256")
+	c.Assert(err, gc.ErrorMatches, `cannot add charm "local:quantal/dummy-2": invalid min-juju-version: .*`)
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/charm"
+	"github.com/juju/errors"
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/version"
+)
+
+// minJujuVersionMeta holds the metadata fields that are interpreted
+// by juju itself rather than by the charm package.
+//
+// TODO: min-juju-version belongs on charm.Meta. Once the
+// github.com/juju/charm revision in dependencies.tsv parses it,
+// use ch.Meta() instead of reading metadata.yaml a second time.
+type minJujuVersionMeta struct {
+	MinJujuVersion string `yaml:"min-juju-version"`
+}
+
+// readMinJujuVersion returns the minimum juju version declared by the
+// "min-juju-version" field of the charm's metadata. The zero version
+// is returned if the charm does not declare one, or if its metadata
+// cannot be read directly because it was not loaded from disk.
+func readMinJujuVersion(ch charm.Charm) (version.Number, error) {
+	var data []byte
+	var err error
+	switch ch := ch.(type) {
+	case *charm.Dir:
+		data, err = ioutil.ReadFile(filepath.Join(ch.Path, "metadata.yaml"))
+	case *charm.Bundle:
+		if ch.Path == "" {
+			return version.Zero, nil
+		}
+		data, err = readBundleFile(ch.Path, "metadata.yaml")
+	default:
+		return version.Zero, nil
+	}
+	if err != nil {
+		return version.Zero, errors.Annotate(err, "cannot read charm metadata")
+	}
+	return parseMinJujuVersion(data)
+}
+
+// parseMinJujuVersion returns the minimum juju version declared in
+// the given charm metadata.
+func parseMinJujuVersion(data []byte) (version.Number, error) {
+	var meta minJujuVersionMeta
+	if err := goyaml.Unmarshal(data, &meta); err != nil {
+		return version.Zero, errors.Annotate(err, "cannot parse charm metadata")
+	}
+	if meta.MinJujuVersion == "" {
+		return version.Zero, nil
+	}
+	v, err := version.Parse(meta.MinJujuVersion)
+	if err != nil {
+		return version.Zero, errors.Annotate(err, "invalid min-juju-version")
+	}
+	return v, nil
+}
+
+// readBundleFile returns the contents of the named file
+// in the charm bundle at the given path.
+func readBundleFile(path, name string) ([]byte, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return ioutil.ReadAll(rc)
+	}
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+}
@@ -5,6 +5,8 @@ package state
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/juju/errors"
 	"labix.org/v2/mgo/bson"
@@ -120,6 +122,69 @@ func (st *State) cleanupRelationSettings(prefix string) error {
 	return nil
 }
 
+// OrphanedRelationSettings returns the keys of the relation unit settings
+// documents whose relation no longer exists. Such documents are normally
+// removed by a cleanup scheduled along with the relation's removal, but
+// may be left behind when units die abruptly during relation teardown.
+func (st *State) OrphanedRelationSettings() ([]string, error) {
+	settings, closer := st.getCollection(settingsC)
+	defer closer()
+	relations, closer := st.getCollection(relationsC)
+	defer closer()
+
+	// Settings are read before relations: a relation always exists
+	// before any of its units' settings, so the settings of a newly
+	// added relation cannot be mistaken for orphans.
+	var settingsDocs []struct {
+		Key string `bson:"_id"`
+	}
+	sel := bson.D{{"_id", bson.D{{"$regex", "^r#"}}}}
+	if err := settings.Find(sel).Select(bson.D{{"_id", 1}}).All(&settingsDocs); err != nil {
+		return nil, fmt.Errorf("cannot read relation settings: %v", err)
+	}
+	var relationDocs []struct {
+		Id int
+	}
+	if err := relations.Find(nil).Select(bson.D{{"id", 1}}).All(&relationDocs); err != nil {
+		return nil, fmt.Errorf("cannot read relations: %v", err)
+	}
+	relationIds := make(map[string]bool)
+	for _, doc := range relationDocs {
+		relationIds[strconv.Itoa(doc.Id)] = true
+	}
+	var orphans []string
+	for _, doc := range settingsDocs {
+		// Relation unit settings keys start with "r#<relation id>#".
+		parts := strings.SplitN(doc.Key, "#", 3)
+		if len(parts) == 3 && !relationIds[parts[1]] {
+			orphans = append(orphans, doc.Key)
+		}
+	}
+	return orphans, nil
+}
+
+// RemoveOrphanedRelationSettings removes the relation unit settings
+// documents reported by OrphanedRelationSettings, and returns the
+// number of documents removed.
+func (st *State) RemoveOrphanedRelationSettings() (int, error) {
+	orphans, err := st.OrphanedRelationSettings()
+	if err != nil {
+		return 0, err
+	}
+	if len(orphans) == 0 {
+		return 0, nil
+	}
+	// As with cleanupRelationSettings, the documents are not otherwise
+	// referenced in the system and are safe to delete directly.
+	settings, closer := st.getCollection(settingsC)
+	defer closer()
+	info, err := settings.RemoveAll(bson.D{{"_id", bson.D{{"$in", orphans}}}})
+	if err != nil {
+		return 0, fmt.Errorf("cannot remove orphaned relation settings: %v", err)
+	}
+	return info.Removed, nil
+}
+
 // cleanupServicesForDyingEnvironment sets all services to Dying, if they are
 // not already Dying or Dead. It's expected to be used when an environment is
 // destroyed.
@@ -219,10 +284,10 @@ func (st *State) cleanupForceDestroyedMachine(machineId string) error {
 	} else if err != nil {
 		return err
 	}
-	// In an ideal world, we'd call machine.Destroy() here, and thus prevent
-	// new dependencies being added while we clean up the ones we know about.
-	// But machine destruction is unsophisticated, and doesn't allow for
-	// destruction while dependencies exist; so we just have to deal with that
+	// A machine that was itself force-destroyed is already Dying, so no
+	// new units can be assigned to it while we clean up the ones we know
+	// about. New containers can still be added, though, and containers
+	// are cleaned up while still Alive, so we have to deal with that
 	// possibility below.
 	if err := st.cleanupContainers(machine); err != nil {
 		return err
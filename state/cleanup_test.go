@@ -138,6 +138,42 @@ func (s *CleanupSuite) TestCleanupRelationSettings(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `cannot read settings for unit "riak/0" in relation "riak:ring": settings not found`)
 }
 
+func (s *CleanupSuite) TestOrphanedRelationSettings(c *gc.C) {
+	// Create a relation with a unit in scope.
+	pr := NewPeerRelation(c, s.State)
+	rel := pr.ru0.Relation()
+	err := pr.ru0.EnterScope(map[string]interface{}{"some": "settings"})
+	c.Assert(err, gc.IsNil)
+	orphans, err := s.State.OrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(orphans, gc.HasLen, 0)
+
+	// Remove the relation without running the cleanup
+	// that would remove the unit's settings.
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = pr.ru0.LeaveScope()
+	c.Assert(err, gc.IsNil)
+	orphans, err = s.State.OrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(orphans, gc.DeepEquals, []string{fmt.Sprintf("r#%d#peer#riak/0", rel.Id())})
+
+	removed, err := s.State.RemoveOrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(removed, gc.Equals, 1)
+	orphans, err = s.State.OrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(orphans, gc.HasLen, 0)
+	_, err = pr.ru1.ReadSettings("riak/0")
+	c.Assert(err, gc.ErrorMatches, `cannot read settings for unit "riak/0" in relation "riak:ring": settings not found`)
+
+	// The scheduled cleanup still runs happily.
+	s.assertCleanupCount(c, 1)
+	removed, err = s.State.RemoveOrphanedRelationSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(removed, gc.Equals, 0)
+}
+
 func (s *CleanupSuite) TestForceDestroyMachineErrors(c *gc.C) {
 	manager, err := s.State.AddMachine("quantal", state.JobManageEnviron)
 	c.Assert(err, gc.IsNil)
@@ -147,6 +183,16 @@ func (s *CleanupSuite) TestForceDestroyMachineErrors(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, expect)
 	s.assertDoesNotNeedCleanup(c)
 	assertLife(c, manager, state.Alive)
+
+	voter, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = voter.SetHasVote(true)
+	c.Assert(err, gc.IsNil)
+	err = voter.ForceDestroy()
+	expect = fmt.Sprintf("machine %s is a voting replica set member", voter.Id())
+	c.Assert(err, gc.ErrorMatches, expect)
+	s.assertDoesNotNeedCleanup(c)
+	assertLife(c, voter, state.Alive)
 }
 
 func (s *CleanupSuite) TestCleanupForceDestroyedMachineUnit(c *gc.C) {
@@ -169,6 +215,11 @@ func (s *CleanupSuite) TestCleanupForceDestroyedMachineUnit(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	s.assertNeedsCleanup(c)
 
+	// The machine is Dying at once, so no more units can be assigned to it.
+	assertLife(c, machine, state.Dying)
+	err = pr.u1.AssignToMachine(machine)
+	c.Assert(err, gc.ErrorMatches, ".*: machine is not alive")
+
 	// Clean up, and check that the unit has been removed...
 	s.assertCleanupCount(c, 2)
 	assertRemoved(c, pr.u0)
@@ -74,6 +74,55 @@ func (s *compatSuite) TestEnvironAssertAlive(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 }
 
+func (s *compatSuite) TestControllerSettingsWithoutDocument(c *gc.C) {
+	// Controllers initialized before 1.21 have no separate
+	// controller settings. We remove them here to test
+	// backwards compatibility.
+	ops := []txn.Op{{
+		C:      settingsC,
+		Id:     controllerSettingsGlobalKey,
+		Remove: true,
+	}}
+	err := s.state.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := s.state.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	settings, err := s.state.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["api-port"], gc.Equals, cfg.APIPort())
+
+	// Updating the environment configuration creates them.
+	err = s.state.UpdateEnvironConfig(map[string]interface{}{"rsyslog-ca-cert": testing.CACert}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	doc, err := readSettings(s.state, controllerSettingsGlobalKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(doc.Map()["rsyslog-ca-cert"], gc.Equals, testing.CACert)
+	c.Assert(doc.Map()["api-port"], gc.Equals, cfg.APIPort())
+}
+
+func (s *compatSuite) TestControllerSettingsAreAuthoritative(c *gc.C) {
+	// The controller settings win over any stale copies of them
+	// held in the environment settings.
+	settings, err := readSettings(s.state, controllerSettingsGlobalKey)
+	c.Assert(err, gc.IsNil)
+	settings.Set("api-port", 4321)
+	_, err = settings.Write()
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := s.state.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.APIPort(), gc.Equals, 4321)
+
+	// Updating the environment configuration brings the copy in
+	// the environment settings up to date.
+	err = s.state.UpdateEnvironConfig(map[string]interface{}{"logging-config": "juju=DEBUG"}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	environ, err := readSettings(s.state, environGlobalKey)
+	c.Assert(err, gc.IsNil)
+	c.Assert(environ.Map()["api-port"], gc.Equals, 4321)
+}
+
 func (s *compatSuite) TestGetServiceWithoutNetworksIsOK(c *gc.C) {
 	_, err := s.state.AddAdminUser("pass")
 	c.Assert(err, gc.IsNil)
@@ -136,7 +185,7 @@ func (s *compatSuite) TestShowUnitPorts(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{{"tcp", 80}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{80, 80, "tcp"}})
 }
 
 // Check if opening ports on a unit with ports stored in the unit doc works.
@@ -173,7 +222,7 @@ func (s *compatSuite) TestMigratePortsOnOpen(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 }
 
 // Check if closing ports on a unit with ports stored in the unit doc works.
@@ -210,5 +259,5 @@ func (s *compatSuite) TestMigratePortsOnClose(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	ports := unit.OpenedPorts()
-	c.Assert(ports, gc.DeepEquals, []network.Port{})
+	c.Assert(ports, gc.DeepEquals, []network.PortRange{})
 }
@@ -0,0 +1,458 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+// InconsistencyKind identifies a category of inconsistency in state.
+type InconsistencyKind string
+
+const (
+	// InconsistencyUnitServiceMissing reports a unit whose
+	// service does not exist.
+	InconsistencyUnitServiceMissing InconsistencyKind = "unit-service-missing"
+
+	// InconsistencyUnitMachineMissing reports a unit assigned
+	// to a machine that does not exist.
+	InconsistencyUnitMachineMissing InconsistencyKind = "unit-machine-missing"
+
+	// InconsistencyMachinePrincipal reports a machine listing a
+	// principal unit that does not exist or is not assigned to it.
+	InconsistencyMachinePrincipal InconsistencyKind = "machine-principal"
+
+	// InconsistencyServiceUnitCount reports a service whose unit
+	// count does not match the number of its units.
+	InconsistencyServiceUnitCount InconsistencyKind = "service-unit-count"
+
+	// InconsistencyServiceRelationCount reports a service whose
+	// relation count does not match the number of its relations.
+	InconsistencyServiceRelationCount InconsistencyKind = "service-relation-count"
+
+	// InconsistencyRelationSettingsOrphaned reports relation unit
+	// settings whose relation no longer exists.
+	InconsistencyRelationSettingsOrphaned InconsistencyKind = "relation-settings-orphaned"
+)
+
+// Inconsistency describes a dangling reference or reference count
+// mismatch found in state.
+type Inconsistency struct {
+	// Kind identifies the category of the inconsistency.
+	Kind InconsistencyKind
+
+	// Id holds the id of the document that is inconsistent.
+	Id string
+
+	// Detail describes the inconsistency.
+	Detail string
+
+	// check reports whether the inconsistency is still present,
+	// reading the documents involved afresh.
+	check func(st *State) (bool, error)
+
+	// repair, if not nil, fixes the inconsistency. It must fail
+	// if the inconsistency is no longer present.
+	repair func(st *State) error
+}
+
+// Repairable reports whether the inconsistency is in a category
+// that can be repaired safely.
+func (i Inconsistency) Repairable() bool {
+	return i.repair != nil
+}
+
+// ErrNotRepairable is returned when trying to repair an inconsistency
+// that cannot be repaired safely.
+var ErrNotRepairable = fmt.Errorf("inconsistency cannot be repaired safely")
+
+// errStateChanged is returned by repairs when the inconsistent
+// document has changed since it was checked.
+var errStateChanged = fmt.Errorf("state has changed")
+
+// RepairInconsistency repairs the given inconsistency, as returned by
+// CheckConsistency. It returns ErrNotRepairable if the inconsistency
+// is not Repairable, and an error if the inconsistent document has
+// changed since it was checked.
+func (st *State) RepairInconsistency(i Inconsistency) error {
+	if i.repair == nil {
+		return ErrNotRepairable
+	}
+	if err := i.repair(st); err != nil {
+		return fmt.Errorf("cannot repair %s %q: %v", i.Kind, i.Id, err)
+	}
+	return nil
+}
+
+// CheckConsistency scans state for dangling references between
+// services, units, machines and relations, and for reference counts
+// that do not match the documents they count. It does not change
+// anything; see RepairInconsistency.
+func (st *State) CheckConsistency() ([]Inconsistency, error) {
+	services, closer := st.getCollection(servicesC)
+	defer closer()
+	units, closer := st.getCollection(unitsC)
+	defer closer()
+	machines, closer := st.getCollection(machinesC)
+	defer closer()
+	relations, closer := st.getCollection(relationsC)
+	defer closer()
+
+	var sdocs []serviceDoc
+	if err := services.Find(nil).All(&sdocs); err != nil {
+		return nil, fmt.Errorf("cannot read services: %v", err)
+	}
+	var udocs []unitDoc
+	if err := units.Find(nil).All(&udocs); err != nil {
+		return nil, fmt.Errorf("cannot read units: %v", err)
+	}
+	var mdocs []machineDoc
+	if err := machines.Find(nil).All(&mdocs); err != nil {
+		return nil, fmt.Errorf("cannot read machines: %v", err)
+	}
+	var rdocs []relationDoc
+	if err := relations.Find(nil).All(&rdocs); err != nil {
+		return nil, fmt.Errorf("cannot read relations: %v", err)
+	}
+
+	machineExists := make(map[string]bool)
+	for _, mdoc := range mdocs {
+		machineExists[mdoc.Id] = true
+	}
+	serviceExists := make(map[string]bool)
+	for _, sdoc := range sdocs {
+		serviceExists[sdoc.Name] = true
+	}
+	unitMachines := make(map[string]string)
+	unitCounts := make(map[string]int)
+	var candidates []Inconsistency
+	for _, udoc := range udocs {
+		unitMachines[udoc.Name] = udoc.MachineId
+		unitCounts[udoc.Service]++
+		if !serviceExists[udoc.Service] {
+			candidates = append(candidates, Inconsistency{
+				Kind:   InconsistencyUnitServiceMissing,
+				Id:     udoc.Name,
+				Detail: fmt.Sprintf("unit %q belongs to missing service %q", udoc.Name, udoc.Service),
+				check:  unitServiceMissingCheck(udoc.Name, udoc.Service),
+			})
+		}
+		if udoc.MachineId != "" && !machineExists[udoc.MachineId] {
+			candidates = append(candidates, Inconsistency{
+				Kind:   InconsistencyUnitMachineMissing,
+				Id:     udoc.Name,
+				Detail: fmt.Sprintf("unit %q is assigned to missing machine %s", udoc.Name, udoc.MachineId),
+				check:  unitMachineMissingCheck(udoc.Name, udoc.MachineId),
+			})
+		}
+	}
+	for _, mdoc := range mdocs {
+		for _, name := range mdoc.Principals {
+			if machineId, ok := unitMachines[name]; ok && machineId == mdoc.Id {
+				continue
+			}
+			candidates = append(candidates, Inconsistency{
+				Kind:   InconsistencyMachinePrincipal,
+				Id:     mdoc.Id,
+				Detail: fmt.Sprintf("machine %s lists principal %q, which is not assigned to it", mdoc.Id, name),
+				check:  machinePrincipalCheck(mdoc.Id, name),
+				repair: pullPrincipalRepair(mdoc.Id, name),
+			})
+		}
+	}
+	relationCounts := make(map[string]int)
+	for _, rdoc := range rdocs {
+		for _, ep := range rdoc.Endpoints {
+			relationCounts[ep.ServiceName]++
+		}
+	}
+	for _, sdoc := range sdocs {
+		if count := unitCounts[sdoc.Name]; count != sdoc.UnitCount {
+			candidates = append(candidates, Inconsistency{
+				Kind:   InconsistencyServiceUnitCount,
+				Id:     sdoc.Name,
+				Detail: fmt.Sprintf("service %q has unit count %d, but %d units", sdoc.Name, sdoc.UnitCount, count),
+				check:  countCheck(sdoc.Name, "unitcount"),
+				repair: setCountRepair(sdoc.Name, "unitcount"),
+			})
+		}
+		if count := relationCounts[sdoc.Name]; count != sdoc.RelationCount {
+			candidates = append(candidates, Inconsistency{
+				Kind:   InconsistencyServiceRelationCount,
+				Id:     sdoc.Name,
+				Detail: fmt.Sprintf("service %q has relation count %d, but %d relations", sdoc.Name, sdoc.RelationCount, count),
+				check:  countCheck(sdoc.Name, "relationcount"),
+				repair: setCountRepair(sdoc.Name, "relationcount"),
+			})
+		}
+	}
+
+	orphans, err := st.OrphanedRelationSettings()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range orphans {
+		candidates = append(candidates, Inconsistency{
+			Kind:   InconsistencyRelationSettingsOrphaned,
+			Id:     key,
+			Detail: fmt.Sprintf("relation settings %q belong to a missing relation", key),
+			check:  orphanedSettingsCheck(key),
+			repair: removeSettingsRepair(key),
+		})
+	}
+
+	// The collections above were not read at a single point in
+	// time, so a change made while they were being read can look
+	// like an inconsistency. Only report those that are still
+	// present when the documents involved are read again.
+	var found []Inconsistency
+	for _, candidate := range candidates {
+		present, err := candidate.check(st)
+		if err != nil {
+			return nil, err
+		}
+		if present {
+			found = append(found, candidate)
+		}
+	}
+	return found, nil
+}
+
+// docExists reports whether the document with the given id exists in
+// the named collection.
+func (st *State) docExists(collection string, id interface{}) (bool, error) {
+	coll, closer := st.getCollection(collection)
+	defer closer()
+	count, err := coll.FindId(id).Count()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// unitMachineId returns the id of the machine the named unit is
+// assigned to, and whether the unit exists.
+func (st *State) unitMachineId(unitName string) (string, bool, error) {
+	units, closer := st.getCollection(unitsC)
+	defer closer()
+	var doc struct {
+		MachineId string
+	}
+	err := units.FindId(unitName).Select(bson.D{{"machineid", 1}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	return doc.MachineId, true, nil
+}
+
+// unitServiceMissingCheck returns a check that the named unit exists
+// while its service does not.
+func unitServiceMissingCheck(unitName, serviceName string) func(*State) (bool, error) {
+	return func(st *State) (bool, error) {
+		// The service is read first: a service always exists
+		// before its units, and is removed after them.
+		if exists, err := st.docExists(servicesC, serviceName); err != nil || exists {
+			return false, err
+		}
+		return st.docExists(unitsC, unitName)
+	}
+}
+
+// unitMachineMissingCheck returns a check that the named unit is
+// assigned to the given machine, which does not exist.
+func unitMachineMissingCheck(unitName, machineId string) func(*State) (bool, error) {
+	return func(st *State) (bool, error) {
+		if exists, err := st.docExists(machinesC, machineId); err != nil || exists {
+			return false, err
+		}
+		assigned, exists, err := st.unitMachineId(unitName)
+		if err != nil {
+			return false, err
+		}
+		return exists && assigned == machineId, nil
+	}
+}
+
+// machinePrincipalCheck returns a check that the given machine lists
+// the named unit as a principal, while the unit is not assigned to it.
+func machinePrincipalCheck(machineId, unitName string) func(*State) (bool, error) {
+	return func(st *State) (bool, error) {
+		// The unit is read first: it is assigned to a machine
+		// before the machine lists it as a principal.
+		assigned, exists, err := st.unitMachineId(unitName)
+		if err != nil || exists && assigned == machineId {
+			return false, err
+		}
+		machines, closer := st.getCollection(machinesC)
+		defer closer()
+		sel := bson.D{{"_id", machineId}, {"principals", unitName}}
+		count, err := machines.Find(sel).Count()
+		if err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	}
+}
+
+// pullPrincipalRepair returns a repair that removes the named unit
+// from the machine's principals.
+func pullPrincipalRepair(machineId, unitName string) func(*State) error {
+	return func(st *State) error {
+		// The unit must not have been added or assigned to the
+		// machine since the inconsistency was found.
+		unitAssert := txn.DocMissing
+		if exists, err := st.docExists(unitsC, unitName); err != nil {
+			return err
+		} else if exists {
+			unitAssert = bson.D{{"machineid", bson.D{{"$ne", machineId}}}}
+		}
+		ops := []txn.Op{{
+			C:      unitsC,
+			Id:     unitName,
+			Assert: unitAssert,
+		}, {
+			C:      machinesC,
+			Id:     machineId,
+			Assert: bson.D{{"principals", unitName}},
+			Update: bson.D{{"$pull", bson.D{{"principals", unitName}}}},
+		}}
+		return onAbort(st.runTransaction(ops), errStateChanged)
+	}
+}
+
+// serviceCount returns the value of the given count field of the named
+// service, the number of documents it should count, and the service's
+// txn-revno when the count was taken.
+func (st *State) serviceCount(serviceName, field string) (stored, actual int, txnRevno int64, err error) {
+	services, closer := st.getCollection(servicesC)
+	defer closer()
+	var sdoc serviceDoc
+	if err := services.FindId(serviceName).One(&sdoc); err == mgo.ErrNotFound {
+		return 0, 0, 0, errStateChanged
+	} else if err != nil {
+		return 0, 0, 0, err
+	}
+	// Units and relations are only added and removed along with a
+	// change to the service's count, so any change made after the
+	// service was read also changes its txn-revno.
+	var count int
+	switch field {
+	case "unitcount":
+		stored = sdoc.UnitCount
+		units, closer := st.getCollection(unitsC)
+		defer closer()
+		count, err = units.Find(bson.D{{"service", serviceName}}).Count()
+	case "relationcount":
+		stored = sdoc.RelationCount
+		relations, closer := st.getCollection(relationsC)
+		defer closer()
+		count, err = relations.Find(bson.D{{"endpoints.servicename", serviceName}}).Count()
+	default:
+		panic(fmt.Errorf("unknown service count %q", field))
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return stored, count, sdoc.TxnRevno, nil
+}
+
+// countCheck returns a check that the given count field of the named
+// service does not match the number of documents it counts.
+func countCheck(serviceName, field string) func(*State) (bool, error) {
+	return func(st *State) (bool, error) {
+		stored, actual, _, err := st.serviceCount(serviceName, field)
+		if err == errStateChanged {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return stored != actual, nil
+	}
+}
+
+// setCountRepair returns a repair that recounts the documents counted
+// by the given count field of the named service, and sets the field
+// to match.
+func setCountRepair(serviceName, field string) func(*State) error {
+	return func(st *State) error {
+		stored, actual, txnRevno, err := st.serviceCount(serviceName, field)
+		if err != nil {
+			return err
+		}
+		if stored == actual {
+			return errStateChanged
+		}
+		ops := []txn.Op{{
+			C:      servicesC,
+			Id:     serviceName,
+			Assert: bson.D{{"txn-revno", txnRevno}},
+			Update: bson.D{{"$set", bson.D{{field, actual}}}},
+		}}
+		return onAbort(st.runTransaction(ops), errStateChanged)
+	}
+}
+
+// relationSettingsId returns the id of the relation the relation unit
+// settings with the given key belong to.
+func relationSettingsId(key string) (int, error) {
+	// Relation unit settings keys start with "r#<relation id>#".
+	parts := strings.SplitN(key, "#", 3)
+	if len(parts) != 3 || parts[0] != "r" {
+		return 0, fmt.Errorf("%q is not a relation settings key", key)
+	}
+	return strconv.Atoi(parts[1])
+}
+
+// orphanedSettingsCheck returns a check that the relation unit
+// settings with the given key exist while their relation does not.
+func orphanedSettingsCheck(key string) func(*State) (bool, error) {
+	return func(st *State) (bool, error) {
+		id, err := relationSettingsId(key)
+		if err != nil {
+			return false, err
+		}
+		// A relation always exists before its units' settings, and
+		// relation ids are never reused, so once the relation is
+		// gone the settings are orphaned for good.
+		relations, closer := st.getCollection(relationsC)
+		defer closer()
+		count, err := relations.Find(bson.D{{"id", id}}).Count()
+		if err != nil || count > 0 {
+			return false, err
+		}
+		return st.docExists(settingsC, key)
+	}
+}
+
+// removeSettingsRepair returns a repair that removes the orphaned
+// relation settings with the given key.
+func removeSettingsRepair(key string) func(*State) error {
+	return func(st *State) error {
+		orphaned, err := orphanedSettingsCheck(key)(st)
+		if err != nil {
+			return err
+		}
+		if !orphaned {
+			return errStateChanged
+		}
+		// As with cleanupRelationSettings, the document is not
+		// otherwise referenced and is safe to delete directly.
+		settings, closer := st.getCollection(settingsC)
+		defer closer()
+		if err := settings.RemoveId(key); err == mgo.ErrNotFound {
+			return errStateChanged
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type ConsistencySuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ConsistencySuite{})
+
+func (s *ConsistencySuite) assertConsistent(c *gc.C) {
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 0)
+}
+
+func (s *ConsistencySuite) TestConsistent(c *gc.C) {
+	s.assertConsistent(c)
+
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	for _, svc := range []*state.Service{wordpress, mysql} {
+		unit, err := svc.AddUnit()
+		c.Assert(err, gc.IsNil)
+		err = unit.AssignToNewMachine()
+		c.Assert(err, gc.IsNil)
+	}
+	s.assertConsistent(c)
+}
+
+func (s *ConsistencySuite) TestServiceUnitCount(c *gc.C) {
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	state.SetServiceUnitCount(c, mysql, 3)
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Kind, gc.Equals, state.InconsistencyServiceUnitCount)
+	c.Assert(found[0].Id, gc.Equals, "mysql")
+	c.Assert(found[0].Detail, gc.Equals, `service "mysql" has unit count 3, but 1 units`)
+	c.Assert(found[0].Repairable(), jc.IsTrue)
+
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.IsNil)
+	s.assertConsistent(c)
+
+	// Repairing again fails, because the count has changed.
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.ErrorMatches, `cannot repair service-unit-count "mysql": state has changed`)
+}
+
+func (s *ConsistencySuite) TestServiceUnitCountRepairRecounts(c *gc.C) {
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	_, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	state.SetServiceUnitCount(c, mysql, 3)
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+
+	// A unit added after the check is counted by the repair.
+	err = mysql.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, err = mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.IsNil)
+	s.assertConsistent(c)
+}
+
+func (s *ConsistencySuite) TestMachinePrincipal(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	state.AddMachinePrincipal(c, machine, "mysql/0")
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Kind, gc.Equals, state.InconsistencyMachinePrincipal)
+	c.Assert(found[0].Id, gc.Equals, machine.Id())
+	c.Assert(found[0].Repairable(), jc.IsTrue)
+
+	// If the unit is added and assigned to the machine in the
+	// meantime, the repair is refused.
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf(`cannot repair machine-principal %q: state has changed`, machine.Id()))
+	s.assertConsistent(c)
+}
+
+func (s *ConsistencySuite) TestMachinePrincipalRepair(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	state.AddMachinePrincipal(c, machine, "mysql/0")
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.IsNil)
+	s.assertConsistent(c)
+
+	// The machine no longer appears to have units assigned.
+	err = machine.Destroy()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ConsistencySuite) TestUnitMachineMissing(c *gc.C) {
+	mysql := s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	unit, err := mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	state.SetUnitMachineId(c, unit, "42")
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Kind, gc.Equals, state.InconsistencyUnitMachineMissing)
+	c.Assert(found[0].Id, gc.Equals, "mysql/0")
+	c.Assert(found[0].Detail, gc.Equals, `unit "mysql/0" is assigned to missing machine 42`)
+	c.Assert(found[0].Repairable(), jc.IsFalse)
+
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.Equals, state.ErrNotRepairable)
+}
+
+func (s *ConsistencySuite) TestRelationSettingsOrphaned(c *gc.C) {
+	pr := NewPeerRelation(c, s.State)
+	rel := pr.ru0.Relation()
+	err := pr.ru0.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	err = rel.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = pr.ru0.LeaveScope()
+	c.Assert(err, gc.IsNil)
+
+	found, err := s.State.CheckConsistency()
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, gc.HasLen, 1)
+	c.Assert(found[0].Kind, gc.Equals, state.InconsistencyRelationSettingsOrphaned)
+	c.Assert(found[0].Id, gc.Equals, fmt.Sprintf("r#%d#peer#riak/0", rel.Id()))
+	c.Assert(found[0].Repairable(), jc.IsTrue)
+
+	err = s.State.RepairInconsistency(found[0])
+	c.Assert(err, gc.IsNil)
+	s.assertConsistent(c)
+}
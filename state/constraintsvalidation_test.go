@@ -4,6 +4,7 @@
 package state_test
 
 import (
+	"github.com/juju/loggo"
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
@@ -109,3 +110,25 @@ func (s *constraintsValidationSuite) TestServiceConstraints(c *gc.C) {
 		c.Check(*ucons, jc.DeepEquals, constraints.MustParse(t.expected))
 	}
 }
+
+func (s *constraintsValidationSuite) TestIgnoredConstraintsWarning(c *gc.C) {
+	defer loggo.ResetWriters()
+	var tw loggo.TestWriter
+	c.Assert(loggo.RegisterWriter("constraints-tester", &tw, loggo.DEBUG), gc.IsNil)
+
+	err := s.State.SetEnvironConstraints(constraints.MustParse("mem=4G cpu-power=1000"))
+	c.Assert(err, gc.IsNil)
+	tw.Clear()
+	m, err := s.addOneMachine(c, constraints.MustParse("instance-type=foo"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(tw.Log(), jc.LogMatches, jc.SimpleMessages{{
+		loggo.WARNING,
+		`ignoring environment constraints overridden by conflicting constraints: mem`,
+	}, {
+		loggo.WARNING,
+		`ignoring unsupported constraints: cpu-power`,
+	}})
+	cons, err := m.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cons, jc.DeepEquals, constraints.MustParse("instance-type=foo cpu-power=1000"))
+}
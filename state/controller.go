@@ -0,0 +1,100 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"github.com/juju/errors"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/juju/environs/config"
+)
+
+// controllerSettingsGlobalKey is the key for the settings shared by
+// all environments hosted by the controller.
+const controllerSettingsGlobalKey = "controller"
+
+// ControllerSettingsKeys holds the names of the configuration
+// attributes that belong to the controller rather than to any one
+// environment it hosts.
+var ControllerSettingsKeys = []string{
+	"api-port",
+	"state-port",
+	"syslog-port",
+	"ca-cert",
+	"rsyslog-ca-cert",
+	"state-server-dns-name",
+	"mongo-oplog-size",
+	"mongo-cache-size",
+	"mongo-db-dir",
+}
+
+// controllerSettings returns the controller settings held in cfg.
+func controllerSettings(cfg *config.Config) map[string]interface{} {
+	attrs := cfg.AllAttrs()
+	settings := make(map[string]interface{})
+	for _, key := range ControllerSettingsKeys {
+		if value, ok := attrs[key]; ok {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+// ControllerSettings returns the settings shared by all environments
+// hosted by the controller, such as the ports its servers listen on
+// and its CA certificate.
+func (st *State) ControllerSettings() (map[string]interface{}, error) {
+	settings, err := readSettings(st, controllerSettingsGlobalKey)
+	if err == nil {
+		return settings.Map(), nil
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	// Controllers initialized by versions of Juju that kept all
+	// settings in the environment configuration have no separate
+	// controller settings until the configuration is next updated.
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	return controllerSettings(cfg), nil
+}
+
+// readEnvironSettings returns the environment settings, and the
+// controller settings if they exist, along with the attributes of the
+// environment configuration. The controller settings take precedence
+// over any copies of them held in the environment settings.
+func readEnvironSettings(st *State) (environ, controller *Settings, attrs map[string]interface{}, err error) {
+	environ, err = readSettings(st, environGlobalKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	attrs = environ.Map()
+	controller, err = readSettings(st, controllerSettingsGlobalKey)
+	if errors.IsNotFound(err) {
+		return environ, nil, attrs, nil
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, key := range ControllerSettingsKeys {
+		delete(attrs, key)
+	}
+	for key, value := range controller.Map() {
+		attrs[key] = value
+	}
+	return environ, controller, attrs, nil
+}
+
+// writeEnvironSettingsOps returns the operations needed to replace the
+// environment and controller settings read by readEnvironSettings with
+// those held in cfg. The controller settings are still copied into the
+// environment settings, for the benefit of older clients and agents,
+// but both are written in the same transaction.
+func writeEnvironSettingsOps(st *State, environ, controller *Settings, cfg *config.Config) []txn.Op {
+	ops := []txn.Op{environ.replaceOp(cfg.AllAttrs())}
+	if controller == nil {
+		return append(ops, createSettingsOp(st, controllerSettingsGlobalKey, controllerSettings(cfg)))
+	}
+	return append(ops, controller.replaceOp(controllerSettings(cfg)))
+}
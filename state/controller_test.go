@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+)
+
+type ControllerSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ControllerSuite{})
+
+func (s *ControllerSuite) TestControllerSettings(c *gc.C) {
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	settings, err := s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+
+	caCert, _ := cfg.CACert()
+	c.Assert(settings["api-port"], gc.Equals, cfg.APIPort())
+	c.Assert(settings["state-port"], gc.Equals, cfg.StatePort())
+	c.Assert(settings["syslog-port"], gc.Equals, cfg.SyslogPort())
+	c.Assert(settings["ca-cert"], gc.Equals, caCert)
+	for key := range settings {
+		c.Assert(key, jc.Satisfies, isControllerSettingsKey)
+	}
+	// Environment-specific attributes are not controller settings.
+	c.Assert(settings["name"], gc.IsNil)
+	c.Assert(settings["uuid"], gc.IsNil)
+}
+
+func (s *ControllerSuite) TestControllerSettingsFollowEnvironConfig(c *gc.C) {
+	settings, err := s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["rsyslog-ca-cert"], gc.IsNil)
+
+	err = s.State.UpdateEnvironConfig(map[string]interface{}{
+		"rsyslog-ca-cert": testing.CACert,
+		"logging-config":  "juju=DEBUG",
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	settings, err = s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["rsyslog-ca-cert"], gc.Equals, testing.CACert)
+	c.Assert(settings["logging-config"], gc.IsNil)
+
+	err = s.State.UpdateEnvironConfig(nil, []string{"rsyslog-ca-cert"}, nil)
+	c.Assert(err, gc.IsNil)
+	settings, err = s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["rsyslog-ca-cert"], gc.IsNil)
+}
+
+func (s *ControllerSuite) TestUpdateEnvironConfigConcurrentChange(c *gc.C) {
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := s.State.UpdateEnvironConfig(map[string]interface{}{
+			"rsyslog-ca-cert": testing.CACert,
+		}, nil, nil)
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"logging-config": "juju=DEBUG",
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+
+	// Neither change is lost.
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.AllAttrs()["logging-config"], gc.Equals, "juju=DEBUG")
+	c.Assert(cfg.AllAttrs()["rsyslog-ca-cert"], gc.Equals, testing.CACert)
+	settings, err := s.State.ControllerSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["rsyslog-ca-cert"], gc.Equals, testing.CACert)
+}
+
+func isControllerSettingsKey(key string) bool {
+	for _, k := range state.ControllerSettingsKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
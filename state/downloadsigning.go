@@ -0,0 +1,60 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+const downloadSigningKeyKey = "downloadSigningKey"
+
+// downloadSigningKeySize holds the size in bytes of the download
+// signing key.
+const downloadSigningKeySize = 32
+
+// downloadSigningKeyDoc holds the key with which API servers sign the
+// URLs of downloads they proxy.
+type downloadSigningKeyDoc struct {
+	Key string `bson:"key"`
+}
+
+// DownloadSigningKey returns the secret key with which API servers
+// sign the expiring URLs of the downloads they proxy to agents. The key
+// is shared by all the API servers, and is independent of any other
+// secret; it is created the first time it is needed.
+func (st *State) DownloadSigningKey() ([]byte, error) {
+	stateServers, closer := st.getCollection(stateServersC)
+	defer closer()
+
+	var doc downloadSigningKeyDoc
+	err := stateServers.Find(bson.D{{"_id", downloadSigningKeyKey}}).One(&doc)
+	if err == mgo.ErrNotFound {
+		key := make([]byte, downloadSigningKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.Annotate(err, "cannot generate download signing key")
+		}
+		doc.Key = hex.EncodeToString(key)
+		ops := []txn.Op{{
+			C:      stateServersC,
+			Id:     downloadSigningKeyKey,
+			Assert: txn.DocMissing,
+			Insert: &doc,
+		}}
+		err = st.runTransaction(ops)
+		if err == txn.ErrAborted {
+			// Another API server created the key first.
+			err = stateServers.Find(bson.D{{"_id", downloadSigningKeyKey}}).One(&doc)
+		}
+	}
+	if err != nil {
+		return nil, errors.Annotate(err, "cannot get download signing key")
+	}
+	return hex.DecodeString(doc.Key)
+}
@@ -9,6 +9,9 @@ import (
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/juju/constraints"
+	"github.com/juju/juju/environs/config"
 )
 
 // environGlobalKey is the key for the environment, its
@@ -22,30 +25,184 @@ type Environment struct {
 	annotator
 }
 
+// EnvironPhase describes the phase of an environment's lifecycle as
+// seen by clients of its API server. The earlier phases of bootstrap
+// cannot be seen through the API, as there is no API server until
+// they are over; providers record them in their own state instead.
+type EnvironPhase string
+
+const (
+	// EnvironReady is the phase of an environment that has been
+	// bootstrapped and is in use.
+	EnvironReady EnvironPhase = "ready"
+
+	// EnvironDestroying is the phase of an environment that is
+	// being destroyed.
+	EnvironDestroying EnvironPhase = "destroying"
+)
+
 // environmentDoc represents the internal state of the environment in MongoDB.
 type environmentDoc struct {
 	UUID string `bson:"_id"`
 	Name string
 	Life Life
+
+	// ServerUUID holds the UUID of the environment whose state
+	// servers host this one. It is empty for that environment.
+	ServerUUID string `bson:",omitempty"`
+
+	// Users holds the names of the users that may connect to a
+	// hosted environment. Any user may connect to the state server
+	// environment.
+	Users []string `bson:",omitempty"`
+}
+
+// environNameDoc reserves the name of a hosted environment, so that
+// no two hosted environments can be created with the same name.
+type environNameDoc struct {
+	Name string `bson:"_id"`
+	UUID string
 }
 
-// Environment returns the environment entity.
+// isServerEnvironDoc selects the environment document of the
+// environment that the state servers were bootstrapped into.
+var isServerEnvironDoc = bson.D{{"serveruuid", bson.D{{"$exists", false}}}}
+
+// Environment returns the environment entity of the environment
+// that the state servers were bootstrapped into.
 func (st *State) Environment() (*Environment, error) {
 	environments, closer := st.getCollection(environmentsC)
 	defer closer()
 
 	env := &Environment{st: st}
-	if err := env.refresh(environments.Find(nil)); err != nil {
+	if err := env.refresh(environments.Find(isServerEnvironDoc)); err != nil {
 		return nil, err
 	}
-	env.annotator = annotator{
-		globalKey: env.globalKey(),
-		tag:       env.Tag(),
-		st:        st,
+	env.setAnnotator()
+	return env, nil
+}
+
+// GetEnvironment returns the environment with the given tag, which
+// may be hosted by the state servers of another environment.
+func (st *State) GetEnvironment(tag names.EnvironTag) (*Environment, error) {
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+
+	env := &Environment{st: st}
+	if err := env.refresh(environments.FindId(tag.Id())); err != nil {
+		return nil, err
 	}
+	env.setAnnotator()
 	return env, nil
 }
 
+// AllEnvironments returns all the environments known to the state
+// servers, including the one they were bootstrapped into.
+func (st *State) AllEnvironments() ([]*Environment, error) {
+	environments, closer := st.getCollection(environmentsC)
+	defer closer()
+
+	var docs []environmentDoc
+	if err := environments.Find(nil).Sort("name").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all environments")
+	}
+	envs := make([]*Environment, len(docs))
+	for i, doc := range docs {
+		envs[i] = &Environment{st: st, doc: doc}
+		envs[i].setAnnotator()
+	}
+	return envs, nil
+}
+
+// NewEnvironment creates an environment with the given configuration,
+// hosted by the state servers of the environment they were
+// bootstrapped into. Only the given owner may connect to it.
+func (st *State) NewEnvironment(cfg *config.Config, owner names.UserTag) (_ *Environment, err error) {
+	defer errors.Maskf(&err, "cannot create environment %q", cfg.Name())
+	if err := checkEnvironConfig(cfg); err != nil {
+		return nil, err
+	}
+	uuid, ok := cfg.UUID()
+	if !ok {
+		return nil, errors.Errorf("environment uuid was not supplied")
+	}
+	serverEnv, err := st.Environment()
+	if err != nil {
+		return nil, err
+	}
+	if serverEnv.Life() != Alive {
+		return nil, errors.Errorf("state server environment is no longer alive")
+	}
+	// The state server environment's name never changes, so it
+	// need not be reserved by the transaction.
+	if cfg.Name() == serverEnv.Name() {
+		return nil, errors.AlreadyExistsf("environment")
+	}
+
+	env := &Environment{
+		st: st,
+		doc: environmentDoc{
+			UUID:       uuid,
+			Name:       cfg.Name(),
+			Life:       Alive,
+			ServerUUID: serverEnv.UUID(),
+			Users:      []string{owner.Name()},
+		},
+	}
+	env.setAnnotator()
+	ops := []txn.Op{
+		serverEnv.assertAliveOp(),
+		{
+			C:      environNamesC,
+			Id:     cfg.Name(),
+			Assert: txn.DocMissing,
+			Insert: &environNameDoc{UUID: uuid},
+		},
+		createConstraintsOp(st, env.globalKey(), constraints.Value{}),
+		createSettingsOp(st, env.globalKey(), cfg.AllAttrs()),
+		{
+			C:      environmentsC,
+			Id:     uuid,
+			Assert: txn.DocMissing,
+			Insert: &env.doc,
+		},
+	}
+	if err := st.runTransaction(ops); err == txn.ErrAborted {
+		if exists, err := st.environNameExists(cfg.Name()); err != nil {
+			return nil, err
+		} else if exists {
+			return nil, errors.AlreadyExistsf("environment")
+		}
+		if _, err := st.GetEnvironment(env.EnvironTag()); err == nil {
+			return nil, errors.AlreadyExistsf("environment")
+		}
+		return nil, errors.Errorf("state server environment is no longer alive")
+	} else if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// environNameExists reports whether a hosted environment has
+// reserved the given name.
+func (st *State) environNameExists(name string) (bool, error) {
+	environNames, closer := st.getCollection(environNamesC)
+	defer closer()
+	n, err := environNames.FindId(name).Count()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (e *Environment) setAnnotator() {
+	e.annotator = annotator{
+		globalKey: e.globalKey(),
+		tag:       e.Tag(),
+		st:        e.st,
+	}
+}
+
 // Tag returns a name identifying the environment.
 // The returned name will be different from other Tag values returned
 // by any other entities from the same state.
@@ -53,6 +210,50 @@ func (e *Environment) Tag() names.Tag {
 	return names.NewEnvironTag(e.doc.UUID)
 }
 
+// EnvironTag is the concrete environ tag for this environment.
+func (e *Environment) EnvironTag() names.EnvironTag {
+	return names.NewEnvironTag(e.doc.UUID)
+}
+
+// ServerTag returns the tag of the environment whose state servers
+// host this one.
+func (e *Environment) ServerTag() names.EnvironTag {
+	if e.doc.ServerUUID == "" {
+		return e.EnvironTag()
+	}
+	return names.NewEnvironTag(e.doc.ServerUUID)
+}
+
+// IsHosted reports whether the environment is hosted by the state
+// servers of another environment.
+func (e *Environment) IsHosted() bool {
+	return e.doc.ServerUUID != ""
+}
+
+// HasAccess reports whether the given user may connect to the
+// environment. Any user may connect to the state server environment;
+// only the users it was created for may connect to a hosted one.
+func (e *Environment) HasAccess(user names.UserTag) bool {
+	if !e.IsHosted() {
+		return true
+	}
+	for _, name := range e.doc.Users {
+		if name == user.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// Config returns the configuration of the environment.
+func (e *Environment) Config() (*config.Config, error) {
+	settings, err := readSettings(e.st, e.globalKey())
+	if err != nil {
+		return nil, err
+	}
+	return config.New(config.NoDefaults, settings.Map())
+}
+
 // UUID returns the universally unique identifier of the environment.
 func (e *Environment) UUID() string {
 	return e.doc.UUID
@@ -68,8 +269,19 @@ func (e *Environment) Life() Life {
 	return e.doc.Life
 }
 
+// Phase returns the phase of the environment's lifecycle.
+func (e *Environment) Phase() EnvironPhase {
+	if e.doc.Life != Alive {
+		return EnvironDestroying
+	}
+	return EnvironReady
+}
+
 // globalKey returns the global database key for the environment.
 func (e *Environment) globalKey() string {
+	if e.doc.ServerUUID != "" {
+		return environGlobalKey + "#" + e.doc.UUID
+	}
 	return environGlobalKey
 }
 
@@ -90,10 +302,16 @@ func (e *Environment) refresh(query *mgo.Query) error {
 
 // Destroy sets the environment's lifecycle to Dying, preventing
 // addition of services or machines to state.
+//
+// Hosted environments hold no machines or services of their own, so
+// destroying one removes it from state immediately.
 func (e *Environment) Destroy() error {
 	if e.Life() != Alive {
 		return nil
 	}
+	if e.IsHosted() {
+		return e.removeHosted()
+	}
 	// TODO(axw) 2013-12-11 #1218688
 	// Resolve the race between checking for manual machines and
 	// destroying the environment. We can set Environment to Dying
@@ -124,10 +342,42 @@ func (e *Environment) Destroy() error {
 	return err
 }
 
+// removeHosted removes a hosted environment, together with its
+// settings and constraints, and releases its name.
+func (e *Environment) removeHosted() error {
+	ops := []txn.Op{{
+		C:      environmentsC,
+		Id:     e.doc.UUID,
+		Assert: isEnvAliveDoc,
+		Remove: true,
+	}, {
+		C:      environNamesC,
+		Id:     e.doc.Name,
+		Remove: true,
+	}, {
+		C:      settingsC,
+		Id:     e.globalKey(),
+		Remove: true,
+	}, removeConstraintsOp(e.st, e.globalKey())}
+	err := e.st.runTransaction(ops)
+	switch err {
+	case nil, txn.ErrAborted:
+		// If the transaction aborted, the environment has
+		// already been removed.
+		e.doc.Life = Dead
+		return nil
+	}
+	return err
+}
+
 // createEnvironmentOp returns the operation needed to create
 // an environment document with the given name and UUID.
 func createEnvironmentOp(st *State, name, uuid string) txn.Op {
-	doc := &environmentDoc{uuid, name, Alive}
+	doc := &environmentDoc{
+		UUID: uuid,
+		Name: name,
+		Life: Alive,
+	}
 	return txn.Op{
 		C:      environmentsC,
 		Id:     uuid,
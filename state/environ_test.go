@@ -4,10 +4,14 @@
 package state_test
 
 import (
+	"github.com/juju/errors"
 	"github.com/juju/names"
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
 )
 
 type EnvironSuite struct {
@@ -48,8 +52,147 @@ func (s *EnvironSuite) TestUUID(c *gc.C) {
 	c.Assert(uuidA, gc.Not(gc.Equals), uuidB)
 }
 
+func (s *EnvironSuite) TestPhase(c *gc.C) {
+	c.Assert(s.env.Phase(), gc.Equals, state.EnvironReady)
+	err := s.env.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.env.Phase(), gc.Equals, state.EnvironDestroying)
+	env, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Phase(), gc.Equals, state.EnvironDestroying)
+}
+
 func (s *EnvironSuite) TestAnnotatorForEnvironment(c *gc.C) {
 	testAnnotator(c, func() (state.Annotator, error) {
 		return s.State.Environment()
 	})
 }
+
+func (s *EnvironSuite) newHostedEnviron(c *gc.C, name string) *state.Environment {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": name,
+		"uuid": uuid.String(),
+	})
+	env, err := s.State.NewEnvironment(cfg, names.NewUserTag("admin"))
+	c.Assert(err, gc.IsNil)
+	return env
+}
+
+func (s *EnvironSuite) TestNewEnvironment(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	c.Assert(env.Name(), gc.Equals, "hosted")
+	c.Assert(env.Life(), gc.Equals, state.Alive)
+	c.Assert(env.Phase(), gc.Equals, state.EnvironReady)
+	c.Assert(env.IsHosted(), jc.IsTrue)
+	c.Assert(env.ServerTag(), gc.Equals, s.env.EnvironTag())
+
+	cfg, err := env.Config()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Name(), gc.Equals, "hosted")
+
+	got, err := s.State.GetEnvironment(env.EnvironTag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.UUID(), gc.Equals, env.UUID())
+
+	// The state server environment is unaffected.
+	serverEnv, err := s.State.Environment()
+	c.Assert(err, gc.IsNil)
+	c.Assert(serverEnv.UUID(), gc.Equals, s.env.UUID())
+	c.Assert(serverEnv.IsHosted(), jc.IsFalse)
+	c.Assert(serverEnv.ServerTag(), gc.Equals, s.env.EnvironTag())
+	serverCfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(serverCfg.Name(), gc.Equals, "testenv")
+}
+
+func (s *EnvironSuite) TestNewEnvironmentDuplicateName(c *gc.C) {
+	s.newHostedEnviron(c, "hosted")
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": "hosted",
+		"uuid": uuid.String(),
+	})
+	_, err = s.State.NewEnvironment(cfg, names.NewUserTag("admin"))
+	c.Assert(err, gc.ErrorMatches, `cannot create environment "hosted": environment already exists`)
+
+	// The state server environment's name is taken too.
+	cfg = testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": "testenv",
+		"uuid": uuid.String(),
+	})
+	_, err = s.State.NewEnvironment(cfg, names.NewUserTag("admin"))
+	c.Assert(err, gc.ErrorMatches, `cannot create environment "testenv": environment already exists`)
+}
+
+func (s *EnvironSuite) TestNewEnvironmentNameReleasedOnDestroy(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	err := env.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.newHostedEnviron(c, "hosted")
+}
+
+func (s *EnvironSuite) TestHasAccess(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	c.Assert(env.HasAccess(names.NewUserTag("admin")), jc.IsTrue)
+	c.Assert(env.HasAccess(names.NewUserTag("bob")), jc.IsFalse)
+	c.Assert(s.env.HasAccess(names.NewUserTag("bob")), jc.IsTrue)
+}
+
+func (s *EnvironSuite) TestNewEnvironmentServerNotAlive(c *gc.C) {
+	err := s.env.Destroy()
+	c.Assert(err, gc.IsNil)
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	cfg := testing.CustomEnvironConfig(c, testing.Attrs{
+		"name": "hosted",
+		"uuid": uuid.String(),
+	})
+	_, err = s.State.NewEnvironment(cfg, names.NewUserTag("admin"))
+	c.Assert(err, gc.ErrorMatches, `cannot create environment "hosted": state server environment is no longer alive`)
+}
+
+func (s *EnvironSuite) TestAllEnvironments(c *gc.C) {
+	s.newHostedEnviron(c, "zebra")
+	s.newHostedEnviron(c, "aardvark")
+	envs, err := s.State.AllEnvironments()
+	c.Assert(err, gc.IsNil)
+	var envNames []string
+	for _, env := range envs {
+		envNames = append(envNames, env.Name())
+	}
+	c.Assert(envNames, jc.DeepEquals, []string{"aardvark", "testenv", "zebra"})
+}
+
+func (s *EnvironSuite) TestFindEntityHostedEnvironment(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	entity, err := s.State.FindEntity(env.Tag().String())
+	c.Assert(err, gc.IsNil)
+	c.Assert(entity.Tag(), gc.Equals, env.Tag())
+}
+
+func (s *EnvironSuite) TestDestroyHostedEnvironment(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	err := env.Destroy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(env.Life(), gc.Equals, state.Dead)
+
+	_, err = s.State.GetEnvironment(env.EnvironTag())
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	_, err = env.Config()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+
+	// The state server environment is still alive.
+	err = s.env.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.env.Life(), gc.Equals, state.Alive)
+}
+
+func (s *EnvironSuite) TestAnnotatorForHostedEnvironment(c *gc.C) {
+	env := s.newHostedEnviron(c, "hosted")
+	testAnnotator(c, func() (state.Annotator, error) {
+		return s.State.GetEnvironment(env.EnvironTag())
+	})
+}
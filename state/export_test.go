@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"path/filepath"
+	"time"
 
 	"github.com/juju/charm"
 	charmtesting "github.com/juju/charm/testing"
@@ -176,6 +177,7 @@ func GetPasswordHash(e Authenticator) string {
 
 func init() {
 	logSize = logSizeTests
+	metricsSize = metricsSizeTests
 }
 
 // TxnRevno returns the txn-revno field of the document
@@ -191,6 +193,18 @@ func TxnRevno(st *State, coll string, id interface{}) (int64, error) {
 	return doc.TxnRevno, nil
 }
 
+// PruneTransactionsBefore prunes completed transactions
+// created before the given time.
+func PruneTransactionsBefore(st *State, t time.Time) (PruneResult, error) {
+	return st.pruneTransactionsBefore(t, nil)
+}
+
+// TxnCount returns the number of documents in the
+// transactions collection.
+func TxnCount(st *State) (int, error) {
+	return st.db.C(txnsC).Count()
+}
+
 // MinUnitsRevno returns the Revno of the minUnits document
 // associated with the given service name.
 func MinUnitsRevno(st *State, serviceName string) (int, error) {
@@ -248,6 +262,43 @@ func WatcherMakeIdFilter(marker string, receivers ...ActionReceiver) func(interf
 }
 
 var (
-	GetOrCreatePorts = getOrCreatePorts
-	GetPorts         = getPorts
+	GetOrCreatePorts  = getOrCreatePorts
+	GetPorts          = getPorts
+	TxnPruneBatchSize = &txnPruneBatchSize
 )
+
+// SetServiceUnitCount sets the unit count of the given service without
+// changing its units, as an interrupted or buggy change might.
+func SetServiceUnitCount(c *gc.C, s *Service, count int) {
+	ops := []txn.Op{{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Update: bson.D{{"$set", bson.D{{"unitcount", count}}}},
+	}}
+	err := s.st.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+}
+
+// AddMachinePrincipal adds the named unit to the machine's principals
+// without assigning the unit to the machine.
+func AddMachinePrincipal(c *gc.C, m *Machine, unitName string) {
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Update: bson.D{{"$addToSet", bson.D{{"principals", unitName}}}},
+	}}
+	err := m.st.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+}
+
+// SetUnitMachineId sets the machine id of the given unit without
+// changing the machine's principals.
+func SetUnitMachineId(c *gc.C, u *Unit, machineId string) {
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Update: bson.D{{"$set", bson.D{{"machineid", machineId}}}},
+	}}
+	err := u.st.runTransaction(ops)
+	c.Assert(err, gc.IsNil)
+}
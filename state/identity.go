@@ -203,7 +203,7 @@ func (i *Identity) PasswordValid(password string) bool {
 	}
 
 	pwHash := utils.UserPasswordHash(password, i.doc.PasswordSalt)
-	return pwHash == i.doc.PasswordHash
+	return passwordHashMatches(pwHash, i.doc.PasswordHash)
 }
 
 // Refresh refreshes information about the Identity from the state.
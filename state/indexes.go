@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"labix.org/v2/mgo"
+)
+
+var indexes = []struct {
+	collection string
+	key        []string
+	unique     bool
+}{
+	// After the first public release, do not remove entries from here
+	// without adding them to a list of indexes to drop, to ensure
+	// old databases are modified to have the correct indexes.
+	{relationsC, []string{"endpoints.relationname"}, false},
+	{relationsC, []string{"endpoints.servicename"}, false},
+	{relationsC, []string{"id"}, false},
+	{unitsC, []string{"service"}, false},
+	{unitsC, []string{"principal"}, false},
+	{unitsC, []string{"machineid"}, false},
+	{machinesC, []string{"jobs"}, false},
+	// TODO(thumper): schema change to remove this index.
+	{usersC, []string{"name"}, false},
+	{networksC, []string{"providerid"}, true},
+	{networkInterfacesC, []string{"interfacename", "machineid"}, true},
+	{networkInterfacesC, []string{"macaddress", "networkname"}, true},
+	{networkInterfacesC, []string{"networkname"}, false},
+	{networkInterfacesC, []string{"machineid"}, false},
+	{metricsC, []string{"entity", "created"}, false},
+	// Transactions are found by state when they are resumed
+	// and pruned.
+	{txnsC, []string{"s"}, false},
+
+	// Statuses and annotations are only ever looked up by global
+	// key, which is their _id, and the transaction log is read in
+	// natural order, so they need no further indexes.
+}
+
+// IndexInfo identifies an index on a collection in the juju database.
+type IndexInfo struct {
+	// Collection holds the name of the indexed collection.
+	Collection string
+
+	// Key holds the indexed fields, in order.
+	Key []string
+}
+
+func (i IndexInfo) String() string {
+	return fmt.Sprintf("%s (%s)", i.Collection, strings.Join(i.Key, ", "))
+}
+
+// IndexReport describes how the indexes in the juju database differ
+// from those that juju requires.
+type IndexReport struct {
+	// Missing holds the required indexes that do not exist.
+	Missing []IndexInfo
+
+	// Extra holds the indexes that exist but are not required.
+	// They are left in place, as they may have been added by an
+	// administrator.
+	Extra []IndexInfo
+}
+
+// CheckIndexes reports the required indexes that are missing from
+// the juju database, and any others that exist.
+func (st *State) CheckIndexes() (*IndexReport, error) {
+	return checkIndexes(st.db)
+}
+
+// EnsureIndexes creates any required indexes that are missing from
+// the juju database, logging them and any others that exist. It
+// returns the report from before they were created. As it lists the
+// indexes of every collection, it is run as an upgrade step rather
+// than whenever state is opened.
+func (st *State) EnsureIndexes() (*IndexReport, error) {
+	report, err := checkIndexes(st.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range report.Missing {
+		logger.Infof("creating missing index on %v", info)
+	}
+	for _, info := range report.Extra {
+		logger.Infof("found index not required by juju on %v", info)
+	}
+	if err := ensureIndexes(st.db); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func checkIndexes(db *mgo.Database) (*IndexReport, error) {
+	required := make(map[string]bool)
+	for _, item := range indexes {
+		required[indexId(item.collection, item.key)] = true
+	}
+	names, err := db.CollectionNames()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list collections: %v", err)
+	}
+	existing := make(map[string]bool)
+	report := &IndexReport{}
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") {
+			continue
+		}
+		found, err := db.C(name).Indexes()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list indexes of %q: %v", name, err)
+		}
+		for _, index := range found {
+			if len(index.Key) == 1 && index.Key[0] == "_id" {
+				continue
+			}
+			id := indexId(name, index.Key)
+			existing[id] = true
+			if !required[id] {
+				report.Extra = append(report.Extra, IndexInfo{name, index.Key})
+			}
+		}
+	}
+	for _, item := range indexes {
+		if !existing[indexId(item.collection, item.key)] {
+			report.Missing = append(report.Missing, IndexInfo{item.collection, item.key})
+		}
+	}
+	sort.Sort(indexInfos(report.Extra))
+	return report, nil
+}
+
+// ensureIndexes creates the indexes required by juju. They are built
+// in the background, so that opening state is not held up while a
+// large collection, such as the transactions, is indexed.
+func ensureIndexes(db *mgo.Database) error {
+	for _, item := range indexes {
+		index := mgo.Index{Key: item.key, Unique: item.unique, Background: true}
+		if err := db.C(item.collection).EnsureIndex(index); err != nil {
+			return fmt.Errorf("cannot create database index: %v", err)
+		}
+	}
+	return nil
+}
+
+func indexId(collection string, key []string) string {
+	return collection + " " + strings.Join(key, " ")
+}
+
+type indexInfos []IndexInfo
+
+func (s indexInfos) Len() int      { return len(s) }
+func (s indexInfos) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s indexInfos) Less(i, j int) bool {
+	return s[i].String() < s[j].String()
+}
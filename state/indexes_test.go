@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	jc "github.com/juju/testing/checkers"
+	"labix.org/v2/mgo"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type IndexesSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&IndexesSuite{})
+
+func (s *IndexesSuite) TestCheckIndexes(c *gc.C) {
+	report, err := s.State.CheckIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Missing, gc.HasLen, 0)
+	c.Assert(report.Extra, gc.HasLen, 0)
+}
+
+func (s *IndexesSuite) TestEnsureIndexesCreatesMissing(c *gc.C) {
+	err := s.units.DropIndex("service")
+	c.Assert(err, gc.IsNil)
+
+	report, err := s.State.CheckIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Missing, gc.DeepEquals, []state.IndexInfo{
+		{Collection: "units", Key: []string{"service"}},
+	})
+
+	report, err = s.State.EnsureIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Missing, gc.HasLen, 1)
+
+	report, err = s.State.CheckIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Missing, gc.HasLen, 0)
+}
+
+func (s *IndexesSuite) TestCheckIndexesReportsExtra(c *gc.C) {
+	err := s.relations.EnsureIndex(mgo.Index{Key: []string{"life"}})
+	c.Assert(err, gc.IsNil)
+
+	report, err := s.State.CheckIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Missing, gc.HasLen, 0)
+	c.Assert(report.Extra, gc.DeepEquals, []state.IndexInfo{
+		{Collection: "relations", Key: []string{"life"}},
+	})
+	c.Assert(report.Extra[0].String(), gc.Equals, "relations (life)")
+
+	// Indexes not required by juju are left in place.
+	report, err = s.State.EnsureIndexes()
+	c.Assert(err, gc.IsNil)
+	c.Assert(report.Extra, gc.HasLen, 1)
+	indexes, err := s.relations.Indexes()
+	c.Assert(err, gc.IsNil)
+	var found bool
+	for _, index := range indexes {
+		if len(index.Key) == 1 && index.Key[0] == "life" {
+			found = true
+		}
+	}
+	c.Assert(found, jc.IsTrue)
+}
@@ -115,6 +115,18 @@ var (
 	_ MongoPassworder = (*Unit)(nil)
 )
 
+// PasswordRotator represents agent entities that can be required
+// to change their password the next time they connect.
+type PasswordRotator interface {
+	RequirePasswordRotation() error
+	PasswordRotationRequired() bool
+}
+
+var (
+	_ PasswordRotator = (*Machine)(nil)
+	_ PasswordRotator = (*Unit)(nil)
+)
+
 // Annotator represents entities capable of handling annotations.
 type Annotator interface {
 	Annotation(key string) (string, error)
@@ -188,6 +200,14 @@ type EnvironMachinesWatcher interface {
 
 var _ EnvironMachinesWatcher = (*State)(nil)
 
+// MetricsAdder is implemented by *State. See State.AddMetricBatch
+// for documentation on the method.
+type MetricsAdder interface {
+	AddMetricBatch(entityTag string, metrics []Metric) (*MetricBatch, error)
+}
+
+var _ MetricsAdder = (*State)(nil)
+
 // InstanceIdGetter defines a single method - InstanceId.
 type InstanceIdGetter interface {
 	InstanceId() (instance.Id, error)
@@ -0,0 +1,182 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juju/charm"
+	"labix.org/v2/mgo/bson"
+)
+
+// ListParams controls the paging, ordering and projection of the
+// entities returned by ListMachines, ListUnits and ListServices.
+type ListParams struct {
+	// Marker holds the name of the last entity in the previous
+	// page. If it is not empty, the listing starts after it.
+	Marker string
+
+	// Limit holds the maximum number of entities to return.
+	// If it is zero, all remaining entities are returned.
+	Limit int
+
+	// Descending reverses the order of the listing, which is
+	// otherwise in ascending byte order of name. Note that
+	// machine ids are therefore not in numeric order.
+	Descending bool
+
+	// Fields holds the fields to fill in, besides the name.
+	// If it is empty, all fields are filled in.
+	Fields []string
+}
+
+// MachineSummary holds the fields of a machine returned by ListMachines.
+type MachineSummary struct {
+	Id     string `bson:"_id"`
+	Series string
+	Life   Life
+	Jobs   []MachineJob
+}
+
+var machineSummaryFields = map[string]string{
+	"series": "series",
+	"life":   "life",
+	"jobs":   "jobs",
+}
+
+// ListMachines returns a page of the machines in the environment,
+// and the marker from which to request the next page. The marker
+// is empty if there are no more machines.
+func (st *State) ListMachines(p ListParams) ([]MachineSummary, string, error) {
+	var docs []MachineSummary
+	err := st.list(machinesC, p, machineSummaryFields, &docs)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot list machines: %v", err)
+	}
+	var marker string
+	if p.Limit > 0 && len(docs) > p.Limit {
+		docs = docs[:p.Limit]
+		marker = docs[p.Limit-1].Id
+	}
+	return docs, marker, nil
+}
+
+// UnitSummary holds the fields of a unit returned by ListUnits.
+type UnitSummary struct {
+	Name      string `bson:"_id"`
+	Service   string
+	Series    string
+	MachineId string
+	Life      Life
+}
+
+var unitSummaryFields = map[string]string{
+	"service": "service",
+	"series":  "series",
+	"machine": "machineid",
+	"life":    "life",
+}
+
+// ListUnits returns a page of the units in the environment, and the
+// marker from which to request the next page. The marker is empty
+// if there are no more units.
+func (st *State) ListUnits(p ListParams) ([]UnitSummary, string, error) {
+	var docs []UnitSummary
+	err := st.list(unitsC, p, unitSummaryFields, &docs)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot list units: %v", err)
+	}
+	var marker string
+	if p.Limit > 0 && len(docs) > p.Limit {
+		docs = docs[:p.Limit]
+		marker = docs[p.Limit-1].Name
+	}
+	return docs, marker, nil
+}
+
+// ServiceSummary holds the fields of a service returned by ListServices.
+type ServiceSummary struct {
+	Name     string `bson:"_id"`
+	CharmURL *charm.URL
+	Life     Life
+	Exposed  bool
+}
+
+var serviceSummaryFields = map[string]string{
+	"charm":   "charmurl",
+	"life":    "life",
+	"exposed": "exposed",
+}
+
+// ListServices returns a page of the services in the environment,
+// and the marker from which to request the next page. The marker
+// is empty if there are no more services.
+func (st *State) ListServices(p ListParams) ([]ServiceSummary, string, error) {
+	var docs []ServiceSummary
+	err := st.list(servicesC, p, serviceSummaryFields, &docs)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot list services: %v", err)
+	}
+	var marker string
+	if p.Limit > 0 && len(docs) > p.Limit {
+		docs = docs[:p.Limit]
+		marker = docs[p.Limit-1].Name
+	}
+	return docs, marker, nil
+}
+
+// list reads the documents in the named collection selected by p
+// into result, which must be a pointer to a slice. The fields map
+// holds the document field for each field name that may be requested.
+// When p has a limit, one more document than the limit is read, so
+// the caller can tell whether there is another page.
+func (st *State) list(collection string, p ListParams, fields map[string]string, result interface{}) error {
+	if p.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", p.Limit)
+	}
+	projection, err := listProjection(p.Fields, fields)
+	if err != nil {
+		return err
+	}
+	var sel bson.D
+	order := "_id"
+	if p.Marker != "" {
+		op := "$gt"
+		if p.Descending {
+			op = "$lt"
+		}
+		sel = bson.D{{"_id", bson.D{{op, p.Marker}}}}
+	}
+	if p.Descending {
+		order = "-_id"
+	}
+	coll, closer := st.getCollection(collection)
+	defer closer()
+	query := coll.Find(sel).Sort(order).Select(projection)
+	if p.Limit > 0 {
+		query = query.Limit(p.Limit + 1)
+	}
+	return query.All(result)
+}
+
+// listProjection returns the projection selecting the given field
+// names, or all the fields if there are none.
+func listProjection(names []string, fields map[string]string) (bson.D, error) {
+	if len(names) == 0 {
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	projection := bson.D{{"_id", 1}}
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		projection = append(projection, bson.DocElem{field, 1})
+	}
+	return projection, nil
+}
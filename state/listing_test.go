@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type ListingSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ListingSuite{})
+
+func (s *ListingSuite) addMachines(c *gc.C, n int) {
+	for i := 0; i < n; i++ {
+		_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *ListingSuite) TestListMachinesAll(c *gc.C) {
+	s.addMachines(c, 3)
+	machines, marker, err := s.State.ListMachines(state.ListParams{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(machines, gc.DeepEquals, []state.MachineSummary{
+		{Id: "0", Series: "quantal", Life: state.Alive, Jobs: []state.MachineJob{state.JobHostUnits}},
+		{Id: "1", Series: "quantal", Life: state.Alive, Jobs: []state.MachineJob{state.JobHostUnits}},
+		{Id: "2", Series: "quantal", Life: state.Alive, Jobs: []state.MachineJob{state.JobHostUnits}},
+	})
+}
+
+func (s *ListingSuite) TestListMachinesPages(c *gc.C) {
+	s.addMachines(c, 5)
+	var ids []string
+	p := state.ListParams{Limit: 2, Fields: []string{"series"}}
+	for page := 0; ; page++ {
+		c.Assert(page, gc.Not(gc.Equals), 5)
+		machines, marker, err := s.State.ListMachines(p)
+		c.Assert(err, gc.IsNil)
+		c.Assert(len(machines) <= 2, gc.Equals, true)
+		for _, m := range machines {
+			c.Assert(m.Series, gc.Equals, "quantal")
+			c.Assert(m.Jobs, gc.HasLen, 0)
+			ids = append(ids, m.Id)
+		}
+		if marker == "" {
+			break
+		}
+		p.Marker = marker
+	}
+	c.Assert(ids, gc.DeepEquals, []string{"0", "1", "2", "3", "4"})
+}
+
+func (s *ListingSuite) TestListMachinesDescending(c *gc.C) {
+	s.addMachines(c, 3)
+	p := state.ListParams{Limit: 2, Descending: true, Fields: []string{"jobs"}}
+	machines, marker, err := s.State.ListMachines(p)
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "1")
+	c.Assert(machines, gc.DeepEquals, []state.MachineSummary{
+		{Id: "2", Jobs: []state.MachineJob{state.JobHostUnits}},
+		{Id: "1", Jobs: []state.MachineJob{state.JobHostUnits}},
+	})
+
+	p.Marker = marker
+	machines, marker, err = s.State.ListMachines(p)
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(machines, gc.DeepEquals, []state.MachineSummary{
+		{Id: "0", Jobs: []state.MachineJob{state.JobHostUnits}},
+	})
+}
+
+func (s *ListingSuite) TestListUnits(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	for i := 0; i < 3; i++ {
+		_, err := svc.AddUnit()
+		c.Assert(err, gc.IsNil)
+	}
+	units, marker, err := s.State.ListUnits(state.ListParams{Limit: 3, Fields: []string{"service"}})
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(units, gc.HasLen, 3)
+	for i, u := range units {
+		c.Assert(u, gc.DeepEquals, state.UnitSummary{
+			Name:    fmt.Sprintf("wordpress/%d", i),
+			Service: "wordpress",
+		})
+	}
+}
+
+func (s *ListingSuite) TestListServices(c *gc.C) {
+	ch := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", ch)
+	svc := s.AddTestingService(c, "blog", ch)
+	err := svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+
+	services, marker, err := s.State.ListServices(state.ListParams{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(marker, gc.Equals, "")
+	c.Assert(services, gc.HasLen, 2)
+	c.Assert(services[0].Name, gc.Equals, "blog")
+	c.Assert(services[0].Exposed, gc.Equals, true)
+	c.Assert(services[0].CharmURL, gc.DeepEquals, ch.URL())
+	c.Assert(services[1].Name, gc.Equals, "wordpress")
+	c.Assert(services[1].Exposed, gc.Equals, false)
+}
+
+func (s *ListingSuite) TestListErrors(c *gc.C) {
+	_, _, err := s.State.ListMachines(state.ListParams{Limit: -1})
+	c.Assert(err, gc.ErrorMatches, "cannot list machines: invalid limit -1")
+	_, _, err = s.State.ListUnits(state.ListParams{Fields: []string{"charm"}})
+	c.Assert(err, gc.ErrorMatches, `cannot list units: unknown field "charm"`)
+}
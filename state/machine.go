@@ -46,11 +46,16 @@ const (
 
 	// Deprecated in 1.18.
 	JobManageStateDeprecated
+
+	// JobManageAPI runs an API server that connects to the
+	// state servers' mongo, without running mongo itself.
+	JobManageAPI
 )
 
 var jobNames = map[MachineJob]params.MachineJob{
 	JobHostUnits:     params.JobHostUnits,
 	JobManageEnviron: params.JobManageEnviron,
+	JobManageAPI:     params.JobManageAPI,
 
 	// Deprecated in 1.18.
 	JobManageStateDeprecated: params.JobManageStateDeprecated,
@@ -58,7 +63,7 @@ var jobNames = map[MachineJob]params.MachineJob{
 
 // AllJobs returns all supported machine jobs.
 func AllJobs() []MachineJob {
-	return []MachineJob{JobHostUnits, JobManageEnviron}
+	return []MachineJob{JobHostUnits, JobManageEnviron, JobManageAPI}
 }
 
 // ToParams returns the job as params.MachineJob.
@@ -106,7 +111,15 @@ type machineDoc struct {
 	NoVote        bool
 	HasVote       bool
 	PasswordHash  string
+	PasswordSalt  string `bson:",omitempty"`
 	Clean         bool
+	// PasswordRotationRequired records that the machine agent
+	// must change its password the next time it connects.
+	PasswordRotationRequired bool
+	// Demoting records that JobManageEnviron is being removed from
+	// the machine; the job is removed once the machine's vote has
+	// been removed from the replica set.
+	Demoting bool `bson:",omitempty"`
 	// We store 2 different sets of addresses for the machine, obtained
 	// from different sources.
 	// Addresses is the set of addresses obtained by asking the provider.
@@ -120,6 +133,10 @@ type machineDoc struct {
 	// Placement is the placement directive that should be used when provisioning
 	// an instance for the machine.
 	Placement string `bson:",omitempty"`
+	// UpgradeSeriesStatus records the progress of an in-place upgrade
+	// of the machine's OS to UpgradeSeriesTarget.
+	UpgradeSeriesStatus params.UpgradeSeriesStatus `bson:",omitempty"`
+	UpgradeSeriesTarget string                     `bson:",omitempty"`
 	// Deprecated. InstanceId, now lives on instanceData.
 	// This attribute is retained so that data from existing machines can be read.
 	// SCHEMACHANGE
@@ -246,17 +263,46 @@ func (m *Machine) HasVote() bool {
 // SetHasVote sets whether the machine is currently a voting
 // member of the replica set. It should only be called
 // from the worker that maintains the replica set.
+// If the machine is being demoted and loses its vote, its
+// JobManageEnviron is removed.
 func (m *Machine) SetHasVote(hasVote bool) error {
-	ops := []txn.Op{{
-		C:      machinesC,
-		Id:     m.doc.Id,
-		Assert: notDeadDoc,
-		Update: bson.D{{"$set", bson.D{{"hasvote", hasVote}}}},
-	}}
-	if err := m.st.runTransaction(ops); err != nil {
-		return fmt.Errorf("cannot set HasVote of machine %v: %v", m, onAbort(err, errDead))
+	var demoted bool
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt != 0 {
+			if err := m.Refresh(); errors.IsNotFound(err) {
+				return nil, errDead
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life == Dead {
+			return nil, errDead
+		}
+		demoted = !hasVote && m.doc.Demoting
+		if demoted {
+			return removeManageEnvironOps(m, bson.D{
+				{"jobs", JobManageEnviron},
+				{"demoting", true},
+			}), nil
+		}
+		assert := notDeadDoc
+		if !hasVote {
+			assert = append(bson.D{{"demoting", bson.D{{"$ne", true}}}}, assert...)
+		}
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: assert,
+			Update: bson.D{{"$set", bson.D{{"hasvote", hasVote}}}},
+		}}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return fmt.Errorf("cannot set HasVote of machine %v: %v", m, err)
 	}
 	m.doc.HasVote = hasVote
+	if demoted {
+		m.removeJobFromDoc(JobManageEnviron)
+	}
 	return nil
 }
 
@@ -265,6 +311,259 @@ func (m *Machine) IsManager() bool {
 	return hasJob(m.doc.Jobs, JobManageEnviron)
 }
 
+// exclusiveJobs maps each job to a job that a machine
+// may not have at the same time.
+var exclusiveJobs = map[MachineJob]MachineJob{
+	JobManageEnviron: JobManageAPI,
+	JobManageAPI:     JobManageEnviron,
+}
+
+// AddJob adds the given job to the machine's responsibilities, so
+// that the machine agent starts the workers needed to fulfil it. A
+// machine given JobManageEnviron becomes a non-voting state server,
+// which EnsureAvailability may then promote, so that the number of
+// voting state servers stays odd. A machine cannot have both
+// JobManageEnviron and JobManageAPI. It is not an error to add a job
+// that the machine already has.
+func (m *Machine) AddJob(job MachineJob) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt != 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		if hasJob(m.doc.Jobs, job) {
+			return nil, jujutxn.ErrNoOperations
+		}
+		absent := []MachineJob{job}
+		if other, ok := exclusiveJobs[job]; ok {
+			if hasJob(m.doc.Jobs, other) {
+				return nil, errManageAPIWithManageEnviron
+			}
+			absent = append(absent, other)
+		}
+		op := txn.Op{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: append(bson.D{{"jobs", bson.D{{"$nin", absent}}}}, isAliveDoc...),
+			Update: bson.D{{"$addToSet", bson.D{{"jobs", job}}}},
+		}
+		switch job {
+		case JobManageEnviron:
+			currentInfo, err := m.st.StateServerInfo()
+			if err != nil {
+				return nil, err
+			}
+			mdoc := m.doc
+			mdoc.Jobs = append(append([]MachineJob{}, m.doc.Jobs...), job)
+			mdoc.NoVote = true
+			ssOps, err := m.st.maintainStateServersOps([]*machineDoc{&mdoc}, currentInfo)
+			if err != nil {
+				return nil, err
+			}
+			op.Update = append(op.Update, bson.DocElem{"$set", bson.D{{"novote", true}}})
+			return append([]txn.Op{op}, ssOps...), nil
+		case JobManageAPI:
+			return []txn.Op{op, addAPIMachineOp(m.doc.Id)}, nil
+		}
+		return []txn.Op{op}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return errors.Annotatef(err, "cannot add job %q to machine %v", job, m)
+	}
+	if !hasJob(m.doc.Jobs, job) {
+		m.doc.Jobs = append(m.doc.Jobs, job)
+		if job == JobManageEnviron {
+			m.doc.NoVote = true
+		}
+	}
+	return nil
+}
+
+// RemoveJob removes the given job from the machine's responsibilities,
+// so that the machine agent stops the workers that fulfil it.
+// JobHostUnits can only be removed from a machine with no units
+// assigned. A machine that has a vote in the replica set is demoted
+// rather than losing JobManageEnviron immediately; the job is removed
+// once the machine's vote has been removed. It is not an error to
+// remove a job that the machine does not have.
+func (m *Machine) RemoveJob(job MachineJob) error {
+	var demoting bool
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt != 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		demoting = false
+		if !hasJob(m.doc.Jobs, job) || (job == JobManageEnviron && m.doc.Demoting) {
+			return nil, jujutxn.ErrNoOperations
+		}
+		if len(m.doc.Jobs) == 1 {
+			return nil, fmt.Errorf("machine must have at least one job")
+		}
+		op := txn.Op{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: bson.D{{"jobs", bson.D{{"$size", len(m.doc.Jobs)}, {"$all", []MachineJob{job}}}}},
+			Update: bson.D{{"$pull", bson.D{{"jobs", job}}}},
+		}
+		switch job {
+		case JobManageEnviron:
+			info, err := m.st.StateServerInfo()
+			if err != nil {
+				return nil, err
+			}
+			if len(info.MachineIds) <= 1 {
+				return nil, fmt.Errorf("machine %s is the only state server", m.doc.Id)
+			}
+			var promoteOps []txn.Op
+			if m.WantsVote() {
+				if promoteOps, err = m.replacementVoterOps(info); err != nil {
+					return nil, err
+				}
+			}
+			// The checks above hold only while the state servers are
+			// unchanged.
+			ssAssert := bson.D{
+				{"machineids", bson.D{{"$size", len(info.MachineIds)}}},
+				{"votingmachineids", bson.D{{"$size", len(info.VotingMachineIds)}}},
+			}
+			if !m.doc.HasVote {
+				ops := removeManageEnvironOps(m, append(op.Assert, bson.DocElem{"hasvote", false}))
+				ops[1].Assert = ssAssert
+				return append(ops, promoteOps...), nil
+			}
+			// Demote the machine as EnsureAvailability demotes
+			// unavailable ones, and let the peer grouper remove its
+			// vote; SetHasVote then removes the job.
+			demoting = true
+			return append([]txn.Op{{
+				C:  machinesC,
+				Id: m.doc.Id,
+				Assert: bson.D{
+					{"jobs", bson.D{{"$size", len(m.doc.Jobs)}, {"$all", []MachineJob{job}}}},
+					{"hasvote", true},
+				},
+				Update: bson.D{{"$set", bson.D{{"novote", true}, {"demoting", true}}}},
+			}, {
+				C:      stateServersC,
+				Id:     environGlobalKey,
+				Assert: ssAssert,
+				Update: bson.D{{"$pull", bson.D{{"votingmachineids", m.doc.Id}}}},
+			}}, promoteOps...), nil
+		case JobManageAPI:
+			return []txn.Op{op, removeAPIMachineOp(m.doc.Id)}, nil
+		case JobHostUnits:
+			if len(m.doc.Principals) != 0 {
+				return nil, &HasAssignedUnitsError{
+					MachineId: m.doc.Id,
+					UnitNames: m.doc.Principals,
+				}
+			}
+			op.Assert = append(op.Assert, bson.DocElem{"$or", []bson.D{
+				{{"principals", bson.D{{"$size", 0}}}},
+				{{"principals", bson.D{{"$exists", false}}}},
+			}})
+		}
+		return []txn.Op{op}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		if IsHasAssignedUnitsError(err) {
+			return err
+		}
+		return errors.Annotatef(err, "cannot remove job %q from machine %v", job, m)
+	}
+	if demoting {
+		m.doc.NoVote = true
+		m.doc.Demoting = true
+	} else {
+		m.removeJobFromDoc(job)
+	}
+	return nil
+}
+
+// removeJobFromDoc removes the given job from the machine's
+// document once the removal has been committed.
+func (m *Machine) removeJobFromDoc(job MachineJob) {
+	jobs := make([]MachineJob, 0, len(m.doc.Jobs))
+	for _, j := range m.doc.Jobs {
+		if j != job {
+			jobs = append(jobs, j)
+		}
+	}
+	m.doc.Jobs = jobs
+	if job == JobManageEnviron {
+		m.doc.NoVote = false
+		m.doc.HasVote = false
+		m.doc.Demoting = false
+	}
+}
+
+// replacementVoterOps returns the operations needed to keep the
+// number of voting state servers odd and non-zero once m no longer
+// wants a vote. If that needs another state server to be promoted and
+// none is available, it returns an error.
+func (m *Machine) replacementVoterOps(info *StateServerInfo) ([]txn.Op, error) {
+	voters := len(info.VotingMachineIds) - 1
+	if voters%2 == 1 {
+		return nil, nil
+	}
+	voting := set.NewStrings(info.VotingMachineIds...)
+	for _, id := range info.MachineIds {
+		if id == m.doc.Id || voting.Contains(id) {
+			continue
+		}
+		candidate, err := m.st.Machine(id)
+		if err != nil {
+			return nil, err
+		}
+		if candidate.doc.Demoting || candidate.Life() != Alive {
+			continue
+		}
+		available, err := stateServerAvailable(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			continue
+		}
+		ops := promoteStateServerOps(candidate)
+		ops[0].Assert = append(ops[0].Assert, bson.DocElem{"demoting", bson.D{{"$ne", true}}})
+		return ops, nil
+	}
+	if voters == 0 {
+		return nil, fmt.Errorf("machine %s is the only voting state server", m.doc.Id)
+	}
+	return nil, fmt.Errorf("removing the vote of machine %s would leave %d voting state servers and no state server is available to replace it", m.doc.Id, voters)
+}
+
+// removeManageEnvironOps returns the operations that remove
+// JobManageEnviron from a machine without a vote in the replica set,
+// asserting the given conditions on the machine document.
+func removeManageEnvironOps(m *Machine, assert bson.D) []txn.Op {
+	return []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: assert,
+		Update: bson.D{
+			{"$pull", bson.D{{"jobs", JobManageEnviron}}},
+			{"$set", bson.D{{"novote", false}, {"hasvote", false}}},
+			{"$unset", bson.D{{"demoting", nil}}},
+		},
+	}, {
+		C:  stateServersC,
+		Id: environGlobalKey,
+		Update: bson.D{{"$pull", bson.D{
+			{"machineids", m.doc.Id},
+			{"votingmachineids", m.doc.Id},
+		}}},
+	}}
+}
+
 // IsManual returns true if the machine was manually provisioned.
 func (m *Machine) IsManual() (bool, error) {
 	// Apart from the bootstrap machine, manually provisioned
@@ -340,23 +639,77 @@ func (m *Machine) SetPassword(password string) error {
 	if len(password) < utils.MinAgentPasswordLength {
 		return fmt.Errorf("password is only %d bytes long, and is not a valid Agent password", len(password))
 	}
-	return m.setPasswordHash(utils.AgentPasswordHash(password))
+	hash, salt, err := newAgentPasswordHash(password)
+	if err != nil {
+		return err
+	}
+	// Any required rotation is satisfied in the same transaction
+	// that changes the password, so the two cannot disagree.
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{
+			{"passwordhash", hash},
+			{"passwordsalt", salt},
+			{"passwordrotationrequired", false},
+		}}},
+	}}
+	if err := m.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot set password of machine %v: %v", m, onAbort(err, errDead))
+	}
+	m.doc.PasswordHash = hash
+	m.doc.PasswordSalt = salt
+	m.doc.PasswordRotationRequired = false
+	return nil
+}
+
+// RequirePasswordRotation records that the machine agent must change
+// its password the next time it connects.
+func (m *Machine) RequirePasswordRotation() error {
+	ops := []txn.Op{{
+		C:      machinesC,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{{"passwordrotationrequired", true}}}},
+	}}
+	if err := m.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot require password rotation of machine %v: %v", m, onAbort(err, errDead))
+	}
+	m.doc.PasswordRotationRequired = true
+	return nil
+}
+
+// PasswordRotationRequired returns whether the machine agent must
+// change its password the next time it connects.
+func (m *Machine) PasswordRotationRequired() bool {
+	return m.doc.PasswordRotationRequired
 }
 
 // setPasswordHash sets the underlying password hash in the database directly
-// to the value supplied. This is split out from SetPassword to allow direct
-// manipulation in tests (to check for backwards compatibility).
+// to the value supplied, without a salt. This is split out from SetPassword
+// to allow direct manipulation in tests (to check for backwards compatibility).
 func (m *Machine) setPasswordHash(passwordHash string) error {
+	return m.setPasswordHashAndSalt(passwordHash, "")
+}
+
+// setPasswordHashAndSalt sets the password hash and the salt
+// it was made with in the database.
+func (m *Machine) setPasswordHashAndSalt(passwordHash, passwordSalt string) error {
 	ops := []txn.Op{{
 		C:      machinesC,
 		Id:     m.doc.Id,
 		Assert: notDeadDoc,
-		Update: bson.D{{"$set", bson.D{{"passwordhash", passwordHash}}}},
+		Update: bson.D{{"$set", bson.D{
+			{"passwordhash", passwordHash},
+			{"passwordsalt", passwordSalt},
+		}}},
 	}}
 	if err := m.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set password of machine %v: %v", m, onAbort(err, errDead))
 	}
 	m.doc.PasswordHash = passwordHash
+	m.doc.PasswordSalt = passwordSalt
 	return nil
 }
 
@@ -370,22 +723,17 @@ func (m *Machine) getPasswordHash() string {
 // PasswordValid returns whether the given password is valid
 // for the given machine.
 func (m *Machine) PasswordValid(password string) bool {
-	agentHash := utils.AgentPasswordHash(password)
-	if agentHash == m.doc.PasswordHash {
-		return true
-	}
-	// In Juju 1.16 and older we used the slower password hash for unit
-	// agents. So check to see if the supplied password matches the old
-	// path, and if so, update it to the new mechanism.
-	// We ignore any error in setting the password, as we'll just try again
-	// next time
-	if utils.UserPasswordHash(password, utils.CompatSalt) == m.doc.PasswordHash {
-		logger.Debugf("%s logged in with old password hash, changing to AgentPasswordHash",
-			m.Tag())
-		m.setPasswordHash(agentHash)
-		return true
+	valid, upgrade := agentPasswordValid(password, m.doc.PasswordHash, m.doc.PasswordSalt)
+	if upgrade {
+		// The password hash was stored by an earlier version without
+		// a salt, so replace it with a salted one. We ignore any error
+		// in doing so, as we'll just try again next time.
+		logger.Debugf("%s logged in with unsalted password hash, changing to salted hash", m.Tag())
+		if hash, salt, err := newAgentPasswordHash(password); err == nil {
+			m.setPasswordHashAndSalt(hash, salt)
+		}
 	}
-	return false
+	return valid
 }
 
 // Destroy sets the machine lifecycle to Dying if it is Alive. It does
@@ -398,19 +746,49 @@ func (m *Machine) Destroy() error {
 }
 
 // ForceDestroy queues the machine for complete removal, including the
-// destruction of all units and containers on the machine.
+// destruction of all units and containers on the machine. It does not
+// depend on the machine agent: the machine is set to Dying at once, so
+// that nothing new can be placed on it, and the queued cleanup marks
+// its units and containers dead and removes them before setting the
+// machine to Dead, after which the provisioner releases its instance
+// and removes it. ForceDestroy fails if the machine has
+// JobManageEnviron or is a voting member of the replica set.
 func (m *Machine) ForceDestroy() error {
-	if !m.IsManager() {
-		ops := []txn.Op{{
-			C:      machinesC,
-			Id:     m.doc.Id,
-			Assert: bson.D{{"jobs", bson.D{{"$nin", []MachineJob{JobManageEnviron}}}}},
-		}, m.st.newCleanupOp(cleanupForceDestroyedMachine, m.doc.Id)}
-		if err := m.st.runTransaction(ops); err != txn.ErrAborted {
-			return err
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt != 0 {
+			if err := m.Refresh(); errors.IsNotFound(err) {
+				return nil, jujutxn.ErrNoOperations
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		if hasJob(m.doc.Jobs, JobManageEnviron) {
+			return nil, fmt.Errorf("machine %s is required by the environment", m.doc.Id)
+		}
+		if m.doc.HasVote {
+			return nil, fmt.Errorf("machine %s is a voting replica set member", m.doc.Id)
+		}
+		op := txn.Op{
+			C:  machinesC,
+			Id: m.doc.Id,
+			Assert: bson.D{
+				{"jobs", bson.D{{"$nin", []MachineJob{JobManageEnviron}}}},
+				{"hasvote", bson.D{{"$ne", true}}},
+			},
+		}
+		if m.doc.Life == Alive {
+			op.Assert = append(op.Assert, isAliveDoc...)
+			op.Update = bson.D{{"$set", bson.D{{"life", Dying}}}}
 		}
+		return []txn.Op{op, m.st.newCleanupOp(cleanupForceDestroyedMachine, m.doc.Id)}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	if m.doc.Life == Alive {
+		m.doc.Life = Dying
 	}
-	return fmt.Errorf("machine %s is required by the environment", m.doc.Id)
+	return nil
 }
 
 // EnsureDead sets the machine lifecycle to Dead if it is Alive or Dying.
@@ -654,6 +1032,9 @@ func (m *Machine) Remove() (err error) {
 	ops = append(ops, ifacesOps...)
 	ops = append(ops, portsOps...)
 	ops = append(ops, removeContainerRefOps(m.st, m.Id())...)
+	if hasJob(m.doc.Jobs, JobManageAPI) {
+		ops = append(ops, removeAPIMachineOp(m.doc.Id))
+	}
 	// The only abort conditions in play indicate that the machine has already
 	// been removed.
 	return onAbort(m.st.runTransaction(ops), nil)
@@ -684,6 +1065,12 @@ func (m *Machine) AgentPresence() (bool, error) {
 	return b, err
 }
 
+// AgentLastSeen returns when the respective remote agent was last
+// known to be alive, or the zero time if that is not known.
+func (m *Machine) AgentLastSeen() (time.Time, error) {
+	return m.st.pwatcher.LastSeen(m.globalKey())
+}
+
 // WaitAgentPresence blocks until the respective agent is alive.
 func (m *Machine) WaitAgentPresence(timeout time.Duration) (err error) {
 	defer errors.Maskf(&err, "waiting for agent of machine %v", m)
@@ -1176,6 +1563,46 @@ func (m *Machine) Placement() string {
 	return m.doc.Placement
 }
 
+// SetPlacement sets the placement directive to use when provisioning an
+// instance for the machine. It will fail if the machine is not Alive, or
+// if it is already provisioned.
+func (m *Machine) SetPlacement(placement string) (err error) {
+	defer errors.Maskf(&err, "cannot set placement")
+	cons, err := m.Constraints()
+	if err != nil {
+		return err
+	}
+	if err := m.st.precheckInstance(m.doc.Series, cons, placement); err != nil {
+		return err
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		if _, err := m.InstanceId(); err == nil {
+			return nil, fmt.Errorf("machine is already provisioned")
+		} else if !IsNotProvisionedError(err) {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: append(isAliveDoc, bson.DocElem{"nonce", ""}),
+			Update: bson.D{{"$set", bson.D{{"placement", placement}}}},
+		}}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	m.doc.Placement = placement
+	return nil
+}
+
 // Constraints returns the exact constraints that should apply when provisioning
 // an instance for the machine.
 func (m *Machine) Constraints() (constraints.Value, error) {
@@ -1187,7 +1614,7 @@ func (m *Machine) Constraints() (constraints.Value, error) {
 // is already provisioned.
 func (m *Machine) SetConstraints(cons constraints.Value) (err error) {
 	defer errors.Maskf(&err, "cannot set constraints")
-	unsupported, err := m.st.validateConstraints(cons)
+	unsupported, err := m.st.ValidateConstraints(cons)
 	if len(unsupported) > 0 {
 		logger.Warningf(
 			"setting constraints on machine %q: unsupported constraints: %v", m.Id(), strings.Join(unsupported, ","))
@@ -84,6 +84,225 @@ func (s *MachineSuite) TestMachineIsManager(c *gc.C) {
 	c.Assert(s.machine.IsManager(), jc.IsFalse)
 }
 
+func (s *MachineSuite) TestAddJob(c *gc.C) {
+	err := s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits, state.JobManageEnviron})
+	c.Assert(s.machine.WantsVote(), jc.IsFalse)
+
+	// Adding a job the machine already has does nothing.
+	err = s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	// The machine joins the state servers without a vote,
+	// so that the number of voters stays odd.
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits, state.JobManageEnviron})
+	c.Assert(s.machine.WantsVote(), jc.IsFalse)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.DeepEquals, []string{"0", "1"})
+	c.Assert(info.VotingMachineIds, gc.DeepEquals, []string{"0"})
+}
+
+func (s *MachineSuite) TestAddJobManageEnvironPromotedByEnsureAvailability(c *gc.C) {
+	s.PatchValue(state.StateServerAvailable, func(m *state.Machine) (bool, error) {
+		return true, nil
+	})
+	err := s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	changes, err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	c.Assert(changes.Promoted, gc.DeepEquals, []string{"1"})
+	c.Assert(changes.Added, gc.HasLen, 1)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.VotingMachineIds, gc.HasLen, 3)
+}
+
+func (s *MachineSuite) TestAddJobNotAlive(c *gc.C) {
+	err := s.machine.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.ErrorMatches, `cannot add job "JobManageEnviron" to machine 1: not found or not alive`)
+}
+
+func (s *MachineSuite) TestRemoveJobManageEnviron(c *gc.C) {
+	err := s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	// A machine without a vote loses the job immediately.
+	err = s.machine.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits})
+
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits})
+	c.Assert(s.machine.IsManager(), jc.IsFalse)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.DeepEquals, []string{"0"})
+
+	// Removing a job the machine does not have does nothing.
+	err = s.machine.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *MachineSuite) TestRemoveJobManageEnvironDemotes(c *gc.C) {
+	s.PatchValue(state.StateServerAvailable, func(m *state.Machine) (bool, error) {
+		return true, nil
+	})
+	err := s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.WantsVote(), jc.IsTrue)
+	err = s.machine.SetHasVote(true)
+	c.Assert(err, gc.IsNil)
+	spare, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = spare.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	// A voting machine is demoted, and keeps the job until the
+	// peer grouper has removed its vote; the spare state server
+	// is promoted in its place.
+	err = s.machine.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.WantsVote(), jc.IsFalse)
+	c.Assert(s.machine.IsManager(), jc.IsTrue)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.HasLen, 4)
+	c.Assert(info.VotingMachineIds, gc.HasLen, 3)
+	c.Assert(info.VotingMachineIds, jc.SameContents, []string{"0", "2", spare.Id()})
+
+	// EnsureAvailability does not promote it again.
+	changes, err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	c.Assert(changes.Promoted, gc.HasLen, 0)
+	c.Assert(changes.Added, gc.HasLen, 0)
+
+	m, err := s.State.Machine(s.machine.Id())
+	c.Assert(err, gc.IsNil)
+	err = m.SetHasVote(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.IsManager(), jc.IsFalse)
+	err = s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits})
+	c.Assert(s.machine.HasVote(), jc.IsFalse)
+	info, err = s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.HasLen, 3)
+	for _, id := range info.MachineIds {
+		c.Assert(id, gc.Not(gc.Equals), s.machine.Id())
+	}
+}
+
+func (s *MachineSuite) TestRemoveJobManageEnvironLastStateServer(c *gc.C) {
+	err := s.machine0.AddJob(state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.machine0.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.ErrorMatches, `cannot remove job "JobManageEnviron" from machine 0: machine 0 is the only state server`)
+	c.Assert(s.machine0.IsManager(), jc.IsTrue)
+}
+
+func (s *MachineSuite) TestRemoveJobManageEnvironLastVoter(c *gc.C) {
+	err := s.machine0.AddJob(state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+
+	// The other state server is not available to take the vote.
+	err = s.machine0.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.ErrorMatches, `cannot remove job "JobManageEnviron" from machine 0: machine 0 is the only voting state server`)
+	c.Assert(s.machine0.IsManager(), jc.IsTrue)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.VotingMachineIds, gc.DeepEquals, []string{"0"})
+
+	// Once it is available, it is promoted in place of machine 0.
+	s.PatchValue(state.StateServerAvailable, func(m *state.Machine) (bool, error) {
+		return true, nil
+	})
+	err = s.machine0.RemoveJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine0.IsManager(), jc.IsFalse)
+	info, err = s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.DeepEquals, []string{"1"})
+	c.Assert(info.VotingMachineIds, gc.DeepEquals, []string{"1"})
+}
+
+func (s *MachineSuite) TestRemoveJobHostUnits(c *gc.C) {
+	err := s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	unit, err := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress")).AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.RemoveJob(state.JobHostUnits)
+	c.Assert(err, jc.Satisfies, state.IsHasAssignedUnitsError)
+
+	err = unit.UnassignFromMachine()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.RemoveJob(state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobManageEnviron})
+}
+
+func (s *MachineSuite) TestAddRemoveJobManageAPI(c *gc.C) {
+	err := s.machine0.AddJob(state.JobManageAPI)
+	c.Assert(err, gc.ErrorMatches, `cannot add job "JobManageAPI" to machine 0: JobManageAPI and JobManageEnviron jobs cannot be combined`)
+
+	err = s.machine.AddJob(state.JobManageAPI)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits, state.JobManageAPI})
+	c.Assert(s.machine.WantsVote(), jc.IsFalse)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.MachineIds, gc.DeepEquals, []string{"0"})
+	c.Assert(info.APIMachineIds, gc.DeepEquals, []string{"1"})
+
+	err = s.machine.AddJob(state.JobManageEnviron)
+	c.Assert(err, gc.ErrorMatches, `cannot add job "JobManageEnviron" to machine 1: JobManageAPI and JobManageEnviron jobs cannot be combined`)
+
+	err = s.machine.RemoveJob(state.JobManageAPI)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits})
+	info, err = s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APIMachineIds, gc.HasLen, 0)
+}
+
+func (s *MachineSuite) TestRemoveAPIServerMachine(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobManageAPI)
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APIMachineIds, gc.DeepEquals, []string{m.Id()})
+
+	err = m.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = m.Remove()
+	c.Assert(err, gc.IsNil)
+	info, err = s.State.StateServerInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.APIMachineIds, gc.HasLen, 0)
+}
+
+func (s *MachineSuite) TestRemoveLastJob(c *gc.C) {
+	err := s.machine.RemoveJob(state.JobHostUnits)
+	c.Assert(err, gc.ErrorMatches, `cannot remove job "JobHostUnits" from machine 1: machine must have at least one job`)
+}
+
 func (s *MachineSuite) TestMachineIsManualBootstrap(c *gc.C) {
 	cfg, err := s.State.EnvironConfig()
 	c.Assert(err, gc.IsNil)
@@ -339,6 +558,21 @@ func (s *MachineSuite) TestMachineSetAgentPresence(c *gc.C) {
 	c.Assert(alive, gc.Equals, true)
 }
 
+func (s *MachineSuite) TestMachineAgentLastSeen(c *gc.C) {
+	lastSeen, err := s.machine.AgentLastSeen()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.IsZero(), gc.Equals, true)
+
+	pinger, err := s.machine.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+
+	s.State.StartSync()
+	lastSeen, err = s.machine.AgentLastSeen()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.IsZero(), gc.Equals, false)
+}
+
 func (s *MachineSuite) TestTag(c *gc.C) {
 	c.Assert(s.machine.Tag().String(), gc.Equals, "machine-1")
 }
@@ -355,6 +589,18 @@ func (s *MachineSuite) TestSetPassword(c *gc.C) {
 	})
 }
 
+func (s *MachineSuite) TestPasswordSalted(c *gc.C) {
+	testAgentPasswordSalted(c, func() (state.Authenticator, error) {
+		return s.State.Machine(s.machine.Id())
+	})
+}
+
+func (s *MachineSuite) TestPasswordRotation(c *gc.C) {
+	testPasswordRotation(c, func() (passwordRotator, error) {
+		return s.State.Machine(s.machine.Id())
+	})
+}
+
 func (s *MachineSuite) TestSetAgentCompatPassword(c *gc.C) {
 	e, err := s.State.Machine(s.machine.Id())
 	c.Assert(err, gc.IsNil)
@@ -1279,6 +1525,28 @@ func (s *MachineSuite) TestSetConstraints(c *gc.C) {
 	c.Assert(mcons, gc.DeepEquals, cons1)
 }
 
+func (s *MachineSuite) TestSetPlacement(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	// Placement can be set...
+	err = machine.SetPlacement("zone=a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Placement(), gc.Equals, "zone=a")
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Placement(), gc.Equals, "zone=a")
+
+	// ...until the machine is provisioned.
+	err = machine.SetProvisioned("i-mstuck", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetPlacement("zone=b")
+	c.Assert(err, gc.ErrorMatches, "cannot set placement: machine is already provisioned")
+	err = machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Placement(), gc.Equals, "zone=a")
+}
+
 func (s *MachineSuite) TestSetAmbiguousConstraints(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"labix.org/v2/mgo"
 
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/state/presence"
 	"github.com/juju/juju/state/watcher"
 )
 
@@ -22,6 +25,11 @@ type allWatcherStateBacking struct {
 	st *State
 	// collections
 	collectionByName map[string]allWatcherStateCollection
+
+	mu sync.Mutex
+	// presence holds the forwarders of agent presence changes
+	// to each channel passed to Watch.
+	presence map[chan<- watcher.Change]*presenceForwarder
 }
 
 type backingMachine machineDoc
@@ -47,21 +55,29 @@ func (m *backingMachine) updated(st *State, store *multiwatcher.Store, id interf
 		}
 		info.Status = sdoc.Status
 		info.StatusInfo = sdoc.StatusInfo
+		info.AgentAlive, info.AgentLastSeen, err = agentPresence(st, machineGlobalKey(m.Id))
+		if err != nil {
+			return err
+		}
 	} else {
-		// The entry already exists, so preserve the current status and
-		// instance data.
+		// The entry already exists, so preserve the current status,
+		// agent presence and instance data.
 		oldInfo := oldInfo.(*params.MachineInfo)
 		info.Status = oldInfo.Status
 		info.StatusInfo = oldInfo.StatusInfo
+		info.AgentAlive = oldInfo.AgentAlive
+		info.AgentLastSeen = oldInfo.AgentLastSeen
 		info.InstanceId = oldInfo.InstanceId
+		info.InstanceStatus = oldInfo.InstanceStatus
 		info.HardwareCharacteristics = oldInfo.HardwareCharacteristics
 	}
 	// If the machine is been provisioned, fetch the instance id as required,
-	// and set instance id and hardware characteristics.
+	// and set instance id, instance status and hardware characteristics.
 	if m.Nonce != "" && info.InstanceId == "" {
 		instanceData, err := getInstanceData(st, m.Id)
 		if err == nil {
 			info.InstanceId = string(instanceData.InstanceId)
+			info.InstanceStatus = instanceData.Status
 			info.HardwareCharacteristics = hardwareCharacteristics(instanceData)
 		} else if !errors.IsNotFound(err) {
 			return err
@@ -87,11 +103,13 @@ type backingUnit unitDoc
 
 func (u *backingUnit) updated(st *State, store *multiwatcher.Store, id interface{}) error {
 	info := &params.UnitInfo{
-		Name:      u.Name,
-		Service:   u.Service,
-		Series:    u.Series,
-		MachineId: u.MachineId,
-		Ports:     u.Ports,
+		Name:               u.Name,
+		Service:            u.Service,
+		Series:             u.Series,
+		MachineId:          u.MachineId,
+		Ports:              u.Ports,
+		WorkloadStatus:     u.WorkloadStatus,
+		WorkloadStatusInfo: u.WorkloadStatusInfo,
 	}
 	if u.CharmURL != nil {
 		info.CharmURL = u.CharmURL.String()
@@ -106,11 +124,18 @@ func (u *backingUnit) updated(st *State, store *multiwatcher.Store, id interface
 		}
 		info.Status = sdoc.Status
 		info.StatusInfo = sdoc.StatusInfo
+		info.AgentAlive, info.AgentLastSeen, err = agentPresence(st, unitGlobalKey(u.Name))
+		if err != nil {
+			return err
+		}
 	} else {
-		// The entry already exists, so preserve the current status.
+		// The entry already exists, so preserve the current status
+		// and agent presence.
 		oldInfo := oldInfo.(*params.UnitInfo)
 		info.Status = oldInfo.Status
 		info.StatusInfo = oldInfo.StatusInfo
+		info.AgentAlive = oldInfo.AgentAlive
+		info.AgentLastSeen = oldInfo.AgentLastSeen
 	}
 	publicAddress, privateAddress, err := getUnitAddresses(st, u.Name)
 	if err != nil {
@@ -315,6 +340,112 @@ func (a *backingStatus) mongoId() interface{} {
 	panic("cannot find mongo id from status document")
 }
 
+// agentPresence returns whether the agent with the given presence key
+// is alive and, if it is not, when it was last seen alive.
+func agentPresence(st *State, key string) (alive bool, lastSeen time.Time, err error) {
+	alive, err = st.pwatcher.Alive(key)
+	if err != nil || alive {
+		return alive, time.Time{}, err
+	}
+	lastSeen, err = st.pwatcher.LastSeen(key)
+	return false, lastSeen, err
+}
+
+// presenceChanged updates the agent presence of the machine or unit
+// with the given presence key.
+func presenceChanged(st *State, store *multiwatcher.Store, key string) error {
+	id, ok := backingEntityIdForGlobalKey(key)
+	if !ok {
+		return nil
+	}
+	info0 := store.Get(id)
+	if info0 == nil {
+		// The entity isn't known yet; its agent presence is
+		// fetched when it is.
+		return nil
+	}
+	alive, lastSeen, err := agentPresence(st, key)
+	if err != nil {
+		return err
+	}
+	switch info := info0.(type) {
+	case *params.MachineInfo:
+		newInfo := *info
+		newInfo.AgentAlive = alive
+		newInfo.AgentLastSeen = lastSeen
+		info0 = &newInfo
+	case *params.UnitInfo:
+		newInfo := *info
+		newInfo.AgentAlive = alive
+		newInfo.AgentLastSeen = lastSeen
+		info0 = &newInfo
+	default:
+		return nil
+	}
+	store.Update(info0)
+	return nil
+}
+
+// presenceForwarder sends a change for the presenceC collection onto
+// a channel whenever the presence of an agent changes, with the agent's
+// presence key as its id.
+type presenceForwarder struct {
+	changes chan presence.Change
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (f *presenceForwarder) loop(out chan<- watcher.Change) {
+	defer close(f.done)
+	for {
+		select {
+		case change := <-f.changes:
+			select {
+			case out <- watcher.Change{C: presenceC, Id: change.Key}:
+			case <-f.stop:
+				return
+			}
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+type backingInstanceData instanceData
+
+func (d *backingInstanceData) updated(st *State, store *multiwatcher.Store, id interface{}) error {
+	info0 := store.Get(params.EntityId{Kind: "machine", Id: id})
+	switch info := info0.(type) {
+	case nil:
+		// The machine info doesn't exist. Ignore the instance
+		// data until it does.
+		return nil
+	case *params.MachineInfo:
+		if info.InstanceId == "" {
+			// The machine is not yet known to be provisioned; the
+			// instance data is fetched along with its instance id.
+			return nil
+		}
+		newInfo := *info
+		newInfo.InstanceStatus = d.Status
+		info0 = &newInfo
+	default:
+		panic(fmt.Errorf("instance data for unexpected entity with id %q; type %T", id, info))
+	}
+	store.Update(info0)
+	return nil
+}
+
+func (d *backingInstanceData) removed(st *State, store *multiwatcher.Store, id interface{}) error {
+	// If the instance data is removed, the machine will follow
+	// not long after, so do nothing.
+	return nil
+}
+
+func (d *backingInstanceData) mongoId() interface{} {
+	panic("cannot find mongo id from instance data document")
+}
+
 type backingConstraints constraintsDoc
 
 func (s *backingConstraints) updated(st *State, store *multiwatcher.Store, id interface{}) error {
@@ -474,6 +605,7 @@ func newAllWatcherStateBacking(st *State) multiwatcher.Backing {
 	b := &allWatcherStateBacking{
 		st:               st,
 		collectionByName: make(map[string]allWatcherStateCollection),
+		presence:         make(map[chan<- watcher.Change]*presenceForwarder),
 	}
 
 	collections := []allWatcherStateCollection{{
@@ -503,6 +635,10 @@ func newAllWatcherStateBacking(st *State) multiwatcher.Backing {
 		Collection: st.db.C(settingsC),
 		infoType:   reflect.TypeOf(backingSettings{}),
 		subsidiary: true,
+	}, {
+		Collection: st.db.C(instanceDataC),
+		infoType:   reflect.TypeOf(backingInstanceData{}),
+		subsidiary: true,
 	}}
 	// Populate the collection maps from the above set of collections.
 	for _, c := range collections {
@@ -519,18 +655,37 @@ func newAllWatcherStateBacking(st *State) multiwatcher.Backing {
 	return b
 }
 
-// Watch watches all the collections.
+// Watch watches all the collections, and the presence of all agents.
 func (b *allWatcherStateBacking) Watch(in chan<- watcher.Change) {
 	for _, c := range b.collectionByName {
 		b.st.watcher.WatchCollection(c.Name, in)
 	}
+	f := &presenceForwarder{
+		changes: make(chan presence.Change),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.presence[in] = f
+	b.mu.Unlock()
+	go f.loop(in)
+	b.st.pwatcher.WatchAll(f.changes)
 }
 
-// Unwatch unwatches all the collections.
+// Unwatch unwatches all the collections, and the presence of all agents.
 func (b *allWatcherStateBacking) Unwatch(in chan<- watcher.Change) {
 	for _, c := range b.collectionByName {
 		b.st.watcher.UnwatchCollection(c.Name, in)
 	}
+	b.mu.Lock()
+	f := b.presence[in]
+	delete(b.presence, in)
+	b.mu.Unlock()
+	if f != nil {
+		b.st.pwatcher.UnwatchAll(f.changes)
+		close(f.stop)
+		<-f.done
+	}
 }
 
 // GetAll fetches all items that we want to watch from the state.
@@ -560,6 +715,9 @@ func (b *allWatcherStateBacking) GetAll(all *multiwatcher.Store) error {
 // Changed updates the allWatcher's idea of the current state
 // in response to the given change.
 func (b *allWatcherStateBacking) Changed(all *multiwatcher.Store, change watcher.Change) error {
+	if change.C == presenceC {
+		return presenceChanged(b.st, all, change.Id.(string))
+	}
 	db, closer := b.st.newDB()
 	defer closer()
 
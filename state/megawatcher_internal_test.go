@@ -13,6 +13,7 @@ import (
 	"github.com/juju/charm"
 	"github.com/juju/names"
 	gitjujutesting "github.com/juju/testing"
+	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/constraints"
@@ -797,6 +798,68 @@ var allWatcherChangedTests = []struct {
 			},
 		},
 	},
+	// Machine instance data changes
+	{
+		about: "no machine in store -> do nothing",
+		setUp: func(c *gc.C, st *State) {
+			m, err := st.AddMachine("quantal", JobHostUnits)
+			c.Assert(err, gc.IsNil)
+			err = m.SetProvisioned("i-0", "fake_nonce", nil)
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "instanceData",
+			Id: "0",
+		},
+	}, {
+		about: "no change if the machine in the store is not provisioned",
+		add: []params.EntityInfo{&params.MachineInfo{
+			Id:     "0",
+			Status: params.StatusPending,
+		}},
+		setUp: func(c *gc.C, st *State) {
+			m, err := st.AddMachine("quantal", JobHostUnits)
+			c.Assert(err, gc.IsNil)
+			err = m.SetProvisioned("i-0", "fake_nonce", nil)
+			c.Assert(err, gc.IsNil)
+			err = m.SetInstanceStatus("running")
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "instanceData",
+			Id: "0",
+		},
+		expectContents: []params.EntityInfo{&params.MachineInfo{
+			Id:     "0",
+			Status: params.StatusPending,
+		}},
+	}, {
+		about: "instance status is changed if the machine exists in the store",
+		add: []params.EntityInfo{&params.MachineInfo{
+			Id:             "0",
+			InstanceId:     "i-0",
+			InstanceStatus: "running",
+			Status:         params.StatusStarted,
+		}},
+		setUp: func(c *gc.C, st *State) {
+			m, err := st.AddMachine("quantal", JobHostUnits)
+			c.Assert(err, gc.IsNil)
+			err = m.SetProvisioned("i-0", "fake_nonce", nil)
+			c.Assert(err, gc.IsNil)
+			err = m.SetInstanceStatus("shutting-down")
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "instanceData",
+			Id: "0",
+		},
+		expectContents: []params.EntityInfo{&params.MachineInfo{
+			Id:             "0",
+			InstanceId:     "i-0",
+			InstanceStatus: "shutting-down",
+			Status:         params.StatusStarted,
+		}},
+	},
 	// Service constraints changes
 	{
 		about: "no service in state -> do nothing",
@@ -978,6 +1041,70 @@ func (s *storeManagerStateSuite) TestChanged(c *gc.C) {
 	}
 }
 
+func (s *storeManagerStateSuite) TestChangedPresence(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	b := newAllWatcherStateBacking(s.State)
+	all := multiwatcher.NewStore()
+	all.Update(&params.MachineInfo{Id: m.Id()})
+
+	pinger, err := m.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = b.Changed(all, watcher.Change{C: presenceC, Id: m.globalKey()})
+	c.Assert(err, gc.IsNil)
+	assertEntitiesEqual(c, all.All(), []params.EntityInfo{
+		&params.MachineInfo{Id: m.Id(), AgentAlive: true},
+	})
+
+	// When the agent goes away, the time it was last seen is kept.
+	err = pinger.Kill()
+	c.Assert(err, gc.IsNil)
+	s.State.StartSync()
+	err = b.Changed(all, watcher.Change{C: presenceC, Id: m.globalKey()})
+	c.Assert(err, gc.IsNil)
+	infos := all.All()
+	c.Assert(infos, gc.HasLen, 1)
+	info := infos[0].(*params.MachineInfo)
+	c.Assert(info.AgentAlive, jc.IsFalse)
+	c.Assert(info.AgentLastSeen.IsZero(), jc.IsFalse)
+
+	// Presence changes for entities not in the store are ignored.
+	err = b.Changed(all, watcher.Change{C: presenceC, Id: "u#wordpress/0"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(all.All(), gc.HasLen, 1)
+}
+
+func (s *storeManagerStateSuite) TestStateWatcherPresence(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	b := newAllWatcherStateBacking(s.State)
+	aw := multiwatcher.NewStoreManager(b)
+	defer aw.Stop()
+	w := multiwatcher.NewWatcher(aw)
+	defer w.Stop()
+	s.State.StartSync()
+	machineInfo := &params.MachineInfo{
+		Id:        m.Id(),
+		Status:    params.StatusPending,
+		Life:      params.Alive,
+		Series:    "quantal",
+		Jobs:      []params.MachineJob{JobHostUnits.ToParams()},
+		Addresses: []network.Address{},
+	}
+	checkNext(c, w, b, []params.Delta{{Entity: machineInfo}}, "")
+
+	// The watcher reports the agent coming alive.
+	pinger, err := m.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	aliveInfo := *machineInfo
+	aliveInfo.AgentAlive = true
+	checkNext(c, w, b, []params.Delta{{Entity: &aliveInfo}}, "")
+}
+
 // TestStateWatcher tests the integration of the state watcher
 // with the state-based backing. Most of the logic is tested elsewhere -
 // this just tests end-to-end.
@@ -0,0 +1,181 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	"labix.org/v2/mgo/bson"
+)
+
+// validMetricKey matches the keys allowed for metrics, such as
+// "cpu.load1" or "memory-used".
+var validMetricKey = regexp.MustCompile(`^[a-z][a-z0-9]*([-.][a-z0-9]+)*$`)
+
+// Metric holds a single measurement taken by an agent.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+func (m Metric) validate() error {
+	if !validMetricKey.MatchString(m.Key) {
+		return fmt.Errorf("invalid metric key %q", m.Key)
+	}
+	if _, err := strconv.ParseFloat(m.Value, 64); err != nil {
+		return fmt.Errorf("invalid value %q for metric %q", m.Value, m.Key)
+	}
+	return nil
+}
+
+// metricBatchDoc holds a batch of metrics sent together by the agent
+// of a machine or unit. Batches are kept in a capped collection, so
+// the oldest are discarded as new ones arrive.
+type metricBatchDoc struct {
+	Id      bson.ObjectId `bson:"_id"`
+	Entity  string
+	Created time.Time
+	Metrics []Metric
+}
+
+// MetricBatch represents a batch of metrics sent by an agent.
+type MetricBatch struct {
+	doc metricBatchDoc
+}
+
+// Entity returns the tag of the machine or unit the metrics were
+// collected for.
+func (b *MetricBatch) Entity() string {
+	return b.doc.Entity
+}
+
+// Created returns the time at which the batch was received.
+func (b *MetricBatch) Created() time.Time {
+	return b.doc.Created
+}
+
+// Metrics returns the metrics in the batch.
+func (b *MetricBatch) Metrics() []Metric {
+	result := make([]Metric, len(b.doc.Metrics))
+	copy(result, b.doc.Metrics)
+	return result
+}
+
+// AddMetricBatch records a batch of metrics collected for the machine
+// or unit with the given tag.
+func (st *State) AddMetricBatch(entityTag string, metrics []Metric) (_ *MetricBatch, err error) {
+	defer errors.Maskf(&err, "cannot add metrics for %q", entityTag)
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no metrics")
+	}
+	for _, m := range metrics {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+	}
+	tag, err := names.ParseTag(entityTag)
+	if err != nil {
+		return nil, err
+	}
+	switch tag.(type) {
+	case names.MachineTag, names.UnitTag:
+	default:
+		return nil, fmt.Errorf("metrics are only recorded for machines and units")
+	}
+	if _, err := st.FindEntity(entityTag); err != nil {
+		return nil, err
+	}
+	doc := metricBatchDoc{
+		Id:      bson.NewObjectId(),
+		Entity:  entityTag,
+		Created: nowToTheSecond(),
+		Metrics: metrics,
+	}
+	collection, closer := st.getCollection(metricsC)
+	defer closer()
+	if err := collection.Insert(&doc); err != nil {
+		return nil, err
+	}
+	return &MetricBatch{doc: doc}, nil
+}
+
+// MetricBatches returns the batches of metrics received since the given
+// time for the machine or unit with the given tag, oldest first. If the
+// tag is empty, batches for all entities are returned.
+func (st *State) MetricBatches(entityTag string, since time.Time) ([]*MetricBatch, error) {
+	batches, _, err := st.MetricBatchesPage(entityTag, since, "", 0)
+	return batches, err
+}
+
+// MetricBatchesPage returns a page of the batches returned by
+// MetricBatches, and the marker from which to request the next page.
+// The page starts after the batch identified by marker, or at the
+// beginning if marker is empty, and holds at most limit batches, or
+// all the remaining batches if limit is zero. The returned marker is
+// empty if there are no more batches.
+func (st *State) MetricBatchesPage(entityTag string, since time.Time, marker string, limit int) ([]*MetricBatch, string, error) {
+	collection, closer := st.getCollection(metricsC)
+	defer closer()
+
+	query := bson.D{{"created", bson.D{{"$gte", since}}}}
+	if entityTag != "" {
+		query = append(query, bson.DocElem{"entity", entityTag})
+	}
+	if marker != "" {
+		created, id, err := parseMetricsMarker(marker)
+		if err != nil {
+			return nil, "", err
+		}
+		query = append(query, bson.DocElem{"$or", []bson.D{
+			{{"created", bson.D{{"$gt", created}}}},
+			{{"created", created}, {"_id", bson.D{{"$gt", id}}}},
+		}})
+	}
+	q := collection.Find(query).Sort("created", "_id")
+	if limit > 0 {
+		// Read one more than asked, to find out whether
+		// there is another page.
+		q = q.Limit(limit + 1)
+	}
+	var docs []metricBatchDoc
+	if err := q.All(&docs); err != nil {
+		return nil, "", errors.Annotate(err, "cannot get metrics")
+	}
+	var next string
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+		next = metricsMarker(docs[limit-1])
+	}
+	result := make([]*MetricBatch, len(docs))
+	for i, doc := range docs {
+		result[i] = &MetricBatch{doc: doc}
+	}
+	return result, next, nil
+}
+
+// metricsMarker returns the marker from which to list the batches
+// after the given one. Batches are ordered by creation time and then
+// by id, so the marker holds both.
+func metricsMarker(doc metricBatchDoc) string {
+	return fmt.Sprintf("%d-%s", doc.Created.Unix(), doc.Id.Hex())
+}
+
+// parseMetricsMarker returns the creation time and id held in a
+// marker returned by metricsMarker.
+func parseMetricsMarker(marker string) (time.Time, bson.ObjectId, error) {
+	parts := strings.SplitN(marker, "-", 2)
+	if len(parts) == 2 && bson.IsObjectIdHex(parts[1]) {
+		if seconds, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+			return time.Unix(seconds, 0), bson.ObjectIdHex(parts[1]), nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("invalid metrics marker %q", marker)
+}
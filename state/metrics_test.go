@@ -0,0 +1,135 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type MetricsSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) TestAddMetricBatch(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	now := time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC)
+	metrics := []state.Metric{
+		{Key: "cpu.load1", Value: "0.5", Time: now},
+		{Key: "memory.used", Value: "1024", Time: now},
+	}
+	batch, err := s.State.AddMetricBatch(m.Tag().String(), metrics)
+	c.Assert(err, gc.IsNil)
+	c.Assert(batch.Entity(), gc.Equals, m.Tag().String())
+	c.Assert(batch.Metrics(), gc.DeepEquals, metrics)
+
+	batches, err := s.State.MetricBatches(m.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Entity(), gc.Equals, m.Tag().String())
+	c.Assert(batches[0].Created().Equal(batch.Created()), gc.Equals, true)
+	got := batches[0].Metrics()
+	c.Assert(got, gc.HasLen, 2)
+	c.Assert(got[0].Key, gc.Equals, "cpu.load1")
+	c.Assert(got[0].Value, gc.Equals, "0.5")
+	c.Assert(got[0].Time.Equal(now), gc.Equals, true)
+}
+
+func (s *MetricsSuite) TestAddMetricBatchErrors(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	now := time.Now()
+	for i, t := range []struct {
+		tag     string
+		metrics []state.Metric
+		err     string
+	}{{
+		tag: m.Tag().String(),
+		err: `cannot add metrics for "machine-0": no metrics`,
+	}, {
+		tag:     m.Tag().String(),
+		metrics: []state.Metric{{Key: "CPU", Value: "1", Time: now}},
+		err:     `cannot add metrics for "machine-0": invalid metric key "CPU"`,
+	}, {
+		tag:     m.Tag().String(),
+		metrics: []state.Metric{{Key: "cpu", Value: "lots", Time: now}},
+		err:     `cannot add metrics for "machine-0": invalid value "lots" for metric "cpu"`,
+	}, {
+		tag:     svc.Tag().String(),
+		metrics: []state.Metric{{Key: "cpu", Value: "1", Time: now}},
+		err:     `cannot add metrics for "service-wordpress": metrics are only recorded for machines and units`,
+	}, {
+		tag:     "machine-42",
+		metrics: []state.Metric{{Key: "cpu", Value: "1", Time: now}},
+		err:     `cannot add metrics for "machine-42": machine 42 not found`,
+	}} {
+		c.Logf("test %d", i)
+		_, err := s.State.AddMetricBatch(t.tag, t.metrics)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MetricsSuite) TestMetricBatchesFiltering(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	metrics := []state.Metric{{Key: "cpu", Value: "1", Time: time.Now()}}
+	_, err = s.State.AddMetricBatch(m0.Tag().String(), metrics)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMetricBatch(m1.Tag().String(), metrics)
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.State.MetricBatches("", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 2)
+	batches, err = s.State.MetricBatches(m1.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Entity(), gc.Equals, m1.Tag().String())
+	batches, err = s.State.MetricBatches("", time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+}
+
+func (s *MetricsSuite) TestMetricBatchesPage(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	metrics := []state.Metric{{Key: "cpu", Value: "1", Time: time.Now()}}
+	var added []*state.MetricBatch
+	for i := 0; i < 5; i++ {
+		batch, err := s.State.AddMetricBatch(m.Tag().String(), metrics)
+		c.Assert(err, gc.IsNil)
+		added = append(added, batch)
+	}
+
+	var all []*state.MetricBatch
+	marker := ""
+	for pages := 0; ; pages++ {
+		c.Assert(pages < 3, jc.IsTrue)
+		batches, next, err := s.State.MetricBatchesPage("", time.Time{}, marker, 2)
+		c.Assert(err, gc.IsNil)
+		c.Assert(len(batches) <= 2, jc.IsTrue)
+		all = append(all, batches...)
+		if next == "" {
+			break
+		}
+		marker = next
+	}
+	c.Assert(all, gc.HasLen, len(added))
+	unpaged, err := s.State.MetricBatches("", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, jc.DeepEquals, unpaged)
+
+	_, _, err = s.State.MetricBatchesPage("", time.Time{}, "rubbish", 2)
+	c.Assert(err, gc.ErrorMatches, `invalid metrics marker "rubbish"`)
+}
@@ -6,7 +6,12 @@ package multiwatcher
 import (
 	"container/list"
 	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"launchpad.net/tomb"
 
@@ -84,6 +89,14 @@ type StoreManager struct {
 	// Each entry in the waiting map holds a linked list of Next requests
 	// outstanding for the associated Watcher.
 	waiting map[*Watcher]*request
+
+	// calls receives functions to be run by the StoreManager
+	// goroutine, with access to all.
+	calls chan func()
+
+	// id distinguishes the change tokens of this StoreManager from
+	// those of any other, whose revnos are unrelated.
+	id string
 }
 
 // InfoId holds an identifier for an Info item held in a Store.
@@ -142,6 +155,8 @@ func newStoreManagerNoRun(backing Backing) *StoreManager {
 		request: make(chan *request),
 		all:     NewStore(),
 		waiting: make(map[*Watcher]*request),
+		calls:   make(chan func()),
+		id:      strconv.FormatInt(time.Now().UnixNano(), 36),
 	}
 }
 
@@ -184,6 +199,8 @@ func (sm *StoreManager) loop() error {
 			}
 		case req := <-sm.request:
 			sm.handle(req)
+		case call := <-sm.calls:
+			call()
 		}
 		sm.respond()
 	}
@@ -195,6 +212,54 @@ func (sm *StoreManager) Stop() error {
 	return sm.tomb.Wait()
 }
 
+// ChangesSince returns the changes made since the given token was
+// returned, oldest first, and a token representing the current state.
+// Unlike a Watcher, it keeps no record of what the caller has seen.
+//
+// If the changes cannot be determined, because the token is empty,
+// came from another StoreManager, or is so old that removals made
+// since have been forgotten, all current entities are returned and
+// reset is true; the caller should then discard anything it knows.
+func (sm *StoreManager) ChangesSince(token string) (deltas []params.Delta, newToken string, reset bool, err error) {
+	revno, ok := sm.parseToken(token)
+	done := make(chan struct{})
+	call := func() {
+		defer close(done)
+		if ok {
+			deltas, ok = sm.all.removalsAndChangesSince(revno)
+		}
+		if !ok {
+			deltas, reset = sm.all.ChangesSince(0), true
+		}
+		newToken = fmt.Sprintf("%s:%d", sm.id, sm.all.latestRevno)
+	}
+	select {
+	case sm.calls <- call:
+	case <-sm.tomb.Dead():
+		err := sm.tomb.Err()
+		if err == nil {
+			err = errors.New("shared state watcher was stopped")
+		}
+		return nil, "", false, err
+	}
+	<-done
+	return deltas, newToken, reset, nil
+}
+
+// parseToken returns the revno held in the given token, and whether
+// the token was made by sm.
+func (sm *StoreManager) parseToken(token string) (int64, bool) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 || parts[0] != sm.id {
+		return 0, false
+	}
+	revno, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return revno, true
+}
+
 // handle processes a request from a Watcher to the StoreManager.
 func (sm *StoreManager) handle(req *request) {
 	if req.w.stopped {
@@ -317,12 +382,33 @@ type entityEntry struct {
 	info params.EntityInfo
 }
 
+// maxTombstones holds the number of removed entities a Store remembers
+// after all Watchers have been told of their removal.
+const maxTombstones = 1000
+
+// tombstone records the removal of an entity that is no longer held in
+// a Store's list.
+type tombstone struct {
+	revno         int64
+	creationRevno int64
+	info          params.EntityInfo
+}
+
 // Store holds a list of all entities known
 // to a Watcher.
 type Store struct {
 	latestRevno int64
 	entities    map[InfoId]*list.Element
 	list        *list.List
+
+	// tombstones holds the most recent removals that have been
+	// deleted from the list, so that they can still be reported
+	// by removalsAndChangesSince.
+	tombstones []tombstone
+
+	// forgottenRevno holds the latest revno of any removal that
+	// has been dropped from tombstones.
+	forgottenRevno int64
 }
 
 // NewStore returns an Store instance holding information about the
@@ -384,6 +470,23 @@ func (a *Store) decRef(entry *entityEntry) {
 	}
 	delete(a.entities, id)
 	a.list.Remove(elem)
+	a.bury(entry)
+}
+
+// bury records the removal of an entry that is being deleted from the
+// list.
+func (a *Store) bury(entry *entityEntry) {
+	a.tombstones = append(a.tombstones, tombstone{
+		revno:         entry.revno,
+		creationRevno: entry.creationRevno,
+		info:          entry.info,
+	})
+	if len(a.tombstones) > maxTombstones {
+		if revno := a.tombstones[0].revno; revno > a.forgottenRevno {
+			a.forgottenRevno = revno
+		}
+		a.tombstones = append(a.tombstones[:0], a.tombstones[1:]...)
+	}
 }
 
 // delete deletes the entry with the given info id.
@@ -407,7 +510,9 @@ func (a *Store) Remove(id params.EntityId) {
 		}
 		a.latestRevno++
 		if entry.refCount == 0 {
+			entry.revno = a.latestRevno
 			a.delete(id)
+			a.bury(entry)
 			return
 		}
 		entry.revno = a.latestRevno
@@ -482,3 +587,53 @@ func (a *Store) ChangesSince(revno int64) []params.Delta {
 	}
 	return changes
 }
+
+// removalsAndChangesSince is like ChangesSince, but also reports
+// removals that have been deleted from the list after all Watchers saw
+// them. It returns false if some removal since the given revno has
+// been forgotten entirely.
+func (a *Store) removalsAndChangesSince(revno int64) ([]params.Delta, bool) {
+	if revno < a.forgottenRevno {
+		return nil, false
+	}
+	var changes revnoDeltas
+	for e := a.list.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*entityEntry)
+		if entry.revno <= revno {
+			break
+		}
+		if entry.removed && entry.creationRevno > revno {
+			continue
+		}
+		changes = append(changes, revnoDelta{entry.revno, params.Delta{
+			Removed: entry.removed,
+			Entity:  entry.info,
+		}})
+	}
+	for _, t := range a.tombstones {
+		if t.revno <= revno || t.creationRevno > revno {
+			continue
+		}
+		changes = append(changes, revnoDelta{t.revno, params.Delta{
+			Removed: true,
+			Entity:  t.info,
+		}})
+	}
+	sort.Sort(changes)
+	deltas := make([]params.Delta, len(changes))
+	for i, change := range changes {
+		deltas[i] = change.delta
+	}
+	return deltas, true
+}
+
+type revnoDelta struct {
+	revno int64
+	delta params.Delta
+}
+
+type revnoDeltas []revnoDelta
+
+func (d revnoDeltas) Len() int           { return len(d) }
+func (d revnoDeltas) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d revnoDeltas) Less(i, j int) bool { return d[i].revno < d[j].revno }
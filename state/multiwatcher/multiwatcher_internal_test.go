@@ -11,6 +11,7 @@ import (
 	stdtesting "testing"
 	"time"
 
+	jc "github.com/juju/testing/checkers"
 	"labix.org/v2/mgo"
 	gc "launchpad.net/gocheck"
 
@@ -260,6 +261,54 @@ func (s *storeSuite) TestChangesSince(c *gc.C) {
 	}})
 }
 
+func (s *storeSuite) TestRemovalsAndChangesSince(c *gc.C) {
+	a := NewStore()
+	for i := 0; i < 3; i++ {
+		a.Update(&MachineInfo{Id: fmt.Sprint(i)})
+	}
+	rev := a.latestRevno
+
+	// Nothing has seen machine 0, so it is deleted from the list at
+	// once, but its removal is still reported.
+	a.Remove(params.EntityId{"machine", "0"})
+	m1 := &MachineInfo{Id: "1", InstanceId: "foo"}
+	a.Update(m1)
+	c.Assert(a.ChangesSince(rev), gc.DeepEquals, []params.Delta{{Entity: m1}})
+	deltas, ok := a.removalsAndChangesSince(rev)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{{
+		Removed: true,
+		Entity:  &MachineInfo{Id: "0"},
+	}, {
+		Entity: m1,
+	}})
+
+	// Removals of entities created since the revno are not reported.
+	deltas, ok = a.removalsAndChangesSince(0)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{{
+		Entity: &MachineInfo{Id: "2"},
+	}, {
+		Entity: m1,
+	}})
+
+	// Once too many removals have been made, the earliest are
+	// forgotten.
+	for i := 0; i < maxTombstones; i++ {
+		id := fmt.Sprint("new", i)
+		a.Update(&MachineInfo{Id: id})
+		a.Remove(params.EntityId{"machine", id})
+	}
+	_, ok = a.removalsAndChangesSince(rev)
+	c.Assert(ok, jc.IsFalse)
+	deltas, ok = a.removalsAndChangesSince(a.latestRevno - 1)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{{
+		Removed: true,
+		Entity:  &MachineInfo{Id: fmt.Sprint("new", maxTombstones-1)},
+	}})
+}
+
 func (s *storeSuite) TestGet(c *gc.C) {
 	a := NewStore()
 	m := &MachineInfo{Id: "0"}
@@ -643,6 +692,55 @@ func (*storeManagerSuite) TestRun(c *gc.C) {
 	}, "")
 }
 
+func (*storeManagerSuite) TestChangesSince(c *gc.C) {
+	b := newTestBacking([]params.EntityInfo{
+		&MachineInfo{Id: "0"},
+		&ServiceInfo{Name: "logging"},
+	})
+	sm := NewStoreManager(b)
+	defer func() {
+		c.Check(sm.Stop(), gc.IsNil)
+	}()
+	deltas, token, reset, err := sm.ChangesSince("")
+	c.Assert(err, gc.IsNil)
+	c.Assert(reset, jc.IsTrue)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{
+		{Entity: &MachineInfo{Id: "0"}},
+		{Entity: &ServiceInfo{Name: "logging"}},
+	})
+
+	b.updateEntity(&MachineInfo{Id: "0", InstanceId: "i-0"})
+	b.deleteEntity(params.EntityId{"service", "logging"})
+	deltas, token, reset, err = sm.ChangesSince(token)
+	c.Assert(err, gc.IsNil)
+	c.Assert(reset, jc.IsFalse)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{
+		{Entity: &MachineInfo{Id: "0", InstanceId: "i-0"}},
+		{Removed: true, Entity: &ServiceInfo{Name: "logging"}},
+	})
+
+	deltas, _, reset, err = sm.ChangesSince(token)
+	c.Assert(err, gc.IsNil)
+	c.Assert(reset, jc.IsFalse)
+	c.Assert(deltas, gc.HasLen, 0)
+
+	// Tokens from elsewhere cannot be interpreted.
+	deltas, _, reset, err = sm.ChangesSince("elsewhere:1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(reset, jc.IsTrue)
+	c.Assert(deltas, gc.DeepEquals, []params.Delta{
+		{Entity: &MachineInfo{Id: "0", InstanceId: "i-0"}},
+	})
+}
+
+func (*storeManagerSuite) TestChangesSinceStopped(c *gc.C) {
+	sm := NewStoreManager(newTestBacking(nil))
+	err := sm.Stop()
+	c.Assert(err, gc.IsNil)
+	_, _, _, err = sm.ChangesSince("")
+	c.Assert(err, gc.ErrorMatches, "shared state watcher was stopped")
+}
+
 func (*storeManagerSuite) TestWatcherStop(c *gc.C) {
 	sm := NewStoreManager(newTestBacking(nil))
 	defer func() {
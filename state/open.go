@@ -5,6 +5,7 @@ package state
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/names"
@@ -32,6 +33,49 @@ import (
 //
 // Open returns unauthorizedError if access is unauthorized.
 func Open(info *authentication.MongoInfo, opts mongo.DialOpts, policy Policy) (*State, error) {
+	return OpenWithWatcherParams(info, opts, policy, WatcherParams{})
+}
+
+// WatcherParams holds parameters that tune how the state watches
+// the transaction log for changes. Zero values select the defaults.
+type WatcherParams struct {
+	// Period holds the delay between each poll of the
+	// transaction log.
+	Period time.Duration
+
+	// BatchSize holds the number of transaction log entries
+	// fetched in each round trip to the database.
+	BatchSize int
+
+	// CoalesceWindow holds how long changes are held back so
+	// that those made close together are delivered together.
+	CoalesceWindow time.Duration
+
+	// Name, if set, identifies the watcher among those opened
+	// with the same credentials. A named watcher records how far
+	// through the transaction log it has seen, and a watcher later
+	// opened with the same name and credentials resumes from there.
+	// States open at the same time must not share a name.
+	Name string
+}
+
+// SetWatcherParams changes the poll period, batch size and coalescing
+// window of the watcher underlying all the state watchers, so that
+// they can be tuned without reopening the state. The watcher's name
+// cannot be changed, and is ignored.
+func (st *State) SetWatcherParams(wp WatcherParams) {
+	st.watcher.SetParams(watcher.Params{
+		Period:         wp.Period,
+		BatchSize:      wp.BatchSize,
+		CoalesceWindow: wp.CoalesceWindow,
+	})
+}
+
+// OpenWithWatcherParams is like Open, but the watcher underlying
+// all the state watchers is configured with the given parameters.
+// Busy environments may trade watcher latency against load on
+// mongo by lengthening the poll period.
+func OpenWithWatcherParams(info *authentication.MongoInfo, opts mongo.DialOpts, policy Policy, wp WatcherParams) (*State, error) {
 	logger.Infof("opening state, mongo addresses: %q; entity %q", info.Addrs, info.Tag)
 	di, err := mongo.DialInfo(info.Info, opts)
 	if err != nil {
@@ -53,7 +97,7 @@ func Open(info *authentication.MongoInfo, opts mongo.DialOpts, policy Policy) (*
 	}
 	session.SetSafe(safe)
 
-	st, err := newState(session, info, policy)
+	st, err := newState(session, info, policy, wp)
 	if err != nil {
 		session.Close()
 		return nil, err
@@ -95,6 +139,7 @@ func Initialize(info *authentication.MongoInfo, cfg *config.Config, opts mongo.D
 	ops := []txn.Op{
 		createConstraintsOp(st, environGlobalKey, constraints.Value{}),
 		createSettingsOp(st, environGlobalKey, cfg.AllAttrs()),
+		createSettingsOp(st, controllerSettingsGlobalKey, controllerSettings(cfg)),
 		createEnvironmentOp(st, cfg.Name(), uuid),
 		{
 			C:      stateServersC,
@@ -115,28 +160,6 @@ func Initialize(info *authentication.MongoInfo, cfg *config.Config, opts mongo.D
 	return st, nil
 }
 
-var indexes = []struct {
-	collection string
-	key        []string
-	unique     bool
-}{
-	// After the first public release, do not remove entries from here
-	// without adding them to a list of indexes to drop, to ensure
-	// old databases are modified to have the correct indexes.
-	{relationsC, []string{"endpoints.relationname"}, false},
-	{relationsC, []string{"endpoints.servicename"}, false},
-	{unitsC, []string{"service"}, false},
-	{unitsC, []string{"principal"}, false},
-	{unitsC, []string{"machineid"}, false},
-	// TODO(thumper): schema change to remove this index.
-	{usersC, []string{"name"}, false},
-	{networksC, []string{"providerid"}, true},
-	{networkInterfacesC, []string{"interfacename", "machineid"}, true},
-	{networkInterfacesC, []string{"macaddress", "networkname"}, true},
-	{networkInterfacesC, []string{"networkname"}, false},
-	{networkInterfacesC, []string{"machineid"}, false},
-}
-
 // The capped collection used for transaction logs defaults to 10MB.
 // It's tweaked in export_test.go to 1MB to avoid the overhead of
 // creating and deleting the large file repeatedly in tests.
@@ -145,6 +168,14 @@ var (
 	logSizeTests = 1000000
 )
 
+// The capped collection used for metrics defaults to 50MB, so that
+// the oldest batches are discarded once it is full. It is tweaked in
+// export_test.go in the same way as the transaction log.
+var (
+	metricsSize      = 50000000
+	metricsSizeTests = 1000000
+)
+
 func maybeUnauthorized(err error, msg string) error {
 	if err == nil {
 		return nil
@@ -172,7 +203,7 @@ func isUnauthorized(err error) bool {
 	return false
 }
 
-func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy Policy) (*State, error) {
+func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy Policy, wp WatcherParams) (*State, error) {
 	db := session.DB("juju")
 	pdb := session.DB("presence")
 	admin := session.DB("admin")
@@ -209,19 +240,32 @@ func newState(session *mgo.Session, mongoInfo *authentication.MongoInfo, policy
 	if err != nil && err.Error() != "collection already exists" {
 		return nil, maybeUnauthorized(err, "cannot create log collection")
 	}
+	metrics := db.C(metricsC)
+	err = metrics.Create(&mgo.CollectionInfo{Capped: true, MaxBytes: metricsSize})
+	if err != nil && err.Error() != "collection already exists" {
+		return nil, maybeUnauthorized(err, "cannot create metrics collection")
+	}
 	txns := db.C(txnsC)
 	err = txns.Create(&mgo.CollectionInfo{})
 	if err != nil && err.Error() != "collection already exists" {
 		return nil, maybeUnauthorized(err, "cannot create transaction collection")
 	}
 
-	st.watcher = watcher.New(log)
+	watcherParams := watcher.Params{
+		Period:         wp.Period,
+		BatchSize:      wp.BatchSize,
+		CoalesceWindow: wp.CoalesceWindow,
+	}
+	if mongoInfo.Tag != nil && wp.Name != "" {
+		// Agents record how far through the log they have seen,
+		// so that restarting an agent does not lose changes.
+		owner := mongoInfo.Tag.String() + ":" + wp.Name
+		watcherParams.Positions = watcher.NewCollectionPositions(db.C(watcherPositionsC), owner)
+	}
+	st.watcher = watcher.NewWithParams(log, watcherParams)
 	st.pwatcher = presence.NewWatcher(pdb.C(presenceC))
-	for _, item := range indexes {
-		index := mgo.Index{Key: item.key, Unique: item.unique}
-		if err := db.C(item.collection).EnsureIndex(index); err != nil {
-			return nil, fmt.Errorf("cannot create database index: %v", err)
-		}
+	if err := ensureIndexes(db); err != nil {
+		return nil, err
 	}
 
 	// TODO(rog) delete this when we can assume there are no
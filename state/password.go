@@ -0,0 +1,53 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"crypto/subtle"
+
+	"github.com/juju/utils"
+)
+
+// newAgentPasswordHash returns a new random salt and the hash
+// of the given agent password with that salt.
+func newAgentPasswordHash(password string) (hash, salt string, err error) {
+	salt, err = utils.RandomSalt()
+	if err != nil {
+		return "", "", err
+	}
+	return saltedAgentPasswordHash(password, salt), salt, nil
+}
+
+// saltedAgentPasswordHash returns the hash of the given agent password
+// with the given salt. Agent passwords are long random strings, so
+// unlike user passwords they do not need the slower pbkdf2 hashing;
+// the salt ensures that equal passwords never have equal hashes.
+func saltedAgentPasswordHash(password, salt string) string {
+	return utils.AgentPasswordHash(salt + password)
+}
+
+// agentPasswordValid reports whether the given agent password
+// matches the stored hash and salt. If the hash was stored unsalted
+// by an earlier version of juju, it also reports that the hash
+// should be replaced by a salted one.
+func agentPasswordValid(password, hash, salt string) (valid, upgrade bool) {
+	if salt != "" {
+		return passwordHashMatches(saltedAgentPasswordHash(password, salt), hash), false
+	}
+	// In Juju 1.16 and older we used the slower password hash for
+	// agents, and until salts were introduced we used the unsalted
+	// agent password hash.
+	if passwordHashMatches(utils.AgentPasswordHash(password), hash) ||
+		passwordHashMatches(utils.UserPasswordHash(password, utils.CompatSalt), hash) {
+		return true, true
+	}
+	return false, false
+}
+
+// passwordHashMatches reports whether the two password hashes are
+// equal, taking the same time whatever their contents so as not
+// to reveal anything about the stored hash.
+func passwordHashMatches(hash, storedHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(storedHash)) == 1
+}
@@ -5,8 +5,10 @@ package state
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
+	"github.com/juju/utils/set"
 
 	"github.com/juju/juju/constraints"
 	"github.com/juju/juju/environs/config"
@@ -137,12 +139,37 @@ func (st *State) resolveConstraints(cons constraints.Value) (constraints.Value,
 	if err != nil {
 		return constraints.Value{}, err
 	}
-	return validator.Merge(envCons, cons)
+	merged, err := validator.Merge(envCons, cons)
+	if err != nil {
+		return constraints.Value{}, err
+	}
+	warnIgnoredConstraints(validator, envCons, merged)
+	return merged, nil
+}
+
+// warnIgnoredConstraints logs a warning naming any environment
+// constraints that were overridden by conflicting constraints when
+// merged, and any merged constraints that the environment does not
+// support. Unsupported constraints are kept, but have no effect.
+func warnIgnoredConstraints(validator constraints.Validator, envCons, merged constraints.Value) {
+	mergedAttrs := set.NewStrings(merged.Attributes()...)
+	var overridden []string
+	for _, attr := range envCons.Attributes() {
+		if !mergedAttrs.Contains(attr) {
+			overridden = append(overridden, attr)
+		}
+	}
+	if len(overridden) > 0 {
+		logger.Warningf("ignoring environment constraints overridden by conflicting constraints: %s", strings.Join(overridden, ","))
+	}
+	if unsupported, _ := validator.Validate(merged); len(unsupported) > 0 {
+		logger.Warningf("ignoring unsupported constraints: %s", strings.Join(unsupported, ","))
+	}
 }
 
-// validateConstraints returns an error if the given constraints are not valid for the
+// ValidateConstraints returns an error if the given constraints are not valid for the
 // current environment, and also any unsupported attributes.
-func (st *State) validateConstraints(cons constraints.Value) ([]string, error) {
+func (st *State) ValidateConstraints(cons constraints.Value) ([]string, error) {
 	validator, err := st.constraintsValidator()
 	if err != nil {
 		return nil, err
@@ -12,6 +12,7 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	statetxn "github.com/juju/txn"
+	"github.com/juju/utils/set"
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
@@ -56,33 +57,32 @@ func NewPortRange(unitName string, fromPort, toPort int, protocol string) (PortR
 	return p, nil
 }
 
+// NetworkPortRange returns the protocol and ports of the range,
+// without the unit that opened them.
+func (p PortRange) NetworkPortRange() network.PortRange {
+	return network.PortRange{
+		FromPort: p.FromPort,
+		ToPort:   p.ToPort,
+		Protocol: strings.ToLower(p.Protocol),
+	}
+}
+
 // IsValid checks if the port range is valid.
 func (p PortRange) IsValid() bool {
-	proto := strings.ToLower(p.Protocol)
-	if proto != "tcp" && proto != "udp" {
-		return false
-	}
 	if !names.IsValidUnit(p.UnitName) {
 		return false
 	}
-	return p.FromPort <= p.ToPort
+	return p.NetworkPortRange().Validate() == nil
 }
 
 // ConflictsWith determines if the two port ranges conflict.
+// ICMP ranges carry no port numbers, so any number of units
+// may open ICMP on the same machine.
 func (a PortRange) ConflictsWith(b PortRange) bool {
-	if a.Protocol != b.Protocol {
+	if strings.ToLower(a.Protocol) == "icmp" {
 		return false
 	}
-	switch {
-	case a.FromPort >= b.FromPort && a.FromPort <= b.ToPort:
-		return true
-	case a.ToPort >= b.FromPort && a.ToPort <= b.ToPort:
-		return true
-	case a.FromPort <= b.FromPort && a.ToPort >= b.ToPort:
-		return true
-	}
-
-	return false
+	return a.NetworkPortRange().ConflictsWith(b.NetworkPortRange())
 }
 
 func (p PortRange) String() string {
@@ -326,6 +326,60 @@ func (p *Ports) PortsForUnit(unit string) []PortRange {
 	return ports
 }
 
+// movePortsOps returns the operations that move the port ranges opened
+// by the given units from the ports document of one machine to that of
+// another. It fails if any of the ranges conflicts with a range already
+// opened on the other machine.
+func movePortsOps(st *State, fromMachineId, toMachineId string, unitNames ...string) ([]txn.Op, error) {
+	from, err := getPorts(st, fromMachineId)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	units := set.NewStrings(unitNames...)
+	var moved, kept []PortRange
+	for _, port := range from.doc.Ports {
+		if units.Contains(port.UnitName) {
+			moved = append(moved, port)
+		} else {
+			kept = append(kept, port)
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+	to, err := getOrCreatePorts(st, toMachineId)
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range moved {
+		if !to.canOpenPorts(port) {
+			return nil, fmt.Errorf("cannot open ports %v on machine %v due to conflict", port, toMachineId)
+		}
+	}
+	ops := []txn.Op{{
+		C:      openedPortsC,
+		Id:     from.Id(),
+		Assert: bson.D{{"txn-revno", from.doc.TxnRevno}},
+		Update: bson.D{{"$set", bson.D{{"ports", kept}}}},
+	}}
+	if to.new {
+		return append(ops, txn.Op{
+			C:      openedPortsC,
+			Id:     to.Id(),
+			Assert: txn.DocMissing,
+			Insert: portsDoc{Id: to.Id(), Ports: moved},
+		}), nil
+	}
+	return append(ops, txn.Op{
+		C:      openedPortsC,
+		Id:     to.Id(),
+		Assert: bson.D{{"txn-revno", to.doc.TxnRevno}},
+		Update: bson.D{{"$set", bson.D{{"ports", append(to.doc.Ports, moved...)}}}},
+	}), nil
+}
+
 // Refresh refreshes the port document from state.
 func (p *Ports) Refresh() error {
 	openedPorts, closer := p.st.getCollection(openedPortsC)
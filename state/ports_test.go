@@ -186,6 +186,11 @@ func (p *PortRangeSuite) TestPortRangeConflicts(c *gc.C) {
 		state.PortRange{"wordpress/0", 100, 200, "TCP"},
 		state.PortRange{"wordpress/0", 120, 140, "TCP"},
 		true,
+	}, {
+		"icmp never conflicts",
+		state.PortRange{"wordpress/0", -1, -1, "icmp"},
+		state.PortRange{"mysql/0", -1, -1, "icmp"},
+		false,
 	}}
 
 	for i, t := range testCases {
@@ -233,6 +238,22 @@ func (p *PortRangeSuite) TestPortRangeValidity(c *gc.C) {
 		"invalid unit",
 		state.PortRange{"invalid unit", 80, 80, "tcp"},
 		false,
+	}, {
+		"port number too low",
+		state.PortRange{"wordpress/0", 0, 80, "tcp"},
+		false,
+	}, {
+		"port number too high",
+		state.PortRange{"wordpress/0", 80, 65536, "udp"},
+		false,
+	}, {
+		"valid icmp",
+		state.PortRange{"wordpress/0", -1, -1, "ICMP"},
+		true,
+	}, {
+		"icmp with port numbers",
+		state.PortRange{"wordpress/0", 80, 80, "icmp"},
+		false,
 	}}
 
 	for i, t := range testCases {
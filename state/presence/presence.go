@@ -73,6 +73,9 @@ type Watcher struct {
 	// watches has the per-key observer channels from Watch/Unwatch.
 	watches map[string][]chan<- Change
 
+	// allWatches has the observer channels from WatchAll/UnwatchAll.
+	allWatches []chan<- Change
+
 	// pending contains all the events to be dispatched to the watcher
 	// channels. They're queued during processing and flushed at the
 	// end to simplify the algorithm.
@@ -150,6 +153,14 @@ type reqUnwatch struct {
 	ch  chan<- Change
 }
 
+type reqWatchAll struct {
+	ch chan<- Change
+}
+
+type reqUnwatchAll struct {
+	ch chan<- Change
+}
+
 type reqSync struct {
 	done chan bool
 }
@@ -180,6 +191,20 @@ func (w *Watcher) Unwatch(key string, ch chan<- Change) {
 	w.sendReq(reqUnwatch{key, ch})
 }
 
+// WatchAll starts watching the liveness of all keys. From then on,
+// an event will be sent onto ch whenever a key is found to have
+// become alive or dead; no events report the initial status of keys.
+// Change values sent to the channel must be consumed, or the whole
+// watcher will blocked.
+func (w *Watcher) WatchAll(ch chan<- Change) {
+	w.sendReq(reqWatchAll{ch})
+}
+
+// UnwatchAll stops watching the liveness of all keys via ch.
+func (w *Watcher) UnwatchAll(ch chan<- Change) {
+	w.sendReq(reqUnwatchAll{ch})
+}
+
 // StartSync forces the watcher to load new events from the database.
 func (w *Watcher) StartSync() {
 	w.sendReq(reqSync{nil})
@@ -210,6 +235,51 @@ func (w *Watcher) Alive(key string) (bool, error) {
 	return alive, nil
 }
 
+// LastSeen returns the start of the most recent time slot in which a
+// pinger for key reported that it was alive, by the database clock,
+// or the zero time if no pinger for key has ever done so. It reads
+// the pings collection rather than what w has observed, so it gives
+// the same answer wherever and whenever it is called.
+func (w *Watcher) LastSeen(key string) (time.Time, error) {
+	session := w.pings.Database.Session.Copy()
+	defer session.Close()
+	beings := w.beings.With(session)
+	pings := w.pings.With(session)
+
+	var seqs []beingInfo
+	if err := beings.Find(bson.D{{"key", key}}).All(&seqs); err != nil {
+		return time.Time{}, err
+	}
+	if len(seqs) == 0 {
+		return time.Time{}, nil
+	}
+	// Pingers for key may have used any of its sequences; find
+	// the latest slot in which any of them reported.
+	bits := make(map[string]int64)
+	var sel []bson.D
+	for _, being := range seqs {
+		field := fmt.Sprintf("%x", being.Seq/63)
+		if _, ok := bits[field]; !ok {
+			sel = append(sel, bson.D{{"alive." + field, bson.D{{"$exists", true}}}})
+		}
+		bits[field] |= 1 << uint64(being.Seq%63)
+	}
+	iter := pings.Find(bson.D{{"$or", sel}}).Sort("-_id").Iter()
+	for {
+		var ping pingInfo
+		if !iter.Next(&ping) {
+			break
+		}
+		for field, bit := range bits {
+			if ping.Alive[field]&bit != 0 {
+				iter.Close()
+				return time.Unix(ping.Slot, 0), nil
+			}
+		}
+	}
+	return time.Time{}, iter.Close()
+}
+
 // period is the length of each time slot in seconds.
 // It's not a time.Duration because the code is more convenient like
 // this and also because sub-second timings don't work as the slot
@@ -299,6 +369,27 @@ func (w *Watcher) handle(req interface{}) {
 				e.ch = nil
 			}
 		}
+	case reqWatchAll:
+		for _, ch := range w.allWatches {
+			if ch == r.ch {
+				panic("adding channel twice to watch all keys")
+			}
+		}
+		w.allWatches = append(w.allWatches, r.ch)
+	case reqUnwatchAll:
+		for i, ch := range w.allWatches {
+			if ch == r.ch {
+				w.allWatches[i] = w.allWatches[len(w.allWatches)-1]
+				w.allWatches = w.allWatches[:len(w.allWatches)-1]
+				break
+			}
+		}
+		for i := range w.pending {
+			e := &w.pending[i]
+			if e.ch == r.ch {
+				e.ch = nil
+			}
+		}
 	case reqAlive:
 		_, alive := w.beingSeq[r.key]
 		r.result <- alive
@@ -429,9 +520,7 @@ func (w *Watcher) sync() error {
 					continue
 				}
 				logger.Tracef("found seq=%d alive with key %q", seq, being.Key)
-				for _, ch := range w.watches[being.Key] {
-					w.pending = append(w.pending, event{ch, being.Key, true})
-				}
+				w.queue(being.Key, true)
 			}
 		}
 	}
@@ -443,14 +532,23 @@ func (w *Watcher) sync() error {
 		if dead[seq] || !alive[seq] {
 			delete(w.beingKey, seq)
 			delete(w.beingSeq, key)
-			for _, ch := range w.watches[key] {
-				w.pending = append(w.pending, event{ch, key, false})
-			}
+			w.queue(key, false)
 		}
 	}
 	return nil
 }
 
+// queue queues an event reporting the liveness of key for all
+// channels observing it.
+func (w *Watcher) queue(key string, alive bool) {
+	for _, ch := range w.watches[key] {
+		w.pending = append(w.pending, event{ch, key, alive})
+	}
+	for _, ch := range w.allWatches {
+		w.pending = append(w.pending, event{ch, key, alive})
+	}
+}
+
 // Pinger periodically reports that a specific key is alive, so that
 // watchers interested on that fact can react appropriately.
 type Pinger struct {
@@ -189,6 +189,77 @@ func (s *PresenceSuite) TestWorkflow(c *gc.C) {
 	c.Assert(w.Stop(), gc.IsNil)
 }
 
+func (s *PresenceSuite) TestWatchAll(c *gc.C) {
+	w := presence.NewWatcher(s.presence)
+	pa := presence.NewPinger(s.presence, "a")
+	pb := presence.NewPinger(s.presence, "b")
+	defer w.Stop()
+	defer pa.Stop()
+	defer pb.Stop()
+
+	ch := make(chan presence.Change, 2)
+	w.WatchAll(ch)
+
+	// No initial events are sent.
+	w.StartSync()
+	assertNoChange(c, ch)
+
+	c.Assert(pa.Start(), gc.IsNil)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", true})
+	assertNoChange(c, ch)
+
+	c.Assert(pb.Start(), gc.IsNil)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"b", true})
+	assertNoChange(c, ch)
+
+	c.Assert(pa.Kill(), gc.IsNil)
+	w.StartSync()
+	assertChange(c, ch, presence.Change{"a", false})
+	assertNoChange(c, ch)
+
+	// Changes after unwatching are not observed.
+	w.UnwatchAll(ch)
+	c.Assert(pb.Kill(), gc.IsNil)
+	w.StartSync()
+	assertNoChange(c, ch)
+}
+
+func (s *PresenceSuite) TestLastSeen(c *gc.C) {
+	w := presence.NewWatcher(s.presence)
+	p := presence.NewPinger(s.presence, "a")
+	defer w.Stop()
+	defer p.Stop()
+
+	lastSeen, err := w.LastSeen("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.IsZero(), gc.Equals, true)
+
+	c.Assert(p.Start(), gc.IsNil)
+	firstSeen, err := w.LastSeen("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(firstSeen.IsZero(), gc.Equals, false)
+
+	// A later pinger for the same key is seen in a later slot.
+	c.Assert(p.Kill(), gc.IsNil)
+	presence.FakeTimeSlot(1)
+	c.Assert(p.Start(), gc.IsNil)
+	c.Assert(p.Kill(), gc.IsNil)
+	lastSeen, err = w.LastSeen("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.Sub(firstSeen), gc.Equals, 30*time.Second)
+
+	// The time it was last seen remains once the pinger has gone,
+	// and is the same for any watcher, even a new one.
+	presence.FakeTimeSlot(3)
+	other := presence.NewWatcher(s.presence)
+	defer other.Stop()
+	otherSeen, err := other.LastSeen("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(otherSeen, gc.Equals, lastSeen)
+}
+
 func (s *PresenceSuite) TestScale(c *gc.C) {
 	const N = 1000
 	var ps []*presence.Pinger
@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+// scheduledTaskDoc records the definition of a task run periodically
+// by the state servers, and the outcome of its most recent run.
+type scheduledTaskDoc struct {
+	Name      string `bson:"_id"`
+	Interval  time.Duration
+	Enabled   bool
+	LastRun   time.Time `bson:",omitempty"`
+	LastError string    `bson:",omitempty"`
+}
+
+// ScheduledTask represents a task run periodically by the state servers.
+type ScheduledTask struct {
+	st  *State
+	doc scheduledTaskDoc
+}
+
+// Name returns the name of the task.
+func (t *ScheduledTask) Name() string {
+	return t.doc.Name
+}
+
+// Interval returns the time to wait between runs of the task.
+func (t *ScheduledTask) Interval() time.Duration {
+	return t.doc.Interval
+}
+
+// Enabled returns whether the task should be run.
+func (t *ScheduledTask) Enabled() bool {
+	return t.doc.Enabled
+}
+
+// LastRun returns the time the task was last run, or the zero time if
+// it has never been run.
+func (t *ScheduledTask) LastRun() time.Time {
+	return t.doc.LastRun
+}
+
+// LastError returns the error returned by the last run of the task, or
+// the empty string if it succeeded.
+func (t *ScheduledTask) LastError() string {
+	return t.doc.LastError
+}
+
+// Refresh refreshes the contents of the task from the underlying state.
+func (t *ScheduledTask) Refresh() error {
+	task, err := t.st.ScheduledTask(t.doc.Name)
+	if err != nil {
+		return err
+	}
+	t.doc = task.doc
+	return nil
+}
+
+// SetEnabled sets whether the task should be run.
+func (t *ScheduledTask) SetEnabled(enabled bool) error {
+	ops := []txn.Op{{
+		C:      scheduledTasksC,
+		Id:     t.doc.Name,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{{"enabled", enabled}}}},
+	}}
+	if err := t.st.runTransaction(ops); err != nil {
+		err = onAbort(err, errors.NotFoundf("scheduled task %q", t.doc.Name))
+		return errors.Annotatef(err, "cannot set enabled for scheduled task %q", t.doc.Name)
+	}
+	t.doc.Enabled = enabled
+	return nil
+}
+
+// SetLastRun records the time at which the task was last run, and the
+// error it returned, if any.
+func (t *ScheduledTask) SetLastRun(when time.Time, runErr error) error {
+	var lastError string
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	ops := []txn.Op{{
+		C:      scheduledTasksC,
+		Id:     t.doc.Name,
+		Assert: txn.DocExists,
+		Update: bson.D{{"$set", bson.D{
+			{"lastrun", when},
+			{"lasterror", lastError},
+		}}},
+	}}
+	if err := t.st.runTransaction(ops); err != nil {
+		err = onAbort(err, errors.NotFoundf("scheduled task %q", t.doc.Name))
+		return errors.Annotatef(err, "cannot record run of scheduled task %q", t.doc.Name)
+	}
+	t.doc.LastRun = when
+	t.doc.LastError = lastError
+	return nil
+}
+
+// EnsureScheduledTask returns the task with the given name, first adding
+// it, enabled and with the given interval, if it does not already exist.
+// The definition of an existing task is left untouched, so that changes
+// made through SetEnabled persist.
+func (st *State) EnsureScheduledTask(name string, interval time.Duration) (*ScheduledTask, error) {
+	doc := scheduledTaskDoc{
+		Name:     name,
+		Interval: interval,
+		Enabled:  true,
+	}
+	ops := []txn.Op{{
+		C:      scheduledTasksC,
+		Id:     name,
+		Assert: txn.DocMissing,
+		Insert: &doc,
+	}}
+	switch err := st.runTransaction(ops); err {
+	case nil:
+		return &ScheduledTask{st: st, doc: doc}, nil
+	case txn.ErrAborted:
+		return st.ScheduledTask(name)
+	default:
+		return nil, errors.Annotatef(err, "cannot add scheduled task %q", name)
+	}
+}
+
+// ScheduledTask returns the task with the given name.
+func (st *State) ScheduledTask(name string) (*ScheduledTask, error) {
+	tasks, closer := st.getCollection(scheduledTasksC)
+	defer closer()
+
+	var doc scheduledTaskDoc
+	err := tasks.FindId(name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("scheduled task %q", name)
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "cannot get scheduled task %q", name)
+	}
+	return &ScheduledTask{st: st, doc: doc}, nil
+}
+
+// AllScheduledTasks returns all the scheduled tasks, ordered by name.
+func (st *State) AllScheduledTasks() ([]*ScheduledTask, error) {
+	tasks, closer := st.getCollection(scheduledTasksC)
+	defer closer()
+
+	var docs []scheduledTaskDoc
+	if err := tasks.Find(nil).Sort("_id").All(&docs); err != nil {
+		return nil, errors.Annotate(err, "cannot get all scheduled tasks")
+	}
+	result := make([]*ScheduledTask, len(docs))
+	for i, doc := range docs {
+		result[i] = &ScheduledTask{st: st, doc: doc}
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+)
+
+type ScheduledTaskSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&ScheduledTaskSuite{})
+
+func (s *ScheduledTaskSuite) TestEnsureScheduledTask(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Name(), gc.Equals, "prune")
+	c.Assert(task.Interval(), gc.Equals, time.Hour)
+	c.Assert(task.Enabled(), jc.IsTrue)
+	c.Assert(task.LastRun().IsZero(), jc.IsTrue)
+	c.Assert(task.LastError(), gc.Equals, "")
+
+	// An existing definition is left untouched.
+	err = task.SetEnabled(false)
+	c.Assert(err, gc.IsNil)
+	task, err = s.State.EnsureScheduledTask("prune", time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Interval(), gc.Equals, time.Hour)
+	c.Assert(task.Enabled(), jc.IsFalse)
+}
+
+func (s *ScheduledTaskSuite) TestScheduledTaskNotFound(c *gc.C) {
+	_, err := s.State.ScheduledTask("missing")
+	c.Assert(err, gc.ErrorMatches, `scheduled task "missing" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *ScheduledTaskSuite) TestAllScheduledTasks(c *gc.C) {
+	tasks, err := s.State.AllScheduledTasks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tasks, gc.HasLen, 0)
+
+	_, err = s.State.EnsureScheduledTask("zebra", time.Hour)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.EnsureScheduledTask("aardvark", time.Minute)
+	c.Assert(err, gc.IsNil)
+	tasks, err = s.State.AllScheduledTasks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(tasks, gc.HasLen, 2)
+	c.Assert(tasks[0].Name(), gc.Equals, "aardvark")
+	c.Assert(tasks[1].Name(), gc.Equals, "zebra")
+}
+
+func (s *ScheduledTaskSuite) TestSetEnabled(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+	err = task.SetEnabled(false)
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsFalse)
+
+	task, err = s.State.ScheduledTask("prune")
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsFalse)
+	err = task.SetEnabled(true)
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.Enabled(), jc.IsTrue)
+}
+
+func (s *ScheduledTaskSuite) TestSetLastRun(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("prune", time.Hour)
+	c.Assert(err, gc.IsNil)
+	// Mongo stores times with millisecond precision.
+	when := time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	err = task.SetLastRun(when, fmt.Errorf("boom"))
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.LastRun().Equal(when), jc.IsTrue)
+	c.Assert(task.LastError(), gc.Equals, "boom")
+
+	err = task.SetLastRun(when.Add(time.Hour), nil)
+	c.Assert(err, gc.IsNil)
+	err = task.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.LastRun().Equal(when.Add(time.Hour)), jc.IsTrue)
+	c.Assert(task.LastError(), gc.Equals, "")
+}
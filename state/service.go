@@ -14,6 +14,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/juju/names"
 	jujutxn "github.com/juju/txn"
+	"github.com/juju/utils/set"
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
@@ -45,6 +46,12 @@ type serviceDoc struct {
 	MinUnits      int
 	OwnerTag      string
 	TxnRevno      int64 `bson:"txn-revno"`
+
+	// PinnedCharmURL and PinnedUnits record a staged charm upgrade:
+	// the named units stay on PinnedCharmURL, the service's charm
+	// before the upgrade, while the rest use CharmURL.
+	PinnedCharmURL *charm.URL `bson:",omitempty"`
+	PinnedUnits    []string   `bson:",omitempty"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -286,6 +293,31 @@ func (s *Service) CharmURL() (curl *charm.URL, force bool) {
 	return s.doc.CharmURL, s.doc.ForceCharm
 }
 
+// CharmURLForUnit returns the charm URL the named unit should use, and
+// whether the unit should upgrade to it even if in an error state. This
+// is the service's charm, unless a staged upgrade has pinned the unit
+// to the charm the service had before.
+func (s *Service) CharmURLForUnit(unitName string) (curl *charm.URL, force bool) {
+	if s.doc.PinnedCharmURL != nil {
+		for _, name := range s.doc.PinnedUnits {
+			if name == unitName {
+				return s.doc.PinnedCharmURL, false
+			}
+		}
+	}
+	return s.doc.CharmURL, s.doc.ForceCharm
+}
+
+// PinnedUnits returns the charm URL that a staged upgrade has pinned
+// units to, and the names of those units. The URL is nil if no staged
+// upgrade is in progress.
+func (s *Service) PinnedUnits() (*charm.URL, []string) {
+	if s.doc.PinnedCharmURL == nil {
+		return nil, nil
+	}
+	return s.doc.PinnedCharmURL, append([]string(nil), s.doc.PinnedUnits...)
+}
+
 // Endpoints returns the service's currently available relation endpoints.
 func (s *Service) Endpoints() (eps []Endpoint, err error) {
 	ch, _, err := s.Charm()
@@ -425,7 +457,11 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 			C:      servicesC,
 			Id:     s.doc.Name,
 			Assert: append(isAliveDoc, differentCharm...),
-			Update: bson.D{{"$set", bson.D{{"charmurl", ch.URL()}, {"forcecharm", force}}}},
+			Update: bson.D{
+				{"$set", bson.D{{"charmurl", ch.URL()}, {"forcecharm", force}}},
+				// Any staged upgrade in progress is superseded.
+				{"$unset", bson.D{{"pinnedcharmurl", nil}, {"pinnedunits", nil}}},
+			},
 		},
 	}
 	// Add any extra peer relations that need creation.
@@ -464,8 +500,21 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 
 // SetCharm changes the charm for the service. New units will be started with
 // this charm, and existing units will be upgraded to use it. If force is true,
-// units will be upgraded even if they are in an error state.
-func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
+// units will be upgraded even if they are in an error state. Any staged
+// upgrade in progress is abandoned.
+func (s *Service) SetCharm(ch *Charm, force bool) error {
+	return s.setCharm(ch, force, false, nil)
+}
+
+// SetCharmStaged changes the charm for the service as SetCharm does, but
+// only new units and the named canary units are upgraded straight away.
+// The service's other units stay pinned to its current charm until they
+// are released with ReleaseUnits or ReleaseAllUnits.
+func (s *Service) SetCharmStaged(ch *Charm, force bool, canaries []string) error {
+	return s.setCharm(ch, force, true, canaries)
+}
+
+func (s *Service) setCharm(ch *Charm, force, staged bool, canaries []string) (err error) {
 	services, closer := s.st.getCollection(servicesC)
 	defer closer()
 	settings := services.Database.C(settingsC)
@@ -476,6 +525,7 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 	if ch.URL().Series != s.doc.Series {
 		return fmt.Errorf("cannot change a service's series")
 	}
+	var pinned []string
 	buildTxn := func(attempt int) ([]txn.Op, error) {
 		if attempt > 0 {
 			// If the service is not alive, fail out immediately; otherwise,
@@ -486,11 +536,21 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 				return nil, fmt.Errorf("service %q is not alive", s.doc.Name)
 			}
 		}
+		if staged {
+			sel := bson.D{{"_id", s.doc.Name}, {"pinnedcharmurl", bson.D{{"$exists", true}}}}
+			if count, err := services.Find(sel).Count(); err != nil {
+				return nil, err
+			} else if count == 1 {
+				return nil, fmt.Errorf("service %q already has a staged upgrade in progress", s.doc.Name)
+			}
+		}
 		// Make sure the service doesn't have this charm already.
 		sel := bson.D{{"_id", s.doc.Name}, {"charmurl", ch.URL()}}
 		var ops []txn.Op
 		if count, err := services.Find(sel).Count(); err != nil {
 			return nil, err
+		} else if count == 1 && staged {
+			return nil, fmt.Errorf("service %q already uses charm %q", s.doc.Name, ch.URL())
 		} else if count == 1 {
 			// Charm URL already set; just update the force flag.
 			sameCharm := bson.D{{"charmurl", ch.URL()}}
@@ -507,9 +567,25 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 				return nil, err
 			}
 		}
+		pinned = nil
+		if staged {
+			var pinOps []txn.Op
+			pinOps, pinned, err = s.pinUnitsOps(canaries)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, pinOps...)
+		}
 		return ops, nil
 	}
 	if err = s.st.run(buildTxn); err == nil {
+		if len(pinned) > 0 {
+			s.doc.PinnedCharmURL = s.doc.CharmURL
+			s.doc.PinnedUnits = pinned
+		} else {
+			s.doc.PinnedCharmURL = nil
+			s.doc.PinnedUnits = nil
+		}
 		s.doc.CharmURL = ch.URL()
 		s.doc.ForceCharm = force
 		return nil
@@ -517,6 +593,92 @@ func (s *Service) SetCharm(ch *Charm, force bool) (err error) {
 	return err
 }
 
+// pinUnitsOps returns the operations needed to pin all the service's
+// units except the named canaries to its current charm, along with
+// the names of the pinned units.
+func (s *Service) pinUnitsOps(canaries []string) ([]txn.Op, []string, error) {
+	units, err := s.AllUnits()
+	if err != nil {
+		return nil, nil, err
+	}
+	isCanary := set.NewStrings(canaries...)
+	var pinned []string
+	for _, unit := range units {
+		if isCanary.Contains(unit.Name()) {
+			isCanary.Remove(unit.Name())
+		} else {
+			pinned = append(pinned, unit.Name())
+		}
+	}
+	if !isCanary.IsEmpty() {
+		return nil, nil, fmt.Errorf("unit %q is not a unit of service %q", isCanary.SortedValues()[0], s.doc.Name)
+	}
+	if len(pinned) == 0 {
+		// Every unit is a canary, so there is nothing to stage.
+		return nil, nil, nil
+	}
+	notStaged := bson.D{{"pinnedcharmurl", bson.D{{"$exists", false}}}}
+	sameUnits := bson.D{{"unitcount", len(units)}}
+	return []txn.Op{{
+		C:      servicesC,
+		Id:     s.doc.Name,
+		Assert: append(notStaged, sameUnits...),
+		Update: bson.D{{"$set", bson.D{
+			{"pinnedcharmurl", s.doc.CharmURL},
+			{"pinnedunits", pinned},
+		}}},
+	}}, pinned, nil
+}
+
+// ReleaseUnits advances a staged upgrade by releasing the named units
+// from their pin, so that they upgrade to the service's charm. Names
+// of units that are not pinned are ignored. The staged upgrade is
+// complete once no units remain pinned.
+func (s *Service) ReleaseUnits(unitNames []string) error {
+	release := set.NewStrings(unitNames...)
+	return s.releaseUnits(release.Contains)
+}
+
+// ReleaseAllUnits completes a staged upgrade by releasing all the units
+// still pinned.
+func (s *Service) ReleaseAllUnits() error {
+	return s.releaseUnits(func(string) bool { return true })
+}
+
+func (s *Service) releaseUnits(release func(unitName string) bool) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if err := s.Refresh(); err != nil {
+			return nil, err
+		}
+		if s.doc.PinnedCharmURL == nil {
+			return nil, fmt.Errorf("no staged upgrade in progress")
+		}
+		var remaining []string
+		for _, name := range s.doc.PinnedUnits {
+			if !release(name) {
+				remaining = append(remaining, name)
+			}
+		}
+		if len(remaining) == len(s.doc.PinnedUnits) {
+			return nil, jujutxn.ErrNoOperations
+		}
+		update := bson.D{{"$set", bson.D{{"pinnedunits", remaining}}}}
+		if len(remaining) == 0 {
+			update = bson.D{{"$unset", bson.D{{"pinnedcharmurl", nil}, {"pinnedunits", nil}}}}
+		}
+		return []txn.Op{{
+			C:      servicesC,
+			Id:     s.doc.Name,
+			Assert: bson.D{{"txn-revno", s.doc.TxnRevno}},
+			Update: update,
+		}}, nil
+	}
+	if err := s.st.run(buildTxn); err != nil {
+		return fmt.Errorf("cannot release units of service %q: %v", s, err)
+	}
+	return s.Refresh()
+}
+
 // String returns the service name.
 func (s *Service) String() string {
 	return s.doc.Name
@@ -821,7 +983,7 @@ func (s *Service) Constraints() (constraints.Value, error) {
 
 // SetConstraints replaces the current service constraints.
 func (s *Service) SetConstraints(cons constraints.Value) (err error) {
-	unsupported, err := s.st.validateConstraints(cons)
+	unsupported, err := s.st.ValidateConstraints(cons)
 	if len(unsupported) > 0 {
 		logger.Warningf(
 			"setting constraints on service %q: unsupported constraints: %v", s.Name(), strings.Join(unsupported, ","))
@@ -80,6 +80,105 @@ func (s *ServiceSuite) TestSetCharmErrors(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "cannot change a service's series")
 }
 
+func (s *ServiceSuite) TestSetCharmStaged(c *gc.C) {
+	var units []*state.Unit
+	for i := 0; i < 3; i++ {
+		unit, err := s.mysql.AddUnit()
+		c.Assert(err, gc.IsNil)
+		units = append(units, unit)
+	}
+	sch := s.AddMetaCharm(c, "mysql", metaBase, 2)
+	err := s.mysql.SetCharmStaged(sch, true, []string{"mysql/1"})
+	c.Assert(err, gc.IsNil)
+
+	assertStaged := func(pinned ...string) {
+		for _, svc := range []*state.Service{s.mysql, s.refreshedService(c)} {
+			url, force := svc.CharmURL()
+			c.Assert(url, gc.DeepEquals, sch.URL())
+			c.Assert(force, gc.Equals, true)
+			pinnedURL, pinnedUnits := svc.PinnedUnits()
+			if len(pinned) == 0 {
+				c.Assert(pinnedURL, gc.IsNil)
+				c.Assert(pinnedUnits, gc.HasLen, 0)
+			} else {
+				c.Assert(pinnedURL, gc.DeepEquals, s.charm.URL())
+				c.Assert(pinnedUnits, gc.DeepEquals, pinned)
+			}
+			for _, unit := range units {
+				expectURL, expectForce := sch.URL(), true
+				for _, name := range pinned {
+					if unit.Name() == name {
+						expectURL, expectForce = s.charm.URL(), false
+					}
+				}
+				url, force := svc.CharmURLForUnit(unit.Name())
+				c.Check(url, gc.DeepEquals, expectURL)
+				c.Check(force, gc.Equals, expectForce)
+			}
+		}
+	}
+	assertStaged("mysql/0", "mysql/2")
+
+	// New units use the new charm.
+	url, _ := s.mysql.CharmURLForUnit("mysql/3")
+	c.Assert(url, gc.DeepEquals, sch.URL())
+
+	// Only one staged upgrade may be in progress.
+	other := s.AddMetaCharm(c, "mysql", metaBase, 3)
+	err = s.mysql.SetCharmStaged(other, false, nil)
+	c.Assert(err, gc.ErrorMatches, `service "mysql" already has a staged upgrade in progress`)
+
+	err = s.mysql.ReleaseUnits([]string{"mysql/2", "mysql/42"})
+	c.Assert(err, gc.IsNil)
+	assertStaged("mysql/0")
+
+	err = s.mysql.ReleaseUnits([]string{"mysql/0"})
+	c.Assert(err, gc.IsNil)
+	assertStaged()
+
+	err = s.mysql.ReleaseAllUnits()
+	c.Assert(err, gc.ErrorMatches, `cannot release units of service "mysql": no staged upgrade in progress`)
+}
+
+func (s *ServiceSuite) TestSetCharmStagedErrors(c *gc.C) {
+	_, err := s.mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.mysql.SetCharmStaged(s.charm, false, nil)
+	c.Assert(err, gc.ErrorMatches, `service "mysql" already uses charm ".*"`)
+
+	sch := s.AddMetaCharm(c, "mysql", metaBase, 2)
+	err = s.mysql.SetCharmStaged(sch, false, []string{"wordpress/0"})
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/0" is not a unit of service "mysql"`)
+}
+
+func (s *ServiceSuite) TestSetCharmAbandonsStagedUpgrade(c *gc.C) {
+	_, err := s.mysql.AddUnit()
+	c.Assert(err, gc.IsNil)
+	sch := s.AddMetaCharm(c, "mysql", metaBase, 2)
+	err = s.mysql.SetCharmStaged(sch, false, nil)
+	c.Assert(err, gc.IsNil)
+	pinnedURL, pinned := s.mysql.PinnedUnits()
+	c.Assert(pinnedURL, gc.DeepEquals, s.charm.URL())
+	c.Assert(pinned, gc.DeepEquals, []string{"mysql/0"})
+
+	other := s.AddMetaCharm(c, "mysql", metaBase, 3)
+	err = s.mysql.SetCharm(other, false)
+	c.Assert(err, gc.IsNil)
+	for _, svc := range []*state.Service{s.mysql, s.refreshedService(c)} {
+		pinnedURL, pinned = svc.PinnedUnits()
+		c.Assert(pinnedURL, gc.IsNil)
+		c.Assert(pinned, gc.HasLen, 0)
+		url, _ := svc.CharmURLForUnit("mysql/0")
+		c.Assert(url, gc.DeepEquals, other.URL())
+	}
+}
+
+func (s *ServiceSuite) refreshedService(c *gc.C) *state.Service {
+	svc, err := s.State.Service(s.mysql.Name())
+	c.Assert(err, gc.IsNil)
+	return svc
+}
+
 var metaBase = `
 name: mysql
 summary: "Fake MySQL Database engine"
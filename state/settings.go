@@ -80,6 +80,12 @@ type Settings struct {
 	txnRevno int64
 }
 
+// Version returns the generation of the settings as of the last
+// read. It increases each time the settings are changed.
+func (c *Settings) Version() int64 {
+	return c.txnRevno
+}
+
 // Keys returns the current keys in alphabetical order.
 func (c *Settings) Keys() []string {
 	keys := []string{}
@@ -315,6 +321,21 @@ func replaceSettingsOp(st *State, key string, values map[string]interface{}) (tx
 	if err != nil {
 		return txn.Op{}, nil, err
 	}
+	op := s.replaceOp(values)
+	assertFailed := func() (bool, error) {
+		latest, err := readSettings(st, key)
+		if err != nil {
+			return false, err
+		}
+		return latest.txnRevno != s.txnRevno, nil
+	}
+	return op, assertFailed, nil
+}
+
+// replaceOp returns a txn.Op that replaces the contents of the
+// settings with the supplied values, as long as they have not changed
+// since they were last read.
+func (s *Settings) replaceOp(values map[string]interface{}) txn.Op {
 	deletes := map[string]int{}
 	for k := range s.disk {
 		if _, found := values[k]; !found {
@@ -327,14 +348,7 @@ func replaceSettingsOp(st *State, key string, values map[string]interface{}) (tx
 		{"$set", newValues},
 		{"$unset", deletes},
 	}
-	assertFailed := func() (bool, error) {
-		latest, err := readSettings(st, key)
-		if err != nil {
-			return false, err
-		}
-		return latest.txnRevno != s.txnRevno, nil
-	}
-	return op, assertFailed, nil
+	return op
 }
 
 func (s *Settings) assertUnchangedOp() txn.Op {
@@ -0,0 +1,258 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/juju/names"
+	jujutxn "github.com/juju/txn"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/juju/version"
+)
+
+// stagedUpgradeKey identifies the document holding the
+// environment's staged upgrade, if any.
+const stagedUpgradeKey = "staged"
+
+// stagedUpgradeDoc records an agent version whose tools machine
+// agents download before the environment is upgraded to it.
+type stagedUpgradeDoc struct {
+	Id          string `bson:"_id"`
+	Version     version.Number
+	ReadyAgents []string
+	TxnRevno    int64 `bson:"txn-revno"`
+}
+
+// StagedUpgrade represents an upgrade in its download phase: every
+// machine agent fetches and verifies the tools of the staged version,
+// without restarting, and reports when it is ready. The upgrade is
+// started by ActivateStagedUpgrade once every agent is ready.
+type StagedUpgrade struct {
+	st  *State
+	doc stagedUpgradeDoc
+}
+
+// Version returns the agent version being staged.
+func (u *StagedUpgrade) Version() version.Number {
+	return u.doc.Version
+}
+
+// ReadyAgents returns the tags of the agents that have reported
+// the staged version's tools ready.
+func (u *StagedUpgrade) ReadyAgents() []string {
+	return u.doc.ReadyAgents
+}
+
+// StagedUpgrade returns the environment's staged upgrade. It returns
+// a not found error if no upgrade is staged.
+func (st *State) StagedUpgrade() (*StagedUpgrade, error) {
+	stagedUpgrades, closer := st.getCollection(stagedUpgradesC)
+	defer closer()
+
+	var doc stagedUpgradeDoc
+	err := stagedUpgrades.FindId(stagedUpgradeKey).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("staged upgrade")
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get staged upgrade: %v", err)
+	}
+	return &StagedUpgrade{st, doc}, nil
+}
+
+// StageAgentVersion starts the download phase of an upgrade of the
+// environment to the given agent version, replacing any upgrade
+// already staged. It fails for the same reasons as
+// SetEnvironAgentVersion.
+func (st *State) StageAgentVersion(newVersion version.Number) error {
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		settings, err := readSettings(st, environGlobalKey)
+		if err != nil {
+			return nil, err
+		}
+		currentVersion, ok := settings.Get("agent-version")
+		if !ok {
+			return nil, fmt.Errorf("no agent version set in the environment")
+		}
+		currentString, ok := currentVersion.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid agent version format: expected string, got %v", currentVersion)
+		}
+		current, err := version.Parse(currentString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent version %q: %v", currentString, err)
+		}
+		if newVersion == current {
+			return nil, fmt.Errorf("environment is already running version %s", current)
+		}
+		if newVersion.Major < current.Major {
+			return nil, &majorVersionDowngradeError{current, newVersion}
+		}
+		if err := st.checkCanUpgrade(currentString, newVersion.String()); err != nil {
+			return nil, err
+		}
+		ops := []txn.Op{{
+			C:      settingsC,
+			Id:     environGlobalKey,
+			Assert: bson.D{{"txn-revno", settings.txnRevno}},
+		}}
+		staged, err := st.StagedUpgrade()
+		if errors.IsNotFound(err) {
+			return append(ops, txn.Op{
+				C:      stagedUpgradesC,
+				Id:     stagedUpgradeKey,
+				Assert: txn.DocMissing,
+				Insert: &stagedUpgradeDoc{
+					Id:      stagedUpgradeKey,
+					Version: newVersion,
+				},
+			}), nil
+		} else if err != nil {
+			return nil, err
+		}
+		if staged.doc.Version == newVersion {
+			return nil, jujutxn.ErrNoOperations
+		}
+		return append(ops, txn.Op{
+			C:      stagedUpgradesC,
+			Id:     stagedUpgradeKey,
+			Assert: bson.D{{"txn-revno", staged.doc.TxnRevno}},
+			Update: bson.D{{"$set", bson.D{
+				{"version", newVersion},
+				{"readyagents", []string{}},
+			}}},
+		}), nil
+	}
+	return st.run(buildTxn)
+}
+
+// errStagedUpgradeChanged is returned when the staged upgrade
+// has been replaced or removed.
+var errStagedUpgradeChanged = fmt.Errorf("staged upgrade has changed")
+
+// SetAgentReady records that the agent with the given tag has
+// downloaded and verified the tools of the given version. It fails
+// if the version is not the one staged.
+func (u *StagedUpgrade) SetAgentReady(tag string, vers version.Number) (err error) {
+	defer errors.Maskf(&err, "cannot set %s ready for upgrade to %s", tag, vers)
+	if vers != u.doc.Version {
+		return errStagedUpgradeChanged
+	}
+	ops := []txn.Op{{
+		C:      stagedUpgradesC,
+		Id:     stagedUpgradeKey,
+		Assert: bson.D{{"version", vers}},
+		Update: bson.D{{"$addToSet", bson.D{{"readyagents", tag}}}},
+	}}
+	if err := onAbort(u.st.runTransaction(ops), errStagedUpgradeChanged); err != nil {
+		return err
+	}
+	for _, ready := range u.doc.ReadyAgents {
+		if ready == tag {
+			return nil
+		}
+	}
+	u.doc.ReadyAgents = append(u.doc.ReadyAgents, tag)
+	return nil
+}
+
+// PendingAgents returns the tags of the machine agents that have not
+// yet reported the staged version's tools ready, sorted by machine
+// id. Machines whose agents
+// have never started are not waited for, since they will fetch the
+// tools of the environment's version when they do.
+func (u *StagedUpgrade) PendingAgents() ([]string, error) {
+	machines, err := u.st.AllMachines()
+	if err != nil {
+		return nil, err
+	}
+	ready := make(map[string]bool)
+	for _, tag := range u.doc.ReadyAgents {
+		ready[tag] = true
+	}
+	var pending []string
+	for _, m := range machines {
+		if m.doc.Life == Dead || m.doc.Tools == nil {
+			continue
+		}
+		if m.doc.Tools.Version.Number == u.doc.Version {
+			// The agent is already running the staged version.
+			continue
+		}
+		tag := names.NewMachineTag(m.doc.Id).String()
+		if !ready[tag] {
+			pending = append(pending, tag)
+		}
+	}
+	return pending, nil
+}
+
+// Refresh refreshes the contents of the staged upgrade from the
+// underlying state. It returns a not found error if the upgrade is
+// no longer staged.
+func (u *StagedUpgrade) Refresh() error {
+	staged, err := u.st.StagedUpgrade()
+	if err != nil {
+		return err
+	}
+	u.doc = staged.doc
+	return nil
+}
+
+// ActivateStagedUpgrade ends the download phase of the staged upgrade
+// by setting the environment's agent version to the staged version,
+// so that agents restart into the tools they have downloaded. It
+// fails unless every machine agent has reported the tools ready.
+func (st *State) ActivateStagedUpgrade() error {
+	staged, err := st.StagedUpgrade()
+	if err != nil {
+		return err
+	}
+	pending, err := staged.PendingAgents()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return &agentsNotReadyError{staged.doc.Version, pending}
+	}
+	if err := st.SetEnvironAgentVersion(staged.doc.Version); err != nil {
+		return err
+	}
+	ops := []txn.Op{{
+		C:      stagedUpgradesC,
+		Id:     stagedUpgradeKey,
+		Assert: bson.D{{"version", staged.doc.Version}},
+		Remove: true,
+	}}
+	return onAbort(st.runTransaction(ops), errStagedUpgradeChanged)
+}
+
+// agentsNotReadyError indicates that a staged upgrade cannot be
+// activated because some agents have not downloaded its tools.
+type agentsNotReadyError struct {
+	version version.Number
+	agents  []string
+}
+
+func (e *agentsNotReadyError) Error() string {
+	return fmt.Sprintf("agents not ready for upgrade to %s: %s", e.version, strings.Join(e.agents, ", "))
+}
+
+// IsAgentsNotReadyError returns if the given error is
+// agentsNotReadyError.
+func IsAgentsNotReadyError(e interface{}) bool {
+	_, ok := e.(*agentsNotReadyError)
+	return ok
+}
+
+// WatchStagedUpgrade returns a NotifyWatcher that notifies when
+// an upgrade is staged, replaced or activated.
+func (st *State) WatchStagedUpgrade() NotifyWatcher {
+	return newEntityWatcher(st, stagedUpgradesC, stagedUpgradeKey)
+}
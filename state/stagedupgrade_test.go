@@ -0,0 +1,183 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"github.com/juju/errors"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	statetesting "github.com/juju/juju/state/testing"
+	"github.com/juju/juju/version"
+)
+
+type StagedUpgradeSuite struct {
+	ConnSuite
+	current  version.Number
+	machines []*state.Machine
+}
+
+var _ = gc.Suite(&StagedUpgradeSuite{})
+
+func (s *StagedUpgradeSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	current, ok := envConfig.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	s.current = current
+
+	// Add two machines running the current version.
+	s.machines = nil
+	for i := 0; i < 2; i++ {
+		m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		err = m.SetAgentVersion(version.Binary{Number: current, Series: "quantal", Arch: "amd64"})
+		c.Assert(err, gc.IsNil)
+		s.machines = append(s.machines, m)
+	}
+}
+
+func (s *StagedUpgradeSuite) assertAgentVersion(c *gc.C, vers version.Number) {
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	agentVersion, ok := envConfig.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(agentVersion, gc.Equals, vers)
+}
+
+func (s *StagedUpgradeSuite) TestNoStagedUpgrade(c *gc.C) {
+	_, err := s.State.StagedUpgrade()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+	err = s.State.ActivateStagedUpgrade()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *StagedUpgradeSuite) TestStageAgentVersion(c *gc.C) {
+	newVersion := version.MustParse("9.8.7")
+	err := s.State.StageAgentVersion(newVersion)
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.Version(), gc.Equals, newVersion)
+	c.Assert(staged.ReadyAgents(), gc.HasLen, 0)
+	pending, err := staged.PendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0", "machine-1"})
+
+	// Machines whose agents have not started are not waited for.
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	pending, err = staged.PendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0", "machine-1"})
+
+	// The environment's agent version is unchanged.
+	s.assertAgentVersion(c, s.current)
+
+	// Staging the same version again changes nothing.
+	err = staged.SetAgentReady("machine-0", newVersion)
+	c.Assert(err, gc.IsNil)
+	err = s.State.StageAgentVersion(newVersion)
+	c.Assert(err, gc.IsNil)
+	err = staged.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.ReadyAgents(), gc.DeepEquals, []string{"machine-0"})
+
+	// Staging another version resets readiness.
+	otherVersion := version.MustParse("9.8.8")
+	err = s.State.StageAgentVersion(otherVersion)
+	c.Assert(err, gc.IsNil)
+	err = staged.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(staged.Version(), gc.Equals, otherVersion)
+	c.Assert(staged.ReadyAgents(), gc.HasLen, 0)
+}
+
+func (s *StagedUpgradeSuite) TestStageAgentVersionErrors(c *gc.C) {
+	err := s.State.StageAgentVersion(s.current)
+	c.Assert(err, gc.ErrorMatches, "environment is already running version .*")
+
+	downgrade := s.current
+	downgrade.Major--
+	err = s.State.StageAgentVersion(downgrade)
+	c.Assert(err, jc.Satisfies, state.IsMajorVersionDowngradeError)
+
+	err = s.machines[1].SetAgentVersion(version.MustParseBinary("9.9.9-quantal-amd64"))
+	c.Assert(err, gc.IsNil)
+	err = s.State.StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, jc.Satisfies, state.IsVersionInconsistentError)
+
+	_, err = s.State.StagedUpgrade()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *StagedUpgradeSuite) TestSetAgentReadyWrongVersion(c *gc.C) {
+	err := s.State.StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+
+	err = staged.SetAgentReady("machine-0", version.MustParse("9.8.6"))
+	c.Assert(err, gc.ErrorMatches, "cannot set machine-0 ready for upgrade to 9.8.6: staged upgrade has changed")
+
+	// Replace the staged upgrade behind the stale one's back.
+	err = s.State.StageAgentVersion(version.MustParse("9.8.8"))
+	c.Assert(err, gc.IsNil)
+	err = staged.SetAgentReady("machine-0", version.MustParse("9.8.7"))
+	c.Assert(err, gc.ErrorMatches, "cannot set machine-0 ready for upgrade to 9.8.7: staged upgrade has changed")
+}
+
+func (s *StagedUpgradeSuite) TestActivateStagedUpgrade(c *gc.C) {
+	newVersion := version.MustParse("9.8.7")
+	err := s.State.StageAgentVersion(newVersion)
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	err = staged.SetAgentReady("machine-0", newVersion)
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.ActivateStagedUpgrade()
+	c.Assert(err, gc.ErrorMatches, "agents not ready for upgrade to 9.8.7: machine-1")
+	c.Assert(err, jc.Satisfies, state.IsAgentsNotReadyError)
+	s.assertAgentVersion(c, s.current)
+
+	err = staged.SetAgentReady("machine-1", newVersion)
+	c.Assert(err, gc.IsNil)
+	err = s.State.ActivateStagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	s.assertAgentVersion(c, newVersion)
+	_, err = s.State.StagedUpgrade()
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
+func (s *StagedUpgradeSuite) TestPendingAgentsIgnoresDeadMachines(c *gc.C) {
+	err := s.State.StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	err = s.machines[1].EnsureDead()
+	c.Assert(err, gc.IsNil)
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	pending, err := staged.PendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0"})
+}
+
+func (s *StagedUpgradeSuite) TestWatchStagedUpgrade(c *gc.C) {
+	w := s.State.WatchStagedUpgrade()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	err := s.State.StageAgentVersion(version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	staged, err := s.State.StagedUpgrade()
+	c.Assert(err, gc.IsNil)
+	err = staged.SetAgentReady("machine-0", version.MustParse("9.8.7"))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+}
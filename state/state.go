@@ -66,11 +66,25 @@ const (
 	statusesC          = "statuses"
 	stateServersC      = "stateServers"
 	openedPortsC       = "openedPorts"
+	scheduledTasksC    = "scheduledtasks"
+	stagedUpgradesC    = "stagedupgrades"
+	environNamesC      = "environnames"
+
+	// This capped collection holds metrics sent by the agents.
+	metricsC = "metrics"
 
 	// These collections are used by the mgo transaction runner.
 	txnLogC = "txns.log"
 	txnsC   = "txns"
 
+	// This collection records the running totals of the
+	// transaction pruner.
+	txnPruneStatsC = "txnprunestats"
+
+	// This collection records the position of each agent's
+	// watcher in the transaction log.
+	watcherPositionsC = "watcherpositions"
+
 	AdminUser = "admin"
 )
 
@@ -182,20 +196,30 @@ func (st *State) ResumeTransactions() error {
 }
 
 func (st *State) Watch() *multiwatcher.Watcher {
+	return multiwatcher.NewWatcher(st.getAllManager())
+}
+
+// ChangesSince returns the changes to the entities in the environment
+// since the given token was returned, and a token for the current
+// state. See multiwatcher.StoreManager.ChangesSince for details.
+func (st *State) ChangesSince(token string) (deltas []params.Delta, newToken string, reset bool, err error) {
+	return st.getAllManager().ChangesSince(token)
+}
+
+func (st *State) getAllManager() *multiwatcher.StoreManager {
 	st.mu.Lock()
+	defer st.mu.Unlock()
 	if st.allManager == nil {
 		st.allManager = multiwatcher.NewStoreManager(newAllWatcherStateBacking(st))
 	}
-	st.mu.Unlock()
-	return multiwatcher.NewWatcher(st.allManager)
+	return st.allManager
 }
 
 func (st *State) EnvironConfig() (*config.Config, error) {
-	settings, err := readSettings(st, environGlobalKey)
+	_, _, attrs, err := readEnvironSettings(st)
 	if err != nil {
 		return nil, err
 	}
-	attrs := settings.Map()
 	return config.New(config.NoDefaults, attrs)
 }
 
@@ -236,6 +260,24 @@ func IsVersionInconsistentError(e interface{}) bool {
 	return ok
 }
 
+// majorVersionDowngradeError indicates an attempt to change the
+// environment's agent version to an earlier major version.
+type majorVersionDowngradeError struct {
+	currentVersion version.Number
+	newVersion     version.Number
+}
+
+func (e *majorVersionDowngradeError) Error() string {
+	return fmt.Sprintf("cannot change agent version from %s to %s: major version downgrades are not supported", e.currentVersion, e.newVersion)
+}
+
+// IsMajorVersionDowngradeError returns if the given error is
+// majorVersionDowngradeError.
+func IsMajorVersionDowngradeError(e interface{}) bool {
+	_, ok := e.(*majorVersionDowngradeError)
+	return ok
+}
+
 func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 	db, closer := st.newDB()
 	defer closer()
@@ -278,7 +320,8 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 
 // SetEnvironAgentVersion changes the agent version for the
 // environment to the given version, only if the environment is in a
-// stable state (all agents are running the current version).
+// stable state (all agents are running the current version), and the
+// new version is not of an earlier major version.
 func (st *State) SetEnvironAgentVersion(newVersion version.Number) (err error) {
 	buildTxn := func(attempt int) ([]txn.Op, error) {
 		settings, err := readSettings(st, environGlobalKey)
@@ -297,6 +340,13 @@ func (st *State) SetEnvironAgentVersion(newVersion version.Number) (err error) {
 			// Nothing to do.
 			return nil, jujutxn.ErrNoOperations
 		}
+		current, err := version.Parse(currentVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent version %q: %v", currentVersion, err)
+		}
+		if newVersion.Major < current.Major {
+			return nil, &majorVersionDowngradeError{current, newVersion}
+		}
 
 		if err := st.checkCanUpgrade(currentVersion, newVersion.String()); err != nil {
 			return nil, err
@@ -343,41 +393,34 @@ func (st *State) UpdateEnvironConfig(updateAttrs map[string]interface{}, removeA
 		return nil
 	}
 
-	// TODO(axw) 2013-12-6 #1167616
-	// Ensure that the settings on disk have not changed
-	// underneath us. The settings changes are actually
-	// applied as a delta to what's on disk; if there has
-	// been a concurrent update, the change may not be what
-	// the user asked for.
-	settings, err := readSettings(st, environGlobalKey)
-	if err != nil {
-		return err
-	}
-
-	// Get the existing environment config from state.
-	oldConfig, err := config.New(config.NoDefaults, settings.Map())
-	if err != nil {
-		return err
-	}
-	if additionalValidation != nil {
-		err = additionalValidation(updateAttrs, removeAttrs, oldConfig)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		// The environment and controller settings are both
+		// asserted unchanged, so the changes are applied to
+		// the configuration they were validated against.
+		environ, controller, attrs, err := readEnvironSettings(st)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	}
-	validCfg, err := st.buildAndValidateEnvironConfig(updateAttrs, removeAttrs, oldConfig)
-	if err != nil {
-		return err
-	}
-
-	validAttrs := validCfg.AllAttrs()
-	for k := range oldConfig.AllAttrs() {
-		if _, ok := validAttrs[k]; !ok {
-			settings.Delete(k)
+		oldConfig, err := config.New(config.NoDefaults, attrs)
+		if err != nil {
+			return nil, err
 		}
+		if additionalValidation != nil {
+			err = additionalValidation(updateAttrs, removeAttrs, oldConfig)
+			if err != nil {
+				return nil, err
+			}
+		}
+		validCfg, err := st.buildAndValidateEnvironConfig(updateAttrs, removeAttrs, oldConfig)
+		if err != nil {
+			return nil, err
+		}
+		return writeEnvironSettingsOps(st, environ, controller, validCfg), nil
+	}
+	err := st.run(buildTxn)
+	if err == jujutxn.ErrExcessiveContention {
+		err = errors.Annotate(err, "cannot update environment configuration")
 	}
-	settings.Update(validAttrs)
-	_, err = settings.Write()
 	return err
 }
 
@@ -388,7 +431,7 @@ func (st *State) EnvironConstraints() (constraints.Value, error) {
 
 // SetEnvironConstraints replaces the current environment constraints.
 func (st *State) SetEnvironConstraints(cons constraints.Value) error {
-	unsupported, err := st.validateConstraints(cons)
+	unsupported, err := st.ValidateConstraints(cons)
 	if len(unsupported) > 0 {
 		logger.Warningf(
 			"setting environment constraints: unsupported constraints: %v", strings.Join(unsupported, ","))
@@ -426,6 +469,25 @@ func (st *State) AllMachines() (machines []*Machine, err error) {
 	return
 }
 
+// MachinesWithJob returns all the machines in the environment that
+// have been assigned the given job, ordered by id.
+func (st *State) MachinesWithJob(job MachineJob) ([]*Machine, error) {
+	machinesCollection, closer := st.getCollection(machinesC)
+	defer closer()
+
+	mdocs := machineDocSlice{}
+	err := machinesCollection.Find(bson.D{{"jobs", job}}).All(&mdocs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get machines with job %v: %v", job, err)
+	}
+	sort.Sort(mdocs)
+	machines := make([]*Machine, len(mdocs))
+	for i, doc := range mdocs {
+		machines[i] = newMachine(st, &doc)
+	}
+	return machines, nil
+}
+
 type machineDocSlice []machineDoc
 
 func (ms machineDocSlice) Len() int      { return len(ms) }
@@ -522,7 +584,11 @@ func (st *State) FindEntity(tag string) (Entity, error) {
 		// the current one.
 		if id != env.UUID() {
 			if utils.IsValidUUIDString(id) {
-				return nil, errors.NotFoundf("environment %q", id)
+				hosted, err := st.GetEnvironment(names.NewEnvironTag(id))
+				if errors.IsNotFound(err) {
+					return nil, errors.NotFoundf("environment %q", id)
+				}
+				return hosted, err
 			}
 			// TODO(axw) 2013-12-04 #1257587
 			// We should not accept environment tags that do not match the
@@ -593,13 +659,18 @@ func (st *State) AddCharm(ch charm.Charm, curl *charm.URL, bundleURL *url.URL, b
 
 	err = charms.Find(bson.D{{"_id", curl.String()}, {"placeholder", true}}).One(&existing)
 	if err == mgo.ErrNotFound {
+		minJujuVersion, err := readMinJujuVersion(ch)
+		if err != nil {
+			return nil, fmt.Errorf("cannot add charm %q: %v", curl, err)
+		}
 		cdoc := &charmDoc{
-			URL:          curl,
-			Meta:         ch.Meta(),
-			Config:       ch.Config(),
-			Actions:      ch.Actions(),
-			BundleURL:    bundleURL,
-			BundleSha256: bundleSha256,
+			URL:            curl,
+			Meta:           ch.Meta(),
+			Config:         ch.Config(),
+			Actions:        ch.Actions(),
+			MinJujuVersion: minJujuVersion,
+			BundleURL:      bundleURL,
+			BundleSha256:   bundleSha256,
 		}
 		err = charms.Insert(cdoc)
 		if err != nil {
@@ -943,10 +1014,15 @@ func (st *State) UpdateUploadedCharm(ch charm.Charm, curl *charm.URL, bundleURL
 func (st *State) updateCharmDoc(
 	ch charm.Charm, curl *charm.URL, bundleURL *url.URL, bundleSha256 string, preReq interface{}) (*Charm, error) {
 
+	minJujuVersion, err := readMinJujuVersion(ch)
+	if err != nil {
+		return nil, fmt.Errorf("cannot update charm %q: %v", curl, err)
+	}
 	updateFields := bson.D{{"$set", bson.D{
 		{"meta", ch.Meta()},
 		{"config", ch.Config()},
 		{"actions", ch.Actions()},
+		{"minjujuversion", minJujuVersion},
 		{"bundleurl", bundleURL},
 		{"bundlesha256", bundleSha256},
 		{"pendingupload", false},
@@ -998,6 +1074,17 @@ func (st *State) addPeerRelationsOps(serviceName string, peers map[string]charm.
 // supplied name (which must be unique). If the charm defines peer relations,
 // they will be created automatically.
 func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string) (service *Service, err error) {
+	return st.AddServiceWithConfig(name, ownerTag, ch, networks, nil, constraints.Value{})
+}
+
+// AddServiceWithConfig works like AddService, but also sets the
+// service's initial charm config settings and constraints in the same
+// transaction, so that the service is never visible without them.
+// The settings are validated against the charm's config schema.
+// Constraints are ignored for subordinate services.
+func (st *State) AddServiceWithConfig(
+	name, ownerTag string, ch *Charm, networks []string, settings charm.Settings, cons constraints.Value,
+) (service *Service, err error) {
 	defer errors.Maskf(&err, "cannot add service %q", name)
 	tag, err := names.ParseUserTag(ownerTag)
 	if err != nil {
@@ -1010,6 +1097,32 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 	if ch == nil {
 		return nil, fmt.Errorf("charm is nil")
 	}
+	settings, err = ch.Config().ValidateSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	// A nil value resets a setting to its default, which is
+	// where every setting of a new service starts anyway.
+	for name, value := range settings {
+		if value == nil {
+			delete(settings, name)
+		}
+	}
+	if ch.Meta().Subordinate {
+		// Subordinate services have no machines of their own, so
+		// their constraints are ignored, as they always have been
+		// when deploying.
+		cons = constraints.Value{}
+	}
+	if !constraints.IsEmpty(&cons) {
+		unsupported, err := st.ValidateConstraints(cons)
+		if len(unsupported) > 0 {
+			logger.Warningf(
+				"adding service %q: unsupported constraints: %v", name, strings.Join(unsupported, ","))
+		} else if err != nil {
+			return nil, err
+		}
+	}
 	if exists, err := isNotDead(st.db, servicesC, name); err != nil {
 		return nil, err
 	} else if exists {
@@ -1041,13 +1154,13 @@ func (st *State) AddService(name, ownerTag string, ch *Charm, networks []string)
 	svc := newService(st, svcDoc)
 	ops := []txn.Op{
 		env.assertAliveOp(),
-		createConstraintsOp(st, svc.globalKey(), constraints.Value{}),
+		createConstraintsOp(st, svc.globalKey(), cons),
 		// TODO(dimitern) 2014-04-04 bug #1302498
 		// Once we can add networks independently of machine
 		// provisioning, we should check the given networks are valid
 		// and known before setting them.
 		createRequestedNetworksOp(st, svc.globalKey(), networks),
-		createSettingsOp(st, svc.settingsKey(), nil),
+		createSettingsOp(st, svc.settingsKey(), settings),
 		{
 			C:      usersC,
 			Id:     ownerId,
@@ -1276,11 +1389,35 @@ outer:
 	}
 	keys := []string{}
 	for _, cand := range candidates {
-		keys = append(keys, fmt.Sprintf("%q", relationKey(cand)))
+		keys = append(keys, relationKey(cand))
 	}
 	sort.Strings(keys)
-	return nil, fmt.Errorf("ambiguous relation: %q could refer to %s",
-		strings.Join(names, " "), strings.Join(keys, "; "))
+	return nil, &AmbiguousRelationError{Names: names, Candidates: keys}
+}
+
+// AmbiguousRelationError is returned by InferEndpoints when the
+// supplied endpoint names could refer to more than one relation.
+type AmbiguousRelationError struct {
+	// Names holds the endpoint names as supplied.
+	Names []string
+	// Candidates holds the sorted keys of the relations
+	// that the names could refer to.
+	Candidates []string
+}
+
+func (e *AmbiguousRelationError) Error() string {
+	quoted := make([]string, len(e.Candidates))
+	for i, key := range e.Candidates {
+		quoted[i] = fmt.Sprintf("%q", key)
+	}
+	return fmt.Sprintf("ambiguous relation: %q could refer to %s",
+		strings.Join(e.Names, " "), strings.Join(quoted, "; "))
+}
+
+// IsAmbiguousRelation returns whether err is an AmbiguousRelationError.
+func IsAmbiguousRelation(err error) bool {
+	_, ok := err.(*AmbiguousRelationError)
+	return ok
 }
 
 func isPeer(ep Endpoint) bool {
@@ -1645,6 +1782,7 @@ type stateServersDoc struct {
 	Id               string `bson:"_id"`
 	MachineIds       []string
 	VotingMachineIds []string
+	APIMachineIds    []string
 }
 
 // StateServerInfo holds information about currently
@@ -1659,6 +1797,11 @@ type StateServerInfo struct {
 	// configured to run a state server and to have a vote
 	// in peer election.
 	VotingMachineIds []string
+
+	// APIMachineIds holds the ids of all machines configured
+	// to run only an API server. They are not members of the
+	// mongo replica set.
+	APIMachineIds []string
 }
 
 // StateServerInfo returns information about
@@ -1675,6 +1818,7 @@ func (st *State) StateServerInfo() (*StateServerInfo, error) {
 	return &StateServerInfo{
 		MachineIds:       doc.MachineIds,
 		VotingMachineIds: doc.VotingMachineIds,
+		APIMachineIds:    doc.APIMachineIds,
 	}, nil
 }
 
@@ -97,7 +97,7 @@ func (s *StateSuite) TestMongoSession(c *gc.C) {
 
 func (s *StateSuite) TestAddresses(c *gc.C) {
 	var err error
-	machines := make([]*state.Machine, 4)
+	machines := make([]*state.Machine, 5)
 	machines[0], err = s.State.AddMachine("quantal", state.JobManageEnviron, state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
 	machines[1], err = s.State.AddMachine("quantal", state.JobHostUnits)
@@ -110,6 +110,8 @@ func (s *StateSuite) TestAddresses(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	machines[3], err = s.State.Machine("3")
 	c.Assert(err, gc.IsNil)
+	machines[4], err = s.State.AddMachine("quantal", state.JobManageAPI)
+	c.Assert(err, gc.IsNil)
 
 	for i, m := range machines {
 		err := m.SetAddresses(network.Address{
@@ -143,13 +145,16 @@ func (s *StateSuite) TestAddresses(c *gc.C) {
 		fmt.Sprintf("10.0.0.3:%d", envConfig.StatePort()),
 	})
 
+	// Machines running only an API server have API
+	// addresses but no state addresses.
 	addrs, err = s.State.APIAddressesFromMachines()
 	c.Assert(err, gc.IsNil)
-	c.Assert(addrs, gc.HasLen, 3)
+	c.Assert(addrs, gc.HasLen, 4)
 	c.Assert(addrs, jc.SameContents, []string{
 		fmt.Sprintf("10.0.0.0:%d", envConfig.APIPort()),
 		fmt.Sprintf("10.0.0.2:%d", envConfig.APIPort()),
 		fmt.Sprintf("10.0.0.3:%d", envConfig.APIPort()),
+		fmt.Sprintf("10.0.0.4:%d", envConfig.APIPort()),
 	})
 }
 
@@ -501,6 +506,7 @@ var jobStringTests = []struct {
 	{state.JobHostUnits, "JobHostUnits"},
 	{state.JobManageEnviron, "JobManageEnviron"},
 	{state.JobManageStateDeprecated, "JobManageState"},
+	{state.JobManageAPI, "JobManageAPI"},
 	{0, "<unknown job 0>"},
 	{5, "<unknown job 5>"},
 }
@@ -518,6 +524,8 @@ func (s *StateSuite) TestAddMachineErrors(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: no jobs specified")
 	_, err = s.State.AddMachine("quantal", state.JobHostUnits, state.JobHostUnits)
 	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: duplicate job: .*")
+	_, err = s.State.AddMachine("quantal", state.JobManageEnviron, state.JobManageAPI)
+	c.Assert(err, gc.ErrorMatches, "cannot add a new machine: JobManageAPI and JobManageEnviron jobs cannot be combined")
 }
 
 func (s *StateSuite) TestAddMachine(c *gc.C) {
@@ -995,6 +1003,27 @@ func (s *StateSuite) TestAllMachines(c *gc.C) {
 	}
 }
 
+func (s *StateSuite) TestMachinesWithJob(c *gc.C) {
+	m0, err := s.State.AddMachine("quantal", state.JobManageEnviron)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m2, err := s.State.AddMachine("quantal", state.JobHostUnits, state.JobManageAPI)
+	c.Assert(err, gc.IsNil)
+
+	assertMachines := func(job state.MachineJob, expected ...*state.Machine) {
+		machines, err := s.State.MachinesWithJob(job)
+		c.Assert(err, gc.IsNil)
+		c.Assert(machines, gc.HasLen, len(expected))
+		for i, m := range machines {
+			c.Assert(m.Id(), gc.Equals, expected[i].Id())
+		}
+	}
+	assertMachines(state.JobManageEnviron, m0)
+	assertMachines(state.JobHostUnits, m1, m2)
+	assertMachines(state.JobManageAPI, m2)
+}
+
 func (s *StateSuite) TestAllRelations(c *gc.C) {
 	const numRelations = 32
 	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
@@ -1160,6 +1189,40 @@ func (s *StateSuite) TestAddService(c *gc.C) {
 	c.Assert(ch.URL(), gc.DeepEquals, charm.URL())
 }
 
+func (s *StateSuite) TestAddServiceWithConfig(c *gc.C) {
+	ch := s.AddTestingCharm(c, "dummy")
+	cons := constraints.MustParse("mem=4G")
+	service, err := s.State.AddServiceWithConfig("dummy", "user-admin", ch, nil, charm.Settings{
+		"title":    "Dummy",
+		"username": nil,
+	}, cons)
+	c.Assert(err, gc.IsNil)
+	settings, err := service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"title": "Dummy"})
+	scons, err := service.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(scons, gc.DeepEquals, cons)
+}
+
+func (s *StateSuite) TestAddServiceWithConfigSubordinateIgnoresConstraints(c *gc.C) {
+	ch := s.AddTestingCharm(c, "logging")
+	service, err := s.State.AddServiceWithConfig("logging", "user-admin", ch, nil, nil, constraints.MustParse("mem=4G"))
+	c.Assert(err, gc.IsNil)
+	_, err = service.Constraints()
+	c.Assert(err, gc.Equals, state.ErrSubordinateConstraints)
+}
+
+func (s *StateSuite) TestAddServiceWithConfigInvalidSettings(c *gc.C) {
+	ch := s.AddTestingCharm(c, "dummy")
+	_, err := s.State.AddServiceWithConfig("dummy", "user-admin", ch, nil, charm.Settings{
+		"skill-level": "high",
+	}, constraints.Value{})
+	c.Assert(err, gc.ErrorMatches, `cannot add service "dummy": option "skill-level" expected int, got "high"`)
+	_, err = s.State.Service("dummy")
+	c.Assert(err, jc.Satisfies, errors.IsNotFound)
+}
+
 func (s *StateSuite) TestAddServiceEnvironmentDying(c *gc.C) {
 	charm := s.AddTestingCharm(c, "dummy")
 	s.AddTestingService(c, "s0", charm)
@@ -1412,6 +1475,16 @@ func (s *StateSuite) TestInferEndpoints(c *gc.C) {
 	}
 }
 
+func (s *StateSuite) TestInferEndpointsAmbiguous(c *gc.C) {
+	s.AddTestingService(c, "ms", s.AddTestingCharm(c, "mysql-alternative"))
+	s.AddTestingService(c, "wp", s.AddTestingCharm(c, "wordpress"))
+	_, err := s.State.InferEndpoints([]string{"wp", "ms"})
+	c.Assert(err, jc.Satisfies, state.IsAmbiguousRelation)
+	c.Assert(err.(*state.AmbiguousRelationError).Candidates, gc.DeepEquals, []string{
+		"wp:db ms:dev", "wp:db ms:prod",
+	})
+}
+
 func (s *StateSuite) TestEnvironConfig(c *gc.C) {
 	attrs := map[string]interface{}{
 		"authorized-keys": "different-keys",
@@ -2206,49 +2279,94 @@ func testSetPassword(c *gc.C, getEntity func() (state.Authenticator, error)) {
 	}
 }
 
+type passwordRotator interface {
+	state.Authenticator
+	state.PasswordRotator
+}
+
+func testPasswordRotation(c *gc.C, getEntity func() (passwordRotator, error)) {
+	e, err := getEntity()
+	c.Assert(err, gc.IsNil)
+	c.Assert(e.PasswordRotationRequired(), jc.IsFalse)
+
+	err = e.RequirePasswordRotation()
+	c.Assert(err, gc.IsNil)
+	c.Assert(e.PasswordRotationRequired(), jc.IsTrue)
+	e2, err := getEntity()
+	c.Assert(err, gc.IsNil)
+	c.Assert(e2.PasswordRotationRequired(), jc.IsTrue)
+
+	// Setting a new password completes the rotation.
+	err = e2.SetPassword(goodPassword)
+	c.Assert(err, gc.IsNil)
+	c.Assert(e2.PasswordRotationRequired(), jc.IsFalse)
+	err = e.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(e.PasswordRotationRequired(), jc.IsFalse)
+	c.Assert(e.PasswordValid(goodPassword), jc.IsTrue)
+}
+
 func testSetAgentCompatPassword(c *gc.C, entity state.Authenticator) {
 	// In Juju versions 1.16 and older we used UserPasswordHash(password,CompatSalt)
 	// for Machine and Unit agents. This was determined to be overkill
 	// (since we know that Unit agents will actually use
 	// utils.RandomPassword() and get 18 bytes of entropy, and thus won't
-	// be brute-forced.)
+	// be brute-forced.) Later versions used the unsalted AgentPasswordHash.
+	// After logging in with either kind of hash, the db should be
+	// updated with a salted hash.
 	c.Assert(entity.PasswordValid(goodPassword), jc.IsFalse)
 	agentHash := utils.AgentPasswordHash(goodPassword)
-	err := state.SetPasswordHash(entity, agentHash)
-	c.Assert(err, gc.IsNil)
-	c.Assert(entity.PasswordValid(goodPassword), jc.IsTrue)
-	c.Assert(entity.PasswordValid(alternatePassword), jc.IsFalse)
-	c.Assert(state.GetPasswordHash(entity), gc.Equals, agentHash)
-
 	backwardsCompatibleHash := utils.UserPasswordHash(goodPassword, utils.CompatSalt)
 	c.Assert(backwardsCompatibleHash, gc.Not(gc.Equals), agentHash)
-	err = state.SetPasswordHash(entity, backwardsCompatibleHash)
-	c.Assert(err, gc.IsNil)
-	c.Assert(entity.PasswordValid(alternatePassword), jc.IsFalse)
-	c.Assert(state.GetPasswordHash(entity), gc.Equals, backwardsCompatibleHash)
-	// After succeeding to log in with the old compatible hash, the db
-	// should be updated with the new hash
-	c.Assert(entity.PasswordValid(goodPassword), jc.IsTrue)
-	c.Assert(state.GetPasswordHash(entity), gc.Equals, agentHash)
-	c.Assert(entity.PasswordValid(goodPassword), jc.IsTrue)
+	for i, oldHash := range []string{agentHash, backwardsCompatibleHash} {
+		c.Logf("test %d", i)
+		err := state.SetPasswordHash(entity, oldHash)
+		c.Assert(err, gc.IsNil)
+		c.Assert(entity.PasswordValid(alternatePassword), jc.IsFalse)
+		c.Assert(state.GetPasswordHash(entity), gc.Equals, oldHash)
+		c.Assert(entity.PasswordValid(goodPassword), jc.IsTrue)
+		saltedHash := state.GetPasswordHash(entity)
+		c.Assert(saltedHash, gc.Not(gc.Equals), agentHash)
+		c.Assert(saltedHash, gc.Not(gc.Equals), backwardsCompatibleHash)
+		c.Assert(entity.PasswordValid(goodPassword), jc.IsTrue)
+		c.Assert(entity.PasswordValid(alternatePassword), jc.IsFalse)
+		c.Assert(state.GetPasswordHash(entity), gc.Equals, saltedHash)
+	}
 
 	// Agents are unable to set short passwords
-	err = entity.SetPassword("short")
+	err := entity.SetPassword("short")
 	c.Check(err, gc.ErrorMatches, "password is only 5 bytes long, and is not a valid Agent password")
 	// Grandfather clause. Agents that have short passwords are allowed if
 	// it was done in the compatHash form
-	agentHash = utils.AgentPasswordHash("short")
 	backwardsCompatibleHash = utils.UserPasswordHash("short", utils.CompatSalt)
 	err = state.SetPasswordHash(entity, backwardsCompatibleHash)
 	c.Assert(err, gc.IsNil)
 	c.Assert(entity.PasswordValid("short"), jc.IsTrue)
-	// We'll still update the hash, but now it points to the hash of the
-	// shorter password. Agents still can't set the password to it
-	c.Assert(state.GetPasswordHash(entity), gc.Equals, agentHash)
+	// We'll still update the hash, but now it points to the salted hash
+	// of the shorter password. Agents still can't set the password to it
+	c.Assert(state.GetPasswordHash(entity), gc.Not(gc.Equals), backwardsCompatibleHash)
 	// Still valid with the shorter password
 	c.Assert(entity.PasswordValid("short"), jc.IsTrue)
 }
 
+func testAgentPasswordSalted(c *gc.C, getEntity func() (state.Authenticator, error)) {
+	e1, err := getEntity()
+	c.Assert(err, gc.IsNil)
+	err = e1.SetPassword(goodPassword)
+	c.Assert(err, gc.IsNil)
+	hash1 := state.GetPasswordHash(e1)
+	c.Assert(hash1, gc.Not(gc.Equals), utils.AgentPasswordHash(goodPassword))
+
+	// Setting the same password again uses a new salt.
+	err = e1.SetPassword(goodPassword)
+	c.Assert(err, gc.IsNil)
+	c.Assert(state.GetPasswordHash(e1), gc.Not(gc.Equals), hash1)
+	e2, err := getEntity()
+	c.Assert(err, gc.IsNil)
+	c.Assert(e2.PasswordValid(goodPassword), jc.IsTrue)
+	c.Assert(e2.PasswordValid(alternatePassword), jc.IsFalse)
+}
+
 type entity interface {
 	state.Entity
 	state.Lifer
@@ -2842,6 +2960,16 @@ func (s *StateSuite) TestSetEnvironAgentVersionErrors(c *gc.C) {
 	c.Assert(err, jc.Satisfies, state.IsVersionInconsistentError)
 }
 
+func (s *StateSuite) TestSetEnvironAgentVersionMajorDowngrade(c *gc.C) {
+	envConfig, _ := s.prepareAgentVersionTests(c)
+	s.changeEnviron(c, envConfig, "agent-version", "4.5.6")
+
+	err := s.State.SetEnvironAgentVersion(version.MustParse("3.9.9"))
+	c.Assert(err, gc.ErrorMatches, "cannot change agent version from 4.5.6 to 3.9.9: major version downgrades are not supported")
+	c.Assert(err, jc.Satisfies, state.IsMajorVersionDowngradeError)
+	s.assertAgentVersion(c, envConfig, "4.5.6")
+}
+
 func (s *StateSuite) prepareAgentVersionTests(c *gc.C) (*config.Config, string) {
 	// Get the agent-version set in the environment.
 	envConfig, err := s.State.EnvironConfig()
@@ -3390,6 +3518,17 @@ func (s *StateSuite) TestStateServingInfo(c *gc.C) {
 	c.Assert(info, jc.DeepEquals, data)
 }
 
+func (s *StateSuite) TestDownloadSigningKey(c *gc.C) {
+	key, err := s.State.DownloadSigningKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(key, gc.HasLen, 32)
+
+	// The key is created once and shared thereafter.
+	again, err := s.State.DownloadSigningKey()
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.DeepEquals, key)
+}
+
 var setStateServingInfoWithInvalidInfoTests = []func(info *params.StateServingInfo){
 	func(info *params.StateServingInfo) { info.APIPort = 0 },
 	func(info *params.StateServingInfo) { info.StatePort = 0 },
@@ -3818,3 +3957,83 @@ func (s *StateSuite) TestWatchMachineAddresses(c *gc.C) {
 	}
 	c.Assert(w.Err(), jc.Satisfies, errors.IsNotFound)
 }
+
+func (s *StateSuite) TestWatchUnitPublicAddress(c *gc.C) {
+	service := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	w := unit.WatchPublicAddress()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	// Assign to a machine without addresses: not reported.
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	// Set a public address on the machine: reported.
+	err = machine.SetAddresses(network.NewAddress("8.8.8.8", network.ScopePublic))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Add an address that does not change the public address: not reported.
+	err = machine.SetAddresses(
+		network.NewAddress("8.8.8.8", network.ScopePublic),
+		network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+	)
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	// Change the public address: reported.
+	err = machine.SetAddresses(network.NewAddress("8.8.4.4", network.ScopePublic))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Unassign the unit: reported.
+	err = unit.UnassignFromMachine()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	// Changes to the old machine: not reported.
+	err = machine.SetAddresses(network.NewAddress("8.8.8.8", network.ScopePublic))
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+}
+
+func (s *StateSuite) TestWatchSubordinateUnitPublicAddress(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.AddTestingService(c, "logging", s.AddTestingCharm(c, "logging"))
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "logging"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	principal, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = principal.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(principal)
+	c.Assert(err, gc.IsNil)
+	err = ru.EnterScope(nil)
+	c.Assert(err, gc.IsNil)
+	subordinate, err := s.State.Unit("logging/0")
+	c.Assert(err, gc.IsNil)
+
+	w := subordinate.WatchPublicAddress()
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	// The subordinate's address is that of its principal's machine.
+	err = machine.SetAddresses(network.NewAddress("8.8.8.8", network.ScopePublic))
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	address, ok := subordinate.PublicAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(address, gc.Equals, "8.8.8.8")
+}
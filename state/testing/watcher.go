@@ -10,7 +10,6 @@ import (
 	jc "github.com/juju/testing/checkers"
 	gc "launchpad.net/gocheck"
 
-	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/testing"
 )
@@ -47,21 +46,43 @@ func AssertCanStopWhenSending(c *gc.C, stopper Stopper) {
 	}
 }
 
+// SyncStarter is implemented by *state.State, and by anything else
+// that can prompt the watchers under test to look for changes
+// immediately rather than waiting for their next poll.
+type SyncStarter interface {
+	StartSync()
+}
+
+// NoSync is a SyncStarter that does nothing, for use with watchers
+// that do not depend on the state.
+var NoSync SyncStarter = noSync{}
+
+type noSync struct{}
+
+func (noSync) StartSync() {}
+
 type NotifyWatcher interface {
 	Changes() <-chan struct{}
 }
 
+// notifyChan adapts a channel to the NotifyWatcher interface.
+type notifyChan <-chan struct{}
+
+func (ch notifyChan) Changes() <-chan struct{} {
+	return ch
+}
+
 // NotifyWatcherC embeds a gocheck.C and adds methods to help verify
 // the behaviour of any watcher that uses a <-chan struct{}.
 type NotifyWatcherC struct {
 	*gc.C
-	State   *state.State
+	State   SyncStarter
 	Watcher NotifyWatcher
 }
 
 // NewNotifyWatcherC returns a NotifyWatcherC that checks for aggressive
 // event coalescence.
-func NewNotifyWatcherC(c *gc.C, st *state.State, w NotifyWatcher) NotifyWatcherC {
+func NewNotifyWatcherC(c *gc.C, st SyncStarter, w NotifyWatcher) NotifyWatcherC {
 	return NotifyWatcherC{
 		C:       c,
 		State:   st,
@@ -69,6 +90,13 @@ func NewNotifyWatcherC(c *gc.C, st *state.State, w NotifyWatcher) NotifyWatcherC
 	}
 }
 
+// NewNotifyChanC returns a NotifyWatcherC that verifies the events
+// sent on ch, for use with types (such as the uniter's filter) that
+// expose event channels rather than watchers.
+func NewNotifyChanC(c *gc.C, st SyncStarter, ch <-chan struct{}) NotifyWatcherC {
+	return NewNotifyWatcherC(c, st, notifyChan(ch))
+}
+
 func (c NotifyWatcherC) AssertNoChange() {
 	c.State.StartSync()
 	select {
@@ -78,7 +106,9 @@ func (c NotifyWatcherC) AssertNoChange() {
 	}
 }
 
-func (c NotifyWatcherC) AssertOneChange() {
+// AssertChange asserts that the watcher sends a change,
+// but does not assume there are no following changes.
+func (c NotifyWatcherC) AssertChange() {
 	c.State.StartSync()
 	select {
 	case _, ok := <-c.Watcher.Changes():
@@ -86,6 +116,10 @@ func (c NotifyWatcherC) AssertOneChange() {
 	case <-time.After(testing.LongWait):
 		c.Fatalf("watcher did not send change")
 	}
+}
+
+func (c NotifyWatcherC) AssertOneChange() {
+	c.AssertChange()
 	c.AssertNoChange()
 }
 
@@ -102,13 +136,13 @@ func (c NotifyWatcherC) AssertClosed() {
 // the behaviour of any watcher that uses a <-chan []string.
 type StringsWatcherC struct {
 	*gc.C
-	State   *state.State
+	State   SyncStarter
 	Watcher StringsWatcher
 }
 
 // NewStringsWatcherC returns a StringsWatcherC that checks for aggressive
 // event coalescence.
-func NewStringsWatcherC(c *gc.C, st *state.State, w StringsWatcher) StringsWatcherC {
+func NewStringsWatcherC(c *gc.C, st SyncStarter, w StringsWatcher) StringsWatcherC {
 	return StringsWatcherC{
 		C:       c,
 		State:   st,
@@ -121,6 +155,24 @@ type StringsWatcher interface {
 	Changes() <-chan []string
 }
 
+// stringsChan adapts a channel to the StringsWatcher interface.
+type stringsChan <-chan []string
+
+func (ch stringsChan) Stop() error {
+	return nil
+}
+
+func (ch stringsChan) Changes() <-chan []string {
+	return ch
+}
+
+// NewStringsChanC returns a StringsWatcherC that verifies the events
+// sent on ch, for use with types that expose event channels rather
+// than watchers.
+func NewStringsChanC(c *gc.C, st SyncStarter, ch <-chan []string) StringsWatcherC {
+	return NewStringsWatcherC(c, st, stringsChan(ch))
+}
+
 func (c StringsWatcherC) AssertNoChange() {
 	c.State.StartSync()
 	select {
@@ -180,7 +232,7 @@ func (c StringsWatcherC) AssertClosed() {
 // params.RelationUnitsChange.
 type RelationUnitsWatcherC struct {
 	*gc.C
-	State   *state.State
+	State   SyncStarter
 	Watcher RelationUnitsWatcher
 	// settingsVersions keeps track of the settings version of each
 	// changed unit since the last received changes to ensure version
@@ -190,7 +242,7 @@ type RelationUnitsWatcherC struct {
 
 // NewRelationUnitsWatcherC returns a RelationUnitsWatcherC that
 // checks for aggressive event coalescence.
-func NewRelationUnitsWatcherC(c *gc.C, st *state.State, w RelationUnitsWatcher) RelationUnitsWatcherC {
+func NewRelationUnitsWatcherC(c *gc.C, st SyncStarter, w RelationUnitsWatcher) RelationUnitsWatcherC {
 	return RelationUnitsWatcherC{
 		C:                c,
 		State:            st,
@@ -0,0 +1,226 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
+// The following mirror the transaction states used internally by
+// mgo/txn. Only transactions in one of the terminal states may be
+// pruned.
+const (
+	txnAborted = 5
+	txnApplied = 6
+)
+
+// PruneResult holds the outcome of a call to PruneTransactions.
+type PruneResult struct {
+	// Pruned holds the number of completed transactions removed.
+	Pruned int
+
+	// Retained holds the number of completed transactions that were
+	// old enough to be pruned but are still referenced by a
+	// document's transaction queue.
+	Retained int
+}
+
+// TxnPruneStats holds the running totals recorded by
+// PruneTransactions, so that they can be published by the API
+// servers regardless of which state server ran the pruning.
+type TxnPruneStats struct {
+	// Runs holds the number of completed pruning passes.
+	Runs int64 `bson:"runs"`
+
+	// Pruned holds the total number of transactions removed.
+	Pruned int64 `bson:"pruned"`
+
+	// Retained holds the number of transactions kept back during
+	// the most recent pass because they were still referenced.
+	Retained int `bson:"retained"`
+
+	// LastRun holds the time the most recent pass completed.
+	LastRun time.Time `bson:"lastrun"`
+}
+
+// txnPruneStatsKey identifies the document holding the pruner's totals.
+const txnPruneStatsKey = "txnpruner"
+
+// txnPruneBatchSize holds the number of completed transactions
+// examined in each step of a pruning pass.
+var txnPruneBatchSize = 1000
+
+// errPruneStopped is returned by PruneTransactions when it is stopped
+// part way through a pass.
+var errPruneStopped = errors.New("transaction pruning stopped")
+
+// PruneTransactions removes completed transactions that were created
+// more than retention ago and are no longer referenced by any
+// document's transaction queue. Transactions still in progress are
+// never touched. The totals of each pass are recorded, and can be
+// read with TxnPruneStats.
+//
+// The transaction queues of all documents are read once per pass,
+// before any transactions are removed. Completed transactions cannot
+// be added to a queue again, so a transaction found unreferenced then
+// is still unreferenced when it is removed.
+//
+// If stop is closed, the pass is abandoned before its next batch of
+// transactions, and an error is returned; the transactions already
+// removed are not recorded in the totals.
+//
+// The transactions collection is not compacted, because compaction
+// blocks all operations on the database while it runs.
+func (st *State) PruneTransactions(retention time.Duration, stop <-chan struct{}) (PruneResult, error) {
+	return st.pruneTransactionsBefore(time.Now().Add(-retention), stop)
+}
+
+// pruneTransactionsBefore implements PruneTransactions, removing
+// eligible transactions created before the given time.
+func (st *State) pruneTransactionsBefore(t time.Time, stop <-chan struct{}) (PruneResult, error) {
+	var result PruneResult
+	db, closer := st.newDB()
+	defer closer()
+
+	txns := db.C(txnsC)
+	cutoff := bson.NewObjectIdWithTime(t)
+	referenced, err := referencedTxnIds(db, cutoff)
+	if err != nil {
+		return result, errors.Annotate(err, "cannot read transaction queues")
+	}
+	var after bson.ObjectId
+	for {
+		select {
+		case <-stop:
+			return result, errPruneStopped
+		default:
+		}
+		ids, err := completedTxnIds(txns, after, cutoff)
+		if err != nil {
+			return result, errors.Annotate(err, "cannot read completed transactions")
+		}
+		if len(ids) == 0 {
+			break
+		}
+		prune := make([]bson.ObjectId, 0, len(ids))
+		for _, id := range ids {
+			if referenced[id] {
+				result.Retained++
+				continue
+			}
+			prune = append(prune, id)
+		}
+		if len(prune) > 0 {
+			info, err := txns.RemoveAll(bson.D{{"_id", bson.D{{"$in", prune}}}})
+			if err != nil {
+				return result, errors.Annotate(err, "cannot remove completed transactions")
+			}
+			result.Pruned += info.Removed
+		}
+		if len(ids) < txnPruneBatchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+	if err := recordTxnPrune(db, result); err != nil {
+		return result, errors.Annotate(err, "cannot record pruned transactions")
+	}
+	return result, nil
+}
+
+// completedTxnIds returns, in order, the ids of up to txnPruneBatchSize
+// completed transactions created after the transaction with the given
+// id (if any) and before cutoff.
+func completedTxnIds(txns *mgo.Collection, after, cutoff bson.ObjectId) ([]bson.ObjectId, error) {
+	idRange := bson.D{{"$lt", cutoff}}
+	if after != "" {
+		idRange = append(idRange, bson.DocElem{"$gt", after})
+	}
+	iter := txns.Find(bson.D{
+		{"_id", idRange},
+		{"s", bson.D{{"$in", []int{txnAborted, txnApplied}}}},
+	}).Select(bson.D{{"_id", 1}}).Sort("_id").Limit(txnPruneBatchSize).Iter()
+	var doc struct {
+		Id bson.ObjectId `bson:"_id"`
+	}
+	var ids []bson.ObjectId
+	for iter.Next(&doc) {
+		ids = append(ids, doc.Id)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// referencedTxnIds returns the ids of the transactions created before
+// cutoff that are found in the transaction queue of any document in
+// the juju database. The txn-queue field is not indexed, so this reads
+// every collection in full; it is called once per pruning pass.
+func referencedTxnIds(db *mgo.Database, cutoff bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	names, err := db.CollectionNames()
+	if err != nil {
+		return nil, err
+	}
+	// Tokens are of the form "<txn id>_<nonce>", with the id in
+	// lower case hex, so they sort in the order of their ids, and
+	// every token for an id before cutoff sorts before cutoff's.
+	tokenRange := bson.D{{"$lt", cutoff.Hex()}}
+	referenced := make(map[bson.ObjectId]bool)
+	for _, name := range names {
+		if name == txnsC || name == txnLogC || name == txnPruneStatsC || name == "system.indexes" {
+			continue
+		}
+		iter := db.C(name).Find(bson.D{
+			{"txn-queue", bson.D{{"$elemMatch", tokenRange}}},
+		}).Select(bson.D{{"txn-queue", 1}}).Iter()
+		var doc struct {
+			Queue []string `bson:"txn-queue"`
+		}
+		for iter.Next(&doc) {
+			for _, token := range doc.Queue {
+				if len(token) < 24 || !bson.IsObjectIdHex(token[:24]) {
+					continue
+				}
+				id := bson.ObjectIdHex(token[:24])
+				if id < cutoff {
+					referenced[id] = true
+				}
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return referenced, nil
+}
+
+// recordTxnPrune adds the outcome of a pruning pass to the recorded
+// totals.
+func recordTxnPrune(db *mgo.Database, result PruneResult) error {
+	_, err := db.C(txnPruneStatsC).UpsertId(txnPruneStatsKey, bson.D{
+		{"$inc", bson.D{{"runs", 1}, {"pruned", result.Pruned}}},
+		{"$set", bson.D{{"retained", result.Retained}, {"lastrun", time.Now()}}},
+	})
+	return err
+}
+
+// TxnPruneStats returns the totals recorded by PruneTransactions.
+func (st *State) TxnPruneStats() (TxnPruneStats, error) {
+	var stats TxnPruneStats
+	coll, closer := st.getCollection(txnPruneStatsC)
+	defer closer()
+	err := coll.FindId(txnPruneStatsKey).One(&stats)
+	if err == mgo.ErrNotFound {
+		return TxnPruneStats{}, nil
+	}
+	if err != nil {
+		return TxnPruneStats{}, errors.Annotate(err, "cannot read transaction pruning totals")
+	}
+	return stats, nil
+}
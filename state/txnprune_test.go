@@ -0,0 +1,105 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+)
+
+type TxnPruneSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&TxnPruneSuite{})
+
+func (s *TxnPruneSuite) TestPruneRespectsRetention(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	before, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(before, gc.Not(gc.Equals), 0)
+
+	result, err := s.State.PruneTransactions(time.Hour, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.Equals, state.PruneResult{})
+
+	after, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(after, gc.Equals, before)
+}
+
+func (s *TxnPruneSuite) TestPruneRemovesCompletedTransactions(c *gc.C) {
+	wordpress := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	before, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+
+	result, err := state.PruneTransactionsBefore(s.State, time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Pruned > 0, gc.Equals, true)
+
+	after, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(after, gc.Equals, before-result.Pruned)
+	c.Assert(after, gc.Equals, result.Retained)
+
+	// Transactions can still be run against the pruned documents.
+	_, err = wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = wordpress.Destroy()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *TxnPruneSuite) TestPruneRecordsStats(c *gc.C) {
+	stats, err := s.State.TxnPruneStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats, gc.Equals, state.TxnPruneStats{})
+
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	first, err := state.PruneTransactionsBefore(s.State, time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "mysql", s.AddTestingCharm(c, "mysql"))
+	second, err := state.PruneTransactionsBefore(s.State, time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(second.Pruned > 0, gc.Equals, true)
+
+	stats, err = s.State.TxnPruneStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Runs, gc.Equals, int64(2))
+	c.Assert(stats.Pruned, gc.Equals, int64(first.Pruned+second.Pruned))
+	c.Assert(stats.Retained, gc.Equals, second.Retained)
+	c.Assert(stats.LastRun.IsZero(), gc.Equals, false)
+}
+
+func (s *TxnPruneSuite) TestPruneInBatches(c *gc.C) {
+	s.PatchValue(state.TxnPruneBatchSize, 2)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	before, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(before > 2, gc.Equals, true)
+
+	result, err := state.PruneTransactionsBefore(s.State, time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Pruned+result.Retained, gc.Equals, before)
+}
+
+func (s *TxnPruneSuite) TestPruneStopped(c *gc.C) {
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	before, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+
+	stop := make(chan struct{})
+	close(stop)
+	_, err = s.State.PruneTransactions(0, stop)
+	c.Assert(err, gc.ErrorMatches, "transaction pruning stopped")
+
+	after, err := state.TxnCount(s.State)
+	c.Assert(err, gc.IsNil)
+	c.Assert(after, gc.Equals, before)
+	stats, err := s.State.TxnPruneStats()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Runs, gc.Equals, int64(0))
+}
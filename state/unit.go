@@ -79,6 +79,15 @@ type unitDoc struct {
 	Life         Life
 	TxnRevno     int64 `bson:"txn-revno"`
 	PasswordHash string
+	PasswordSalt string `bson:",omitempty"`
+	// PasswordRotationRequired records that the unit agent
+	// must change its password the next time it connects.
+	PasswordRotationRequired bool
+
+	// WorkloadStatus and WorkloadStatusInfo hold the status of the
+	// unit's workload as last reported by its charm.
+	WorkloadStatus     params.WorkloadStatus
+	WorkloadStatusInfo string
 
 	// No longer used - to be removed.
 	PublicAddress  string
@@ -134,6 +143,20 @@ func (u *Unit) ConfigSettings() (charm.Settings, error) {
 	return result, nil
 }
 
+// ConfigSettingsVersion returns the generation of the service config
+// settings used by the unit's charm. It increases each time the
+// settings are changed.
+func (u *Unit) ConfigSettingsVersion() (int64, error) {
+	if u.doc.CharmURL == nil {
+		return 0, fmt.Errorf("unit charm not set")
+	}
+	settings, err := readSettings(u.st, serviceSettingsKey(u.doc.Service, u.doc.CharmURL))
+	if err != nil {
+		return 0, err
+	}
+	return settings.Version(), nil
+}
+
 // ServiceName returns the service name.
 func (u *Unit) ServiceName() string {
 	return u.doc.Service
@@ -213,24 +236,77 @@ func (u *Unit) SetPassword(password string) error {
 	if len(password) < utils.MinAgentPasswordLength {
 		return fmt.Errorf("password is only %d bytes long, and is not a valid Agent password", len(password))
 	}
-	return u.setPasswordHash(utils.AgentPasswordHash(password))
+	hash, salt, err := newAgentPasswordHash(password)
+	if err != nil {
+		return err
+	}
+	// Any required rotation is satisfied in the same transaction
+	// that changes the password, so the two cannot disagree.
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{
+			{"passwordhash", hash},
+			{"passwordsalt", salt},
+			{"passwordrotationrequired", false},
+		}}},
+	}}
+	if err := u.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot set password of unit %q: %v", u, onAbort(err, errDead))
+	}
+	u.doc.PasswordHash = hash
+	u.doc.PasswordSalt = salt
+	u.doc.PasswordRotationRequired = false
+	return nil
+}
+
+// RequirePasswordRotation records that the unit agent must change
+// its password the next time it connects.
+func (u *Unit) RequirePasswordRotation() error {
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{{"passwordrotationrequired", true}}}},
+	}}
+	if err := u.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot require password rotation of unit %q: %v", u, onAbort(err, errDead))
+	}
+	u.doc.PasswordRotationRequired = true
+	return nil
+}
+
+// PasswordRotationRequired returns whether the unit agent must
+// change its password the next time it connects.
+func (u *Unit) PasswordRotationRequired() bool {
+	return u.doc.PasswordRotationRequired
 }
 
 // setPasswordHash sets the underlying password hash in the database directly
-// to the value supplied. This is split out from SetPassword to allow direct
-// manipulation in tests (to check for backwards compatibility).
+// to the value supplied, without a salt. This is split out from SetPassword
+// to allow direct manipulation in tests (to check for backwards compatibility).
 func (u *Unit) setPasswordHash(passwordHash string) error {
+	return u.setPasswordHashAndSalt(passwordHash, "")
+}
+
+// setPasswordHashAndSalt sets the password hash and the salt
+// it was made with in the database.
+func (u *Unit) setPasswordHashAndSalt(passwordHash, passwordSalt string) error {
 	ops := []txn.Op{{
 		C:      unitsC,
 		Id:     u.doc.Name,
 		Assert: notDeadDoc,
-		Update: bson.D{{"$set", bson.D{{"passwordhash", passwordHash}}}},
+		Update: bson.D{{"$set", bson.D{
+			{"passwordhash", passwordHash},
+			{"passwordsalt", passwordSalt},
+		}}},
 	}}
-	err := u.st.runTransaction(ops)
-	if err != nil {
+	if err := u.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set password of unit %q: %v", u, onAbort(err, errDead))
 	}
 	u.doc.PasswordHash = passwordHash
+	u.doc.PasswordSalt = passwordSalt
 	return nil
 }
 
@@ -244,22 +320,17 @@ func (u *Unit) getPasswordHash() string {
 // PasswordValid returns whether the given password is valid
 // for the given unit.
 func (u *Unit) PasswordValid(password string) bool {
-	agentHash := utils.AgentPasswordHash(password)
-	if agentHash == u.doc.PasswordHash {
-		return true
-	}
-	// In Juju 1.16 and older we used the slower password hash for unit
-	// agents. So check to see if the supplied password matches the old
-	// path, and if so, update it to the new mechanism.
-	// We ignore any error in setting the password hash, as we'll just try
-	// again next time
-	if utils.UserPasswordHash(password, utils.CompatSalt) == u.doc.PasswordHash {
-		logger.Debugf("%s logged in with old password hash, changing to AgentPasswordHash",
-			u.Tag())
-		u.setPasswordHash(agentHash)
-		return true
+	valid, upgrade := agentPasswordValid(password, u.doc.PasswordHash, u.doc.PasswordSalt)
+	if upgrade {
+		// The password hash was stored by an earlier version without
+		// a salt, so replace it with a salted one. We ignore any error
+		// in doing so, as we'll just try again next time.
+		logger.Debugf("%s logged in with unsalted password hash, changing to salted hash", u.Tag())
+		if hash, salt, err := newAgentPasswordHash(password); err == nil {
+			u.setPasswordHashAndSalt(hash, salt)
+		}
 	}
-	return false
+	return valid
 }
 
 // Destroy, when called on a Alive unit, advances its lifecycle as far as
@@ -736,9 +807,50 @@ func (u *Unit) SetStatus(status params.Status, info string, data params.StatusDa
 	return nil
 }
 
+// WorkloadStatus returns the status of the unit's workload as last
+// reported by its charm, along with any accompanying message.
+func (u *Unit) WorkloadStatus() (status params.WorkloadStatus, info string) {
+	if u.doc.WorkloadStatus == "" {
+		return params.WorkloadStatusUnknown, ""
+	}
+	return u.doc.WorkloadStatus, u.doc.WorkloadStatusInfo
+}
+
+// SetWorkloadStatus records the status of the unit's workload, as
+// reported by its charm, along with an optional message.
+func (u *Unit) SetWorkloadStatus(status params.WorkloadStatus, info string) error {
+	if !status.Valid() || status == params.WorkloadStatusUnknown {
+		return fmt.Errorf("cannot set invalid workload status %q", status)
+	}
+	ops := []txn.Op{{
+		C:      unitsC,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+		Update: bson.D{{"$set", bson.D{
+			{"workloadstatus", status},
+			{"workloadstatusinfo", info},
+		}}},
+	}}
+	err := u.st.runTransaction(ops)
+	if err != nil {
+		return fmt.Errorf("cannot set workload status of unit %q: %v", u, onAbort(err, errDead))
+	}
+	u.doc.WorkloadStatus = status
+	u.doc.WorkloadStatusInfo = info
+	return nil
+}
+
 // OpenPort sets the policy of the port with protocol and number to be opened.
-func (u *Unit) OpenPort(protocol string, number int) (err error) {
-	ports, err := NewPortRange(u.Name(), number, number, protocol)
+func (u *Unit) OpenPort(protocol string, number int) error {
+	return u.OpenPorts(protocol, number, number)
+}
+
+// OpenPorts sets the policy of the ports in the given range to be
+// opened. The range must not overlap any range already opened on the
+// unit's machine for the same protocol. ICMP is opened with both port
+// numbers set to -1.
+func (u *Unit) OpenPorts(protocol string, fromPort, toPort int) (err error) {
+	ports, err := NewPortRange(u.Name(), fromPort, toPort, protocol)
 	if err != nil {
 		return err
 	}
@@ -774,7 +886,9 @@ func (u *Unit) OpenPort(protocol string, number int) (err error) {
 		return err
 	}
 	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
-	return u.openUnitPort(protocol, number)
+	// Ranges cannot overlap, so the first port of a range
+	// identifies it in the unit document.
+	return u.openUnitPort(ports.Protocol, fromPort)
 }
 
 // openUnitPort is the old implementation of OpenPort that amends the list of ports on the unit document.
@@ -833,8 +947,14 @@ func (u *Unit) closeUnitPort(protocol string, number int) (err error) {
 }
 
 // ClosePort sets the policy of the port with protocol and number to be closed.
-func (u *Unit) ClosePort(protocol string, number int) (err error) {
-	ports, err := NewPortRange(u.Name(), number, number, protocol)
+func (u *Unit) ClosePort(protocol string, number int) error {
+	return u.ClosePorts(protocol, number, number)
+}
+
+// ClosePorts sets the policy of the ports in the given range to be
+// closed. The range must match one previously opened by the unit.
+func (u *Unit) ClosePorts(protocol string, fromPort, toPort int) (err error) {
+	ports, err := NewPortRange(u.Name(), fromPort, toPort, protocol)
 	if err != nil {
 		return err
 	}
@@ -870,12 +990,12 @@ func (u *Unit) ClosePort(protocol string, number int) (err error) {
 		return err
 	}
 	// TODO(domas) 2014-07-04 bug #1337813: remove once firewaller is updated to watch openedPorts collection
-	return u.closeUnitPort(protocol, number)
+	return u.closeUnitPort(ports.Protocol, fromPort)
 }
 
-// OpenedPorts returns a slice containing the open ports of the unit.
-// TODO(domas) 2014-07-04 but #1337817: update this function to return port ranges.
-func (u *Unit) OpenedPorts() []network.Port {
+// OpenedPorts returns a slice containing the port ranges opened by
+// the unit, sorted by protocol and then by port.
+func (u *Unit) OpenedPorts() []network.PortRange {
 	machineId, err := u.AssignedMachineId()
 	if err != nil {
 		unitLogger.Errorf("Cannot retrieve opened ports list for unit %v: %v", u, err)
@@ -883,20 +1003,23 @@ func (u *Unit) OpenedPorts() []network.Port {
 	}
 
 	machinePorts, err := getPorts(u.st, machineId)
-	result := []network.Port{}
+	result := []network.PortRange{}
 	if err == nil {
-		ports := machinePorts.PortsForUnit(u.Name())
-		for _, port := range ports {
-			result = append(result, network.Port{
-				Protocol: port.Protocol,
-				Number:   port.FromPort})
+		for _, port := range machinePorts.PortsForUnit(u.Name()) {
+			result = append(result, port.NetworkPortRange())
 		}
 	} else {
 		// Read the port list in the unit document if the ports
 		// document does not exist.
-		result = append([]network.Port{}, u.doc.Ports...)
+		for _, port := range u.doc.Ports {
+			result = append(result, network.PortRange{
+				FromPort: port.Number,
+				ToPort:   port.Number,
+				Protocol: port.Protocol,
+			})
+		}
 	}
-	network.SortPorts(result)
+	network.SortPortRanges(result)
 	return result
 }
 
@@ -978,6 +1101,12 @@ func (u *Unit) AgentPresence() (bool, error) {
 	return u.st.pwatcher.Alive(u.globalKey())
 }
 
+// AgentLastSeen returns when the respective remote agent was last
+// known to be alive, or the zero time if that is not known.
+func (u *Unit) AgentLastSeen() (time.Time, error) {
+	return u.st.pwatcher.LastSeen(u.globalKey())
+}
+
 // Tag returns a name identifying the unit.
 // The returned name will be different from other Tag values returned by any
 // other entities from the same state.
@@ -1167,6 +1296,125 @@ func (u *Unit) AssignToMachine(m *Machine) (err error) {
 	return u.assignToMachine(m, false)
 }
 
+// MoveToMachine moves this unit, together with its subordinates, from
+// the machine it is assigned to onto the given machine. The deployer on
+// the given machine then deploys a new agent for the unit, and the one
+// on the old machine recalls the old agent. The ports opened by the unit
+// and its subordinates are moved with them. Once the unit is moved, its
+// settings in every relation it is in scope for are updated with its new
+// private address, if known, so that related units observe the move.
+func (u *Unit) MoveToMachine(m *Machine) (err error) {
+	defer errors.Maskf(&err, "cannot move unit %q to machine %s", u, m)
+	if u.doc.Principal != "" {
+		return fmt.Errorf("unit is a subordinate")
+	}
+	if u.doc.Series != m.doc.Series {
+		return fmt.Errorf("series does not match")
+	}
+	if !hasJob(m.doc.Jobs, JobHostUnits) {
+		return fmt.Errorf("machine %q cannot host units", m)
+	}
+	if err := u.st.supportsUnitPlacement(); err != nil {
+		return err
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := u.Refresh(); err != nil {
+				return nil, err
+			}
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if u.doc.Life != Alive {
+			return nil, unitNotAliveErr
+		}
+		if m.doc.Life != Alive {
+			return nil, machineNotAliveErr
+		}
+		switch u.doc.MachineId {
+		case "":
+			return nil, fmt.Errorf("unit is not assigned to a machine")
+		case m.doc.Id:
+			return nil, jujutxn.ErrNoOperations
+		}
+		// The ports opened by the unit and its subordinates
+		// move with them.
+		portsOps, err := movePortsOps(u.st, u.doc.MachineId, m.doc.Id,
+			append([]string{u.doc.Name}, u.doc.Subordinates...)...)
+		if err != nil {
+			return nil, err
+		}
+		ops := []txn.Op{{
+			C:      unitsC,
+			Id:     u.doc.Name,
+			Assert: append(isAliveDoc, bson.DocElem{"machineid", u.doc.MachineId}),
+			Update: bson.D{{"$set", bson.D{{"machineid", m.doc.Id}}}},
+		}, {
+			C:      machinesC,
+			Id:     u.doc.MachineId,
+			Assert: txn.DocExists,
+			Update: bson.D{{"$pull", bson.D{{"principals", u.doc.Name}}}},
+		}, {
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: isAliveDoc,
+			Update: bson.D{{"$addToSet", bson.D{{"principals", u.doc.Name}}}, {"$set", bson.D{{"clean", false}}}},
+		}}
+		return append(ops, portsOps...), nil
+	}
+	if err := u.st.run(buildTxn); err != nil {
+		return err
+	}
+	u.doc.MachineId = m.doc.Id
+	m.doc.Clean = false
+	return u.updateRelationAddresses()
+}
+
+// updateRelationAddresses sets the private-address setting of the unit
+// and its subordinates, in every relation they are in scope for, to the
+// address of the machine they are now assigned to.
+func (u *Unit) updateRelationAddresses() error {
+	units := []*Unit{u}
+	for _, name := range u.doc.Subordinates {
+		sub, err := u.st.Unit(name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		units = append(units, sub)
+	}
+	for _, unit := range units {
+		address, ok := unit.PrivateAddress()
+		if !ok {
+			continue
+		}
+		relations, err := unit.RelationsInScope()
+		if err != nil {
+			return err
+		}
+		for _, rel := range relations {
+			ru, err := rel.Unit(unit)
+			if err != nil {
+				return err
+			}
+			settings, err := ru.Settings()
+			if err != nil {
+				return err
+			}
+			if settings.Map()["private-address"] == address {
+				continue
+			}
+			settings.Set("private-address", address)
+			if _, err := settings.Write(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // assignToNewMachine assigns the unit to a machine created according to
 // the supplied params, with the supplied constraints.
 func (u *Unit) assignToNewMachine(template MachineTemplate, parentId string, containerType instance.ContainerType) error {
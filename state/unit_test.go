@@ -69,6 +69,27 @@ func (s *UnitSuite) TestConfigSettingsIncludeDefaults(c *gc.C) {
 	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "My Title"})
 }
 
+func (s *UnitSuite) TestConfigSettingsVersion(c *gc.C) {
+	_, err := s.unit.ConfigSettingsVersion()
+	c.Assert(err, gc.ErrorMatches, "unit charm not set")
+
+	err = s.unit.SetCharmURL(s.charm.URL())
+	c.Assert(err, gc.IsNil)
+	version, err := s.unit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
+
+	// Reading the settings does not change the version.
+	again, err := s.unit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.Equals, version)
+
+	err = s.service.UpdateConfigSettings(charm.Settings{"blog-title": "no title"})
+	c.Assert(err, gc.IsNil)
+	changed, err := s.unit.ConfigSettingsVersion()
+	c.Assert(err, gc.IsNil)
+	c.Assert(changed > version, gc.Equals, true)
+}
+
 func (s *UnitSuite) TestConfigSettingsReflectService(c *gc.C) {
 	err := s.service.UpdateConfigSettings(charm.Settings{"blog-title": "no title"})
 	c.Assert(err, gc.IsNil)
@@ -580,6 +601,39 @@ func (s *UnitSuite) TestGetSetStatusWhileNotAlive(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, "status not found")
 }
 
+func (s *UnitSuite) TestGetSetWorkloadStatus(c *gc.C) {
+	status, info := s.unit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusUnknown)
+	c.Assert(info, gc.Equals, "")
+
+	err := s.unit.SetWorkloadStatus(params.WorkloadStatus("vliegkat"), "orville")
+	c.Assert(err, gc.ErrorMatches, `cannot set invalid workload status "vliegkat"`)
+	err = s.unit.SetWorkloadStatus(params.WorkloadStatusUnknown, "")
+	c.Assert(err, gc.ErrorMatches, `cannot set invalid workload status "unknown"`)
+
+	err = s.unit.SetWorkloadStatus(params.WorkloadStatusBlocked, "waiting for db relation")
+	c.Assert(err, gc.IsNil)
+	status, info = s.unit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusBlocked)
+	c.Assert(info, gc.Equals, "waiting for db relation")
+
+	// The workload status is independent of the agent status.
+	agentStatus, _, _, err := s.unit.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(agentStatus, gc.Equals, params.StatusPending)
+
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, info = s.unit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusBlocked)
+	c.Assert(info, gc.Equals, "waiting for db relation")
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetWorkloadStatus(params.WorkloadStatusActive, "")
+	c.Assert(err, gc.ErrorMatches, `cannot set workload status of unit "wordpress/0": not found or dead`)
+}
+
 func (s *UnitSuite) TestGetSetStatusDataStandard(c *gc.C) {
 	err := s.unit.SetStatus(params.StatusStarted, "", nil)
 	c.Assert(err, gc.IsNil)
@@ -876,6 +930,18 @@ func (s *UnitSuite) TestSetPassword(c *gc.C) {
 	})
 }
 
+func (s *UnitSuite) TestPasswordSalted(c *gc.C) {
+	testAgentPasswordSalted(c, func() (state.Authenticator, error) {
+		return s.State.Unit(s.unit.Name())
+	})
+}
+
+func (s *UnitSuite) TestPasswordRotation(c *gc.C) {
+	testPasswordRotation(c, func() (passwordRotator, error) {
+		return s.State.Unit(s.unit.Name())
+	})
+}
+
 func (s *UnitSuite) TestSetAgentCompatPassword(c *gc.C) {
 	e, err := s.State.Unit(s.unit.Name())
 	c.Assert(err, gc.IsNil)
@@ -964,6 +1030,21 @@ func (s *UnitSuite) TestUnitSetAgentPresence(c *gc.C) {
 	c.Assert(alive, gc.Equals, true)
 }
 
+func (s *UnitSuite) TestUnitAgentLastSeen(c *gc.C) {
+	lastSeen, err := s.unit.AgentLastSeen()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.IsZero(), gc.Equals, true)
+
+	pinger, err := s.unit.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+
+	s.State.StartSync()
+	lastSeen, err = s.unit.AgentLastSeen()
+	c.Assert(err, gc.IsNil)
+	c.Assert(lastSeen.IsZero(), gc.Equals, false)
+}
+
 func (s *UnitSuite) TestUnitWaitAgentPresence(c *gc.C) {
 	alive, err := s.unit.AgentPresence()
 	c.Assert(err, gc.IsNil)
@@ -1062,56 +1143,111 @@ func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	err = s.unit.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 	open := s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 80},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{80, 80, "tcp"},
 	})
 
 	err = s.unit.OpenPort("udp", 53)
 	c.Assert(err, gc.IsNil)
 	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 80},
-		{"udp", 53},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{80, 80, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.OpenPort("tcp", 53)
 	c.Assert(err, gc.IsNil)
 	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 80},
-		{"udp", 53},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{80, 80, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.OpenPort("tcp", 443)
 	c.Assert(err, gc.IsNil)
 	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 80},
-		{"tcp", 443},
-		{"udp", 53},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{80, 80, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 443},
-		{"udp", 53},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 
 	err = s.unit.ClosePort("tcp", 80)
 	c.Assert(err, gc.ErrorMatches, ".* no match found for port range: .*")
 	open = s.unit.OpenedPorts()
-	c.Assert(open, gc.DeepEquals, []network.Port{
-		{"tcp", 53},
-		{"tcp", 443},
-		{"udp", 53},
+	c.Assert(open, gc.DeepEquals, []network.PortRange{
+		{53, 53, "tcp"},
+		{443, 443, "tcp"},
+		{53, 53, "udp"},
 	})
 }
 
+func (s *UnitSuite) TestOpenedPortRanges(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.OpenPorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.OpenPort("udp", 53)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.OpenedPorts(), gc.DeepEquals, []network.PortRange{
+		{-1, -1, "icmp"},
+		{8000, 8002, "tcp"},
+		{53, 53, "udp"},
+	})
+
+	// Overlapping ranges are rejected, whichever unit opened them.
+	err = s.unit.OpenPorts("tcp", 7990, 8000)
+	c.Assert(err, gc.ErrorMatches, `cannot open ports 7990-8000/tcp for unit "wordpress/0": .* due to conflict`)
+	unit1, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit1.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = unit1.OpenPort("tcp", 8001)
+	c.Assert(err, gc.ErrorMatches, `cannot open ports 8001-8001/tcp for unit "wordpress/1": .* due to conflict`)
+	err = unit1.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+
+	// Only whole ranges can be closed.
+	err = s.unit.ClosePort("tcp", 8001)
+	c.Assert(err, gc.ErrorMatches, ".* no match found for port range: .*")
+	err = s.unit.ClosePorts("tcp", 8000, 8002)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.OpenedPorts(), gc.DeepEquals, []network.PortRange{
+		{-1, -1, "icmp"},
+		{53, 53, "udp"},
+	})
+}
+
+func (s *UnitSuite) TestOpenInvalidPortRange(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.OpenPorts("tcp", 90, 80)
+	c.Assert(err, gc.ErrorMatches, `Port range 90-80/tcp for unit wordpress/0 is invalid.`)
+	err = s.unit.OpenPorts("tcp", 0, 80)
+	c.Assert(err, gc.ErrorMatches, `Port range 0-80/tcp for unit wordpress/0 is invalid.`)
+	err = s.unit.OpenPorts("icmp", 80, 80)
+	c.Assert(err, gc.ErrorMatches, `Port range 80-80/icmp for unit wordpress/0 is invalid.`)
+}
+
 func (s *UnitSuite) TestOpenClosePortWhenDying(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
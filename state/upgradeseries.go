@@ -0,0 +1,201 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	jujutxn "github.com/juju/txn"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// UpgradeSeriesStatus returns the progress of any in-place upgrade of
+// the machine's OS series, and the series being upgraded to.
+func (m *Machine) UpgradeSeriesStatus() (params.UpgradeSeriesStatus, string) {
+	return m.doc.UpgradeSeriesStatus, m.doc.UpgradeSeriesTarget
+}
+
+// PrepareSeriesUpgrade marks the machine as preparing for an in-place
+// upgrade of its OS to the given series. The machine agent responds by
+// stopping the unit agents on the machine. It fails if the machine is
+// not Alive, if an upgrade is already in progress, or if any unit on the
+// machine has a charm that cannot run on the given series.
+func (m *Machine) PrepareSeriesUpgrade(series string) (err error) {
+	defer errors.Maskf(&err, "cannot prepare series upgrade of machine %v to %q", m, series)
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.Life != Alive {
+			return nil, errNotAlive
+		}
+		if m.doc.UpgradeSeriesStatus != params.UpgradeSeriesNotStarted {
+			return nil, fmt.Errorf("upgrade to %q already in progress", m.doc.UpgradeSeriesTarget)
+		}
+		if series == m.doc.Series {
+			return nil, fmt.Errorf("machine is already running series %q", series)
+		}
+		if err := m.checkUnitsSeries(series); err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:  machinesC,
+			Id: m.doc.Id,
+			Assert: append(isAliveDoc,
+				bson.DocElem{"upgradeseriesstatus", bson.D{{"$exists", false}}},
+				principalsUnchangedDoc(m.doc.Principals),
+			),
+			Update: bson.D{{"$set", bson.D{
+				{"upgradeseriesstatus", params.UpgradeSeriesPreparing},
+				{"upgradeseriestarget", series},
+			}}},
+		}}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	m.doc.UpgradeSeriesStatus = params.UpgradeSeriesPreparing
+	m.doc.UpgradeSeriesTarget = series
+	return nil
+}
+
+// prevUpgradeSeriesStatus holds, for each status that the machine agent
+// may set, the status the upgrade must be in beforehand.
+var prevUpgradeSeriesStatus = map[params.UpgradeSeriesStatus]params.UpgradeSeriesStatus{
+	params.UpgradeSeriesPrepared: params.UpgradeSeriesPreparing,
+	params.UpgradeSeriesUpgraded: params.UpgradeSeriesPrepared,
+}
+
+// SetUpgradeSeriesStatus records the machine agent's progress through
+// a series upgrade. The agent sets UpgradeSeriesPrepared once it has
+// stopped the unit agents, and UpgradeSeriesUpgraded once it finds the
+// machine running the target series.
+func (m *Machine) SetUpgradeSeriesStatus(status params.UpgradeSeriesStatus) (err error) {
+	defer errors.Maskf(&err, "cannot set series upgrade status of machine %v to %q", m, status)
+	prev, ok := prevUpgradeSeriesStatus[status]
+	if !ok {
+		return fmt.Errorf("invalid status")
+	}
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		switch m.doc.UpgradeSeriesStatus {
+		case status:
+			return nil, jujutxn.ErrNoOperations
+		case prev:
+		default:
+			return nil, fmt.Errorf("series upgrade is %q, not %q", m.doc.UpgradeSeriesStatus, prev)
+		}
+		return []txn.Op{{
+			C:      machinesC,
+			Id:     m.doc.Id,
+			Assert: bson.D{{"upgradeseriesstatus", prev}},
+			Update: bson.D{{"$set", bson.D{{"upgradeseriesstatus", status}}}},
+		}}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	m.doc.UpgradeSeriesStatus = status
+	return nil
+}
+
+// CompleteSeriesUpgrade records the machine as running the series it
+// was upgraded to, and ends the upgrade so that the machine agent
+// restarts the unit agents. It fails unless the machine agent has found
+// the machine running the target series, or if any unit on the machine
+// has a charm that cannot run on that series.
+func (m *Machine) CompleteSeriesUpgrade() (err error) {
+	defer errors.Maskf(&err, "cannot complete series upgrade of machine %v", m)
+	var series string
+	buildTxn := func(attempt int) ([]txn.Op, error) {
+		if attempt > 0 {
+			if err := m.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+		if m.doc.UpgradeSeriesStatus == params.UpgradeSeriesNotStarted {
+			return nil, fmt.Errorf("no series upgrade in progress")
+		}
+		if m.doc.UpgradeSeriesStatus != params.UpgradeSeriesUpgraded {
+			return nil, fmt.Errorf("machine is not yet running series %q", m.doc.UpgradeSeriesTarget)
+		}
+		series = m.doc.UpgradeSeriesTarget
+		if err := m.checkUnitsSeries(series); err != nil {
+			return nil, err
+		}
+		return []txn.Op{{
+			C:  machinesC,
+			Id: m.doc.Id,
+			Assert: bson.D{
+				{"upgradeseriesstatus", params.UpgradeSeriesUpgraded},
+				principalsUnchangedDoc(m.doc.Principals),
+			},
+			Update: bson.D{
+				{"$set", bson.D{{"series", series}}},
+				{"$unset", bson.D{
+					{"upgradeseriesstatus", nil},
+					{"upgradeseriestarget", nil},
+				}},
+			},
+		}}, nil
+	}
+	if err := m.st.run(buildTxn); err != nil {
+		return err
+	}
+	m.doc.Series = series
+	m.doc.UpgradeSeriesStatus = params.UpgradeSeriesNotStarted
+	m.doc.UpgradeSeriesTarget = ""
+	return nil
+}
+
+// checkUnitsSeries returns an error if any unit on the machine has a
+// charm for a series other than the given one. A unit's charm is the
+// one it is running, or the one it will be given if it has yet to
+// start running any.
+func (m *Machine) checkUnitsSeries(series string) error {
+	units, err := m.Units()
+	if err != nil {
+		return err
+	}
+	services := make(map[string]*Service)
+	for _, u := range units {
+		curl, ok := u.CharmURL()
+		if !ok {
+			svc, found := services[u.ServiceName()]
+			if !found {
+				if svc, err = u.Service(); err != nil {
+					return err
+				}
+				services[u.ServiceName()] = svc
+			}
+			curl, _ = svc.CharmURLForUnit(u.Name())
+		}
+		if curl.Series != series {
+			return fmt.Errorf("unit %q has a charm for series %q", u.Name(), curl.Series)
+		}
+	}
+	return nil
+}
+
+// principalsUnchangedDoc returns an assertion that a machine's principal
+// units are exactly those given.
+func principalsUnchangedDoc(principals []string) bson.DocElem {
+	if len(principals) == 0 {
+		return bson.DocElem{"$or", []bson.D{
+			{{"principals", bson.D{{"$size", 0}}}},
+			{{"principals", bson.D{{"$exists", false}}}},
+		}}
+	}
+	return bson.DocElem{"principals", principals}
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+)
+
+type UpgradeSeriesSuite struct {
+	ConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&UpgradeSeriesSuite{})
+
+func (s *UpgradeSeriesSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UpgradeSeriesSuite) assertStatus(c *gc.C, status params.UpgradeSeriesStatus, series string) {
+	err := s.machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	gotStatus, gotSeries := s.machine.UpgradeSeriesStatus()
+	c.Assert(gotStatus, gc.Equals, status)
+	c.Assert(gotSeries, gc.Equals, series)
+}
+
+func (s *UpgradeSeriesSuite) TestUpgradeSeries(c *gc.C) {
+	s.assertStatus(c, params.UpgradeSeriesNotStarted, "")
+
+	err := s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+	s.assertStatus(c, params.UpgradeSeriesPreparing, "trusty")
+
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+	s.assertStatus(c, params.UpgradeSeriesPrepared, "trusty")
+
+	// Setting the same status again is a no-op.
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.CompleteSeriesUpgrade()
+	c.Assert(err, gc.ErrorMatches, `cannot complete series upgrade of machine 0: machine is not yet running series "trusty"`)
+
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+	c.Assert(err, gc.IsNil)
+	s.assertStatus(c, params.UpgradeSeriesUpgraded, "trusty")
+
+	err = s.machine.CompleteSeriesUpgrade()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.machine.Series(), gc.Equals, "trusty")
+	s.assertStatus(c, params.UpgradeSeriesNotStarted, "")
+}
+
+func (s *UpgradeSeriesSuite) TestPrepareSeriesUpgradeErrors(c *gc.C) {
+	err := s.machine.PrepareSeriesUpgrade("quantal")
+	c.Assert(err, gc.ErrorMatches, `cannot prepare series upgrade of machine 0 to "quantal": machine is already running series "quantal"`)
+
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+	err = s.machine.PrepareSeriesUpgrade("utopic")
+	c.Assert(err, gc.ErrorMatches, `cannot prepare series upgrade of machine 0 to "utopic": upgrade to "trusty" already in progress`)
+}
+
+func (s *UpgradeSeriesSuite) TestPrepareSeriesUpgradeIncompatibleUnit(c *gc.C) {
+	unit, err := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress")).AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.ErrorMatches, `cannot prepare series upgrade of machine 0 to "trusty": unit "wordpress/0" has a charm for series "quantal"`)
+	s.assertStatus(c, params.UpgradeSeriesNotStarted, "")
+}
+
+func (s *UpgradeSeriesSuite) TestPrepareSeriesUpgradeChecksUnitCharm(c *gc.C) {
+	unit, err := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress")).AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	// The charm the unit is running is checked, rather than
+	// the series the unit was deployed with.
+	err = unit.SetCharmURL(s.AddSeriesCharm(c, "wordpress", "trusty").URL())
+	c.Assert(err, gc.IsNil)
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+	s.assertStatus(c, params.UpgradeSeriesPreparing, "trusty")
+}
+
+func (s *UpgradeSeriesSuite) TestPrepareSeriesUpgradeDyingMachine(c *gc.C) {
+	err := s.machine.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.ErrorMatches, `cannot prepare series upgrade of machine 0 to "trusty": not found or not alive`)
+}
+
+func (s *UpgradeSeriesSuite) TestSetUpgradeSeriesStatusErrors(c *gc.C) {
+	err := s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPreparing)
+	c.Assert(err, gc.ErrorMatches, `cannot set series upgrade status of machine 0 to "preparing": invalid status`)
+
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	c.Assert(err, gc.ErrorMatches, `cannot set series upgrade status of machine 0 to "prepared": series upgrade is "", not "preparing"`)
+
+	err = s.machine.PrepareSeriesUpgrade("trusty")
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+	c.Assert(err, gc.ErrorMatches, `cannot set series upgrade status of machine 0 to "upgraded": series upgrade is "preparing", not "prepared"`)
+}
+
+func (s *UpgradeSeriesSuite) TestCompleteSeriesUpgradeNotStarted(c *gc.C) {
+	err := s.machine.CompleteSeriesUpgrade()
+	c.Assert(err, gc.ErrorMatches, "cannot complete series upgrade of machine 0: no series upgrade in progress")
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/juju/juju/environs/config"
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/watcher"
 )
@@ -1516,6 +1517,126 @@ func (w *machineAddressesWatcher) loop() error {
 	}
 }
 
+// unitPublicAddressWatcher notifies about changes to a unit's public
+// address, which follows the addresses of the machine the unit (or, for
+// a subordinate, its principal) is assigned to.
+type unitPublicAddressWatcher struct {
+	commonWatcher
+	unit      *Unit
+	machineId string
+	address   string
+	out       chan struct{}
+}
+
+var _ Watcher = (*unitPublicAddressWatcher)(nil)
+
+// WatchPublicAddress returns a new NotifyWatcher watching u's public
+// address. The first event is sent immediately; after that, an event is
+// sent whenever the address returned by u.PublicAddress changes.
+func (u *Unit) WatchPublicAddress() NotifyWatcher {
+	return newUnitPublicAddressWatcher(u)
+}
+
+func newUnitPublicAddressWatcher(u *Unit) NotifyWatcher {
+	w := &unitPublicAddressWatcher{
+		commonWatcher: commonWatcher{st: u.st},
+		out:           make(chan struct{}),
+		unit:          &Unit{st: u.st, doc: u.doc}, // Copy so it may be freely refreshed
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// Changes returns the event channel for w.
+func (w *unitPublicAddressWatcher) Changes() <-chan struct{} {
+	return w.out
+}
+
+func (w *unitPublicAddressWatcher) loop() error {
+	// Assignments are recorded on the principal.
+	unitName := w.unit.doc.Name
+	if !w.unit.IsPrincipal() {
+		unitName = w.unit.doc.Principal
+	}
+	unitCh := make(chan watcher.Change)
+	w.st.watcher.Watch(unitsC, unitName, -1, unitCh)
+	defer w.st.watcher.Unwatch(unitsC, unitName, unitCh)
+	machineCh := make(chan watcher.Change)
+	defer func() {
+		if w.machineId != "" {
+			w.st.watcher.Unwatch(machinesC, w.machineId, machineCh)
+		}
+	}()
+	if _, err := w.update(machineCh); err != nil {
+		return err
+	}
+	out := w.out
+	for {
+		var changed bool
+		var err error
+		select {
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-unitCh:
+			changed, err = w.update(machineCh)
+		case <-machineCh:
+			changed, err = w.update(machineCh)
+		case out <- struct{}{}:
+			out = nil
+		}
+		if err != nil {
+			return err
+		}
+		if changed {
+			out = w.out
+		}
+	}
+}
+
+// update finds the unit's current machine and public address, moving
+// the watch on the machine if the unit has been assigned elsewhere, and
+// reports whether the address has changed.
+func (w *unitPublicAddressWatcher) update(machineCh chan watcher.Change) (bool, error) {
+	if w.unit.IsPrincipal() {
+		if err := w.unit.Refresh(); err != nil {
+			return false, err
+		}
+	}
+	machineId, err := w.unit.AssignedMachineId()
+	if IsNotAssigned(err) {
+		machineId = ""
+	} else if err != nil {
+		return false, err
+	}
+	if machineId != w.machineId {
+		if w.machineId != "" {
+			w.st.watcher.Unwatch(machinesC, w.machineId, machineCh)
+		}
+		if machineId != "" {
+			w.st.watcher.Watch(machinesC, machineId, -1, machineCh)
+		}
+		w.machineId = machineId
+	}
+	var address string
+	if machineId != "" {
+		machine, err := w.st.Machine(machineId)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		} else if err == nil {
+			address = network.SelectPublicAddress(machine.Addresses())
+		}
+	}
+	changed := address != w.address
+	w.address = address
+	return changed, nil
+}
+
 // cleanupWatcher notifies of changes in the cleanups collection.
 type cleanupWatcher struct {
 	commonWatcher
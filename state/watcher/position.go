@@ -0,0 +1,48 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package watcher
+
+import (
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+)
+
+// collectionPositions implements PositionStore by holding
+// positions in a mongo collection, keyed by owner.
+type collectionPositions struct {
+	coll  *mgo.Collection
+	owner string
+}
+
+// NewCollectionPositions returns a PositionStore that records the
+// changelog position for the given owner in a document in coll.
+// Each watcher that records its position should use a distinct
+// owner.
+func NewCollectionPositions(coll *mgo.Collection, owner string) PositionStore {
+	return &collectionPositions{coll: coll, owner: owner}
+}
+
+type positionDoc struct {
+	Owner    string      `bson:"_id"`
+	Position interface{} `bson:"position"`
+}
+
+// LastPosition implements PositionStore.LastPosition.
+func (p *collectionPositions) LastPosition() (interface{}, error) {
+	var doc positionDoc
+	err := p.coll.FindId(p.owner).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Position, nil
+}
+
+// SetLastPosition implements PositionStore.SetLastPosition.
+func (p *collectionPositions) SetLastPosition(id interface{}) error {
+	_, err := p.coll.UpsertId(p.owner, bson.D{{"$set", bson.D{{"position", id}}}})
+	return err
+}
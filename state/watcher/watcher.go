@@ -49,6 +49,59 @@ type Watcher struct {
 
 	// lastId is the most recent transaction id observed by a sync.
 	lastId interface{}
+
+	// positions, if not nil, records lastId so that a new watcher
+	// can resume from where a previous one stopped.
+	positions PositionStore
+
+	// positionPeriod holds the minimum delay between each
+	// recording of lastId in positions.
+	positionPeriod time.Duration
+
+	// savedId and savedAt hold the position most recently
+	// recorded in positions, and when it was recorded.
+	savedId interface{}
+	savedAt time.Time
+
+	// replaying is set until the first sync after resuming from a
+	// recorded position, which replays the changelog entries
+	// written since then.
+	replaying bool
+
+	// replayed holds the documents changed by the replay. Watches
+	// of their collections registered before replayUntil are told
+	// of them, because the replay usually happens before the
+	// watcher's clients have had a chance to register.
+	replayed     map[watchKey]bool
+	replayUntil  time.Time
+	replayWindow time.Duration
+
+	// period holds the delay between each sync.
+	period time.Duration
+
+	// batchSize holds the number of changelog entries
+	// fetched in each round trip.
+	batchSize int
+
+	// coalesceWindow holds how long events found by a sync are
+	// held back so that they are delivered together with those
+	// found by later syncs.
+	coalesceWindow time.Duration
+
+	// coalesce, if not nil, fires when the sync events held
+	// during the current coalescing window are to be delivered.
+	coalesce <-chan time.Time
+}
+
+// PositionStore is implemented by types that can persist the
+// position of the most recent changelog entry seen by a Watcher.
+type PositionStore interface {
+	// LastPosition returns the most recently saved changelog
+	// entry id, or nil if none has been saved.
+	LastPosition() (interface{}, error)
+
+	// SetLastPosition saves the given changelog entry id.
+	SetLastPosition(id interface{}) error
 }
 
 // A Change holds information about a document change.
@@ -102,15 +155,85 @@ type event struct {
 	revno int64
 }
 
+// Params holds optional parameters for a Watcher.
+type Params struct {
+	// Positions, if not nil, is used to record the watcher's
+	// position in the changelog. When the watcher starts, it
+	// replays any entries written since the recorded position
+	// so long as that position is still present in the
+	// changelog. Otherwise it starts from the latest entry.
+	Positions PositionStore
+
+	// PositionPeriod holds the minimum delay between each
+	// recording of the watcher's position, which is otherwise
+	// written on every sync that sees new changelog entries.
+	// The position is always recorded when the watcher stops.
+	// If it is zero, DefaultPositionPeriod is used.
+	PositionPeriod time.Duration
+
+	// ReplayWindow holds how long after replaying from a recorded
+	// position the replayed changes are still reported to newly
+	// registered collection watches. If it is zero,
+	// DefaultReplayWindow is used.
+	ReplayWindow time.Duration
+
+	// Period holds the delay between each sync.
+	// If it is zero, the package Period is used.
+	Period time.Duration
+
+	// BatchSize holds the number of changelog entries fetched
+	// from the database in each round trip during a sync.
+	// If it is zero, DefaultBatchSize is used.
+	BatchSize int
+
+	// CoalesceWindow holds how long changes found in the changelog
+	// are held back before they are delivered. Changes found
+	// during the window are delivered together, with a single
+	// event for each document reporting its latest revno, which
+	// trades latency for fewer notifications on busy environments.
+	// If it is zero, changes are delivered after every sync.
+	CoalesceWindow time.Duration
+}
+
+// DefaultBatchSize is the number of changelog entries fetched
+// in each round trip when Params.BatchSize is not set.
+const DefaultBatchSize = 10
+
+// DefaultPositionPeriod is the minimum delay between each recording
+// of a watcher's position when Params.PositionPeriod is not set.
+const DefaultPositionPeriod = 30 * time.Second
+
+// DefaultReplayWindow is how long replayed changes are reported to
+// newly registered collection watches when Params.ReplayWindow is
+// not set.
+const DefaultReplayWindow = time.Minute
+
 // New returns a new Watcher observing the changelog collection,
 // which must be a capped collection maintained by mgo/txn.
 func New(changelog *mgo.Collection) *Watcher {
+	return NewWithParams(changelog, Params{})
+}
+
+// NewWithParams returns a new Watcher observing the changelog
+// collection, which must be a capped collection maintained by
+// mgo/txn, configured according to the given parameters.
+func NewWithParams(changelog *mgo.Collection, params Params) *Watcher {
+	if params.PositionPeriod <= 0 {
+		params.PositionPeriod = DefaultPositionPeriod
+	}
+	if params.ReplayWindow <= 0 {
+		params.ReplayWindow = DefaultReplayWindow
+	}
 	w := &Watcher{
-		log:     changelog,
-		watches: make(map[watchKey][]watchInfo),
-		current: make(map[watchKey]int64),
-		request: make(chan interface{}),
+		log:            changelog,
+		watches:        make(map[watchKey][]watchInfo),
+		current:        make(map[watchKey]int64),
+		request:        make(chan interface{}),
+		positions:      params.Positions,
+		positionPeriod: params.PositionPeriod,
+		replayWindow:   params.ReplayWindow,
 	}
+	w.setParams(params)
 	go func() {
 		w.tomb.Kill(w.loop())
 		w.tomb.Done()
@@ -149,6 +272,10 @@ type reqUnwatch struct {
 
 type reqSync struct{}
 
+type reqParams struct {
+	params Params
+}
+
 func (w *Watcher) sendReq(req interface{}) {
 	select {
 	case w.request <- req:
@@ -201,31 +328,68 @@ func (w *Watcher) StartSync() {
 	w.sendReq(reqSync{})
 }
 
-// Period is the delay between each sync.
+// SetParams changes the watcher's period, batch size and coalescing
+// window to those given, with the same defaults as NewWithParams. The
+// other parameters are fixed when the watcher is created, and are
+// ignored. A new period takes effect from the next sync, and a new
+// coalescing window from the next window opened.
+func (w *Watcher) SetParams(params Params) {
+	w.sendReq(reqParams{params})
+}
+
+// setParams sets the parameters that may be changed with SetParams.
+func (w *Watcher) setParams(params Params) {
+	if params.Period <= 0 {
+		params.Period = Period
+	}
+	if params.BatchSize <= 0 {
+		params.BatchSize = DefaultBatchSize
+	}
+	w.period = params.Period
+	w.batchSize = params.BatchSize
+	w.coalesceWindow = params.CoalesceWindow
+}
+
+// Period is the default delay between each sync.
 // It must not be changed when any watchers are active.
 var Period time.Duration = 5 * time.Second
 
 // loop implements the main watcher loop.
 func (w *Watcher) loop() error {
-	next := time.After(Period)
+	next := time.After(w.period)
 	w.needSync = true
 	if err := w.initLastId(); err != nil {
 		return err
 	}
+	defer w.savePosition(true)
 	for {
 		if w.needSync {
 			if err := w.sync(); err != nil {
 				return err
 			}
+			if w.coalesce == nil && w.coalesceWindow > 0 && len(w.syncEvents) > 0 {
+				// Hold the events back, so that changes made
+				// during the window are delivered with them.
+				w.coalesce = time.After(w.coalesceWindow)
+			}
 			w.flush()
-			next = time.After(Period)
+			next = time.After(w.period)
 		}
 		select {
 		case <-w.tomb.Dying():
 			return tomb.ErrDying
 		case <-next:
-			next = time.After(Period)
+			next = time.After(w.period)
 			w.needSync = true
+		case <-w.coalesce:
+			// Catch up with the changelog before delivering
+			// everything held during the window.
+			w.coalesce = nil
+			if err := w.sync(); err != nil {
+				return err
+			}
+			w.flush()
+			next = time.After(w.period)
 		case req := <-w.request:
 			w.handle(req)
 			w.flush()
@@ -234,10 +398,16 @@ func (w *Watcher) loop() error {
 }
 
 // flush sends all pending events to their respective channels.
+// Events found by syncs are held back while a coalescing window
+// is open.
 func (w *Watcher) flush() {
-	// refreshEvents are stored newest first.
-	for i := len(w.syncEvents) - 1; i >= 0; i-- {
-		e := &w.syncEvents[i]
+	if w.coalesce == nil {
+		w.flushSyncEvents()
+	}
+	// requestEvents are stored oldest first, and
+	// may grow during the loop.
+	for i := 0; i < len(w.requestEvents); i++ {
+		e := &w.requestEvents[i]
 		for e.ch != nil {
 			select {
 			case <-w.tomb.Dying():
@@ -250,10 +420,15 @@ func (w *Watcher) flush() {
 			break
 		}
 	}
-	// requestEvents are stored oldest first, and
-	// may grow during the loop.
-	for i := 0; i < len(w.requestEvents); i++ {
-		e := &w.requestEvents[i]
+	w.requestEvents = w.requestEvents[:0]
+}
+
+// flushSyncEvents sends the events found by syncs to their
+// respective channels.
+func (w *Watcher) flushSyncEvents() {
+	// syncEvents are stored newest first.
+	for i := len(w.syncEvents) - 1; i >= 0; i-- {
+		e := &w.syncEvents[i]
 		for e.ch != nil {
 			select {
 			case <-w.tomb.Dying():
@@ -267,7 +442,6 @@ func (w *Watcher) flush() {
 		}
 	}
 	w.syncEvents = w.syncEvents[:0]
-	w.requestEvents = w.requestEvents[:0]
 }
 
 // handle deals with requests delivered by the public API
@@ -277,6 +451,8 @@ func (w *Watcher) handle(req interface{}) {
 	switch r := req.(type) {
 	case reqSync:
 		w.needSync = true
+	case reqParams:
+		w.setParams(r.params)
 	case reqWatch:
 		for _, info := range w.watches[r.key] {
 			if info.ch == r.info.ch {
@@ -287,6 +463,9 @@ func (w *Watcher) handle(req interface{}) {
 			r.info.revno = revno
 			w.requestEvents = append(w.requestEvents, event{r.info.ch, r.key, revno})
 		}
+		if r.key.id == nil {
+			w.queueReplayed(r.key, r.info)
+		}
 		w.watches[r.key] = append(w.watches[r.key], r.info)
 	case reqUnwatch:
 		watches := w.watches[r.key]
@@ -324,10 +503,35 @@ type logInfo struct {
 	Revnos []int64       `bson:"r"`
 }
 
-// initLastId reads the most recent changelog document and initializes
-// lastId with it. This causes all history that precedes the creation
-// of the watcher to be ignored.
+// initLastId initializes lastId with the position recorded in the
+// watcher's position store, if that position is still held in the
+// changelog. Otherwise it reads the most recent changelog document
+// and initializes lastId with it. This causes all history that
+// precedes the creation of the watcher (or the recorded position)
+// to be ignored.
 func (w *Watcher) initLastId() error {
+	if w.positions != nil {
+		id, err := w.positions.LastPosition()
+		if err != nil {
+			return errors.Annotate(err, "cannot read changelog position")
+		}
+		if id != nil {
+			n, err := w.log.FindId(id).Count()
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Debugf("resuming from changelog position %v", id)
+				w.replaying = true
+				w.replayed = make(map[watchKey]bool)
+				w.lastId = id
+				w.savedId = id
+				w.savedAt = time.Now()
+				return nil
+			}
+			logger.Warningf("changelog position %v has expired; changes made since then will be missed", id)
+		}
+	}
 	var entry struct {
 		Id interface{} `bson:"_id"`
 	}
@@ -344,10 +548,17 @@ func (w *Watcher) initLastId() error {
 func (w *Watcher) sync() error {
 	w.needSync = false
 	// Iterate through log events in reverse insertion order (newest first).
-	iter := w.log.Find(nil).Batch(10).Sort("-$natural").Iter()
+	// All the entries written since the last sync are processed
+	// together, and the resulting events are delivered as a single
+	// set when the watcher is next flushed.
+	iter := w.log.Find(nil).Batch(w.batchSize).Sort("-$natural").Iter()
 	seen := make(map[watchKey]bool)
 	first := true
 	lastId := w.lastId
+	// Events still held from earlier syncs in a coalescing
+	// window are merged with the new ones below.
+	held := w.syncEvents
+	w.syncEvents = nil
 	var entry bson.D
 	for iter.Next(&entry) {
 		if len(entry) == 0 {
@@ -399,6 +610,9 @@ func (w *Watcher) sync() error {
 					continue
 				}
 				w.current[key] = revno
+				if w.replaying {
+					w.replayed[key] = true
+				}
 				// Queue notifications for per-collection watches.
 				for _, info := range w.watches[watchKey{c.Name, nil}] {
 					if info.filter != nil && !info.filter(d[i]) {
@@ -420,5 +634,90 @@ func (w *Watcher) sync() error {
 	if err := iter.Close(); err != nil {
 		return errors.Errorf("watcher iteration error: %v", err)
 	}
+	w.syncEvents = coalesceEvents(w.syncEvents, held)
+	if w.replaying {
+		w.replaying = false
+		w.replayUntil = time.Now().Add(w.replayWindow)
+	}
+	w.savePosition(false)
 	return nil
 }
+
+// queueReplayed queues events telling a newly registered watch of
+// the given collection about the documents changed by the replay
+// from a recorded position, for as long as the replay window lasts.
+// The replay happens when the watcher starts, usually before any
+// watches are registered, which would otherwise miss those changes.
+func (w *Watcher) queueReplayed(key watchKey, info watchInfo) {
+	if w.replayed == nil || w.replaying {
+		// Either there was no replay, or it has yet to happen
+		// and the watch will see it.
+		return
+	}
+	if time.Now().After(w.replayUntil) {
+		w.replayed = nil
+		return
+	}
+	for k := range w.replayed {
+		if !key.match(k) {
+			continue
+		}
+		if info.filter != nil && !info.filter(k.id) {
+			continue
+		}
+		revno, ok := w.current[k]
+		if !ok {
+			revno = -1
+		}
+		w.requestEvents = append(w.requestEvents, event{info.ch, k, revno})
+	}
+}
+
+// coalesceEvents returns the events queued by a sync followed by
+// those held from earlier syncs that are not superseded by a newer
+// event for the same channel and document. Both sets of events, and
+// the result, are ordered newest first.
+func coalesceEvents(events, held []event) []event {
+	if len(held) == 0 {
+		return events
+	}
+	type eventKey struct {
+		ch  chan<- Change
+		key watchKey
+	}
+	queued := make(map[eventKey]bool, len(events))
+	for _, e := range events {
+		if e.ch != nil {
+			queued[eventKey{e.ch, e.key}] = true
+		}
+	}
+	for _, h := range held {
+		if h.ch != nil && !queued[eventKey{h.ch, h.key}] {
+			events = append(events, h)
+		}
+	}
+	return events
+}
+
+// savePosition records lastId in the watcher's position store, if
+// it has changed since it was last recorded. Unless force is true,
+// the position is recorded at most once every positionPeriod, so
+// that a busy changelog does not cause a write on every sync.
+func (w *Watcher) savePosition(force bool) {
+	if w.positions == nil || w.lastId == w.savedId {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(w.savedAt) < w.positionPeriod {
+		return
+	}
+	// Failing to record the position only means a future
+	// watcher may have to replay more of the changelog, or
+	// start afresh, so it's not fatal.
+	if err := w.positions.SetLastPosition(w.lastId); err != nil {
+		logger.Warningf("cannot record changelog position: %v", err)
+		return
+	}
+	w.savedId = w.lastId
+	w.savedAt = now
+}
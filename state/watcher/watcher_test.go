@@ -608,6 +608,198 @@ func (s *FastPeriodSuite) TestNonMutatingTxn(c *gc.C) {
 	assertNoChange(c, chA)
 }
 
+func (s *FastPeriodSuite) TestResumeFromPosition(c *gc.C) {
+	positions := watcher.NewCollectionPositions(s.log.Database.C("positions"), "owner")
+	w := watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	// Ensure the watcher has initialized its position.
+	w.StartSync()
+	revno1 := s.insert(c, "test", "a")
+	w.StartSync()
+	w.Watch("test", "a", -1, s.ch)
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno1})
+	w.Unwatch("test", "a", s.ch)
+	c.Assert(w.Stop(), gc.IsNil)
+
+	// Changes made while no watcher is running are
+	// seen by a watcher resuming from the saved position.
+	revno2 := s.update(c, "test", "a")
+	w = watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	w.Watch("test", "a", revno1, s.ch)
+	w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno2})
+	assertNoChange(c, s.ch)
+}
+
+func (s *FastPeriodSuite) TestResumeReportsReplayToLaterWatches(c *gc.C) {
+	positions := watcher.NewCollectionPositions(s.log.Database.C("positions"), "owner")
+	w := watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	w.StartSync()
+	c.Assert(w.Stop(), gc.IsNil)
+
+	revno := s.insert(c, "test", "a")
+	w = watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	// The watcher replays before handling any request, so the
+	// replay has happened once StartSync returns.
+	w.StartSync()
+
+	// A collection watch registered after the replay is told
+	// of the changes it found.
+	w.WatchCollection("test", s.ch)
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno})
+	assertNoChange(c, s.ch)
+	w.UnwatchCollection("test", s.ch)
+}
+
+func (s *FastPeriodSuite) TestResumeReplayWindowExpires(c *gc.C) {
+	positions := watcher.NewCollectionPositions(s.log.Database.C("positions"), "owner")
+	w := watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	w.StartSync()
+	c.Assert(w.Stop(), gc.IsNil)
+
+	s.insert(c, "test", "a")
+	w = watcher.NewWithParams(s.log, watcher.Params{
+		Positions:    positions,
+		ReplayWindow: time.Millisecond,
+	})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	w.StartSync()
+	time.Sleep(10 * time.Millisecond)
+
+	w.WatchCollection("test", s.ch)
+	assertNoChange(c, s.ch)
+	w.UnwatchCollection("test", s.ch)
+}
+
+func (s *FastPeriodSuite) TestResumeFromExpiredPosition(c *gc.C) {
+	positions := &fakePositions{position: "no-such-entry"}
+	w := watcher.NewWithParams(s.log, watcher.Params{Positions: positions})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	revno1 := s.insert(c, "test", "a")
+
+	// The unknown position is ignored, so the watcher only
+	// reports changes made after it started.
+	w.Watch("test", "a", revno1, s.ch)
+	w.StartSync()
+	assertNoChange(c, s.ch)
+
+	revno2 := s.update(c, "test", "a")
+	w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno2})
+}
+
+func (s *FastPeriodSuite) TestPositionRecordingThrottled(c *gc.C) {
+	positions := &fakePositions{}
+	w := watcher.NewWithParams(s.log, watcher.Params{
+		Positions:      positions,
+		PositionPeriod: time.Hour,
+	})
+	w.WatchCollection("test", s.ch)
+	for _, id := range []string{"a", "b", "c"} {
+		revno := s.insert(c, "test", id)
+		w.StartSync()
+		assertChange(c, s.ch, watcher.Change{"test", id, revno})
+	}
+	w.UnwatchCollection("test", s.ch)
+	c.Assert(w.Stop(), gc.IsNil)
+
+	// Only the first sync and stopping the watcher
+	// recorded the position.
+	c.Assert(positions.sets <= 2, gc.Equals, true)
+	var entry struct {
+		Id interface{} `bson:"_id"`
+	}
+	err := s.log.Find(nil).Sort("-$natural").One(&entry)
+	c.Assert(err, gc.IsNil)
+	c.Assert(positions.position, gc.Equals, entry.Id)
+}
+
+func (s *FastPeriodSuite) TestCoalesceWindow(c *gc.C) {
+	w := watcher.NewWithParams(s.log, watcher.Params{CoalesceWindow: slowPeriod})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	ch2 := make(chan watcher.Change, 2)
+	w.Watch("test", "a", -1, s.ch)
+	w.WatchCollection("test", ch2)
+
+	// Changes are held back during the window.
+	revno1 := s.insert(c, "test", "a")
+	w.StartSync()
+	assertNoChange(c, s.ch)
+	revno2 := s.update(c, "test", "a")
+	revno3 := s.insert(c, "test", "b")
+	w.StartSync()
+	assertNoChange(c, s.ch)
+	assertOrder(c, revno1, revno2)
+
+	// They are then delivered together, with a single
+	// event for each document carrying its latest revno.
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno2})
+	assertNoChange(c, s.ch)
+	assertChange(c, ch2, watcher.Change{"test", "a", revno2})
+	assertChange(c, ch2, watcher.Change{"test", "b", revno3})
+	assertNoChange(c, ch2)
+}
+
+func (s *FastPeriodSuite) TestParams(c *gc.C) {
+	w := watcher.NewWithParams(s.log, watcher.Params{
+		Period:    slowPeriod,
+		BatchSize: 1,
+	})
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	w.StartSync()
+	w.WatchCollection("test", s.ch)
+	revno1 := s.insert(c, "test", "a")
+	revno2 := s.insert(c, "test", "b")
+
+	// The watcher polls with its own period rather
+	// than the package default.
+	assertNoChange(c, s.ch)
+
+	// Entries fetched one at a time are still
+	// delivered together in order.
+	w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno1})
+	assertChange(c, s.ch, watcher.Change{"test", "b", revno2})
+	assertNoChange(c, s.ch)
+}
+
+func (s *FastPeriodSuite) TestSetParams(c *gc.C) {
+	w := watcher.New(s.log)
+	defer func() { c.Assert(w.Stop(), gc.IsNil) }()
+	w.Watch("test", "a", -1, s.ch)
+
+	// A coalescing window set on a running watcher
+	// holds back the changes it then finds.
+	w.SetParams(watcher.Params{CoalesceWindow: slowPeriod})
+	revno1 := s.insert(c, "test", "a")
+	w.StartSync()
+	assertNoChange(c, s.ch)
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno1})
+
+	// Removing it again delivers changes straight away.
+	w.SetParams(watcher.Params{})
+	revno2 := s.update(c, "test", "a")
+	w.StartSync()
+	assertChange(c, s.ch, watcher.Change{"test", "a", revno2})
+	assertNoChange(c, s.ch)
+}
+
+type fakePositions struct {
+	position interface{}
+	sets     int
+}
+
+func (p *fakePositions) LastPosition() (interface{}, error) {
+	return p.position, nil
+}
+
+func (p *fakePositions) SetLastPosition(id interface{}) error {
+	p.position = id
+	p.sets++
+	return nil
+}
+
 // SlowPeriodSuite implements tests
 // that are flaky when the watcher refresh period
 // is small.
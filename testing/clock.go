@@ -0,0 +1,116 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock implements a mock clock.Clock for testing purposes.
+// Time only passes when Advance is called.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	alarms []alarm
+	notify chan struct{}
+
+	// added receives a value, if it does not already hold one,
+	// whenever an alarm is added, to wake WaitAdvance.
+	added chan struct{}
+}
+
+// alarm records a channel waiting on the clock.
+type alarm struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewClock returns a new clock set to the supplied time.
+func NewClock(now time.Time) *Clock {
+	return &Clock{
+		now:    now,
+		notify: make(chan struct{}, 1024),
+		added:  make(chan struct{}, 1),
+	}
+}
+
+// Now is part of the clock.Clock interface.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After is part of the clock.Clock interface. The returned channel
+// receives a value only when the clock has been advanced far enough.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+	} else {
+		c.alarms = append(c.alarms, alarm{deadline, ch})
+		sort.Sort(byDeadline(c.alarms))
+		select {
+		case c.added <- struct{}{}:
+		default:
+		}
+	}
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+	return ch
+}
+
+// Advance moves the clock forward by d, triggering any
+// waiting channels whose deadlines have passed.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for len(c.alarms) > 0 && !c.alarms[0].deadline.After(c.now) {
+		c.alarms[0].ch <- c.now
+		c.alarms = c.alarms[1:]
+	}
+}
+
+// WaitAdvance waits until at least n channels are waiting on the
+// clock, and then advances it by d. It returns an error if fewer than
+// n are waiting once the given timeout has passed.
+func (c *Clock) WaitAdvance(d, timeout time.Duration, n int) error {
+	deadline := time.After(timeout)
+	for {
+		c.mu.Lock()
+		waiting := len(c.alarms)
+		c.mu.Unlock()
+		if waiting >= n {
+			c.Advance(d)
+			return nil
+		}
+		select {
+		case <-c.added:
+		case <-deadline:
+			return fmt.Errorf("got %d waiters on the clock, want %d", waiting, n)
+		}
+	}
+}
+
+// Alarms returns a channel that receives a value each time
+// After is called, so that tests can tell when code under
+// test has started waiting on the clock.
+func (c *Clock) Alarms() <-chan struct{} {
+	return c.notify
+}
+
+type byDeadline []alarm
+
+func (a byDeadline) Len() int           { return len(a) }
+func (a byDeadline) Less(i, j int) bool { return a[i].deadline.Before(a[j].deadline) }
+func (a byDeadline) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
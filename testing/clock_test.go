@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/utils/clock"
+)
+
+type ClockSuite struct{}
+
+var _ = gc.Suite(&ClockSuite{})
+
+var _ clock.Clock = (*testing.Clock)(nil)
+
+func (*ClockSuite) TestNow(c *gc.C) {
+	t0 := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := testing.NewClock(t0)
+	c.Assert(cl.Now(), gc.Equals, t0)
+	cl.Advance(time.Minute)
+	c.Assert(cl.Now(), gc.Equals, t0.Add(time.Minute))
+}
+
+func (*ClockSuite) TestAfter(c *gc.C) {
+	t0 := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := testing.NewClock(t0)
+	late := cl.After(2 * time.Second)
+	early := cl.After(time.Second)
+	assertAlarms(c, cl, 2)
+
+	cl.Advance(500 * time.Millisecond)
+	assertNotFired(c, early)
+
+	cl.Advance(500 * time.Millisecond)
+	c.Assert(<-early, gc.Equals, t0.Add(time.Second))
+	assertNotFired(c, late)
+
+	cl.Advance(time.Hour)
+	c.Assert(<-late, gc.Equals, t0.Add(time.Hour+time.Second))
+}
+
+func (*ClockSuite) TestWaitAdvance(c *gc.C) {
+	t0 := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	cl := testing.NewClock(t0)
+	err := cl.WaitAdvance(time.Second, testing.ShortWait, 1)
+	c.Assert(err, gc.ErrorMatches, "got 0 waiters on the clock, want 1")
+	c.Assert(cl.Now(), gc.Equals, t0)
+
+	fired := make(chan time.Time, 1)
+	go func() {
+		fired <- <-cl.After(time.Second)
+	}()
+	err = cl.WaitAdvance(time.Second, testing.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+	select {
+	case t := <-fired:
+		c.Assert(t, gc.Equals, t0.Add(time.Second))
+	case <-time.After(testing.LongWait):
+		c.Fatalf("alarm did not fire")
+	}
+}
+
+func assertAlarms(c *gc.C, cl *testing.Clock, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-cl.Alarms():
+		case <-time.After(testing.LongWait):
+			c.Fatalf("timed out waiting for alarm %d", i)
+		}
+	}
+}
+
+func assertNotFired(c *gc.C, ch <-chan time.Time) {
+	select {
+	case t := <-ch:
+		c.Fatalf("unexpected alarm at %v", t)
+	default:
+	}
+}
@@ -11,4 +11,9 @@
 //     target      - the type of Juju node being upgraded
 //     context     - provides API access to Juju state servers
 //
+// Upgrade steps are registered in upgradeSteps, keyed by the Juju version
+// they upgrade to. Each step has a description, the targets it applies to,
+// and a function run with the upgrade Context. Steps must be idempotent,
+// and can be tested in isolation using the helpers in upgrades/testing.
+//
 package upgrades
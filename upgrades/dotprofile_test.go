@@ -13,6 +13,7 @@ import (
 
 	"github.com/juju/juju/testing"
 	"github.com/juju/juju/upgrades"
+	upgradetesting "github.com/juju/juju/upgrades/testing"
 )
 
 type ensureDotProfileSuite struct {
@@ -67,6 +68,14 @@ func (s *ensureDotProfileSuite) TestIdempotent(c *gc.C) {
 	s.assertProfile(c, expectedLine)
 }
 
+func (s *ensureDotProfileSuite) TestRegisteredStepIdempotent(c *gc.C) {
+	s.writeDotProfile(c, "")
+	step := upgradetesting.FindStep(c, upgrades.StepsFor118(), "make /home/ubuntu/.profile source .juju-proxy file")
+	upgradetesting.AssertStepIdempotent(c, step, s.ctx, func() {
+		s.assertProfile(c, expectedLine)
+	})
+}
+
 func (s *ensureDotProfileSuite) TestProfileUntouchedIfJujuProxyInSource(c *gc.C) {
 	content := "source .juju-proxy\n"
 	s.writeDotProfile(c, content)
@@ -5,6 +5,7 @@ package upgrades
 
 var (
 	UpgradeOperations = &upgradeOperations
+	UpgradeSteps      = &upgradeSteps
 	UbuntuHome        = &ubuntuHome
 	RootLogDir        = &rootLogDir
 	RootSpoolDir      = &rootSpoolDir
@@ -20,4 +21,11 @@ var (
 	UpdateRsyslogPort                      = updateRsyslogPort
 	ProcessDeprecatedEnvSettings           = processDeprecatedEnvSettings
 	MigrateLocalProviderAgentConfig        = migrateLocalProviderAgentConfig
+
+	// 121 upgrade functions
+	StepsFor121         = stepsFor121
+	MongoRunningVersion = &mongoRunningVersion
+	MongoUpgradeServer  = &mongoUpgradeServer
+	UpgradeMongo        = upgradeMongo
+	AuditIndexes        = auditIndexes
 )
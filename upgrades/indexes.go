@@ -0,0 +1,11 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+// auditIndexes creates any state database indexes that are missing,
+// logging them and any indexes that juju does not require.
+func auditIndexes(context Context) error {
+	_, err := context.State().EnsureIndexes()
+	return err
+}
@@ -0,0 +1,42 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/upgrades"
+	upgradetesting "github.com/juju/juju/upgrades/testing"
+)
+
+type auditIndexesSuite struct {
+	jujutesting.JujuConnSuite
+	ctx upgrades.Context
+}
+
+var _ = gc.Suite(&auditIndexesSuite{})
+
+func (s *auditIndexesSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	apiState, _ := s.OpenAPIAsNewMachine(c, state.JobManageEnviron)
+	s.ctx = &mockContext{
+		agentConfig: &mockAgentConfig{dataDir: s.DataDir()},
+		apiState:    apiState,
+		state:       s.State,
+	}
+}
+
+func (s *auditIndexesSuite) TestAuditIndexes(c *gc.C) {
+	err := s.MgoSuite.Session.DB("juju").C("units").DropIndex("service")
+	c.Assert(err, gc.IsNil)
+
+	step := upgradetesting.FindStep(c, upgrades.StepsFor121(), "audit state database indexes")
+	upgradetesting.AssertStepIdempotent(c, step, s.ctx, func() {
+		report, err := s.State.CheckIndexes()
+		c.Assert(err, gc.IsNil)
+		c.Assert(report.Missing, gc.HasLen, 0)
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+import (
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/mongo"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/version"
+)
+
+var (
+	mongoUpgradeServer  = mongo.UpgradeServer
+	mongoRunningVersion = runningMongoVersion
+)
+
+// runningMongoVersion returns the version of the mongod serving st.
+func runningMongoVersion(st *state.State) (version.Number, error) {
+	info, err := st.MongoSession().BuildInfo()
+	if err != nil {
+		return version.Zero, err
+	}
+	return version.Parse(info.Version)
+}
+
+// upgradeMongo upgrades the state server's mongod to the latest
+// release in its minor series, restarting it if that changed it, and
+// records the version now serving the database in the agent config.
+// The machine agent refuses to start a later mongod that cannot serve
+// the database in place, so any upgrade step that migrates the
+// database to a new mongod must record its version again.
+//
+// Restarting mongod drops the agent's state connections, so this
+// must be the last step run on state servers.
+func upgradeMongo(context Context) error {
+	running, err := mongoRunningVersion(context.State())
+	if err != nil {
+		return err
+	}
+	namespace := context.AgentConfig().Value(agent.Namespace)
+	installed, err := mongoUpgradeServer(namespace, running)
+	if err != nil {
+		return err
+	}
+	context.AgentConfig().SetValue(agent.MongoVersion, installed.String())
+	return nil
+}
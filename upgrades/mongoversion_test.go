@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	"errors"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/agent"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/upgrades"
+	upgradetesting "github.com/juju/juju/upgrades/testing"
+	"github.com/juju/juju/version"
+)
+
+type mongoVersionSuite struct {
+	testing.BaseSuite
+	agentConfig *mockAgentConfig
+	ctx         upgrades.Context
+}
+
+var _ = gc.Suite(&mongoVersionSuite{})
+
+func (s *mongoVersionSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.agentConfig = &mockAgentConfig{
+		dataDir: c.MkDir(),
+		values:  map[string]string{agent.Namespace: "namespace"},
+	}
+	s.ctx = &mockContext{agentConfig: s.agentConfig}
+	s.PatchValue(upgrades.MongoRunningVersion, func(*state.State) (version.Number, error) {
+		return version.MustParse("2.4.6"), nil
+	})
+}
+
+func (s *mongoVersionSuite) TestUpgradeMongo(c *gc.C) {
+	var upgraded []string
+	s.PatchValue(upgrades.MongoUpgradeServer, func(namespace string, running version.Number) (version.Number, error) {
+		upgraded = append(upgraded, namespace+" "+running.String())
+		return version.MustParse("2.4.9"), nil
+	})
+	step := upgradetesting.FindStep(c, upgrades.StepsFor121(), "upgrade mongod in place")
+	upgradetesting.AssertStepIdempotent(c, step, s.ctx, func() {
+		c.Assert(s.agentConfig.Value(agent.MongoVersion), gc.Equals, "2.4.9")
+	})
+	c.Assert(upgraded, gc.DeepEquals, []string{"namespace 2.4.6", "namespace 2.4.6"})
+}
+
+func (s *mongoVersionSuite) TestUpgradeMongoError(c *gc.C) {
+	s.PatchValue(upgrades.MongoUpgradeServer, func(string, version.Number) (version.Number, error) {
+		return version.Zero, errors.New("cannot upgrade mongod 2.4.6 to 2.6.1 in place")
+	})
+	err := upgrades.UpgradeMongo(s.ctx)
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade mongod 2.4.6 to 2.6.1 in place")
+	c.Assert(s.agentConfig.Value(agent.MongoVersion), gc.Equals, "")
+}
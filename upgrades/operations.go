@@ -3,18 +3,39 @@
 
 package upgrades
 
-import "github.com/juju/juju/version"
+import (
+	"sort"
+
+	"github.com/juju/juju/version"
+)
+
+// upgradeSteps registers, for each Juju version that needs upgrade
+// steps, a function returning the steps required to upgrade to that
+// version. Register the steps for a new version by adding an entry here.
+var upgradeSteps = map[version.Number]func() []Step{
+	version.MustParse("1.18.0"):      stepsFor118,
+	version.MustParse("1.21-alpha1"): stepsFor121,
+}
 
 // upgradeOperations returns an ordered slice of sets of operations needed
 // to upgrade Juju to particular version. The slice is ordered by target
 // version, so that the sets of operations are executed in order from oldest
 // version to most recent.
 var upgradeOperations = func() []Operation {
-	steps := []Operation{
-		upgradeToVersion{
-			version.MustParse("1.18.0"),
-			stepsFor118(),
-		},
+	ops := make([]Operation, 0, len(upgradeSteps))
+	for targetVersion, steps := range upgradeSteps {
+		ops = append(ops, upgradeToVersion{targetVersion, steps()})
 	}
-	return steps
+	sort.Sort(byTargetVersion(ops))
+	return ops
+}
+
+// byTargetVersion sorts operations from oldest to most recent
+// target version.
+type byTargetVersion []Operation
+
+func (s byTargetVersion) Len() int      { return len(s) }
+func (s byTargetVersion) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byTargetVersion) Less(i, j int) bool {
+	return s[i].TargetVersion().Compare(s[j].TargetVersion()) < 0
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/juju/juju/testing"
 	"github.com/juju/juju/upgrades"
+	upgradetesting "github.com/juju/juju/upgrades/testing"
 )
 
 type steps118Suite struct {
@@ -31,3 +32,11 @@ func (s *steps118Suite) TestUpgradeOperationsContent(c *gc.C) {
 	c.Assert(upgradeSteps, gc.HasLen, len(expectedSteps))
 	assertExpectedSteps(c, upgradeSteps, expectedSteps)
 }
+
+func (s *steps118Suite) TestUpgradeOperationsTargets(c *gc.C) {
+	upgradeSteps := upgrades.StepsFor118()
+	step := upgradetesting.FindStep(c, upgradeSteps, "generate system ssh key")
+	upgradetesting.AssertStepTargets(c, step, upgrades.StateServer)
+	step = upgradetesting.FindStep(c, upgradeSteps, "install rsyslog-gnutls")
+	upgradetesting.AssertStepTargets(c, step, upgrades.AllMachines)
+}
@@ -0,0 +1,21 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades
+
+// stepsFor121 returns upgrade steps to upgrade to a Juju 1.21 deployment.
+func stepsFor121() []Step {
+	return []Step{
+		&upgradeStep{
+			description: "audit state database indexes",
+			targets:     []Target{StateServer},
+			run:         auditIndexes,
+		},
+		// This restarts mongod, so it comes last.
+		&upgradeStep{
+			description: "upgrade mongod in place",
+			targets:     []Target{StateServer},
+			run:         upgradeMongo,
+		},
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/upgrades"
+	upgradetesting "github.com/juju/juju/upgrades/testing"
+)
+
+type steps121Suite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&steps121Suite{})
+
+var expectedSteps121 = []string{
+	"audit state database indexes",
+	"upgrade mongod in place",
+}
+
+func (s *steps121Suite) TestUpgradeOperationsContent(c *gc.C) {
+	upgradeSteps := upgrades.StepsFor121()
+	c.Assert(upgradeSteps, gc.HasLen, len(expectedSteps121))
+	assertExpectedSteps(c, upgradeSteps, expectedSteps121)
+}
+
+func (s *steps121Suite) TestUpgradeOperationsTargets(c *gc.C) {
+	upgradeSteps := upgrades.StepsFor121()
+	step := upgradetesting.FindStep(c, upgradeSteps, "upgrade mongod in place")
+	upgradetesting.AssertStepTargets(c, step, upgrades.StateServer)
+	step = upgradetesting.FindStep(c, upgradeSteps, "audit state database indexes")
+	upgradetesting.AssertStepTargets(c, step, upgrades.StateServer)
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package testing
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/upgrades"
+)
+
+// FindStep returns the step with the given description from steps,
+// failing the test if there is no such step.
+func FindStep(c *gc.C, steps []upgrades.Step, description string) upgrades.Step {
+	for _, step := range steps {
+		if step.Description() == description {
+			return step
+		}
+	}
+	c.Fatalf("no upgrade step %q", description)
+	return nil
+}
+
+// AssertStepTargets checks that the given step applies to exactly
+// the expected targets.
+func AssertStepTargets(c *gc.C, step upgrades.Step, expected ...upgrades.Target) {
+	c.Assert(step.Targets(), gc.DeepEquals, expected)
+}
+
+// AssertStepIdempotent runs the given step twice with the given
+// context, checking that it succeeds both times. If check is not
+// nil, it is called after each run to verify the step's effects,
+// which must be the same whether or not the step has already run.
+func AssertStepIdempotent(c *gc.C, step upgrades.Step, context upgrades.Context, check func()) {
+	for i := 0; i < 2; i++ {
+		c.Logf("running %q, attempt %d", step.Description(), i+1)
+		err := step.Run(context)
+		c.Assert(err, gc.IsNil)
+		if check != nil {
+			check()
+		}
+	}
+}
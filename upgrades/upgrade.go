@@ -18,6 +18,11 @@ var logger = loggo.GetLogger("juju.upgrade")
 
 // Step defines an idempotent operation that is run to perform
 // a specific upgrade step.
+//
+// Steps must be idempotent: a failed upgrade is retried from the
+// start, so a step may be run again after it has completed, or after
+// it has failed partway through, and must then succeed without
+// repeating changes already made.
 type Step interface {
 	// Description is a human readable description of what the upgrade step does.
 	Description() string
@@ -78,7 +83,9 @@ func (u upgradeToVersion) TargetVersion() version.Number {
 }
 
 // Context is used give the upgrade steps attributes needed
-// to do their job.
+// to do their job. Steps should use only the accessors relevant
+// to their targets; State, for example, is nil on machines other
+// than state servers.
 type Context interface {
 	// APIState returns an API connection to state.
 	APIState() *api.State
@@ -140,6 +140,13 @@ func (mock *mockAgentConfig) Value(name string) string {
 	return mock.values[name]
 }
 
+func (mock *mockAgentConfig) SetValue(name, value string) {
+	if mock.values == nil {
+		mock.values = make(map[string]string)
+	}
+	mock.values[name] = value
+}
+
 func (mock *mockAgentConfig) MongoInfo() (*authentication.MongoInfo, bool) {
 	return mock.mongoInfo, true
 }
@@ -332,7 +339,30 @@ func (s *upgradeSuite) TestUpgradeOperationsOrdered(c *gc.C) {
 	}
 }
 
-var expectedVersions = []string{"1.18.0"}
+func (s *upgradeSuite) TestUpgradeOperationsFromRegistry(c *gc.C) {
+	stepsFor := func(description string) func() []upgrades.Step {
+		return func() []upgrades.Step {
+			return []upgrades.Step{&mockUpgradeStep{description, nil}}
+		}
+	}
+	s.PatchValue(upgrades.UpgradeSteps, map[version.Number]func() []upgrades.Step{
+		version.MustParse("1.20.0"):      stepsFor("step - 1.20.0"),
+		version.MustParse("1.18.0"):      stepsFor("step - 1.18.0"),
+		version.MustParse("1.21-alpha1"): stepsFor("step - 1.21-alpha1"),
+	})
+	var versions []string
+	var descriptions []string
+	for _, utv := range (*upgrades.UpgradeOperations)() {
+		versions = append(versions, utv.TargetVersion().String())
+		for _, step := range utv.Steps() {
+			descriptions = append(descriptions, step.Description())
+		}
+	}
+	c.Assert(versions, gc.DeepEquals, []string{"1.18.0", "1.20.0", "1.21-alpha1"})
+	c.Assert(descriptions, gc.DeepEquals, []string{"step - 1.18.0", "step - 1.20.0", "step - 1.21-alpha1"})
+}
+
+var expectedVersions = []string{"1.18.0", "1.21-alpha1"}
 
 func (s *upgradeSuite) TestUpgradeOperationsVersions(c *gc.C) {
 	var versions []string
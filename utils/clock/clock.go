@@ -0,0 +1,36 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The clock package provides an abstraction of the passing of time,
+// so that workers which wait or retry can be driven by tests
+// without real delays.
+package clock
+
+import (
+	"time"
+)
+
+// Clock provides an interface for dealing with clocks.
+type Clock interface {
+	// Now returns the current clock time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the
+	// current time on the returned channel.
+	After(time.Duration) <-chan time.Time
+}
+
+// WallClock exposes wall-clock time as returned by time.Now.
+var WallClock Clock = wallClock{}
+
+type wallClock struct{}
+
+// Now is part of the Clock interface.
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
+// After is part of the Clock interface.
+func (wallClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,41 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cleaner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/juju/worker/scheduler"
+)
+
+// settingsInterval sets how often orphaned relation settings are removed.
+const settingsInterval = 6 * time.Hour
+
+// SettingsRemover defines the interface for types capable of removing
+// relation unit settings left behind by relations that no longer exist.
+type SettingsRemover interface {
+	// RemoveOrphanedRelationSettings removes the orphaned settings
+	// and returns the number removed.
+	RemoveOrphanedRelationSettings() (int, error)
+}
+
+// NewSettingsTask returns a task that removes orphaned relation
+// unit settings, to be run by the scheduler worker.
+func NewSettingsTask(r SettingsRemover) scheduler.Task {
+	return scheduler.Task{
+		Name:     "remove-orphaned-relation-settings",
+		Interval: settingsInterval,
+		Run: func(<-chan struct{}) error {
+			removed, err := r.RemoveOrphanedRelationSettings()
+			if err != nil {
+				return fmt.Errorf("cannot remove orphaned relation settings: %v", err)
+			}
+			if removed > 0 {
+				logger.Infof("removed %d orphaned relation settings documents", removed)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package cleaner_test
+
+import (
+	"errors"
+
+	gc "launchpad.net/gocheck"
+
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/cleaner"
+)
+
+type SettingsTaskSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&SettingsTaskSuite{})
+
+func (s *SettingsTaskSuite) TestTask(c *gc.C) {
+	r := &settingsRemoverMock{removed: 2}
+	task := cleaner.NewSettingsTask(r)
+	c.Assert(task.Name, gc.Equals, "remove-orphaned-relation-settings")
+	c.Assert(task.Interval, gc.Equals, cleaner.SettingsInterval)
+
+	err := task.Run(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(r.calls, gc.Equals, 1)
+}
+
+func (s *SettingsTaskSuite) TestTaskError(c *gc.C) {
+	r := &settingsRemoverMock{err: errors.New("boom")}
+	task := cleaner.NewSettingsTask(r)
+	err := task.Run(nil)
+	c.Assert(err, gc.ErrorMatches, "cannot remove orphaned relation settings: boom")
+}
+
+// settingsRemoverMock is used to check the calls
+// of RemoveOrphanedRelationSettings().
+type settingsRemoverMock struct {
+	removed int
+	err     error
+	calls   int
+}
+
+func (r *settingsRemoverMock) RemoveOrphanedRelationSettings() (int, error) {
+	r.calls++
+	return r.removed, r.err
+}
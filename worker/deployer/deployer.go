@@ -5,17 +5,21 @@ package deployer
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/juju/loggo"
 	"github.com/juju/names"
 	"github.com/juju/utils"
 	"github.com/juju/utils/set"
+	"launchpad.net/tomb"
 
 	"github.com/juju/juju/agent"
 	apideployer "github.com/juju/juju/state/api/deployer"
 	"github.com/juju/juju/state/api/params"
-	"github.com/juju/juju/state/api/watcher"
+	apiwatcher "github.com/juju/juju/state/api/watcher"
+	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/worker"
 )
 
@@ -25,11 +29,21 @@ var logger = loggo.GetLogger("juju.worker.deployer")
 // to changes in a set of state units; and for the final removal of its agents'
 // units from state when they are no longer needed.
 type Deployer struct {
+	tomb     tomb.Tomb
 	st       *apideployer.State
 	ctx      Context
+	clock    clock.Clock
 	deployed set.Strings
+
+	// failed holds the units whose deployment or recall failed,
+	// to be checked again once retryDelay has passed.
+	failed set.Strings
 }
 
+// retryDelay holds how long the deployer waits before checking again
+// the units whose deployment or recall failed.
+const retryDelay = 10 * time.Second
+
 // Context abstracts away the differences between different unit deployment
 // strategies; where a Deployer is responsible for what to deploy, a Context
 // is responsible for how to deploy.
@@ -53,16 +67,67 @@ type Context interface {
 }
 
 // NewDeployer returns a Worker that deploys and recalls unit agents
-// via ctx, taking a machine id to operate on.
-func NewDeployer(st *apideployer.State, ctx Context) worker.Worker {
+// via ctx, taking a machine id to operate on. Units whose deployment
+// or recall fails are checked again after a delay measured with the
+// given clock.
+func NewDeployer(st *apideployer.State, ctx Context, clock clock.Clock) worker.Worker {
 	d := &Deployer{
-		st:  st,
-		ctx: ctx,
+		st:       st,
+		ctx:      ctx,
+		clock:    clock,
+		deployed: set.NewStrings(),
+		failed:   set.NewStrings(),
+	}
+	go func() {
+		defer d.tomb.Done()
+		d.tomb.Kill(d.loop())
+	}()
+	return d
+}
+
+// Kill is part of the worker.Worker interface.
+func (d *Deployer) Kill() {
+	d.tomb.Kill(nil)
+}
+
+// Wait is part of the worker.Worker interface.
+func (d *Deployer) Wait() error {
+	return d.tomb.Wait()
+}
+
+func (d *Deployer) loop() error {
+	w, err := d.setUp()
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop(w, &d.tomb)
+	var retry <-chan time.Time
+	for {
+		if retry == nil && !d.failed.IsEmpty() {
+			retry = d.clock.After(retryDelay)
+		}
+		select {
+		case <-d.tomb.Dying():
+			return tomb.ErrDying
+		case unitNames, ok := <-w.Changes():
+			if !ok {
+				return watcher.MustErr(w)
+			}
+			if err := d.handle(unitNames); err != nil {
+				return err
+			}
+		case <-retry:
+			retry = nil
+			unitNames := d.failed.SortedValues()
+			d.failed = set.NewStrings()
+			if err := d.handle(unitNames); err != nil {
+				return err
+			}
+		}
 	}
-	return worker.NewStringsWorker(d)
 }
 
-func (d *Deployer) SetUp() (watcher.StringsWatcher, error) {
+func (d *Deployer) setUp() (apiwatcher.StringsWatcher, error) {
 	tag := d.ctx.AgentConfig().Tag()
 	machineTag, ok := tag.(names.MachineTag)
 	if !ok {
@@ -79,18 +144,20 @@ func (d *Deployer) SetUp() (watcher.StringsWatcher, error) {
 
 	deployed, err := d.ctx.DeployedUnits()
 	if err != nil {
+		machineUnitsWatcher.Stop()
 		return nil, err
 	}
 	for _, unitName := range deployed {
 		d.deployed.Add(unitName)
-		if err := d.changed(unitName); err != nil {
-			return nil, err
-		}
+	}
+	if err := d.handle(deployed); err != nil {
+		machineUnitsWatcher.Stop()
+		return nil, err
 	}
 	return machineUnitsWatcher, nil
 }
 
-func (d *Deployer) Handle(unitNames []string) error {
+func (d *Deployer) handle(unitNames []string) error {
 	for _, unitName := range unitNames {
 		if err := d.changed(unitName); err != nil {
 			return err
@@ -153,7 +220,9 @@ func (d *Deployer) deploy(unit *apideployer.Unit) error {
 		return fmt.Errorf("cannot set password for unit %q: %v", unitName, err)
 	}
 	if err := d.ctx.DeployUnit(unitName, initialPassword); err != nil {
-		return err
+		logger.Errorf("cannot deploy unit %q (will retry): %v", unitName, err)
+		d.failed.Add(unitName)
+		return nil
 	}
 	d.deployed.Add(unitName)
 	return nil
@@ -167,7 +236,9 @@ func (d *Deployer) recall(unitName string) error {
 	}
 	logger.Infof("recalling unit %q", unitName)
 	if err := d.ctx.RecallUnit(unitName); err != nil {
-		return err
+		logger.Errorf("cannot recall unit %q (will retry): %v", unitName, err)
+		d.failed.Add(unitName)
+		return nil
 	}
 	d.deployed.Remove(unitName)
 	return nil
@@ -185,8 +256,3 @@ func (d *Deployer) remove(unit *apideployer.Unit) error {
 	logger.Infof("removing unit %q", unitName)
 	return unit.Remove()
 }
-
-func (d *Deployer) TearDown() error {
-	// Nothing to do here.
-	return nil
-}
@@ -33,16 +33,16 @@ type deployerSuite struct {
 	machine       *state.Machine
 	stateAPI      *api.State
 	deployerState *apideployer.State
+	clock         *coretesting.Clock
 }
 
 var _ = gc.Suite(&deployerSuite{})
 
-var _ worker.StringsWatchHandler = (*deployer.Deployer)(nil)
-
 func (s *deployerSuite) SetUpTest(c *gc.C) {
 	s.JujuConnSuite.SetUpTest(c)
 	s.SimpleToolsFixture.SetUp(c, s.DataDir())
 	s.stateAPI, s.machine = s.OpenAPIAsNewMachine(c)
+	s.clock = coretesting.NewClock(time.Now())
 	// Create the deployer facade.
 	s.deployerState = s.stateAPI.Deployer()
 	c.Assert(s.deployerState, gc.NotNil)
@@ -56,7 +56,22 @@ func (s *deployerSuite) TearDownTest(c *gc.C) {
 func (s *deployerSuite) makeDeployerAndContext(c *gc.C) (worker.Worker, deployer.Context) {
 	// Create a deployer acting on behalf of the machine.
 	ctx := s.getContextForMachine(c, s.machine.Tag())
-	return deployer.NewDeployer(s.deployerState, ctx), ctx
+	return deployer.NewDeployer(s.deployerState, ctx, s.clock), ctx
+}
+
+// failingContext wraps a deployer.Context, failing the first
+// deployment of each unit.
+type failingContext struct {
+	deployer.Context
+	failed map[string]bool
+}
+
+func (ctx *failingContext) DeployUnit(unitName, initialPassword string) error {
+	if !ctx.failed[unitName] {
+		ctx.failed[unitName] = true
+		return errors.New("boom")
+	}
+	return ctx.Context.DeployUnit(unitName, initialPassword)
 }
 
 func (s *deployerSuite) TestDeployRecallRemovePrincipals(c *gc.C) {
@@ -218,6 +233,35 @@ func (s *deployerSuite) TestNonAliveSubordinates(c *gc.C) {
 	s.waitFor(c, isRemoved(s.State, sub1.Name()))
 }
 
+func (s *deployerSuite) TestRetryFailedDeployment(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	u0, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	ctx := &failingContext{
+		Context: s.getContextForMachine(c, s.machine.Tag()),
+		failed:  make(map[string]bool),
+	}
+	dep := deployer.NewDeployer(s.deployerState, ctx, s.clock)
+	defer stop(c, dep)
+
+	// The first deployment fails, and the deployer waits on the
+	// clock before trying again.
+	err = u0.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	select {
+	case <-s.clock.Alarms():
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("deployer did not wait on the clock")
+	}
+	s.waitFor(c, isDeployed(ctx))
+
+	// Once the delay has passed, the unit is deployed.
+	s.clock.Advance(10 * time.Second)
+	s.waitFor(c, isDeployed(ctx, u0.Name()))
+}
+
 func (s *deployerSuite) waitFor(c *gc.C, t func(c *gc.C) bool) {
 	s.BackingState.StartSync()
 	if t(c) {
@@ -4,10 +4,23 @@
 package deployer
 
 import (
+	"fmt"
+
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/state/api/params"
+	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/version"
 )
 
+// FakeTools holds the tools returned by the fake API's FindAgentTools
+// method, matching those unpacked by the tests.
+var FakeTools = &coretools.Tools{
+	Version: version.Current,
+	URL:     "http://testing.invalid/tools",
+	SHA256:  "1234567890abcdef",
+	Size:    1234,
+}
+
 type fakeAPI struct{}
 
 func (*fakeAPI) ConnectionInfo() (params.DeployerConnectionValues, error) {
@@ -17,6 +30,13 @@ func (*fakeAPI) ConnectionInfo() (params.DeployerConnectionValues, error) {
 	}, nil
 }
 
+func (*fakeAPI) FindAgentTools(tag string, vers version.Binary) (*coretools.Tools, error) {
+	if vers != FakeTools.Version {
+		return nil, fmt.Errorf("tools %s not found", vers)
+	}
+	return FakeTools, nil
+}
+
 func NewTestSimpleContext(agentConfig agent.Config, initDir, logDir string) *SimpleContext {
 	return &SimpleContext{
 		api:         &fakeAPI{},
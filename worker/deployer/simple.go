@@ -15,11 +15,14 @@ import (
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/agent/tools"
+	jujunames "github.com/juju/juju/juju/names"
 	"github.com/juju/juju/juju/osenv"
 	"github.com/juju/juju/service"
 	"github.com/juju/juju/service/common"
 	"github.com/juju/juju/state/api/params"
+	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker/uniter/jujuc"
 )
 
 // InitDir is the default upstart init directory.
@@ -29,6 +32,7 @@ var InitDir = "/etc/init"
 // APICalls defines the interface to the API that the simple context needs.
 type APICalls interface {
 	ConnectionInfo() (params.DeployerConnectionValues, error)
+	FindAgentTools(tag string, vers version.Binary) (*coretools.Tools, error)
 }
 
 // SimpleContext is a Context that manages unit deployments on the local system.
@@ -45,6 +49,11 @@ type SimpleContext struct {
 	// initDir specifies the directory used by upstart on the local system.
 	// It is typically set to "/etc/init".
 	initDir string
+
+	// verifiedSHA256 holds the tarball checksum of the running tools
+	// once it has been checked against the tools metadata, so that
+	// the metadata is only looked up once.
+	verifiedSHA256 string
 }
 
 var _ Context = (*SimpleContext)(nil)
@@ -93,17 +102,29 @@ func (ctx *SimpleContext) DeployUnit(unitName, initialPassword string) (err erro
 	tag := names.NewUnitTag(unitName)
 	dataDir := ctx.agentConfig.DataDir()
 	logDir := ctx.agentConfig.LogDir()
+	if err := ctx.verifyTools(dataDir); err != nil {
+		return err
+	}
 	// TODO(dfc)
 	_, err = tools.ChangeAgentTools(dataDir, tag.String(), version.Current)
+	if err != nil {
+		return err
+	}
 	// TODO(dfc)
 	toolsDir := tools.ToolsDir(dataDir, tag.String())
 	defer removeOnErr(&err, toolsDir)
 
+	// Hook tools are links to jujud, which dispatches on the name
+	// it is invoked with; install them alongside the tools so they
+	// are in place before the unit agent first runs.
+	if err := jujuc.EnsureSymlinks(toolsDir); err != nil {
+		return err
+	}
+
 	result, err := ctx.api.ConnectionInfo()
 	if err != nil {
 		return err
 	}
-	logger.Debugf("state addresses: %q", result.StateAddresses)
 	logger.Debugf("API addresses: %q", result.APIAddresses)
 	containerType := ctx.agentConfig.Value(agent.ContainerType)
 	namespace := ctx.agentConfig.Value(agent.Namespace)
@@ -113,12 +134,13 @@ func (ctx *SimpleContext) DeployUnit(unitName, initialPassword string) (err erro
 			LogDir:            logDir,
 			UpgradedToVersion: version.Current.Number,
 			Tag:               tag,
+			Environment:       ctx.agentConfig.Environment(),
 			Password:          initialPassword,
 			Nonce:             "unused",
-			// TODO: remove the state addresses here and test when api only.
-			StateAddresses: result.StateAddresses,
-			APIAddresses:   result.APIAddresses,
-			CACert:         ctx.agentConfig.CACert(),
+			// Unit agents only ever connect to the API, so they
+			// are not given the state server addresses.
+			APIAddresses: result.APIAddresses,
+			CACert:       ctx.agentConfig.CACert(),
 			Values: map[string]string{
 				agent.ContainerType: containerType,
 				agent.Namespace:     namespace,
@@ -132,10 +154,11 @@ func (ctx *SimpleContext) DeployUnit(unitName, initialPassword string) (err erro
 	}
 	defer removeOnErr(&err, conf.Dir())
 
-	// Install an upstart job that runs the unit agent.
+	// Install a service that runs the unit agent; this is an upstart
+	// job, or a Windows service on Windows.
 	logPath := path.Join(logDir, tag.String()+".log")
 	cmd := strings.Join([]string{
-		path.Join(toolsDir, "jujud"), "unit",
+		path.Join(toolsDir, jujunames.Jujud), "unit",
 		"--data-dir", dataDir,
 		"--unit-name", unitName,
 		"--debug", // TODO: propagate debug state sensibly
@@ -157,6 +180,43 @@ func (ctx *SimpleContext) DeployUnit(unitName, initialPassword string) (err erro
 	return svc.Install()
 }
 
+// verifyTools checks that the tools unpacked in dataDir for the
+// running version, which are linked for use by new unit agents, are
+// unchanged since they were unpacked, and that the tarball they were
+// unpacked from matches the tools metadata for that version. Units
+// are not deployed with tools that cannot be verified.
+func (ctx *SimpleContext) verifyTools(dataDir string) error {
+	agentTools, err := tools.VerifyTools(dataDir, version.Current)
+	if err == tools.ErrNoChecksums {
+		// Tools unpacked by older agents have no recorded
+		// checksums; only the tarball can be checked.
+		logger.Warningf("cannot verify unpacked tools %s: %v", version.Current, err)
+	} else if err != nil {
+		return fmt.Errorf("cannot verify tools %s: %v", version.Current, err)
+	}
+	if agentTools.SHA256 != "" && agentTools.SHA256 == ctx.verifiedSHA256 {
+		return nil
+	}
+	expected, err := ctx.api.FindAgentTools(ctx.agentConfig.Tag().String(), version.Current)
+	if err != nil {
+		return fmt.Errorf("cannot verify tools %s: %v", version.Current, err)
+	}
+	switch {
+	case expected.SHA256 == "":
+		err = fmt.Errorf("no sha256 in tools metadata")
+	case agentTools.SHA256 != expected.SHA256:
+		err = fmt.Errorf("sha256 mismatch, expected %s, got %s", expected.SHA256, agentTools.SHA256)
+	case agentTools.Size != expected.Size:
+		err = fmt.Errorf("size mismatch, expected %d, got %d", expected.Size, agentTools.Size)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot verify tools %s: %v", version.Current, err)
+	}
+	logger.Debugf("verified tools %s (sha256 %s)", version.Current, agentTools.SHA256)
+	ctx.verifiedSHA256 = agentTools.SHA256
+	return nil
+}
+
 // findUpstartJob tries to find an upstart job matching the
 // given unit name in one of these formats:
 //   jujud-<deployer-tag>:<unit-tag>.conf (for compatibility)
@@ -233,6 +293,31 @@ func (ctx *SimpleContext) DeployedUnits() ([]string, error) {
 	return installed, nil
 }
 
+// StopUnitAgents stops the agents of all deployed units, without
+// removing them, so that they may be started again by StartUnitAgents.
+func (ctx *SimpleContext) StopUnitAgents() error {
+	return ctx.forEachUnitAgent(service.Service.Stop)
+}
+
+// StartUnitAgents starts the agents of all deployed units.
+func (ctx *SimpleContext) StartUnitAgents() error {
+	return ctx.forEachUnitAgent(service.Service.Start)
+}
+
+func (ctx *SimpleContext) forEachUnitAgent(f func(service.Service) error) error {
+	unitsAndJobs, err := ctx.deployedUnitsUpstartJobs()
+	if err != nil {
+		return err
+	}
+	for unitName, job := range unitsAndJobs {
+		svc := service.NewService(job, common.Conf{InitDir: ctx.initDir})
+		if err := f(svc); err != nil {
+			return fmt.Errorf("cannot control agent of unit %q: %v", unitName, err)
+		}
+	}
+	return nil
+}
+
 // service returns a service.Service corresponding to the specified
 // unit.
 func (ctx *SimpleContext) service(unitName string) service.Service {
@@ -4,6 +4,7 @@
 package deployer_test
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -14,15 +15,19 @@ import (
 
 	"github.com/juju/names"
 	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/symlink"
 	gc "launchpad.net/gocheck"
 
 	"github.com/juju/juju/agent"
 	"github.com/juju/juju/agent/tools"
+	"github.com/juju/juju/service"
+	"github.com/juju/juju/service/common"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/testing"
 	coretools "github.com/juju/juju/tools"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker/deployer"
+	"github.com/juju/juju/worker/uniter/jujuc"
 )
 
 type SimpleContextSuite struct {
@@ -63,6 +68,26 @@ func (s *SimpleContextSuite) TestDeployRecall(c *gc.C) {
 	s.checkUnitRemoved(c, "foo/123")
 }
 
+func (s *SimpleContextSuite) TestStopStartUnitAgents(c *gc.C) {
+	mgr := s.getContext(c)
+	err := mgr.DeployUnit("foo/123", "some-password")
+	c.Assert(err, gc.IsNil)
+	svc := service.NewService("jujud-unit-foo-123", common.Conf{InitDir: s.initDir})
+	c.Assert(svc.Running(), jc.IsTrue)
+
+	err = mgr.StopUnitAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Running(), jc.IsFalse)
+	c.Assert(svc.Installed(), jc.IsTrue)
+	units, err := mgr.DeployedUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.DeepEquals, []string{"foo/123"})
+
+	err = mgr.StartUnitAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Running(), jc.IsTrue)
+}
+
 func (s *SimpleContextSuite) TestOldDeployedUnitsCanBeRecalled(c *gc.C) {
 	// After r1347 deployer tag is no longer part of the upstart conf filenames,
 	// now only the units' tags are used. This change is with the assumption only
@@ -130,6 +155,51 @@ func (s *SimpleContextSuite) TestOldDeployedUnitsCanBeRecalled(c *gc.C) {
 	c.Assert(units, gc.HasLen, 0)
 }
 
+func (s *SimpleContextSuite) TestDeployUnverifiedTools(c *gc.C) {
+	otherVersion := version.Current
+	otherVersion.Minor++
+	for i, test := range []struct {
+		about string
+		tools coretools.Tools
+		err   string
+	}{{
+		about: "no metadata for running version",
+		tools: coretools.Tools{Version: otherVersion, SHA256: "1234567890abcdef", Size: 1234},
+		err:   "cannot verify tools .*: tools " + version.Current.String() + " not found",
+	}, {
+		about: "no sha256",
+		tools: coretools.Tools{Version: version.Current, Size: 1234},
+		err:   "cannot verify tools .*: no sha256 in tools metadata",
+	}, {
+		about: "sha256 mismatch",
+		tools: coretools.Tools{Version: version.Current, SHA256: "fedcba0987654321", Size: 1234},
+		err:   "cannot verify tools .*: sha256 mismatch, expected fedcba0987654321, got 1234567890abcdef",
+	}, {
+		about: "size mismatch",
+		tools: coretools.Tools{Version: version.Current, SHA256: "1234567890abcdef", Size: 4321},
+		err:   "cannot verify tools .*: size mismatch, expected 4321, got 1234",
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		orig := *deployer.FakeTools
+		*deployer.FakeTools = test.tools
+		err := s.getContext(c).DeployUnit("foo/123", "some-password")
+		*deployer.FakeTools = orig
+		c.Check(err, gc.ErrorMatches, test.err)
+		s.assertUpstartCount(c, 0)
+		s.checkUnitRemoved(c, "foo/123")
+	}
+}
+
+func (s *SimpleContextSuite) TestDeployModifiedTools(c *gc.C) {
+	jujudPath := filepath.Join(tools.SharedToolsDir(s.dataDir, version.Current), "jujud")
+	err := ioutil.WriteFile(jujudPath, []byte("#!/bin/bash --norc\nexit 1\n"), 0755)
+	c.Assert(err, gc.IsNil)
+	err = s.getContext(c).DeployUnit("foo/123", "some-password")
+	c.Assert(err, gc.ErrorMatches, `cannot verify tools .*: sha256 mismatch for "jujud", .*`)
+	s.assertUpstartCount(c, 0)
+	s.checkUnitRemoved(c, "foo/123")
+}
+
 type SimpleToolsFixture struct {
 	dataDir  string
 	logDir   string
@@ -150,9 +220,12 @@ func (fix *SimpleToolsFixture) SetUp(c *gc.C, dataDir string) {
 	jujudPath := filepath.Join(toolsDir, "jujud")
 	err = ioutil.WriteFile(jujudPath, []byte(fakeJujud), 0755)
 	c.Assert(err, gc.IsNil)
+	checksumsPath := filepath.Join(toolsDir, "downloaded-tools.sha256")
+	checksums := fmt.Sprintf("%x  jujud\n", sha256.Sum256([]byte(fakeJujud)))
+	err = ioutil.WriteFile(checksumsPath, []byte(checksums), 0644)
+	c.Assert(err, gc.IsNil)
 	toolsPath := filepath.Join(toolsDir, "downloaded-tools.txt")
-	testTools := coretools.Tools{Version: version.Current, URL: "http://testing.invalid/tools"}
-	data, err := json.Marshal(testTools)
+	data, err := json.Marshal(deployer.FakeTools)
 	c.Assert(err, gc.IsNil)
 	err = ioutil.WriteFile(toolsPath, data, 0644)
 	c.Assert(err, gc.IsNil)
@@ -231,14 +304,28 @@ func (fix *SimpleToolsFixture) checkUnitInstalled(c *gc.C, name, password string
 		}
 	}
 
-	conf, err := agent.ReadConfig(agent.ConfigPath(fix.dataDir, tag))
+	confPath := agent.ConfigPath(fix.dataDir, tag)
+	conf, err := agent.ReadConfig(confPath)
 	c.Assert(err, gc.IsNil)
 	c.Assert(conf.Tag(), gc.Equals, tag)
 	c.Assert(conf.DataDir(), gc.Equals, fix.dataDir)
+	apiAddresses, err := conf.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(apiAddresses, gc.DeepEquals, []string{"a1:123", "a2:123"})
+	// Unit agents are not given the state server addresses.
+	confData, err := ioutil.ReadFile(confPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(confData), gc.Not(jc.Contains), "s1:123")
 
 	jujudData, err := ioutil.ReadFile(jujudPath)
 	c.Assert(err, gc.IsNil)
 	c.Assert(string(jujudData), gc.Equals, fakeJujud)
+
+	for _, name := range jujuc.CommandNames() {
+		target, err := symlink.Read(filepath.Join(toolsDir, name))
+		c.Assert(err, gc.IsNil)
+		c.Assert(target, gc.Equals, jujudPath)
+	}
 }
 
 func (fix *SimpleToolsFixture) checkUnitRemoved(c *gc.C, name string) {
@@ -0,0 +1,22 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package eventpublisher
+
+var MinRetryDelay = &minRetryDelay
+
+// Publish connects to the broker at the given URL with the given
+// credentials and publishes a single message.
+func Publish(url, username, password, topic string, payload []byte) error {
+	conn, err := dialMQTT(brokerInfo{
+		URL:      url,
+		ClientId: "client",
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.publish(topic, payload)
+}
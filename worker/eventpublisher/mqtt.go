@@ -0,0 +1,107 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package eventpublisher
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// ioTimeout bounds the time spent waiting for the broker to accept or
+// acknowledge anything.
+var ioTimeout = 30 * time.Second
+
+// brokerInfo holds what is needed to connect to an MQTT broker.
+type brokerInfo struct {
+	// URL holds the mqtt:// or mqtts:// URL of the broker.
+	URL string
+
+	// ClientId, Username and Password are sent to the broker when
+	// connecting. Username and Password may be empty.
+	ClientId string
+	Username string
+	Password string
+
+	// HostnameVerification determines whether the broker's
+	// certificate is verified when connecting with mqtts.
+	HostnameVerification bool
+}
+
+// mqttConn is a connection to an MQTT broker, able only to publish
+// messages at quality of service level 1.
+type mqttConn struct {
+	client mqtt.Client
+}
+
+// dialMQTT connects to the broker described by info.
+func dialMQTT(info brokerInfo) (*mqttConn, error) {
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "mqtt":
+		u.Host = withPort(u.Host, "1883")
+	case "mqtts":
+		u.Host = withPort(u.Host, "8883")
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(u.String()).
+		SetClientID(info.ClientId).
+		SetUsername(info.Username).
+		SetPassword(info.Password).
+		SetTLSConfig(&tls.Config{
+			InsecureSkipVerify: !info.HostnameVerification,
+		}).
+		// Speak only MQTT 3.1.1, rather than retrying refused
+		// connections with MQTT 3.1.
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetConnectTimeout(ioTimeout).
+		SetWriteTimeout(ioTimeout).
+		// The publisher reconnects itself, so that it knows which
+		// messages must be published again.
+		SetAutoReconnect(false)
+	client := mqtt.NewClient(opts)
+	if err := wait(client.Connect()); err != nil {
+		return nil, err
+	}
+	return &mqttConn{client: client}, nil
+}
+
+func withPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// wait waits for the given token to complete, for no longer than
+// ioTimeout.
+func wait(token mqtt.Token) error {
+	if !token.WaitTimeout(ioTimeout) {
+		return fmt.Errorf("timed out waiting for broker")
+	}
+	return token.Error()
+}
+
+// publish sends the payload to the given topic, and waits until the
+// broker has acknowledged it.
+func (c *mqttConn) publish(topic string, payload []byte) error {
+	return wait(c.client.Publish(topic, 1, false, payload))
+}
+
+// Close disconnects from the broker, waiting briefly for any work in
+// progress to complete.
+func (c *mqttConn) Close() error {
+	c.client.Disconnect(250)
+	return nil
+}
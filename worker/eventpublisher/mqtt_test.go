@@ -0,0 +1,236 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package eventpublisher_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/eventpublisher"
+)
+
+// MQTT control packet types, as found in the top four bits of a
+// packet's first byte.
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPuback     = 0x40
+	packetPingreq    = 0xc0
+	packetPingresp   = 0xd0
+	packetDisconnect = 0xe0
+)
+
+// message holds a message received by a fakeBroker.
+type message struct {
+	topic   string
+	payload string
+}
+
+// fakeBroker implements just enough of an MQTT broker to accept
+// connections and acknowledge published messages.
+type fakeBroker struct {
+	listener  net.Listener
+	published chan message
+
+	mu sync.Mutex
+	// clients holds the client identifier, user name and password
+	// sent by each connection.
+	clients [][]string
+	// refuse holds the return code sent in reply to connections.
+	refuse byte
+	// drop holds the number of published messages to respond to by
+	// closing the connection instead of acknowledging them.
+	drop int
+}
+
+func newFakeBroker(c *gc.C) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, gc.IsNil)
+	b := &fakeBroker{
+		listener:  listener,
+		published: make(chan message, 100),
+	}
+	go b.serve()
+	return b
+}
+
+func (b *fakeBroker) URL() string {
+	return "mqtt://" + b.listener.Addr().String()
+}
+
+func (b *fakeBroker) Close() {
+	b.listener.Close()
+}
+
+func (b *fakeBroker) Clients() [][]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([][]string(nil), b.clients...)
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *fakeBroker) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header & 0xf0 {
+		case packetConnect:
+			b.mu.Lock()
+			b.clients = append(b.clients, readStrings(body[10:]))
+			refuse := b.refuse
+			b.mu.Unlock()
+			writePacket(conn, packetConnack, []byte{0, refuse})
+		case packetPublish:
+			topic := readStrings(body)[0]
+			id := body[2+len(topic) : 4+len(topic)]
+			b.mu.Lock()
+			drop := b.drop > 0
+			if drop {
+				b.drop--
+			}
+			b.mu.Unlock()
+			if drop {
+				return
+			}
+			b.published <- message{topic, string(body[4+len(topic):])}
+			writePacket(conn, packetPuback, id)
+		case packetPingreq:
+			writePacket(conn, packetPingresp, nil)
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+// writePacket writes an MQTT packet with the given first byte and
+// body, prefixed by the body's encoded length.
+func writePacket(w io.Writer, header byte, body []byte) error {
+	buf := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(buf, body...))
+	return err
+}
+
+// readPacket reads an MQTT packet, returning its first byte and its
+// body.
+func readPacket(r *bufio.Reader) (header byte, body []byte, err error) {
+	if header, err = r.ReadByte(); err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("malformed packet length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body = make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// readStrings reads the length-prefixed strings at the start of the
+// given data, stopping at the first that is incomplete.
+func readStrings(data []byte) []string {
+	var result []string
+	for len(data) >= 2 {
+		n := int(data[0])<<8 | int(data[1])
+		if len(data) < 2+n {
+			break
+		}
+		result = append(result, string(data[2:2+n]))
+		data = data[2+n:]
+	}
+	return result
+}
+
+type mqttSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&mqttSuite{})
+
+func (s *mqttSuite) TestPublish(c *gc.C) {
+	broker := newFakeBroker(c)
+	defer broker.Close()
+
+	err := eventpublisher.Publish(broker.URL(), "", "", "some/topic", []byte("hello"))
+	c.Assert(err, gc.IsNil)
+	select {
+	case m := <-broker.published:
+		c.Assert(m, gc.Equals, message{"some/topic", "hello"})
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("message not published")
+	}
+	c.Assert(broker.Clients(), gc.DeepEquals, [][]string{{"client"}})
+}
+
+func (s *mqttSuite) TestCredentials(c *gc.C) {
+	broker := newFakeBroker(c)
+	defer broker.Close()
+
+	err := eventpublisher.Publish(broker.URL(), "juju", "sekrit", "some/topic", []byte("hello"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(broker.Clients(), gc.DeepEquals, [][]string{{"client", "juju", "sekrit"}})
+}
+
+func (s *mqttSuite) TestConnectionRefused(c *gc.C) {
+	broker := newFakeBroker(c)
+	defer broker.Close()
+	broker.refuse = 5
+
+	err := eventpublisher.Publish(broker.URL(), "", "", "some/topic", []byte("hello"))
+	c.Assert(err, gc.ErrorMatches, "not Authorized")
+}
+
+func (s *mqttSuite) TestPublishNotAcknowledged(c *gc.C) {
+	broker := newFakeBroker(c)
+	defer broker.Close()
+	broker.drop = 1
+
+	err := eventpublisher.Publish(broker.URL(), "", "", "some/topic", []byte("hello"))
+	c.Assert(err, gc.NotNil)
+}
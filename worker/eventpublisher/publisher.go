@@ -0,0 +1,192 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package eventpublisher
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.eventpublisher")
+
+// minRetryDelay and maxRetryDelay bound the time waited before trying
+// again to publish changes that the broker did not acknowledge. The
+// delay doubles with each consecutive failure.
+var (
+	minRetryDelay = time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// Publisher publishes the changes seen by the all-watcher to the MQTT
+// broker named in the environment configuration.
+//
+// Changes are published at least once: any that were not acknowledged
+// are published again once the broker can be reached. While the broker
+// is slow or unreachable, no further changes are read from the
+// all-watcher, which combines any that arrive meanwhile, so a backlog
+// of changes cannot grow without bound.
+type Publisher struct {
+	tomb tomb.Tomb
+	st   *state.State
+
+	// conn holds the connection to the broker described by info,
+	// if any.
+	conn *mqttConn
+	info brokerInfo
+}
+
+// NewPublisher returns a worker that publishes changes in the
+// environment to the broker named by its event-publisher-url setting.
+// Changes are discarded while event-publisher-url is unset.
+func NewPublisher(st *state.State) worker.Worker {
+	p := &Publisher{st: st}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *Publisher) String() string {
+	return "eventpublisher"
+}
+
+func (p *Publisher) Kill() {
+	p.tomb.Kill(nil)
+}
+
+func (p *Publisher) Stop() error {
+	p.tomb.Kill(nil)
+	return p.tomb.Wait()
+}
+
+func (p *Publisher) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *Publisher) loop() error {
+	defer p.disconnect()
+	w := p.st.Watch()
+	defer w.Stop()
+	changes := make(chan []params.Delta)
+	go func() {
+		for {
+			deltas, err := w.Next()
+			if err == multiwatcher.ErrWatcherStopped {
+				return
+			} else if err != nil {
+				p.tomb.Kill(err)
+				return
+			}
+			select {
+			case changes <- deltas:
+			case <-p.tomb.Dying():
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case deltas := <-changes:
+			if err := p.publish(deltas); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish publishes each of the deltas, retrying until all have been
+// acknowledged by the broker.
+func (p *Publisher) publish(deltas []params.Delta) error {
+	payloads := make([][]byte, len(deltas))
+	for i := range deltas {
+		payload, err := json.Marshal(&deltas[i])
+		if err != nil {
+			return err
+		}
+		payloads[i] = payload
+	}
+	delay := minRetryDelay
+	for {
+		cfg, err := p.st.EnvironConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.EventPublisherURL() == "" {
+			p.disconnect()
+			return nil
+		}
+		sent, err := p.publishAll(cfg, payloads)
+		if err == nil {
+			return nil
+		}
+		payloads = payloads[sent:]
+		logger.Warningf("cannot publish changes to %q (retrying in %v): %v", cfg.EventPublisherURL(), delay, err)
+		p.disconnect()
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// publishAll publishes the payloads in order, connecting to the broker
+// if necessary, and returns the number that were acknowledged.
+func (p *Publisher) publishAll(cfg *config.Config, payloads [][]byte) (int, error) {
+	clientId := "juju-" + p.st.EnvironTag().Id()
+	// Brokers need only accept client identifiers of up to 23
+	// characters.
+	if len(clientId) > 23 {
+		clientId = clientId[:23]
+	}
+	info := brokerInfo{
+		URL:                  cfg.EventPublisherURL(),
+		ClientId:             clientId,
+		Username:             cfg.EventPublisherUsername(),
+		Password:             cfg.EventPublisherPassword(),
+		HostnameVerification: cfg.SSLHostnameVerification(),
+	}
+	if p.conn != nil && p.info != info {
+		p.disconnect()
+	}
+	if p.conn == nil {
+		conn, err := dialMQTT(info)
+		if err != nil {
+			return 0, err
+		}
+		logger.Infof("connected to %q", info.URL)
+		p.conn, p.info = conn, info
+	}
+	for i, payload := range payloads {
+		if err := p.conn.publish(cfg.EventPublisherTopic(), payload); err != nil {
+			return i, err
+		}
+	}
+	return len(payloads), nil
+}
+
+func (p *Publisher) disconnect() {
+	if p.conn == nil {
+		return
+	}
+	if err := p.conn.Close(); err != nil {
+		logger.Debugf("error closing connection to %q: %v", p.info.URL, err)
+	}
+	p.conn, p.info = nil, brokerInfo{}
+}
@@ -0,0 +1,119 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package eventpublisher_test
+
+import (
+	"encoding/json"
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/eventpublisher"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PublisherSuite struct {
+	testing.JujuConnSuite
+	broker *fakeBroker
+}
+
+var _ = gc.Suite(&PublisherSuite{})
+
+func (s *PublisherSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(eventpublisher.MinRetryDelay, 10*time.Millisecond)
+	s.broker = newFakeBroker(c)
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"event-publisher-url": s.broker.URL(),
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *PublisherSuite) TearDownTest(c *gc.C) {
+	s.broker.Close()
+	s.JujuConnSuite.TearDownTest(c)
+}
+
+// waitService waits for a change to the named service to be published.
+func (s *PublisherSuite) waitService(c *gc.C, name string) {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		s.BackingState.StartSync()
+		select {
+		case m := <-s.broker.published:
+			c.Assert(m.topic, gc.Equals, "juju/events")
+			var delta params.Delta
+			err := json.Unmarshal([]byte(m.payload), &delta)
+			c.Assert(err, gc.IsNil)
+			if info, ok := delta.Entity.(*params.ServiceInfo); ok && info.Name == name {
+				return
+			}
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("change to service %q not published", name)
+		}
+	}
+}
+
+func (s *PublisherSuite) TestPublishesChanges(c *gc.C) {
+	charm := s.AddTestingCharm(c, "wordpress")
+	s.AddTestingService(c, "wordpress", charm)
+	w := eventpublisher.NewPublisher(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	// The current state of the environment is published first.
+	s.waitService(c, "wordpress")
+
+	s.AddTestingService(c, "mysql", charm)
+	s.waitService(c, "mysql")
+	c.Assert(s.broker.Clients(), gc.HasLen, 1)
+	c.Assert(s.broker.Clients()[0][0], gc.Matches, "juju-.*")
+}
+
+func (s *PublisherSuite) TestConnectsWithCredentials(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"event-publisher-username": "juju",
+		"event-publisher-password": "sekrit",
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	w := eventpublisher.NewPublisher(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	s.waitService(c, "wordpress")
+	clients := s.broker.Clients()
+	c.Assert(clients, gc.HasLen, 1)
+	c.Assert(clients[0][1:], gc.DeepEquals, []string{"juju", "sekrit"})
+}
+
+func (s *PublisherSuite) TestRepublishesAfterReconnecting(c *gc.C) {
+	s.broker.drop = 1
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	w := eventpublisher.NewPublisher(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	s.waitService(c, "wordpress")
+	c.Assert(len(s.broker.Clients()) > 1, jc.IsTrue)
+}
+
+func (s *PublisherSuite) TestChangesDiscardedWithoutURL(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(nil, []string{"event-publisher-url"}, nil)
+	c.Assert(err, gc.IsNil)
+	w := eventpublisher.NewPublisher(s.State)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	s.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+	c.Assert(s.broker.Clients(), gc.HasLen, 0)
+}
@@ -37,7 +37,13 @@ type Firewaller struct {
 	serviceds       map[string]*serviceData
 	exposedChange   chan *exposedChange
 	globalMode      bool
-	globalPortRef   map[network.Port]int
+	globalPortRef   map[network.PortRange]int
+
+	// reconciled holds whether the ports opened in the environment
+	// have been reconciled with those wanted by the units. Until
+	// then, ports are only recorded as wanted, and the environment
+	// is left alone; see reconcileGlobal and reconcileInstances.
+	reconciled bool
 }
 
 // NewFirewaller returns a new Firewaller.
@@ -72,15 +78,13 @@ func (fw *Firewaller) loop() error {
 	defer fw.stopWatchers()
 
 	var err error
-	var reconciled bool
-
 	fw.environ, err = worker.WaitForEnviron(fw.environWatcher, fw.st, fw.tomb.Dying())
 	if err != nil {
 		return err
 	}
 	if fw.environ.Config().FirewallMode() == config.FwGlobal {
 		fw.globalMode = true
-		fw.globalPortRef = make(map[network.Port]int)
+		fw.globalPortRef = make(map[network.PortRange]int)
 	}
 	for {
 		select {
@@ -101,11 +105,10 @@ func (fw *Firewaller) loop() error {
 			if !ok {
 				return watcher.MustErr(fw.machinesWatcher)
 			}
-			for _, machineId := range change {
-				fw.machineLifeChanged(names.NewMachineTag(machineId).String())
+			if err := fw.machinesLifeChanged(change); err != nil {
+				return err
 			}
-			if !reconciled {
-				reconciled = true
+			if !fw.reconciled {
 				var err error
 				if fw.globalMode {
 					err = fw.reconcileGlobal()
@@ -115,6 +118,7 @@ func (fw *Firewaller) loop() error {
 				if err != nil {
 					return err
 				}
+				fw.reconciled = true
 			}
 		case change := <-fw.unitsChange:
 			if err := fw.unitsChanged(change); err != nil {
@@ -157,7 +161,7 @@ func (fw *Firewaller) startMachine(machineTag string) error {
 		fw:     fw,
 		tag:    tag,
 		unitds: make(map[string]*unitData),
-		ports:  make([]network.Port, 0),
+		ports:  make([]network.PortRange, 0),
 	}
 	m, err := machined.machine()
 	if params.IsCodeNotFound(err) {
@@ -224,7 +228,7 @@ func (fw *Firewaller) startUnit(unit *apifirewaller.Unit, machineTag string) err
 	unitd.serviced = fw.serviceds[serviceName]
 	unitd.serviced.unitds[unitName] = unitd
 
-	ports := make([]network.Port, len(unitd.ports))
+	ports := make([]network.PortRange, len(unitd.ports))
 	copy(ports, unitd.ports)
 
 	go unitd.watchLoop(ports)
@@ -249,50 +253,46 @@ func (fw *Firewaller) startService(service *apifirewaller.Service) error {
 	return nil
 }
 
-// reconcileGlobal compares the initially started watcher for machines,
-// units and services with the opened and closed ports globally and
-// opens and closes the appropriate ports for the whole environment.
+// reconcileGlobal compares the ports wanted by the units of the
+// initially started machines with the ports opened globally in the
+// environment, and opens and closes only those ports that differ.
+// The wanted ports are taken from the unit data recorded when the
+// units were started, as no ports events have been handled yet.
 func (fw *Firewaller) reconcileGlobal() error {
 	initialPorts, err := fw.environ.Ports()
 	if err != nil {
 		return err
 	}
-	collector := make(map[network.Port]bool)
-	for _, unitd := range fw.unitds {
-		if unitd.serviced.exposed {
-			for _, port := range unitd.ports {
-				collector[port] = true
-			}
-		}
-	}
-	wantedPorts := []network.Port{}
-	for port := range collector {
-		wantedPorts = append(wantedPorts, port)
-	}
+	wanted := wantedPorts(fw.unitds)
 	// Check which ports to open or to close.
-	toOpen := Diff(wantedPorts, initialPorts)
-	toClose := Diff(initialPorts, wantedPorts)
+	toOpen, toClose := reconcilePorts(wanted, initialPorts)
 	if len(toOpen) > 0 {
+		network.SortPortRanges(toOpen)
 		logger.Infof("opening global ports %v", toOpen)
 		if err := fw.environ.OpenPorts(toOpen); err != nil {
 			return err
 		}
-		network.SortPorts(toOpen)
 	}
 	if len(toClose) > 0 {
+		network.SortPortRanges(toClose)
 		logger.Infof("closing global ports %v", toClose)
 		if err := fw.environ.ClosePorts(toClose); err != nil {
 			return err
 		}
-		network.SortPorts(toClose)
 	}
+	logger.Infof("reconciled global ports: %d wanted, %d opened, %d closed",
+		len(wanted), len(toOpen), len(toClose))
 	return nil
 }
 
-// reconcileInstances compares the initially started watcher for machines,
-// units and services with the opened and closed ports of the instances and
-// opens and closes the appropriate ports for each instance.
+// reconcileInstances compares the ports wanted by the units of the
+// initially started machines with the ports opened on their instances,
+// and opens and closes only those ports that differ. As in
+// reconcileGlobal, the wanted ports are taken from the unit data. The
+// instances are fetched from the environment in a single call.
 func (fw *Firewaller) reconcileInstances() error {
+	var machineds []*machineData
+	var instanceIds []instance.Id
 	for _, machined := range fw.machineds {
 		m, err := machined.machine()
 		if params.IsCodeNotFound(err) {
@@ -304,42 +304,65 @@ func (fw *Firewaller) reconcileInstances() error {
 			return err
 		}
 		instanceId, err := m.InstanceId()
-		if err != nil {
+		if params.IsCodeNotProvisioned(err) {
+			logger.Debugf("not reconciling ports for %q: no instance", machined.tag)
+			continue
+		} else if err != nil {
 			return err
 		}
-		instances, err := fw.environ.Instances([]instance.Id{instanceId})
+		machineds = append(machineds, machined)
+		instanceIds = append(instanceIds, instanceId)
+	}
+	if len(instanceIds) == 0 {
+		return nil
+	}
+	instances, err := fw.environ.Instances(instanceIds)
+	if err != nil && err != environs.ErrPartialInstances {
 		if err == environs.ErrNoInstances {
 			return nil
-		} else if err != nil {
-			return err
+		}
+		return err
+	}
+	var opened, closed, changed int
+	for i, machined := range machineds {
+		if instances[i] == nil {
+			logger.Warningf("instance %q for %q not found", instanceIds[i], machined.tag)
+			continue
 		}
 		machineId := machined.tag.Id()
-		initialPorts, err := instances[0].Ports(machineId)
+		initialPorts, err := instances[i].Ports(machineId)
 		if err != nil {
 			return err
 		}
 		// Check which ports to open or to close.
-		toOpen := Diff(machined.ports, initialPorts)
-		toClose := Diff(initialPorts, machined.ports)
+		wanted := wantedPorts(machined.unitds)
+		toOpen, toClose := reconcilePorts(wanted, initialPorts)
 		if len(toOpen) > 0 {
+			network.SortPortRanges(toOpen)
 			logger.Infof("opening instance ports %v for %q",
 				toOpen, machined.tag)
-			if err := instances[0].OpenPorts(machineId, toOpen); err != nil {
+			if err := instances[i].OpenPorts(machineId, toOpen); err != nil {
 				// TODO(mue) Add local retry logic.
 				return err
 			}
-			network.SortPorts(toOpen)
 		}
 		if len(toClose) > 0 {
+			network.SortPortRanges(toClose)
 			logger.Infof("closing instance ports %v for %q",
 				toClose, machined.tag)
-			if err := instances[0].ClosePorts(machineId, toClose); err != nil {
+			if err := instances[i].ClosePorts(machineId, toClose); err != nil {
 				// TODO(mue) Add local retry logic.
 				return err
 			}
-			network.SortPorts(toClose)
 		}
+		if len(toOpen) > 0 || len(toClose) > 0 {
+			changed++
+		}
+		opened += len(toOpen)
+		closed += len(toClose)
 	}
+	logger.Infof("reconciled ports of %d instances: %d opened and %d closed on %d of them",
+		len(machineds), opened, closed, changed)
 	return nil
 }
 
@@ -400,33 +423,40 @@ func (fw *Firewaller) flushUnits(unitds []*unitData) error {
 // flushMachine opens and closes ports for the passed machine.
 func (fw *Firewaller) flushMachine(machined *machineData) error {
 	// Gather ports to open and close.
-	ports := map[network.Port]bool{}
-	for _, unitd := range machined.unitds {
+	want := wantedPorts(machined.unitds)
+	toOpen := Diff(want, machined.ports)
+	toClose := Diff(machined.ports, want)
+	machined.ports = want
+	if fw.globalMode {
+		return fw.flushGlobalPorts(toOpen, toClose)
+	}
+	return fw.flushInstancePorts(machined, toOpen, toClose)
+}
+
+// wantedPorts returns the ports opened by those of the given units
+// whose services are exposed.
+func wantedPorts(unitds map[string]*unitData) []network.PortRange {
+	ports := map[network.PortRange]bool{}
+	for _, unitd := range unitds {
 		if unitd.serviced.exposed {
 			for _, port := range unitd.ports {
 				ports[port] = true
 			}
 		}
 	}
-	want := []network.Port{}
+	want := []network.PortRange{}
 	for port := range ports {
 		want = append(want, port)
 	}
-	toOpen := Diff(want, machined.ports)
-	toClose := Diff(machined.ports, want)
-	machined.ports = want
-	if fw.globalMode {
-		return fw.flushGlobalPorts(toOpen, toClose)
-	}
-	return fw.flushInstancePorts(machined, toOpen, toClose)
+	return want
 }
 
 // flushGlobalPorts opens and closes global ports in the environment.
 // It keeps a reference count for ports so that only 0-to-1 and 1-to-0 events
 // modify the environment.
-func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose []network.Port) error {
+func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose []network.PortRange) error {
 	// Filter which ports are really to open or close.
-	var toOpen, toClose []network.Port
+	var toOpen, toClose []network.PortRange
 	for _, port := range rawOpen {
 		if fw.globalPortRef[port] == 0 {
 			toOpen = append(toOpen, port)
@@ -440,13 +470,17 @@ func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose []network.Port) error {
 			delete(fw.globalPortRef, port)
 		}
 	}
+	if !fw.reconciled {
+		// The wanted ports are opened by reconcileGlobal.
+		return nil
+	}
 	// Open and close the ports.
 	if len(toOpen) > 0 {
 		if err := fw.environ.OpenPorts(toOpen); err != nil {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toOpen)
+		network.SortPortRanges(toOpen)
 		logger.Infof("opened ports %v in environment", toOpen)
 	}
 	if len(toClose) > 0 {
@@ -454,14 +488,14 @@ func (fw *Firewaller) flushGlobalPorts(rawOpen, rawClose []network.Port) error {
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toClose)
+		network.SortPortRanges(toClose)
 		logger.Infof("closed ports %v in environment", toClose)
 	}
 	return nil
 }
 
 // flushInstancePorts opens and closes ports global on the machine.
-func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose []network.Port) error {
+func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose []network.PortRange) error {
 	// If there's nothing to do, do nothing.
 	// This is important because when a machine is first created,
 	// it will have no instance id but also no open ports -
@@ -469,6 +503,10 @@ func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose
 	if len(toOpen) == 0 && len(toClose) == 0 {
 		return nil
 	}
+	if !fw.reconciled {
+		// The wanted ports are opened by reconcileInstances.
+		return nil
+	}
 	m, err := machined.machine()
 	if params.IsCodeNotFound(err) {
 		return nil
@@ -491,7 +529,7 @@ func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toOpen)
+		network.SortPortRanges(toOpen)
 		logger.Infof("opened ports %v on %q", toOpen, machined.tag)
 	}
 	if len(toClose) > 0 {
@@ -499,33 +537,45 @@ func (fw *Firewaller) flushInstancePorts(machined *machineData, toOpen, toClose
 			// TODO(mue) Add local retry logic.
 			return err
 		}
-		network.SortPorts(toClose)
+		network.SortPortRanges(toClose)
 		logger.Infof("closed ports %v on %q", toClose, machined.tag)
 	}
 	return nil
 }
 
+// machinesLifeChanged gets the machines with the given ids in a single
+// call, and handles the change in life of each of them.
+func (fw *Firewaller) machinesLifeChanged(machineIds []string) error {
+	tags := make([]names.MachineTag, len(machineIds))
+	for i, id := range machineIds {
+		tags[i] = names.NewMachineTag(id)
+	}
+	results, err := fw.st.Machines(tags...)
+	if err != nil {
+		return errors.Annotate(err, "cannot get machines")
+	}
+	for i, result := range results {
+		fw.machineLifeChanged(tags[i], result)
+	}
+	return nil
+}
+
 // machineLifeChanged starts watching new machines when the firewaller
 // is starting, or when new machines come to life, and stops watching
 // machines that are dying.
-func (fw *Firewaller) machineLifeChanged(machineTag string) error {
-	tag, err := names.ParseMachineTag(machineTag)
-	if err != nil {
-		return err
+func (fw *Firewaller) machineLifeChanged(tag names.MachineTag, result apifirewaller.MachineResult) error {
+	found := !params.IsCodeNotFound(result.Err)
+	if found && result.Err != nil {
+		return result.Err
 	}
-	m, err := fw.st.Machine(tag)
-	found := !params.IsCodeNotFound(err)
-	if found && err != nil {
-		return err
-	}
-	dead := !found || m.Life() == params.Dead
+	machineTag := tag.String()
+	dead := !found || result.Machine.Life() == params.Dead
 	machined, known := fw.machineds[machineTag]
 	if known && dead {
 		return fw.forgetMachine(machined)
 	}
 	if !known && !dead {
-		err = fw.startMachine(machineTag)
-		if err != nil {
+		if err := fw.startMachine(machineTag); err != nil {
 			return err
 		}
 		logger.Debugf("started watching %q", tag)
@@ -619,7 +669,7 @@ type machineData struct {
 	fw     *Firewaller
 	tag    names.MachineTag
 	unitds map[string]*unitData
-	ports  []network.Port
+	ports  []network.PortRange
 }
 
 func (md *machineData) machine() (*apifirewaller.Machine, error) {
@@ -660,7 +710,7 @@ func (md *machineData) Stop() error {
 // portsChange contains the changed ports for one specific unit.
 type portsChange struct {
 	unitd *unitData
-	ports []network.Port
+	ports []network.PortRange
 }
 
 // unitData holds unit details and watches port changes.
@@ -670,11 +720,11 @@ type unitData struct {
 	unit     *apifirewaller.Unit
 	serviced *serviceData
 	machined *machineData
-	ports    []network.Port
+	ports    []network.PortRange
 }
 
 // watchLoop watches the unit for port changes.
-func (ud *unitData) watchLoop(latestPorts []network.Port) {
+func (ud *unitData) watchLoop(latestPorts []network.PortRange) {
 	defer ud.tomb.Done()
 	w, err := ud.unit.Watch()
 	if err != nil {
@@ -715,9 +765,10 @@ func (ud *unitData) watchLoop(latestPorts []network.Port) {
 	}
 }
 
-// samePorts returns whether old and new contain the same set of ports.
+// samePorts returns whether old and new contain the same set of port
+// ranges.
 // Both old and new must be sorted.
-func samePorts(old, new []network.Port) bool {
+func samePorts(old, new []network.PortRange) bool {
 	if len(old) != len(new) {
 		return false
 	}
@@ -798,8 +849,55 @@ func (sd *serviceData) Stop() error {
 	return sd.tomb.Wait()
 }
 
-// Diff returns all the ports that exist in A but not B.
-func Diff(A, B []network.Port) (missing []network.Port) {
+// reconcilePorts returns the port ranges that must be opened and
+// closed for the opened port ranges to cover the wanted ones. Providers
+// may report ranges differently from how they were opened (Azure, for
+// example, reports each port of a range separately), so opened ranges
+// are compared by the ports they cover rather than exactly.
+func reconcilePorts(wanted, opened []network.PortRange) (toOpen, toClose []network.PortRange) {
+	toClose = uncovered(opened, wanted)
+	toOpen = uncovered(wanted, Diff(opened, toClose))
+	return toOpen, toClose
+}
+
+// uncovered returns all the port ranges in A that are not entirely
+// covered by the port ranges in B.
+func uncovered(A, B []network.PortRange) (missing []network.PortRange) {
+	for _, a := range A {
+		if !covered(a, B) {
+			missing = append(missing, a)
+		}
+	}
+	return
+}
+
+// covered reports whether every port in r is within one of the given
+// port ranges.
+func covered(r network.PortRange, ranges []network.PortRange) bool {
+	var same []network.PortRange
+	for _, other := range ranges {
+		if other.Protocol == r.Protocol {
+			same = append(same, other)
+		}
+	}
+	network.SortPortRanges(same)
+	next := r.FromPort
+	for _, other := range same {
+		if other.FromPort > next {
+			break
+		}
+		if other.ToPort >= next {
+			next = other.ToPort + 1
+		}
+		if next > r.ToPort {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns all the port ranges that exist in A but not B.
+func Diff(A, B []network.PortRange) (missing []network.PortRange) {
 next:
 	for _, a := range A {
 		for _, b := range B {
@@ -4,6 +4,7 @@
 package firewaller_test
 
 import (
+	"errors"
 	"reflect"
 	stdtesting "testing"
 	"time"
@@ -46,7 +47,7 @@ var _ worker.Worker = (*firewaller.Firewaller)(nil)
 
 // assertPorts retrieves the open ports of the instance and compares them
 // to the expected.
-func (s *FirewallerSuite) assertPorts(c *gc.C, inst instance.Instance, machineId string, expected []network.Port) {
+func (s *FirewallerSuite) assertPorts(c *gc.C, inst instance.Instance, machineId string, expected []network.PortRange) {
 	s.BackingState.StartSync()
 	start := time.Now()
 	for {
@@ -55,8 +56,8 @@ func (s *FirewallerSuite) assertPorts(c *gc.C, inst instance.Instance, machineId
 			c.Fatal(err)
 			return
 		}
-		network.SortPorts(got)
-		network.SortPorts(expected)
+		network.SortPortRanges(got)
+		network.SortPortRanges(expected)
 		if reflect.DeepEqual(got, expected) {
 			c.Succeed()
 			return
@@ -71,7 +72,7 @@ func (s *FirewallerSuite) assertPorts(c *gc.C, inst instance.Instance, machineId
 
 // assertEnvironPorts retrieves the open ports of environment and compares them
 // to the expected.
-func (s *FirewallerSuite) assertEnvironPorts(c *gc.C, expected []network.Port) {
+func (s *FirewallerSuite) assertEnvironPorts(c *gc.C, expected []network.PortRange) {
 	s.BackingState.StartSync()
 	start := time.Now()
 	for {
@@ -80,8 +81,8 @@ func (s *FirewallerSuite) assertEnvironPorts(c *gc.C, expected []network.Port) {
 			c.Fatal(err)
 			return
 		}
-		network.SortPorts(got)
-		network.SortPorts(expected)
+		network.SortPortRanges(got)
+		network.SortPortRanges(expected)
 		if reflect.DeepEqual(got, expected) {
 			c.Succeed()
 			return
@@ -188,12 +189,38 @@ func (s *FirewallerSuite) TestExposedService(c *gc.C) {
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	err = u.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 8080}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{8080, 8080, "tcp"}})
+}
+
+func (s *FirewallerSuite) TestExposedServicePortRanges(c *gc.C) {
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
+
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+
+	err = svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+
+	// Ranges are opened on the instance as they are.
+	err = u.OpenPorts("tcp", 1, 65535)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPorts("icmp", -1, -1)
+	c.Assert(err, gc.IsNil)
+
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{-1, -1, "icmp"}, {1, 65535, "tcp"}})
+
+	err = u.ClosePorts("tcp", 1, 65535)
+	c.Assert(err, gc.IsNil)
+
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{-1, -1, "icmp"}})
 }
 
 func (s *FirewallerSuite) TestMultipleExposedServices(c *gc.C) {
@@ -222,15 +249,15 @@ func (s *FirewallerSuite) TestMultipleExposedServices(c *gc.C) {
 	err = u2.OpenPort("tcp", 3306)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 80}, {"tcp", 8080}})
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 3306}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{3306, 3306, "tcp"}})
 
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 	err = u2.ClosePort("tcp", 3306)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 8080}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{8080, 8080, "tcp"}})
 	s.assertPorts(c, inst2, m2.Id(), nil)
 }
 
@@ -251,12 +278,12 @@ func (s *FirewallerSuite) TestMachineWithoutInstanceId(c *gc.C) {
 	inst2 := s.startInstance(c, m2)
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	inst1 := s.startInstance(c, m1)
 	err = u1.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 8080}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{8080, 8080, "tcp"}})
 }
 
 func (s *FirewallerSuite) TestMultipleUnits(c *gc.C) {
@@ -278,8 +305,8 @@ func (s *FirewallerSuite) TestMultipleUnits(c *gc.C) {
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 80}})
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
@@ -310,7 +337,7 @@ func (s *FirewallerSuite) TestStartWithState(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	err = svc.SetExposed()
 	c.Assert(err, gc.IsNil)
@@ -340,7 +367,7 @@ func (s *FirewallerSuite) TestStartWithPartialState(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
 }
 
 func (s *FirewallerSuite) TestStartWithUnexposedService(c *gc.C) {
@@ -366,7 +393,68 @@ func (s *FirewallerSuite) TestStartWithUnexposedService(c *gc.C) {
 	// Expose service.
 	err = svc.SetExposed()
 	c.Assert(err, gc.IsNil)
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
+}
+
+func (s *FirewallerSuite) TestRestartOnlyChangesDifference(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err := svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	inst := s.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPort("tcp", 8080)
+	c.Assert(err, gc.IsNil)
+
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
+	c.Assert(fw.Stop(), gc.IsNil)
+
+	// Change the ports while the firewaller is stopped.
+	err = u.ClosePort("tcp", 8080)
+	c.Assert(err, gc.IsNil)
+	err = u.OpenPort("tcp", 8888)
+	c.Assert(err, gc.IsNil)
+
+	op := make(chan dummy.Operation, 200)
+	dummy.Listen(op)
+	defer dummy.Listen(nil)
+
+	// Restarting the firewaller only opens and closes the
+	// ports that differ.
+	fw, err = firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8888, 8888, "tcp"}})
+
+	var portOps []dummy.Operation
+	for done := false; !done; {
+		select {
+		case o := <-op:
+			switch o.(type) {
+			case dummy.OpOpenPorts, dummy.OpClosePorts:
+				portOps = append(portOps, o)
+			}
+		default:
+			done = true
+		}
+	}
+	c.Assert(portOps, gc.DeepEquals, []dummy.Operation{
+		dummy.OpOpenPorts{
+			Env:        "dummyenv",
+			MachineId:  m.Id(),
+			InstanceId: inst.Id(),
+			Ports:      []network.PortRange{{8888, 8888, "tcp"}},
+		},
+		dummy.OpClosePorts{
+			Env:        "dummyenv",
+			MachineId:  m.Id(),
+			InstanceId: inst.Id(),
+			Ports:      []network.PortRange{{8080, 8080, "tcp"}},
+		},
+	})
 }
 
 func (s *FirewallerSuite) TestSetClearExposedService(c *gc.C) {
@@ -390,7 +478,7 @@ func (s *FirewallerSuite) TestSetClearExposedService(c *gc.C) {
 	err = svc.SetExposed()
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// ClearExposed closes the ports again.
 	err = svc.ClearExposed()
@@ -418,8 +506,8 @@ func (s *FirewallerSuite) TestRemoveUnit(c *gc.C) {
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 80}})
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	// Remove unit.
 	err = u1.EnsureDead()
@@ -428,7 +516,7 @@ func (s *FirewallerSuite) TestRemoveUnit(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	s.assertPorts(c, inst1, m1.Id(), nil)
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{80, 80, "tcp"}})
 }
 
 func (s *FirewallerSuite) TestRemoveService(c *gc.C) {
@@ -445,7 +533,7 @@ func (s *FirewallerSuite) TestRemoveService(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	// Remove service.
 	err = u.EnsureDead()
@@ -480,8 +568,8 @@ func (s *FirewallerSuite) TestRemoveMultipleServices(c *gc.C) {
 	err = u2.OpenPort("tcp", 3306)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst1, m1.Id(), []network.Port{{"tcp", 80}})
-	s.assertPorts(c, inst2, m2.Id(), []network.Port{{"tcp", 3306}})
+	s.assertPorts(c, inst1, m1.Id(), []network.PortRange{{80, 80, "tcp"}})
+	s.assertPorts(c, inst2, m2.Id(), []network.PortRange{{3306, 3306, "tcp"}})
 
 	// Remove services.
 	err = u2.EnsureDead()
@@ -516,7 +604,7 @@ func (s *FirewallerSuite) TestDeadMachine(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	// Remove unit and service, also tested without. Has no effect.
 	err = u.EnsureDead()
@@ -549,7 +637,7 @@ func (s *FirewallerSuite) TestRemoveMachine(c *gc.C) {
 	err = u.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertPorts(c, inst, m.Id(), []network.Port{{"tcp", 80}})
+	s.assertPorts(c, inst, m.Id(), []network.PortRange{{80, 80, "tcp"}})
 
 	// Remove unit.
 	err = u.EnsureDead()
@@ -595,17 +683,17 @@ func (s *FirewallerGlobalModeSuite) TestGlobalMode(c *gc.C) {
 	err = u2.OpenPort("tcp", 80)
 	c.Assert(err, gc.IsNil)
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Closing a port opened by a different unit won't touch the environment.
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Closing a port used just once changes the environment.
 	err = u1.ClosePort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
 
 	// Closing the last port also modifies the environment.
 	err = u2.ClosePort("tcp", 80)
@@ -636,7 +724,7 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeStartWithUnexposedService(c *g
 	// Expose service.
 	err = svc.SetExposed()
 	c.Assert(err, gc.IsNil)
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
 }
 
 func (s *FirewallerGlobalModeSuite) TestGlobalModeRestart(c *gc.C) {
@@ -655,7 +743,7 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestart(c *gc.C) {
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Stop firewaller and close one and open a different port.
 	err = fw.Stop()
@@ -671,7 +759,60 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestart(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8888}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8888, 8888, "tcp"}})
+}
+
+func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartLeavesWantedPorts(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err := svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	s.startInstance(c, m)
+	err = u.OpenPort("tcp", 80)
+	c.Assert(err, gc.IsNil)
+
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
+	c.Assert(fw.Stop(), gc.IsNil)
+
+	// Restarting the firewaller neither closes nor reopens the
+	// ports that are still wanted; if it tried, it would fail.
+	dummy.InjectFailure("OpenPorts", errors.New("unexpected open"), 0)
+	dummy.InjectFailure("ClosePorts", errors.New("unexpected close"), 0)
+	defer dummy.ClearFailures()
+	fw, err = firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
+	s.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+	c.Assert(fw.Stop(), gc.IsNil)
+}
+
+func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartLeavesCoveredPorts(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.charm)
+	err := svc.SetExposed()
+	c.Assert(err, gc.IsNil)
+	u, m := s.addUnit(c, svc)
+	s.startInstance(c, m)
+	err = u.OpenPorts("tcp", 80, 81)
+	c.Assert(err, gc.IsNil)
+
+	// Some providers report each port of an opened range separately.
+	err = s.Environ.OpenPorts([]network.PortRange{{80, 80, "tcp"}, {81, 81, "tcp"}})
+	c.Assert(err, gc.IsNil)
+
+	// Starting the firewaller neither closes the ports covering the
+	// wanted range nor opens the range again.
+	dummy.InjectFailure("OpenPorts", errors.New("unexpected open"), 0)
+	dummy.InjectFailure("ClosePorts", errors.New("unexpected close"), 0)
+	defer dummy.ClearFailures()
+	fw, err := firewaller.NewFirewaller(s.firewaller)
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {81, 81, "tcp"}})
+	c.Assert(fw.Stop(), gc.IsNil)
 }
 
 func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartUnexposedService(c *gc.C) {
@@ -690,7 +831,7 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartUnexposedService(c *gc.
 	err = u.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Stop firewaller and clear exposed flag on service.
 	err = fw.Stop()
@@ -723,7 +864,7 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartPortCount(c *gc.C) {
 	err = u1.OpenPort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Stop firewaller and add another service using the port.
 	err = fw.Stop()
@@ -743,17 +884,17 @@ func (s *FirewallerGlobalModeSuite) TestGlobalModeRestartPortCount(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	defer func() { c.Assert(fw.Stop(), gc.IsNil) }()
 
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Closing a port opened by a different unit won't touch the environment.
 	err = u1.ClosePort("tcp", 80)
 	c.Assert(err, gc.IsNil)
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}, {"tcp", 8080}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}, {8080, 8080, "tcp"}})
 
 	// Closing a port used just once changes the environment.
 	err = u1.ClosePort("tcp", 8080)
 	c.Assert(err, gc.IsNil)
-	s.assertEnvironPorts(c, []network.Port{{"tcp", 80}})
+	s.assertEnvironPorts(c, []network.PortRange{{80, 80, "tcp"}})
 
 	// Closing the last port also modifies the environment.
 	err = u2.ClosePort("tcp", 80)
@@ -59,6 +59,34 @@ func (s *machineSuite) TestSetsInstanceInfoInitially(c *gc.C) {
 	c.Assert(m.instStatus, gc.Equals, "running")
 }
 
+func (s *machineSuite) TestSetsMissingInstanceStatus(c *gc.C) {
+	context := &testMachineContext{
+		getInstanceInfo: instanceInfoGetter(c, "i1234", nil, "", errors.NotFoundf("instance i1234")),
+		dyingc:          make(chan struct{}),
+	}
+	m := &testMachine{
+		id:         "99",
+		instanceId: "i1234",
+		instStatus: "running",
+		addresses:  testAddrs,
+		refresh:    func() error { return nil },
+		life:       state.Alive,
+	}
+	died := make(chan machine)
+	s.PatchValue(&ShortPoll, coretesting.ShortWait/10)
+	s.PatchValue(&LongPoll, coretesting.ShortWait/10)
+
+	go runMachine(context, m, nil, died)
+	time.Sleep(coretesting.ShortWait)
+
+	killMachineLoop(c, m, context.dyingc, died)
+	c.Assert(context.killAllErr, gc.Equals, nil)
+	c.Assert(m.instStatus, gc.Equals, "missing")
+	// The last known addresses are kept.
+	c.Assert(m.addresses, gc.DeepEquals, testAddrs)
+	c.Assert(m.setAddressCount, gc.Equals, 0)
+}
+
 func (s *machineSuite) TestShortPollIntervalWhenNoAddress(c *gc.C) {
 	s.PatchValue(&ShortPoll, 1*time.Millisecond)
 	s.PatchValue(&LongPoll, coretesting.LongWait)
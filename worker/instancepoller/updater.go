@@ -32,6 +32,11 @@ var (
 	LongPoll         = 15 * time.Minute
 )
 
+// missingInstanceStatus is recorded as the instance status of a
+// machine whose instance the provider no longer knows about, for
+// example because it was terminated outside of juju.
+const missingInstanceStatus = "missing"
+
 type machine interface {
 	Id() string
 	InstanceId() (instance.Id, error)
@@ -240,7 +245,15 @@ func pollInstanceInfo(context machineContext, m machine) (instInfo instanceInfo,
 		return instInfo, fmt.Errorf("cannot get machine's instance id: %v", err)
 	}
 	instInfo, err = context.instanceInfo(instId)
-	if err != nil {
+	missing := errors.IsNotFound(err)
+	if missing {
+		// The instance has gone away without juju's involvement.
+		// Record that, but keep the machine's last known addresses
+		// in case the instance reappears.
+		logger.Warningf("instance %q for machine %v not found", instId, m.Id())
+		instInfo = instanceInfo{status: missingInstanceStatus}
+		err = nil
+	} else if err != nil {
 		if errors.IsNotImplemented(err) {
 			return instInfo, err
 		}
@@ -261,7 +274,7 @@ func pollInstanceInfo(context machineContext, m machine) (instInfo instanceInfo,
 			}
 		}
 	}
-	if !addressesEqual(m.Addresses(), instInfo.addresses) {
+	if !missing && !addressesEqual(m.Addresses(), instInfo.addresses) {
 		logger.Infof("machine %q has new addresses: %v", m.Id(), instInfo.addresses)
 		if err = m.SetAddresses(instInfo.addresses...); err != nil {
 			logger.Errorf("cannot set addresses on %q: %v", m, err)
@@ -0,0 +1,14 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package logsignal
+
+import (
+	"syscall"
+)
+
+// dup2 makes newfd a copy of oldfd. Not every linux architecture
+// has the dup2 system call, so dup3 is used instead.
+func dup2(oldfd, newfd int) error {
+	return syscall.Dup3(oldfd, newfd, 0)
+}
@@ -0,0 +1,16 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// Package logsignal provides a worker that lets operators manage a
+// running agent's logging with signals: the agent's log file can be
+// rotated externally, and its log level raised temporarily to debug
+// a live agent without restarting it.
+package logsignal
+
+import (
+	"time"
+)
+
+// DefaultDebugWindow is how long an agent logs at DEBUG level
+// after it is signalled, unless configured otherwise.
+const DefaultDebugWindow = 10 * time.Minute
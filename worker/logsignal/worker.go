@@ -0,0 +1,154 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package logsignal
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.logsignal")
+
+// ReopenSignal is the signal that indicates the agent
+// should reopen its log file, after it has been rotated.
+const ReopenSignal = syscall.SIGHUP
+
+// DebugSignal is the signal that indicates the agent
+// should log at DEBUG level for a while.
+const DebugSignal = syscall.SIGUSR1
+
+// debugConfig is the logging configuration used while the
+// agent logs at DEBUG level.
+const debugConfig = "<root>=DEBUG"
+
+// outputFds holds the file descriptors that are replaced when the
+// log file is reopened. The init system redirects the agent's
+// standard output and error to its log file.
+var outputFds = []int{1, 2}
+
+var (
+	catchOnce sync.Once
+	signals   = make(chan os.Signal, 1)
+)
+
+// CatchSignals arranges for ReopenSignal and DebugSignal to be
+// caught for the rest of the process's lifetime, so that they do
+// not kill the agent while no worker is running to act on them,
+// for example while the worker is being restarted. Agents should
+// call it as early as possible; NewWorker calls it too.
+func CatchSignals() {
+	catchOnce.Do(func() {
+		signal.Notify(signals, ReopenSignal, DebugSignal)
+	})
+}
+
+type logSignalWorker struct {
+	tomb        tomb.Tomb
+	logPath     string
+	debugWindow time.Duration
+}
+
+// NewWorker returns a worker that reopens the log file at logPath
+// when the agent receives ReopenSignal, and logs at DEBUG level
+// for debugWindow when it receives DebugSignal. Receiving
+// DebugSignal again restarts the window.
+func NewWorker(logPath string, debugWindow time.Duration) worker.Worker {
+	CatchSignals()
+	w := &logSignalWorker{
+		logPath:     logPath,
+		debugWindow: debugWindow,
+	}
+	go func() {
+		defer w.tomb.Done()
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+func (w *logSignalWorker) Kill() {
+	w.tomb.Kill(nil)
+}
+
+func (w *logSignalWorker) Wait() error {
+	return w.tomb.Wait()
+}
+
+func (w *logSignalWorker) loop() error {
+	var restore <-chan time.Time
+	var lastConfig string
+	for {
+		select {
+		case <-w.tomb.Dying():
+			if restore != nil {
+				restoreLogging(lastConfig)
+			}
+			return nil
+		case sig := <-signals:
+			switch sig {
+			case ReopenSignal:
+				if err := reopenLog(w.logPath); err != nil {
+					logger.Errorf("cannot reopen log file: %v", err)
+					continue
+				}
+				logger.Infof("reopened log file %q", w.logPath)
+			case DebugSignal:
+				if restore == nil {
+					lastConfig = loggo.LoggerInfo()
+					loggo.ResetLoggers()
+					if err := loggo.ConfigureLoggers(debugConfig); err != nil {
+						return err
+					}
+				}
+				restore = time.After(w.debugWindow)
+				logger.Infof("logging at DEBUG level for %v", w.debugWindow)
+			}
+		case <-restore:
+			restoreLogging(lastConfig)
+			restore = nil
+		}
+	}
+}
+
+// restoreLogging returns logging to lastConfig, the configuration
+// in place before the agent started logging at DEBUG level. If the
+// configuration has been changed in the meantime, for example by
+// the logger worker, it is left alone.
+func restoreLogging(lastConfig string) {
+	if current := loggo.LoggerInfo(); current != debugConfig {
+		logger.Infof("logging configuration changed to %q; not restoring %q", current, lastConfig)
+		return
+	}
+	loggo.ResetLoggers()
+	if err := loggo.ConfigureLoggers(lastConfig); err != nil {
+		logger.Errorf("cannot restore logging configuration %q: %v", lastConfig, err)
+		return
+	}
+	logger.Infof("logging configuration restored to %q", lastConfig)
+}
+
+// reopenLog opens the log file at logPath, creating it if it has
+// been moved away, and redirects output to it.
+func reopenLog(logPath string) error {
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, fd := range outputFds {
+		if err := dup2(int(f.Fd()), fd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
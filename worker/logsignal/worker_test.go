@@ -0,0 +1,141 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package logsignal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/loggo"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/logsignal"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+var _ = gc.Suite(&LogSignalWorkerSuite{})
+
+type LogSignalWorkerSuite struct {
+	testing.BaseSuite
+	// c is a channel that also waits for the worker's signals,
+	// so that they never terminate the process.
+	c       chan os.Signal
+	logPath string
+	output  *os.File
+}
+
+func (s *LogSignalWorkerSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	s.c = make(chan os.Signal, 1)
+	signal.Notify(s.c, logsignal.ReopenSignal, logsignal.DebugSignal)
+
+	// Reopening the log replaces a file descriptor of our own
+	// rather than the test process's standard output.
+	s.logPath = filepath.Join(c.MkDir(), "machine-0.log")
+	var err error
+	s.output, err = os.Create(s.logPath + ".orig")
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(logsignal.OutputFds, []int{int(s.output.Fd())})
+
+	err = loggo.ConfigureLoggers("<root>=WARNING;juju=INFO")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LogSignalWorkerSuite) TearDownTest(c *gc.C) {
+	s.output.Close()
+	signal.Stop(s.c)
+	close(s.c)
+	loggo.ResetLoggers()
+	s.BaseSuite.TearDownTest(c)
+}
+
+func (s *LogSignalWorkerSuite) signal(c *gc.C, sig os.Signal) {
+	proc, err := os.FindProcess(os.Getpid())
+	c.Assert(err, gc.IsNil)
+	defer proc.Release()
+	err = proc.Signal(sig)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LogSignalWorkerSuite) startWorker(c *gc.C, debugWindow time.Duration) worker.Worker {
+	w := logsignal.NewWorker(s.logPath, debugWindow)
+	s.AddCleanup(func(c *gc.C) {
+		w.Kill()
+		c.Assert(w.Wait(), gc.IsNil)
+	})
+	return w
+}
+
+func (s *LogSignalWorkerSuite) TestStartStop(c *gc.C) {
+	w := logsignal.NewWorker(s.logPath, time.Minute)
+	w.Kill()
+	err := w.Wait()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LogSignalWorkerSuite) TestReopenLog(c *gc.C) {
+	s.startWorker(c, time.Minute)
+	s.signal(c, logsignal.ReopenSignal)
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		_, err := s.output.Write([]byte("after rotation\n"))
+		c.Assert(err, gc.IsNil)
+		data, err := ioutil.ReadFile(s.logPath)
+		if err == nil && strings.Contains(string(data), "after rotation\n") {
+			return
+		}
+	}
+	c.Fatalf("log file %q not reopened", s.logPath)
+}
+
+func (s *LogSignalWorkerSuite) waitForLogging(c *gc.C, expect string) {
+	for a := testing.LongAttempt.Start(); a.Next(); {
+		if loggo.LoggerInfo() == expect {
+			return
+		}
+	}
+	c.Fatalf("logging configuration is %q; want %q", loggo.LoggerInfo(), expect)
+}
+
+func (s *LogSignalWorkerSuite) TestDebugWindow(c *gc.C) {
+	lastConfig := loggo.LoggerInfo()
+	s.startWorker(c, 50*time.Millisecond)
+	s.signal(c, logsignal.DebugSignal)
+	s.waitForLogging(c, "<root>=DEBUG")
+	s.waitForLogging(c, lastConfig)
+}
+
+func (s *LogSignalWorkerSuite) TestDebugRestoredOnStop(c *gc.C) {
+	lastConfig := loggo.LoggerInfo()
+	w := logsignal.NewWorker(s.logPath, time.Hour)
+	s.signal(c, logsignal.DebugSignal)
+	s.waitForLogging(c, "<root>=DEBUG")
+	w.Kill()
+	c.Assert(w.Wait(), gc.IsNil)
+	c.Assert(loggo.LoggerInfo(), gc.Equals, lastConfig)
+}
+
+func (s *LogSignalWorkerSuite) TestDebugWindowKeepsNewConfig(c *gc.C) {
+	s.startWorker(c, 50*time.Millisecond)
+	s.signal(c, logsignal.DebugSignal)
+	s.waitForLogging(c, "<root>=DEBUG")
+
+	// The logger worker reconfigures logging during the window.
+	loggo.ResetLoggers()
+	err := loggo.ConfigureLoggers("<root>=ERROR")
+	c.Assert(err, gc.IsNil)
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(loggo.LoggerInfo(), gc.Equals, "<root>=ERROR")
+}
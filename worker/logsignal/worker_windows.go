@@ -0,0 +1,20 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package logsignal
+
+import (
+	"time"
+
+	"github.com/juju/juju/worker"
+)
+
+// CatchSignals does nothing: Windows has no signals with
+// which to manage an agent's logging.
+func CatchSignals() {}
+
+// NewWorker returns a worker that does nothing: Windows
+// has no signals with which to manage an agent's logging.
+func NewWorker(logPath string, debugWindow time.Duration) worker.Worker {
+	return worker.NewNoOpWorker()
+}
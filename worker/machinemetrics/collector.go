@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The machinemetrics package implements a worker that periodically
+// collects basic metrics about the machine it runs on, and sends them
+// to the state server.
+package machinemetrics
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/utils/clock"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.machinemetrics")
+
+// interval sets how often metrics are collected and sent.
+var interval = 5 * time.Minute
+
+// Machine is implemented by the machine the metrics are sent for.
+type Machine interface {
+	AddMetrics(metrics []params.Metric) error
+}
+
+// collector gathers one kind of metric from the local machine.
+type collector func(now time.Time) ([]params.Metric, error)
+
+// collectors holds the functions used to gather the machine metrics.
+var collectors = []collector{
+	collectLoad,
+	collectMemory,
+	collectDisk,
+}
+
+// Collector periodically sends the metrics of the local machine.
+type Collector struct {
+	tomb    tomb.Tomb
+	machine Machine
+	clock   clock.Clock
+}
+
+// NewCollector returns a worker that collects the metrics of the local
+// machine once every interval, as measured by the given clock, and
+// sends them as a single batch.
+func NewCollector(machine Machine, clock clock.Clock) worker.Worker {
+	c := &Collector{
+		machine: machine,
+		clock:   clock,
+	}
+	go func() {
+		defer c.tomb.Done()
+		c.tomb.Kill(c.loop())
+	}()
+	return c
+}
+
+func (c *Collector) String() string {
+	return "machinemetrics"
+}
+
+func (c *Collector) Kill() {
+	c.tomb.Kill(nil)
+}
+
+func (c *Collector) Stop() error {
+	c.tomb.Kill(nil)
+	return c.tomb.Wait()
+}
+
+func (c *Collector) Wait() error {
+	return c.tomb.Wait()
+}
+
+func (c *Collector) loop() error {
+	for {
+		select {
+		case <-c.tomb.Dying():
+			return tomb.ErrDying
+		case <-c.clock.After(interval):
+			if err := c.send(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// send collects the current metrics and sends them, if there are any.
+// Metrics that cannot be collected are logged and skipped.
+func (c *Collector) send() error {
+	now := c.clock.Now()
+	var metrics []params.Metric
+	for _, collect := range collectors {
+		collected, err := collect(now)
+		if err != nil {
+			logger.Warningf("cannot collect metrics: %v", err)
+			continue
+		}
+		metrics = append(metrics, collected...)
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	return c.machine.AddMetrics(metrics)
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package machinemetrics_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/machinemetrics"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type CollectorSuite struct {
+	coretesting.BaseSuite
+}
+
+var _ = gc.Suite(&CollectorSuite{})
+
+const meminfo = `MemTotal:        2048 kB
+MemFree:          512 kB
+MemAvailable:    1024 kB
+Buffers:          128 kB
+`
+
+func (s *CollectorSuite) SetUpTest(c *gc.C) {
+	s.BaseSuite.SetUpTest(c)
+	dir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "loadavg"), []byte("0.50 0.25 0.10 1/123 4567\n"), 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(dir, "meminfo"), []byte(meminfo), 0644)
+	c.Assert(err, gc.IsNil)
+	s.PatchValue(machinemetrics.ProcDir, dir)
+}
+
+func (s *CollectorSuite) TestCollectLoad(c *gc.C) {
+	now := time.Now()
+	metrics, err := machinemetrics.CollectLoad(now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.DeepEquals, []params.Metric{
+		{Key: "cpu.load1", Value: "0.50", Time: now},
+		{Key: "cpu.load5", Value: "0.25", Time: now},
+		{Key: "cpu.load15", Value: "0.10", Time: now},
+	})
+}
+
+func (s *CollectorSuite) TestCollectMemory(c *gc.C) {
+	now := time.Now()
+	metrics, err := machinemetrics.CollectMemory(now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.DeepEquals, []params.Metric{
+		{Key: "memory.total", Value: "2097152", Time: now},
+		{Key: "memory.available", Value: "1048576", Time: now},
+	})
+}
+
+func (s *CollectorSuite) TestCollectMemoryWithoutAvailable(c *gc.C) {
+	err := ioutil.WriteFile(filepath.Join(*machinemetrics.ProcDir, "meminfo"), []byte(
+		"MemTotal: 2048 kB\nMemFree: 512 kB\nBuffers: 128 kB\nCached: 256 kB\n",
+	), 0644)
+	c.Assert(err, gc.IsNil)
+	metrics, err := machinemetrics.CollectMemory(time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics[1].Key, gc.Equals, "memory.available")
+	c.Assert(metrics[1].Value, gc.Equals, "917504")
+}
+
+func (s *CollectorSuite) TestSendsMetrics(c *gc.C) {
+	clock := coretesting.NewClock(time.Now())
+	machine := &mockMachine{batches: make(chan []params.Metric, 1)}
+	w := machinemetrics.NewCollector(machine, clock)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	for i := 0; i < 2; i++ {
+		waitAlarm(c, clock)
+		clock.Advance(*machinemetrics.Interval)
+		select {
+		case batch := <-machine.batches:
+			keys := make(map[string]bool)
+			for _, m := range batch {
+				c.Check(m.Time.Equal(clock.Now()), gc.Equals, true)
+				keys[m.Key] = true
+			}
+			c.Assert(keys["cpu.load1"], gc.Equals, true)
+			c.Assert(keys["memory.total"], gc.Equals, true)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for metrics")
+		}
+	}
+}
+
+func (s *CollectorSuite) TestSendError(c *gc.C) {
+	clock := coretesting.NewClock(time.Now())
+	machine := &mockMachine{
+		batches: make(chan []params.Metric, 1),
+		err:     fmt.Errorf("boom"),
+	}
+	w := machinemetrics.NewCollector(machine, clock)
+	waitAlarm(c, clock)
+	clock.Advance(*machinemetrics.Interval)
+	c.Assert(w.Wait(), gc.ErrorMatches, "boom")
+}
+
+func waitAlarm(c *gc.C, clock *coretesting.Clock) {
+	select {
+	case <-clock.Alarms():
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("collector did not wait on the clock")
+	}
+}
+
+type mockMachine struct {
+	batches chan []params.Metric
+	err     error
+}
+
+func (m *mockMachine) AddMetrics(metrics []params.Metric) error {
+	m.batches <- metrics
+	return m.err
+}
@@ -0,0 +1,32 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// +build !windows
+
+package machinemetrics
+
+import (
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// diskPath holds the path of the filesystem whose usage is reported.
+var diskPath = "/"
+
+// collectDisk reports the total and available space on the root
+// filesystem, in bytes.
+func collectDisk(now time.Time) ([]params.Metric, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(diskPath, &stat); err != nil {
+		return nil, err
+	}
+	total := stat.Blocks * uint64(stat.Bsize)
+	available := stat.Bavail * uint64(stat.Bsize)
+	return []params.Metric{
+		{Key: "disk.total", Value: strconv.FormatUint(total, 10), Time: now},
+		{Key: "disk.available", Value: strconv.FormatUint(available, 10), Time: now},
+	}, nil
+}
@@ -0,0 +1,16 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package machinemetrics
+
+import (
+	"time"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// collectDisk reports no disk metrics, as they are not yet collected
+// on Windows.
+func collectDisk(now time.Time) ([]params.Metric, error) {
+	return nil, nil
+}
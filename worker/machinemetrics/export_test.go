@@ -0,0 +1,11 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package machinemetrics
+
+var (
+	Interval      = &interval
+	ProcDir       = &procDir
+	CollectLoad   = collectLoad
+	CollectMemory = collectMemory
+)
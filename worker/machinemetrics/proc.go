@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package machinemetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// procDir holds the location of the proc filesystem.
+var procDir = "/proc"
+
+// collectLoad reports the load averages of the machine.
+func collectLoad(now time.Time) ([]params.Metric, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procDir, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected load averages %q", data)
+	}
+	var metrics []params.Metric
+	for i, key := range []string{"cpu.load1", "cpu.load5", "cpu.load15"} {
+		if _, err := strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, fmt.Errorf("invalid load average %q", fields[i])
+		}
+		metrics = append(metrics, params.Metric{Key: key, Value: fields[i], Time: now})
+	}
+	return metrics, nil
+}
+
+// collectMemory reports the total and available memory of the machine,
+// in bytes.
+func collectMemory(now time.Time) ([]params.Metric, error) {
+	f, err := os.Open(filepath.Join(procDir, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines look like "MemTotal:        2048000 kB".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			value *= 1024
+		}
+		info[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	total, ok := info["MemTotal"]
+	if !ok {
+		return nil, fmt.Errorf("total memory not found")
+	}
+	// Older kernels do not report the available memory.
+	available, ok := info["MemAvailable"]
+	if !ok {
+		available = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	return []params.Metric{
+		{Key: "memory.total", Value: strconv.FormatUint(total, 10), Time: now},
+		{Key: "memory.available", Value: strconv.FormatUint(available, 10), Time: now},
+	}, nil
+}
@@ -4,7 +4,10 @@ package machiner
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 
 	"github.com/juju/loggo"
 	"github.com/juju/names"
@@ -14,24 +17,52 @@ import (
 	"github.com/juju/juju/state/api/machiner"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/watcher"
+	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 )
 
 var logger = loggo.GetLogger("juju.worker.machiner")
 
+// UnitAgents controls the agents of the units deployed to a machine.
+type UnitAgents interface {
+	StopUnitAgents() error
+	StartUnitAgents() error
+}
+
+// unitAgentsStoppedFile is the name of the file, in the machine
+// agent's directory, recording that the unit agents have been stopped
+// for a series upgrade. It outlives the agent, which is restarted
+// during the upgrade, so that the unit agents are always started
+// again once the upgrade has been completed.
+const unitAgentsStoppedFile = "series-upgrade-stopped-units"
+
 // Machiner is responsible for a machine agent's lifecycle.
 type Machiner struct {
-	st      *machiner.State
-	tag     names.MachineTag
-	machine *machiner.Machine
+	st         *machiner.State
+	tag        names.MachineTag
+	machine    *machiner.Machine
+	unitAgents UnitAgents
+
+	// unitAgentsStoppedPath holds the path of the file recording
+	// that the unit agents have been stopped.
+	unitAgentsStoppedPath string
 }
 
 // NewMachiner returns a Worker that will wait for the identified machine
 // to become Dying and make it Dead; or until the machine becomes Dead by
-// other means.
-func NewMachiner(st *machiner.State, agentConfig agent.Config) worker.Worker {
+// other means. While the machine's series is being upgraded, it keeps
+// the machine's unit agents stopped.
+func NewMachiner(st *machiner.State, agentConfig agent.Config, unitAgents UnitAgents) worker.Worker {
 	// TODO(dfc) clearly agentConfig.Tag() can _only_ return a machine tag
-	mr := &Machiner{st: st, tag: agentConfig.Tag().(names.MachineTag)}
+	tag := agentConfig.Tag().(names.MachineTag)
+	mr := &Machiner{
+		st:         st,
+		tag:        tag,
+		unitAgents: unitAgents,
+		unitAgentsStoppedPath: filepath.Join(
+			agent.Dir(agentConfig.DataDir(), tag), unitAgentsStoppedFile,
+		),
+	}
 	return worker.NewNotifyWorker(mr)
 }
 
@@ -45,6 +76,13 @@ func (mr *Machiner) SetUp() (watcher.NotifyWatcher, error) {
 	}
 	mr.machine = m
 
+	// A machine that was forcibly destroyed may already be Dead,
+	// and there is nothing left to do for it.
+	if m.Life() == params.Dead {
+		logger.Infof("%q is dead", mr.tag)
+		return nil, worker.ErrTerminateAgent
+	}
+
 	// Set the addresses in state to the host's addresses.
 	if err := setMachineAddresses(m); err != nil {
 		return nil, err
@@ -95,22 +133,78 @@ func (mr *Machiner) Handle() error {
 	} else if err != nil {
 		return err
 	}
-	if mr.machine.Life() == params.Alive {
-		return nil
+	switch mr.machine.Life() {
+	case params.Alive:
+		return mr.handleUpgradeSeries()
+	case params.Dead:
+		// The machine was forcibly destroyed, and made Dead
+		// without the agent's help.
+		logger.Infof("%q is now dead", mr.tag)
+		return worker.ErrTerminateAgent
 	}
 	logger.Debugf("%q is now %s", mr.tag, mr.machine.Life())
 	if err := mr.machine.SetStatus(params.StatusStopped, "", nil); err != nil {
 		return fmt.Errorf("%s failed to set status stopped: %v", mr.tag, err)
 	}
 
-	// If the machine is Dying, it has no units,
-	// and can be safely set to Dead.
-	if err := mr.machine.EnsureDead(); err != nil {
+	// If the machine is Dying, it normally has no units, and can be
+	// safely set to Dead. If it was forcibly destroyed, its units are
+	// being removed, after which the machine is made Dead for us.
+	err := mr.machine.EnsureDead()
+	if params.IsCodeHasAssignedUnits(err) {
+		logger.Infof("%q is being forcibly destroyed; waiting for its units to be removed", mr.tag)
+		return nil
+	} else if err != nil {
 		return fmt.Errorf("%s failed to set machine to dead: %v", mr.tag, err)
 	}
 	return worker.ErrTerminateAgent
 }
 
+// handleUpgradeSeries moves a series upgrade of the machine along:
+// it stops the unit agents once the upgrade is prepared, reports when
+// the machine is running the target series, and restarts the unit
+// agents once the upgrade has been completed.
+func (mr *Machiner) handleUpgradeSeries() error {
+	status, series, err := mr.machine.UpgradeSeriesStatus()
+	if err != nil {
+		return err
+	}
+	if status == params.UpgradeSeriesNotStarted {
+		if _, err := os.Stat(mr.unitAgentsStoppedPath); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		logger.Infof("series upgrade of %q complete; starting unit agents", mr.tag)
+		if err := mr.unitAgents.StartUnitAgents(); err != nil {
+			return err
+		}
+		return os.Remove(mr.unitAgentsStoppedPath)
+	}
+	// Record that the unit agents are stopped before stopping them,
+	// so that they are started again however the agent is interrupted.
+	if err := ioutil.WriteFile(mr.unitAgentsStoppedPath, []byte(series+"\n"), 0644); err != nil {
+		return err
+	}
+	// The unit agents will have been started again if the machine
+	// was rebooted, so make sure they stay stopped until the upgrade
+	// has been completed.
+	if err := mr.unitAgents.StopUnitAgents(); err != nil {
+		return err
+	}
+	switch status {
+	case params.UpgradeSeriesPreparing:
+		logger.Infof("unit agents of %q stopped for upgrade to %q", mr.tag, series)
+		return mr.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesPrepared)
+	case params.UpgradeSeriesPrepared:
+		if version.Current.Series == series {
+			logger.Infof("%q is now running series %q", mr.tag, series)
+			return mr.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+		}
+	}
+	return nil
+}
+
 func (mr *Machiner) TearDown() error {
 	// Nothing to do here.
 	return nil
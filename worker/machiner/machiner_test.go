@@ -5,6 +5,8 @@ package machiner_test
 
 import (
 	"net"
+	"os"
+	"sync"
 	stdtesting "testing"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	apimachiner "github.com/juju/juju/state/api/machiner"
 	"github.com/juju/juju/state/api/params"
 	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/machiner"
 )
@@ -58,6 +61,9 @@ func (s *MachinerSuite) SetUpTest(c *gc.C) {
 	s.apiMachine, err = s.machinerState.Machine(s.machine.Tag().(names.MachineTag))
 	c.Assert(err, gc.IsNil)
 	c.Assert(s.apiMachine.Tag(), gc.Equals, s.machine.Tag())
+
+	err = os.MkdirAll(agent.Dir(s.DataDir(), s.machine.Tag()), 0755)
+	c.Assert(err, gc.IsNil)
 }
 
 func (s *MachinerSuite) waitMachineStatus(c *gc.C, m *state.Machine, expectStatus params.Status) {
@@ -82,24 +88,58 @@ var _ worker.NotifyWatchHandler = (*machiner.Machiner)(nil)
 
 type mockConfig struct {
 	agent.Config
-	tag names.Tag
+	tag     names.Tag
+	dataDir string
 }
 
 func (mock *mockConfig) Tag() names.Tag {
 	return mock.tag
 }
 
-func agentConfig(tag names.Tag) agent.Config {
-	return &mockConfig{tag: tag}
+func (mock *mockConfig) DataDir() string {
+	return mock.dataDir
+}
+
+func agentConfig(tag names.Tag, dataDir string) agent.Config {
+	return &mockConfig{tag: tag, dataDir: dataDir}
+}
+
+type fakeUnitAgents struct {
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (f *fakeUnitAgents) StopUnitAgents() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	return nil
+}
+
+func (f *fakeUnitAgents) StartUnitAgents() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = false
+	return nil
+}
+
+func (f *fakeUnitAgents) isStopped() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stopped
 }
 
 func (s *MachinerSuite) TestNotFoundOrUnauthorized(c *gc.C) {
-	mr := machiner.NewMachiner(s.machinerState, agentConfig(names.NewMachineTag("99")))
+	mr := machiner.NewMachiner(s.machinerState, agentConfig(names.NewMachineTag("99"), s.DataDir()), &fakeUnitAgents{})
 	c.Assert(mr.Wait(), gc.Equals, worker.ErrTerminateAgent)
 }
 
 func (s *MachinerSuite) makeMachiner() worker.Worker {
-	return machiner.NewMachiner(s.machinerState, agentConfig(s.apiMachine.Tag()))
+	return s.makeMachinerWithUnitAgents(&fakeUnitAgents{})
+}
+
+func (s *MachinerSuite) makeMachinerWithUnitAgents(unitAgents machiner.UnitAgents) worker.Worker {
+	return machiner.NewMachiner(s.machinerState, agentConfig(s.apiMachine.Tag(), s.DataDir()), unitAgents)
 }
 
 func (s *MachinerSuite) TestRunStop(c *gc.C) {
@@ -138,6 +178,53 @@ func (s *MachinerSuite) TestSetDead(c *gc.C) {
 	c.Assert(s.machine.Life(), gc.Equals, state.Dead)
 }
 
+func (s *MachinerSuite) TestAlreadyDead(c *gc.C) {
+	c.Assert(s.machine.EnsureDead(), gc.IsNil)
+	mr := s.makeMachiner()
+	defer worker.Stop(mr)
+	c.Assert(mr.Wait(), gc.Equals, worker.ErrTerminateAgent)
+}
+
+func (s *MachinerSuite) TestForceDestroyWithUnits(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	mr := s.makeMachiner()
+	defer worker.Stop(mr)
+	s.waitMachineStatus(c, s.machine, params.StatusStarted)
+
+	// The machine cannot be made Dead while its units remain, but
+	// the machiner waits for them to be removed rather than failing.
+	c.Assert(s.machine.ForceDestroy(), gc.IsNil)
+	s.waitMachineStatus(c, s.machine, params.StatusStopped)
+	done := make(chan error, 1)
+	go func() {
+		done <- mr.Wait()
+	}()
+	s.State.StartSync()
+	select {
+	case err := <-done:
+		c.Fatalf("machiner stopped while units remain: %v", err)
+	case <-time.After(coretesting.ShortWait):
+	}
+
+	// Once the cleanup has removed the units and made the machine
+	// Dead, the machiner stops.
+	c.Assert(s.State.Cleanup(), gc.IsNil)
+	s.State.StartSync()
+	select {
+	case err := <-done:
+		c.Assert(err, gc.Equals, worker.ErrTerminateAgent)
+	case <-time.After(worstCase):
+		c.Fatalf("machiner did not stop")
+	}
+	c.Assert(s.machine.Refresh(), gc.IsNil)
+	c.Assert(s.machine.Life(), gc.Equals, state.Dead)
+}
+
 func (s *MachinerSuite) TestMachineAddresses(c *gc.C) {
 	s.PatchValue(machiner.InterfaceAddrs, func() ([]net.Addr, error) {
 		addrs := []net.Addr{
@@ -162,3 +249,94 @@ func (s *MachinerSuite) TestMachineAddresses(c *gc.C) {
 		network.NewAddress("127.0.0.1", network.ScopeMachineLocal),
 	})
 }
+
+func (s *MachinerSuite) waitUpgradeSeriesStatus(c *gc.C, expectStatus params.UpgradeSeriesStatus) {
+	timeout := time.After(worstCase)
+	for {
+		s.State.StartSync()
+		select {
+		case <-timeout:
+			c.Fatalf("timeout while waiting for series upgrade status to change")
+		case <-time.After(10 * time.Millisecond):
+			err := s.machine.Refresh()
+			c.Assert(err, gc.IsNil)
+			if status, _ := s.machine.UpgradeSeriesStatus(); status != expectStatus {
+				c.Logf("series upgrade status is %q, still waiting", status)
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (s *MachinerSuite) TestUpgradeSeries(c *gc.C) {
+	target := "trusty"
+	if version.Current.Series == target {
+		target = "precise"
+	}
+	unitAgents := &fakeUnitAgents{}
+	mr := s.makeMachinerWithUnitAgents(unitAgents)
+	defer worker.Stop(mr)
+
+	err := s.machine.PrepareSeriesUpgrade(target)
+	c.Assert(err, gc.IsNil)
+	s.waitUpgradeSeriesStatus(c, params.UpgradeSeriesPrepared)
+	c.Assert(unitAgents.isStopped(), jc.IsTrue)
+
+	// The agent leaves the upgrade prepared until it finds the machine
+	// running the target series, as it will once the OS has been
+	// upgraded and the agent restarted.
+	c.Assert(worker.Stop(mr), gc.IsNil)
+	current := version.Current
+	current.Series = target
+	s.PatchValue(&version.Current, current)
+	mr = s.makeMachinerWithUnitAgents(unitAgents)
+	defer worker.Stop(mr)
+	s.waitUpgradeSeriesStatus(c, params.UpgradeSeriesUpgraded)
+	c.Assert(unitAgents.isStopped(), jc.IsTrue)
+
+	err = s.machine.CompleteSeriesUpgrade()
+	c.Assert(err, gc.IsNil)
+	timeout := time.After(worstCase)
+	for unitAgents.isStopped() {
+		s.State.StartSync()
+		select {
+		case <-timeout:
+			c.Fatalf("timeout while waiting for unit agents to start")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (s *MachinerSuite) TestUpgradeSeriesStartsUnitAgentsAfterRestart(c *gc.C) {
+	target := "trusty"
+	if version.Current.Series == target {
+		target = "precise"
+	}
+	mr := s.makeMachinerWithUnitAgents(&fakeUnitAgents{})
+	defer worker.Stop(mr)
+	err := s.machine.PrepareSeriesUpgrade(target)
+	c.Assert(err, gc.IsNil)
+	s.waitUpgradeSeriesStatus(c, params.UpgradeSeriesPrepared)
+	err = s.machine.SetUpgradeSeriesStatus(params.UpgradeSeriesUpgraded)
+	c.Assert(err, gc.IsNil)
+	c.Assert(worker.Stop(mr), gc.IsNil)
+
+	// The upgrade is completed while the agent is down; the new
+	// agent remembers that the unit agents were stopped and starts
+	// them again.
+	err = s.machine.CompleteSeriesUpgrade()
+	c.Assert(err, gc.IsNil)
+	unitAgents := &fakeUnitAgents{stopped: true}
+	mr = s.makeMachinerWithUnitAgents(unitAgents)
+	defer worker.Stop(mr)
+	timeout := time.After(worstCase)
+	for unitAgents.isStopped() {
+		s.State.StartSync()
+		select {
+		case <-timeout:
+			c.Fatalf("timeout while waiting for unit agents to start")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package notifier
+
+var (
+	PresenceInterval = &presenceInterval
+	SendAttempt      = &sendAttempt
+	SendTimeout      = &sendTimeout
+)
@@ -0,0 +1,349 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/juju/loggo"
+	"github.com/juju/names"
+	"github.com/juju/utils"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/environs/config"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/state/multiwatcher"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.notifier")
+
+// The kinds of event that may be sent.
+const (
+	UnitError      = "unit-error"
+	MachineDown    = "machine-down"
+	ServiceRemoved = "service-removed"
+)
+
+// SignatureHeader holds the name of the HTTP header that carries the
+// hex-encoded HMAC-SHA256 of a notification's body, when the
+// environment has a notification-secret.
+const SignatureHeader = "X-Juju-Signature"
+
+// presenceInterval sets how often the agents of started machines are
+// checked for liveness. Agent presence is not reported through the
+// all-watcher, so it must be polled.
+var presenceInterval = time.Minute
+
+// sendAttempt governs the retrying of notifications that could not be
+// delivered.
+var sendAttempt = utils.AttemptStrategy{
+	Total: time.Minute,
+	Delay: 10 * time.Second,
+}
+
+// sendTimeout bounds each attempt to deliver a notification, so that
+// an unresponsive webhook cannot hold up those that follow.
+var sendTimeout = 30 * time.Second
+
+// queueSize holds the number of notifications that may wait to be
+// sent; any more are dropped.
+const queueSize = 100
+
+// notification holds an event waiting to be sent, and the
+// configuration in force when it happened.
+type notification struct {
+	cfg   *config.Config
+	event Event
+}
+
+// Event holds the body of a notification.
+type Event struct {
+	Kind        string    `json:"kind"`
+	Environment string    `json:"environment"`
+	Entity      string    `json:"entity"`
+	Info        string    `json:"info,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Notifier watches the environment for the events selected in its
+// configuration, and posts each one to the configured webhook.
+type Notifier struct {
+	tomb tomb.Tomb
+	st   *state.State
+
+	// unitStatus holds the last seen status of each unit.
+	unitStatus map[string]params.Status
+
+	// machines holds the ids of the started machines, and whether
+	// each has already been reported as down.
+	machines map[string]bool
+}
+
+// NewNotifier returns a worker that sends notifications of events in
+// the environment to the webhook named by its notification-url setting.
+// Nothing is sent while notification-url is unset.
+func NewNotifier(st *state.State) worker.Worker {
+	n := &Notifier{
+		st:         st,
+		unitStatus: make(map[string]params.Status),
+		machines:   make(map[string]bool),
+	}
+	go func() {
+		defer n.tomb.Done()
+		n.tomb.Kill(n.loop())
+	}()
+	return n
+}
+
+func (n *Notifier) String() string {
+	return "notifier"
+}
+
+func (n *Notifier) Kill() {
+	n.tomb.Kill(nil)
+}
+
+func (n *Notifier) Stop() error {
+	n.tomb.Kill(nil)
+	return n.tomb.Wait()
+}
+
+func (n *Notifier) Wait() error {
+	return n.tomb.Wait()
+}
+
+func (n *Notifier) loop() error {
+	w := n.st.Watch()
+	defer w.Stop()
+	changes := make(chan []params.Delta)
+	go func() {
+		for {
+			deltas, err := w.Next()
+			if err == multiwatcher.ErrWatcherStopped {
+				return
+			} else if err != nil {
+				n.tomb.Kill(err)
+				return
+			}
+			select {
+			case changes <- deltas:
+			case <-n.tomb.Dying():
+				return
+			}
+		}
+	}()
+
+	// Notifications are sent by a separate goroutine, so that slow
+	// deliveries do not hold up watching the environment.
+	queue := make(chan notification, queueSize)
+	stop := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		sendQueued(queue, stop)
+	}()
+	defer func() {
+		close(stop)
+		<-sent
+	}()
+
+	// The first deltas describe the environment as it is now; only
+	// later changes are worth telling anyone about.
+	initial := true
+	presenceTimer := time.After(presenceInterval)
+	for {
+		var events []Event
+		select {
+		case <-n.tomb.Dying():
+			return tomb.ErrDying
+		case deltas := <-changes:
+			events = n.handleDeltas(deltas)
+			if initial {
+				events, initial = nil, false
+			}
+		case <-presenceTimer:
+			presenceTimer = time.After(presenceInterval)
+			var err error
+			if events, err = n.checkPresence(); err != nil {
+				return err
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+		cfg, err := n.st.EnvironConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.NotificationURL() == "" {
+			continue
+		}
+		wanted := make(map[string]bool)
+		for _, kind := range cfg.NotificationEvents() {
+			wanted[kind] = true
+		}
+		for _, event := range events {
+			if !wanted[event.Kind] {
+				continue
+			}
+			event.Environment = n.st.EnvironTag().Id()
+			select {
+			case queue <- notification{cfg, event}:
+			default:
+				logger.Errorf("dropped %s notification for %q: too many notifications waiting", event.Kind, event.Entity)
+			}
+		}
+	}
+}
+
+// sendQueued sends the notifications received on queue until stop is
+// closed.
+func sendQueued(queue <-chan notification, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case item := <-queue:
+			if err := send(item.cfg, item.event, stop); err != nil {
+				logger.Errorf("cannot send %s notification for %q: %v", item.event.Kind, item.event.Entity, err)
+			}
+		}
+	}
+}
+
+// handleDeltas records the changes described by the given deltas, and
+// returns the events they represent.
+func (n *Notifier) handleDeltas(deltas []params.Delta) []Event {
+	var events []Event
+	now := time.Now()
+	for _, delta := range deltas {
+		switch info := delta.Entity.(type) {
+		case *params.UnitInfo:
+			if delta.Removed {
+				delete(n.unitStatus, info.Name)
+				continue
+			}
+			previous := n.unitStatus[info.Name]
+			n.unitStatus[info.Name] = info.Status
+			if info.Status == params.StatusError && previous != params.StatusError {
+				events = append(events, Event{
+					Kind:   UnitError,
+					Entity: names.NewUnitTag(info.Name).String(),
+					Info:   info.StatusInfo,
+					Time:   now,
+				})
+			}
+		case *params.MachineInfo:
+			if delta.Removed || info.Life != params.Alive || info.Status != params.StatusStarted {
+				delete(n.machines, info.Id)
+			} else if _, ok := n.machines[info.Id]; !ok {
+				n.machines[info.Id] = false
+			}
+		case *params.ServiceInfo:
+			if delta.Removed {
+				events = append(events, Event{
+					Kind:   ServiceRemoved,
+					Entity: names.NewServiceTag(info.Name).String(),
+					Time:   now,
+				})
+			}
+		}
+	}
+	return events
+}
+
+// checkPresence returns an event for each started machine whose agent
+// has stopped responding since it was last checked.
+func (n *Notifier) checkPresence() ([]Event, error) {
+	var events []Event
+	for id, down := range n.machines {
+		machine, err := n.st.Machine(id)
+		if errors.IsNotFound(err) {
+			delete(n.machines, id)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		alive, err := machine.AgentPresence()
+		if err != nil {
+			return nil, err
+		}
+		n.machines[id] = !alive
+		if !alive && !down {
+			events = append(events, Event{
+				Kind:   MachineDown,
+				Entity: names.NewMachineTag(id).String(),
+				Info:   "agent is not communicating with the server",
+				Time:   time.Now(),
+			})
+		}
+	}
+	return events, nil
+}
+
+// send posts the event to the configured webhook, retrying until it is
+// accepted, the attempts run out, or stop is closed.
+func send(cfg *config.Config, event Event, stop <-chan struct{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := *utils.GetHTTPClient(cfg.SSLHostnameVerification())
+	client.Timeout = sendTimeout
+	for a := sendAttempt.Start(); a.Next(); {
+		err = post(&client, cfg.NotificationURL(), cfg.NotificationSecret(), body)
+		if err == nil {
+			logger.Debugf("sent %s notification for %q", event.Kind, event.Entity)
+			return nil
+		}
+		select {
+		case <-stop:
+			return err
+		default:
+		}
+		logger.Warningf("%s notification for %q not delivered: %v", event.Kind, event.Entity, err)
+	}
+	return err
+}
+
+// post makes a single attempt to deliver a notification, signing it if
+// secret is not empty.
+func post(client *http.Client, url, secret string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a notification body, as sent in the
+// SignatureHeader, so that receivers can verify that a notification
+// came from the environment.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,237 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package notifier_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	stdtesting "testing"
+	"time"
+
+	"github.com/juju/utils"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/state/api/params"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/notifier"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type NotifierSuite struct {
+	testing.JujuConnSuite
+	server   *httptest.Server
+	received chan received
+	failures int
+	hang     chan struct{}
+}
+
+type received struct {
+	event     notifier.Event
+	signature string
+}
+
+var _ = gc.Suite(&NotifierSuite{})
+
+func (s *NotifierSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(notifier.PresenceInterval, 10*time.Millisecond)
+	s.PatchValue(notifier.SendAttempt, utils.AttemptStrategy{
+		Total: coretesting.LongWait,
+		Delay: time.Millisecond,
+	})
+	s.failures = 0
+	s.hang = nil
+	s.received = make(chan received, 10)
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"notification-url":          s.server.URL,
+		"notification-secret":       "sekrit",
+		"ssl-hostname-verification": false,
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *NotifierSuite) TearDownTest(c *gc.C) {
+	s.server.Close()
+	s.JujuConnSuite.TearDownTest(c)
+}
+
+func (s *NotifierSuite) handle(w http.ResponseWriter, r *http.Request) {
+	if s.hang != nil {
+		<-s.hang
+		return
+	}
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var event notifier.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get(notifier.SignatureHeader) != notifier.Sign("sekrit", body) {
+		http.Error(w, "bad signature", http.StatusForbidden)
+		return
+	}
+	s.received <- received{event, r.Header.Get(notifier.SignatureHeader)}
+}
+
+func (s *NotifierSuite) startNotifier(c *gc.C) func() {
+	w := notifier.NewNotifier(s.State)
+	// Give the notifier time to read the initial state of the
+	// environment, which it does not report.
+	time.Sleep(coretesting.ShortWait)
+	return func() { c.Assert(worker.Stop(w), gc.IsNil) }
+}
+
+func (s *NotifierSuite) assertEvent(c *gc.C, kind, entity, info string) {
+	select {
+	case r := <-s.received:
+		c.Assert(r.event.Kind, gc.Equals, kind)
+		c.Assert(r.event.Entity, gc.Equals, entity)
+		c.Assert(r.event.Info, gc.Equals, info)
+		c.Assert(r.event.Environment, gc.Equals, s.State.EnvironTag().Id())
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for %s notification", kind)
+	}
+}
+
+func (s *NotifierSuite) assertNoEvent(c *gc.C) {
+	s.BackingState.StartSync()
+	select {
+	case r := <-s.received:
+		c.Fatalf("unexpected notification: %#v", r.event)
+	case <-time.After(coretesting.ShortWait):
+	}
+}
+
+func (s *NotifierSuite) TestUnitError(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	defer s.startNotifier(c)()
+
+	err = unit.SetStatus(params.StatusError, "hook failed", nil)
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	s.assertEvent(c, notifier.UnitError, "unit-wordpress-0", "hook failed")
+
+	// Further changes while in error are not reported again.
+	err = unit.SetStatus(params.StatusError, "hook failed again", nil)
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+}
+
+func (s *NotifierSuite) TestExistingErrorNotReported(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetStatus(params.StatusError, "hook failed", nil)
+	c.Assert(err, gc.IsNil)
+	defer s.startNotifier(c)()
+	s.assertNoEvent(c)
+}
+
+func (s *NotifierSuite) TestServiceRemoved(c *gc.C) {
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	defer s.startNotifier(c)()
+
+	err := svc.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	s.assertEvent(c, notifier.ServiceRemoved, "service-wordpress", "")
+}
+
+func (s *NotifierSuite) TestMachineDown(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	pinger, err := m.SetAgentPresence()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	defer s.startNotifier(c)()
+	s.assertNoEvent(c)
+
+	err = pinger.Kill()
+	c.Assert(err, gc.IsNil)
+	s.State.StartSync()
+	s.assertEvent(c, notifier.MachineDown, m.Tag().String(), "agent is not communicating with the server")
+}
+
+func (s *NotifierSuite) TestRetriesFailedDelivery(c *gc.C) {
+	s.failures = 2
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	defer s.startNotifier(c)()
+
+	err := svc.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	s.assertEvent(c, notifier.ServiceRemoved, "service-wordpress", "")
+}
+
+func (s *NotifierSuite) TestUnresponsiveWebhook(c *gc.C) {
+	s.PatchValue(notifier.SendTimeout, 50*time.Millisecond)
+	s.hang = make(chan struct{})
+	defer close(s.hang)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	w := notifier.NewNotifier(s.State)
+	time.Sleep(coretesting.ShortWait)
+
+	err := svc.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+
+	// The notifier stops promptly, although the notification
+	// is still being retried.
+	stopped := make(chan error)
+	go func() { stopped <- worker.Stop(w) }()
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("notifier did not stop")
+	}
+}
+
+func (s *NotifierSuite) TestOnlySelectedEventsSent(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(map[string]interface{}{
+		"notification-events": "unit-error",
+	}, nil, nil)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	defer s.startNotifier(c)()
+
+	err = svc.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+}
+
+func (s *NotifierSuite) TestNothingSentWithoutURL(c *gc.C) {
+	err := s.State.UpdateEnvironConfig(nil, []string{"notification-url"}, nil)
+	c.Assert(err, gc.IsNil)
+	svc := s.AddTestingService(c, "wordpress", s.AddTestingCharm(c, "wordpress"))
+	defer s.startNotifier(c)()
+
+	err = svc.Destroy()
+	c.Assert(err, gc.IsNil)
+	s.assertNoEvent(c)
+}
@@ -219,6 +219,12 @@ func (st *fakeState) setStateServers(ids ...string) {
 	})
 }
 
+func (st *fakeState) setAPIServers(ids ...string) {
+	info := deepCopy(st.stateServers.Get()).(*state.StateServerInfo)
+	info.APIMachineIds = ids
+	st.stateServers.Set(info)
+}
+
 func (st *fakeState) StateServerInfo() (*state.StateServerInfo, error) {
 	if err := errorFor("State.StateServerInfo"); err != nil {
 		return nil, err
@@ -106,6 +106,12 @@ type pgWorker struct {
 	// watches attributes of that machine.
 	machines map[string]*machine
 
+	// apiMachines holds the set of machines we are currently
+	// watching that run only an API server. Their API
+	// addresses are published, but they take no part
+	// in the replica set.
+	apiMachines map[string]*machine
+
 	// publisher holds the implementation of the API
 	// address publisher.
 	publisher publisherInterface
@@ -127,10 +133,11 @@ func New(st *state.State) (worker.Worker, error) {
 
 func newWorker(st stateInterface, pub publisherInterface) worker.Worker {
 	w := &pgWorker{
-		st:        st,
-		notifyCh:  make(chan notifyFunc),
-		machines:  make(map[string]*machine),
-		publisher: pub,
+		st:          st,
+		notifyCh:    make(chan notifyFunc),
+		machines:    make(map[string]*machine),
+		apiMachines: make(map[string]*machine),
+		publisher:   pub,
 	}
 	go func() {
 		defer w.tomb.Done()
@@ -213,19 +220,21 @@ func (w *pgWorker) loop() error {
 }
 
 func (w *pgWorker) apiPublishInfo() ([][]network.HostPort, []instance.Id, error) {
-	servers := make([][]network.HostPort, 0, len(w.machines))
-	instanceIds := make([]instance.Id, 0, len(w.machines))
-	for _, m := range w.machines {
-		if len(m.apiHostPorts) == 0 {
-			continue
-		}
-		instanceId, err := m.stm.InstanceId()
-		if err != nil {
-			return nil, nil, err
+	n := len(w.machines) + len(w.apiMachines)
+	servers := make([][]network.HostPort, 0, n)
+	instanceIds := make([]instance.Id, 0, n)
+	for _, machines := range []map[string]*machine{w.machines, w.apiMachines} {
+		for _, m := range machines {
+			if len(m.apiHostPorts) == 0 {
+				continue
+			}
+			instanceId, err := m.stm.InstanceId()
+			if err != nil {
+				return nil, nil, err
+			}
+			instanceIds = append(instanceIds, instanceId)
+			servers = append(servers, m.apiHostPorts)
 		}
-		instanceIds = append(instanceIds, instanceId)
-		servers = append(servers, m.apiHostPorts)
-
 	}
 	return servers, instanceIds, nil
 }
@@ -404,19 +413,34 @@ func (infow *serverInfoWatcher) updateMachines() (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("cannot get state server info: %v", err)
 	}
+	changed, err := infow.updateMachineSet(infow.worker.machines, info.MachineIds)
+	if err != nil {
+		return false, err
+	}
+	apiChanged, err := infow.updateMachineSet(infow.worker.apiMachines, info.APIMachineIds)
+	if err != nil {
+		return false, err
+	}
+	return changed || apiChanged, nil
+}
+
+// updateMachineSet updates the given set of machines so that
+// it holds exactly the machines with the given ids, and reports
+// whether any were added or removed.
+func (infow *serverInfoWatcher) updateMachineSet(machines map[string]*machine, ids []string) (bool, error) {
 	changed := false
 	// Stop machine goroutines that no longer correspond to state server
 	// machines.
-	for _, m := range infow.worker.machines {
-		if !inStrings(m.id, info.MachineIds) {
+	for _, m := range machines {
+		if !inStrings(m.id, ids) {
 			m.stop()
-			delete(infow.worker.machines, m.id)
+			delete(machines, m.id)
 			changed = true
 		}
 	}
 	// Start machines with no watcher
-	for _, id := range info.MachineIds {
-		if _, ok := infow.worker.machines[id]; ok {
+	for _, id := range ids {
+		if _, ok := machines[id]; ok {
 			continue
 		}
 		logger.Debugf("found new machine %q", id)
@@ -432,7 +456,7 @@ func (infow *serverInfoWatcher) updateMachines() (bool, error) {
 			}
 			return false, fmt.Errorf("cannot get machine %q: %v", id, err)
 		}
-		infow.worker.machines[id] = infow.worker.newMachine(stm)
+		machines[id] = infow.worker.newMachine(stm)
 		changed = true
 	}
 	return changed, nil
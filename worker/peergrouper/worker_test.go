@@ -15,6 +15,7 @@ import (
 	"github.com/juju/juju/instance"
 	"github.com/juju/juju/juju/testing"
 	"github.com/juju/juju/network"
+	"github.com/juju/juju/replicaset"
 	statetesting "github.com/juju/juju/state/testing"
 	coretesting "github.com/juju/juju/testing"
 	"github.com/juju/juju/worker"
@@ -495,6 +496,48 @@ func (s *workerSuite) TestStateServersArePublished(c *gc.C) {
 	})
 }
 
+func (s *workerSuite) TestAPIServersArePublished(c *gc.C) {
+	testForIPv4AndIPv6(func(ipVersion testIPVersion) {
+		publishCh := make(chan [][]network.HostPort)
+		publish := func(apiServers [][]network.HostPort, instanceIds []instance.Id) error {
+			publishCh <- apiServers
+			return nil
+		}
+
+		st := newFakeState()
+		initState(c, st, 3, ipVersion)
+		w := newWorker(st, publisherFunc(publish))
+		defer func() {
+			c.Check(worker.Stop(w), gc.IsNil)
+		}()
+		select {
+		case servers := <-publishCh:
+			assertAPIHostPorts(c, servers, expectedAPIHostPorts(3, ipVersion))
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for publish")
+		}
+
+		// Add a machine that runs only an API server and check
+		// that its addresses are published along with the rest.
+		m := st.addMachine("13", false)
+		m.setInstanceId("id-13")
+		m.setAPIHostPorts(addressesWithPort(apiPort, fmt.Sprintf(ipVersion.formatHost, 13)))
+		st.setAPIServers("13")
+		select {
+		case servers := <-publishCh:
+			assertAPIHostPorts(c, servers, expectedAPIHostPorts(4, ipVersion))
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for publish")
+		}
+
+		// The API server does not join the replica set.
+		members := st.session.members.Get().([]replicaset.Member)
+		for _, member := range members {
+			c.Check(member.Tags[jujuMachineTag], gc.Not(gc.Equals), "13")
+		}
+	})
+}
+
 func (s *workerSuite) TestWorkerRetriesOnPublishError(c *gc.C) {
 	testForIPv4AndIPv6(func(ipVersion testIPVersion) {
 		s.PatchValue(&pollInterval, coretesting.LongWait+time.Second)
@@ -20,3 +20,5 @@ func GetRetryWatcher(p Provisioner) (watcher.NotifyWatcher, error) {
 }
 
 var ContainerManagerConfig = containerManagerConfig
+
+var StartInstanceAttempt = &startInstanceAttempt
@@ -300,6 +300,36 @@ func (s *lxcProvisionerSuite) TestContainerStartedAndStopped(c *gc.C) {
 	s.waitRemoved(c, container)
 }
 
+func (s *lxcProvisionerSuite) TestContainerWithOtherArchNotStarted(c *gc.C) {
+	p := s.newLxcProvisioner(c)
+	defer stop(c, p)
+
+	otherArch := "i386"
+	if version.Current.Arch == otherArch {
+		otherArch = "amd64"
+	}
+	template := state.MachineTemplate{
+		Series:      coretesting.FakeDefaultSeries,
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: constraints.MustParse("arch=" + otherArch),
+	}
+	container, err := s.State.AddMachineInsideMachine(template, "0", instance.LXC)
+	c.Assert(err, gc.IsNil)
+	s.expectNoEvents(c)
+
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		status, info, _, err := container.Status()
+		c.Assert(err, gc.IsNil)
+		if status == params.StatusPending {
+			continue
+		}
+		c.Assert(status, gc.Equals, params.StatusError)
+		c.Assert(info, gc.Equals, "no matching tools available")
+		return
+	}
+	c.Fatalf("container status not set to error")
+}
+
 type fakeAPI struct{}
 
 func (*fakeAPI) ContainerConfig() (params.ContainerConfig, error) {
@@ -42,9 +42,15 @@ type ProvisionerTask interface {
 	SetSafeMode(safeMode bool)
 }
 
+// MachineGetter provides the provisioner task with bulk access to
+// machines, so that an environment with many machines does not cost
+// a call per machine when the provisioner starts.
 type MachineGetter interface {
-	Machine(names.MachineTag) (*apiprovisioner.Machine, error)
+	Machines(...names.MachineTag) ([]apiprovisioner.MachineResult, error)
 	MachinesWithTransientErrors() ([]*apiprovisioner.Machine, []params.StatusResult, error)
+	InstanceIds(...*apiprovisioner.Machine) ([]params.StringResult, error)
+	Statuses(...*apiprovisioner.Machine) ([]params.StatusResult, error)
+	SetStatuses(params.Status, string, ...*apiprovisioner.Machine) ([]params.ErrorResult, error)
 }
 
 var _ MachineGetter = (*apiprovisioner.State)(nil)
@@ -185,15 +191,23 @@ func (task *provisionerTask) processMachinesWithTransientErrors() error {
 		return nil
 	}
 	logger.Tracef("processMachinesWithTransientErrors(%v)", statusResults)
-	var pending []*apiprovisioner.Machine
+	var retrying []*apiprovisioner.Machine
 	for i, status := range statusResults {
 		if status.Error != nil {
 			logger.Errorf("cannot retry provisioning of machine %q: %v", status.Id, status.Error)
 			continue
 		}
-		machine := machines[i]
-		if err := machine.SetStatus(params.StatusPending, "", nil); err != nil {
-			logger.Errorf("cannot reset status of machine %q: %v", status.Id, err)
+		retrying = append(retrying, machines[i])
+	}
+	results, err := task.machineGetter.SetStatuses(params.StatusPending, "", retrying...)
+	if err != nil {
+		logger.Errorf("cannot reset status of machines: %v", err)
+		return nil
+	}
+	var pending []*apiprovisioner.Machine
+	for i, machine := range retrying {
+		if err := results[i].Error; err != nil {
+			logger.Errorf("cannot reset status of machine %q: %v", machine, err)
 			continue
 		}
 		task.machines[machine.Tag().String()] = machine
@@ -276,16 +290,22 @@ func (task *provisionerTask) populateMachineMaps(ids []string) error {
 
 	// Update the machines map with new data for each of the machines in the
 	// change list.
-	// TODO(thumper): update for API server later to get all machines in one go.
-	for _, id := range ids {
-		machineTag := names.NewMachineTag(id)
-		machine, err := task.machineGetter.Machine(machineTag)
-		switch {
+	tags := make([]names.MachineTag, len(ids))
+	for i, id := range ids {
+		tags[i] = names.NewMachineTag(id)
+	}
+	results, err := task.machineGetter.Machines(tags...)
+	if err != nil {
+		return errors.Annotatef(err, "failed to get machines %v", ids)
+	}
+	for i, result := range results {
+		id := ids[i]
+		switch err := result.Err; {
 		case params.IsCodeNotFoundOrCodeUnauthorized(err):
 			logger.Debugf("machine %q not found in state", id)
 			delete(task.machines, id)
 		case err == nil:
-			task.machines[id] = machine
+			task.machines[id] = result.Machine
 		default:
 			return errors.Annotatef(err, "failed to get machine %v", id)
 		}
@@ -296,45 +316,66 @@ func (task *provisionerTask) populateMachineMaps(ids []string) error {
 // pendingOrDead looks up machines with ids and returns those that do not
 // have an instance id assigned yet, and also those that are dead.
 func (task *provisionerTask) pendingOrDead(ids []string) (pending, dead []*apiprovisioner.Machine, err error) {
+	var machines []*apiprovisioner.Machine
 	for _, id := range ids {
 		machine, found := task.machines[id]
 		if !found {
 			logger.Infof("machine %q not found", id)
 			continue
 		}
+		machines = append(machines, machine)
+	}
+	instanceIds, err := task.machineGetter.InstanceIds(machines...)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "failed to load machine instance ids")
+	}
+	var unprovisioned []*apiprovisioner.Machine
+	for i, machine := range machines {
+		instanceId := instanceIds[i]
 		switch machine.Life() {
 		case params.Dying:
-			if _, err := machine.InstanceId(); err == nil {
+			if instanceId.Error == nil {
 				continue
-			} else if !params.IsCodeNotProvisioned(err) {
-				return nil, nil, errors.Annotatef(err, "failed to load machine %q instance id: %v", machine)
+			} else if !params.IsCodeNotProvisioned(instanceId.Error) {
+				return nil, nil, errors.Annotatef(instanceId.Error, "failed to load machine %q instance id", machine)
 			}
 			logger.Infof("killing dying, unprovisioned machine %q", machine)
-			if err := machine.EnsureDead(); err != nil {
-				return nil, nil, errors.Annotatef(err, "failed to ensure machine dead %q: %v", machine)
+			if err := machine.EnsureDead(); params.IsCodeHasAssignedUnits(err) {
+				// The machine was force-destroyed and its units
+				// have not yet been cleaned up; it will be seen
+				// again when it becomes Dead.
+				logger.Infof("machine %q still has units assigned", machine)
+				continue
+			} else if err != nil {
+				return nil, nil, errors.Annotatef(err, "failed to ensure machine dead %q", machine)
 			}
 			fallthrough
 		case params.Dead:
 			dead = append(dead, machine)
 			continue
 		}
-		if instId, err := machine.InstanceId(); err != nil {
+		if err := instanceId.Error; err != nil {
 			if !params.IsCodeNotProvisioned(err) {
 				logger.Errorf("failed to load machine %q instance id: %v", machine, err)
 				continue
 			}
-			status, _, err := machine.Status()
-			if err != nil {
-				logger.Infof("cannot get machine %q status: %v", machine, err)
-				continue
-			}
-			if status == params.StatusPending {
-				pending = append(pending, machine)
-				logger.Infof("found machine %q pending provisioning", machine)
-				continue
-			}
+			unprovisioned = append(unprovisioned, machine)
 		} else {
-			logger.Infof("machine %v already started as instance %q", machine, instId)
+			logger.Infof("machine %v already started as instance %q", machine, instanceId.Result)
+		}
+	}
+	statuses, err := task.machineGetter.Statuses(unprovisioned...)
+	if err != nil {
+		return nil, nil, errors.Annotate(err, "failed to load machine statuses")
+	}
+	for i, machine := range unprovisioned {
+		if err := statuses[i].Error; err != nil {
+			logger.Infof("cannot get machine %q status: %v", machine, err)
+			continue
+		}
+		if statuses[i].Status == params.StatusPending {
+			pending = append(pending, machine)
+			logger.Infof("found machine %q pending provisioning", machine)
 		}
 	}
 	logger.Tracef("pending machines: %v", pending)
@@ -350,11 +391,18 @@ func (task *provisionerTask) findUnknownInstances(stopping []instance.Instance)
 		instances[k] = v
 	}
 
+	machines := make([]*apiprovisioner.Machine, 0, len(task.machines))
 	for _, m := range task.machines {
-		instId, err := m.InstanceId()
-		switch {
+		machines = append(machines, m)
+	}
+	results, err := task.machineGetter.InstanceIds(machines...)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		switch err := result.Error; {
 		case err == nil:
-			delete(instances, instId)
+			delete(instances, instance.Id(result.Result))
 		case params.IsCodeNotProvisioned(err):
 		case params.IsCodeNotFoundOrCodeUnauthorized(err):
 		default:
@@ -462,13 +510,19 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 	if err != nil {
 		return task.setErrorStatus("cannot find tools for machine %q: %v", machine, err)
 	}
-	inst, metadata, networkInfo, err := task.broker.StartInstance(environs.StartInstanceParams{
+	inst, metadata, networkInfo, err := task.startInstance(machine, environs.StartInstanceParams{
 		Constraints:       provisioningInfo.Constraints,
 		Tools:             possibleTools,
 		MachineConfig:     provisioningInfo.MachineConfig,
 		Placement:         provisioningInfo.Placement,
 		DistributionGroup: machine.DistributionGroup,
+		// The nonce is unique to this attempt to provision the
+		// machine, so it serves to identify the request.
+		IdempotencyToken: provisioningInfo.MachineConfig.MachineNonce,
 	})
+	if err == tomb.ErrDying {
+		return err
+	}
 	if err != nil {
 		// Set the state to error, so the machine will be skipped next
 		// time until the error is resolved, but don't return an
@@ -494,12 +548,56 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 	return nil
 }
 
+// startInstanceAttempt governs how StartInstance is retried for
+// brokers that honour idempotency tokens.
+var startInstanceAttempt = utils.AttemptStrategy{
+	Total: 2 * time.Minute,
+	Delay: 10 * time.Second,
+}
+
+// startInstance asks the broker to start an instance for the given
+// machine. A failed StartInstance may still have started the instance,
+// so it is only retried if the broker honours idempotency tokens; the
+// same args, and hence the same machine config, are used each time.
+// It returns tomb.ErrDying if the task is stopped while waiting to
+// retry.
+func (task *provisionerTask) startInstance(machine *apiprovisioner.Machine, args environs.StartInstanceParams) (
+	inst instance.Instance, hc *instance.HardwareCharacteristics, networkInfo []network.Info, err error,
+) {
+	retry := false
+	if starter, ok := task.broker.(environs.IdempotentStarter); ok && starter.StartInstanceIsIdempotent() {
+		retry = true
+	}
+	deadline := time.Now().Add(startInstanceAttempt.Total)
+	for attempts := 1; ; attempts++ {
+		inst, hc, networkInfo, err = task.broker.StartInstance(args)
+		if err == nil || !retry {
+			return inst, hc, networkInfo, err
+		}
+		if attempts >= startInstanceAttempt.Min && time.Now().Add(startInstanceAttempt.Delay).After(deadline) {
+			return inst, hc, networkInfo, err
+		}
+		logger.Warningf("cannot start instance for machine %q, retrying: %v", machine, err)
+		select {
+		case <-task.tomb.Dying():
+			return nil, nil, nil, tomb.ErrDying
+		case <-time.After(startInstanceAttempt.Delay):
+		}
+	}
+}
+
 func (task *provisionerTask) possibleTools(series string, cons constraints.Value) (coretools.List, error) {
 	if env, ok := task.broker.(environs.Environ); ok {
 		return tools.FindInstanceTools(env, version.Current.Number, series, cons.Arch)
 	}
 	if hasTools, ok := task.broker.(coretools.HasTools); ok {
-		return hasTools.Tools(series), nil
+		possibleTools := hasTools.Tools(series)
+		if cons.Arch != nil {
+			// Containers run the tools of their host, so they
+			// cannot satisfy constraints on other architectures.
+			return possibleTools.Match(coretools.Filter{Arch: *cons.Arch})
+		}
+		return possibleTools, nil
 	}
 	panic(fmt.Errorf("broker of type %T does not provide any tools", task.broker))
 }
@@ -75,6 +75,13 @@ func (s *CommonProvisionerSuite) SetUpTest(c *gc.C) {
 
 	s.JujuConnSuite.SetUpTest(c)
 
+	// Retry failed StartInstance calls without delaying tests
+	// that break the dummy provider.
+	s.PatchValue(provisioner.StartInstanceAttempt, utils.AttemptStrategy{
+		Min:   2,
+		Delay: coretesting.ShortWait,
+	})
+
 	// Create the operations channel with more than enough space
 	// for those tests that don't listen on it.
 	op := make(chan dummy.Operation, 500)
@@ -203,6 +210,7 @@ func (s *CommonProvisionerSuite) checkStartInstanceCustom(
 				c.Assert(nonceParts[0], gc.Equals, names.NewMachineTag("0").String())
 				c.Assert(nonceParts[1], jc.Satisfies, utils.IsValidUUIDString)
 				c.Assert(o.Secret, gc.Equals, secret)
+				c.Assert(o.APIInfo.EnvironTag, gc.Equals, s.State.EnvironTag())
 				c.Assert(o.Networks, jc.DeepEquals, networks)
 				c.Assert(o.NetworkInfo, jc.DeepEquals, networkInfo)
 
@@ -508,6 +516,52 @@ func (s *ProvisionerSuite) TestProvisionerSetsErrorStatusWhenNoToolsAreAvailable
 	s.checkNoOperations(c)
 }
 
+func (s *ProvisionerSuite) TestProvisionerRetriesStartInstanceWithToken(c *gc.C) {
+	// The first StartInstance starts the instance but
+	// reports failure, as when the request times out.
+	dummy.InjectFailure("StartInstance.Started", errors.New("request timed out"), 1)
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	inst := s.checkStartInstance(c, m)
+
+	// The retry returns the instance already started,
+	// rather than starting another.
+	insts, err := s.Environ.AllInstances()
+	c.Assert(err, gc.IsNil)
+	var ids []instance.Id
+	for _, i := range insts {
+		ids = append(ids, i.Id())
+	}
+	c.Assert(ids, jc.SameContents, []instance.Id{dummy.BootstrapInstanceId, inst.Id()})
+	s.checkNoOperations(c)
+}
+
+func (s *ProvisionerSuite) TestProvisionerStopsWhileRetryingStartInstance(c *gc.C) {
+	s.PatchValue(provisioner.StartInstanceAttempt, utils.AttemptStrategy{
+		Total: time.Hour,
+		Delay: time.Hour,
+	})
+	dummy.InjectFailure("StartInstance", errors.New("request timed out"), 1)
+	p := s.newEnvironProvisioner(c)
+	_, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	s.BackingState.StartSync()
+	time.Sleep(coretesting.ShortWait)
+
+	// The provisioner does not wait out the retry delay.
+	stopped := make(chan error)
+	go func() { stopped <- p.Stop() }()
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("provisioner did not stop")
+	}
+}
+
 func (s *ProvisionerSuite) TestProvisionerSetsErrorStatusWhenStartInstanceFailed(c *gc.C) {
 	brokenMsg := breakDummyProvider(c, s.State, "StartInstance")
 	p := s.newEnvironProvisioner(c)
@@ -894,7 +948,7 @@ func (s *ProvisionerSuite) TestProvisioningSafeMode(c *gc.C) {
 
 type mockMachineGetter struct{}
 
-func (*mockMachineGetter) Machine(names.MachineTag) (*apiprovisioner.Machine, error) {
+func (*mockMachineGetter) Machines(...names.MachineTag) ([]apiprovisioner.MachineResult, error) {
 	return nil, fmt.Errorf("error")
 }
 
@@ -902,6 +956,18 @@ func (*mockMachineGetter) MachinesWithTransientErrors() ([]*apiprovisioner.Machi
 	return nil, nil, fmt.Errorf("error")
 }
 
+func (*mockMachineGetter) InstanceIds(...*apiprovisioner.Machine) ([]params.StringResult, error) {
+	return nil, fmt.Errorf("error")
+}
+
+func (*mockMachineGetter) Statuses(...*apiprovisioner.Machine) ([]params.StatusResult, error) {
+	return nil, fmt.Errorf("error")
+}
+
+func (*mockMachineGetter) SetStatuses(params.Status, string, ...*apiprovisioner.Machine) ([]params.ErrorResult, error) {
+	return nil, fmt.Errorf("error")
+}
+
 func (s *ProvisionerSuite) TestMachineErrorsRetainInstances(c *gc.C) {
 	task := s.newProvisionerTask(c, false, s.Environ, s.provisioner)
 	defer stop(c, task)
@@ -3,14 +3,4 @@
 
 package resumer
 
-import (
-	"time"
-)
-
-func SetInterval(i time.Duration) {
-	interval = i
-}
-
-func RestoreInterval() {
-	interval = defaultInterval
-}
+const DefaultInterval = defaultInterval
@@ -9,6 +9,8 @@ import (
 
 	"github.com/juju/loggo"
 	"launchpad.net/tomb"
+
+	"github.com/juju/juju/utils/clock"
 )
 
 var logger = loggo.GetLogger("juju.worker.resumer")
@@ -28,13 +30,15 @@ type TransactionResumer interface {
 
 // Resumer is responsible for a periodical resuming of pending transactions.
 type Resumer struct {
-	tomb tomb.Tomb
-	tr   TransactionResumer
+	tomb  tomb.Tomb
+	tr    TransactionResumer
+	clock clock.Clock
 }
 
-// NewResumer periodically resumes pending transactions.
-func NewResumer(tr TransactionResumer) *Resumer {
-	rr := &Resumer{tr: tr}
+// NewResumer periodically resumes pending transactions,
+// measuring the interval between them with the given clock.
+func NewResumer(tr TransactionResumer, clock clock.Clock) *Resumer {
+	rr := &Resumer{tr: tr, clock: clock}
 	go func() {
 		defer rr.tomb.Done()
 		rr.tomb.Kill(rr.loop())
@@ -64,7 +68,7 @@ func (rr *Resumer) loop() error {
 		select {
 		case <-rr.tomb.Dying():
 			return tomb.ErrDying
-		case <-time.After(interval):
+		case <-rr.clock.After(interval):
 			if err := rr.tr.ResumeTransactions(); err != nil {
 				logger.Errorf("cannot resume transactions: %v", err)
 			}
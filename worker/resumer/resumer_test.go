@@ -28,36 +28,44 @@ var _ = gc.Suite(&ResumerSuite{})
 func (s *ResumerSuite) TestRunStopWithState(c *gc.C) {
 	// Test with state ensures that state fulfills the
 	// TransactionResumer interface.
-	rr := resumer.NewResumer(s.State)
+	rr := resumer.NewResumer(s.State, coretesting.NewClock(time.Now()))
 
 	c.Assert(rr.Stop(), gc.IsNil)
 }
 
 func (s *ResumerSuite) TestResumerCalls(c *gc.C) {
-	// Shorter interval and mock help to count
-	// the resumer calls in a given timespan.
-	testInterval := 10 * time.Millisecond
-	resumer.SetInterval(testInterval)
-	defer resumer.RestoreInterval()
-
+	// A mock clock and mock resumer let us count
+	// the resumer calls as time is advanced.
+	clock := coretesting.NewClock(time.Now())
 	var tr transactionResumerMock
-	rr := resumer.NewResumer(&tr)
+	tr.clock = clock
+	rr := resumer.NewResumer(&tr, clock)
 	defer func() { c.Assert(rr.Stop(), gc.IsNil) }()
 
-	time.Sleep(10 * testInterval)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-clock.Alarms():
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("resumer did not wait (attempt %d)", i)
+		}
+		clock.Advance(resumer.DefaultInterval)
+	}
+	// Wait for the resumer to start waiting again, so
+	// that all the calls are known to have happened.
+	select {
+	case <-clock.Alarms():
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("resumer did not wait after last call")
+	}
 
-	// Check that a number of calls has happened with a time
-	// difference somewhere between the interval and twice the
-	// interval. A more precise time behavior cannot be
-	// specified due to the load during the test.
+	// Check that the calls happened exactly one
+	// interval apart.
 	tr.mu.Lock()
 	defer tr.mu.Unlock()
-	c.Assert(len(tr.timestamps) > 0, gc.Equals, true)
+	c.Assert(tr.timestamps, gc.HasLen, 3)
 	for i := 1; i < len(tr.timestamps); i++ {
 		diff := tr.timestamps[i].Sub(tr.timestamps[i-1])
-
-		c.Assert(diff >= testInterval, gc.Equals, true)
-		c.Assert(diff <= 4*testInterval, gc.Equals, true)
+		c.Assert(diff, gc.Equals, resumer.DefaultInterval)
 	}
 }
 
@@ -65,12 +73,13 @@ func (s *ResumerSuite) TestResumerCalls(c *gc.C) {
 // calls of ResumeTransactions().
 type transactionResumerMock struct {
 	mu         sync.Mutex
+	clock      *coretesting.Clock
 	timestamps []time.Time
 }
 
 func (tr *transactionResumerMock) ResumeTransactions() error {
 	tr.mu.Lock()
-	tr.timestamps = append(tr.timestamps, time.Now())
+	tr.timestamps = append(tr.timestamps, tr.clock.Now())
 	tr.mu.Unlock()
 	return nil
 }
@@ -0,0 +1,143 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/juju/loggo"
+	"launchpad.net/tomb"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/utils/clock"
+	"github.com/juju/juju/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.scheduler")
+
+// checkInterval sets how often the scheduler looks for tasks that are
+// due to run.
+var checkInterval = time.Minute
+
+// Task describes a job that the scheduler runs periodically.
+type Task struct {
+	// Name identifies the task's definition in state.
+	Name string
+
+	// Interval holds the time to wait between runs of the task. It
+	// is only used when the task's definition is first added to
+	// state.
+	Interval time.Duration
+
+	// Run performs the task. The stop channel is closed when the
+	// scheduler is asked to stop; a long-running task should check
+	// it between steps, and return early once it is closed.
+	Run func(stop <-chan struct{}) error
+}
+
+// Scheduler runs tasks periodically, according to their definitions in
+// state, and records the outcome of each run.
+type Scheduler struct {
+	tomb  tomb.Tomb
+	st    *state.State
+	clock clock.Clock
+	tasks []Task
+}
+
+// NewScheduler returns a worker that runs each of the given tasks
+// whenever it is enabled and its interval has passed since it last
+// ran, measuring time with the given clock.
+func NewScheduler(st *state.State, clock clock.Clock, tasks []Task) worker.Worker {
+	s := &Scheduler{
+		st:    st,
+		clock: clock,
+		tasks: tasks,
+	}
+	go func() {
+		defer s.tomb.Done()
+		s.tomb.Kill(s.loop())
+	}()
+	return s
+}
+
+func (s *Scheduler) String() string {
+	return "scheduler"
+}
+
+func (s *Scheduler) Kill() {
+	s.tomb.Kill(nil)
+}
+
+func (s *Scheduler) Stop() error {
+	s.tomb.Kill(nil)
+	return s.tomb.Wait()
+}
+
+func (s *Scheduler) Wait() error {
+	return s.tomb.Wait()
+}
+
+func (s *Scheduler) loop() error {
+	definitions := make([]*state.ScheduledTask, len(s.tasks))
+	for i, task := range s.tasks {
+		definition, err := s.st.EnsureScheduledTask(task.Name, task.Interval)
+		if err != nil {
+			return err
+		}
+		definitions[i] = definition
+	}
+	for {
+		for i, task := range s.tasks {
+			if err := s.runIfDue(task, definitions[i]); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-s.tomb.Dying():
+			return tomb.ErrDying
+		case <-s.clock.After(checkInterval):
+		}
+	}
+}
+
+// runIfDue runs the task if its definition says it is enabled and due,
+// and records the outcome.
+func (s *Scheduler) runIfDue(task Task, definition *state.ScheduledTask) error {
+	if err := definition.Refresh(); err != nil {
+		return err
+	}
+	if !definition.Enabled() {
+		return nil
+	}
+	now := s.clock.Now()
+	lastRun := definition.LastRun()
+	if !lastRun.IsZero() && now.Sub(lastRun) < definition.Interval() {
+		return nil
+	}
+	logger.Debugf("running scheduled task %q", task.Name)
+	// Run the task in its own goroutine, so that the scheduler can
+	// tell it to stop when the scheduler is killed. The scheduler
+	// still waits for the task to return, so that it never uses the
+	// state after the scheduler has stopped; the outcome of a run
+	// stopped this way is not recorded.
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- task.Run(stop)
+	}()
+	var runErr error
+	select {
+	case <-s.tomb.Dying():
+		close(stop)
+		if err := <-done; err != nil {
+			logger.Debugf("scheduled task %q stopped: %v", task.Name, err)
+		}
+		return tomb.ErrDying
+	case runErr = <-done:
+	}
+	if runErr != nil {
+		logger.Errorf("scheduled task %q failed: %v", task.Name, runErr)
+	}
+	return definition.SetLastRun(now, runErr)
+}
@@ -0,0 +1,162 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scheduler_test
+
+import (
+	"fmt"
+	stdtesting "testing"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker"
+	"github.com/juju/juju/worker/scheduler"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type SchedulerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&SchedulerSuite{})
+
+func (s *SchedulerSuite) TestRunsTasksWhenDue(c *gc.C) {
+	clock := coretesting.NewClock(time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC))
+	runs := make(chan string, 10)
+	tasks := []scheduler.Task{{
+		Name:     "hourly",
+		Interval: time.Hour,
+		Run: func(<-chan struct{}) error {
+			runs <- "hourly"
+			return nil
+		},
+	}, {
+		Name:     "failing",
+		Interval: 2 * time.Hour,
+		Run: func(<-chan struct{}) error {
+			runs <- "failing"
+			return fmt.Errorf("boom")
+		},
+	}}
+	w := scheduler.NewScheduler(s.State, clock, tasks)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	// Tasks that have never run are run at once.
+	waitAlarm(c, clock)
+	assertRuns(c, runs, "hourly", "failing")
+	task, err := s.State.ScheduledTask("failing")
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.LastRun().Equal(clock.Now()), jc.IsTrue)
+	c.Assert(task.LastError(), gc.Equals, "boom")
+
+	// Only tasks whose interval has passed are run again.
+	clock.Advance(time.Hour)
+	waitAlarm(c, clock)
+	assertRuns(c, runs, "hourly")
+	clock.Advance(time.Hour)
+	waitAlarm(c, clock)
+	assertRuns(c, runs, "hourly", "failing")
+}
+
+func (s *SchedulerSuite) TestDisabledTaskNotRun(c *gc.C) {
+	task, err := s.State.EnsureScheduledTask("disabled", time.Hour)
+	c.Assert(err, gc.IsNil)
+	err = task.SetEnabled(false)
+	c.Assert(err, gc.IsNil)
+
+	clock := coretesting.NewClock(time.Now())
+	runs := make(chan string, 10)
+	tasks := []scheduler.Task{{
+		Name:     "disabled",
+		Interval: time.Hour,
+		Run: func(<-chan struct{}) error {
+			runs <- "disabled"
+			return nil
+		},
+	}}
+	w := scheduler.NewScheduler(s.State, clock, tasks)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	waitAlarm(c, clock)
+	assertRuns(c, runs)
+
+	// Enabling the task causes it to be run on the next check.
+	err = task.SetEnabled(true)
+	c.Assert(err, gc.IsNil)
+	clock.Advance(*scheduler.CheckInterval)
+	waitAlarm(c, clock)
+	assertRuns(c, runs, "disabled")
+}
+
+func (s *SchedulerSuite) TestStopWhileTaskRunning(c *gc.C) {
+	clock := coretesting.NewClock(time.Now())
+	started := make(chan struct{})
+	returned := make(chan struct{})
+	tasks := []scheduler.Task{{
+		Name:     "blocking",
+		Interval: time.Hour,
+		Run: func(stop <-chan struct{}) error {
+			defer close(returned)
+			close(started)
+			<-stop
+			return fmt.Errorf("stopped")
+		},
+	}}
+	w := scheduler.NewScheduler(s.State, clock, tasks)
+	select {
+	case <-started:
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("task was not run")
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- worker.Stop(w)
+	}()
+	select {
+	case err := <-stopped:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("scheduler did not stop while its task was running")
+	}
+
+	// The scheduler waited for the task to return,
+	// and the stopped run is not recorded.
+	select {
+	case <-returned:
+	default:
+		c.Fatalf("scheduler stopped before its task returned")
+	}
+	task, err := s.State.ScheduledTask("blocking")
+	c.Assert(err, gc.IsNil)
+	c.Assert(task.LastRun().IsZero(), jc.IsTrue)
+}
+
+func waitAlarm(c *gc.C, clock *coretesting.Clock) {
+	select {
+	case <-clock.Alarms():
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("scheduler did not wait on the clock")
+	}
+}
+
+func assertRuns(c *gc.C, runs <-chan string, expect ...string) {
+	var got []string
+	for {
+		select {
+		case name := <-runs:
+			got = append(got, name)
+			continue
+		default:
+		}
+		break
+	}
+	c.Assert(got, gc.DeepEquals, expect)
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package txnpruner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/loggo"
+
+	"github.com/juju/juju/state"
+	"github.com/juju/juju/worker/scheduler"
+)
+
+var logger = loggo.GetLogger("juju.worker.txnpruner")
+
+const (
+	// defaultInterval is how often the pruning task is run.
+	defaultInterval = time.Hour
+
+	// defaultRetention is the standard value for the retention setting.
+	defaultRetention = 24 * time.Hour
+)
+
+// retention sets how long completed transactions are kept
+// before they become eligible for pruning.
+var retention = defaultRetention
+
+// TransactionPruner defines the interface for types capable of
+// pruning completed transactions.
+type TransactionPruner interface {
+	// PruneTransactions removes completed transactions older
+	// than the given retention window, returning early if stop
+	// is closed.
+	PruneTransactions(retention time.Duration, stop <-chan struct{}) (state.PruneResult, error)
+}
+
+// NewTask returns a task that prunes completed transactions, to be
+// run by the scheduler worker.
+func NewTask(tp TransactionPruner) scheduler.Task {
+	return scheduler.Task{
+		Name:     "prune-transactions",
+		Interval: defaultInterval,
+		Run: func(stop <-chan struct{}) error {
+			result, err := tp.PruneTransactions(retention, stop)
+			if err != nil {
+				return fmt.Errorf("cannot prune transactions: %v", err)
+			}
+			logger.Infof("pruned %d transactions (%d retained)", result.Pruned, result.Retained)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package txnpruner_test
+
+import (
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/juju/testing"
+	"github.com/juju/juju/state"
+	coretesting "github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/txnpruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PrunerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+func (s *PrunerSuite) TestStateIsTransactionPruner(c *gc.C) {
+	var tp txnpruner.TransactionPruner = s.State
+	result, err := tp.PruneTransactions(txnpruner.DefaultRetention, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Pruned, gc.Equals, 0)
+}
+
+func (s *PrunerSuite) TestTask(c *gc.C) {
+	tp := &transactionPrunerMock{
+		result: state.PruneResult{Pruned: 3, Retained: 1},
+	}
+	task := txnpruner.NewTask(tp)
+	c.Assert(task.Name, gc.Equals, "prune-transactions")
+	c.Assert(task.Interval, gc.Equals, txnpruner.DefaultInterval)
+
+	err := task.Run(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(tp.retentions, gc.DeepEquals, []time.Duration{txnpruner.DefaultRetention})
+}
+
+// transactionPrunerMock is used to check the
+// calls of PruneTransactions().
+type transactionPrunerMock struct {
+	mu         sync.Mutex
+	result     state.PruneResult
+	retentions []time.Duration
+}
+
+func (tp *transactionPrunerMock) PruneTransactions(retention time.Duration, stop <-chan struct{}) (state.PruneResult, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	tp.retentions = append(tp.retentions, retention)
+	return tp.result, nil
+}
@@ -18,34 +18,65 @@ import (
 // BundlesDir is responsible for storing and retrieving charm bundles
 // identified by state charms.
 type BundlesDir struct {
-	path string
+	path   string
+	cache  *ArchiveCache
+	caCert string
 }
 
 // NewBundlesDir returns a new BundlesDir which uses path for storage.
-func NewBundlesDir(path string) *BundlesDir {
-	return &BundlesDir{path}
+// If cache is not nil, bundles are taken from it when possible, and
+// downloaded bundles are added to it. Bundles served by an API server
+// are downloaded only if its certificate is signed by caCert.
+func NewBundlesDir(path string, cache *ArchiveCache, caCert string) *BundlesDir {
+	return &BundlesDir{path, cache, caCert}
 }
 
 // Read returns a charm bundle from the directory. If no bundle exists yet,
-// one will be downloaded and validated and copied into the directory before
-// being returned. Downloads will be aborted if a value is received on abort.
+// one will be taken from the cache or downloaded and validated and copied
+// into the directory before being returned. Downloads will be aborted if
+// a value is received on abort.
 func (d *BundlesDir) Read(info BundleInfo, abort <-chan struct{}) (Bundle, error) {
 	path := d.bundlePath(info)
 	if _, err := os.Stat(path); err != nil {
 		if !os.IsNotExist(err) {
 			return nil, err
-		} else if err = d.download(info, abort); err != nil {
+		} else if err = d.fetch(info, abort); err != nil {
 			return nil, err
 		}
 	}
 	return charm.ReadBundle(path)
 }
 
+// fetch copies the supplied charm into the directory, from the cache if
+// it is held there and otherwise by downloading it. Cache failures are
+// logged but do not prevent the charm from being downloaded.
+func (d *BundlesDir) fetch(info BundleInfo, abort <-chan struct{}) error {
+	if d.cache == nil {
+		return d.download(info, abort)
+	}
+	if err := os.MkdirAll(d.path, 0755); err != nil {
+		return err
+	}
+	found, err := d.cache.Get(info, d.bundlePath(info))
+	if err != nil {
+		logger.Warningf("cannot get charm %q from cache: %v", info.URL(), err)
+	} else if found {
+		return nil
+	}
+	if err := d.download(info, abort); err != nil {
+		return err
+	}
+	if err := d.cache.Put(info, d.bundlePath(info)); err != nil {
+		logger.Warningf("cannot add charm %q to cache: %v", info.URL(), err)
+	}
+	return nil
+}
+
 // download fetches the supplied charm and checks that it has the correct sha256
 // hash, then copies it into the directory. If a value is received on abort, the
 // download will be stopped.
 func (d *BundlesDir) download(info BundleInfo, abort <-chan struct{}) (err error) {
-	archiveURL, disableSSLHostnameVerification, err := info.ArchiveURL()
+	archiveURL, verification, err := info.ArchiveURL()
 	if err != nil {
 		return err
 	}
@@ -56,10 +87,14 @@ func (d *BundlesDir) download(info BundleInfo, abort <-chan struct{}) (err error
 	}
 	aurl := archiveURL.String()
 	logger.Infof("downloading %s from %s", info.URL(), aurl)
-	if disableSSLHostnameVerification {
+	if !verification.FromAPIServer && !verification.HostnameVerification {
 		logger.Infof("SSL hostname verification disabled")
 	}
-	dl := downloader.New(aurl, dir, disableSSLHostnameVerification)
+	client, err := verification.HTTPClient(d.caCert)
+	if err != nil {
+		return err
+	}
+	dl := downloader.NewWithClient(aurl, dir, client)
 	defer dl.Stop()
 	for {
 		select {
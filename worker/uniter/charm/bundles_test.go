@@ -93,7 +93,7 @@ func (s *BundlesDirSuite) AddCharm(c *gc.C) (*uniter.Charm, *state.Charm, []byte
 func (s *BundlesDirSuite) TestGet(c *gc.C) {
 	basedir := c.MkDir()
 	bunsdir := filepath.Join(basedir, "random", "bundles")
-	d := charm.NewBundlesDir(bunsdir)
+	d := charm.NewBundlesDir(bunsdir, nil, coretesting.CACert)
 
 	// Check it doesn't get created until it's needed.
 	_, err := os.Stat(bunsdir)
@@ -144,6 +144,25 @@ func (s *BundlesDirSuite) TestGet(c *gc.C) {
 	}
 }
 
+func (s *BundlesDirSuite) TestGetCached(c *gc.C) {
+	cache := charm.NewArchiveCache(c.MkDir(), charm.DefaultMaxCachedArchives)
+	d1 := charm.NewBundlesDir(c.MkDir(), cache, coretesting.CACert)
+	d2 := charm.NewBundlesDir(c.MkDir(), cache, coretesting.CACert)
+	apiCharm, sch, bundata := s.AddCharm(c)
+
+	// The first read downloads the charm and caches it.
+	gitjujutesting.Server.Response(200, nil, bundata)
+	ch, err := d1.Read(apiCharm, nil)
+	c.Assert(err, gc.IsNil)
+	assertCharm(c, ch, sch)
+
+	// Another bundles dir gets the charm from the cache,
+	// without preparing a response from the server.
+	ch, err = d2.Read(apiCharm, nil)
+	c.Assert(err, gc.IsNil)
+	assertCharm(c, ch, sch)
+}
+
 func readHash(c *gc.C, path string) ([]byte, string) {
 	data, err := ioutil.ReadFile(path)
 	c.Assert(err, gc.IsNil)
@@ -0,0 +1,203 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/charm"
+)
+
+// DefaultMaxCachedArchives holds the number of charm archives kept
+// in a machine's archive cache when no other limit is specified.
+const DefaultMaxCachedArchives = 10
+
+// tempPrefix prefixes the names of partially written files in
+// an archive cache, so they are never mistaken for archives.
+const tempPrefix = ".tmp-"
+
+// ArchiveCache holds verified charm archives in a directory shared by
+// all the unit agents on a machine, so that an archive needed by several
+// units is only downloaded once. Archives are identified by charm URL and
+// SHA-256 digest, and their content is checked against the digest every
+// time one is taken from the cache. Where the file system allows it,
+// archives are hard linked rather than copied in and out of the cache,
+// so that each one is only stored once on disk.
+type ArchiveCache struct {
+	path        string
+	maxArchives int
+}
+
+// NewArchiveCache returns a new ArchiveCache which uses path for storage
+// and keeps at most maxArchives archives, evicting the least recently
+// used ones first.
+func NewArchiveCache(path string, maxArchives int) *ArchiveCache {
+	return &ArchiveCache{path, maxArchives}
+}
+
+// Get places the cached archive identified by info at target, and
+// reports whether it was found. A cached archive whose content does
+// not match the expected digest is removed from the cache and
+// reported as not found.
+func (c *ArchiveCache) Get(info BundleInfo, target string) (bool, error) {
+	archiveSha256, err := info.ArchiveSha256()
+	if err != nil {
+		return false, err
+	}
+	path := c.archivePath(info.URL(), archiveSha256)
+	actualSha256, err := fileSha256(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if actualSha256 == archiveSha256 {
+		err = linkOrCopyFile(target, path, archiveSha256)
+	}
+	if actualSha256 != archiveSha256 || err == errDigestMismatch {
+		logger.Warningf("removing corrupt cached archive for charm %q", info.URL())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	// Record the use so that eviction keeps recently used archives.
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	logger.Infof("using cached archive for charm %q", info.URL())
+	return true, nil
+}
+
+// Put adds the verified archive at source, identified by info, to the
+// cache, and evicts the least recently used archives beyond the cache's
+// limit.
+func (c *ArchiveCache) Put(info BundleInfo, source string) error {
+	archiveSha256, err := info.ArchiveSha256()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.path, 0755); err != nil {
+		return err
+	}
+	if err := linkOrCopyFile(c.archivePath(info.URL(), archiveSha256), source, archiveSha256); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the least recently used archives until no more
+// than the cache's limit remain.
+func (c *ArchiveCache) evict() error {
+	infos, err := ioutil.ReadDir(c.path)
+	if err != nil {
+		return err
+	}
+	var archives []os.FileInfo
+	for _, info := range infos {
+		if info.Mode().IsRegular() && !strings.HasPrefix(info.Name(), tempPrefix) {
+			archives = append(archives, info)
+		}
+	}
+	if len(archives) <= c.maxArchives {
+		return nil
+	}
+	sort.Sort(byModTime(archives))
+	for _, info := range archives[:len(archives)-c.maxArchives] {
+		// Another agent on the machine may be evicting concurrently.
+		err := os.Remove(filepath.Join(c.path, info.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivePath returns the path to the location where the archive
+// for the given charm URL with the given digest is cached.
+func (c *ArchiveCache) archivePath(url *charm.URL, archiveSha256 string) string {
+	return filepath.Join(c.path, charm.Quote(url.String())+"-"+archiveSha256)
+}
+
+// errDigestMismatch is returned by copyFile when the copied content
+// does not match the expected digest.
+var errDigestMismatch = errors.New("archive content does not match digest")
+
+// linkOrCopyFile places the file at source at path, by hard linking it
+// if possible and otherwise by copying it. Archives are never modified
+// once written, so the two names may safely share storage. A copied
+// file is only put in place if its content has the expected
+// hex-encoded SHA-256 digest.
+func linkOrCopyFile(path, source, expectedSha256 string) error {
+	err := os.Link(source, path)
+	if err == nil || os.IsExist(err) {
+		// An existing file is identified by the same digest; it
+		// will be verified when it is next read.
+		return nil
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return copyFile(path, f, expectedSha256)
+}
+
+// copyFile atomically writes the content of r to the file at path,
+// if the content has the expected hex-encoded SHA-256 digest. The
+// digest is checked before the file is put in place, so a corrupt
+// copy is never visible at path.
+func copyFile(path string, r io.Reader, expectedSha256 string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), tempPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != expectedSha256 {
+		return errDigestMismatch
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// fileSha256 returns the hex-encoded SHA-256 digest of the content of
+// the file at path.
+func fileSha256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// byModTime sorts file infos from least to most recently modified.
+type byModTime []os.FileInfo
+
+func (s byModTime) Len() int           { return len(s) }
+func (s byModTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byModTime) Less(i, j int) bool { return s[i].ModTime().Before(s[j].ModTime()) }
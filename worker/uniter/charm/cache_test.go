@@ -0,0 +1,162 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	corecharm "github.com/juju/charm"
+	jc "github.com/juju/testing/checkers"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/charm"
+)
+
+type ArchiveCacheSuite struct {
+	testing.BaseSuite
+}
+
+var _ = gc.Suite(&ArchiveCacheSuite{})
+
+// archiveInfo is a charm.BundleInfo identifying an archive
+// by charm URL and digest only.
+type archiveInfo struct {
+	charm.BundleInfo
+	url    *corecharm.URL
+	sha256 string
+}
+
+func (info *archiveInfo) URL() *corecharm.URL {
+	return info.url
+}
+
+func (info *archiveInfo) ArchiveSha256() (string, error) {
+	return info.sha256, nil
+}
+
+// writeArchive writes the given data to a new file and returns its
+// path along with an archiveInfo identifying it.
+func writeArchive(c *gc.C, revision int, data string) (string, *archiveInfo) {
+	path := filepath.Join(c.MkDir(), "archive")
+	err := ioutil.WriteFile(path, []byte(data), 0644)
+	c.Assert(err, gc.IsNil)
+	hash := sha256.New()
+	hash.Write([]byte(data))
+	return path, &archiveInfo{url: charmURL(revision), sha256: hex.EncodeToString(hash.Sum(nil))}
+}
+
+func assertCached(c *gc.C, cache *charm.ArchiveCache, info charm.BundleInfo, data string) {
+	target := filepath.Join(c.MkDir(), "target")
+	found, err := cache.Get(info, target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.IsTrue)
+	content, err := ioutil.ReadFile(target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(content), gc.Equals, data)
+}
+
+func assertNotCached(c *gc.C, cache *charm.ArchiveCache, info charm.BundleInfo) {
+	target := filepath.Join(c.MkDir(), "target")
+	found, err := cache.Get(info, target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.IsFalse)
+	_, err = os.Stat(target)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+}
+
+func (s *ArchiveCacheSuite) TestPutGet(c *gc.C) {
+	cache := charm.NewArchiveCache(filepath.Join(c.MkDir(), "cache"), 2)
+	path, info := writeArchive(c, 1, "some archive")
+	assertNotCached(c, cache, info)
+
+	err := cache.Put(info, path)
+	c.Assert(err, gc.IsNil)
+	assertCached(c, cache, info, "some archive")
+
+	// Archives are identified by digest as well as charm URL.
+	otherInfo := *info
+	otherInfo.sha256 = "0123456789abcdef"
+	assertNotCached(c, cache, &otherInfo)
+}
+
+func (s *ArchiveCacheSuite) TestPutGetSharesStorage(c *gc.C) {
+	cachePath := filepath.Join(c.MkDir(), "cache")
+	cache := charm.NewArchiveCache(cachePath, 2)
+	path, info := writeArchive(c, 1, "some archive")
+	err := cache.Put(info, path)
+	c.Assert(err, gc.IsNil)
+
+	// The archive is not copied in or out of the cache, so it
+	// is only stored once however many units use it.
+	infos, err := ioutil.ReadDir(cachePath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(infos, gc.HasLen, 1)
+	source, err := os.Stat(path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(os.SameFile(source, infos[0]), jc.IsTrue)
+
+	target := filepath.Join(c.MkDir(), "target")
+	found, err := cache.Get(info, target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(found, jc.IsTrue)
+	targetInfo, err := os.Stat(target)
+	c.Assert(err, gc.IsNil)
+	c.Assert(os.SameFile(source, targetInfo), jc.IsTrue)
+}
+
+func (s *ArchiveCacheSuite) TestGetCorrupt(c *gc.C) {
+	cachePath := filepath.Join(c.MkDir(), "cache")
+	cache := charm.NewArchiveCache(cachePath, 2)
+	path, info := writeArchive(c, 1, "some archive")
+	err := cache.Put(info, path)
+	c.Assert(err, gc.IsNil)
+
+	// Corrupt the cached archive.
+	infos, err := ioutil.ReadDir(cachePath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(infos, gc.HasLen, 1)
+	err = ioutil.WriteFile(filepath.Join(cachePath, infos[0].Name()), []byte("rubbish"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	assertNotCached(c, cache, info)
+	infos, err = ioutil.ReadDir(cachePath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(infos, gc.HasLen, 0)
+}
+
+func (s *ArchiveCacheSuite) TestEviction(c *gc.C) {
+	cachePath := filepath.Join(c.MkDir(), "cache")
+	cache := charm.NewArchiveCache(cachePath, 2)
+	path1, info1 := writeArchive(c, 1, "archive 1")
+	path2, info2 := writeArchive(c, 2, "archive 2")
+	path3, info3 := writeArchive(c, 3, "archive 3")
+
+	err := cache.Put(info1, path1)
+	c.Assert(err, gc.IsNil)
+	err = cache.Put(info2, path2)
+	c.Assert(err, gc.IsNil)
+
+	// Make the first archive look old, then use it, so that
+	// the second archive is the least recently used.
+	infos, err := ioutil.ReadDir(cachePath)
+	c.Assert(err, gc.IsNil)
+	old := time.Now().Add(-time.Hour)
+	for _, info := range infos {
+		err := os.Chtimes(filepath.Join(cachePath, info.Name()), old, old)
+		c.Assert(err, gc.IsNil)
+	}
+	assertCached(c, cache, info1, "archive 1")
+
+	err = cache.Put(info3, path3)
+	c.Assert(err, gc.IsNil)
+	assertCached(c, cache, info1, "archive 1")
+	assertNotCached(c, cache, info2)
+	assertCached(c, cache, info3, "archive 3")
+}
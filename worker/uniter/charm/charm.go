@@ -11,6 +11,8 @@ import (
 	"github.com/juju/loggo"
 	"github.com/juju/utils"
 	"github.com/juju/utils/set"
+
+	"github.com/juju/juju/downloader"
 )
 
 var logger = loggo.GetLogger("juju.worker.uniter.charm")
@@ -39,8 +41,9 @@ type BundleInfo interface {
 	// URL returns the charm URL identifying the bundle.
 	URL() *charm.URL
 
-	// Archive URL returns the location of the bundle data.
-	ArchiveURL() (*url.URL, utils.SSLHostnameVerification, error)
+	// Archive URL returns the location of the bundle data, along
+	// with how to verify the server it is downloaded from.
+	ArchiveURL() (*url.URL, downloader.Verification, error)
 
 	// ArchiveSha256 returns the hex-encoded SHA-256 digest of the bundle data.
 	ArchiveSha256() (string, error)
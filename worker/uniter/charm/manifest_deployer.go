@@ -5,6 +5,7 @@ package charm
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
@@ -15,23 +16,35 @@ import (
 
 const (
 	// deployingURLPath holds the path in the charm dir where the manifest
-	// deployer writes what charm is currently being deployed.
+	// deployer used to write what charm was being deployed, when charms
+	// were deployed in place.
 	deployingURLPath = ".juju-deploying"
 
 	// manifestsDataPath holds the path in the data dir where the manifest
 	// deployer stores the manifests for its charms.
 	manifestsDataPath = "manifests"
+
+	// stagingDataPath holds the path in the data dir where the manifest
+	// deployer expands a charm before moving it into place.
+	stagingDataPath = "charm-staging"
+
+	// previousDataPath holds the path in the data dir to which the
+	// manifest deployer moves the deployed charm directory while it is
+	// being replaced.
+	previousDataPath = "charm-previous"
 )
 
 // NewManifestDeployer returns a Deployer that installs bundles from the
 // supplied BundleReader into charmPath, and which reads and writes its
 // persistent data into dataPath.
 //
-// It works by always writing the full contents of a deployed charm; and, if
-// another charm was previously deployed, deleting only those files unique to
-// that base charm. It thus leaves user files in place, with the exception of
-// those in directories referenced only in the original charm, which will be
-// deleted.
+// It works by expanding the full contents of a charm alongside the deployed
+// one, and only swapping it into place once it has been completely expanded;
+// a failure before then leaves the deployed charm untouched. Files that were
+// not part of the previously deployed charm are then moved into the new charm
+// directory. It thus keeps user files, with the exception of those replaced by
+// the new charm and those in directories referenced only in the original
+// charm, which will be deleted.
 func NewManifestDeployer(charmPath, dataPath string, bundles BundleReader) Deployer {
 	return &manifestDeployer{
 		charmPath: charmPath,
@@ -76,6 +89,9 @@ func (d *manifestDeployer) Deploy() (err error) {
 	}
 
 	// Detect and resolve state of charm directory.
+	if err := d.recoverSwap(); err != nil {
+		return fmt.Errorf("cannot recover charm directory: %v", err)
+	}
 	baseURL, baseManifest, err := d.loadManifest(charmURLPath)
 	if err != nil && !os.IsNotExist(err) {
 		return err
@@ -86,26 +102,12 @@ func (d *manifestDeployer) Deploy() (err error) {
 		return err
 	}
 
-	// Write or overwrite the deploying URL to point to the staged one.
-	if err := d.startDeploy(); err != nil {
+	// Expand the staged charm alongside the deployed one, and swap it
+	// into place only once it is complete.
+	if err := d.prepareStaged(); err != nil {
 		return err
 	}
-
-	// Delete files in the base version not present in the staged charm.
-	if upgrading {
-		if err := d.removeDiff(baseManifest, d.staged.manifest); err != nil {
-			return err
-		}
-	}
-
-	// Overwrite whatever's in place with the staged charm.
-	logger.Debugf("deploying charm %q", d.staged.url)
-	if err := d.staged.bundle.ExpandTo(d.charmPath); err != nil {
-		return err
-	}
-
-	// Move the deploying file over the charm URL file, and we're done.
-	return d.finishDeploy()
+	return d.swapStaged(baseManifest)
 }
 
 func (d *manifestDeployer) NotifyResolved() error {
@@ -121,13 +123,125 @@ func (d *manifestDeployer) NotifyRevert() error {
 	return nil
 }
 
-// startDeploy persists the fact that we've started deploying the staged bundle.
-func (d *manifestDeployer) startDeploy() error {
+// prepareStaged expands the staged bundle into the staging directory, and
+// checks that every entry in its manifest is present.
+func (d *manifestDeployer) prepareStaged() error {
 	logger.Debugf("preparing to deploy charm %q", d.staged.url)
-	if err := os.MkdirAll(d.charmPath, 0755); err != nil {
+	stagingPath := d.DataPath(stagingDataPath)
+	if err := os.RemoveAll(stagingPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stagingPath, 0755); err != nil {
+		return err
+	}
+	if err := d.staged.bundle.ExpandTo(stagingPath); err != nil {
 		return err
 	}
-	return WriteCharmURL(d.CharmPath(deployingURLPath), d.staged.url)
+	for _, path := range d.staged.manifest.SortedValues() {
+		if _, err := os.Lstat(filepath.Join(stagingPath, filepath.FromSlash(path))); err != nil {
+			return fmt.Errorf("charm %q not fully expanded: %v", d.staged.url, err)
+		}
+	}
+	return WriteCharmURL(filepath.Join(stagingPath, charmURLPath), d.staged.url)
+}
+
+// swapStaged moves the prepared staging directory into place as the charm
+// directory, and then moves user files across from the charm directory it
+// replaced, whose charm had the supplied manifest.
+func (d *manifestDeployer) swapStaged(baseManifest set.Strings) error {
+	logger.Debugf("deploying charm %q", d.staged.url)
+	stagingPath := d.DataPath(stagingDataPath)
+	previousPath := d.DataPath(previousDataPath)
+	if err := os.Rename(d.charmPath, previousPath); os.IsNotExist(err) {
+		return os.Rename(stagingPath, d.charmPath)
+	} else if err != nil {
+		return err
+	}
+	if err := os.Rename(stagingPath, d.charmPath); err != nil {
+		if err := os.Rename(previousPath, d.charmPath); err != nil {
+			logger.Errorf("cannot restore charm directory: %v", err)
+		}
+		return err
+	}
+	return d.finishSwap(baseManifest)
+}
+
+// recoverSwap deals with a swap of the charm directory that was interrupted.
+// If the staged charm was not moved into place, the previous charm directory
+// is restored; otherwise the swap is completed.
+func (d *manifestDeployer) recoverSwap() error {
+	previousPath := d.DataPath(previousDataPath)
+	if _, err := os.Lstat(previousPath); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(d.charmPath); os.IsNotExist(err) {
+		logger.Infof("restoring charm directory after interrupted deploy")
+		return os.Rename(previousPath, d.charmPath)
+	} else if err != nil {
+		return err
+	}
+	logger.Infof("completing interrupted deploy")
+	_, baseManifest, err := d.readManifest(filepath.Join(previousPath, charmURLPath))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return d.finishSwap(baseManifest)
+}
+
+// finishSwap moves user files from the previous charm directory, whose charm
+// had the supplied manifest, into the charm directory, and then removes the
+// previous charm directory.
+func (d *manifestDeployer) finishSwap(baseManifest set.Strings) error {
+	previousPath := d.DataPath(previousDataPath)
+	if err := d.moveUserFiles(previousPath, "", baseManifest); err != nil {
+		return err
+	}
+	return os.RemoveAll(previousPath)
+}
+
+// moveUserFiles moves every entry under the slash-separated dir within
+// previousPath that is not in baseManifest to the same location in the charm
+// directory. Entries replaced by the new charm, and those whose directory is
+// not in the new charm, are left to be removed.
+func (d *manifestDeployer) moveUserFiles(previousPath, dir string, baseManifest set.Strings) error {
+	infos, err := ioutil.ReadDir(filepath.Join(previousPath, filepath.FromSlash(dir)))
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		path := info.Name()
+		if dir != "" {
+			path = dir + "/" + path
+		} else if path == charmURLPath || path == deployingURLPath {
+			continue
+		}
+		if baseManifest.Contains(path) {
+			if info.IsDir() {
+				if err := d.moveUserFiles(previousPath, path, baseManifest); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		target := d.CharmPath(filepath.FromSlash(path))
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		if parent, err := os.Lstat(filepath.Dir(target)); os.IsNotExist(err) || err == nil && !parent.IsDir() {
+			continue
+		} else if err != nil {
+			return err
+		}
+		source := filepath.Join(previousPath, filepath.FromSlash(path))
+		if err := os.Rename(source, target); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // removeDiff removes every path in oldManifest that is not present in newManifest.
@@ -142,23 +256,15 @@ func (d *manifestDeployer) removeDiff(oldManifest, newManifest set.Strings) erro
 	return nil
 }
 
-// finishDeploy persists the fact that we've finished deploying the staged bundle.
-func (d *manifestDeployer) finishDeploy() error {
-	logger.Debugf("finishing deploy of charm %q", d.staged.url)
-	oldPath := d.CharmPath(deployingURLPath)
-	newPath := d.CharmPath(charmURLPath)
-	return utils.ReplaceFile(oldPath, newPath)
-}
-
-// ensureBaseFiles checks for an interrupted deploy operation and, if it finds
-// one, removes all entries in the manifest unique to the interrupted operation.
-// This leaves files from the base charm in an indeterminate state, but ready to
-// be either removed (if they are not referenced by the new charm) or overwritten
-// (if they are referenced by the new charm).
+// ensureBaseFiles checks for a deploy operation that was interrupted while the
+// charm was deployed in place and, if it finds one, removes all entries in the
+// manifest unique to the interrupted operation. This leaves files from the base
+// charm in an indeterminate state, but ready to be either removed (if they are
+// not referenced by the new charm) or replaced (if they are referenced by the
+// new charm).
 //
-// Note that deployingURLPath is *not* written, because the charm state remains
-// indeterminate; that file will be removed when and only when a deploy completes
-// successfully.
+// Note that deployingURLPath is left in place; it is discarded along with the
+// replaced charm directory when a deploy completes successfully.
 func (d *manifestDeployer) ensureBaseFiles(baseManifest set.Strings) error {
 	deployingURL, deployingManifest, err := d.loadManifest(deployingURLPath)
 	if err == nil {
@@ -189,7 +295,13 @@ func (d *manifestDeployer) storeManifest(url *charm.URL, manifest set.Strings) e
 // loadManifest loads, from dataPath, the manifest for the charm identified by the
 // identity file at the supplied path within the charm directory.
 func (d *manifestDeployer) loadManifest(urlFilePath string) (*charm.URL, set.Strings, error) {
-	url, err := ReadCharmURL(d.CharmPath(urlFilePath))
+	return d.readManifest(d.CharmPath(urlFilePath))
+}
+
+// readManifest loads, from dataPath, the manifest for the charm identified by
+// the identity file at the supplied path.
+func (d *manifestDeployer) readManifest(urlPath string) (*charm.URL, set.Strings, error) {
+	url, err := ReadCharmURL(urlPath)
 	if err != nil {
 		return nil, set.NewStrings(), err
 	}
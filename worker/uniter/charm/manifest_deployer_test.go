@@ -5,6 +5,7 @@ package charm_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	ft "github.com/juju/testing/filetesting"
@@ -19,6 +20,7 @@ type ManifestDeployerSuite struct {
 	testing.BaseSuite
 	bundles    *bundleReader
 	targetPath string
+	dataPath   string
 	deployer   charm.Deployer
 }
 
@@ -32,8 +34,8 @@ func (s *ManifestDeployerSuite) SetUpTest(c *gc.C) {
 	s.BaseSuite.SetUpTest(c)
 	s.bundles = &bundleReader{}
 	s.targetPath = filepath.Join(c.MkDir(), "target")
-	deployerPath := filepath.Join(c.MkDir(), "deployer")
-	s.deployer = charm.NewManifestDeployer(s.targetPath, deployerPath, s.bundles)
+	s.dataPath = filepath.Join(c.MkDir(), "deployer")
+	s.deployer = charm.NewManifestDeployer(s.targetPath, s.dataPath, s.bundles)
 }
 
 func (s *ManifestDeployerSuite) addMockCharm(c *gc.C, revision int, bundle charm.Bundle) charm.BundleInfo {
@@ -250,3 +252,105 @@ func (s *ManifestDeployerSuite) TestUpgradeConflictRevertRetryDifferentCharm(c *
 	ft.Removed{"old-file"}.Check(c, s.targetPath)
 	ft.Removed{"bad-file"}.Check(c, s.targetPath)
 }
+
+func (s *ManifestDeployerSuite) TestUpgradeFailureLeavesCharm(c *gc.C) {
+	originalContent := ft.Entries{
+		ft.File{"shared-file", "old", 0755},
+		ft.File{"old-file", "old", 0644},
+	}
+	s.deployCharm(c, 1, originalContent...)
+	userFile := ft.File{"user-file", "user", 0644}.Create(c, s.targetPath)
+
+	// An upgrade that fails to expand leaves the deployed charm intact.
+	badCharm := mockBundle{
+		paths: set.NewStrings("shared-file", "bad-file"),
+		expand: func(targetPath string) error {
+			ft.File{"shared-file", "bad", 0644}.Create(c, targetPath)
+			return fmt.Errorf("oh noes")
+		},
+	}
+	info := s.addMockCharm(c, 2, badCharm)
+	err := s.deployer.Stage(info, nil)
+	c.Assert(err, gc.IsNil)
+	err = s.deployer.Deploy()
+	c.Assert(err, gc.Equals, charm.ErrConflict)
+	s.assertCharm(c, 1, originalContent...)
+	userFile.Check(c, s.targetPath)
+	ft.Removed{"bad-file"}.Check(c, s.targetPath)
+}
+
+func (s *ManifestDeployerSuite) TestUpgradeIncompleteExpandLeavesCharm(c *gc.C) {
+	originalContent := ft.Entries{
+		ft.File{"shared-file", "old", 0755},
+	}
+	s.deployCharm(c, 1, originalContent...)
+
+	// An upgrade that claims to expand, but is missing files from its
+	// manifest, is not deployed.
+	incompleteCharm := mockBundle{
+		paths: set.NewStrings("shared-file", "missing-file"),
+		expand: func(targetPath string) error {
+			ft.File{"shared-file", "new", 0755}.Create(c, targetPath)
+			return nil
+		},
+	}
+	info := s.addMockCharm(c, 2, incompleteCharm)
+	err := s.deployer.Stage(info, nil)
+	c.Assert(err, gc.IsNil)
+	err = s.deployer.Deploy()
+	c.Assert(err, gc.Equals, charm.ErrConflict)
+	s.assertCharm(c, 1, originalContent...)
+}
+
+func (s *ManifestDeployerSuite) TestUpgradeRecoversInterruptedSwap(c *gc.C) {
+	s.deployCharm(c, 1,
+		ft.File{"shared-file", "old", 0755},
+		ft.File{"old-file", "old", 0644},
+	)
+	userFile := ft.File{"user-file", "user", 0644}.Create(c, s.targetPath)
+
+	// Simulate a deploy interrupted after the charm directory was moved
+	// aside, but before the new one was moved into place.
+	err := os.Rename(s.targetPath, filepath.Join(s.dataPath, "charm-previous"))
+	c.Assert(err, gc.IsNil)
+
+	s.deployCharm(c, 2,
+		ft.File{"shared-file", "new", 0755},
+		ft.File{"new-file", "new", 0644},
+	)
+	userFile.Check(c, s.targetPath)
+	ft.Removed{"old-file"}.Check(c, s.targetPath)
+	ft.Removed{"charm-previous"}.Check(c, s.dataPath)
+	ft.Removed{"charm-staging"}.Check(c, s.dataPath)
+}
+
+func (s *ManifestDeployerSuite) TestUpgradeCompletesInterruptedSwap(c *gc.C) {
+	s.deployCharm(c, 1,
+		ft.File{"shared-file", "old", 0755},
+		ft.File{"old-file", "old", 0644},
+	)
+	userFile := ft.File{"user-file", "user", 0644}.Create(c, s.targetPath)
+	upgradeContent := ft.Entries{
+		ft.File{"shared-file", "new", 0755},
+	}
+	info := s.addCharm(c, 2, upgradeContent...)
+	err := s.deployer.Stage(info, nil)
+	c.Assert(err, gc.IsNil)
+
+	// Simulate a deploy interrupted after the new charm directory was
+	// moved into place, but before user files were moved across.
+	err = os.Rename(s.targetPath, filepath.Join(s.dataPath, "charm-previous"))
+	c.Assert(err, gc.IsNil)
+	err = os.Mkdir(s.targetPath, 0755)
+	c.Assert(err, gc.IsNil)
+	upgradeContent.Create(c, s.targetPath)
+	err = charm.WriteCharmURL(filepath.Join(s.targetPath, ".juju-charm"), charmURL(2))
+	c.Assert(err, gc.IsNil)
+
+	err = s.deployer.Deploy()
+	c.Assert(err, gc.IsNil)
+	s.assertCharm(c, 2, upgradeContent...)
+	userFile.Check(c, s.targetPath)
+	ft.Removed{"old-file"}.Check(c, s.targetPath)
+	ft.Removed{"charm-previous"}.Check(c, s.dataPath)
+}
@@ -0,0 +1,269 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"launchpad.net/goyaml"
+
+	"github.com/juju/juju/worker/uniter/hook"
+)
+
+// concurrentRelationHooks runs the hooks of different relations
+// concurrently, for charms that declare it safe with the
+// "concurrent-relation-hooks" field of their metadata. The hooks of
+// any one relation still run one at a time and in order, and other
+// hooks only run once all relation hooks have completed.
+//
+// Relation hooks run this way are not recorded in the uniter's state
+// file while they run. A hook interrupted by the agent stopping is
+// therefore not treated as failed when the agent restarts; it is left
+// uncommitted in its relation's state, and is queued again when the
+// relation's hooks are restarted.
+type concurrentRelationHooks struct {
+	u *Uniter
+
+	// enabled holds whether the deployed charm allows its
+	// relation hooks to run concurrently.
+	enabled bool
+
+	// running holds the ids of the relations with a hook running,
+	// and waiting the hooks queued behind them, in order.
+	running map[int]bool
+	waiting map[int][]hook.Info
+
+	// results receives the outcome of each hook run.
+	results chan relationHookResult
+
+	// failed holds the first hook to fail while others were
+	// still running.
+	failed *hook.Info
+
+	// deferred holds a hook that was not run because a relation
+	// hook failed while it waited; it is run when the uniter
+	// next abides.
+	deferred *hook.Info
+}
+
+// relationHookResult holds the outcome of a relation hook run by
+// concurrentRelationHooks.
+type relationHookResult struct {
+	hi       hook.Info
+	hookName string
+	hctx     *HookContext
+	err      error
+}
+
+func newConcurrentRelationHooks(u *Uniter) *concurrentRelationHooks {
+	return &concurrentRelationHooks{
+		u:       u,
+		running: make(map[int]bool),
+		waiting: make(map[int][]hook.Info),
+		results: make(chan relationHookResult),
+	}
+}
+
+// charmHookConcurrency holds the fields of a charm's metadata that
+// control how its hooks are run.
+//
+// TODO: concurrent-relation-hooks belongs on charm.Meta alongside the
+// other fields. Once the github.com/juju/charm revision in
+// dependencies.tsv parses it, read it from the deployed charm's Meta.
+type charmHookConcurrency struct {
+	ConcurrentRelationHooks bool `yaml:"concurrent-relation-hooks"`
+}
+
+// readConcurrentRelationHooks reports whether the charm deployed at
+// charmPath allows hooks of different relations to run concurrently.
+func readConcurrentRelationHooks(charmPath string) (bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(charmPath, "metadata.yaml"))
+	if err != nil {
+		return false, err
+	}
+	var meta charmHookConcurrency
+	if err := goyaml.Unmarshal(data, &meta); err != nil {
+		return false, fmt.Errorf("cannot parse charm metadata: %v", err)
+	}
+	return meta.ConcurrentRelationHooks, nil
+}
+
+// start runs hi, a relation hook, in the background, or queues it to
+// run once the hook already running for its relation has completed.
+func (c *concurrentRelationHooks) start(hi hook.Info) error {
+	if c.running[hi.RelationId] {
+		c.waiting[hi.RelationId] = append(c.waiting[hi.RelationId], hi)
+		return nil
+	}
+	return c.run(hi)
+}
+
+// run runs hi in the background, sending its outcome on c.results.
+// The machine's hook lock is held while any relation hooks are
+// running, so that hooks of other units on the machine still run
+// exclusively.
+func (c *concurrentRelationHooks) run(hi hook.Info) (err error) {
+	u := c.u
+	if err := hi.Validate(); err != nil {
+		return err
+	}
+	hookName, err := u.relationers[hi.RelationId].PrepareHook(hi)
+	if err != nil {
+		return err
+	}
+	if len(c.running) == 0 {
+		lockMessage := fmt.Sprintf("%s: running relation hooks", u.unit.Name())
+		if err := u.acquireHookLock(lockMessage); err != nil {
+			return err
+		}
+	}
+	c.running[hi.RelationId] = true
+	defer func() {
+		if err != nil {
+			c.release(hi.RelationId)
+		}
+	}()
+
+	hctxId := fmt.Sprintf("%s:%s:%d", u.unit.Name(), hookName, u.rand.Int63())
+	hctx, err := u.getHookContext(hctxId, hi.RelationId, hi.RemoteUnit, nil)
+	if err != nil {
+		return err
+	}
+	// The unit and the relations' contexts are shared with the
+	// uniter's loop, which refreshes them while this hook runs, and
+	// with the hooks of other relations. Give the hook its own.
+	unit := *u.unit
+	hctx.unit = &unit
+	for id, ctx := range hctx.relations {
+		hctx.relations[id] = ctx.snapshot()
+	}
+	socketName := fmt.Sprintf("agent-%d.socket", hi.RelationId)
+	srv, socketPath, err := u.startJujucServer(hctx, socketName)
+	if err != nil {
+		return err
+	}
+	logger.Infof("running %q hook", hookName)
+	go func() {
+		err := hctx.RunHook(hookName, u.charmPath, u.toolsDir, socketPath)
+		srv.Close()
+		c.results <- relationHookResult{hi, hookName, hctx, err}
+	}()
+	return nil
+}
+
+// release records that the hook running for the given relation has
+// completed, releasing the machine's hook lock if no others are
+// running.
+func (c *concurrentRelationHooks) release(relationId int) {
+	delete(c.running, relationId)
+	if len(c.running) == 0 {
+		c.u.hookLock.Unlock()
+	}
+}
+
+// finish commits the hook whose outcome is given, and starts the next
+// hook waiting for its relation. If the hook failed, it returns
+// errHookFailed and no further hooks are started.
+func (c *concurrentRelationHooks) finish(result relationHookResult) error {
+	u := c.u
+	c.release(result.hi.RelationId)
+	if IsMissingHookError(result.err) {
+		logger.Infof("skipped %q hook (missing)", result.hookName)
+	} else if result.err != nil {
+		logger.Errorf("hook failed: %s", result.err)
+		u.notifyHookFailed(result.hookName, result.hctx)
+		if c.failed == nil {
+			c.failed = &result.hi
+		}
+		return errHookFailed
+	} else {
+		logger.Infof("ran %q hook", result.hookName)
+		u.notifyHookCompleted(result.hookName, result.hctx)
+	}
+	if err := u.commitHook(result.hi); err != nil {
+		return err
+	}
+	if c.failed != nil {
+		return nil
+	}
+	waiting := c.waiting[result.hi.RelationId]
+	if len(waiting) == 0 {
+		return nil
+	}
+	if len(waiting) == 1 {
+		delete(c.waiting, result.hi.RelationId)
+	} else {
+		c.waiting[result.hi.RelationId] = waiting[1:]
+	}
+	return c.run(waiting[0])
+}
+
+// handle deals with the outcome of a relation hook received from
+// c.results. If the hook failed, it waits for the other running hooks
+// to complete and returns errHookFailed.
+func (c *concurrentRelationHooks) handle(result relationHookResult) error {
+	if err := c.finish(result); err != errHookFailed {
+		return err
+	}
+	return c.wait()
+}
+
+// wait waits for all running relation hooks, and those waiting behind
+// them, to complete. If any hook failed, the first failure is recorded
+// in the uniter's state, so that it can be resolved like any other
+// failed hook, and errHookFailed is returned. Hooks of other relations
+// that were waiting to run are discarded; their relations queue them
+// again when their hooks are restarted.
+func (c *concurrentRelationHooks) wait() error {
+	var err error
+	for len(c.running) > 0 {
+		result := <-c.results
+		if e := c.finish(result); e != nil && e != errHookFailed && err == nil {
+			err = e
+		}
+	}
+	c.waiting = make(map[int][]hook.Info)
+	if err != nil {
+		return err
+	}
+	if c.failed == nil {
+		return nil
+	}
+	failed := c.failed
+	c.failed = nil
+	if err := c.u.writeState(RunHook, Pending, failed, nil); err != nil {
+		return err
+	}
+	return errHookFailed
+}
+
+// runAbideHook runs hi, a hook chosen in ModeAbide. Relation hooks are
+// started in the background if the charm allows it; other hooks run
+// once all relation hooks have completed.
+func (u *Uniter) runAbideHook(hi hook.Info) error {
+	c := u.concurrentHooks
+	if c.enabled && hi.Kind.IsRelation() {
+		return c.start(hi)
+	}
+	if err := c.wait(); err == errHookFailed {
+		c.deferred = &hi
+		return err
+	} else if err != nil {
+		return err
+	}
+	return u.runHook(hi)
+}
+
+// runDeferredHook runs the hook, if any, that was deferred because a
+// relation hook failed before it could run.
+func (u *Uniter) runDeferredHook() error {
+	hi := u.concurrentHooks.deferred
+	if hi == nil {
+		return nil
+	}
+	u.concurrentHooks.deferred = nil
+	return u.runHook(*hi)
+}
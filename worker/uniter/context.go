@@ -98,6 +98,16 @@ type HookContext struct {
 
 	// proxySettings are the current proxy settings that the uniter knows about
 	proxySettings proxy.Settings
+
+	// logCount holds the number of juju-log messages written so far
+	// by the executing hook. The hook's tools may run concurrently,
+	// so it is guarded by logMu.
+	logMu    sync.Mutex
+	logCount int
+
+	// metrics holds the metrics recorded by the executing hook, to be
+	// sent when it completes successfully.
+	metrics []params.Metric
 }
 
 func NewHookContext(
@@ -151,12 +161,51 @@ func (ctx *HookContext) PrivateAddress() (string, bool) {
 	return ctx.privateAddress, ctx.privateAddress != ""
 }
 
-func (ctx *HookContext) OpenPort(protocol string, port int) error {
-	return ctx.unit.OpenPort(protocol, port)
+// maxHookLogMessages holds the number of juju-log messages a single hook
+// may write. Any further messages are discarded, so that a chatty charm
+// cannot flood the unit's log.
+var maxHookLogMessages = 1000
+
+func (ctx *HookContext) WriteLog(level loggo.Level, message string) {
+	logger := loggo.GetLogger(fmt.Sprintf("unit.%s.juju-log", ctx.UnitName()))
+	ctx.logMu.Lock()
+	ctx.logCount++
+	count := ctx.logCount
+	ctx.logMu.Unlock()
+	switch {
+	case count <= maxHookLogMessages:
+		logger.Logf(level, "%s", message)
+	case count == maxHookLogMessages+1:
+		logger.Warningf("hook wrote more than %d log messages; discarding the rest", maxHookLogMessages)
+	}
 }
 
-func (ctx *HookContext) ClosePort(protocol string, port int) error {
-	return ctx.unit.ClosePort(protocol, port)
+// NetworkInfo returns the unit's addresses for the given endpoint.
+// Endpoints are not yet bound to particular networks, so every
+// endpoint is served by the addresses of the unit's machine: the
+// private address is used for binding, and the public address, if
+// different, is offered as a further ingress address. The netmasks of
+// the machine's addresses are not known, so no egress subnets are
+// reported.
+func (ctx *HookContext) NetworkInfo(endpoint string) (jujuc.NetworkInfo, error) {
+	var info jujuc.NetworkInfo
+	if ctx.privateAddress == "" {
+		return info, fmt.Errorf("unit %q has no private address", ctx.unit.Name())
+	}
+	info.BindAddress = ctx.privateAddress
+	info.IngressAddresses = []string{ctx.privateAddress}
+	if ctx.publicAddress != "" && ctx.publicAddress != ctx.privateAddress {
+		info.IngressAddresses = append(info.IngressAddresses, ctx.publicAddress)
+	}
+	return info, nil
+}
+
+func (ctx *HookContext) OpenPorts(protocol string, fromPort, toPort int) error {
+	return ctx.unit.OpenPorts(protocol, fromPort, toPort)
+}
+
+func (ctx *HookContext) ClosePorts(protocol string, fromPort, toPort int) error {
+	return ctx.unit.ClosePorts(protocol, fromPort, toPort)
 }
 
 func (ctx *HookContext) OwnerTag() string {
@@ -178,6 +227,19 @@ func (ctx *HookContext) ConfigSettings() (charm.Settings, error) {
 	return result, nil
 }
 
+func (ctx *HookContext) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	return ctx.unit.SetWorkloadStatus(status, message)
+}
+
+func (ctx *HookContext) AddMetric(key, value string, created time.Time) error {
+	ctx.metrics = append(ctx.metrics, params.Metric{
+		Key:   key,
+		Value: value,
+		Time:  created,
+	})
+	return nil
+}
+
 func (ctx *HookContext) ActionParams() map[string]interface{} {
 	return ctx.actionParams
 }
@@ -306,6 +368,16 @@ func (ctx *HookContext) finalizeContext(process string, err error) error {
 		}
 		rctx.ClearCache()
 	}
+	if writeChanges && len(ctx.metrics) > 0 {
+		if e := ctx.unit.AddMetrics(ctx.metrics); e != nil {
+			e = fmt.Errorf("could not send metrics from %q: %v", process, e)
+			logger.Errorf("%v", e)
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	ctx.metrics = nil
 	return err
 }
 
@@ -538,6 +610,16 @@ func (ctx *ContextRelation) ClearCache() {
 	ctx.cache = make(SettingsMap)
 }
 
+// snapshot returns a copy of the context with the same membership and
+// caches of its own, for use by a hook that runs concurrently with
+// others.
+func (ctx *ContextRelation) snapshot() *ContextRelation {
+	snapshot := &ContextRelation{ru: ctx.ru, members: SettingsMap{}}
+	snapshot.UpdateMembers(ctx.members)
+	snapshot.ClearCache()
+	return snapshot
+}
+
 // UpdateMembers ensures that the context is aware of every supplied
 // member unit. For each supplied member, the cached settings will be
 // overwritten.
@@ -586,18 +668,41 @@ func (ctx *ContextRelation) Settings() (jujuc.Settings, error) {
 
 func (ctx *ContextRelation) ReadSettings(unit string) (settings params.RelationSettings, err error) {
 	settings, member := ctx.members[unit]
-	if settings == nil {
-		if settings = ctx.cache[unit]; settings == nil {
-			settings, err = ctx.ru.ReadSettings(unit)
-			if err != nil {
+	if member {
+		if settings == nil {
+			if err := ctx.readMemberSettings(); err != nil {
 				return nil, err
 			}
+			settings = ctx.members[unit]
 		}
+		return settings, nil
 	}
-	if member {
-		ctx.members[unit] = settings
-	} else {
+	if settings = ctx.cache[unit]; settings == nil {
+		settings, err = ctx.ru.ReadSettings(unit)
+		if err != nil {
+			return nil, err
+		}
 		ctx.cache[unit] = settings
 	}
 	return settings, nil
 }
+
+// readMemberSettings caches the settings of every member whose settings
+// are not yet known. Hooks commonly read the settings of each member in
+// turn, so fetching them all at once saves an API call per member.
+func (ctx *ContextRelation) readMemberSettings() error {
+	var unknown []string
+	for unit, settings := range ctx.members {
+		if settings == nil {
+			unknown = append(unknown, unit)
+		}
+	}
+	settings, err := ctx.ru.ReadSettingsOfUnits(unknown)
+	if err != nil {
+		return err
+	}
+	for unit, s := range settings {
+		ctx.members[unit] = s
+	}
+	return nil
+}
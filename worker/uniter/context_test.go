@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/juju/charm"
+	"github.com/juju/loggo"
 	"github.com/juju/names"
 	envtesting "github.com/juju/testing"
 	jc "github.com/juju/testing/checkers"
@@ -376,6 +377,46 @@ func (s *RunHookSuite) TestRunHookRelationFlushing(c *gc.C) {
 	})
 }
 
+func (s *RunHookSuite) TestRunHookMetricsFlushing(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.getHookContext(c, uuid.String(), -1, "", noProxies)
+
+	// Metrics recorded by a failing hook are discarded.
+	charmDir, _ := makeCharm(c, hookSpec{
+		name: "something-happened",
+		perm: 0700,
+		code: 123,
+	})
+	err = ctx.AddMetric("requests", "1", time.Now())
+	c.Assert(err, gc.IsNil)
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.ErrorMatches, "exit status 123")
+	batches, err := s.State.MetricBatches(s.unit.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+
+	// Metrics recorded by a successful hook are sent as one batch.
+	charmDir, _ = makeCharm(c, hookSpec{
+		name: "something-happened",
+		perm: 0700,
+	})
+	err = ctx.AddMetric("requests", "2", time.Now())
+	c.Assert(err, gc.IsNil)
+	err = ctx.AddMetric("queue.length", "3", time.Now())
+	c.Assert(err, gc.IsNil)
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.IsNil)
+	batches, err = s.State.MetricBatches(s.unit.Tag().String(), time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	metrics := batches[0].Metrics()
+	c.Assert(metrics, gc.HasLen, 2)
+	c.Assert(metrics[0].Key, gc.Equals, "requests")
+	c.Assert(metrics[0].Value, gc.Equals, "2")
+	c.Assert(metrics[1].Key, gc.Equals, "queue.length")
+}
+
 type ContextRelationSuite struct {
 	testing.JujuConnSuite
 	svc *state.Service
@@ -505,6 +546,35 @@ func (s *ContextRelationSuite) TestMemberCaching(c *gc.C) {
 	c.Assert(m, gc.DeepEquals, params.RelationSettings{"entirely": "different"})
 }
 
+func (s *ContextRelationSuite) TestMemberSettingsReadTogether(c *gc.C) {
+	var settings []*state.Settings
+	for i := 0; i < 2; i++ {
+		unit, err := s.svc.AddUnit()
+		c.Assert(err, gc.IsNil)
+		ru, err := s.rel.Unit(unit)
+		c.Assert(err, gc.IsNil)
+		err = ru.EnterScope(map[string]interface{}{"ping": "pong"})
+		c.Assert(err, gc.IsNil)
+		node, err := ru.Settings()
+		c.Assert(err, gc.IsNil)
+		settings = append(settings, node)
+	}
+	ctx := uniter.NewContextRelation(s.apiRelUnit, map[string]int64{"u/1": 0, "u/2": 0})
+
+	// Reading the settings of one member caches those of every member...
+	m, err := ctx.ReadSettings("u/1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m["ping"], gc.Equals, "pong")
+
+	// ...so later changes to another member are not seen.
+	settings[1].Set("ping", "pow")
+	_, err = settings[1].Write()
+	c.Assert(err, gc.IsNil)
+	m, err = ctx.ReadSettings("u/2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(m["ping"], gc.Equals, "pong")
+}
+
 func (s *ContextRelationSuite) TestNonMemberCaching(c *gc.C) {
 	unit, err := s.svc.AddUnit()
 	c.Assert(err, gc.IsNil)
@@ -668,6 +738,73 @@ func (s *InterfaceSuite) TestConfigCaching(c *gc.C) {
 	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "My Title"})
 }
 
+func (s *InterfaceSuite) TestSetWorkloadStatus(c *gc.C) {
+	ctx := s.GetContext(c, -1, "")
+	err := ctx.SetWorkloadStatus(params.WorkloadStatusBlocked, "waiting for db relation")
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	status, info := s.unit.WorkloadStatus()
+	c.Assert(status, gc.Equals, params.WorkloadStatusBlocked)
+	c.Assert(info, gc.Equals, "waiting for db relation")
+}
+
+func (s *InterfaceSuite) TestWriteLogLimit(c *gc.C) {
+	s.PatchValue(uniter.MaxHookLogMessages, 2)
+	loggo.GetLogger("unit").SetLogLevel(loggo.TRACE)
+	tw := &loggo.TestWriter{}
+	c.Assert(loggo.RegisterWriter("hook-log-test", tw, loggo.TRACE), gc.IsNil)
+	defer loggo.RemoveWriter("hook-log-test")
+	hookMessages := func() (messages []string) {
+		for _, entry := range tw.Log() {
+			if entry.Module == "unit.u/0.juju-log" {
+				messages = append(messages, entry.Message)
+			}
+		}
+		return messages
+	}
+
+	ctx := s.GetContext(c, -1, "")
+	for i := 0; i < 4; i++ {
+		ctx.WriteLog(loggo.INFO, fmt.Sprintf("message %d", i))
+	}
+	c.Assert(hookMessages(), gc.DeepEquals, []string{
+		"message 0",
+		"message 1",
+		"hook wrote more than 2 log messages; discarding the rest",
+	})
+
+	// The limit applies to each hook separately.
+	tw.Clear()
+	ctx = s.GetContext(c, -1, "")
+	ctx.WriteLog(loggo.INFO, "another hook")
+	c.Assert(hookMessages(), gc.DeepEquals, []string{"another hook"})
+}
+
+func (s *InterfaceSuite) TestNetworkInfo(c *gc.C) {
+	ctx := s.GetContext(c, -1, "")
+	info, err := ctx.NetworkInfo("db")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.DeepEquals, jujuc.NetworkInfo{
+		BindAddress:      "u-0.testing.invalid",
+		IngressAddresses: []string{"u-0.testing.invalid"},
+	})
+
+	err = s.machine.SetAddresses(
+		network.NewAddress("10.0.0.1", network.ScopeCloudLocal),
+		network.NewAddress("54.0.0.1", network.ScopePublic),
+	)
+	c.Assert(err, gc.IsNil)
+	ctx = s.GetContext(c, -1, "")
+	info, err = ctx.NetworkInfo("db")
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.DeepEquals, jujuc.NetworkInfo{
+		BindAddress:      "10.0.0.1",
+		IngressAddresses: []string{"10.0.0.1", "54.0.0.1"},
+	})
+}
+
 type HookContextSuite struct {
 	testing.JujuConnSuite
 	service  *state.Service
@@ -18,3 +18,5 @@ func (u *Uniter) GetProxyValues() proxy.Settings {
 }
 
 var MergeEnvironment = mergeEnvironment
+
+var MaxHookLogMessages = &maxHookLogMessages
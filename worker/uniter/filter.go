@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"sort"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/charm/hooks"
@@ -16,6 +17,7 @@ import (
 	"github.com/juju/juju/state/api/uniter"
 	apiwatcher "github.com/juju/juju/state/api/watcher"
 	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/uniter/hook"
 )
@@ -26,8 +28,9 @@ var filterLogger = loggo.GetLogger("juju.worker.uniter.filter")
 // state watchers, and presents it as events on channels designed specifically
 // for the convenience of the uniter.
 type filter struct {
-	st   *uniter.State
-	tomb tomb.Tomb
+	st    *uniter.State
+	tomb  tomb.Tomb
+	clock clock.Clock
 
 	// outUnitDying is closed when the unit's life becomes Dying.
 	outUnitDying chan struct{}
@@ -46,12 +49,19 @@ type filter struct {
 	outResolvedOn  chan params.ResolvedMode
 	outRelations   chan []int
 	outRelationsOn chan []int
+	outRetry       chan struct{}
+	outRetryOn     chan struct{}
 
 	// The want* chans are used to indicate that the filter should send
 	// events if it has them available.
 	wantForcedUpgrade chan bool
 	wantResolved      chan struct{}
 
+	// wantRetry is used to request a retry event after a delay
+	// measured by the filter's clock, or, if the delay is negative,
+	// to cancel a pending one.
+	wantRetry chan time.Duration
+
 	// discardConfig is used to indicate that any pending config event
 	// should be discarded.
 	discardConfig chan struct{}
@@ -95,10 +105,12 @@ type filter struct {
 }
 
 // newFilter returns a filter that handles state changes pertaining to the
-// supplied unit.
-func newFilter(st *uniter.State, unitTag string) (*filter, error) {
+// supplied unit, measuring the delays before retry events with the
+// given clock.
+func newFilter(st *uniter.State, unitTag string, clock clock.Clock) (*filter, error) {
 	f := &filter{
 		st:                st,
+		clock:             clock,
 		outUnitDying:      make(chan struct{}),
 		outConfig:         make(chan struct{}),
 		outConfigOn:       make(chan struct{}),
@@ -110,8 +122,11 @@ func newFilter(st *uniter.State, unitTag string) (*filter, error) {
 		outResolvedOn:     make(chan params.ResolvedMode),
 		outRelations:      make(chan []int),
 		outRelationsOn:    make(chan []int),
+		outRetry:          make(chan struct{}),
+		outRetryOn:        make(chan struct{}),
 		wantForcedUpgrade: make(chan bool),
 		wantResolved:      make(chan struct{}),
+		wantRetry:         make(chan time.Duration),
 		discardConfig:     make(chan struct{}),
 		setCharm:          make(chan *charm.URL),
 		didSetCharm:       make(chan struct{}),
@@ -178,6 +193,12 @@ func (f *filter) RelationsEvents() <-chan []int {
 	return f.outRelationsOn
 }
 
+// RetryEvents returns a channel that will receive a signal when a retry
+// requested by WantRetryEvent is due.
+func (f *filter) RetryEvents() <-chan struct{} {
+	return f.outRetryOn
+}
+
 // WantUpgradeEvent controls whether the filter will generate upgrade
 // events for unforced service charm changes.
 func (f *filter) WantUpgradeEvent(mustForce bool) {
@@ -224,6 +245,16 @@ func (f *filter) WantResolvedEvent() {
 	}
 }
 
+// WantRetryEvent indicates that the filter should send a retry event
+// once the given delay has passed, replacing any retry event already
+// requested. A negative delay cancels any pending retry event.
+func (f *filter) WantRetryEvent(delay time.Duration) {
+	select {
+	case <-f.tomb.Dying():
+	case f.wantRetry <- delay:
+	}
+}
+
 // ClearResolved notifies the filter that a resolved event has been handled
 // and should not be reported again.
 func (f *filter) ClearResolved() error {
@@ -341,6 +372,7 @@ func (f *filter) loop(unitTag string) (err error) {
 	// once we receive the initial change, we unblock discard requests by
 	// setting this channel to its namesake on f.
 	var discardConfig chan struct{}
+	var retryDue <-chan time.Time
 	for {
 		var ok bool
 		select {
@@ -433,6 +465,13 @@ func (f *filter) loop(unitTag string) (err error) {
 			filterLogger.Debugf("sent relations event")
 			f.outRelations = nil
 			f.relations = nil
+		case <-retryDue:
+			filterLogger.Debugf("preparing retry event")
+			retryDue = nil
+			f.outRetry = f.outRetryOn
+		case f.outRetry <- nothing:
+			filterLogger.Debugf("sent retry event")
+			f.outRetry = nil
 
 		// Handle explicit requests.
 		case curl := <-f.setCharm:
@@ -479,6 +518,15 @@ func (f *filter) loop(unitTag string) (err error) {
 			if err = f.upgradeChanged(); err != nil {
 				return err
 			}
+		case delay := <-f.wantRetry:
+			f.outRetry = nil
+			if delay < 0 {
+				filterLogger.Debugf("retry event cancelled")
+				retryDue = nil
+			} else {
+				filterLogger.Debugf("want retry event in %v", delay)
+				retryDue = f.clock.After(delay)
+			}
 		case <-f.wantResolved:
 			filterLogger.Debugf("want resolved event")
 			if f.resolved != params.ResolvedNone {
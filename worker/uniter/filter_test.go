@@ -34,12 +34,14 @@ type FilterSuite struct {
 
 	st     *api.State
 	uniter *apiuniter.State
+	clock  *coretesting.Clock
 }
 
 var _ = gc.Suite(&FilterSuite{})
 
 func (s *FilterSuite) SetUpTest(c *gc.C) {
 	s.JujuConnSuite.SetUpTest(c)
+	s.clock = coretesting.NewClock(time.Now())
 	s.wpcharm = s.AddTestingCharm(c, "wordpress")
 	s.wordpress = s.AddTestingService(c, "wordpress", s.wpcharm)
 	var err error
@@ -67,7 +69,7 @@ func (s *FilterSuite) APILogin(c *gc.C, unit *state.Unit) {
 }
 
 func (s *FilterSuite) TestUnitDeath(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer f.Stop() // no AssertStop, we test for an error below
 	asserter := coretesting.NotifyAsserterC{
@@ -101,7 +103,7 @@ func (s *FilterSuite) TestUnitDeath(c *gc.C) {
 }
 
 func (s *FilterSuite) TestUnitRemoval(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer f.Stop() // no AssertStop, we test for an error below
 
@@ -127,7 +129,7 @@ func (s *FilterSuite) assertAgentTerminates(c *gc.C, f *filter) {
 }
 
 func (s *FilterSuite) TestServiceDeath(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 	dyingAsserter := coretesting.NotifyAsserterC{
@@ -162,7 +164,7 @@ loop:
 }
 
 func (s *FilterSuite) TestResolvedEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -224,7 +226,7 @@ func (s *FilterSuite) TestCharmUpgradeEvents(c *gc.C) {
 
 	s.APILogin(c, unit)
 
-	f, err := newFilter(s.uniter, unit.Tag().String())
+	f, err := newFilter(s.uniter, unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -290,7 +292,7 @@ func (s *FilterSuite) TestCharmUpgradeEvents(c *gc.C) {
 }
 
 func (s *FilterSuite) TestConfigEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -298,30 +300,13 @@ func (s *FilterSuite) TestConfigEvents(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	// Test no changes before the charm URL is set.
-	assertNoChange := func() {
-		s.BackingState.StartSync()
-		select {
-		case <-f.ConfigEvents():
-			c.Fatalf("unexpected config event")
-		case <-time.After(coretesting.ShortWait):
-		}
-	}
-	assertNoChange()
+	wc := statetesting.NewNotifyChanC(c, s.BackingState, f.ConfigEvents())
+	wc.AssertNoChange()
 
 	// Set the charm URL to trigger config events.
 	err = f.SetCharm(s.wpcharm.URL())
 	c.Assert(err, gc.IsNil)
-	assertChange := func() {
-		s.BackingState.StartSync()
-		select {
-		case _, ok := <-f.ConfigEvents():
-			c.Assert(ok, gc.Equals, true)
-		case <-time.After(coretesting.LongWait):
-			c.Fatalf("timed out")
-		}
-		assertNoChange()
-	}
-	assertChange()
+	wc.AssertOneChange()
 
 	// Change the config; new event received.
 	changeConfig := func(title interface{}) {
@@ -331,7 +316,7 @@ func (s *FilterSuite) TestConfigEvents(c *gc.C) {
 		c.Assert(err, gc.IsNil)
 	}
 	changeConfig("20,000 leagues in the cloud")
-	assertChange()
+	wc.AssertOneChange()
 
 	// Change the config a few more times, then reset the events. We sync to
 	// make sure the events have arrived in the watcher -- and then wait a
@@ -344,31 +329,31 @@ func (s *FilterSuite) TestConfigEvents(c *gc.C) {
 	s.BackingState.StartSync()
 	time.Sleep(250 * time.Millisecond)
 	f.DiscardConfigEvent()
-	assertNoChange()
+	wc.AssertNoChange()
 
 	// Change the addresses of the unit's assigned machine; new event received.
 	err = s.machine.SetAddresses(network.NewAddress("0.1.2.4", network.ScopeUnknown))
 	c.Assert(err, gc.IsNil)
-	s.BackingState.StartSync()
-	assertChange()
+	wc.AssertOneChange()
 
 	// Check that a filter's initial event works with DiscardConfigEvent
 	// as expected.
-	f, err = newFilter(s.uniter, s.unit.Tag().String())
+	f, err = newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
+	wc = statetesting.NewNotifyChanC(c, s.BackingState, f.ConfigEvents())
 	s.BackingState.StartSync()
 	f.DiscardConfigEvent()
-	assertNoChange()
+	wc.AssertNoChange()
 
 	// Further changes are still collapsed as appropriate.
 	changeConfig("forsooth")
 	changeConfig("imagination failure")
-	assertChange()
+	wc.AssertOneChange()
 }
 
 func (s *FilterSuite) TestInitialAddressEventIgnored(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -380,34 +365,19 @@ func (s *FilterSuite) TestInitialAddressEventIgnored(c *gc.C) {
 
 	// We should not get any config-change events until
 	// setting the charm URL.
-	s.BackingState.StartSync()
-	select {
-	case <-f.ConfigEvents():
-		c.Fatalf("unexpected config event")
-	case <-time.After(coretesting.ShortWait):
-	}
+	wc := statetesting.NewNotifyChanC(c, s.BackingState, f.ConfigEvents())
+	wc.AssertNoChange()
 
 	// Set the charm URL to trigger config events.
 	err = f.SetCharm(s.wpcharm.URL())
 	c.Assert(err, gc.IsNil)
 
 	// We should get one config-change event only.
-	s.BackingState.StartSync()
-	select {
-	case _, ok := <-f.ConfigEvents():
-		c.Assert(ok, gc.Equals, true)
-	case <-time.After(coretesting.LongWait):
-		c.Fatalf("timed out")
-	}
-	select {
-	case <-f.ConfigEvents():
-		c.Fatalf("unexpected config event")
-	case <-time.After(coretesting.ShortWait):
-	}
+	wc.AssertOneChange()
 }
 
 func (s *FilterSuite) TestConfigAndAddressEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -422,30 +392,17 @@ func (s *FilterSuite) TestConfigAndAddressEvents(c *gc.C) {
 	)
 	c.Assert(err, gc.IsNil)
 
-	assertChange := func() {
-		select {
-		case _, ok := <-f.ConfigEvents():
-			c.Assert(ok, gc.Equals, true)
-		case <-time.After(coretesting.LongWait):
-			c.Fatalf("timed out")
-		}
-	}
-
 	// Config and address events should be coalesced. Start
 	// the synchronisation and sleep a bit to give the filter
 	// a chance to pick them both up.
 	s.BackingState.StartSync()
 	time.Sleep(250 * time.Millisecond)
-	assertChange()
-	select {
-	case <-f.ConfigEvents():
-		c.Fatalf("unexpected config event")
-	case <-time.After(coretesting.ShortWait):
-	}
+	wc := statetesting.NewNotifyChanC(c, s.BackingState, f.ConfigEvents())
+	wc.AssertOneChange()
 }
 
 func (s *FilterSuite) TestConfigAndAddressEventsDiscarded(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -462,11 +419,8 @@ func (s *FilterSuite) TestConfigAndAddressEventsDiscarded(c *gc.C) {
 	// We should not receive any config-change events.
 	s.BackingState.StartSync()
 	f.DiscardConfigEvent()
-	select {
-	case <-f.ConfigEvents():
-		c.Fatalf("unexpected config event")
-	case <-time.After(coretesting.ShortWait):
-	}
+	wc := statetesting.NewNotifyChanC(c, s.BackingState, f.ConfigEvents())
+	wc.AssertNoChange()
 }
 
 // TestActionEvent helper functions
@@ -512,7 +466,7 @@ func getAddAction(s *FilterSuite, c *gc.C) func(name string) string {
 }
 
 func (s *FilterSuite) TestActionEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -546,7 +500,7 @@ func (s *FilterSuite) TestPreexistingActions(c *gc.C) {
 	testId := addAction("snapshot")
 
 	// Now create the Filter and see whether the Action comes in as expected.
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -559,8 +513,65 @@ func (s *FilterSuite) TestPreexistingActions(c *gc.C) {
 	assertNoChange()
 }
 
+func (s *FilterSuite) TestRetryEvents(c *gc.C) {
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, f)
+
+	assertNoChange := func() {
+		select {
+		case <-f.RetryEvents():
+			c.Fatalf("unexpected retry event")
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	assertChange := func() {
+		select {
+		case <-f.RetryEvents():
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for retry event")
+		}
+	}
+	waitAlarm := func() {
+		select {
+		case <-s.clock.Alarms():
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("filter did not wait on the clock")
+		}
+	}
+
+	// No retry events are sent until one is requested.
+	assertNoChange()
+
+	// A requested event is sent once the delay has passed.
+	f.WantRetryEvent(time.Minute)
+	waitAlarm()
+	s.clock.Advance(time.Minute - time.Second)
+	assertNoChange()
+	s.clock.Advance(time.Second)
+	assertChange()
+	assertNoChange()
+
+	// A new request replaces the pending one.
+	f.WantRetryEvent(time.Minute)
+	waitAlarm()
+	f.WantRetryEvent(time.Hour)
+	waitAlarm()
+	s.clock.Advance(time.Minute)
+	assertNoChange()
+	s.clock.Advance(time.Hour)
+	assertChange()
+
+	// A pending event can be cancelled.
+	f.WantRetryEvent(time.Minute)
+	waitAlarm()
+	f.WantRetryEvent(-1)
+	s.clock.Advance(time.Minute)
+	assertNoChange()
+}
+
 func (s *FilterSuite) TestCharmErrorEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer f.Stop() // no AssertStop, we test for an error below
 
@@ -580,7 +591,7 @@ func (s *FilterSuite) TestCharmErrorEvents(c *gc.C) {
 	s.assertFilterDies(c, f)
 
 	// Filter died after the error, so restart it.
-	f, err = newFilter(s.uniter, s.unit.Tag().String())
+	f, err = newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer f.Stop() // no AssertStop, we test for an error below
 
@@ -592,7 +603,7 @@ func (s *FilterSuite) TestCharmErrorEvents(c *gc.C) {
 }
 
 func (s *FilterSuite) TestRelationsEvents(c *gc.C) {
-	f, err := newFilter(s.uniter, s.unit.Tag().String())
+	f, err := newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 
@@ -642,7 +653,7 @@ func (s *FilterSuite) TestRelationsEvents(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 
 	// Start a new filter, check initial event.
-	f, err = newFilter(s.uniter, s.unit.Tag().String())
+	f, err = newFilter(s.uniter, s.unit.Tag().String(), s.clock)
 	c.Assert(err, gc.IsNil)
 	defer statetesting.AssertStop(c, f)
 	assertChange([]int{0, 2})
@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/cmd"
+)
+
+// Metric holds a single metric reported by a charm.
+type Metric struct {
+	Key   string
+	Value string
+	Time  time.Time
+}
+
+// AddMetricCommand implements the add-metric command.
+type AddMetricCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Metrics []Metric
+}
+
+func NewAddMetricCommand(ctx Context) cmd.Command {
+	return &AddMetricCommand{ctx: ctx}
+}
+
+func (c *AddMetricCommand) Info() *cmd.Info {
+	doc := `
+Records metrics about the unit's workload, which are sent to the state server
+when the hook completes successfully. Each value must be a number; for example:
+
+    add-metric requests=42 queue.length=3
+`
+	return &cmd.Info{
+		Name:    "add-metric",
+		Args:    "<key>=<value> [<key>=<value> ...]",
+		Purpose: "record metrics about the unit's workload",
+		Doc:     doc,
+	}
+}
+
+func (c *AddMetricCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no metrics specified")
+	}
+	now := time.Now()
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf(`expected "key=value", got %q`, arg)
+		}
+		if _, err := strconv.ParseFloat(parts[1], 64); err != nil {
+			return fmt.Errorf("invalid value %q for metric %q", parts[1], parts[0])
+		}
+		c.Metrics = append(c.Metrics, Metric{Key: parts[0], Value: parts[1], Time: now})
+	}
+	return nil
+}
+
+func (c *AddMetricCommand) Run(ctx *cmd.Context) error {
+	for _, metric := range c.Metrics {
+		if err := c.ctx.AddMetric(metric.Key, metric.Value, metric.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+type AddMetricSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&AddMetricSuite{})
+
+func (s *AddMetricSuite) TestAddMetric(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "add-metric")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"requests=42", "queue.length=0.5"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.metrics, gc.HasLen, 2)
+	c.Assert(hctx.metrics[0].Key, gc.Equals, "requests")
+	c.Assert(hctx.metrics[0].Value, gc.Equals, "42")
+	c.Assert(hctx.metrics[1].Key, gc.Equals, "queue.length")
+	c.Assert(hctx.metrics[1].Value, gc.Equals, "0.5")
+	c.Assert(hctx.metrics[0].Time.IsZero(), gc.Equals, false)
+}
+
+var badAddMetricTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no metrics specified"},
+	{[]string{"requests"}, `expected "key=value", got "requests"`},
+	{[]string{"=42"}, `expected "key=value", got "=42"`},
+	{[]string{"requests=lots"}, `invalid value "lots" for metric "requests"`},
+}
+
+func (s *AddMetricSuite) TestBadArgs(c *gc.C) {
+	for i, t := range badAddMetricTests {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		com, err := jujuc.NewCommand(hctx, "add-metric")
+		c.Assert(err, gc.IsNil)
+		err = testing.InitCommand(com, t.args)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
@@ -142,6 +142,10 @@ options:
 When no <key> is supplied, all keys with values or defaults are printed. If
 --all is set, all known keys are printed; those without defaults or values are
 reported as null. <key> and --all are mutually exclusive.
+
+Values are typed according to the charm's config.yaml, so that integer and
+boolean options are printed as numbers and booleans rather than strings; use
+--format json or --format yaml for output that can be parsed reliably.
 `)
 	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
 }
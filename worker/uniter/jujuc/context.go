@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/charm"
+	"github.com/juju/loggo"
 
 	"github.com/juju/juju/state/api/params"
 )
@@ -26,18 +28,35 @@ type Context interface {
 	// PrivateAddress returns the executing unit's private address.
 	PrivateAddress() (string, bool)
 
-	// OpenPort marks the supplied port for opening when the executing unit's
-	// service is exposed.
-	OpenPort(protocol string, port int) error
+	// WriteLog writes a message from the charm to the unit's log at
+	// the given level.
+	WriteLog(level loggo.Level, message string)
 
-	// ClosePort ensures the supplied port is closed even when the executing
-	// unit's service is exposed (unless it is opened separately by a co-
-	// located unit).
-	ClosePort(protocol string, port int) error
+	// NetworkInfo returns the addresses the executing unit should use
+	// for the named charm endpoint.
+	NetworkInfo(endpoint string) (NetworkInfo, error)
+
+	// OpenPorts marks the supplied port range for opening when the
+	// executing unit's service is exposed. ICMP has no ports, and is
+	// opened with both port numbers set to -1.
+	OpenPorts(protocol string, fromPort, toPort int) error
+
+	// ClosePorts ensures the supplied port range is closed even when
+	// the executing unit's service is exposed (unless it is opened
+	// separately by a co-located unit).
+	ClosePorts(protocol string, fromPort, toPort int) error
 
 	// Config returns the current service configuration of the executing unit.
 	ConfigSettings() (charm.Settings, error)
 
+	// SetWorkloadStatus records the status of the executing unit's
+	// workload, along with an explanatory message.
+	SetWorkloadStatus(status params.WorkloadStatus, message string) error
+
+	// AddMetric records a metric about the executing unit's workload,
+	// to be sent when the hook completes successfully.
+	AddMetric(key, value string, created time.Time) error
+
 	// HookRelation returns the ContextRelation associated with the executing
 	// hook if it was found, and whether it was found.
 	HookRelation() (ContextRelation, bool)
@@ -84,6 +103,21 @@ type ContextRelation interface {
 	ReadSettings(unit string) (params.RelationSettings, error)
 }
 
+// NetworkInfo holds the addresses relevant to a single charm endpoint.
+type NetworkInfo struct {
+	// BindAddress holds the address on which the unit should listen
+	// for connections to the endpoint.
+	BindAddress string
+
+	// IngressAddresses holds the addresses that other units should
+	// use to connect to the endpoint, preferred address first.
+	IngressAddresses []string
+
+	// EgressSubnets holds the subnets, in CIDR notation, from which
+	// connections made by the unit over the endpoint will originate.
+	EgressSubnets []string
+}
+
 // Settings is implemented by types that manipulate unit settings.
 type Settings interface {
 	Map() params.RelationSettings
@@ -54,8 +54,6 @@ func (c *JujuLogCommand) Run(ctx *cmd.Context) error {
 	if c.formatFlag != "" {
 		fmt.Fprintf(ctx.Stderr, "--format flag deprecated for command %q", c.Info().Name)
 	}
-	logger := loggo.GetLogger(fmt.Sprintf("unit.%s.juju-log", c.ctx.UnitName()))
-
 	logLevel := loggo.INFO
 	if c.Debug {
 		logLevel = loggo.DEBUG
@@ -63,6 +61,7 @@ func (c *JujuLogCommand) Run(ctx *cmd.Context) error {
 		var ok bool
 		logLevel, ok = loggo.ParseLevel(c.Level)
 		if !ok {
+			logger := loggo.GetLogger(fmt.Sprintf("unit.%s.juju-log", c.ctx.UnitName()))
 			logger.Warningf("Specified log level of %q is not valid", c.Level)
 			logLevel = loggo.INFO
 		}
@@ -73,6 +72,6 @@ func (c *JujuLogCommand) Run(ctx *cmd.Context) error {
 		prefix = r.FakeId() + ": "
 	}
 
-	logger.Logf(logLevel, "%s%s", prefix, c.Message)
+	c.ctx.WriteLog(logLevel, prefix+c.Message)
 	return nil
 }
@@ -0,0 +1,92 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juju/cmd"
+	"launchpad.net/gnuflag"
+)
+
+// NetworkGetCommand implements the network-get command.
+type NetworkGetCommand struct {
+	cmd.CommandBase
+	ctx      Context
+	Endpoint string
+	Key      string // The key to show. If empty, show all.
+	out      cmd.Output
+
+	bindAddress      bool
+	ingressAddresses bool
+	egressSubnets    bool
+}
+
+func NewNetworkGetCommand(ctx Context) cmd.Command {
+	return &NetworkGetCommand{ctx: ctx}
+}
+
+func (c *NetworkGetCommand) Info() *cmd.Info {
+	doc := `
+network-get prints the addresses the unit should use for the named endpoint:
+the bind-address on which the unit should listen, the ingress-addresses by
+which other units can reach it, and the egress-subnets from which its own
+connections originate. When one of --bind-address, --ingress-addresses or
+--egress-subnets is given, only that value is printed.
+`
+	return &cmd.Info{
+		Name:    "network-get",
+		Args:    "<endpoint>",
+		Purpose: "print network addresses for an endpoint",
+		Doc:     doc,
+	}
+}
+
+func (c *NetworkGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	f.BoolVar(&c.bindAddress, "bind-address", false, "print only the bind address")
+	f.BoolVar(&c.ingressAddresses, "ingress-addresses", false, "print only the ingress addresses")
+	f.BoolVar(&c.egressSubnets, "egress-subnets", false, "print only the egress subnets")
+}
+
+func (c *NetworkGetCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no endpoint specified")
+	}
+	c.Endpoint = args[0]
+	for _, key := range []struct {
+		set  bool
+		name string
+	}{
+		{c.bindAddress, "bind-address"},
+		{c.ingressAddresses, "ingress-addresses"},
+		{c.egressSubnets, "egress-subnets"},
+	} {
+		if !key.set {
+			continue
+		}
+		if c.Key != "" {
+			return fmt.Errorf("cannot print both --%s and --%s", c.Key, key.name)
+		}
+		c.Key = key.name
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *NetworkGetCommand) Run(ctx *cmd.Context) error {
+	info, err := c.ctx.NetworkInfo(c.Endpoint)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{
+		"bind-address":      info.BindAddress,
+		"ingress-addresses": info.IngressAddresses,
+		"egress-subnets":    info.EgressSubnets,
+	}
+	if c.Key != "" {
+		return c.out.Write(ctx, values[c.Key])
+	}
+	return c.out.Write(ctx, values)
+}
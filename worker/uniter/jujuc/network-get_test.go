@@ -0,0 +1,122 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+type NetworkGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&NetworkGetSuite{})
+
+var networkGetTests = []struct {
+	args []string
+	out  string
+}{{
+	args: []string{"peer0"},
+	out: `bind-address: 192.168.0.99
+egress-subnets:
+- 192.168.0.99/32
+ingress-addresses:
+- 192.168.0.99
+- gimli.minecraft.testing.invalid
+`,
+}, {
+	args: []string{"peer0", "--format", "json"},
+	out: `{"bind-address":"192.168.0.99","egress-subnets":["192.168.0.99/32"],` +
+		`"ingress-addresses":["192.168.0.99","gimli.minecraft.testing.invalid"]}` + "\n",
+}, {
+	args: []string{"peer0", "--bind-address"},
+	out:  "192.168.0.99\n",
+}, {
+	args: []string{"--ingress-addresses", "peer1"},
+	out:  "192.168.0.99\ngimli.minecraft.testing.invalid\n",
+}, {
+	args: []string{"peer1", "--egress-subnets", "--format", "json"},
+	out:  `["192.168.0.99/32"]` + "\n",
+}}
+
+func (s *NetworkGetSuite) createCommand(c *gc.C) cmd.Command {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "network-get")
+	c.Assert(err, gc.IsNil)
+	return com
+}
+
+func (s *NetworkGetSuite) TestOutputFormat(c *gc.C) {
+	for i, t := range networkGetTests {
+		c.Logf("test %d: %v", i, t.args)
+		com := s.createCommand(c)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *NetworkGetSuite) TestUnknownEndpoint(c *gc.C) {
+	com := s.createCommand(c)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"db"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: unknown endpoint \"db\"\n")
+}
+
+func (s *NetworkGetSuite) TestBadArgs(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: nil,
+		err:  "no endpoint specified",
+	}, {
+		args: []string{"peer0", "peer1"},
+		err:  `unrecognized args: \["peer1"\]`,
+	}, {
+		args: []string{"peer0", "--bind-address", "--egress-subnets"},
+		err:  "cannot print both --bind-address and --egress-subnets",
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		com := s.createCommand(c)
+		err := testing.InitCommand(com, t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *NetworkGetSuite) TestHelp(c *gc.C) {
+	com := s.createCommand(c)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"--help"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, `usage: network-get [options] <endpoint>
+purpose: print network addresses for an endpoint
+
+options:
+--bind-address  (= false)
+    print only the bind address
+--egress-subnets  (= false)
+    print only the egress subnets
+--format  (= smart)
+    specify output format (json|smart|yaml)
+--ingress-addresses  (= false)
+    print only the ingress addresses
+-o, --output (= "")
+    specify an output file
+
+network-get prints the addresses the unit should use for the named endpoint:
+the bind-address on which the unit should listen, the ingress-addresses by
+which other units can reach it, and the egress-subnets from which its own
+connections originate. When one of --bind-address, --ingress-addresses or
+--egress-subnets is given, only that value is printed.
+`)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+}
@@ -6,22 +6,21 @@ package jujuc
 import (
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/juju/cmd"
 	"launchpad.net/gnuflag"
+
+	"github.com/juju/juju/network"
 )
 
-const portFormat = "<port>[/<protocol>]"
+const portFormat = "<port>[-<port>][/<protocol>] | icmp"
 
 // portCommand implements the open-port and close-port commands.
 type portCommand struct {
 	cmd.CommandBase
 	info       *cmd.Info
 	action     func(*portCommand) error
-	Protocol   string
-	Port       int
+	Ports      network.PortRange
 	formatFlag string // deprecated
 }
 
@@ -29,10 +28,6 @@ func (c *portCommand) Info() *cmd.Info {
 	return c.info
 }
 
-func badPort(value interface{}) error {
-	return fmt.Errorf(`port must be in the range [1, 65535]; got "%v"`, value)
-}
-
 func (c *portCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.formatFlag, "format", "", "deprecated format flag")
 }
@@ -41,26 +36,11 @@ func (c *portCommand) Init(args []string) error {
 	if args == nil {
 		return errors.New("no port specified")
 	}
-	parts := strings.Split(args[0], "/")
-	if len(parts) > 2 {
-		return fmt.Errorf("expected %s; got %q", portFormat, args[0])
-	}
-	port, err := strconv.Atoi(parts[0])
+	ports, err := network.ParsePortRange(args[0])
 	if err != nil {
-		return badPort(parts[0])
-	}
-	if port < 1 || port > 65535 {
-		return badPort(port)
-	}
-	protocol := "tcp"
-	if len(parts) == 2 {
-		protocol = strings.ToLower(parts[1])
-		if protocol != "tcp" && protocol != "udp" {
-			return fmt.Errorf(`protocol must be "tcp" or "udp"; got %q`, protocol)
-		}
+		return err
 	}
-	c.Port = port
-	c.Protocol = protocol
+	c.Ports = ports
 	return cmd.CheckEmpty(args[1:])
 }
 
@@ -74,15 +54,19 @@ func (c *portCommand) Run(ctx *cmd.Context) error {
 var openPortInfo = &cmd.Info{
 	Name:    "open-port",
 	Args:    portFormat,
-	Purpose: "register a port to open",
-	Doc:     "The port will only be open while the service is exposed.",
+	Purpose: "register a port or range to open",
+	Doc: `
+The port will only be open while the service is exposed. A range of ports
+may be given as <from>-<to>, and the protocol may be tcp (the default), udp,
+or icmp, which takes no port. A range must not overlap any port range
+already opened on the same machine.`[1:],
 }
 
 func NewOpenPortCommand(ctx Context) cmd.Command {
 	return &portCommand{
 		info: openPortInfo,
 		action: func(c *portCommand) error {
-			return ctx.OpenPort(c.Protocol, c.Port)
+			return ctx.OpenPorts(c.Ports.Protocol, c.Ports.FromPort, c.Ports.ToPort)
 		},
 	}
 }
@@ -90,14 +74,14 @@ func NewOpenPortCommand(ctx Context) cmd.Command {
 var closePortInfo = &cmd.Info{
 	Name:    "close-port",
 	Args:    portFormat,
-	Purpose: "ensure a port is always closed",
+	Purpose: "ensure a port or range is always closed",
 }
 
 func NewClosePortCommand(ctx Context) cmd.Command {
 	return &portCommand{
 		info: closePortInfo,
 		action: func(c *portCommand) error {
-			return ctx.ClosePort(c.Protocol, c.Port)
+			return ctx.ClosePorts(c.Ports.Protocol, c.Ports.FromPort, c.Ports.ToPort)
 		},
 	}
 }
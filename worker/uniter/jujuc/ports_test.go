@@ -27,6 +27,10 @@ var portsTests = []struct {
 	{[]string{"close-port", "80/TCP"}, set.NewStrings("99/tcp")},
 	{[]string{"open-port", "123/udp"}, set.NewStrings("99/tcp", "123/udp")},
 	{[]string{"close-port", "9999/UDP"}, set.NewStrings("99/tcp", "123/udp")},
+	{[]string{"open-port", "8000-8100/tcp"}, set.NewStrings("99/tcp", "123/udp", "8000-8100/tcp")},
+	{[]string{"open-port", "icmp"}, set.NewStrings("99/tcp", "123/udp", "8000-8100/tcp", "icmp")},
+	{[]string{"close-port", "8000-8100/TCP"}, set.NewStrings("99/tcp", "123/udp", "icmp")},
+	{[]string{"close-port", "ICMP"}, set.NewStrings("99/tcp", "123/udp")},
 }
 
 func (s *PortsSuite) TestOpenClose(c *gc.C) {
@@ -48,11 +52,14 @@ var badPortsTests = []struct {
 	err  string
 }{
 	{nil, "no port specified"},
-	{[]string{"0"}, `port must be in the range \[1, 65535\]; got "0"`},
-	{[]string{"65536"}, `port must be in the range \[1, 65535\]; got "65536"`},
-	{[]string{"two"}, `port must be in the range \[1, 65535\]; got "two"`},
-	{[]string{"80/http"}, `protocol must be "tcp" or "udp"; got "http"`},
-	{[]string{"blah/blah/blah"}, `expected <port>\[/<protocol>\]; got "blah/blah/blah"`},
+	{[]string{"0"}, `port must be in the range \[1, 65535\]; got 0`},
+	{[]string{"65536"}, `port must be in the range \[1, 65535\]; got 65536`},
+	{[]string{"two"}, `invalid port "two"`},
+	{[]string{"80/http"}, `protocol must be "tcp", "udp" or "icmp"; got "http"`},
+	{[]string{"blah/blah/blah"}, `invalid port "blah"`},
+	{[]string{"90-80"}, `invalid port range 90-80`},
+	{[]string{"80-"}, `invalid port ""`},
+	{[]string{"80/icmp"}, `icmp port range must not specify ports`},
 	{[]string{"123", "haha"}, `unrecognized args: \["haha"\]`},
 }
 
@@ -74,17 +81,20 @@ func (s *PortsSuite) TestHelp(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	flags := testing.NewFlagSet()
 	c.Assert(string(open.Info().Help(flags)), gc.Equals, `
-usage: open-port <port>[/<protocol>]
-purpose: register a port to open
+usage: open-port <port>[-<port>][/<protocol>] | icmp
+purpose: register a port or range to open
 
-The port will only be open while the service is exposed.
+The port will only be open while the service is exposed. A range of ports
+may be given as <from>-<to>, and the protocol may be tcp (the default), udp,
+or icmp, which takes no port. A range must not overlap any port range
+already opened on the same machine.
 `[1:])
 
 	close, err := jujuc.NewCommand(hctx, "close-port")
 	c.Assert(err, gc.IsNil)
 	c.Assert(string(close.Info().Help(flags)), gc.Equals, `
-usage: close-port <port>[/<protocol>]
-purpose: ensure a port is always closed
+usage: close-port <port>[-<port>][/<protocol>] | icmp
+purpose: ensure a port or range is always closed
 `[1:])
 }
 
@@ -60,6 +60,9 @@ func (c *RelationSetCommand) Run(ctx *cmd.Context) (err error) {
 		return fmt.Errorf("unknown relation id")
 	}
 	settings, err := r.Settings()
+	if err != nil {
+		return err
+	}
 	for k, v := range c.Settings {
 		if v != "" {
 			settings.Set(k, v)
@@ -191,14 +191,17 @@ var newCommandTests = []struct {
 	name string
 	err  string
 }{
+	{"add-metric", ""},
 	{"close-port", ""},
 	{"config-get", ""},
 	{"juju-log", ""},
+	{"network-get", ""},
 	{"open-port", ""},
 	{"relation-get", ""},
 	{"relation-ids", ""},
 	{"relation-list", ""},
 	{"relation-set", ""},
+	{"status-set", ""},
 	{"unit-get", ""},
 	{"random", "unknown command: random"},
 }
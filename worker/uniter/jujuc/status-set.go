@@ -0,0 +1,61 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juju/cmd"
+
+	"github.com/juju/juju/state/api/params"
+)
+
+// StatusSetCommand implements the status-set command.
+type StatusSetCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Status  params.WorkloadStatus
+	Message string
+}
+
+func NewStatusSetCommand(ctx Context) cmd.Command {
+	return &StatusSetCommand{ctx: ctx}
+}
+
+func (c *StatusSetCommand) Info() *cmd.Info {
+	doc := `
+Sets the workload status of the unit, which is reported alongside the status
+of the unit's agent. <status> must be one of maintenance, waiting, blocked or
+active. The optional <message> explains the status to the user; for example:
+
+    status-set blocked "waiting for db relation"
+`
+	return &cmd.Info{
+		Name:    "status-set",
+		Args:    "<status> [<message>]",
+		Purpose: "set the workload status of the unit",
+		Doc:     doc,
+	}
+}
+
+func (c *StatusSetCommand) Init(args []string) error {
+	if args == nil {
+		return errors.New("no status specified")
+	}
+	status := params.WorkloadStatus(args[0])
+	if !status.Valid() || status == params.WorkloadStatusUnknown {
+		return fmt.Errorf("invalid status %q", args[0])
+	}
+	c.Status = status
+	if len(args) > 1 {
+		c.Message = args[1]
+		return cmd.CheckEmpty(args[2:])
+	}
+	return nil
+}
+
+func (c *StatusSetCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.SetWorkloadStatus(c.Status, c.Message)
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"github.com/juju/cmd"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/state/api/params"
+	"github.com/juju/juju/testing"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+type StatusSetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&StatusSetSuite{})
+
+var statusSetTests = []struct {
+	args    []string
+	status  params.WorkloadStatus
+	message string
+}{
+	{[]string{"active"}, params.WorkloadStatusActive, ""},
+	{[]string{"maintenance", "installing packages"}, params.WorkloadStatusMaintenance, "installing packages"},
+	{[]string{"waiting", ""}, params.WorkloadStatusWaiting, ""},
+	{[]string{"blocked", "waiting for db relation"}, params.WorkloadStatusBlocked, "waiting for db relation"},
+}
+
+func (s *StatusSetSuite) TestStatusSet(c *gc.C) {
+	for i, t := range statusSetTests {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		com, err := jujuc.NewCommand(hctx, "status-set")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Assert(code, gc.Equals, 0)
+		c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+		c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Assert(hctx.workloadStatus, gc.Equals, t.status)
+		c.Assert(hctx.statusMessage, gc.Equals, t.message)
+	}
+}
+
+var badStatusSetTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no status specified"},
+	{[]string{"unknown"}, `invalid status "unknown"`},
+	{[]string{"started"}, `invalid status "started"`},
+	{[]string{"blocked", "no db", "extra"}, `unrecognized args: \["extra"\]`},
+}
+
+func (s *StatusSetSuite) TestBadArgs(c *gc.C) {
+	for i, t := range badStatusSetTests {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		com, err := jujuc.NewCommand(hctx, "status-set")
+		c.Assert(err, gc.IsNil)
+		err = testing.InitCommand(com, t.args)
+		c.Assert(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *StatusSetSuite) TestHelp(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "status-set")
+	c.Assert(err, gc.IsNil)
+	flags := testing.NewFlagSet()
+	c.Assert(string(com.Info().Help(flags)), gc.Equals, `
+usage: status-set <status> [<message>]
+purpose: set the workload status of the unit
+
+Sets the workload status of the unit, which is reported alongside the status
+of the unit's agent. <status> must be one of maintenance, waiting, blocked or
+active. The optional <message> explains the status to the user; for example:
+
+    status-set blocked "waiting for db relation"
+`[1:])
+}
@@ -0,0 +1,81 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/utils/symlink"
+
+	"github.com/juju/juju/juju/names"
+)
+
+// EnsureSymlinks makes dir contain a symbolic link to the jujud
+// executable within dir for each hook command, so that jujud can
+// dispatch on the name it was invoked with. Links already in place are
+// left alone. Links left by tools of another version are brought up to
+// date: a command linked to some other executable (such as the jujuc
+// of older tools) is relinked to jujud, and links to jujud or jujuc
+// for commands that no longer exist are removed.
+func EnsureSymlinks(dir string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("cannot initialize hook commands in %q: %v", dir, err)
+		}
+	}()
+	jujudPath := filepath.Join(dir, names.Jujud)
+	for _, name := range CommandNames() {
+		path := filepath.Join(dir, name)
+		if target, err := symlink.Read(path); err == nil {
+			if target == jujudPath {
+				continue
+			}
+			logger.Debugf("relinking hook command %q from %q", name, target)
+		}
+		// Replace copes with the link being missing, and with
+		// anything else being in its place.
+		if err := symlink.Replace(path, jujudPath); err != nil {
+			return err
+		}
+	}
+	return removeStaleSymlinks(dir)
+}
+
+// removeStaleSymlinks removes the links in dir to jujud or jujuc whose
+// names are not hook commands.
+func removeStaleSymlinks(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	dispatchers := map[string]bool{
+		filepath.Join(dir, names.Jujud): true,
+		filepath.Join(dir, names.Jujuc): true,
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.Mode()&os.ModeSymlink == 0 || newCommands[name] != nil {
+			continue
+		}
+		if name == names.Jujud || name == names.Jujuc || name == names.JujuRun {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		target, err := symlink.Read(path)
+		if err != nil {
+			return err
+		}
+		if !dispatchers[target] {
+			continue
+		}
+		logger.Debugf("removing stale hook command %q", name)
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
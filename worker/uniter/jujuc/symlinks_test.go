@@ -0,0 +1,113 @@
+// Copyright 2012, 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	jc "github.com/juju/testing/checkers"
+	"github.com/juju/utils/symlink"
+	gc "launchpad.net/gocheck"
+
+	"github.com/juju/juju/agent/tools"
+	"github.com/juju/juju/juju/names"
+	"github.com/juju/juju/version"
+	"github.com/juju/juju/worker/uniter/jujuc"
+)
+
+type SymlinksSuite struct {
+	toolsDir  string
+	jujudPath string
+}
+
+var _ = gc.Suite(&SymlinksSuite{})
+
+func (s *SymlinksSuite) SetUpTest(c *gc.C) {
+	dataDir := c.MkDir()
+	s.toolsDir = tools.SharedToolsDir(dataDir, version.Current)
+	err := os.MkdirAll(s.toolsDir, 0755)
+	c.Assert(err, gc.IsNil)
+	err = symlink.New(s.toolsDir, tools.ToolsDir(dataDir, "unit-u-123"))
+	c.Assert(err, gc.IsNil)
+	s.jujudPath = filepath.Join(s.toolsDir, names.Jujud)
+	err = ioutil.WriteFile(s.jujudPath, []byte("assume sane"), 0755)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *SymlinksSuite) assertLink(c *gc.C, path string) time.Time {
+	target, err := symlink.Read(path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(target, gc.Equals, s.jujudPath)
+	fi, err := os.Lstat(path)
+	c.Assert(err, gc.IsNil)
+	return fi.ModTime()
+}
+
+func (s *SymlinksSuite) TestEnsureSymlinks(c *gc.C) {
+	// Check that EnsureSymlinks writes appropriate symlinks.
+	err := jujuc.EnsureSymlinks(s.toolsDir)
+	c.Assert(err, gc.IsNil)
+	mtimes := map[string]time.Time{}
+	for _, name := range jujuc.CommandNames() {
+		tool := filepath.Join(s.toolsDir, name)
+		mtimes[tool] = s.assertLink(c, tool)
+	}
+
+	// Check that EnsureSymlinks doesn't overwrite things that don't need to be.
+	err = jujuc.EnsureSymlinks(s.toolsDir)
+	c.Assert(err, gc.IsNil)
+	for tool, mtime := range mtimes {
+		c.Assert(s.assertLink(c, tool), gc.Equals, mtime)
+	}
+}
+
+func (s *SymlinksSuite) TestEnsureSymlinksRelinksOtherVersions(c *gc.C) {
+	// Older tools linked hook commands to a separate jujuc
+	// executable, or shipped them as files.
+	jujucPath := filepath.Join(s.toolsDir, names.Jujuc)
+	err := symlink.New(jujucPath, filepath.Join(s.toolsDir, "relation-get"))
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(s.toolsDir, "config-get"), []byte("old tool"), 0755)
+	c.Assert(err, gc.IsNil)
+
+	err = jujuc.EnsureSymlinks(s.toolsDir)
+	c.Assert(err, gc.IsNil)
+	s.assertLink(c, filepath.Join(s.toolsDir, "relation-get"))
+	s.assertLink(c, filepath.Join(s.toolsDir, "config-get"))
+}
+
+func (s *SymlinksSuite) TestEnsureSymlinksRemovesStaleCommands(c *gc.C) {
+	stale := filepath.Join(s.toolsDir, "no-such-hook-tool")
+	err := symlink.New(s.jujudPath, stale)
+	c.Assert(err, gc.IsNil)
+	staleJujuc := filepath.Join(s.toolsDir, "old-hook-tool")
+	err = symlink.New(filepath.Join(s.toolsDir, names.Jujuc), staleJujuc)
+	c.Assert(err, gc.IsNil)
+	// Links to jujud that are not hook commands, and links to
+	// other executables, are left alone.
+	jujuRun := filepath.Join(s.toolsDir, names.JujuRun)
+	err = symlink.New(s.jujudPath, jujuRun)
+	c.Assert(err, gc.IsNil)
+	other := filepath.Join(s.toolsDir, "other")
+	err = symlink.New("/bin/true", other)
+	c.Assert(err, gc.IsNil)
+
+	err = jujuc.EnsureSymlinks(s.toolsDir)
+	c.Assert(err, gc.IsNil)
+	_, err = os.Lstat(stale)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+	_, err = os.Lstat(staleJujuc)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+	s.assertLink(c, jujuRun)
+	_, err = os.Lstat(other)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *SymlinksSuite) TestEnsureSymlinksBadDir(c *gc.C) {
+	err := jujuc.EnsureSymlinks(filepath.Join(c.MkDir(), "noexist"))
+	c.Assert(err, gc.ErrorMatches, "cannot initialize hook commands in .*: no such file or directory")
+}
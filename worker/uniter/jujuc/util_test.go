@@ -9,11 +9,14 @@ import (
 	"io"
 	"sort"
 	stdtesting "testing"
+	"time"
 
 	"github.com/juju/charm"
+	"github.com/juju/loggo"
 	"github.com/juju/utils/set"
 	gc "launchpad.net/gocheck"
 
+	"github.com/juju/juju/network"
 	"github.com/juju/juju/state"
 	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/testing"
@@ -79,10 +82,13 @@ func setSettings(c *gc.C, ru *state.RelationUnit, settings map[string]interface{
 }
 
 type Context struct {
-	ports  set.Strings
-	relid  int
-	remote string
-	rels   map[int]*ContextRelation
+	ports          set.Strings
+	relid          int
+	remote         string
+	rels           map[int]*ContextRelation
+	workloadStatus params.WorkloadStatus
+	statusMessage  string
+	metrics        []jujuc.Metric
 }
 
 func (c *Context) UnitName() string {
@@ -97,13 +103,30 @@ func (c *Context) PrivateAddress() (string, bool) {
 	return "192.168.0.99", true
 }
 
-func (c *Context) OpenPort(protocol string, port int) error {
-	c.ports.Add(fmt.Sprintf("%d/%s", port, protocol))
+func (c *Context) WriteLog(level loggo.Level, message string) {
+	loggo.GetLogger(fmt.Sprintf("unit.%s.juju-log", c.UnitName())).Logf(level, "%s", message)
+}
+
+func (c *Context) NetworkInfo(endpoint string) (jujuc.NetworkInfo, error) {
+	for _, r := range c.rels {
+		if r.name == endpoint {
+			return jujuc.NetworkInfo{
+				BindAddress:      "192.168.0.99",
+				IngressAddresses: []string{"192.168.0.99", "gimli.minecraft.testing.invalid"},
+				EgressSubnets:    []string{"192.168.0.99/32"},
+			}, nil
+		}
+	}
+	return jujuc.NetworkInfo{}, fmt.Errorf("unknown endpoint %q", endpoint)
+}
+
+func (c *Context) OpenPorts(protocol string, fromPort, toPort int) error {
+	c.ports.Add(network.PortRange{fromPort, toPort, protocol}.String())
 	return nil
 }
 
-func (c *Context) ClosePort(protocol string, port int) error {
-	c.ports.Remove(fmt.Sprintf("%d/%s", port, protocol))
+func (c *Context) ClosePorts(protocol string, fromPort, toPort int) error {
+	c.ports.Remove(network.PortRange{fromPort, toPort, protocol}.String())
 	return nil
 }
 
@@ -111,12 +134,23 @@ func (c *Context) ConfigSettings() (charm.Settings, error) {
 	return charm.Settings{
 		"empty":               nil,
 		"monsters":            false,
-		"spline-reticulation": 45.0,
+		"spline-reticulation": int64(45),
 		"title":               "My Title",
 		"username":            "admin001",
 	}, nil
 }
 
+func (c *Context) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	c.workloadStatus = status
+	c.statusMessage = message
+	return nil
+}
+
+func (c *Context) AddMetric(key, value string, created time.Time) error {
+	c.metrics = append(c.metrics, jujuc.Metric{key, value, created})
+	return nil
+}
+
 func (c *Context) HookRelation() (jujuc.ContextRelation, bool) {
 	return c.Relation(c.relid)
 }
@@ -6,6 +6,7 @@ package uniter
 import (
 	stderrors "errors"
 	"fmt"
+	"time"
 
 	"github.com/juju/charm"
 	"github.com/juju/charm/hooks"
@@ -40,6 +41,10 @@ func ModeContinue(u *Uniter) (next Mode, err error) {
 		} else if err != nil {
 			return nil, err
 		}
+		u.configVersion = configVersion{
+			charmURL: u.s.ConfigCharmURL,
+			version:  u.s.ConfigVersion,
+		}
 	}
 
 	// Filter out states not related to charm deployment.
@@ -57,7 +62,18 @@ func ModeContinue(u *Uniter) (next Mode, err error) {
 			}
 		}
 		if !u.ranConfigChanged {
-			return ModeConfigChanged, nil
+			// After a restart, only run config-changed if the
+			// settings have changed since it last completed.
+			version, err := u.currentConfigVersion()
+			if err != nil {
+				return nil, err
+			}
+			if version.changedSince(u.configVersion) {
+				return ModeConfigChanged, nil
+			}
+			logger.Infof("config settings unchanged since last %q hook", hooks.ConfigChanged)
+			u.ranConfigChanged = true
+			u.f.DiscardConfigEvent()
 		}
 		return ModeAbide, nil
 	case RunHook:
@@ -218,10 +234,22 @@ func ModeAbide(u *Uniter) (next Mode, err error) {
 		return nil, err
 	}
 	u.f.WantUpgradeEvent(false)
+	if u.concurrentHooks.enabled, err = readConcurrentRelationHooks(u.charmPath); err != nil {
+		return nil, err
+	}
 	for _, r := range u.relationers {
 		r.StartHooks()
 	}
 	defer func() {
+		// Relation hooks still running must complete before
+		// the uniter moves on.
+		if e := u.concurrentHooks.wait(); e == errHookFailed {
+			if err == nil {
+				next = ModeHookError
+			}
+		} else if e != nil && err == nil {
+			err = e
+		}
 		for _, r := range u.relationers {
 			if e := r.StopHooks(); e != nil && err == nil {
 				err = e
@@ -239,6 +267,11 @@ func ModeAbide(u *Uniter) (next Mode, err error) {
 // modeAbideAliveLoop handles all state changes for ModeAbide when the unit
 // is in an Alive state.
 func modeAbideAliveLoop(u *Uniter) (Mode, error) {
+	if err := u.runDeferredHook(); err == errHookFailed {
+		return ModeHookError, nil
+	} else if err != nil {
+		return nil, err
+	}
 	for {
 		hi := hook.Info{}
 		select {
@@ -251,6 +284,13 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 		case info := <-u.f.ActionEvents():
 			hi = hook.Info{Kind: info.Kind, ActionId: info.ActionId}
 		case hi = <-u.relationHooks:
+		case result := <-u.concurrentHooks.results:
+			if err := u.concurrentHooks.handle(result); err == errHookFailed {
+				return ModeHookError, nil
+			} else if err != nil {
+				return nil, err
+			}
+			continue
 		case ids := <-u.f.RelationsEvents():
 			added, err := u.updateRelations(ids)
 			if err != nil {
@@ -263,7 +303,7 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 		case curl := <-u.f.UpgradeEvents():
 			return ModeUpgrading(curl), nil
 		}
-		if err := u.runHook(hi); err == errHookFailed {
+		if err := u.runAbideHook(hi); err == errHookFailed {
 			return ModeHookError, nil
 		} else if err != nil {
 			return nil, err
@@ -274,6 +314,13 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 // modeAbideDyingLoop handles the proper termination of all relations in
 // response to a Dying unit.
 func modeAbideDyingLoop(u *Uniter) (next Mode, err error) {
+	// Hooks queued by relations while the unit was alive must not
+	// overlap with those of the dying relations.
+	if err := u.concurrentHooks.wait(); err == errHookFailed {
+		return ModeHookError, nil
+	} else if err != nil {
+		return nil, err
+	}
 	if err := u.unit.Refresh(); err != nil {
 		return nil, err
 	}
@@ -287,6 +334,11 @@ func modeAbideDyingLoop(u *Uniter) (next Mode, err error) {
 			delete(u.relationers, id)
 		}
 	}
+	if err := u.runDeferredHook(); err == errHookFailed {
+		return ModeHookError, nil
+	} else if err != nil {
+		return nil, err
+	}
 	for {
 		if len(u.relationers) == 0 {
 			return ModeStopping, nil
@@ -300,8 +352,15 @@ func modeAbideDyingLoop(u *Uniter) (next Mode, err error) {
 		case info := <-u.f.ActionEvents():
 			hi = hook.Info{Kind: info.Kind, ActionId: info.ActionId}
 		case hi = <-u.relationHooks:
+		case result := <-u.concurrentHooks.results:
+			if err := u.concurrentHooks.handle(result); err == errHookFailed {
+				return ModeHookError, nil
+			} else if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		if err = u.runHook(hi); err == errHookFailed {
+		if err = u.runAbideHook(hi); err == errHookFailed {
 			return ModeHookError, nil
 		} else if err != nil {
 			return nil, err
@@ -331,6 +390,7 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 	}
 	u.f.WantResolvedEvent()
 	u.f.WantUpgradeEvent(true)
+	u.scheduleHookRetry()
 	for {
 		hi := hook.Info{}
 		select {
@@ -338,6 +398,15 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 			return nil, tomb.ErrDying
 		case info := <-u.f.ActionEvents():
 			hi = hook.Info{Kind: info.Kind, ActionId: info.ActionId}
+		case <-u.f.RetryEvents():
+			u.hookRetries++
+			if err := u.runHook(*u.s.Hook); err == errHookFailed {
+				return ModeHookError, nil
+			} else if err != nil {
+				return nil, err
+			}
+			u.retryHook = nil
+			return ModeContinue, nil
 		case rm := <-u.f.ResolvedEvents():
 			switch rm {
 			case params.ResolvedRetryHooks:
@@ -355,6 +424,8 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 			} else if err != nil {
 				return nil, err
 			}
+			u.retryHook = nil
+			u.f.WantRetryEvent(-1)
 			return ModeContinue, nil
 		case curl := <-u.f.UpgradeEvents():
 			return ModeUpgrading(curl), nil
@@ -367,6 +438,40 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 	}
 }
 
+// hookRetryDelay holds the time to wait before automatically retrying a
+// failed hook for the first time. The delay doubles with each further
+// retry, up to maxHookRetryDelay.
+const (
+	hookRetryDelay    = 5 * time.Second
+	maxHookRetryDelay = 5 * time.Minute
+)
+
+// scheduleHookRetry asks the filter for a retry event when the failed
+// hook should next be retried automatically, or cancels any pending
+// retry event if the hook should not be retried without the error
+// being resolved.
+func (u *Uniter) scheduleHookRetry() {
+	if u.retryHook == nil || *u.retryHook != *u.s.Hook {
+		failed := *u.s.Hook
+		u.retryHook = &failed
+		u.hookRetries = 0
+	}
+	attempts := u.getHookRetryAttempts()
+	if u.hookRetries >= attempts {
+		u.f.WantRetryEvent(-1)
+		return
+	}
+	delay := hookRetryDelay
+	for i := 0; i < u.hookRetries && delay < maxHookRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxHookRetryDelay {
+		delay = maxHookRetryDelay
+	}
+	logger.Infof("retrying hook %q in %v (retry %d of %d)", u.currentHookName(), delay, u.hookRetries+1, attempts)
+	u.f.WantRetryEvent(delay)
+}
+
 // ModeConflicted is responsible for watching and responding to:
 // * user resolution of charm upgrade conflicts
 // * forced charm upgrade requests
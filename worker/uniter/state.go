@@ -72,6 +72,14 @@ type State struct {
 	// Charm describes the charm being deployed by an Install or Upgrade
 	// operation, and is otherwise blank.
 	CharmURL *charm.URL `yaml:"charm,omitempty"`
+
+	// ConfigCharmURL and ConfigVersion identify the service config
+	// settings seen by the last completed config-changed hook. Each
+	// charm's settings count their generations independently, so
+	// the version is only meaningful with the charm URL. They are
+	// blank if not known.
+	ConfigCharmURL *charm.URL `yaml:"config-charm,omitempty"`
+	ConfigVersion  int64      `yaml:"config-version,omitempty"`
 }
 
 // validate returns an error if the state violates expectations.
@@ -143,14 +151,7 @@ func (f *StateFile) Read() (*State, error) {
 }
 
 // Write stores the supplied state to the file.
-func (f *StateFile) Write(started bool, op Op, step OpStep, hi *uhook.Info, url *charm.URL) error {
-	st := &State{
-		Started:  started,
-		Op:       op,
-		OpStep:   step,
-		Hook:     hi,
-		CharmURL: url,
-	}
+func (f *StateFile) Write(st *State) error {
 	if err := st.validate(); err != nil {
 		panic(err)
 	}
@@ -159,6 +159,15 @@ var stateTests = []struct {
 			OpStep: uniter.Pending,
 			Hook:   relhook,
 		},
+	}, {
+		st: uniter.State{
+			Started:        true,
+			Op:             uniter.Continue,
+			OpStep:         uniter.Pending,
+			Hook:           &hook.Info{Kind: hooks.ConfigChanged},
+			ConfigCharmURL: stcurl,
+			ConfigVersion:  42,
+		},
 	},
 }
 
@@ -170,7 +179,8 @@ func (s *StateFileSuite) TestStates(c *gc.C) {
 		_, err := file.Read()
 		c.Assert(err, gc.Equals, uniter.ErrNoStateFile)
 		write := func() {
-			err := file.Write(t.st.Started, t.st.Op, t.st.OpStep, t.st.Hook, t.st.CharmURL)
+			st := t.st
+			err := file.Write(&st)
 			c.Assert(err, gc.IsNil)
 		}
 		if t.err != "" {
@@ -30,6 +30,7 @@ import (
 	"github.com/juju/juju/state/api/uniter"
 	apiwatcher "github.com/juju/juju/state/api/watcher"
 	"github.com/juju/juju/state/watcher"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/version"
 	"github.com/juju/juju/worker"
 	"github.com/juju/juju/worker/uniter/charm"
@@ -62,6 +63,7 @@ type UniterExecutionObserver interface {
 type Uniter struct {
 	tomb          tomb.Tomb
 	st            *uniter.State
+	clock         clock.Clock
 	f             *filter
 	unit          *uniter.Unit
 	service       *uniter.Service
@@ -70,7 +72,12 @@ type Uniter struct {
 	uuid          string
 	envName       string
 
+	// concurrentHooks runs the hooks of different relations
+	// concurrently, if the charm allows it.
+	concurrentHooks *concurrentRelationHooks
+
 	dataDir      string
+	caCert       string
 	baseDir      string
 	toolsDir     string
 	relationsDir string
@@ -85,7 +92,24 @@ type Uniter struct {
 	proxy      proxyutils.Settings
 	proxyMutex sync.Mutex
 
+	// hookRetryAttempts holds the number of times a failed hook is
+	// retried automatically, as set in the environment configuration.
+	hookRetryAttempts int
+	hookRetryMutex    sync.Mutex
+
+	// retryHook holds the failed hook being retried automatically,
+	// and hookRetries the number of retries already made.
+	retryHook   *hook.Info
+	hookRetries int
+
 	ranConfigChanged bool
+
+	// configVersion identifies the service config settings seen by
+	// the last completed config-changed hook, and hookConfigVersion
+	// those seen by the running one.
+	configVersion     configVersion
+	hookConfigVersion configVersion
+
 	// The execution observer is only used in tests at this stage. Should this
 	// need to be extended, perhaps a list of observers would be needed.
 	observer UniterExecutionObserver
@@ -93,11 +117,16 @@ type Uniter struct {
 
 // NewUniter creates a new Uniter which will install, run, and upgrade
 // a charm on behalf of the unit with the given unitTag, by executing
-// hooks and operations provoked by changes in st.
-func NewUniter(st *uniter.State, unitTag string, dataDir string, hookLock *fslock.Lock) *Uniter {
+// hooks and operations provoked by changes in st. Charms served by
+// an API server are downloaded only if its certificate is signed by
+// caCert. Delays, such as those before failed hooks are retried, are
+// measured with the given clock.
+func NewUniter(st *uniter.State, unitTag string, dataDir, caCert string, hookLock *fslock.Lock, clock clock.Clock) *Uniter {
 	u := &Uniter{
 		st:       st,
+		clock:    clock,
 		dataDir:  dataDir,
+		caCert:   caCert,
 		hookLock: hookLock,
 	}
 	go func() {
@@ -117,6 +146,13 @@ func (u *Uniter) loop(unitTag string) (err error) {
 	defer u.runListener.Close()
 	logger.Infof("unit %q started", u.unit)
 
+	// Read the hook retry policy before any hook can fail; later
+	// changes are picked up along with the proxy settings.
+	environConfig, err := u.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	u.updateHookRetryAttempts(environConfig)
 	environWatcher, err := u.st.WatchForEnvironConfigChanges()
 	if err != nil {
 		return err
@@ -125,7 +161,7 @@ func (u *Uniter) loop(unitTag string) (err error) {
 	u.watchForProxyChanges(environWatcher)
 
 	// Start filtering state change events for consumption by modes.
-	u.f, err = newFilter(u.st, unitTag)
+	u.f, err = newFilter(u.st, unitTag, u.clock)
 	if err != nil {
 		return err
 	}
@@ -197,7 +233,7 @@ func (u *Uniter) init(unitTag string) (err error) {
 		return err
 	}
 	u.toolsDir = tools.ToolsDir(u.dataDir, unitTag)
-	if err := EnsureJujucSymlinks(u.toolsDir); err != nil {
+	if err := jujuc.EnsureSymlinks(u.toolsDir); err != nil {
 		return err
 	}
 	u.baseDir = filepath.Join(u.dataDir, "agents", unitTag)
@@ -223,9 +259,12 @@ func (u *Uniter) init(unitTag string) (err error) {
 
 	u.relationers = map[int]*Relationer{}
 	u.relationHooks = make(chan hook.Info)
+	u.concurrentHooks = newConcurrentRelationHooks(u)
 	u.charmPath = filepath.Join(u.baseDir, "charm")
 	deployerPath := filepath.Join(u.baseDir, "state", "deployer")
-	bundles := charm.NewBundlesDir(filepath.Join(u.baseDir, "state", "bundles"))
+	// Charm archives are cached for all the units on the machine.
+	cache := charm.NewArchiveCache(filepath.Join(u.dataDir, "charmcache"), charm.DefaultMaxCachedArchives)
+	bundles := charm.NewBundlesDir(filepath.Join(u.baseDir, "state", "bundles"), cache, u.caCert)
 	u.deployer, err = charm.NewDeployer(u.charmPath, deployerPath, bundles)
 	if err != nil {
 		return fmt.Errorf("cannot create deployer: %v", err)
@@ -265,17 +304,51 @@ func (u *Uniter) Dead() <-chan struct{} {
 	return u.tomb.Dead()
 }
 
+// configVersion identifies a generation of the service config
+// settings. Each charm has its own settings, whose generations are
+// counted independently, so the version is only meaningful together
+// with the charm URL.
+type configVersion struct {
+	charmURL *corecharm.URL
+	version  int64
+}
+
+// changedSince reports whether v differs from the given generation.
+// An unknown generation is always considered changed.
+func (v configVersion) changedSince(other configVersion) bool {
+	if v.version == 0 || v.charmURL == nil || other.charmURL == nil {
+		return true
+	}
+	return v.version != other.version || v.charmURL.String() != other.charmURL.String()
+}
+
+// currentConfigVersion returns the generation of the config settings
+// of the unit's current charm.
+func (u *Uniter) currentConfigVersion() (configVersion, error) {
+	curl, err := u.unit.CharmURL()
+	if err != nil {
+		return configVersion{}, err
+	}
+	version, err := u.unit.ConfigSettingsVersion()
+	if err != nil {
+		return configVersion{}, err
+	}
+	return configVersion{charmURL: curl, version: version}, nil
+}
+
 // writeState saves uniter state with the supplied values, and infers the appropriate
 // value of Started.
 func (u *Uniter) writeState(op Op, step OpStep, hi *hook.Info, url *corecharm.URL) error {
 	s := State{
-		Started:  op == RunHook && hi.Kind == hooks.Start || u.s != nil && u.s.Started,
-		Op:       op,
-		OpStep:   step,
-		Hook:     hi,
-		CharmURL: url,
-	}
-	if err := u.sf.Write(s.Started, s.Op, s.OpStep, s.Hook, s.CharmURL); err != nil {
+		Started:        op == RunHook && hi.Kind == hooks.Start || u.s != nil && u.s.Started,
+		Op:             op,
+		OpStep:         step,
+		Hook:           hi,
+		CharmURL:       url,
+		ConfigCharmURL: u.configVersion.charmURL,
+		ConfigVersion:  u.configVersion.version,
+	}
+	if err := u.sf.Write(&s); err != nil {
 		return err
 	}
 	u.s = &s
@@ -394,7 +467,9 @@ func (u *Uniter) acquireHookLock(message string) (err error) {
 	return nil
 }
 
-func (u *Uniter) startJujucServer(context *HookContext) (*jujuc.Server, string, error) {
+// startJujucServer starts a server for the hook tools run in the given
+// context, listening on the socket with the given name.
+func (u *Uniter) startJujucServer(context *HookContext, socketName string) (*jujuc.Server, string, error) {
 	// Prepare server.
 	getCmd := func(ctxId, cmdName string) (cmd.Command, error) {
 		// TODO: switch to long-running server with single context;
@@ -404,7 +479,7 @@ func (u *Uniter) startJujucServer(context *HookContext) (*jujuc.Server, string,
 		}
 		return jujuc.NewCommand(context, cmdName)
 	}
-	socketPath := u.sockPath("agent.socket", "@")
+	socketPath := u.sockPath(socketName, "@")
 	srv, err := jujuc.NewServer(getCmd, socketPath)
 	if err != nil {
 		return nil, "", err
@@ -427,7 +502,7 @@ func (u *Uniter) RunCommands(commands string) (results *exec.ExecResponse, err e
 	if err != nil {
 		return nil, err
 	}
-	srv, socketPath, err := u.startJujucServer(hctx)
+	srv, socketPath, err := u.startJujucServer(hctx, "agent.socket")
 	if err != nil {
 		return nil, err
 	}
@@ -492,6 +567,11 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 
 	hookName := string(hi.Kind)
 	actionParams := map[string]interface{}(nil)
+	if hi.Kind == hooks.ConfigChanged {
+		if u.hookConfigVersion, err = u.currentConfigVersion(); err != nil {
+			return err
+		}
+	}
 
 	// This value is needed to pass results of Action param validation
 	// in case of error or invalidation.  This is probably bad form; it
@@ -527,7 +607,7 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 		return err
 	}
 
-	srv, socketPath, err := u.startJujucServer(hctx)
+	srv, socketPath, err := u.startJujucServer(hctx, "agent.socket")
 	if err != nil {
 		return err
 	}
@@ -590,6 +670,8 @@ func (u *Uniter) commitHook(hi hook.Info) error {
 	}
 	if hi.Kind == hooks.ConfigChanged {
 		u.ranConfigChanged = true
+		u.configVersion = u.hookConfigVersion
+		u.hookConfigVersion = configVersion{}
 	}
 	if err := u.writeState(Continue, Pending, &hi, nil); err != nil {
 		return err
@@ -628,7 +710,7 @@ func (u *Uniter) getJoinedRelations() (map[int]*uniter.Relation, error) {
 			select {
 			case <-u.tomb.Dying():
 				return nil, tomb.ErrDying
-			case <-time.After(15 * time.Second):
+			case <-u.clock.After(15 * time.Second):
 				continue
 			}
 		}
@@ -824,8 +906,24 @@ func (u *Uniter) updatePackageProxy(cfg *config.Config) {
 	}
 }
 
+// updateHookRetryAttempts updates the number of times a failed hook
+// is retried from the environment.
+func (u *Uniter) updateHookRetryAttempts(cfg *config.Config) {
+	u.hookRetryMutex.Lock()
+	defer u.hookRetryMutex.Unlock()
+	u.hookRetryAttempts = cfg.HookRetryAttempts()
+}
+
+// getHookRetryAttempts returns the number of times a failed hook
+// is retried automatically.
+func (u *Uniter) getHookRetryAttempts() int {
+	u.hookRetryMutex.Lock()
+	defer u.hookRetryMutex.Unlock()
+	return u.hookRetryAttempts
+}
+
 // watchForProxyChanges kicks off a go routine to listen to the watcher and
-// update the proxy settings.
+// update the proxy settings and the number of hook retries.
 func (u *Uniter) watchForProxyChanges(environWatcher apiwatcher.NotifyWatcher) {
 	go func() {
 		for {
@@ -842,6 +940,7 @@ func (u *Uniter) watchForProxyChanges(environWatcher apiwatcher.NotifyWatcher) {
 					logger.Errorf("cannot load environment configuration: %v", err)
 				} else {
 					u.updatePackageProxy(environConfig)
+					u.updateHookRetryAttempts(environConfig)
 				}
 			}
 		}
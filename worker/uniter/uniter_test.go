@@ -161,6 +161,7 @@ type context struct {
 	relation      *state.Relation
 	relationUnits map[string]*state.RelationUnit
 	subordinate   *state.Unit
+	clock         *coretesting.Clock
 
 	mu             sync.Mutex
 	hooksCompleted []string
@@ -467,6 +468,53 @@ func (s *UniterSuite) TestUniterMultipleErrors(c *gc.C) {
 	s.runUniterTests(c, multipleErrorsTests)
 }
 
+var hookRetryTests = []uniterTest{
+	ut(
+		"failed hook is retried automatically until attempts run out",
+		setHookRetryAttempts(2),
+		createCharm{badHooks: []string{"start"}},
+		serveCharm{},
+		createUniter{},
+		waitHooks{"install", "config-changed", "fail-start"},
+		// The delay before each retry doubles.
+		advanceClock(5*time.Second),
+		waitHooks{"fail-start"},
+		advanceClock(10*time.Second),
+		waitHooks{"fail-start"},
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook failed: "start"`,
+			data: params.StatusData{
+				"hook": "start",
+			},
+		},
+		waitHooks{},
+
+		fixHook{"start"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"start"},
+		verifyRunning{},
+	), ut(
+		"failed hook is not retried automatically by default",
+		createCharm{badHooks: []string{"start"}},
+		serveCharm{},
+		createUniter{},
+		waitHooks{"install", "config-changed", "fail-start"},
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook failed: "start"`,
+		},
+		waitHooks{},
+	),
+}
+
+func (s *UniterSuite) TestUniterHookRetry(c *gc.C) {
+	s.runUniterTests(c, hookRetryTests)
+}
+
 var configChangedHookTests = []uniterTest{
 	ut(
 		"config-changed hook fail and resolve",
@@ -532,6 +580,35 @@ var configChangedHookTests = []uniterTest{
 		assertYaml{"charm/config.out", map[string]interface{}{
 			"blog-title": "Goodness Gracious Me",
 		}},
+	), ut(
+		"config-changed hook runs on restart only if config changed",
+		quickStart{},
+		stopUniter{},
+		startUniter{},
+		waitHooks{},
+		stopUniter{},
+		changeConfig{"blog-title": "Goodness Gracious Me"},
+		startUniter{},
+		waitHooks{"config-changed"},
+	), ut(
+		"config-changed hook runs on restart if the charm changed",
+		quickStart{},
+		stopUniter{},
+		custom{func(c *gc.C, ctx *context) {
+			// Pretend the recorded config version belongs to
+			// another charm's settings, which start counting
+			// their versions afresh.
+			path := filepath.Join(ctx.path, "state", "uniter")
+			var st uniter.State
+			err := utils.ReadYaml(path, &st)
+			c.Assert(err, gc.IsNil)
+			c.Assert(st.ConfigCharmURL, gc.NotNil)
+			st.ConfigCharmURL = corecharm.MustParseURL("cs:quantal/wordpress-99")
+			err = uniter.NewStateFile(path).Write(&st)
+			c.Assert(err, gc.IsNil)
+		}},
+		startUniter{},
+		waitHooks{"config-changed"},
 	)}
 
 func (s *UniterSuite) TestUniterConfigChangedHook(c *gc.C) {
@@ -906,7 +983,7 @@ func (s *UniterSuite) TestUniterDeployerConversion(c *gc.C) {
 				stopUniter{},
 			}},
 			startUniter{},
-			waitHooks{"config-changed"},
+			waitHooks{},
 
 			// At this point, the deployer has been converted, but the
 			// charm directory itself hasn't; the *next* deployment will
@@ -1189,6 +1266,9 @@ var relationsTests = []uniterTest{
 		custom{func(c *gc.C, ctx *context) {
 			ft.Dir{"state/relations/90210", 0755}.Create(c, ctx.path)
 		}},
+		// Change the config, so we can tell when the restarted
+		// uniter has settled.
+		changeConfig{"blog-title": "Goodness Gracious Me"},
 		startUniter{},
 		waitHooks{"config-changed"},
 		custom{func(c *gc.C, ctx *context) {
@@ -1220,6 +1300,8 @@ var relationsTests = []uniterTest{
 			// they shouldn't been available until after the start hook.
 			ft.File{"charm/relations.out", "", 0644}.Check(c, ctx.path)
 		}},
+		// config-changed only runs on bounce if the config has changed.
+		changeConfig{"blog-title": "Goodness Gracious Me"},
 		startUniter{},
 		waitHooks{"config-changed"},
 		custom{func(c *gc.C, ctx *context) {
@@ -1298,6 +1380,90 @@ func (s *UniterSuite) TestUniterRelationErrors(c *gc.C) {
 	s.runUniterTests(c, relationsErrorTests)
 }
 
+var concurrentRelationHooksTests = []uniterTest{
+	ut(
+		"relation hooks run in order",
+		createCharm{customize: declareConcurrentRelationHooks},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		addRelation{},
+		addRelationUnit{},
+		waitHooks{"db-relation-joined mysql/0 db:0", "db-relation-changed mysql/0 db:0"},
+		changeRelationUnit{"mysql/0"},
+		waitHooks{"db-relation-changed mysql/0 db:0"},
+		changeConfig{"blog-title": "Goodness Gracious Me"},
+		waitHooks{"config-changed"},
+		removeRelationUnit{"mysql/0"},
+		waitHooks{"db-relation-departed mysql/0 db:0"},
+		verifyRunning{},
+	), ut(
+		"relation hook fail and retry",
+		createCharm{
+			badHooks:  []string{"db-relation-changed"},
+			customize: declareConcurrentRelationHooks,
+		},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		addRelation{},
+		addRelationUnit{},
+		waitUnit{
+			status: params.StatusError,
+			info:   `hook failed: "db-relation-changed"`,
+			data: params.StatusData{
+				"hook":        "db-relation-changed",
+				"relation-id": 0,
+				"remote-unit": "mysql/0",
+			},
+		},
+		waitHooks{"db-relation-joined mysql/0 db:0", "fail-db-relation-changed mysql/0 db:0"},
+
+		fixHook{"db-relation-changed"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"db-relation-changed mysql/0 db:0"},
+		verifyRunning{},
+	), ut(
+		"unit becomes dying while in a relation",
+		createCharm{customize: declareConcurrentRelationHooks},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+		addRelation{},
+		addRelationUnit{},
+		waitHooks{"db-relation-joined mysql/0 db:0", "db-relation-changed mysql/0 db:0"},
+		unitDying,
+		waitHooks{"db-relation-departed mysql/0 db:0", "db-relation-broken db:0", "stop"},
+		waitUniterDead{},
+	),
+}
+
+func (s *UniterSuite) TestUniterConcurrentRelationHooks(c *gc.C) {
+	s.runUniterTests(c, concurrentRelationHooksTests)
+}
+
+// declareConcurrentRelationHooks customizes a charm so that it allows
+// the hooks of different relations to run concurrently.
+func declareConcurrentRelationHooks(c *gc.C, ctx *context, path string) {
+	f, err := os.OpenFile(filepath.Join(path, "metadata.yaml"), os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	_, err = f.Write([]byte("concurrent-relation-hooks: true\n"))
+	c.Assert(err, gc.IsNil)
+}
+
 var actionEventTests = []uniterTest{
 	// Relations.
 	ut(
@@ -1437,7 +1603,7 @@ var subordinatesTests = []uniterTest{
 		unitDying,
 		waitSubordinateDying{},
 		waitHooks{"stop"},
-		verifyRunning{true},
+		verifyRunning{},
 		removeSubordinate{},
 		waitUniterDead{},
 	), ut(
@@ -1473,6 +1639,7 @@ func (s *UniterSuite) runUniterTests(c *gc.C, uniterTests []uniterTest) {
 				path:    s.unitDir,
 				dataDir: s.dataDir,
 				charms:  gitjujutesting.ResponseMap{},
+				clock:   coretesting.NewClock(time.Now()),
 			}
 			ctx.run(c, t.steps)
 		}()
@@ -1509,6 +1676,7 @@ func (s *UniterSuite) TestSubordinateDying(c *gc.C) {
 		path:    filepath.Join(s.dataDir, "agents", "unit-u-0"),
 		dataDir: s.dataDir,
 		charms:  gitjujutesting.ResponseMap{},
+		clock:   coretesting.NewClock(time.Now()),
 	}
 
 	testing.AddStateServerMachine(c, ctx.st)
@@ -1706,7 +1874,7 @@ func (s startUniter) step(c *gc.C, ctx *context) {
 	locksDir := filepath.Join(ctx.dataDir, "locks")
 	lock, err := fslock.NewLock(locksDir, "uniter-hook-execution")
 	c.Assert(err, gc.IsNil)
-	ctx.uniter = uniter.NewUniter(ctx.s.uniter, s.unitTag, ctx.dataDir, lock)
+	ctx.uniter = uniter.NewUniter(ctx.s.uniter, s.unitTag, ctx.dataDir, coretesting.CACert, lock, ctx.clock)
 	uniter.SetUniterObserver(ctx.uniter, ctx)
 }
 
@@ -1785,18 +1953,14 @@ func (s verifyWaiting) step(c *gc.C, ctx *context) {
 	step(c, ctx, waitHooks{})
 }
 
-type verifyRunning struct {
-	noHooks bool
-}
+type verifyRunning struct{}
 
 func (s verifyRunning) step(c *gc.C, ctx *context) {
 	step(c, ctx, stopUniter{})
 	step(c, ctx, startUniter{})
-	if s.noHooks {
-		step(c, ctx, waitHooks{})
-	} else {
-		step(c, ctx, waitHooks{"config-changed"})
-	}
+	// The config settings have not changed since config-changed
+	// last ran, so it does not run again.
+	step(c, ctx, waitHooks{})
 }
 
 type startupError struct {
@@ -2446,6 +2610,23 @@ var verifyHookSyncLockLocked = custom{func(c *gc.C, ctx *context) {
 	c.Assert(lock.IsLocked(), jc.IsTrue)
 }}
 
+// advanceClock waits until the uniter is waiting on its clock, and
+// then advances the clock by the given duration.
+type advanceClock time.Duration
+
+func (s advanceClock) step(c *gc.C, ctx *context) {
+	err := ctx.clock.WaitAdvance(time.Duration(s), coretesting.LongWait, 1)
+	c.Assert(err, gc.IsNil)
+}
+
+type setHookRetryAttempts int
+
+func (s setHookRetryAttempts) step(c *gc.C, ctx *context) {
+	attrs := map[string]interface{}{"hook-retry-attempts": int(s)}
+	err := ctx.st.UpdateEnvironConfig(attrs, nil, nil)
+	c.Assert(err, gc.IsNil)
+}
+
 type setProxySettings proxy.Settings
 
 func (s setProxySettings) step(c *gc.C, ctx *context) {
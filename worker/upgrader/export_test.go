@@ -4,16 +4,15 @@
 package upgrader
 
 import (
-	"github.com/juju/utils"
-
+	"github.com/juju/juju/downloader"
 	"github.com/juju/juju/tools"
 )
 
 var (
-	RetryAfter           = &retryAfter
+	RetryDelay           = retryDelay
 	AllowedTargetVersion = allowedTargetVersion
 )
 
-func EnsureTools(u *Upgrader, agentTools *tools.Tools, hostnameVerification utils.SSLHostnameVerification) error {
-	return u.ensureTools(agentTools, hostnameVerification)
+func EnsureTools(u *Upgrader, agentTools *tools.Tools, verification downloader.Verification) error {
+	return u.ensureTools(agentTools, verification)
 }
@@ -10,22 +10,23 @@ import (
 
 	"github.com/juju/loggo"
 	"github.com/juju/names"
-	"github.com/juju/utils"
 	"launchpad.net/tomb"
 
 	"github.com/juju/juju/agent"
 	agenttools "github.com/juju/juju/agent/tools"
+	"github.com/juju/juju/downloader"
+	"github.com/juju/juju/state/api/params"
 	"github.com/juju/juju/state/api/upgrader"
+	apiwatcher "github.com/juju/juju/state/api/watcher"
 	"github.com/juju/juju/state/watcher"
 	coretools "github.com/juju/juju/tools"
+	"github.com/juju/juju/utils/clock"
 	"github.com/juju/juju/version"
 )
 
-// retryAfter returns a channel that receives a value
-// when a failed download should be retried.
-var retryAfter = func() <-chan time.Time {
-	return time.After(5 * time.Second)
-}
+// retryDelay holds how long to wait before retrying
+// a failed download.
+const retryDelay = 5 * time.Second
 
 var logger = loggo.GetLogger("juju.worker.upgrader")
 
@@ -36,6 +37,8 @@ type Upgrader struct {
 	st      *upgrader.State
 	dataDir string
 	tag     names.Tag
+	caCert  string
+	clock   clock.Clock
 }
 
 // NewUpgrader returns a new upgrader worker. It watches changes to the
@@ -43,12 +46,15 @@ type Upgrader struct {
 // download the tools for any new version into the given data directory.  If
 // an upgrade is needed, the worker will exit with an UpgradeReadyError
 // holding details of the requested upgrade. The tools will have been
-// downloaded and unpacked.
-func NewUpgrader(st *upgrader.State, agentConfig agent.Config) *Upgrader {
+// downloaded and unpacked. Failed downloads are retried after a delay
+// measured by the given clock.
+func NewUpgrader(st *upgrader.State, agentConfig agent.Config, clock clock.Clock) *Upgrader {
 	u := &Upgrader{
 		st:      st,
 		dataDir: agentConfig.DataDir(),
 		tag:     agentConfig.Tag(),
+		caCert:  agentConfig.CACert(),
+		clock:   clock,
 	}
 	go func() {
 		defer u.tomb.Done()
@@ -98,16 +104,27 @@ func (u *Upgrader) loop() error {
 	}
 	changes := versionWatcher.Changes()
 	defer watcher.Stop(versionWatcher, &u.tomb)
+	stagedWatcher, err := u.watchStagedUpgrade()
+	if err != nil {
+		return err
+	}
+	if stagedWatcher != nil {
+		defer watcher.Stop(stagedWatcher, &u.tomb)
+	}
 	var retry <-chan time.Time
 	// We don't read on the dying channel until we have received the
 	// initial event from the API version watcher, thus ensuring
 	// that we attempt an upgrade even if other workers are dying
-	// all around us.
+	// all around us. Staged upgrades are only considered once we
+	// know that no upgrade is needed immediately.
 	var (
-		dying                <-chan struct{}
-		wantTools            *coretools.Tools
-		wantVersion          version.Number
-		hostnameVerification utils.SSLHostnameVerification
+		dying              <-chan struct{}
+		stagedChanges      <-chan struct{}
+		wantTools          *coretools.Tools
+		wantVersion        version.Number
+		verification       downloader.Verification
+		stagedTools        *coretools.Tools
+		stagedVerification downloader.Verification
 	)
 	for {
 		select {
@@ -121,11 +138,30 @@ func (u *Upgrader) loop() error {
 			}
 			logger.Infof("desired tool version: %v", wantVersion)
 			dying = u.tomb.Dying()
+			if stagedWatcher != nil {
+				stagedChanges = stagedWatcher.Changes()
+			}
+		case _, ok := <-stagedChanges:
+			if !ok {
+				return watcher.MustErr(stagedWatcher)
+			}
+			stagedTools, stagedVerification, err = u.stagedTools()
+			if err != nil {
+				return err
+			}
 		case <-retry:
 		case <-dying:
 			return nil
 		}
 		if wantVersion == currentTools.Version.Number {
+			if stagedTools != nil {
+				if err := u.stageTools(stagedTools, stagedVerification); err != nil {
+					logger.Errorf("failed to stage tools from %q: %v", stagedTools.URL, err)
+					retry = u.clock.After(retryDelay)
+				} else {
+					stagedTools = nil
+				}
+			}
 			continue
 		} else if !allowedTargetVersion(version.Current.Number, wantVersion) {
 			// See also bug #1299802 where when upgrading from
@@ -141,7 +177,7 @@ func (u *Upgrader) loop() error {
 		// TODO(dimitern) 2013-10-03 bug #1234715
 		// Add a testing HTTPS storage to verify the
 		// disableSSLHostnameVerification behavior here.
-		wantTools, hostnameVerification, err = u.st.Tools(u.tag.String())
+		wantTools, verification, err = u.st.Tools(u.tag.String())
 		if err != nil {
 			// Not being able to lookup Tools is considered fatal
 			return err
@@ -151,7 +187,7 @@ func (u *Upgrader) loop() error {
 		// repeatedly (causing the agent to be stopped), as long
 		// as we have got as far as this, we will still be able to
 		// upgrade the agent.
-		err := u.ensureTools(wantTools, hostnameVerification)
+		err := u.ensureTools(wantTools, verification)
 		if err == nil {
 			return &UpgradeReadyError{
 				OldTools:  version.Current,
@@ -161,17 +197,67 @@ func (u *Upgrader) loop() error {
 			}
 		}
 		logger.Errorf("failed to fetch tools from %q: %v", wantTools.URL, err)
-		retry = retryAfter()
+		retry = u.clock.After(retryDelay)
 	}
 }
 
-func (u *Upgrader) ensureTools(agentTools *coretools.Tools, hostnameVerification utils.SSLHostnameVerification) error {
+// watchStagedUpgrade returns a watcher that notifies when an upgrade
+// is staged, or nil if the agent does not download staged tools. Only
+// machine agents do so, since unit agents use the tools downloaded by
+// their machine's agent; nor do agents connected to an API server that
+// does not support staged upgrades.
+func (u *Upgrader) watchStagedUpgrade() (apiwatcher.NotifyWatcher, error) {
+	if _, ok := u.tag.(names.MachineTag); !ok {
+		return nil, nil
+	}
+	w, err := u.st.WatchStagedUpgrade(u.tag.String())
+	if params.IsCodeNotImplemented(err) {
+		logger.Infof("API server does not support staged upgrades")
+		return nil, nil
+	}
+	return w, err
+}
+
+// stagedTools returns the tools of the staged upgrade that need to be
+// downloaded, or nil if there are none.
+func (u *Upgrader) stagedTools() (*coretools.Tools, downloader.Verification, error) {
+	stagedTools, verification, err := u.st.StagedTools(u.tag.String())
+	if params.IsCodeNotFound(err) {
+		return nil, downloader.Verification{}, nil
+	} else if err != nil {
+		return nil, downloader.Verification{}, err
+	}
+	if stagedTools.Version == version.Current {
+		return nil, downloader.Verification{}, nil
+	}
+	logger.Infof("upgrade to %v staged", stagedTools.Version)
+	return stagedTools, verification, nil
+}
+
+// stageTools downloads and verifies the given staged tools, without
+// switching to them, and reports them ready.
+func (u *Upgrader) stageTools(stagedTools *coretools.Tools, verification downloader.Verification) error {
+	if err := u.ensureTools(stagedTools, verification); err != nil {
+		return err
+	}
+	err := u.st.SetStagedToolsReady(u.tag.String(), stagedTools.Version)
+	if params.IsCodeNotFound(err) {
+		// The upgrade is no longer staged; that's fine.
+		return nil
+	}
+	return err
+}
+
+func (u *Upgrader) ensureTools(agentTools *coretools.Tools, verification downloader.Verification) error {
 	if _, err := agenttools.ReadTools(u.dataDir, agentTools.Version); err == nil {
 		// Tools have already been downloaded
 		return nil
 	}
 	logger.Infof("fetching tools from %q", agentTools.URL)
-	client := utils.GetHTTPClient(hostnameVerification)
+	client, err := verification.HTTPClient(u.caCert)
+	if err != nil {
+		return err
+	}
 	resp, err := client.Get(agentTools.URL)
 	if err != nil {
 		return err
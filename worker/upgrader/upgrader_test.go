@@ -5,6 +5,8 @@ package upgrader_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	stdtesting "testing"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/juju/juju/agent"
 	agenttools "github.com/juju/juju/agent/tools"
+	"github.com/juju/juju/downloader"
 	envtesting "github.com/juju/juju/environs/testing"
 	envtools "github.com/juju/juju/environs/tools"
 	jujutesting "github.com/juju/juju/juju/testing"
@@ -39,9 +42,9 @@ func TestPackage(t *stdtesting.T) {
 type UpgraderSuite struct {
 	jujutesting.JujuConnSuite
 
-	machine       *state.Machine
-	state         *api.State
-	oldRetryAfter func() <-chan time.Time
+	machine *state.Machine
+	state   *api.State
+	clock   *coretesting.Clock
 }
 
 type AllowedTargetVersionSuite struct{}
@@ -54,12 +57,7 @@ func (s *UpgraderSuite) SetUpTest(c *gc.C) {
 	// s.machine needs to have IsManager() so that it can get the actual
 	// current revision to upgrade to.
 	s.state, s.machine = s.OpenAPIAsNewMachine(c, state.JobManageEnviron)
-	// Capture the value of RetryAfter, and use that captured
-	// value in the cleanup lambda.
-	oldRetryAfter := *upgrader.RetryAfter
-	s.AddCleanup(func(*gc.C) {
-		*upgrader.RetryAfter = oldRetryAfter
-	})
+	s.clock = coretesting.NewClock(time.Now())
 }
 
 type mockConfig struct {
@@ -76,13 +74,17 @@ func (mock *mockConfig) DataDir() string {
 	return mock.datadir
 }
 
+func (mock *mockConfig) CACert() string {
+	return coretesting.CACert
+}
+
 func agentConfig(tag names.Tag, datadir string) agent.Config {
 	return &mockConfig{tag: tag, datadir: datadir}
 }
 
 func (s *UpgraderSuite) makeUpgrader() *upgrader.Upgrader {
 	config := agentConfig(s.machine.Tag(), s.DataDir())
-	return upgrader.NewUpgrader(s.state.Upgrader(), config)
+	return upgrader.NewUpgrader(s.state.Upgrader(), config, s.clock)
 }
 
 func (s *UpgraderSuite) TestUpgraderSetsTools(c *gc.C) {
@@ -160,21 +162,13 @@ func (s *UpgraderSuite) TestUpgraderRetryAndChanged(c *gc.C) {
 	err := statetesting.SetAgentVersion(s.State, newTools.Version.Number)
 	c.Assert(err, gc.IsNil)
 
-	retryc := make(chan time.Time)
-	*upgrader.RetryAfter = func() <-chan time.Time {
-		c.Logf("replacement retry after")
-		return retryc
-	}
 	dummy.Poison(s.Environ.Storage(), envtools.StorageName(newTools.Version), fmt.Errorf("a non-fatal dose"))
 	u := s.makeUpgrader()
 	defer u.Stop()
 
 	for i := 0; i < 3; i++ {
-		select {
-		case retryc <- time.Now():
-		case <-time.After(coretesting.LongWait):
-			c.Fatalf("upgrader did not retry (attempt %d)", i)
-		}
+		err := s.clock.WaitAdvance(upgrader.RetryDelay, coretesting.LongWait, 1)
+		c.Assert(err, gc.IsNil, gc.Commentf("upgrader did not retry (attempt %d)", i))
 	}
 
 	// Make it upgrade to some newer tools that can be
@@ -204,6 +198,42 @@ func (s *UpgraderSuite) TestUpgraderRetryAndChanged(c *gc.C) {
 	}
 }
 
+func (s *UpgraderSuite) TestUpgraderDownloadsStagedTools(c *gc.C) {
+	stor := s.Environ.Storage()
+	oldTools := envtesting.PrimeTools(c, stor, s.DataDir(), version.MustParseBinary("5.4.3-precise-amd64"))
+	s.PatchValue(&version.Current, oldTools.Version)
+	err := statetesting.SetAgentVersion(s.State, oldTools.Version.Number)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetAgentVersion(oldTools.Version)
+	c.Assert(err, gc.IsNil)
+	stagedTools := envtesting.AssertUploadFakeToolsVersions(
+		c, stor, version.MustParseBinary("5.4.5-precise-amd64"))[0]
+	err = s.State.StageAgentVersion(stagedTools.Version.Number)
+	c.Assert(err, gc.IsNil)
+
+	u := s.makeUpgrader()
+	defer u.Stop()
+
+	// The upgrader downloads the staged tools and reports them
+	// ready, but does not upgrade.
+	var ready []string
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		s.BackingState.StartSync()
+		staged, err := s.State.StagedUpgrade()
+		c.Assert(err, gc.IsNil)
+		ready = staged.ReadyAgents()
+		if len(ready) > 0 {
+			break
+		}
+	}
+	c.Assert(ready, gc.DeepEquals, []string{s.machine.Tag().String()})
+	foundTools, err := agenttools.ReadTools(s.DataDir(), stagedTools.Version)
+	c.Assert(err, gc.IsNil)
+	envtesting.CheckTools(c, foundTools, stagedTools)
+	err = u.Stop()
+	c.Assert(err, gc.IsNil)
+}
+
 func (s *UpgraderSuite) TestChangeAgentTools(c *gc.C) {
 	oldTools := &coretools.Tools{
 		Version: version.MustParseBinary("1.2.3-quantal-amd64"),
@@ -237,10 +267,28 @@ func (s *UpgraderSuite) TestEnsureToolsChecksBeforeDownloading(c *gc.C) {
 	// it doesn't actually do an HTTP request
 	u := s.makeUpgrader()
 	newTools.URL = "http://0.1.2.3/invalid/path/tools.tgz"
-	err := upgrader.EnsureTools(u, newTools, utils.VerifySSLHostnames)
+	err := upgrader.EnsureTools(u, newTools, downloader.Verification{HostnameVerification: utils.VerifySSLHostnames})
 	c.Assert(err, gc.IsNil)
 }
 
+func (s *UpgraderSuite) TestEnsureToolsFromAPIServerVerifiesCA(c *gc.C) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	newTools := &coretools.Tools{
+		Version: version.MustParseBinary("5.4.3-precise-amd64"),
+		URL:     server.URL + "/tools.tgz",
+	}
+	// The server's certificate is not signed by the environment's
+	// CA, so the download fails even though hostname verification
+	// is disabled for older agents.
+	u := s.makeUpgrader()
+	err := upgrader.EnsureTools(u, newTools, downloader.Verification{
+		HostnameVerification: utils.NoVerifySSLHostnames,
+		FromAPIServer:        true,
+	})
+	c.Assert(err, gc.ErrorMatches, ".*certificate signed by unknown authority.*")
+}
+
 func (s *UpgraderSuite) TestUpgraderRefusesToDowngradeMinorVersions(c *gc.C) {
 	stor := s.Environ.Storage()
 	origTools := envtesting.PrimeTools(c, stor, s.DataDir(), version.MustParseBinary("5.4.3-precise-amd64"))